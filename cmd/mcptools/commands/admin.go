@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/f/mcptools/pkg/admin"
+	"github.com/spf13/cobra"
+)
+
+// AdminCmd creates the admin command.
+func AdminCmd() *cobra.Command {
+	var socket string
+
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Adjust the policy of a running guard or bridge",
+		Long: `Adjust the policy of a running guard or bridge started with --admin, without restarting it.
+
+Overrides allow or deny the tools, prompts or resources matching a pattern, take precedence over
+the process's own rules and expire after --ttl. Every change is written to the process's log.
+
+Examples:
+  # Allow a tool the guard denies, for ten minutes
+  mcp admin allow-tool delete_file --ttl 10m
+
+  # Deny every write tool until the override is revoked
+  mcp admin deny-tool 'write_*'
+
+  # Show and revoke overrides
+  mcp admin list
+  mcp admin revoke 2
+
+  # Debug a process started with --admin-debug
+  mcp admin metrics
+  mcp admin profile cpu --seconds 30 -o cpu.pprof && go tool pprof cpu.pprof`,
+	}
+
+	cmd.PersistentFlags().StringVar(&socket, "socket", "", "Admin socket of the process (default $HOME/.mcpt/admin.sock)")
+
+	client := func() (*admin.Client, error) {
+		path := socket
+		if path == "" {
+			var err error
+			if path, err = admin.GetSocketPath(); err != nil {
+				return nil, err
+			}
+		}
+		return admin.NewClient(path), nil
+	}
+
+	for _, entity := range entityTypes {
+		cmd.AddCommand(adminOverrideCmd(admin.ActionAllow, entity, client))
+		cmd.AddCommand(adminOverrideCmd(admin.ActionDeny, entity, client))
+	}
+	cmd.AddCommand(adminListCmd(client))
+	cmd.AddCommand(adminRevokeCmd(client))
+	cmd.AddCommand(adminMetricsCmd(client))
+	cmd.AddCommand(adminProfileCmd(client))
+
+	return cmd
+}
+
+func adminOverrideCmd(action, entity string, client func() (*admin.Client, error)) *cobra.Command {
+	var ttl time.Duration
+
+	cmd := &cobra.Command{
+		Use:          fmt.Sprintf("%s-%s pattern", action, entity),
+		Short:        fmt.Sprintf("Temporarily %s the %ss matching a pattern", action, entity),
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			o, err := c.Add(action, entity, args[0], ttl)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Added override %s\n", o)
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&ttl, "ttl", 0, "Remove the override after this long, e.g. 10m (default: never)")
+
+	return cmd
+}
+
+func adminListCmd(client func() (*admin.Client, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:          "list",
+		Short:        "List the active overrides",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			list, err := c.List()
+			if err != nil {
+				return err
+			}
+			if len(list) == 0 {
+				fmt.Fprintln(thisCmd.OutOrStdout(), "No overrides")
+				return nil
+			}
+			for _, o := range list {
+				fmt.Fprintln(thisCmd.OutOrStdout(), o)
+			}
+			return nil
+		},
+	}
+}
+
+func adminRevokeCmd(client func() (*admin.Client, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:          "revoke id",
+		Short:        "Remove an override",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid override id %q", args[0])
+			}
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			o, err := c.Remove(id)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Removed override %s\n", o)
+			return nil
+		},
+	}
+}
+
+func adminMetricsCmd(client func() (*admin.Client, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:          "metrics",
+		Short:        "Print the Go runtime metrics of a process started with --admin-debug",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			return c.Debug("/debug/metrics", thisCmd.OutOrStdout())
+		},
+	}
+}
+
+// profiles maps the names taken by admin profile to their pprof endpoints.
+var profiles = map[string]string{
+	"cpu":          "profile",
+	"heap":         "heap",
+	"allocs":       "allocs",
+	"goroutine":    "goroutine",
+	"block":        "block",
+	"mutex":        "mutex",
+	"threadcreate": "threadcreate",
+	"trace":        "trace",
+}
+
+func adminProfileCmd(client func() (*admin.Client, error)) *cobra.Command {
+	var (
+		seconds    int
+		outputPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "profile cpu|heap|allocs|goroutine|block|mutex|threadcreate|trace",
+		Short: "Save a pprof profile of a process started with --admin-debug",
+		Long: `Save a profile of a running guard or bridge started with --admin-debug, for go tool pprof, or an
+execution trace for go tool trace. CPU profiles and traces are collected for --seconds.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			endpoint, ok := profiles[args[0]]
+			if !ok {
+				return usageError(fmt.Sprintf("unknown profile %q", args[0]), "Example: mcp admin profile heap -o heap.pprof")
+			}
+			if outputPath == "" {
+				outputPath = args[0] + ".pprof"
+				if args[0] == "trace" {
+					outputPath = "trace.out"
+				}
+			}
+			c, err := client()
+			if err != nil {
+				return err
+			}
+
+			path := "/debug/pprof/" + endpoint
+			if endpoint == "profile" || endpoint == "trace" {
+				path += "?seconds=" + strconv.Itoa(seconds)
+				fmt.Fprintf(os.Stderr, "Collecting %s for %ds...\n", args[0], seconds)
+			}
+
+			// #nosec G304 - the output path is provided explicitly by the user
+			file, err := os.OpenFile(outputPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			if err = c.Debug(path, file); err != nil {
+				_ = file.Close()
+				_ = os.Remove(outputPath)
+				return err
+			}
+			if err = file.Close(); err != nil {
+				return err
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Saved %s profile to %s\n", args[0], outputPath)
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&seconds, "seconds", 30, "How long to collect CPU profiles and traces")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "File to save the profile to (default <profile>.pprof)")
+
+	return cmd
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/f/mcptools/pkg/aggregate"
+	"github.com/spf13/cobra"
+)
+
+// printAggregate combines documents, such as the pages of a call or the results of the runs
+// of a matrix, with mode and prints the combined document.
+func printAggregate(cmd *cobra.Command, mode aggregate.Mode, documents []any) error {
+	result, err := mode.Apply(context.Background(), documents)
+	if err != nil {
+		return err
+	}
+	return formatAndPrintOutput(cmd, "aggregate", map[string]any{
+		"aggregate": mode.String(),
+		"documents": len(documents),
+		"result":    result,
+	}, nil)
+}
+
+// resultDocument returns the data of a tool result: its structured content, or else JSON it
+// returned as text, or else the result itself.
+func resultDocument(result map[string]any) any {
+	if structured, ok := result["structuredContent"]; ok && structured != nil {
+		return structured
+	}
+	content, _ := result["content"].([]any)
+	for _, item := range content {
+		if text, _ := item.(map[string]any)["text"].(string); text != "" {
+			var decoded any
+			if json.Unmarshal([]byte(text), &decoded) == nil {
+				return decoded
+			}
+		}
+	}
+	return result
+}
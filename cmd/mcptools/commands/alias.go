@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -18,7 +19,13 @@ func AliasCmd() *cobra.Command {
 This command allows you to register MCP server commands with a friendly name and
 reuse them later.
 
-Aliases are stored in $HOME/.mcpt/aliases.json.
+Aliases are stored in $HOME/.mcpt/aliases.json, or in aliases.yaml or aliases.toml once
+converted with mcp config migrate; comments in aliases.yaml are kept when aliases change.
+
+An organization can publish approved aliases in a Consul or etcd key-value store. Once a
+registry is configured with mcp alias registry set, its aliases can be used like local ones;
+local aliases win over registry aliases of the same name. Registry aliases are cached for
+5 minutes by default, and the cache is used while the registry cannot be reached.
 
 Examples:
   # Add a new server alias
@@ -30,13 +37,32 @@ Examples:
   # Remove a server alias
   mcp alias remove myfs
 
+  # Always pass branch=main to create_pr unless the call sets it
+  mcp alias defaults gh create_pr '{"branch":"main"}'
+
+  # Warn when a call to search takes longer than 2 seconds
+  mcp alias slo gh search 2s
+
+  # Fall back to a remote mirror of the server, or race both and keep the fastest
+  mcp alias mirror myfs https://fs.example.com/mcp
+  mcp alias race myfs on
+
   # Use an alias with any MCP command
-  mcp tools myfs`,
+  mcp tools myfs
+
+  # Pick up the aliases published in the organization's Consul
+  mcp alias registry set consul https://consul.example.com:8500 --prefix mcptools/aliases/`,
 	}
 
 	cmd.AddCommand(aliasAddCmd())
 	cmd.AddCommand(aliasListCmd())
 	cmd.AddCommand(aliasRemoveCmd())
+	cmd.AddCommand(aliasDefaultsCmd())
+	cmd.AddCommand(aliasSLOCmd())
+	cmd.AddCommand(aliasMirrorCmd())
+	cmd.AddCommand(aliasRaceCmd())
+	cmd.AddCommand(aliasRegistryCmd())
+	cmd.AddCommand(aliasSyncCmd())
 
 	return cmd
 }
@@ -67,9 +93,10 @@ Example:
 				return fmt.Errorf("error loading aliases: %w", err)
 			}
 
-			aliases[aliasName] = alias.ServerAlias{
-				Command: serverCommand,
-			}
+			// Keep parameter defaults when an alias is registered again
+			existing := aliases[aliasName]
+			existing.Command = serverCommand
+			aliases[aliasName] = existing
 
 			if saveErr := alias.Save(aliases); saveErr != nil {
 				return fmt.Errorf("error saving aliases: %w", saveErr)
@@ -88,7 +115,7 @@ func aliasListCmd() *cobra.Command {
 		Short: "List all registered MCP server aliases",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			// Load existing aliases
-			aliases, err := alias.Load()
+			aliases, sources, err := alias.LoadAll(cmd.ErrOrStderr())
 			if err != nil {
 				return fmt.Errorf("error loading aliases: %w", err)
 			}
@@ -99,8 +126,19 @@ func aliasListCmd() *cobra.Command {
 			}
 
 			fmt.Fprintln(cmd.OutOrStdout(), "Registered MCP server aliases:")
-			for name, a := range aliases {
-				fmt.Fprintf(cmd.OutOrStdout(), "  %s: %s\n", name, a.Command)
+			for _, name := range aliases.Names() {
+				marker := ""
+				if sources[name] == alias.SourceRegistry {
+					marker = " (registry)"
+				}
+				if a := aliases[name]; len(a.Mirrors) > 0 {
+					mode := "mirrors"
+					if a.Race {
+						mode = "racing"
+					}
+					marker = fmt.Sprintf(" (%s: %s)%s", mode, strings.Join(a.Mirrors, ", "), marker)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s: %s%s\n", name, aliases[name].Command, marker)
 			}
 
 			return nil
@@ -145,3 +183,395 @@ Example:
 		},
 	}
 }
+
+func aliasDefaultsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "defaults <name> [tool] [params-json]",
+		Short: "Show or set default tool parameters for an MCP server alias",
+		Long: `Show or set parameters that are filled in when calling a tool through an alias.
+
+Defaults are applied client-side before the call is sent, and parameters given in the call
+always win. Use "*" as the tool name for defaults that apply to every tool; tool-specific
+defaults win over them. Setting '{}' removes the defaults of a tool.
+
+Examples:
+  # Show all defaults of an alias
+  mcp alias defaults gh
+
+  # Always pass branch=main to create_pr unless the call sets it
+  mcp alias defaults gh create_pr '{"branch":"main"}'
+
+  # Pass owner to every tool
+  mcp alias defaults gh '*' '{"owner":"my-org"}'
+
+  # Remove the defaults of create_pr
+  mcp alias defaults gh create_pr '{}'`,
+		Args: cobra.RangeArgs(1, 3),
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			aliasName := args[0]
+
+			aliases, err := alias.Load()
+			if err != nil {
+				return fmt.Errorf("error loading aliases: %w", err)
+			}
+
+			a, exists := aliases[aliasName]
+			if !exists {
+				return fmt.Errorf("alias '%s' does not exist", aliasName)
+			}
+
+			if len(args) < 3 {
+				defaults := a.Defaults
+				if len(args) == 2 {
+					defaults = map[string]map[string]any{args[1]: a.Defaults[args[1]]}
+				}
+				output, marshalErr := json.MarshalIndent(defaults, "", "  ")
+				if marshalErr != nil {
+					return marshalErr
+				}
+				fmt.Fprintln(thisCmd.OutOrStdout(), string(output))
+				return nil
+			}
+
+			toolName := args[1]
+			var params map[string]any
+			if jsonErr := json.Unmarshal([]byte(args[2]), &params); jsonErr != nil {
+				return fmt.Errorf("invalid JSON for params: %w", jsonErr)
+			}
+
+			if len(params) == 0 {
+				delete(a.Defaults, toolName)
+			} else {
+				if a.Defaults == nil {
+					a.Defaults = make(map[string]map[string]any)
+				}
+				a.Defaults[toolName] = params
+			}
+			aliases[aliasName] = a
+
+			if saveErr := alias.Save(aliases); saveErr != nil {
+				return fmt.Errorf("error saving aliases: %w", saveErr)
+			}
+
+			if len(params) == 0 {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "Defaults for '%s' removed from alias '%s'.\n", toolName, aliasName)
+			} else {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "Defaults for '%s' set on alias '%s'.\n", toolName, aliasName)
+			}
+			return nil
+		},
+	}
+}
+
+func aliasSLOCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "slo <name> [tool] [duration]",
+		Short: "Show or set latency objectives for the tools of an MCP server alias",
+		Long: `Show or set how long calls to the tools of an alias should take at most.
+
+A call slower than the SLO of its tool prints a warning with the time it took, and once usage
+recording is enabled with mcp stats enable, the breach is counted in mcp stats tools. Use "*"
+as the tool name for an SLO that applies to every tool; tool-specific SLOs win over it.
+Setting off removes the SLO of a tool.
+
+Examples:
+  # Show all SLOs of an alias
+  mcp alias slo gh
+
+  # Warn when a call to search takes longer than 2 seconds
+  mcp alias slo gh search 2s
+
+  # Expect every tool to answer within 500ms
+  mcp alias slo gh '*' 500ms
+
+  # Remove the SLO of search
+  mcp alias slo gh search off`,
+		Args: cobra.RangeArgs(1, 3),
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			aliasName := args[0]
+
+			aliases, err := alias.Load()
+			if err != nil {
+				return fmt.Errorf("error loading aliases: %w", err)
+			}
+
+			a, exists := aliases[aliasName]
+			if !exists {
+				return fmt.Errorf("alias '%s' does not exist", aliasName)
+			}
+
+			if len(args) < 3 {
+				slos := a.SLOs
+				if len(args) == 2 {
+					slos = map[string]string{args[1]: a.SLOs[args[1]]}
+				}
+				output, marshalErr := json.MarshalIndent(slos, "", "  ")
+				if marshalErr != nil {
+					return marshalErr
+				}
+				fmt.Fprintln(thisCmd.OutOrStdout(), string(output))
+				return nil
+			}
+
+			toolName, value := args[1], args[2]
+			if value == "off" {
+				delete(a.SLOs, toolName)
+			} else {
+				slo, parseErr := alias.ParseSLO(value)
+				if parseErr != nil {
+					return parseErr
+				}
+				if a.SLOs == nil {
+					a.SLOs = make(map[string]string)
+				}
+				a.SLOs[toolName] = slo.String()
+			}
+			aliases[aliasName] = a
+
+			if saveErr := alias.Save(aliases); saveErr != nil {
+				return fmt.Errorf("error saving aliases: %w", saveErr)
+			}
+
+			if value == "off" {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "SLO for '%s' removed from alias '%s'.\n", toolName, aliasName)
+			} else {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "SLO for '%s' set to %s on alias '%s'.\n", toolName, a.SLOs[toolName], aliasName)
+			}
+			return nil
+		},
+	}
+}
+
+func aliasMirrorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:                "mirror <name> [command args... | url | off]",
+		Short:              "Show or add equivalent endpoints of an MCP server alias",
+		DisableFlagParsing: true,
+		Long: `Show or add mirrors of an alias: commands or URLs of servers equivalent to its own, such
+as a remote HTTP deployment of a server the alias runs locally.
+
+When the command of the alias cannot be reached, clients try its mirrors in order. With
+mcp alias race on, they connect to the command and every mirror at once instead, and use
+whichever finishes initializing first. Either way, the endpoint used is kept for the rest of
+the session. Setting off removes every mirror.
+
+Examples:
+  # Show the mirrors of an alias
+  mcp alias mirror myfs
+
+  # Use a remote deployment when the local server fails to start
+  mcp alias mirror myfs https://fs.example.com/mcp
+
+  # Remove every mirror
+  mcp alias mirror myfs off`,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 0 || args[0] == FlagHelp || args[0] == FlagHelpShort {
+				_ = thisCmd.Help()
+				return nil
+			}
+			aliasName := args[0]
+
+			aliases, err := alias.Load()
+			if err != nil {
+				return fmt.Errorf("error loading aliases: %w", err)
+			}
+
+			a, exists := aliases[aliasName]
+			if !exists {
+				return fmt.Errorf("alias '%s' does not exist", aliasName)
+			}
+
+			if len(args) == 1 {
+				for _, endpoint := range a.Mirrors {
+					fmt.Fprintln(thisCmd.OutOrStdout(), endpoint)
+				}
+				return nil
+			}
+
+			endpoint := strings.Join(args[1:], " ")
+			if endpoint == "off" {
+				a.Mirrors, a.Race = nil, false
+			} else {
+				for _, existing := range a.Endpoints() {
+					if existing == endpoint {
+						return fmt.Errorf("'%s' is already an endpoint of alias '%s'", endpoint, aliasName)
+					}
+				}
+				a.Mirrors = append(a.Mirrors, endpoint)
+			}
+			aliases[aliasName] = a
+
+			if saveErr := alias.Save(aliases); saveErr != nil {
+				return fmt.Errorf("error saving aliases: %w", saveErr)
+			}
+
+			if endpoint == "off" {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "Mirrors removed from alias '%s'.\n", aliasName)
+			} else {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "Mirror '%s' added to alias '%s'.\n", endpoint, aliasName)
+			}
+			return nil
+		},
+	}
+}
+
+func aliasRaceCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "race <name> [on|off]",
+		Short: "Show or set whether the endpoints of an MCP server alias race",
+		Long: `Show or set whether clients connect to the command and the mirrors of an alias at once.
+
+In race mode, every endpoint is initialized concurrently and the first to answer is used for
+the rest of the session; the others are closed as soon as they come up. This trades some
+server starts for the latency of the fastest endpoint. Without it, mirrors are only tried
+when the endpoints before them cannot be reached.
+
+Examples:
+  mcp alias race myfs
+  mcp alias race myfs on`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			aliasName := args[0]
+
+			aliases, err := alias.Load()
+			if err != nil {
+				return fmt.Errorf("error loading aliases: %w", err)
+			}
+
+			a, exists := aliases[aliasName]
+			if !exists {
+				return fmt.Errorf("alias '%s' does not exist", aliasName)
+			}
+
+			if len(args) == 1 {
+				state := "off"
+				if a.Race {
+					state = "on"
+				}
+				fmt.Fprintln(thisCmd.OutOrStdout(), state)
+				return nil
+			}
+
+			switch args[1] {
+			case "on":
+				if len(a.Mirrors) == 0 {
+					return fmt.Errorf("alias '%s' has no mirrors to race; add one with: mcp alias mirror %s <command or url>", aliasName, aliasName)
+				}
+				a.Race = true
+			case "off":
+				a.Race = false
+			default:
+				return fmt.Errorf("invalid value %q: expected on or off", args[1])
+			}
+			aliases[aliasName] = a
+
+			if saveErr := alias.Save(aliases); saveErr != nil {
+				return fmt.Errorf("error saving aliases: %w", saveErr)
+			}
+
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Race mode %s for alias '%s'.\n", args[1], aliasName)
+			return nil
+		},
+	}
+}
+
+func aliasRegistryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Configure the remote registry that publishes aliases",
+		Long: `Configure a Consul or etcd key-value store that publishes aliases for an organization.
+
+Every key under the prefix (mcptools/aliases/ by default) is an alias named after the rest of
+the key. Its value is either a server command or an alias object such as
+{"command":"npx -y @modelcontextprotocol/server-github","defaults":{"*":{"owner":"acme"}}}.
+etcd is read through the JSON gateway of its v3 API.
+
+Registry aliases run commands on your machine, so only configure registries you trust.
+
+Examples:
+  mcp alias registry set consul http://127.0.0.1:8500
+  mcp alias registry set etcd https://etcd.example.com:2379 --prefix /mcp/aliases/ --refresh 1h
+  mcp alias registry show
+  mcp alias registry remove`,
+	}
+
+	var prefix, token, refresh string
+	setCmd := &cobra.Command{
+		Use:   "set <consul|etcd> <address>",
+		Short: "Use a Consul or etcd registry",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			registry := &alias.Registry{Backend: args[0], Address: args[1], Prefix: prefix, Token: token, Refresh: refresh}
+			if err := alias.SaveRegistry(registry); err != nil {
+				return err
+			}
+
+			aliases, err := alias.Sync()
+			if err != nil {
+				return fmt.Errorf("registry saved, but fetching its aliases failed: %w", err)
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Registry set to %s at %s (%d aliases).\n", args[0], args[1], len(aliases))
+			return nil
+		},
+	}
+	setCmd.Flags().StringVar(&prefix, "prefix", "", "Key prefix the aliases are published under (default "+alias.DefaultPrefix+")")
+	setCmd.Flags().StringVar(&token, "token", "", "ACL token (Consul) or auth token (etcd)")
+	setCmd.Flags().StringVar(&refresh, "refresh", "", "How long fetched aliases are cached (default 5m)")
+
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the configured registry",
+		Args:  cobra.NoArgs,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			registry, err := alias.LoadRegistry()
+			if err != nil {
+				return err
+			}
+			if registry == nil {
+				fmt.Fprintln(thisCmd.OutOrStdout(), "No alias registry configured.")
+				return nil
+			}
+			if registry.Token != "" {
+				registry.Token = "(set)"
+			}
+			output, err := json.MarshalIndent(registry, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(thisCmd.OutOrStdout(), string(output))
+			return nil
+		},
+	}
+
+	removeCmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Stop using the registry",
+		Args:  cobra.NoArgs,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			if err := alias.SaveRegistry(nil); err != nil {
+				return err
+			}
+			fmt.Fprintln(thisCmd.OutOrStdout(), "Alias registry removed.")
+			return nil
+		},
+	}
+
+	cmd.AddCommand(setCmd, showCmd, removeCmd)
+	return cmd
+}
+
+func aliasSyncCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "sync",
+		Short: "Fetch the aliases published in the registry now",
+		Args:  cobra.NoArgs,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			aliases, err := alias.Sync()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Fetched %d aliases from the registry.\n", len(aliases))
+			return nil
+		},
+	}
+}
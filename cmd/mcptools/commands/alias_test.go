@@ -2,10 +2,15 @@ package commands
 
 import (
 	"bytes"
+	"fmt"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/f/mcptools/pkg/alias"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/spf13/cobra"
 )
 
 func TestAliasCommands(t *testing.T) {
@@ -165,3 +170,162 @@ func TestAliasCommands(t *testing.T) {
 		}
 	})
 }
+
+func TestAliasDefaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := alias.Save(alias.Aliases{"gh": {Command: "gh-server"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"gh", "create_pr", `{"branch":"main","draft":true}`},
+		{"gh", "*", `{"owner":"my-org","draft":false}`},
+	} {
+		cmd := aliasDefaultsCmd()
+		cmd.SetOut(new(bytes.Buffer))
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("defaults %v failed: %v", args, err)
+		}
+	}
+
+	// Registering the alias again keeps its defaults
+	addCmd := aliasAddCmd()
+	addCmd.SetOut(new(bytes.Buffer))
+	addCmd.SetArgs([]string{"gh", "gh-server", "--verbose"})
+	if err := addCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := applyAliasDefaults([]string{"gh"}, "create_pr", map[string]any{"title": "Fix", "draft": false})
+	want := map[string]any{"title": "Fix", "branch": "main", "owner": "my-org", "draft": false}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("applyAliasDefaults() = %v, want %v", got, want)
+	}
+
+	got = applyAliasDefaults([]string{"gh"}, "list_issues", nil)
+	if !reflect.DeepEqual(got, map[string]any{"owner": "my-org", "draft": false}) {
+		t.Errorf("expected only the defaults for every tool, got %v", got)
+	}
+
+	got = applyAliasDefaults([]string{"gh-server"}, "create_pr", nil)
+	if got != nil {
+		t.Errorf("expected no defaults for a server that is not an alias, got %v", got)
+	}
+
+	cmd := aliasDefaultsCmd()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"gh", "create_pr", "{}"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := alias.Get("gh"); a.Defaults["create_pr"] != nil {
+		t.Errorf("expected create_pr defaults to be removed, got %v", a.Defaults)
+	}
+}
+
+func TestAliasSLO(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := alias.Save(alias.Aliases{"gh": {Command: "gh-server"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{{"gh", "search", "2s"}, {"gh", "*", "500ms"}} {
+		cmd := aliasSLOCmd()
+		cmd.SetOut(new(bytes.Buffer))
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("slo %v failed: %v", args, err)
+		}
+	}
+
+	a, _ := alias.Get("gh")
+	if a.SLO("search") != 2*time.Second || a.SLO("create_pr") != 500*time.Millisecond {
+		t.Errorf("SLOs = %v, want 2s for search and 500ms for other tools", a.SLOs)
+	}
+
+	cmd := aliasSLOCmd()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"gh", "search", "soon"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected an error for an SLO that is not a duration")
+	}
+
+	cmd = aliasSLOCmd()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"gh", "search", "off"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ = alias.Get("gh"); a.SLO("search") != 500*time.Millisecond {
+		t.Errorf("SLO of search = %v, want the SLO of every tool once its own is removed", a.SLO("search"))
+	}
+}
+
+func TestAliasMirror(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := alias.Save(alias.Aliases{"fs": {Command: "fs-server"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(cmd func() *cobra.Command, args ...string) error {
+		c := cmd()
+		c.SetOut(new(bytes.Buffer))
+		c.SetArgs(args)
+		return c.Execute()
+	}
+
+	if err := run(aliasRaceCmd, "fs", "on"); err == nil {
+		t.Error("expected an error for racing an alias without mirrors")
+	}
+	if err := run(aliasMirrorCmd, "fs", "https://fs.example.com/mcp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(aliasMirrorCmd, "fs", "https://fs.example.com/mcp"); err == nil {
+		t.Error("expected an error for a mirror added twice")
+	}
+	if err := run(aliasRaceCmd, "fs", "on"); err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := alias.Get("fs")
+	if want := []string{"fs-server", "https://fs.example.com/mcp"}; !reflect.DeepEqual(a.Endpoints(), want) || !a.Race {
+		t.Errorf("endpoints = %v, race = %v, want %v racing", a.Endpoints(), a.Race, want)
+	}
+
+	if err := run(aliasMirrorCmd, "fs", "off"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ = alias.Get("fs"); len(a.Mirrors) != 0 || a.Race {
+		t.Errorf("alias = %+v, want no mirrors and no race", a)
+	}
+}
+
+func TestCreateMirroredClient(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	mcpServer := server.NewMCPServer("mirror", "1.0.0")
+	httpServer := server.NewTestStreamableHTTPServer(mcpServer)
+	defer httpServer.Close()
+
+	for _, race := range []bool{false, true} {
+		name := fmt.Sprintf("fs-race-%v", race)
+		a := alias.ServerAlias{Command: "mcptools-test-missing-server", Mirrors: []string{httpServer.URL + "/mcp"}, Race: race}
+		if err := alias.Save(alias.Aliases{name: a}); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := createClient([]string{name})
+		if err != nil {
+			t.Fatalf("race %v: createClient() error = %v, want the mirror used", race, err)
+		}
+		_ = c.Close()
+
+		if winner, _ := mirrorWinners.Load(name); winner != a.Mirrors[0] {
+			t.Errorf("race %v: cached endpoint = %v, want the mirror", race, winner)
+		}
+	}
+}
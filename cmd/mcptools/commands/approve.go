@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+// ApproveCmd creates the approve command.
+func ApproveCmd() *cobra.Command {
+	var (
+		reject bool
+		reason string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "approve [--reject] [--reason text] [run-id step]",
+		Short: "Approve or reject workflow steps waiting for approval",
+		Long: `Approve or reject a step of a workflow run started with mcp run that is marked approve: true
+and waits for approval. The run ID and step are printed by the waiting run. Without
+arguments, the steps waiting for approval are listed.
+
+Examples:
+  mcp approve
+  mcp approve deploy-3f2a9c rollout
+  mcp approve --reject --reason "outside the change window" deploy-3f2a9c rollout`,
+		Args:         cobra.MaximumNArgs(2),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			dir, err := workflow.GetApprovalsPath()
+			if err != nil {
+				return err
+			}
+			approvals := workflow.Approvals{Dir: dir}
+
+			switch len(args) {
+			case 0:
+				pending, err := approvals.Pending()
+				if err != nil {
+					return err
+				}
+				if jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+					output, formatErr := jsonutils.Format(ConvertJSONToSlice(pending), FormatOption)
+					if formatErr != nil {
+						return formatErr
+					}
+					fmt.Fprintln(thisCmd.OutOrStdout(), output)
+					return nil
+				}
+				printApprovals(thisCmd.OutOrStdout(), pending)
+				return nil
+			case 1:
+				return usageError("both a run ID and a step are required", "Example: mcp approve deploy-3f2a9c rollout")
+			}
+
+			approval, err := approvals.Decide(args[0], args[1], !reject, currentUser(), reason)
+			if err != nil {
+				return withHint(err, "List the steps waiting for approval with: mcp approve")
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Step %s (%s) of run %s %s\n", approval.Step, approval.Tool, approval.Run, approval.Decision)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&reject, "reject", false, "Reject the step instead of approving it")
+	cmd.Flags().StringVar(&reason, "reason", "", "Reason for the decision, reported to the run")
+
+	return cmd
+}
+
+// printApprovals writes the steps waiting for approval as a table.
+func printApprovals(w io.Writer, pending []workflow.Approval) {
+	if len(pending) == 0 {
+		fmt.Fprintln(w, "No steps waiting for approval")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tSTEP\tTOOL\tWAITING\tPARAMS")
+	for _, approval := range pending {
+		params, _ := json.Marshal(approval.Params)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", approval.Run, approval.Step, approval.Tool,
+			time.Since(approval.Requested).Round(time.Second), params)
+	}
+	_ = tw.Flush()
+}
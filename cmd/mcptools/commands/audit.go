@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/f/mcptools/pkg/audit"
+	"github.com/f/mcptools/pkg/bridge"
+	"github.com/spf13/cobra"
+)
+
+// AuditCmd creates the audit command.
+func AuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Work with tamper-evident audit logs",
+		Long: `Work with the tamper-evident audit logs written by the bridge command.
+
+Examples:
+  # Verify the default bridge audit log
+  mcp audit verify
+
+  # Verify a signed log
+  mcp audit verify --key audit.key /var/log/mcp/audit.log`,
+	}
+
+	cmd.AddCommand(auditVerifyCmd())
+
+	return cmd
+}
+
+func auditVerifyCmd() *cobra.Command {
+	var keyPath string
+
+	cmd := &cobra.Command{
+		Use:   "verify [--key file] [log]",
+		Short: "Check an audit log for tampering",
+		Long: `Check that no record of an audit log was edited, inserted, reordered or removed, and with
+--key that every record was signed with the key. The log defaults to
+$HOME/.mcpt/logs/bridge-audit.log.
+
+The hash of the last record is printed: recording it elsewhere also detects records removed
+from the end of the log.`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			} else {
+				var err error
+				if path, err = bridge.GetAuditLogPath(); err != nil {
+					return err
+				}
+			}
+
+			var key []byte
+			if keyPath != "" {
+				var err error
+				if key, err = audit.LoadKey(keyPath); err != nil {
+					return err
+				}
+			}
+
+			// #nosec G304 - the log path is provided by the user or generated internally
+			file, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open audit log: %w", err)
+			}
+			defer func() { _ = file.Close() }()
+
+			result, err := audit.Verify(file, key)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			signed := ""
+			if result.Signed {
+				signed = ", all signed"
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "OK: %d records%s\nHead: %s\n", result.Records, signed, result.Head)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&keyPath, "key", "", "File holding the key the log was signed with")
+
+	return cmd
+}
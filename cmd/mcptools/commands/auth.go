@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/f/mcptools/pkg/deviceauth"
+	"github.com/spf13/cobra"
+)
+
+// openBrowser opens the device authentication page, or asks to open it when no browser can be
+// started, as over SSH.
+func openBrowser(page string) error {
+	if err := deviceauth.OpenBrowser(page); err != nil {
+		fmt.Fprintf(os.Stderr, "Open this page in a browser on this device:\n  %s\n", page)
+	}
+	return nil
+}
+
+// AuthCmd creates the auth command.
+func AuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage credentials for MCP gateways",
+	}
+	cmd.AddCommand(authDeviceCmd())
+	return cmd
+}
+
+func authDeviceCmd() *cobra.Command {
+	var path string
+	var forget, list bool
+
+	cmd := &cobra.Command{
+		Use:   "device [gateway]",
+		Short: "Register this device with a gateway using a FIDO2/WebAuthn passkey",
+		Long: `Register this device with a gateway that supports device-bound authentication.
+
+The gateway's authentication page opens in the browser, where a platform authenticator
+(Touch ID, Windows Hello, a security key...) creates a passkey for the gateway. Only the
+credential ID is stored, in $HOME/.mcpt/devices.json; no bearer token is kept on disk.
+
+Afterwards, --device-auth makes client commands ask the authenticator for an assertion of the
+passkey, which the gateway exchanges for a short-lived access token held in memory:
+
+  mcp tools --device-auth https://gateway.example.com/mcp
+
+The gateway serves the page at /auth/device, or at --path. See the deviceauth package for the
+redirect it is expected to make.
+
+Examples:
+  # Register this device
+  mcp auth device https://gateway.example.com
+
+  # List registered gateways
+  mcp auth device --list
+
+  # Remove the registration of a gateway
+  mcp auth device --forget https://gateway.example.com`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			out := thisCmd.OutOrStdout()
+			devices, err := deviceauth.Load()
+			if err != nil {
+				return err
+			}
+
+			if list {
+				origins := make([]string, 0, len(devices))
+				for origin := range devices {
+					origins = append(origins, origin)
+				}
+				sort.Strings(origins)
+				for _, origin := range origins {
+					fmt.Fprintf(out, "%s\tregistered %s\n", origin, devices[origin].Registered.Local().Format(time.DateTime))
+				}
+				return nil
+			}
+			if len(args) == 0 {
+				return usageError("a gateway URL is required", "Example: mcp auth device https://gateway.example.com")
+			}
+			origin, err := deviceauth.Origin(args[0])
+			if err != nil {
+				return err
+			}
+
+			if forget {
+				if _, ok := devices[origin]; !ok {
+					return fmt.Errorf("%s: %w", origin, deviceauth.ErrNotRegistered)
+				}
+				delete(devices, origin)
+				if err = devices.Save(); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Forgot the device credential for %s\n", origin)
+				fmt.Fprintln(out, "Also remove the passkey from your authenticator and the gateway if it is no longer used.")
+				return nil
+			}
+
+			fmt.Fprintf(os.Stderr, "Opening %s in the browser to create a passkey...\n", origin)
+			session, err := deviceauth.Authenticate(thisCmd.Context(), origin, deviceauth.Device{Path: path}, openBrowser)
+			if err != nil {
+				return err
+			}
+			devices[origin] = deviceauth.Device{CredentialID: session.CredentialID, Path: path, Registered: time.Now().UTC()}
+			if err = devices.Save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Registered this device with %s\n", origin)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&path, "path", "", "Path of the gateway's device authentication page (default: /auth/device)")
+	cmd.Flags().BoolVar(&forget, "forget", false, "Remove the device credential registered for the gateway")
+	cmd.Flags().BoolVar(&list, "list", false, "List the gateways this device is registered with")
+
+	return cmd
+}
+
+// deviceAuthHeader asks the authenticator for an assertion of the device credential registered
+// for the gateway serving serverURL, and returns an Authorization header with the short-lived
+// token the gateway hands out for it.
+func deviceAuthHeader(serverURL string) (string, error) {
+	origin, err := deviceauth.Origin(serverURL)
+	if err != nil {
+		return "", err
+	}
+	devices, err := deviceauth.Load()
+	if err != nil {
+		return "", err
+	}
+	device, ok := devices[origin]
+	if !ok {
+		return "", fmt.Errorf("%s: %w; register it with 'mcp auth device %s'", origin, deviceauth.ErrNotRegistered, origin)
+	}
+
+	fmt.Fprintf(os.Stderr, "Confirm with your authenticator in the browser to sign in to %s...\n", origin)
+	session, err := deviceauth.Authenticate(context.Background(), origin, device, openBrowser)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + session.AccessToken, nil
+}
@@ -0,0 +1,586 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/f/mcptools/pkg/accesslog"
+	"github.com/f/mcptools/pkg/admin"
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/anonymize"
+	"github.com/f/mcptools/pkg/audit"
+	"github.com/f/mcptools/pkg/bridge"
+	"github.com/f/mcptools/pkg/egress"
+	"github.com/f/mcptools/pkg/notify"
+	"github.com/spf13/cobra"
+)
+
+// BridgeCmd creates the bridge command.
+func BridgeCmd() *cobra.Command {
+	var (
+		keysPath   string
+		quotasPath string
+		httpAddr   string
+		auditPath  string
+		auditKey   string
+		adminPath  string
+		adminAPI   bool
+		adminDebug bool
+		buffer     int
+		canarySpec string
+		shadowName string
+		shadowLog  string
+		timeout    time.Duration
+		failures   int
+		cooldown   time.Duration
+		maxCalls   int
+		batchTools string
+		interleave bool
+		drain      time.Duration
+		storeURL   string
+		egressLog  bool
+		localOnly  bool
+		analytics  analyticsOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bridge --keys file [--quotas file] [--http addr] [--audit-log file] [--canary spec] [--shadow alias] command args...",
+		Short: "Share one stdio MCP server with many clients over HTTP",
+		Long: `Share one stdio MCP server with many downstream clients over HTTP.
+
+Clients POST JSON-RPC messages to /mcp with their API key as a bearer token (or in the
+X-API-Key header). The keys file maps each key to a tenant and user:
+
+  {"sk-acme-1": {"tenant": "acme", "user": "alice", "role": "agent"}}
+
+Every request forwarded to the server gets the key's identity in its _meta object, as
+mcptools/tenant and mcptools/user. Identities sent by clients themselves are overwritten.
+
+Each request is written to the audit log ($HOME/.mcpt/logs/bridge-audit.log by default) as a
+JSON line with the tenant, user, a fingerprint of the key, the method and its outcome. Request
+counts and durations labelled by tenant, user, method and status are served in Prometheus
+format at /metrics.
+
+The audit log is append-only: every record carries a sequence number and a hash chaining it to
+the record before, and with --audit-key an HMAC signature made with the key in that file. Check
+it with mcp audit verify.
+
+Clients start a session with initialize and send the returned Mcp-Session-Id header with
+later requests. The quotas file limits the sessions of each role (keys without a role have the
+role "default"):
+
+  {"agent": {"max_calls": 100, "max_bytes": 1048576, "max_duration": "10m"}}
+
+A session that goes over its quota is terminated: its requests fail with a 404 and an error
+saying which limit was exceeded. Keys whose role has a quota must use sessions.
+
+Sessions are kept in memory unless --session-store names a Redis server
+(redis://[user:password@]host[:port][/db], or rediss:// for TLS). Bridges sharing the server
+share sessions: behind a load balancer, a client can reconnect its stream or send its next
+request to any of them with the same Mcp-Session-Id. Quotas count the calls made through all
+of them, and a bridge first seeing a session subscribes its server to the resources the session
+subscribed to. Sessions expire 24 hours after they were last used.
+
+Server notifications are sent to every session that opens a stream with a GET request to /mcp
+(with its Mcp-Session-Id header), as server-sent events. Each stream queues up to
+--notification-buffer notifications: updates of the same resource, progress of the same
+request and list changes replace the queued one, and once the queue is full the oldest
+notification is dropped, so a slow client cannot hold up the server.
+
+Clients behind proxies that block event streams can long-poll instead: a GET request that
+accepts only application/json waits up to 25 seconds and returns the queued notifications as a
+JSON array. mcp does this with --transport longpoll, or automatically when SSE fails.
+
+With --admin (or --admin-socket path), the bridge serves an admin API on a Unix socket
+($HOME/.mcpt/admin.sock by default) for temporarily denying tools, prompts and resources
+without a restart, e.g. mcp admin deny-tool delete_file --ttl 10m. Every change is written
+to the audit log. --admin-debug also serves pprof profiles and Go runtime metrics on it, read
+with mcp admin profile and mcp admin metrics, to debug the CPU and memory use of the bridge.
+
+With --canary fs=10%:fs-v2, the bridge also starts the server of the alias fs-v2 and sends it
+10% of the tool calls, prompt gets and resource reads for fs, the bridged alias, so a new server
+version can be rolled out behind the same endpoint. Listings are always answered by fs. The
+calls, error rates and mean latencies of both servers are served as JSON at /canary and in the
+metrics, and each audit record says which server answered. Calls the canary cannot answer
+because it exited are retried on fs.
+
+With --shadow fs-v2, every request is also sent to the server of the alias fs-v2 in the
+background. Its responses are discarded; those that differ from the bridged server's are
+written to $HOME/.mcpt/logs/bridge-shadow.log (or --shadow-log) with the paths that differ, and
+the number of matching and differing responses and both servers' mean latencies are served as
+JSON at /shadow. Tool calls are mirrored too, so the shadow server must not share state with
+the bridged one.
+
+Each server has a circuit breaker: after --breaker-failures consecutive requests fail because
+the server exited or did not answer within --call-timeout, requests to it fail fast with an
+error saying so for --breaker-cooldown. Then one request probes the server, closing the
+circuit if it succeeds. While the circuit of a canary is open its share of the calls goes to
+the bridged server, and while that of a shadow is open requests are not mirrored.
+
+With --max-concurrent n, at most n requests are sent to the server at a time, and the others
+wait. Calls to tools matching --batch-tools patterns (e.g. export_*,reindex) are batch
+requests; everything else is interactive and goes ahead of waiting batch requests, keeping
+latency low for people while batch jobs run.
+
+The requests of a session are sent to the server one at a time in the order they arrived, as
+some servers misbehave when a client's requests interleave; sessions still run in parallel.
+--interleave sends them as they come instead.
+
+With --analytics-dest, a sample of the requests (--analytics-sample, all by default) is also
+exported for usage analytics, as JSON lines in the schema documented in pkg/accesslog. Users
+are replaced by pseudonyms keyed by a salt ($HOME/.mcpt/analytics.salt by default, created on
+first use), emails and IP addresses in targets are anonymized with the rules in
+$HOME/.mcpt/anonymize.json, the query of resource URIs is dropped, and no request or response
+bodies are included. Records are spooled in $HOME/.mcpt/logs/access and shipped on rotation
+(--analytics-rotate, or every 64 MiB) to the destination: a local directory,
+s3://bucket/prefix or gs://bucket/prefix, with credentials found like the AWS and Google Cloud
+tools do (see mcp read-resource --help). Segments that fail to ship are retried on the next
+rotation.
+
+On Linux, --observe-egress watches the outbound connections of the servers and of processes
+they start, and writes each new destination to the audit log as an "egress" record. With
+--local-only, the servers are declared to need no network: connections to other hosts than
+this one are recorded with the status "unexpected" and reported on stderr. Connections are
+polled from /proc every second, so very short-lived ones may be missed.
+
+On SIGTERM or interrupt, the bridge drains: it stops accepting connections, answers initialize
+requests and its /healthz check with 503, and ends notification streams, while requests in
+flight get up to --drain-timeout to finish. Then the servers are shut down as the MCP stdio
+transport asks: their input is closed, and they are sent SIGTERM and finally killed if they do
+not exit within 5 seconds each time. A second signal exits right away.
+
+Examples:
+  mcp bridge --keys keys.json npx -y @modelcontextprotocol/server-filesystem ~
+  mcp bridge --keys keys.json --http :9000 --audit-log audit.log fs
+  mcp bridge --keys keys.json --quotas quotas.json fs
+  mcp bridge --keys keys.json --session-store redis://cache:6379/0 fs
+  mcp bridge --keys keys.json --canary fs=10%:fs-v2 fs
+  mcp bridge --keys keys.json --shadow fs-v2 fs
+  mcp bridge --keys keys.json --local-only fs
+  mcp bridge --keys keys.json --analytics-dest s3://analytics/mcp --analytics-sample 0.1 fs`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			keys, err := bridge.LoadKeys(keysPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			var store bridge.SessionStore
+			if storeURL != "" {
+				if store, err = bridge.OpenSessionStore(context.Background(), storeURL); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			var quotas bridge.Quotas
+			if quotasPath != "" {
+				if quotas, err = bridge.LoadQuotas(quotasPath); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			var canary *bridge.CanarySpec
+			if canarySpec != "" {
+				spec, specErr := bridge.ParseCanary(canarySpec)
+				if specErr != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", specErr)
+					os.Exit(1)
+				}
+				canary = &spec
+			}
+
+			if len(args) == 1 {
+				if serverCmd, found := alias.GetServerCommand(args[0]); found {
+					if canary != nil && canary.Server != args[0] {
+						fmt.Fprintf(os.Stderr, "Error: canary %s does not match the bridged server %s\n", canary.Server, args[0])
+						os.Exit(1)
+					}
+					args = ParseCommandString(serverCmd)
+				}
+			}
+			command, commandArgs, err := serverCommand(args)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			audit, err := openAuditLog(auditPath, auditKey)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer func() { _ = audit.Close() }()
+
+			upstream, err := bridge.StartUpstream(command, commandArgs)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer func() { _ = upstream.Close() }()
+
+			var canaryUpstream *bridge.Canary
+			if canary != nil {
+				if canaryUpstream, err = startCanary(*canary); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				defer func() { _ = canaryUpstream.Upstream.Close() }()
+			}
+
+			var shadow *bridge.Shadow
+			if shadowName != "" {
+				if shadow, err = startShadow(shadowName, shadowLog); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				defer func() { _ = shadow.Upstream.Close() }()
+			}
+
+			var exporter *accesslog.Exporter
+			if analytics.dest != "" {
+				if exporter, err = analytics.exporter(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				defer func() { _ = exporter.Close() }()
+			}
+
+			if (adminAPI || adminDebug) && adminPath == "" {
+				if adminPath, err = admin.GetSocketPath(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			var overrides *admin.Overrides
+			if adminPath != "" {
+				overrides = admin.NewOverrides()
+			}
+
+			b, err := bridge.New(context.Background(), upstream, bridge.Options{
+				AuditLog:           audit,
+				Keys:               keys,
+				Quotas:             quotas,
+				Overrides:          overrides,
+				NotificationBuffer: buffer,
+				Canary:             canaryUpstream,
+				Shadow:             shadow,
+				CallTimeout:        timeout,
+				BreakerFailures:    failures,
+				BreakerCooldown:    cooldown,
+				MaxConcurrent:      maxCalls,
+				BatchTools:         splitPatterns(batchTools),
+				Interleave:         interleave,
+				Analytics:          analyticsRecorder(exporter),
+				Sessions:           store,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if adminPath != "" {
+				var adminOpts []admin.ListenOption
+				if adminDebug {
+					adminOpts = append(adminOpts, admin.WithDebug())
+				}
+				adminServer, adminErr := admin.Listen(adminPath, overrides, b.LogAdmin, adminOpts...)
+				if adminErr != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", adminErr)
+					os.Exit(1)
+				}
+				defer func() { _ = adminServer.Close() }()
+				fmt.Fprintf(os.Stderr, "Admin API listening on %s\n", adminPath)
+			}
+
+			fmt.Fprintf(os.Stderr, "Bridging %s for %d API keys on http://%s/mcp\n",
+				strings.Join(args, " "), len(keys), displayHTTPAddr(httpAddr))
+			if canary != nil {
+				fmt.Fprintf(os.Stderr, "Sending %g%% of calls to %s; compare them on http://%s/canary\n",
+					canary.Percent, canary.Target, displayHTTPAddr(httpAddr))
+			}
+			if shadow != nil {
+				fmt.Fprintf(os.Stderr, "Mirroring requests to %s; compare them on http://%s/shadow\n",
+					shadowName, displayHTTPAddr(httpAddr))
+			}
+
+			// #nosec G112 - the bridge is a long-running local server without timeouts by design
+			server := &http.Server{Addr: httpAddr, Handler: b}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if egressLog || localOnly {
+				observed := map[string]*bridge.Upstream{strings.Join(args, " "): upstream}
+				if canaryUpstream != nil {
+					observed[canary.Target] = canaryUpstream.Upstream
+				}
+				if shadow != nil {
+					observed[shadowName] = shadow.Upstream
+				}
+				observeEgress(ctx, b, observed, localOnly)
+			}
+			served := make(chan error, 1)
+			go func() { served <- server.ListenAndServe() }()
+			select {
+			case err = <-served:
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			case <-ctx.Done():
+			}
+			// From here on, a second signal terminates the bridge right away
+			stop()
+
+			fmt.Fprintf(os.Stderr, "Draining: waiting up to %s for requests in flight\n", drain)
+			b.Drain()
+			drainCtx, cancel := context.WithTimeout(context.Background(), drain)
+			defer cancel()
+			if err = server.Shutdown(drainCtx); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: requests still in flight after %s were cancelled\n", drain)
+				_ = server.Close()
+			}
+
+			servers := []*bridge.Upstream{upstream}
+			if canaryUpstream != nil {
+				servers = append(servers, canaryUpstream.Upstream)
+			}
+			if shadow != nil {
+				servers = append(servers, shadow.Upstream)
+			}
+			shutdownUpstreams(servers)
+			fmt.Fprintln(os.Stderr, "Bridge stopped")
+		},
+	}
+
+	// Flags after the server command belong to the server
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().StringVar(&keysPath, "keys", "", "JSON file mapping API keys to tenants and users")
+	cmd.Flags().StringVar(&quotasPath, "quotas", "", "JSON file with per-role session quotas")
+	cmd.Flags().StringVar(&httpAddr, "http", ":8080", "Address to serve the bridge on")
+	cmd.Flags().BoolVar(&adminAPI, "admin", false, "Serve the admin API on $HOME/.mcpt/admin.sock")
+	cmd.Flags().StringVar(&adminPath, "admin-socket", "", "Serve the admin API on this Unix socket")
+	cmd.Flags().BoolVar(&adminDebug, "admin-debug", false, "Also serve pprof profiles and Go runtime metrics on the admin API")
+	cmd.Flags().StringVar(&auditPath, "audit-log", "", "Audit log file (default $HOME/.mcpt/logs/bridge-audit.log)")
+	cmd.Flags().StringVar(&auditKey, "audit-key", "", "File holding a secret key to sign audit records with")
+	cmd.Flags().IntVar(&buffer, "notification-buffer", notify.DefaultSize, "Server notifications queued for each client stream before the oldest are dropped")
+	cmd.Flags().StringVar(&canarySpec, "canary", "", "Send a share of the calls to another alias, e.g. fs=10%:fs-v2")
+	cmd.Flags().StringVar(&shadowName, "shadow", "", "Mirror every request to another alias and log the responses that differ")
+	cmd.Flags().StringVar(&shadowLog, "shadow-log", "", "Log of differing shadow responses (default $HOME/.mcpt/logs/bridge-shadow.log)")
+	cmd.Flags().DurationVar(&timeout, "call-timeout", 0, "How long a server may take to answer a request (0 for no limit)")
+	cmd.Flags().IntVar(&failures, "breaker-failures", bridge.DefaultBreakerFailures, "Consecutive failures that open the circuit of a server (0 to disable)")
+	cmd.Flags().DurationVar(&cooldown, "breaker-cooldown", bridge.DefaultBreakerCooldown, "How long requests to a server fail fast once its circuit opens")
+	cmd.Flags().IntVar(&maxCalls, "max-concurrent", 0, "Requests sent to the server at a time, interactive ones first (0 for no limit)")
+	cmd.Flags().StringVar(&batchTools, "batch-tools", "", "Comma-separated patterns of tools whose calls wait behind interactive requests")
+	cmd.Flags().BoolVar(&interleave, "interleave", false, "Send the requests of a session as they come instead of in order")
+	cmd.Flags().StringVar(&storeURL, "session-store", "", "Redis URL to share sessions with other bridges, e.g. redis://cache:6379/0")
+	cmd.Flags().BoolVar(&egressLog, "observe-egress", false, "Write the outbound connections of the servers to the audit log (Linux)")
+	cmd.Flags().BoolVar(&localOnly, "local-only", false, "Flag connections of the servers to other hosts as unexpected (implies --observe-egress)")
+	cmd.Flags().DurationVar(&drain, "drain-timeout", 30*time.Second, "How long requests in flight may take to finish on shutdown")
+	cmd.Flags().StringVar(&analytics.dest, "analytics-dest", "", "Export sampled, PII-scrubbed access logs to a directory, s3://bucket/prefix or gs://bucket/prefix")
+	cmd.Flags().Float64Var(&analytics.sample, "analytics-sample", 1, "Share of requests exported to --analytics-dest, between 0 and 1")
+	cmd.Flags().DurationVar(&analytics.rotate, "analytics-rotate", accesslog.DefaultRotateEvery, "How often exported access logs are rotated and shipped")
+	cmd.Flags().StringVar(&analytics.salt, "analytics-salt", "", "File holding the salt of user pseudonyms (default $HOME/.mcpt/analytics.salt)")
+	_ = cmd.MarkFlagRequired("keys")
+
+	return cmd
+}
+
+// observeEgress writes the outbound connections of servers to the audit log of the bridge until
+// ctx is done. With localOnly, connections to other hosts are flagged as unexpected.
+func observeEgress(ctx context.Context, b *bridge.Bridge, servers map[string]*bridge.Upstream, localOnly bool) {
+	if !egress.Supported() {
+		fmt.Fprintf(os.Stderr, "Warning: %v; egress is not observed\n", egress.ErrUnsupported)
+		return
+	}
+	for name, upstream := range servers {
+		go func() {
+			_ = egress.Watch(ctx, upstream.PID(), egress.DefaultInterval, func(dest egress.Destination) {
+				unexpected := localOnly && !dest.Local()
+				if unexpected {
+					fmt.Fprintf(os.Stderr, "Warning: local-only server %s connected to %s\n", name, dest)
+				}
+				b.LogEgress(name, dest.String(), unexpected)
+			})
+		}()
+	}
+}
+
+// shutdownUpstreams shuts down the servers behind the bridge at the same time, so slow ones do
+// not add up.
+func shutdownUpstreams(servers []*bridge.Upstream) {
+	var wg sync.WaitGroup
+	for _, upstream := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = upstream.Shutdown()
+		}()
+	}
+	wg.Wait()
+}
+
+// analyticsOptions configures the export of access logs for usage analytics.
+type analyticsOptions struct {
+	dest   string
+	salt   string
+	sample float64
+	rotate time.Duration
+}
+
+// exporter creates the exporter of access logs, reporting failures to ship them on stderr.
+func (o analyticsOptions) exporter() (*accesslog.Exporter, error) {
+	sink, err := accesslog.ParseSink(o.dest)
+	if err != nil {
+		return nil, err
+	}
+
+	saltPath := o.salt
+	if saltPath == "" {
+		if saltPath, err = accesslog.GetSaltPath(); err != nil {
+			return nil, err
+		}
+	}
+	salt, err := accesslog.LoadSalt(saltPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := anonymize.LoadRules("")
+	if err != nil {
+		return nil, err
+	}
+	anonymizer, err := anonymize.New(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	spoolDir, err := accesslog.GetSpoolDir()
+	if err != nil {
+		return nil, err
+	}
+	return accesslog.NewExporter(accesslog.Options{
+		Sink:        sink,
+		SpoolDir:    spoolDir,
+		SampleRate:  o.sample,
+		RotateEvery: o.rotate,
+		Scrubber:    &accesslog.Scrubber{Salt: salt, Anonymizer: anonymizer},
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "Warning: access log export: %v\n", err)
+		},
+	})
+}
+
+// analyticsRecorder returns the function passing the audit records of the bridge to exporter,
+// or nil without an exporter.
+func analyticsRecorder(exporter *accesslog.Exporter) func(bridge.AuditRecord) {
+	if exporter == nil {
+		return nil
+	}
+	return func(entry bridge.AuditRecord) {
+		exporter.Add(accesslog.Request{
+			Time:     entry.Time,
+			Tenant:   entry.Tenant,
+			User:     entry.User,
+			Method:   entry.Method,
+			Target:   entry.Target,
+			Upstream: entry.Upstream,
+			Status:   entry.Status,
+			Duration: time.Duration(entry.DurationMS) * time.Millisecond,
+		})
+	}
+}
+
+// splitPatterns splits a comma-separated list of patterns, dropping empty ones.
+func splitPatterns(list string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// startCanary starts the server of the alias a canary spec routes calls to.
+func startCanary(spec bridge.CanarySpec) (*bridge.Canary, error) {
+	serverCmd, found := alias.GetServerCommand(spec.Target)
+	if !found {
+		return nil, fmt.Errorf("canary alias %q does not exist", spec.Target)
+	}
+	command, commandArgs, err := serverCommand(ParseCommandString(serverCmd))
+	if err != nil {
+		return nil, fmt.Errorf("canary %s: %w", spec.Target, err)
+	}
+	upstream, err := bridge.StartUpstream(command, commandArgs)
+	if err != nil {
+		return nil, fmt.Errorf("canary %s: %w", spec.Target, err)
+	}
+	return &bridge.Canary{Name: spec.Target, Upstream: upstream, Percent: spec.Percent}, nil
+}
+
+// startShadow starts the server of the alias requests are mirrored to, with the log its
+// differing responses are written to.
+func startShadow(name, logPath string) (*bridge.Shadow, error) {
+	serverCmd, found := alias.GetServerCommand(name)
+	if !found {
+		return nil, fmt.Errorf("shadow alias %q does not exist", name)
+	}
+	command, commandArgs, err := serverCommand(ParseCommandString(serverCmd))
+	if err != nil {
+		return nil, fmt.Errorf("shadow %s: %w", name, err)
+	}
+
+	if logPath == "" {
+		if logPath, err = bridge.GetShadowLogPath(); err != nil {
+			return nil, err
+		}
+	}
+	// #nosec G304 - the log path is provided explicitly by the user
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shadow log: %w", err)
+	}
+
+	upstream, err := bridge.StartUpstream(command, commandArgs)
+	if err != nil {
+		_ = logFile.Close()
+		return nil, fmt.Errorf("shadow %s: %w", name, err)
+	}
+	return &bridge.Shadow{Name: name, Upstream: upstream, Log: logFile}, nil
+}
+
+// openAuditLog opens the chained audit log at path, or the default audit log if path is empty.
+// Records are signed with the key in keyPath if it is set.
+func openAuditLog(path, keyPath string) (*audit.File, error) {
+	if path == "" {
+		var err error
+		if path, err = bridge.GetAuditLogPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	var key []byte
+	if keyPath != "" {
+		var err error
+		if key, err = audit.LoadKey(keyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return audit.OpenFile(path, key)
+}
+
+// displayHTTPAddr turns a listen address such as ":8080" into one that can be connected to.
+func displayHTTPAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/f/mcptools/pkg/browse"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// BrowseCmd creates the browse command.
+func BrowseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "browse [command args...]",
+		Short: "Browse the resources of an MCP server as a tree",
+		Long: `Browse the resources of an MCP server as a tree built from their URIs, split into
+scheme, host and path segments.
+
+Keys:
+  ↑/↓ or k/j     move
+  → or l         expand a group, or preview a resource
+  Enter          expand or collapse a group, or preview a resource
+  ← or h         collapse a group, or go to its parent
+  c or y         copy the URI of the resource to the clipboard
+  q              quit
+
+The URI is copied with the OSC 52 escape sequence, which most terminals support, also over
+SSH. When the output is not a terminal, or in builds without the tui feature (see mcp version
+--features), the whole tree is printed instead.
+
+Example:
+  mcp browse npx -y @modelcontextprotocol/server-filesystem ~`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
+				_ = thisCmd.Help()
+				return
+			}
+
+			parsedArgs := ProcessFlags(args)
+
+			mcpClient, err := CreateClientFunc(parsedArgs)
+			if err != nil {
+				exitWithError(withHint(err, "Example: mcp browse npx -y @modelcontextprotocol/server-filesystem ~"))
+			}
+
+			resp, err := mcpClient.ListResources(context.Background(), mcp.ListResourcesRequest{})
+			if err != nil {
+				exitWithError(err)
+			}
+
+			resources := make([]browse.Resource, 0, len(resp.Resources))
+			for _, resource := range resp.Resources {
+				resources = append(resources, browse.Resource{
+					URI:         resource.URI,
+					Name:        resource.Name,
+					MimeType:    resource.MIMEType,
+					Description: resource.Description,
+				})
+			}
+			root := browse.BuildTree(resources)
+
+			inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+			if !tuiIncluded || !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+				browse.Print(thisCmd.OutOrStdout(), root)
+				return
+			}
+
+			read := func(uri string) (string, error) {
+				request := mcp.ReadResourceRequest{}
+				request.Params.URI = uri
+				result, readErr := mcpClient.ReadResource(context.Background(), request)
+				if readErr != nil {
+					return "", readErr
+				}
+				return resourceText(result.Contents), nil
+			}
+
+			if err := runBrowser(root, read); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+}
+
+// resourceText returns the text of resource contents for a preview, describing binary
+// contents instead of showing them.
+func resourceText(contents []mcp.ResourceContents) string {
+	var parts []string
+	for _, content := range contents {
+		switch c := content.(type) {
+		case mcp.TextResourceContents:
+			parts = append(parts, c.Text)
+		case mcp.BlobResourceContents:
+			mimeType := c.MIMEType
+			if mimeType == "" {
+				mimeType = "binary"
+			}
+			parts = append(parts, fmt.Sprintf("(%s content, %d bytes base64)", mimeType, len(c.Blob)))
+		}
+	}
+	if len(parts) == 0 {
+		return "(empty)"
+	}
+	return strings.Join(parts, "\n")
+}
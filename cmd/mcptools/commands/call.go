@@ -5,10 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/f/mcptools/pkg/aggregate"
+	"github.com/f/mcptools/pkg/notifier"
+	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 // parseCallArgs parses command line arguments for the call command.
@@ -20,6 +26,11 @@ func parseCallArgs(cmdArgs []string) (string, []string) {
 	entityExtracted := false
 
 	for i < len(cmdArgs) {
+		if n := processClientFlag(cmdArgs, i); n > 0 {
+			i += n
+			continue
+		}
+
 		switch {
 		case (cmdArgs[i] == FlagFormat || cmdArgs[i] == FlagFormatShort) && i+1 < len(cmdArgs):
 			FormatOption = cmdArgs[i+1]
@@ -36,6 +47,35 @@ func parseCallArgs(cmdArgs []string) (string, []string) {
 		case (cmdArgs[i] == FlagAuthHeader) && i+1 < len(cmdArgs):
 			AuthHeader = cmdArgs[i+1]
 			i += 2
+		case cmdArgs[i] == FlagFollowCursor && i+1 < len(cmdArgs):
+			FollowCursorOption = cmdArgs[i+1]
+			i += 2
+		case cmdArgs[i] == FlagAggregate && i+1 < len(cmdArgs):
+			AggregateOption = cmdArgs[i+1]
+			i += 2
+		case cmdArgs[i] == FlagMaxPages && i+1 < len(cmdArgs):
+			pages, err := strconv.Atoi(cmdArgs[i+1])
+			if err != nil || pages < 1 {
+				exitWithError(usageError(fmt.Sprintf("invalid %s %q: expected a positive number", FlagMaxPages, cmdArgs[i+1]), "Example: mcp call list_issues --follow-cursor result.nextCursor=params.cursor --max-pages 5 github"))
+			}
+			MaxPages = pages
+			i += 2
+		case cmdArgs[i] == FlagNotify:
+			NotifyOption = true
+			i++
+		case cmdArgs[i] == FlagBell:
+			BellOption = true
+			i++
+		case cmdArgs[i] == FlagNotifyAfter && i+1 < len(cmdArgs):
+			if after, err := time.ParseDuration(cmdArgs[i+1]); err == nil {
+				NotifyAfter = after
+			} else {
+				exitWithError(usageError(fmt.Sprintf("invalid %s %q: %v", FlagNotifyAfter, cmdArgs[i+1], err), "Example: mcp call --notify --notify-after 30s build npx -y my-server"))
+			}
+			i += 2
+		case cmdArgs[i] == FlagFix:
+			FixOption = true
+			i++
 		case !entityExtracted:
 			entityName = cmdArgs[i]
 			entityExtracted = true
@@ -54,9 +94,38 @@ func parseCallArgs(cmdArgs []string) (string, []string) {
 // CallCmd creates the call command.
 func CallCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:                "call entity [command args...]",
-		Short:              "Call a tool, resource, or prompt on the MCP server",
+		Use:   "call entity [command args...]",
+		Short: "Call a tool, resource, or prompt on the MCP server",
+		Long: `Call a tool, read a resource, or get a prompt on the MCP server.
+
+With --notify, a desktop notification is shown when a call that took at least 10s finishes,
+so you can switch away during slow operations; --bell rings the terminal bell instead or as
+well, and --notify-after sets the threshold.
+
+With --follow-cursor result.<path>=params.<name>, a tool that pages its results with its own
+cursor is called again with each cursor it returns, up to --max-pages pages (10 by default),
+and the pages are concatenated. The cursor is looked up in the result, its structured content,
+or JSON returned as text.
+
+Parameter values of the form @glob:<pattern> are expanded into arrays of what they match
+before the call, for tools that take lists of files: patterns with a scheme, such as
+file:///src/**/*.go, match the URIs of the server's resources, and other patterns match local
+files. ** matches any number of directories, and a pattern matching nothing is an error.
+
+With --fix, when the server rejects the parameters of a tool with a validation error, the
+error, the parameters and the tool's input schema are given to a language model, and the
+corrected parameters it proposes are shown, to call the tool with if you accept. The model is
+served by an OpenAI-compatible /chat/completions endpoint configured by MCPT_LLM_URL,
+MCPT_LLM_MODEL and MCPT_LLM_KEY (or OPENAI_API_KEY).
+
+Examples:
+  mcp call read_file --params '{"path": "README.md"}' npx -y @modelcontextprotocol/server-filesystem ~
+  mcp call --notify --notify-after 30s run_tests npx -y my-server
+  mcp call list_issues --follow-cursor result.nextCursor=params.cursor --max-pages 5 github
+  mcp call lint_files --params '{"paths": "@glob:src/**/*.go"}' linter
+  mcp call --fix create_event --params '{"start": "tomorrow 3pm"}' calendar`,
 		DisableFlagParsing: true,
+		ValidArgsFunction:  completeToolAndServer,
 		SilenceUsage:       true,
 		Run: func(thisCmd *cobra.Command, args []string) {
 			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
@@ -65,23 +134,13 @@ func CallCmd() *cobra.Command {
 			}
 
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: entity name is required")
-				fmt.Fprintln(
-					os.Stderr,
-					"Example: mcp call read_file npx -y @modelcontextprotocol/server-filesystem ~",
-				)
-				os.Exit(1)
+				exitWithError(usageError("entity name is required", "Example: mcp call read_file npx -y @modelcontextprotocol/server-filesystem ~"))
 			}
 
 			entityName, parsedArgs := parseCallArgs(args)
 
 			if entityName == "" {
-				fmt.Fprintln(os.Stderr, "Error: entity name is required")
-				fmt.Fprintln(
-					os.Stderr,
-					"Example: mcp call read_file npx -y @modelcontextprotocol/server-filesystem ~",
-				)
-				os.Exit(1)
+				exitWithError(usageError("entity name is required", "Example: mcp call read_file npx -y @modelcontextprotocol/server-filesystem ~"))
 			}
 
 			entityType := EntityTypeTool
@@ -92,27 +151,44 @@ func CallCmd() *cobra.Command {
 				entityName = parts[1]
 			}
 
-			if len(parsedArgs) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: command to execute is required when using stdio transport")
-				fmt.Fprintln(
-					os.Stderr,
-					"Example: mcp call read_file npx -y @modelcontextprotocol/server-filesystem ~",
-				)
-				os.Exit(1)
+			if len(parsedArgs) == 0 && DiscoverOption == "" {
+				exitWithError(usageError("command to execute is required when using stdio transport", "Example: mcp call read_file npx -y @modelcontextprotocol/server-filesystem ~"))
 			}
 
 			var params map[string]any
 			if ParamsString != "" {
 				if jsonErr := json.Unmarshal([]byte(ParamsString), &params); jsonErr != nil {
-					fmt.Fprintf(os.Stderr, "Error: invalid JSON for params: %v\n", jsonErr)
-					os.Exit(1)
+					exitWithError(usageError(fmt.Sprintf("invalid JSON for params: %v", jsonErr), ""))
 				}
 			}
 
+			var mode aggregate.Mode
+			if AggregateOption != "" {
+				if FollowCursorOption == "" {
+					exitWithError(usageError(FlagAggregate+" combines pages, so it needs "+FlagFollowCursor,
+						"Example: mcp call list_issues --follow-cursor result.nextCursor=params.cursor --aggregate concat:.issues github"))
+				}
+				var modeErr error
+				if mode, modeErr = aggregate.Parse(AggregateOption); modeErr != nil {
+					exitWithError(usageError(modeErr.Error(), ""))
+				}
+			}
+
+			var follow cursorFollow
+			if FollowCursorOption != "" {
+				if entityType != EntityTypeTool {
+					exitWithError(usageError(FlagFollowCursor+" only applies to tools", ""))
+				}
+				var followErr error
+				if follow, followErr = parseFollowCursor(FollowCursorOption); followErr != nil {
+					exitWithError(usageError(followErr.Error(), "Example: mcp call list_issues --follow-cursor result.nextCursor=params.cursor github"))
+				}
+			}
+
+			start := time.Now()
 			mcpClient, clientErr := CreateClientFunc(parsedArgs)
 			if clientErr != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", clientErr)
-				os.Exit(1)
+				exitWithError(clientErr)
 			}
 
 			var resp map[string]any
@@ -120,11 +196,41 @@ func CallCmd() *cobra.Command {
 
 			switch entityType {
 			case EntityTypeTool:
+				toolParams, globErr := expandGlobs(context.Background(), mcpClient, applyAliasDefaults(parsedArgs, entityName, params))
+				if globErr != nil {
+					exitWithError(withHint(globErr, "Patterns with a scheme, such as file:///src/*.go, match the server's resources; others match local files"))
+				}
+				if FollowCursorOption != "" {
+					var pages []map[string]any
+					pages, execErr = callToolPages(context.Background(), mcpClient, entityName, toolParams, follow, MaxPages)
+					notifyIfSlow(entityName, time.Since(start), execErr)
+					// Pages cut short by an error are printed as they are, with the error
+					if execErr == nil && AggregateOption != "" && pages[len(pages)-1]["isError"] != true {
+						documents := make([]any, len(pages))
+						for i, page := range pages {
+							documents[i] = resultDocument(page)
+						}
+						if aggregateErr := printAggregate(thisCmd, mode, documents); aggregateErr != nil {
+							exitWithError(aggregateErr)
+						}
+						return
+					}
+					resp = map[string]any{}
+					if execErr == nil {
+						resp = mergePages(pages)
+					}
+					if formatErr := FormatAndPrintResponse(thisCmd, resp, execErr); formatErr != nil {
+						exitWithError(formatErr)
+					}
+					return
+				}
 				var toolResponse *mcp.CallToolResult
-				request := mcp.CallToolRequest{}
-				request.Params.Name = entityName
-				request.Params.Arguments = params
-				toolResponse, execErr = mcpClient.CallTool(context.Background(), request)
+				if FixOption {
+					toolResponse, execErr = callToolWithFix(context.Background(), mcpClient, entityName, toolParams)
+				} else {
+					toolResponse, execErr = callTool(context.Background(), mcpClient, entityName, toolParams)
+				}
+				warnIfResultDeprecated(entityName, toolResponse)
 				if execErr == nil && toolResponse != nil {
 					resp = ConvertJSONToMap(toolResponse)
 				} else {
@@ -151,14 +257,44 @@ func CallCmd() *cobra.Command {
 					resp = map[string]any{}
 				}
 			default:
-				fmt.Fprintf(os.Stderr, "Error: unsupported entity type: %s\n", entityType)
-				os.Exit(1)
+				exitWithError(usageError(fmt.Sprintf("unsupported entity type: %s", entityType), ""))
 			}
 
+			notifyIfSlow(entityName, time.Since(start), execErr)
+
 			if formatErr := FormatAndPrintResponse(thisCmd, resp, execErr); formatErr != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
-				os.Exit(1)
+				exitWithError(formatErr)
 			}
 		},
 	}
 }
+
+// notifyIfSlow shows a desktop notification and rings the terminal bell, as requested, when a
+// call that took at least NotifyAfter finishes.
+func notifyIfSlow(entityName string, elapsed time.Duration, execErr error) {
+	if (!NotifyOption && !BellOption) || elapsed < NotifyAfter {
+		return
+	}
+
+	if BellOption && term.IsTerminal(int(os.Stderr.Fd())) {
+		fmt.Fprint(os.Stderr, "\a")
+	}
+	if NotifyOption {
+		title := fmt.Sprintf("mcp call %s finished", entityName)
+		message := fmt.Sprintf("Took %s", elapsed.Round(time.Second))
+		if execErr != nil {
+			title = fmt.Sprintf("mcp call %s failed", entityName)
+			message = fmt.Sprintf("%s after %s", execErr, elapsed.Round(time.Second))
+		}
+		if err := notifier.Desktop(title, message); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+func callTool(ctx context.Context, mcpClient *client.Client, tool string, params map[string]any) (*mcp.CallToolResult, error) {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = tool
+	request.Params.Arguments = params
+	return mcpClient.CallTool(ctx, request)
+}
@@ -2,8 +2,12 @@ package commands
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func TestCallCmdRun_Help(t *testing.T) {
@@ -96,6 +100,135 @@ func TestCallCmdRun_Resource(t *testing.T) {
 
 	// Verify output contains expected content
 	output := buf.String()
-	expectedOutput := `{"contents":[{"mimeType":"text/plain","text":"bar","uri":"test://foo"}]}`
+	expectedOutput := `{"apiVersion":"mcptools/v1","contents":[{"mimeType":"text/plain","text":"bar","uri":"test://foo"}],"kind":"CallResult"}`
 	assertContains(t, output, expectedOutput)
 }
+
+func TestParseCallArgs_Notify(t *testing.T) {
+	defer func() { NotifyOption, BellOption, NotifyAfter = false, false, 10*time.Second }()
+
+	entity, args := parseCallArgs([]string{"run_tests", "--notify", "--bell", "--notify-after", "30s", "server", "arg"})
+	if entity != "run_tests" || strings.Join(args, " ") != "server arg" {
+		t.Errorf("parseCallArgs() = %q, %v", entity, args)
+	}
+	if !NotifyOption || !BellOption || NotifyAfter != 30*time.Second {
+		t.Errorf("notify = %v, bell = %v, after = %s, want true, true, 30s", NotifyOption, BellOption, NotifyAfter)
+	}
+}
+
+func TestCallCmdRun_FollowCursor(t *testing.T) {
+	defer func() { FollowCursorOption, MaxPages, FormatOption = "", 10, "table" }()
+
+	var cursors []any
+	cleanup := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method != "tools/call" {
+			return map[string]any{}, nil
+		}
+		arguments, _ := params.(map[string]any)["arguments"].(map[string]any)
+		cursors = append(cursors, arguments["cursor"])
+
+		page := map[string]map[string]any{
+			"<nil>": {"items": []any{"a", "b"}, "nextCursor": "p2"},
+			"p2":    {"items": []any{"c"}, "nextCursor": "p3"},
+			"p3":    {"items": []any{"d"}, "nextCursor": ""},
+		}[fmt.Sprint(arguments["cursor"])]
+		return map[string]any{
+			"content":           []any{map[string]any{"type": "text", "text": fmt.Sprint(page["items"])}},
+			"structuredContent": page,
+		}, nil
+	})
+	defer cleanup()
+
+	cmd := CallCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"list", "--follow-cursor", "result.nextCursor=params.cursor", "--max-pages", "5", "-f", "json", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	if fmt.Sprint(cursors) != "[<nil> p2 p3]" {
+		t.Errorf("called with cursors %v, want none, p2 and p3", cursors)
+	}
+	output := buf.String()
+	if !strings.Contains(output, `"items":["a","b","c","d"]`) || strings.Count(output, `"type":"text"`) != 3 {
+		t.Errorf("output = %s, want the three pages concatenated", output)
+	}
+}
+
+func TestParseFollowCursor(t *testing.T) {
+	follow, err := parseFollowCursor("result.page.next=params.query.cursor")
+	if err != nil {
+		t.Fatalf("parseFollowCursor() error = %v", err)
+	}
+	params := follow.set(map[string]any{"query": map[string]any{"q": "x"}}, "c2")
+	if fmt.Sprint(params) != "map[query:map[cursor:c2 q:x]]" {
+		t.Errorf("set() = %v", params)
+	}
+
+	for _, invalid := range []string{"nextCursor=cursor", "result.nextCursor", "result.=params.cursor"} {
+		if _, err = parseFollowCursor(invalid); err == nil {
+			t.Errorf("parseFollowCursor(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestCallCmdRun_FollowCursorAggregate(t *testing.T) {
+	defer func() { FollowCursorOption, AggregateOption, FormatOption = "", "", "table" }()
+
+	// Pages returned as JSON text, as many tools do
+	cleanup := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method != "tools/call" {
+			return map[string]any{}, nil
+		}
+		arguments, _ := params.(map[string]any)["arguments"].(map[string]any)
+		text := `{"items": [1, 2], "next": "2"}`
+		if arguments["cursor"] == "2" {
+			text = `{"items": [3], "next": null}`
+		}
+		return map[string]any{"content": []any{map[string]any{"type": "text", "text": text}}}, nil
+	})
+	defer cleanup()
+
+	cmd := CallCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"list", "--follow-cursor", "result.next=params.cursor", "--aggregate", "concat:.items", "-f", "json", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	want := `{"aggregate":"concat:.items","apiVersion":"mcptools/v1","documents":2,"kind":"Aggregate","result":[1,2,3]}`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output = %s, want %s", buf.String(), want)
+	}
+}
+
+func TestCallCmdRun_Glob(t *testing.T) {
+	var arguments map[string]any
+	cleanup := setupMockClient(func(method string, params any) (map[string]any, error) {
+		switch method {
+		case "resources/list":
+			return map[string]any{"resources": []any{
+				map[string]any{"uri": "repo://src/main.go", "name": "main.go"},
+				map[string]any{"uri": "repo://README.md", "name": "README.md"},
+			}}, nil
+		case "tools/call":
+			arguments, _ = params.(mcp.CallToolParams).Arguments.(map[string]any)
+			return map[string]any{"content": []any{map[string]any{"type": "text", "text": "ok"}}}, nil
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanup()
+
+	cmd := CallCmd()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"lint_files", "--params", `{"paths": "@glob:repo://src/**/*.go"}`, "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	if fmt.Sprint(arguments["paths"]) != "[repo://src/main.go]" {
+		t.Errorf("called with paths %v, want the matching resource URIs", arguments["paths"])
+	}
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mark3labs/mcp-go/client"
+)
+
+// Features of a server, as named in the capabilities of its initialize result.
+const (
+	featureTools     = "tools"
+	featureResources = "resources"
+	featurePrompts   = "prompts"
+)
+
+// codeMethodNotFound is the JSON-RPC error code of methods the server does not support.
+const codeMethodNotFound = -32601
+
+// declares reports whether the server of mcpClient declared feature when it was initialized.
+func declares(mcpClient *client.Client, feature string) bool {
+	capabilities := mcpClient.GetServerCapabilities()
+	switch feature {
+	case featureTools:
+		return capabilities.Tools != nil
+	case featureResources:
+		return capabilities.Resources != nil
+	case featurePrompts:
+		return capabilities.Prompts != nil
+	}
+	return false
+}
+
+// unsupported returns an error saying the server does not support feature if err is the
+// method-not-found error of a server that did not declare it, and nil otherwise. Servers that
+// work without declaring a feature are still used.
+func unsupported(mcpClient *client.Client, feature string, err error) error {
+	if !methodNotFound(err) || declares(mcpClient, feature) {
+		return nil
+	}
+	return withHint(fmt.Errorf("server does not declare the %s capability: %w", feature, err),
+		"Check what the server offers with mcp tools, mcp resources and mcp prompts")
+}
+
+// methodNotFound reports whether err is the error of a server that does not know the method.
+func methodNotFound(err error) bool {
+	rpcErr := rpcErrorOf(err)
+	return rpcErr != nil && rpcErr.Code == codeMethodNotFound
+}
+
+// warnUnsupported warns on stderr that the server has no entities of a feature it does not
+// declare.
+func warnUnsupported(feature, entities string) {
+	fmt.Fprintf(os.Stderr, "Warning: server does not declare the %s capability, so it has no %s\n", feature, entities)
+}
+
+// degradeList turns the error of listing feature into an empty list, with a warning on stderr,
+// when the server does not support it. Other errors are returned as they are.
+func degradeList(mcpClient *client.Client, feature string, err error) error {
+	if unsupportedErr := unsupported(mcpClient, feature, err); unsupportedErr != nil {
+		warnUnsupported(feature, feature)
+		return nil
+	}
+	return err
+}
+
+// checkSupported returns the error of using feature, explaining it when the server does not
+// support the feature.
+func checkSupported(mcpClient *client.Client, feature string, err error) error {
+	if unsupportedErr := unsupported(mcpClient, feature, err); unsupportedErr != nil {
+		return unsupportedErr
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/protocol"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// unsupportedTransport initializes with the given capabilities and answers every other request
+// with a method-not-found error.
+type unsupportedTransport struct {
+	MockTransport
+	capabilities string
+}
+
+func (t *unsupportedTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	response := &transport.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: request.ID}
+	if request.Method == "initialize" {
+		response.Result = json.RawMessage(`{"protocolVersion":"2025-03-26","capabilities":` + t.capabilities + `,"serverInfo":{"name":"test","version":"1"}}`)
+		return response, nil
+	}
+	response.Error = &struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}{Code: codeMethodNotFound, Message: request.Method + " not supported"}
+	return response, nil
+}
+
+func newUnsupportedClient(t *testing.T, capabilities string) *client.Client {
+	t.Helper()
+	mcpClient := client.NewClient(protocol.NewErrorTransport(&unsupportedTransport{capabilities: capabilities}, rpcErrors))
+	if _, err := mcpClient.Initialize(context.Background(), mcp.InitializeRequest{}); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	return mcpClient
+}
+
+func TestUndeclaredCapabilities(t *testing.T) {
+	mcpClient := newUnsupportedClient(t, `{"tools":{}}`)
+
+	_, err := mcpClient.ListResources(context.Background(), mcp.ListResourcesRequest{})
+	if err == nil {
+		t.Fatal("ListResources() succeeded, want method not found")
+	}
+	if degraded := degradeList(mcpClient, featureResources, err); degraded != nil {
+		t.Errorf("degradeList() = %v, want an empty list", degraded)
+	}
+
+	_, err = mcpClient.GetPrompt(context.Background(), mcp.GetPromptRequest{})
+	err = checkSupported(mcpClient, featurePrompts, err)
+	if err == nil || !strings.Contains(err.Error(), "server does not declare the prompts capability") {
+		t.Errorf("checkSupported() = %v, want the missing capability explained", err)
+	}
+	if report := NewErrorReport(err); report.Code != ErrorCodeRPC || report.RPC.Code != codeMethodNotFound {
+		t.Errorf("NewErrorReport() = %+v, want the JSON-RPC error kept", report)
+	}
+}
+
+func TestDeclaredCapabilityErrorsKept(t *testing.T) {
+	// A server declaring the capability but failing the method has a real problem to report
+	mcpClient := newUnsupportedClient(t, `{"resources":{}}`)
+	_, err := mcpClient.ListResources(context.Background(), mcp.ListResourcesRequest{})
+	if degraded := degradeList(mcpClient, featureResources, err); degraded == nil {
+		t.Error("degradeList() dropped the error of a declared capability")
+	}
+}
@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/configfile"
+	"github.com/spf13/cobra"
+)
+
+// configFiles are the base names of the configuration files in $HOME/.mcpt that can be kept in
+// JSON, YAML or TOML.
+var configFiles = []string{"aliases", "registry"}
+
+// ConfigCmd creates the config command.
+func ConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the mcptools configuration files",
+		Long: `Manage the configuration files of mcptools in $HOME/.mcpt: aliases (aliases.json) and the
+alias registry (registry.json).
+
+Each of them can be kept in JSON, YAML or TOML, so they fit the tooling of your dotfiles:
+aliases.yaml or aliases.toml are read like aliases.json. Only one format of each file may exist
+at a time. Comments in YAML files are kept when mcp changes them.
+
+Examples:
+  mcp config migrate --to yaml
+  mcp config migrate --to toml --dry-run`,
+	}
+
+	cmd.AddCommand(configMigrateCmd())
+	return cmd
+}
+
+func configMigrateCmd() *cobra.Command {
+	var to string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate --to json|yaml|toml",
+		Short: "Convert the configuration files to another format",
+		Long: `Convert the configuration files in $HOME/.mcpt to JSON, YAML or TOML.
+
+Each converted file is checked to read back the same before it replaces the original, which is
+kept with a .bak extension. Comments of YAML and TOML files are carried over when converting to
+YAML; JSON has no comments, and converting to TOML drops them with a warning.
+
+Examples:
+  mcp config migrate --to yaml
+  mcp config migrate --to toml --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			format, err := configfile.ParseFormat(to)
+			if err != nil {
+				return err
+			}
+			aliasesPath, err := alias.GetConfigPath()
+			if err != nil {
+				return err
+			}
+			dir := filepath.Dir(aliasesPath)
+
+			migrated := 0
+			for _, base := range configFiles {
+				path, findErr := configfile.Find(dir, base)
+				if findErr != nil {
+					return findErr
+				}
+				if _, statErr := os.Stat(path); errors.Is(statErr, os.ErrNotExist) {
+					continue
+				}
+				if configfile.FormatOf(path) == format {
+					fmt.Fprintf(thisCmd.OutOrStdout(), "%s is already in %s.\n", filepath.Base(path), format)
+					continue
+				}
+
+				target := configfile.Path(dir, base, format)
+				converted, dropped, convertErr := convertConfigFile(path, format)
+				if convertErr != nil {
+					return convertErr
+				}
+				if dropped {
+					fmt.Fprintf(os.Stderr, "Warning: the comments of %s cannot be kept in %s\n", filepath.Base(path), format)
+				}
+
+				if dryRun {
+					fmt.Fprintf(thisCmd.OutOrStdout(), "--- %s\n%s\n", filepath.Base(target), converted)
+					continue
+				}
+				if writeErr := os.WriteFile(target, converted, 0o600); writeErr != nil {
+					return fmt.Errorf("failed to write %s: %w", target, writeErr)
+				}
+				if renameErr := os.Rename(path, path+".bak"); renameErr != nil {
+					_ = os.Remove(target)
+					return fmt.Errorf("failed to back up %s: %w", path, renameErr)
+				}
+				fmt.Fprintf(thisCmd.OutOrStdout(), "Converted %s to %s; the original is kept as %s.bak.\n",
+					filepath.Base(path), filepath.Base(target), filepath.Base(path))
+				migrated++
+			}
+
+			if migrated == 0 && !dryRun {
+				fmt.Fprintln(thisCmd.OutOrStdout(), "No configuration file to convert.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "Format to convert to: json, yaml or toml")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the converted files instead of writing them")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+// convertConfigFile converts the configuration file at path to format, and checks that the
+// result reads back the same. It reports whether comments were dropped.
+func convertConfigFile(path string, format configfile.Format) ([]byte, bool, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is a configuration file in $HOME/.mcpt
+	if err != nil {
+		return nil, false, err
+	}
+	from := configfile.FormatOf(path)
+	converted, dropped, err := configfile.Convert(data, from, format)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to convert %s: %w", filepath.Base(path), err)
+	}
+
+	var before, after any
+	if err = configfile.Unmarshal(data, from, &before); err != nil {
+		return nil, false, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	if err = configfile.Unmarshal(converted, format, &after); err != nil || !reflect.DeepEqual(before, after) {
+		return nil, false, fmt.Errorf("%s does not convert to %s without changes; convert it by hand", filepath.Base(path), format)
+	}
+	return converted, dropped, nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/alias"
+)
+
+func TestConfigMigrate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".mcpt")
+
+	if err := alias.Save(alias.Aliases{"fs": {Command: "fs-server", Race: true, Mirrors: []string{"https://fs.example.com/mcp"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	migrate := func(args ...string) (string, error) {
+		cmd := configMigrateCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := migrate("--to", "ini"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+
+	out, err := migrate("--to", "toml", "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "--- aliases.toml") || !strings.Contains(out, "command = 'fs-server'") {
+		t.Errorf("dry run output = %q", out)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "aliases.toml")); err == nil {
+		t.Error("dry run wrote aliases.toml")
+	}
+
+	if _, err = migrate("--to", "yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "aliases.json.bak")); err != nil {
+		t.Errorf("the original should be kept: %v", err)
+	}
+
+	// Comments written by hand survive changes made by mcp
+	yamlPath := filepath.Join(dir, "aliases.yaml")
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(yamlPath, append([]byte("# Team servers\n"), data...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	aliases, err := alias.Load()
+	if err != nil || aliases["fs"].Command != "fs-server" || !aliases["fs"].Race {
+		t.Fatalf("Load() = %+v, %v", aliases, err)
+	}
+	aliases["gh"] = alias.ServerAlias{Command: "gh-server"}
+	if err = alias.Save(aliases); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ = os.ReadFile(yamlPath); !strings.HasPrefix(string(data), "# Team servers\n") {
+		t.Errorf("aliases.yaml = %q, want the comment kept", data)
+	}
+
+	if out, err = migrate("--to", "yml"); err != nil || !strings.Contains(out, "already in yaml") {
+		t.Errorf("migrate to yml = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/f/mcptools/pkg/consent"
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/spf13/cobra"
+)
+
+// openConsents returns the store of remembered consents, replaced in tests.
+var openConsents = consent.Open
+
+// ConsentCmd creates the consent command.
+func ConsentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "consent",
+		Short: "Manage the remembered answers to guard confirmations",
+		Long: `Manage the consents remembered when answering always or never to a confirmation of mcp guard,
+such as "always allow read_file on fs" or "never allow exec". The guard applies them instead of
+asking again: entities always allowed pass, and entities never allowed are blocked, even
+without a confirm pattern.
+
+Consents are kept in the keychain (see mcp keychain), per server: by alias for servers given as
+one, and by command line otherwise. Consents for the server * apply to every server.
+
+Examples:
+  mcp consent list
+  mcp consent reset exec
+  mcp consent reset --server fs
+  mcp consent reset`,
+	}
+
+	cmd.AddCommand(consentListCmd())
+	cmd.AddCommand(consentResetCmd())
+	return cmd
+}
+
+func consentListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "list",
+		Short:        "List the remembered consents",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			store, err := openConsents()
+			if err != nil {
+				return err
+			}
+			consents, err := store.List()
+			if err != nil {
+				return err
+			}
+
+			if jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+				output, formatErr := jsonutils.Format(ConvertJSONToSlice(consents), FormatOption)
+				if formatErr != nil {
+					return formatErr
+				}
+				fmt.Fprintln(thisCmd.OutOrStdout(), output)
+				return nil
+			}
+			printConsents(thisCmd.OutOrStdout(), consents)
+			return nil
+		},
+	}
+}
+
+func consentResetCmd() *cobra.Command {
+	var server string
+
+	cmd := &cobra.Command{
+		Use:   "reset [--server name] [name]",
+		Short: "Forget remembered consents, all of them or those of a server or entity",
+		Long: `Forget remembered consents, so the guard asks again. Without arguments every consent is
+forgotten; a name forgets those for the tool, prompt or resource of that name, and --server those
+for a server.
+
+Examples:
+  mcp consent reset exec
+  mcp consent reset --server fs read_file
+  mcp consent reset`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+
+			store, err := openConsents()
+			if err != nil {
+				return err
+			}
+			forgotten, err := store.Reset(server, name)
+			if err != nil {
+				return err
+			}
+			if forgotten == 0 && (server != "" || name != "") {
+				return withHint(errors.New("no consent matches"), "List the remembered consents with: mcp consent list")
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Forgot %d consent(s)\n", forgotten)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "", "Only forget the consents for this server, by alias or command line")
+	return cmd
+}
+
+// printConsents writes the remembered consents as a table.
+func printConsents(w io.Writer, consents []consent.Consent) {
+	if len(consents) == 0 {
+		fmt.Fprintln(w, "No consents remembered")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVER\tENTITY\tNAME\tDECISION\tSINCE")
+	for _, c := range consents {
+		decision := "always allow"
+		if c.Decision == consent.Deny {
+			decision = "never allow"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Server, c.Entity, c.Name, decision, c.Time.Local().Format(time.DateTime))
+	}
+	_ = tw.Flush()
+}
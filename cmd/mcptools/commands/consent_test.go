@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/consent"
+	"github.com/f/mcptools/pkg/keychain"
+)
+
+// memoryKeychain keeps secrets in a map.
+type memoryKeychain map[string]string
+
+func (m memoryKeychain) Get(name string) (string, error) {
+	secret, ok := m[name]
+	if !ok {
+		return "", keychain.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (m memoryKeychain) Set(name, secret string) error {
+	m[name] = secret
+	return nil
+}
+
+func (m memoryKeychain) Delete(name string) error {
+	delete(m, name)
+	return nil
+}
+
+func (m memoryKeychain) Backend() string { return "memory" }
+
+func TestConsentCommands(t *testing.T) {
+	store := consent.New(memoryKeychain{})
+	original := openConsents
+	openConsents = func() (*consent.Store, error) { return store, nil }
+	defer func() { openConsents = original }()
+	originalFormat := FormatOption
+	FormatOption = "table"
+	defer func() { FormatOption = originalFormat }()
+
+	for _, c := range []consent.Consent{
+		{Server: "fs", Entity: "tool", Name: "read_file", Decision: consent.Allow},
+		{Server: "shell", Entity: "tool", Name: "exec", Decision: consent.Deny},
+	} {
+		if err := store.Remember(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(args ...string) (string, error) {
+		cmd := ConsentCmd()
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+
+	output, err := run("list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "always allow") || !strings.Contains(lines[2], "never allow") {
+		t.Errorf("list printed:\n%s", output)
+	}
+
+	if _, err = run("reset", "--server", "fs", "exec"); err == nil {
+		t.Error("expected an error when no consent matches")
+	}
+	if output, err = run("reset", "--server", "shell"); err != nil || !strings.Contains(output, "Forgot 1") {
+		t.Errorf("reset --server shell = %q, %v", output, err)
+	}
+	if consents, _ := store.List(); len(consents) != 1 || consents[0].Name != "read_file" {
+		t.Errorf("consents after reset = %+v, want read_file kept", consents)
+	}
+}
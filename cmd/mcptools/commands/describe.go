@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/spf13/cobra"
+)
+
+// Lazy schemas are an experimental capability of gateways with many tools: tools/list returns
+// stubs without input schemas, and tools/describe returns the full definition of one tool.
+const (
+	capabilityLazySchemas = "lazySchemas"
+	methodToolsDescribe   = "tools/describe"
+)
+
+// DescribeCmd creates the describe command.
+func DescribeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "describe tool [command args...]",
+		Short: "Show the full definition of a tool",
+		Long: `Show the full definition of a tool, including its input schema.
+
+Servers that support the experimental lazySchemas capability are asked for the one tool with
+tools/describe. List their tools with --lazy-schemas to get lightweight stubs, and describe the
+tools you need. For other servers, the tool is looked up in the tools list.
+
+Examples:
+  mcp describe read_file npx -y @modelcontextprotocol/server-filesystem ~
+  mcp describe github_create_issue -f json https://gateway.example.com/mcp`,
+		DisableFlagParsing: true,
+		ValidArgsFunction:  completeToolAndServer,
+		SilenceUsage:       true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
+				_ = thisCmd.Help()
+				return
+			}
+
+			parsedArgs := ProcessFlags(args)
+			if len(parsedArgs) == 0 || (len(parsedArgs) < 2 && DiscoverOption == "") {
+				exitWithError(usageError("a tool name and a server command or URL are required", "Example: mcp describe read_file npx -y @modelcontextprotocol/server-filesystem ~"))
+			}
+
+			mcpClient, err := CreateClientFunc(parsedArgs[1:])
+			if err != nil {
+				exitWithError(err)
+			}
+
+			tool, describeErr := describeTool(context.Background(), mcpClient, parsedArgs[0])
+			var resp map[string]any
+			if describeErr == nil {
+				resp = map[string]any{"tools": warnDeprecatedTools([]any{tool}, false)}
+				translateDescriptions(resp)
+			}
+			if formatErr := FormatAndPrintResponse(thisCmd, resp, describeErr); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+}
+
+// describeTool returns the full definition of a tool as a generic map, asking for it alone if
+// the server supports lazy schemas.
+func describeTool(ctx context.Context, mcpClient *client.Client, name string) (any, error) {
+	if _, lazy := mcpClient.GetServerCapabilities().Experimental[capabilityLazySchemas]; lazy {
+		raw, err := sendRawRequest(ctx, mcpClient, methodToolsDescribe, map[string]any{"name": name})
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Tool map[string]any `json:"tool"`
+		}
+		if err = json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse tool description: %w", err)
+		}
+		if result.Tool == nil {
+			return nil, fmt.Errorf("tool %q not found", name)
+		}
+		return result.Tool, nil
+	}
+
+	tools, err := listToolsRaw(ctx, mcpClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, tool := range tools {
+		if toolName(tool) == name {
+			return tool, nil
+		}
+	}
+	return nil, fmt.Errorf("tool %q not found", name)
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// lazyTransport is a mock transport of a server declaring the lazySchemas capability.
+type lazyTransport struct {
+	*MockTransport
+}
+
+func (l lazyTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if request.Method == "initialize" {
+		return &transport.JSONRPCResponse{
+			Result: json.RawMessage(`{"protocolVersion":"2024-11-05","capabilities":{"experimental":{"lazySchemas":{}}}}`),
+		}, nil
+	}
+	return l.MockTransport.SendRequest(ctx, request)
+}
+
+func TestDescribeCmdRun_LazySchemas(t *testing.T) {
+	origFormatOption := FormatOption
+	defer func() { FormatOption = origFormatOption }()
+
+	var methods []string
+	mock := &MockTransport{ExecuteFunc: func(method string, params any) (map[string]any, error) {
+		methods = append(methods, method)
+		if p, _ := params.(map[string]any); p["name"] != "read_file" {
+			t.Errorf("unexpected params %v", params)
+		}
+		return map[string]any{"tool": map[string]any{
+			"name":        "read_file",
+			"description": "Read a file",
+			"inputSchema": map[string]any{"type": "object", "properties": map[string]any{"path": map[string]any{"type": "string"}}},
+		}}, nil
+	}}
+	mockClient := client.NewClient(lazyTransport{mock})
+	if _, err := mockClient.Initialize(context.Background(), mcp.InitializeRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	originalFunc := CreateClientFunc
+	CreateClientFunc = func(_ []string, _ ...client.ClientOption) (*client.Client, error) { return mockClient, nil }
+	defer func() { CreateClientFunc = originalFunc }()
+
+	cmd := DescribeCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"read_file", "-f", "json", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	assertContains(t, buf.String(), `"path":{"type":"string"}`)
+	if len(methods) != 1 || methods[0] != methodToolsDescribe {
+		t.Errorf("expected a single %s request, got %q", methodToolsDescribe, methods)
+	}
+}
+
+func TestDescribeCmdRun_FallsBackToList(t *testing.T) {
+	origFormatOption := FormatOption
+	defer func() { FormatOption = origFormatOption }()
+
+	cleanup := setupMockClient(func(method string, _ any) (map[string]any, error) {
+		if method != "tools/list" {
+			t.Errorf("Expected method 'tools/list', got %q", method)
+		}
+		return map[string]any{"tools": []any{
+			map[string]any{"name": "list_dir", "description": "List a directory"},
+			map[string]any{"name": "read_file", "description": "Read a file"},
+		}}, nil
+	})
+	defer cleanup()
+
+	cmd := DescribeCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"read_file", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	output := buf.String()
+	assertContains(t, output, "Read a file")
+	if bytes.Contains(buf.Bytes(), []byte("list_dir")) {
+		t.Errorf("unexpected tool in output: %s", output)
+	}
+}
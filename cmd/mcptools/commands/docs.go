@@ -0,0 +1,382 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/doccache"
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// Flags of mcp docs: --offline reads the cache without connecting, and --max-stale refuses
+// cached documentation older than a duration.
+const (
+	FlagOffline  = "--offline"
+	FlagMaxStale = "--max-stale"
+)
+
+// docsRefreshInterval is how old the cached documentation of an alias gets before a connection
+// to it refreshes it.
+const docsRefreshInterval = 10 * time.Minute
+
+// DocsCmd creates the docs command.
+func DocsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "docs [--offline] [--max-stale duration] [alias | command args...]",
+		Short: "Show the documentation of a server, from a local cache when it is unreachable",
+		Long: `Show the documentation of a server: its tools with their parameters, its prompts and its
+resources.
+
+Once caching is enabled with mcp docs enable, every connection to an alias keeps a copy of the
+documentation of its server in $HOME/.mcpt/docs, refreshed when it is older than 10 minutes.
+mcp docs falls back to that copy when the server cannot be reached, and reads it without
+connecting with --offline. Shell completion of mcp call, describe and docs completes tool names
+and aliases from it, so they stay usable without a network.
+
+Cached documentation is shown with its age: how long ago it was last known to be current. With
+--max-stale, copies older than the duration are refused rather than shown. Servers that return
+an etag in the _meta of their listings are sent it back as ifNoneMatch when the cache is
+refreshed, and can answer notModified instead of listing everything again.
+
+Examples:
+  # Keep the documentation of aliases for offline use
+  mcp docs enable
+
+  # Show the documentation of an alias, cached or not
+  mcp docs fs
+  mcp docs --offline fs
+  mcp docs --offline --max-stale 1h fs
+
+  # Show the documentation of any server
+  mcp docs npx -y @modelcontextprotocol/server-filesystem ~
+
+  # Stop caching; the cached documentation is kept
+  mcp docs disable`,
+		Args:               cobra.ArbitraryArgs,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		ValidArgsFunction:  completeCachedServer,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
+				_ = thisCmd.Help()
+				return
+			}
+
+			const example = "Example: mcp docs --offline fs"
+
+			offline := false
+			var maxStale time.Duration
+			var rest []string
+			for i := 0; i < len(args); i++ {
+				switch {
+				case args[i] == FlagOffline:
+					offline = true
+				case args[i] == FlagMaxStale && i+1 < len(args):
+					var err error
+					if maxStale, err = time.ParseDuration(args[i+1]); err != nil || maxStale <= 0 {
+						exitWithError(usageError(fmt.Sprintf("invalid --max-stale %q: expected a duration such as 30m or 1h", args[i+1]), example))
+					}
+					i++
+				default:
+					rest = append(rest, args[i])
+				}
+			}
+			parsedArgs := ProcessFlags(rest)
+			if len(parsedArgs) == 0 {
+				exitWithError(usageError("an alias or a server command is required", example))
+			}
+			server := strings.Join(parsedArgs, " ")
+
+			cached, cacheErr := loadCachedDocs(server, maxStale)
+			if offline {
+				if cacheErr != nil {
+					exitWithError(cacheErr)
+				}
+				printDocs(thisCmd, cached, true)
+				return
+			}
+
+			mcpClient, err := CreateClientFunc(parsedArgs)
+			if err != nil {
+				if cacheErr != nil {
+					exitWithError(withHint(err, example))
+				}
+				fmt.Fprintf(os.Stderr, "Warning: %s is unreachable (%v), showing its cached documentation\n", server, err)
+				printDocs(thisCmd, cached, true)
+				return
+			}
+			defer func() { _ = mcpClient.Close() }()
+
+			// Any cached copy, however old, lets the server answer that nothing changed
+			previous, _ := doccache.Load(server)
+			docs, err := fetchDocs(context.Background(), server, mcpClient, previous)
+			if err != nil {
+				exitWithError(err)
+			}
+			if _, isAlias := alias.Get(server); isAlias && doccache.Enabled() {
+				if saveErr := doccache.Save(docs); saveErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to cache the documentation of %s: %v\n", server, saveErr)
+				}
+			}
+			printDocs(thisCmd, docs, false)
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "enable",
+		Short: "Cache the documentation of aliases on every connection",
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			if err := doccache.Enable(); err != nil {
+				return err
+			}
+			fmt.Fprintln(thisCmd.OutOrStdout(), "Documentation caching enabled")
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "disable",
+		Short: "Stop caching the documentation of aliases",
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			if err := doccache.Disable(); err != nil {
+				return err
+			}
+			fmt.Fprintln(thisCmd.OutOrStdout(), "Documentation caching disabled")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// loadCachedDocs returns the cached documentation of server, refusing it if it is older than
+// maxStale, when set.
+func loadCachedDocs(server string, maxStale time.Duration) (doccache.Docs, error) {
+	docs, err := doccache.Load(server)
+	if err != nil {
+		return doccache.Docs{}, withHint(err, "Enable caching with mcp docs enable, then connect to the alias once")
+	}
+	if age := docs.Age(time.Now()); maxStale > 0 && age > maxStale {
+		return doccache.Docs{}, withHint(
+			fmt.Errorf("the cached documentation of %s is %s old, over --max-stale %s", server, jsonutils.FormatDuration(age), maxStale),
+			fmt.Sprintf("Refresh it by connecting once: mcp docs %s", server))
+	}
+	return docs, nil
+}
+
+// printDocs prints the documentation of a server, a section per listing in table format. The
+// age of cached documentation is printed with it, as ageMs in JSON.
+func printDocs(thisCmd *cobra.Command, docs doccache.Docs, cached bool) {
+	if jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+		resp := ConvertJSONToMap(docs)
+		delete(resp, "etags")
+		if cached {
+			resp["ageMs"] = docs.Age(time.Now()).Milliseconds()
+		}
+		if err := FormatAndPrintResponse(thisCmd, resp, nil); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	w := thisCmd.OutOrStdout()
+	title := docs.Server
+	if docs.Name != "" {
+		title = strings.TrimSpace(fmt.Sprintf("%s: %s %s", docs.Server, docs.Name, docs.Version))
+	}
+	if cached {
+		title += ", cached " + jsonutils.FormatTimestamp(docs.UpdatedAt, time.Now())
+	}
+	fmt.Fprintln(w, title)
+	if docs.Instructions != "" {
+		fmt.Fprintf(w, "\n%s\n", docs.Instructions)
+	}
+
+	for _, section := range []struct {
+		title, key string
+		items      []any
+	}{
+		{"Tools", "tools", docs.Tools},
+		{"Prompts", "prompts", docs.Prompts},
+		{"Resources", "resources", docs.Resources},
+	} {
+		if len(section.items) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		if err := FormatAndPrintResponse(thisCmd, map[string]any{section.key: section.items}, nil); err != nil {
+			exitWithError(err)
+		}
+	}
+}
+
+// fetchDocs lists the tools, prompts and resources of the server mcpClient is connected to,
+// revalidating the listings of previous, its cached documentation if any. Prompts and resources
+// are left out if the server does not list them, and so are tools if it does not declare them.
+func fetchDocs(ctx context.Context, server string, mcpClient *client.Client, previous doccache.Docs) (doccache.Docs, error) {
+	docs := doccache.Docs{Server: server, UpdatedAt: time.Now().UTC()}
+	if result := serverInitializeResult(mcpClient); result != nil {
+		docs.Name, docs.Version = result.ServerInfo.Name, result.ServerInfo.Version
+		docs.Instructions = result.Instructions
+	}
+
+	for _, listing := range []struct {
+		method   mcp.MCPMethod
+		key      string
+		items    *[]any
+		cached   []any
+		required bool
+	}{
+		{mcp.MethodToolsList, "tools", &docs.Tools, previous.Tools, mcpClient.GetServerCapabilities().Tools != nil},
+		{mcp.MethodPromptsList, "prompts", &docs.Prompts, previous.Prompts, false},
+		{mcp.MethodResourcesList, "resources", &docs.Resources, previous.Resources, false},
+	} {
+		method := string(listing.method)
+		items, etag, notModified, err := listRevalidated(ctx, mcpClient, method, listing.key, previous.ETags[method])
+		switch {
+		case err != nil && listing.required:
+			return doccache.Docs{}, fmt.Errorf("failed to list %s: %w", listing.key, err)
+		case err != nil:
+			continue
+		case notModified:
+			items, etag = listing.cached, previous.ETags[method]
+		}
+		*listing.items = items
+		if etag != "" {
+			if docs.ETags == nil {
+				docs.ETags = map[string]string{}
+			}
+			docs.ETags[method] = etag
+		}
+	}
+	return docs, nil
+}
+
+// listRevalidated lists the items of a list method, under key in its results, following
+// pagination cursors. A non-empty etag from an earlier listing is sent as ifNoneMatch in the
+// _meta of the request, and servers supporting revalidation answer with notModified set in the
+// _meta of their result instead of listing the items again. The etag of the listing is returned
+// as the server sent it in the _meta of the first page.
+func listRevalidated(ctx context.Context, mcpClient *client.Client, method, key, etag string) ([]any, string, bool, error) {
+	var items []any
+	var newETag string
+	cursor := ""
+
+	for first := true; ; first = false {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		if first && etag != "" {
+			params["_meta"] = map[string]any{"ifNoneMatch": etag}
+		}
+
+		raw, err := sendRawRequest(ctx, mcpClient, method, params)
+		if err != nil {
+			return nil, "", false, err
+		}
+
+		var page map[string]any
+		if err = json.Unmarshal(raw, &page); err != nil {
+			return nil, "", false, fmt.Errorf("failed to parse %s result: %w", method, err)
+		}
+		if first {
+			meta, _ := page["_meta"].(map[string]any)
+			if notModified, _ := meta["notModified"].(bool); notModified && etag != "" {
+				return nil, etag, true, nil
+			}
+			newETag, _ = meta["etag"].(string)
+		}
+
+		pageItems, _ := page[key].([]any)
+		items = append(items, pageItems...)
+		next, _ := page["nextCursor"].(string)
+		if next == "" || next == cursor {
+			return items, newETag, false, nil
+		}
+		cursor = next
+	}
+}
+
+// refreshDocsCache caches the documentation of the server of an alias, once mcpClient is
+// connected to it, if caching is enabled and the cached copy is older than docsRefreshInterval.
+// Failures leave the cache as it was.
+func refreshDocsCache(name string, mcpClient *client.Client) {
+	if !doccache.Enabled() {
+		return
+	}
+	previous, err := doccache.Load(name)
+	if err == nil && previous.Age(time.Now()) < docsRefreshInterval {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if docs, fetchErr := fetchDocs(ctx, name, mcpClient, previous); fetchErr == nil {
+		_ = doccache.Save(docs)
+	}
+}
+
+// completionArgs returns the positional arguments of a command line being completed, leaving
+// out flags and the values of the common flags taking one.
+func completionArgs(args []string) []string {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case slices.Contains([]string{FlagParams, FlagParamsShort, FlagFormat, FlagFormatShort, FlagTransport, FlagOutput, FlagOutputShort}, arg):
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return positional
+}
+
+// completeCachedServer completes the first argument with the servers of the documentation cache.
+func completeCachedServer(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(completionArgs(args)) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	servers, _ := doccache.Servers()
+	return servers, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeToolAndServer completes commands taking a tool then a server, such as mcp call, from
+// the documentation cache: first with the tools of every cached server, then with the cached
+// servers offering the tool.
+func completeToolAndServer(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	positional := completionArgs(args)
+	if len(positional) > 1 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	servers, _ := doccache.Servers()
+	var completions []string
+	for _, server := range servers {
+		docs, err := doccache.Load(server)
+		if err != nil {
+			continue
+		}
+		tools := docs.ToolNames()
+		switch {
+		case len(positional) == 0:
+			completions = append(completions, tools...)
+		case slices.Contains(tools, positional[0]):
+			completions = append(completions, server)
+		}
+	}
+	if len(completions) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	slices.Sort(completions)
+	return slices.Compact(completions), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/doccache"
+)
+
+func saveTestDocs(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	for _, docs := range []doccache.Docs{
+		{Server: "fs", UpdatedAt: time.Now(), Tools: []any{
+			map[string]any{"name": "read_file", "description": "Read a file"},
+			map[string]any{"name": "search"},
+		}},
+		{Server: "gh", UpdatedAt: time.Now(), Tools: []any{map[string]any{"name": "search"}}},
+	} {
+		if err := doccache.Save(docs); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDocsCmdOffline(t *testing.T) {
+	saveTestDocs(t)
+	oldFormat := FormatOption
+	FormatOption = "table"
+	defer func() { FormatOption = oldFormat }()
+
+	cmd := DocsCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--offline", "fs"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if output := buf.String(); !strings.Contains(output, "cached") || !strings.Contains(output, "read_file") {
+		t.Errorf("output = %q, want the cached tools", output)
+	}
+}
+
+func TestCompleteToolAndServer(t *testing.T) {
+	saveTestDocs(t)
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"read_file", "search"}},
+		{[]string{"--params", "{}"}, []string{"read_file", "search"}},
+		{[]string{"search"}, []string{"fs", "gh"}},
+		{[]string{"read_file", "-f", "json"}, []string{"fs"}},
+		{[]string{"read_file", "fs"}, nil},
+	}
+	for _, tt := range tests {
+		if got, _ := completeToolAndServer(nil, tt.args, ""); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completeToolAndServer(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestDocsCmdRevalidatesCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	oldFormat := FormatOption
+	FormatOption = "json"
+	defer func() { FormatOption = oldFormat }()
+
+	if err := alias.Save(alias.Aliases{"fs": {Command: "fs-server"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := doccache.Enable(); err != nil {
+		t.Fatal(err)
+	}
+	cached := doccache.Docs{
+		Server:    "fs",
+		UpdatedAt: time.Now().Add(-2 * time.Hour),
+		Tools:     []any{map[string]any{"name": "read_file"}},
+		ETags:     map[string]string{"tools/list": "v1"},
+	}
+	if err := doccache.Save(cached); err != nil {
+		t.Fatal(err)
+	}
+
+	var sentETags []any
+	cleanup := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method != "tools/list" {
+			return nil, errors.New("method not found")
+		}
+		meta, _ := params.(map[string]any)["_meta"].(map[string]any)
+		sentETags = append(sentETags, meta["ifNoneMatch"])
+		return map[string]any{"tools": []any{}, "_meta": map[string]any{"notModified": true}}, nil
+	})
+	defer cleanup()
+
+	cmd := DocsCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"fs"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(sentETags, []any{"v1"}) {
+		t.Errorf("sent etags %v, want the cached one", sentETags)
+	}
+	if !strings.Contains(buf.String(), "read_file") {
+		t.Errorf("output = %q, want the cached tools kept", buf.String())
+	}
+	docs, err := doccache.Load("fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs.Age(time.Now()) > time.Minute || docs.ETags["tools/list"] != "v1" || len(docs.Tools) != 1 {
+		t.Errorf("cache = %+v, want the cached tools revalidated just now", docs)
+	}
+}
+
+func TestLoadCachedDocsMaxStale(t *testing.T) {
+	saveTestDocs(t)
+
+	if _, err := loadCachedDocs("fs", time.Hour); err != nil {
+		t.Errorf("loadCachedDocs() error = %v, want the fresh copy", err)
+	}
+	if _, err := loadCachedDocs("fs", time.Nanosecond); err == nil || !strings.Contains(err.Error(), "--max-stale") {
+		t.Errorf("loadCachedDocs() error = %v, want the copy refused as too old", err)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !lite && !noembeddings
+
+package commands
+
+import "github.com/f/mcptools/pkg/search"
+
+// embeddingsIncluded reports whether this build includes semantic search with embeddings.
+const embeddingsIncluded = true
+
+// newEmbedder returns the embedder of the OpenAI-compatible endpoint configured in the
+// environment.
+func newEmbedder() (*search.APIEmbedder, error) {
+	return search.NewAPIEmbedderFromEnv(), nil
+}
@@ -0,0 +1,13 @@
+//go:build lite || noembeddings
+
+package commands
+
+import "github.com/f/mcptools/pkg/search"
+
+// embeddingsIncluded reports whether this build includes semantic search with embeddings.
+const embeddingsIncluded = false
+
+// newEmbedder fails, as this build leaves out semantic search.
+func newEmbedder() (*search.APIEmbedder, error) {
+	return nil, notIncludedError("Semantic search", "embeddings")
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+
+	"github.com/f/mcptools/pkg/contract"
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/protocol"
+)
+
+// Error codes of the structured errors printed with --format json.
+const (
+	ErrorCodeUsage      = "usage"
+	ErrorCodeConnection = "connection_failed"
+	ErrorCodeTimeout    = "timeout"
+	ErrorCodeRPC        = "rpc_error"
+	ErrorCodeOutput     = "invalid_output"
+	ErrorCodeOther      = "error"
+)
+
+// rpcErrors records the JSON-RPC errors returned by the servers of this process.
+var rpcErrors = &protocol.ErrorLog{}
+
+// rpcErrorOf returns the JSON-RPC error behind err: the one it carries, from a request sent with
+// protocol.CaptureErrors, or else the recent error with the same message.
+func rpcErrorOf(err error) *protocol.RPCError {
+	var rpcErr *protocol.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	return rpcErrors.Match(err)
+}
+
+// ErrorReport is the structured form of a command failure.
+type ErrorReport struct {
+	Code    string             `json:"code"`
+	Message string             `json:"message"`
+	RPC     *protocol.RPCError `json:"rpc,omitempty"`
+	Hint    string             `json:"hint,omitempty"`
+}
+
+// hintedError is an error with a suggestion on how to fix it.
+type hintedError struct {
+	err  error
+	hint string
+	code string
+}
+
+func (e *hintedError) Error() string { return e.err.Error() }
+func (e *hintedError) Unwrap() error { return e.err }
+
+// usageError returns an error for a command used wrongly, with an example of correct use.
+func usageError(message, example string) error {
+	return &hintedError{err: errors.New(message), hint: example, code: ErrorCodeUsage}
+}
+
+// withHint attaches a suggestion on how to fix err.
+func withHint(err error, hint string) error {
+	return &hintedError{err: err, hint: hint}
+}
+
+// rpcHints suggests fixes for the standard JSON-RPC error codes.
+var rpcHints = map[int]string{
+	-32700: "The server could not parse the request; try --strict to check the messages exchanged",
+	-32600: "The server rejected the request as invalid; try --strict to check the messages exchanged",
+	-32601: "The server does not support this method; check what it offers with mcp tools, mcp resources or mcp prompts",
+	-32602: "Check the parameters against the tool's input schema with mcp describe",
+	-32603: "The server failed internally; check its logs, e.g. with --server-logs",
+}
+
+// dataHint returns the hint servers such as mcp guard put in the data of their errors.
+func dataHint(data json.RawMessage) string {
+	var fields struct {
+		Hint string `json:"hint"`
+	}
+	if len(data) == 0 || json.Unmarshal(data, &fields) != nil {
+		return ""
+	}
+	return fields.Hint
+}
+
+// NewErrorReport classifies err for a structured error payload.
+func NewErrorReport(err error) ErrorReport {
+	report := ErrorReport{Code: ErrorCodeOther, Message: err.Error()}
+
+	var hinted *hintedError
+	if errors.As(err, &hinted) {
+		report.Hint = hinted.hint
+		if hinted.code != "" {
+			report.Code = hinted.code
+		}
+	}
+
+	var execErr *exec.Error
+	var netErr net.Error
+	switch {
+	case report.Code != ErrorCodeOther:
+	case errors.Is(err, ErrCommandRequired):
+		report.Code = ErrorCodeUsage
+	case errors.Is(err, ErrInitTimeout), errors.Is(err, context.DeadlineExceeded):
+		report.Code = ErrorCodeTimeout
+	case errors.Is(err, contract.ErrInvalidOutput):
+		report.Code = ErrorCodeOutput
+	case errors.As(err, &execErr), errors.As(err, &netErr), errors.Is(err, os.ErrNotExist):
+		report.Code = ErrorCodeConnection
+		if report.Hint == "" {
+			report.Hint = "Check that the server command or URL is correct and that the server is running"
+		}
+	}
+
+	// The client reports server errors by message, so match the message to a recent error
+	if last := rpcErrorOf(err); report.Code == ErrorCodeOther && last != nil {
+		report.Code = ErrorCodeRPC
+		report.RPC = last
+		if report.Hint == "" {
+			report.Hint = dataHint(last.Data)
+		}
+		if report.Hint == "" {
+			report.Hint = rpcHints[last.Code]
+		}
+	}
+
+	return report
+}
+
+// PrintError reports err on w: as a structured error object when FormatOption selects JSON
+// output, and as text otherwise.
+func PrintError(w io.Writer, err error) {
+	report := NewErrorReport(err)
+
+	if jsonutils.ParseFormat(FormatOption) == jsonutils.FormatTable {
+		fmt.Fprintf(w, "Error: %s\n", report.Message)
+		if report.Hint != "" {
+			fmt.Fprintln(w, report.Hint)
+		}
+		return
+	}
+
+	payload, wrapErr := contract.Wrap("error", map[string]any{"error": report})
+	if wrapErr != nil {
+		fmt.Fprintf(w, "Error: %s\n", report.Message)
+		return
+	}
+	var data []byte
+	if jsonutils.ParseFormat(FormatOption) == jsonutils.FormatPretty {
+		data, _ = json.MarshalIndent(payload, "", "  ")
+	} else {
+		data, _ = json.Marshal(payload)
+	}
+	fmt.Fprintln(w, string(data))
+}
+
+// exitWithError reports err on stderr and exits with status 1, discarding the output collected
+// for --output-file. The --stats statistics are printed first, as failures are often when they
+// matter.
+func exitWithError(err error) {
+	_ = FinishOutputFile(false)
+	RecordTelemetry(telemetryCommand, err)
+	PrintSessionStats(os.Stderr)
+	PrintError(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/f/mcptools/pkg/protocol"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// failingTransport answers every request but initialize with a JSON-RPC error.
+type failingTransport struct {
+	MockTransport
+}
+
+func (f *failingTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if request.Method == "initialize" {
+		return f.MockTransport.SendRequest(ctx, request)
+	}
+	response := &transport.JSONRPCResponse{JSONRPC: "2.0", ID: request.ID}
+	response.Error = &struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}{Code: -32602, Message: "missing argument: path", Data: json.RawMessage(`{"field":"path"}`)}
+	return response, nil
+}
+
+func TestPrintErrorReportsRPCErrors(t *testing.T) {
+	originalFormat, originalLog := FormatOption, rpcErrors
+	defer func() { FormatOption, rpcErrors = originalFormat, originalLog }()
+	rpcErrors = &protocol.ErrorLog{}
+
+	c := client.NewClient(protocol.NewErrorTransport(&failingTransport{}, rpcErrors), client.WithSession())
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_, callErr := c.CallTool(context.Background(), mcp.CallToolRequest{})
+	if callErr == nil {
+		t.Fatal("expected the call to fail")
+	}
+
+	FormatOption = "json"
+	var buf bytes.Buffer
+	PrintError(&buf, callErr)
+
+	var payload struct {
+		APIVersion string      `json:"apiVersion"`
+		Kind       string      `json:"kind"`
+		Error      ErrorReport `json:"error"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("stderr is not JSON: %v: %s", err, buf.String())
+	}
+	report := payload.Error
+	if payload.APIVersion != "mcptools/v1" || payload.Kind != "Error" || report.Code != ErrorCodeRPC {
+		t.Errorf("unexpected payload %s", buf.String())
+	}
+	if report.RPC == nil || report.RPC.Code != -32602 || string(report.RPC.Data) != `{"field":"path"}` {
+		t.Errorf("expected the JSON-RPC error with its data, got %+v", report.RPC)
+	}
+	if report.Hint != rpcHints[-32602] {
+		t.Errorf("Hint = %q", report.Hint)
+	}
+}
+
+func TestNewErrorReport(t *testing.T) {
+	originalLog := rpcErrors
+	defer func() { rpcErrors = originalLog }()
+	rpcErrors = &protocol.ErrorLog{}
+
+	_, lookErr := exec.LookPath("mcptools-missing-command")
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+		wantHint bool
+	}{
+		{"usage", usageError("entity name is required", "Example: mcp call read_file server"), ErrorCodeUsage, true},
+		{"missing command", fmt.Errorf("create client: %w", ErrCommandRequired), ErrorCodeUsage, false},
+		{"timeout", ErrInitTimeout, ErrorCodeTimeout, false},
+		{"missing executable", fmt.Errorf("failed to start command: %w", lookErr), ErrorCodeConnection, true},
+		{"other", fmt.Errorf("alias 'x' does not exist"), ErrorCodeOther, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewErrorReport(tt.err)
+			if report.Code != tt.wantCode || (report.Hint != "") != tt.wantHint || report.Message != tt.err.Error() {
+				t.Errorf("NewErrorReport() = %+v", report)
+			}
+		})
+	}
+}
+
+func TestPrintErrorText(t *testing.T) {
+	originalFormat := FormatOption
+	defer func() { FormatOption = originalFormat }()
+	FormatOption = "table"
+
+	var buf bytes.Buffer
+	PrintError(&buf, usageError("prompt name is required", "Example: mcp get-prompt greet server"))
+	assertEquals(t, buf.String(), "Error: prompt name is required\nExample: mcp get-prompt greet server\n")
+}
+
+func TestDataHint(t *testing.T) {
+	for data, want := range map[string]string{
+		`{"reason":"denied","hint":"Remove the deny pattern"}`: "Remove the deny pattern",
+		`{"field":"path"}`: "",
+		`"text"`:           "",
+		``:                 "",
+	} {
+		if got := dataHint(json.RawMessage(data)); got != want {
+			t.Errorf("dataHint(%s) = %q, want %q", data, got, want)
+		}
+	}
+}
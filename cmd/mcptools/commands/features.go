@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"text/tabwriter"
+
+	"github.com/f/mcptools/pkg/keychain"
+)
+
+// Feature is an optional part of mcp. Lite builds, made with the lite build tag for constrained
+// environments such as Termux or small containers, leave all of them out; the noweb, notui,
+// nollm, nowasm and noembeddings tags leave out one each.
+type Feature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Included    bool   `json:"included"`
+}
+
+// Features lists the optional features and whether this build includes them.
+func Features() []Feature {
+	return []Feature{
+		{Name: "web", Description: "the web interface of mcp web", Included: webIncluded},
+		{Name: "tui", Description: "the interactive tree of mcp browse and the notifications pane of mcp shell", Included: tuiIncluded},
+		{Name: "llm", Description: "parameter fixes of call --fix, and translation with --translate of descriptions missing from the glossary", Included: llmIncluded},
+		{Name: "wasm", Description: "the WebAssembly runtime of mcp run-wasm", Included: wasmIncluded},
+		{Name: "embeddings", Description: "semantic search of find --semantic and the resource index", Included: embeddingsIncluded},
+	}
+}
+
+// Lite reports whether this build leaves out any optional feature.
+func Lite() bool {
+	for _, feature := range Features() {
+		if !feature.Included {
+			return true
+		}
+	}
+	return false
+}
+
+// KeychainBackend is a secret store of mcp keychain and whether it can be used on this system.
+type KeychainBackend struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Default   bool   `json:"default"`
+}
+
+// BuildInfo describes this build of mcp, for scripts and support to tell installs apart.
+type BuildInfo struct {
+	Version          string            `json:"version"`
+	GoVersion        string            `json:"goVersion"`
+	OS               string            `json:"os"`
+	Arch             string            `json:"arch"`
+	Lite             bool              `json:"lite"`
+	Features         []Feature         `json:"features"`
+	KeychainBackends []KeychainBackend `json:"keychainBackends"`
+}
+
+// Build returns the description of this build of mcp.
+func Build() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Lite:      Lite(),
+		Features:  Features(),
+	}
+	defaultBackend := keychain.DefaultBackend()
+	for _, backend := range keychain.Backends() {
+		info.KeychainBackends = append(info.KeychainBackends, KeychainBackend{
+			Name:      backend,
+			Available: keychain.Available(backend),
+			Default:   backend == defaultBackend,
+		})
+	}
+	return info
+}
+
+// notIncludedError returns the error of using what, part of feature, in a build without it.
+func notIncludedError(what, feature string) error {
+	return withHint(fmt.Errorf("%s needs the %s feature, which this build of mcp leaves out", what, feature),
+		"List the features of this build with: mcp version --features")
+}
+
+// printBuild writes the description of a build as tables of its features and keychain backends.
+func printBuild(w io.Writer, info BuildInfo) {
+	edition := ""
+	if info.Lite {
+		edition = " (lite)"
+	}
+	fmt.Fprintf(w, "MCP Tools version %s%s, built with %s for %s/%s\n\n", info.Version, edition, info.GoVersion, info.OS, info.Arch)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FEATURE\tINCLUDED\tDESCRIPTION")
+	for _, feature := range info.Features {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", feature.Name, yesNo(feature.Included), feature.Description)
+	}
+	_ = tw.Flush()
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEYCHAIN BACKEND\tAVAILABLE\tDEFAULT")
+	for _, backend := range info.KeychainBackends {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", backend.Name, yesNo(backend.Available), yesNo(backend.Default))
+	}
+	_ = tw.Flush()
+}
+
+// yesNo returns yes or no for a table cell.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/search"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// defaultFindLimit is the number of matches shown by find unless --limit is given.
+const defaultFindLimit = 20
+
+// FindCmd creates the find command.
+func FindCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "find query [--] [command args...]",
+		Short: "Search tools, prompts and resources by keyword",
+		Long: `Search the names and descriptions of the tools, prompts and resources of a server by
+keyword, ranking the best matches first. Names are matched fuzzily, so "crtiss" finds
+"create_issue". Without a server, all registered aliases are searched.
+
+With --semantic, tools are ranked by the similarity of embeddings of their descriptions and the
+query instead. Embeddings are computed with an OpenAI-compatible /embeddings endpoint configured
+by MCPT_EMBEDDINGS_URL, MCPT_EMBEDDINGS_MODEL and MCPT_EMBEDDINGS_KEY (or OPENAI_API_KEY), and
+cached in $HOME/.mcpt/embeddings.json.
+
+Examples:
+  mcp find issue -- npx -y @modelcontextprotocol/server-github
+  mcp find "create issue" --limit 5
+  mcp find calendar -f json
+  mcp find --semantic "make a calendar event"`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			if len(args) == 0 || (len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort)) {
+				_ = thisCmd.Help()
+				return
+			}
+
+			limit := defaultFindLimit
+			semantic := false
+			query := ""
+			queryExtracted := false
+			serverArgs := []string{}
+
+			parsedArgs := ProcessFlags(args)
+			for i := 0; i < len(parsedArgs); i++ {
+				switch {
+				case parsedArgs[i] == FlagLimit && i+1 < len(parsedArgs):
+					n, err := strconv.Atoi(parsedArgs[i+1])
+					if err != nil || n <= 0 {
+						exitWithError(usageError(fmt.Sprintf("invalid limit: %s", parsedArgs[i+1]), ""))
+					}
+					limit = n
+					i++
+				case parsedArgs[i] == FlagSemantic:
+					semantic = true
+				case parsedArgs[i] == "--" && queryExtracted:
+					serverArgs = append(serverArgs, parsedArgs[i+1:]...)
+					i = len(parsedArgs)
+				case !queryExtracted:
+					query = parsedArgs[i]
+					queryExtracted = true
+				default:
+					serverArgs = append(serverArgs, parsedArgs[i])
+				}
+			}
+
+			items, err := collectSearchItems(serverArgs)
+			if err != nil {
+				exitWithError(err)
+			}
+
+			var matches []search.Match
+			if semantic {
+				matches, err = rankSemantic(query, items)
+				if err != nil {
+					exitWithError(err)
+				}
+			} else {
+				matches = search.Rank(query, items)
+			}
+			if len(matches) > limit {
+				matches = matches[:limit]
+			}
+
+			if formatErr := FormatAndPrintResponse(thisCmd, map[string]any{"matches": ConvertJSONToSlice(matches)}, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+}
+
+// collectSearchItems lists the tools, prompts and resources of the server given by serverArgs,
+// or of every registered alias if serverArgs is empty. Aliases that cannot be reached are
+// skipped with a warning.
+func collectSearchItems(serverArgs []string) ([]search.Item, error) {
+	if len(serverArgs) > 0 {
+		mcpClient, err := CreateClientFunc(serverArgs)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = mcpClient.Close() }()
+		return listSearchItems(context.Background(), mcpClient, strings.Join(serverArgs, " "))
+	}
+
+	aliases, _, err := alias.LoadAll(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aliases: %w", err)
+	}
+	if len(aliases) == 0 {
+		return nil, fmt.Errorf("no server given and no aliases registered")
+	}
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	servers := make([]namedServer, len(names))
+	for i, name := range names {
+		servers[i] = namedServer{Name: name, Args: ParseCommandString(aliases[name].Command)}
+	}
+
+	var items []search.Item
+	for _, result := range forEachServer(servers, listSearchItems) {
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", result.Name, result.Err)
+			continue
+		}
+		items = append(items, result.Value...)
+	}
+
+	return items, nil
+}
+
+// listSearchItems lists everything a server offers. Listings the server does not support are
+// left out.
+func listSearchItems(ctx context.Context, mcpClient *client.Client, server string) ([]search.Item, error) {
+	var items []search.Item
+
+	if tools, err := mcpClient.ListTools(ctx, mcp.ListToolsRequest{}); err == nil {
+		for _, tool := range tools.Tools {
+			items = append(items, search.Item{Server: server, Kind: search.KindTool, Name: tool.Name, Description: tool.Description})
+		}
+	}
+
+	if prompts, err := mcpClient.ListPrompts(ctx, mcp.ListPromptsRequest{}); err == nil {
+		for _, prompt := range prompts.Prompts {
+			items = append(items, search.Item{Server: server, Kind: search.KindPrompt, Name: prompt.Name, Description: prompt.Description})
+		}
+	}
+
+	if resources, err := mcpClient.ListResources(ctx, mcp.ListResourcesRequest{}); err == nil {
+		for _, resource := range resources.Resources {
+			items = append(items, search.Item{Server: server, Kind: search.KindResource, Name: resource.Name, Description: resource.Description})
+		}
+	}
+
+	return items, nil
+}
+
+// rankSemantic ranks items by embedding similarity using the embeddings endpoint configured in
+// the environment, caching embeddings between runs.
+func rankSemantic(query string, items []search.Item) ([]search.Match, error) {
+	cachePath, err := search.GetEmbeddingsCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := newEmbedder()
+	if err != nil {
+		return nil, err
+	}
+	embedder := &search.CachedEmbedder{
+		Inner: api,
+		Path:  cachePath,
+		Key:   api.URL + " " + api.Model,
+	}
+
+	return search.RankSemantic(context.Background(), embedder, query, items)
+}
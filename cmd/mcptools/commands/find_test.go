@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFindCmdRun(t *testing.T) {
+	origFormatOption := FormatOption
+	defer func() { FormatOption = origFormatOption }()
+
+	cleanup := setupMockClient(func(method string, _ any) (map[string]any, error) {
+		switch method {
+		case "tools/list":
+			return map[string]any{
+				"tools": []any{
+					map[string]any{"name": "create_issue", "description": "Create a new issue"},
+					map[string]any{"name": "get_file", "description": "Read a file"},
+				},
+			}, nil
+		case "prompts/list":
+			return map[string]any{
+				"prompts": []any{
+					map[string]any{"name": "triage", "description": "Triage an issue"},
+				},
+			}, nil
+		default:
+			return map[string]any{"resources": []any{}}, nil
+		}
+	})
+	defer cleanup()
+
+	cmd := FindCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"issue", "--", "server", "args"})
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("cmd.Execute() error = %v", err)
+	}
+
+	output := buf.String()
+	assertContains(t, output, "create_issue")
+	assertContains(t, output, "triage")
+	if bytes.Contains(buf.Bytes(), []byte("get_file")) {
+		t.Errorf("Expected get_file not to match, got: %s", output)
+	}
+}
@@ -0,0 +1,129 @@
+//go:build !lite && !nollm
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/f/mcptools/pkg/contract"
+	"github.com/f/mcptools/pkg/llm"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/term"
+)
+
+// llmIncluded reports whether this build includes the language model integrations.
+const llmIncluded = true
+
+// fixAttempts bounds the corrections proposed for a single call.
+const fixAttempts = 3
+
+// newCompleter returns the model asked for corrected parameters, replaced in tests.
+var newCompleter = func() llm.Completer { return llm.NewFromEnv() }
+
+// confirmFix asks whether to call a tool with the proposed parameters, replaced in tests.
+// Outside a terminal there is no one to ask.
+var confirmFix = func(tool string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintln(os.Stderr, "Run in a terminal to call the tool with these parameters, or pass them with --params")
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "Call %s with these parameters? [y/N] ", tool)
+	answer, ok := <-terminalLines()
+	if !ok {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// callToolWithFix calls a tool and, when the server rejects the parameters, asks the model for
+// corrected ones and calls the tool again with them if the user accepts.
+func callToolWithFix(ctx context.Context, mcpClient *client.Client, tool string, params map[string]any) (*mcp.CallToolResult, error) {
+	result, err := callTool(ctx, mcpClient, tool, params)
+
+	var definition map[string]any
+	for range fixAttempts {
+		if definition == nil {
+			described, describeErr := describeTool(ctx, mcpClient, tool)
+			if describeErr != nil {
+				break
+			}
+			definition, _ = described.(map[string]any)
+		}
+		schema, _ := definition["inputSchema"].(map[string]any)
+
+		problem, rejected := validationProblem(schema, params, result, err)
+		if !rejected {
+			break
+		}
+
+		description, _ := definition["description"].(string)
+		fmt.Fprintf(os.Stderr, "%s rejected the parameters: %s\nAsking for a fix...\n", tool, problem)
+		fix, fixErr := llm.FixParams(ctx, newCompleter(), tool, description, schema, params, problem)
+		if fixErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not get a fix: %v\n", fixErr)
+			break
+		}
+		if !acceptFix(tool, fix) {
+			break
+		}
+
+		params = fix.Params
+		result, err = callTool(ctx, mcpClient, tool, params)
+	}
+
+	return result, err
+}
+
+// validationProblem returns how the parameters of a call were rejected: with a JSON-RPC invalid
+// params error, or with an error result for parameters that do not match the input schema.
+func validationProblem(schema, params map[string]any, result *mcp.CallToolResult, err error) (string, bool) {
+	if err != nil {
+		report := NewErrorReport(err)
+		if report.RPC != nil && report.RPC.Code == -32602 {
+			return report.Message, true
+		}
+		return "", false
+	}
+	if result == nil || !result.IsError || schema == nil {
+		return "", false
+	}
+
+	// Params decoded from JSON hold float64 numbers, as contract.Validate expects
+	var decoded any
+	raw, _ := json.Marshal(params)
+	_ = json.Unmarshal(raw, &decoded)
+	if decoded == nil {
+		decoded = map[string]any{}
+	}
+	invalid := contract.Validate(schema, decoded)
+	if invalid == nil {
+		return "", false
+	}
+
+	var text []string
+	for _, content := range result.Content {
+		if t, ok := content.(mcp.TextContent); ok {
+			text = append(text, t.Text)
+		}
+	}
+	text = append(text, invalid.Error())
+	return strings.Join(text, "\n"), true
+}
+
+// acceptFix shows the proposed parameters and asks whether to call the tool with them.
+func acceptFix(tool string, fix llm.Fix) bool {
+	params, _ := json.MarshalIndent(fix.Params, "", "  ")
+	if fix.Explanation != "" {
+		fmt.Fprintf(os.Stderr, "Did you mean (%s):\n", strings.TrimSuffix(fix.Explanation, "."))
+	} else {
+		fmt.Fprintln(os.Stderr, "Did you mean:")
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", params)
+	return confirmFix(tool)
+}
@@ -0,0 +1,67 @@
+//go:build !lite && !nollm
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/llm"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type fixCompleter struct{ reply string }
+
+func (f fixCompleter) Complete(context.Context, string, string) (string, error) { return f.reply, nil }
+
+func TestCallCmdRun_Fix(t *testing.T) {
+	defer func(completer func() llm.Completer, confirm func(string) bool) {
+		newCompleter, confirmFix, FixOption = completer, confirm, false
+	}(newCompleter, confirmFix)
+	newCompleter = func() llm.Completer {
+		return fixCompleter{reply: `{"params": {"count": 3}, "explanation": "count must be a number"}`}
+	}
+	confirmFix = func(string) bool { return true }
+
+	var calls []any
+	cleanup := setupMockClient(func(method string, params any) (map[string]any, error) {
+		switch method {
+		case "tools/list":
+			return map[string]any{"tools": []any{map[string]any{
+				"name": "repeat",
+				"inputSchema": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"count": map[string]any{"type": "number"}},
+					"required":   []any{"count"},
+				},
+			}}}, nil
+		case "tools/call":
+			arguments, _ := params.(mcp.CallToolParams).Arguments.(map[string]any)
+			calls = append(calls, arguments["count"])
+			if _, isNumber := arguments["count"].(float64); !isNumber {
+				return map[string]any{"isError": true, "content": []any{map[string]any{"type": "text", "text": "count is invalid"}}}, nil
+			}
+			return map[string]any{"content": []any{map[string]any{"type": "text", "text": "repeated"}}}, nil
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanup()
+
+	cmd := CallCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"repeat", "--fix", "--params", `{"count": "three"}`, "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	if fmt.Sprint(calls) != "[three 3]" {
+		t.Errorf("called with counts %v, want the original and the fixed one", calls)
+	}
+	if !strings.Contains(buf.String(), "repeated") {
+		t.Errorf("output = %s, want the result of the fixed call", buf.String())
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
@@ -24,12 +23,7 @@ func GetPromptCmd() *cobra.Command {
 			}
 
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: prompt name is required")
-				fmt.Fprintln(
-					os.Stderr,
-					"Example: mcp get-prompt read_file npx -y @modelcontextprotocol/server-filesystem ~",
-				)
-				os.Exit(1)
+				exitWithError(usageError("prompt name is required", "Example: mcp get-prompt read_file npx -y @modelcontextprotocol/server-filesystem ~"))
 			}
 
 			cmdArgs := args
@@ -40,6 +34,11 @@ func GetPromptCmd() *cobra.Command {
 			promptExtracted := false
 
 			for i < len(cmdArgs) {
+				if n := processClientFlag(cmdArgs, i); n > 0 {
+					i += n
+					continue
+				}
+
 				switch {
 				case (cmdArgs[i] == FlagFormat || cmdArgs[i] == FlagFormatShort) && i+1 < len(cmdArgs):
 					FormatOption = cmdArgs[i+1]
@@ -61,31 +60,26 @@ func GetPromptCmd() *cobra.Command {
 			}
 
 			if promptName == "" {
-				fmt.Fprintln(os.Stderr, "Error: prompt name is required")
-				fmt.Fprintln(
-					os.Stderr,
-					"Example: mcp get-prompt read_file npx -y @modelcontextprotocol/server-filesystem ~",
-				)
-				os.Exit(1)
+				exitWithError(usageError("prompt name is required", "Example: mcp get-prompt read_file npx -y @modelcontextprotocol/server-filesystem ~"))
 			}
 
 			var params map[string]any
 			if ParamsString != "" {
 				if jsonErr := json.Unmarshal([]byte(ParamsString), &params); jsonErr != nil {
-					fmt.Fprintf(os.Stderr, "Error: invalid JSON for params: %v\n", jsonErr)
-					os.Exit(1)
+					exitWithError(usageError(fmt.Sprintf("invalid JSON for params: %v", jsonErr), ""))
 				}
 			}
 
 			mcpClient, clientErr := CreateClientFunc(parsedArgs)
 			if clientErr != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", clientErr)
-				os.Exit(1)
+				exitWithError(clientErr)
 			}
 
 			request := mcp.GetPromptRequest{}
 			request.Params.Name = promptName
+			request.Params.Arguments = promptArguments(params)
 			resp, execErr := mcpClient.GetPrompt(context.Background(), request)
+			execErr = checkSupported(mcpClient, featurePrompts, execErr)
 
 			var responseMap map[string]any
 			if execErr == nil && resp != nil {
@@ -95,8 +89,7 @@ func GetPromptCmd() *cobra.Command {
 			}
 
 			if formatErr := FormatAndPrintResponse(thisCmd, responseMap, execErr); formatErr != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
-				os.Exit(1)
+				exitWithError(formatErr)
 			}
 		},
 	}
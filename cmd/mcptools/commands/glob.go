@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/f/mcptools/pkg/glob"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// expandGlobs replaces @glob: values in params with the files or server resource URIs they
+// match. Resources are only listed if a pattern is a URI.
+func expandGlobs(ctx context.Context, mcpClient *client.Client, params map[string]any) (map[string]any, error) {
+	if !glob.Has(params) {
+		return params, nil
+	}
+	return glob.Expand(params, func() ([]string, error) {
+		return listResourceURIs(ctx, mcpClient)
+	})
+}
+
+// listResourceURIs returns the URIs of all resources of the server, following its cursor.
+func listResourceURIs(ctx context.Context, mcpClient *client.Client) ([]string, error) {
+	var uris []string
+	request := mcp.ListResourcesRequest{}
+	for {
+		resp, err := mcpClient.ListResources(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resp.Resources {
+			uris = append(uris, resource.URI)
+		}
+		if resp.NextCursor == "" {
+			return uris, nil
+		}
+		request.Params.Cursor = resp.NextCursor
+	}
+}
@@ -3,9 +3,12 @@ package commands
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/f/mcptools/pkg/admin"
 	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/consent"
 	"github.com/f/mcptools/pkg/guard"
 	"github.com/spf13/cobra"
 )
@@ -16,6 +19,10 @@ const (
 	FlagAllowShort = "-a"
 	FlagDeny       = "--deny"
 	FlagDenyShort  = "-d"
+	FlagAdmin      = "--admin"
+	FlagAdminSock  = "--admin-socket"
+	FlagAdminDebug = "--admin-debug"
+	FlagPolicy     = "--policy"
 )
 
 var entityTypes = []string{
@@ -26,8 +33,8 @@ var entityTypes = []string{
 
 // GuardCmd creates the guard command to filter tools, prompts, and resources.
 func GuardCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "guard [--allow type:pattern] [--deny type:pattern] command args...",
+	cmd := &cobra.Command{
+		Use:   "guard [--allow type:pattern] [--deny type:pattern] [--policy file] [--admin] command args...",
 		Short: "Filter tools, prompts, and resources using allow and deny patterns",
 		Long: `Filter tools, prompts, and resources using allow and deny patterns.
 
@@ -35,6 +42,22 @@ Examples:
   mcp guard --allow tools:read_* --deny edit_*,write_*,create_* npx run @modelcontextprotocol/server-filesystem ~
   mcp guard --allow prompts:system_* --deny tools:execute_* npx run @modelcontextprotocol/server-filesystem ~
   mcp guard --allow tools:read_* fs  # Using an alias
+  mcp guard --no-deprecated fs       # Hide and block tools the server marks deprecated
+  mcp guard --admin --deny tools:delete_* fs  # Allow changing the rules with mcp admin
+  mcp guard --policy policy.yaml fs  # Read the patterns from a policy file
+
+A policy file lists allow and deny patterns by entity type in YAML; its patterns add to those
+given with --allow and --deny. Its confirm patterns make the guard ask on its terminal before
+letting matching requests through. Answering always or never there remembers the choice for
+the server in the keychain: the guard no longer asks, and never blocks the entity even without a
+confirm pattern. See the remembered choices with mcp consent list. Write a policy by going through the tools of a server with
+mcp guard init, and try it on a recorded session before enforcing it with mcp guard simulate.
+
+With --admin (or --admin-socket path), the guard serves an admin API on a Unix socket
+($HOME/.mcpt/admin.sock by default) for temporarily overriding the rules without a restart,
+e.g. mcp admin allow-tool delete_file --ttl 10m. Every change is written to the log file.
+--admin-debug also serves pprof profiles and Go runtime metrics on it, read with mcp admin
+profile and mcp admin metrics.
 
 Patterns can include wildcards:
   * matches any sequence of characters
@@ -51,8 +74,38 @@ Entity types:
 				return
 			}
 
+			adminSocket, adminDebug, args, err := extractAdminSocket(args)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			policyPath, args := extractPolicyPath(args)
+
 			// Process and extract the allow and deny patterns
 			allowPatterns, denyPatterns, cmdArgs := extractPatterns(args)
+			var guardOpts []guard.Option
+			if policyPath != "" {
+				policy, policyErr := guard.LoadPolicy(policyPath)
+				if policyErr != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", policyErr)
+					os.Exit(1)
+				}
+				// Explanations of blocked requests point to the file the rule is in
+				if absPath, absErr := filepath.Abs(policyPath); absErr == nil {
+					policyPath = absPath
+				}
+				guardOpts = append(guardOpts, guard.WithPolicy(policyPath, policy))
+				for _, entityType := range entityTypes {
+					if confirm := policy.Confirm[entityType]; len(confirm) > 0 {
+						fmt.Fprintf(os.Stderr, "Confirming %s matching: %s\n", entityType, strings.Join(confirm, ", "))
+					}
+				}
+				for _, entityType := range entityTypes {
+					allowPatterns[entityType] = append(allowPatterns[entityType], policy.Allow[entityType]...)
+					denyPatterns[entityType] = append(denyPatterns[entityType], policy.Deny[entityType]...)
+				}
+			}
 
 			// Process regular flags (format)
 			parsedArgs := ProcessFlags(cmdArgs)
@@ -68,6 +121,9 @@ Entity types:
 				}
 			}
 
+			// Consents are remembered per server, by alias for servers given as one
+			consentServer := serverName(parsedArgs)
+
 			// Check if we're using an alias for the server command
 			if len(parsedArgs) == 1 {
 				aliasName := parsedArgs[0]
@@ -86,6 +142,14 @@ Entity types:
 				os.Exit(1)
 			}
 
+			// Run k8s: servers inside their pod
+			command, commandArgs, err := serverCommand(parsedArgs)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			parsedArgs = append([]string{command}, commandArgs...)
+
 			// Map our entity types to the guard proxy entity types
 			guardAllowPatterns := map[string][]string{
 				"tool":     allowPatterns[EntityTypeTool],
@@ -100,12 +164,75 @@ Entity types:
 
 			// Run the guard proxy with the filtered environment
 			fmt.Fprintf(os.Stderr, "Running command with filtered environment: %s\n", strings.Join(parsedArgs, " "))
-			if err := guard.RunFilterServer(guardAllowPatterns, guardDenyPatterns, parsedArgs); err != nil {
+			if HideDeprecated {
+				fmt.Fprintf(os.Stderr, "Blocking deprecated tools\n")
+				guardOpts = append(guardOpts, guard.WithBlockDeprecated())
+			}
+			if consents, consentErr := consent.Open(); consentErr == nil {
+				guardOpts = append(guardOpts, guard.WithConsents(consents, consentServer))
+			} else {
+				fmt.Fprintf(os.Stderr, "Not remembering consents: %v\n", consentErr)
+			}
+			if adminSocket != "" {
+				guardOpts = append(guardOpts, guard.WithAdminSocket(adminSocket))
+			}
+			if adminDebug {
+				guardOpts = append(guardOpts, guard.WithAdminDebug())
+			}
+			if err = guard.RunFilterServer(guardAllowPatterns, guardDenyPatterns, parsedArgs, guardOpts...); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 		},
 	}
+
+	cmd.AddCommand(guardInitCmd(), guardSimulateCmd())
+	return cmd
+}
+
+// extractPolicyPath removes the --policy flag from args and returns its value, or "" if it is
+// not given.
+func extractPolicyPath(args []string) (string, []string) {
+	path := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == FlagPolicy && i+1 < len(args) {
+			path = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	return path, rest
+}
+
+// extractAdminSocket removes the admin flags from args and returns the admin socket path, or
+// "" if the admin API is not enabled, and whether debug endpoints are served on it.
+// --admin-debug enables the admin API on the default socket if no socket is given.
+func extractAdminSocket(args []string) (string, bool, []string, error) {
+	socket := ""
+	debug := false
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case (args[i] == FlagAdmin || args[i] == FlagAdminDebug) && socket == "":
+			path, err := admin.GetSocketPath()
+			if err != nil {
+				return "", false, nil, err
+			}
+			socket = path
+			debug = debug || args[i] == FlagAdminDebug
+		case args[i] == FlagAdmin:
+		case args[i] == FlagAdminDebug:
+			debug = true
+		case args[i] == FlagAdminSock && i+1 < len(args):
+			socket = args[i+1]
+			i++
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return socket, debug, rest, nil
 }
 
 // extractPatterns processes arguments to extract allow and deny patterns.
@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/f/mcptools/pkg/guard"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// Decisions taken for each tool by mcp guard init.
+const (
+	decisionAllow   = "allow"
+	decisionConfirm = "confirm"
+	decisionDeny    = "deny"
+)
+
+// errPolicyAborted is returned when the input ends before every tool was decided.
+var errPolicyAborted = errors.New("policy not written: input ended before every tool was decided")
+
+func guardInitCmd() *cobra.Command {
+	var outputPath string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init [--output policy.yaml] [--force] -- command args...",
+		Short: "Write a guard policy by deciding on each tool of a server",
+		Long: `List the tools of a server and ask, for each of them, whether the guard should allow it, ask
+for confirmation before each call, or deny it. The answers are written to a policy file ready
+for mcp guard --policy.
+
+Read-only tools are allowed by default and others confirmed; press Enter to accept the
+default. The policy lists the allowed tools, so tools the server adds later are blocked until
+they are added to it.
+
+Examples:
+  mcp guard init -- npx -y @modelcontextprotocol/server-filesystem ~
+  mcp guard init --output fs-policy.yaml fs
+  mcp guard --policy fs-policy.yaml fs`,
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return usageError("a server command is required", "Example: mcp guard init -- npx -y @modelcontextprotocol/server-filesystem ~")
+			}
+			if _, err := os.Stat(outputPath); err == nil && !force {
+				return fmt.Errorf("%s already exists; use --force to replace it", outputPath)
+			}
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				return errors.New("mcp guard init asks about each tool, run it in a terminal")
+			}
+
+			mcpClient, err := CreateClientFunc(args)
+			if err != nil {
+				return err
+			}
+			tools, err := listToolsRaw(context.Background(), mcpClient)
+			_ = mcpClient.Close()
+			if err != nil {
+				return fmt.Errorf("failed to list tools: %w", err)
+			}
+			if len(tools) == 0 {
+				return errors.New("the server has no tools to write a policy for")
+			}
+
+			out := thisCmd.ErrOrStderr()
+			fmt.Fprintf(out, "%s has %d tools. For each, answer allow, confirm or deny (a/c/d).\n", strings.Join(args, " "), len(tools))
+			policy, err := authorPolicy(out, terminalLines(), tools)
+			if err != nil {
+				return err
+			}
+
+			var b bytes.Buffer
+			fmt.Fprintf(&b, "# Guard policy for %s, written by mcp guard init.\n", strings.Join(args, " "))
+			b.WriteString("# Tools the server adds later are blocked until they are allowed here.\n")
+			encoder := yaml.NewEncoder(&b)
+			encoder.SetIndent(2)
+			if err = encoder.Encode(policy); err != nil {
+				return err
+			}
+			if err = os.WriteFile(outputPath, b.Bytes(), 0o600); err != nil {
+				return fmt.Errorf("failed to write policy: %w", err)
+			}
+
+			allowed, confirmed := len(policy.Allow["tools"]), len(policy.Confirm["tools"])
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Wrote %s: %d allowed, %d to confirm, %d denied\n", outputPath,
+				allowed-confirmed, confirmed, len(tools)-allowed)
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Run the server with: mcp guard --policy %s %s\n", outputPath, strings.Join(args, " "))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "policy.yaml", "Policy file to write")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace the policy file if it exists")
+	// Flags after the server command belong to it
+	cmd.Flags().SetInterspersed(false)
+
+	return cmd
+}
+
+// authorPolicy asks on w about each tool and reads the decisions from answers. Confirmed tools
+// are also allowed, as the guard only lets through tools matching an allow pattern.
+func authorPolicy(w io.Writer, answers <-chan string, tools []any) (*guard.Policy, error) {
+	policy := &guard.Policy{Allow: map[string][]string{}, Confirm: map[string][]string{}, Deny: map[string][]string{}}
+
+	for i, tool := range tools {
+		fields, _ := tool.(map[string]any)
+		name, _ := fields["name"].(string)
+		if name == "" {
+			continue
+		}
+		annotations, _ := fields["annotations"].(map[string]any)
+		readOnly, _ := annotations["readOnlyHint"].(bool)
+		destructive, _ := annotations["destructiveHint"].(bool)
+
+		fmt.Fprintf(w, "\n[%d/%d] %s", i+1, len(tools), name)
+		switch {
+		case readOnly:
+			fmt.Fprint(w, " (read-only)")
+		case destructive:
+			fmt.Fprint(w, " (destructive)")
+		}
+		fmt.Fprintln(w)
+		if description, _ := fields["description"].(string); description != "" {
+			fmt.Fprintf(w, "  %s\n", strings.SplitN(strings.TrimSpace(description), "\n", 2)[0])
+		}
+
+		suggested, choices := decisionConfirm, "[a/C/d]"
+		if readOnly {
+			suggested, choices = decisionAllow, "[A/c/d]"
+		}
+		decision := ""
+		for decision == "" {
+			fmt.Fprintf(w, "  allow, confirm or deny? %s ", choices)
+			answer, ok := <-answers
+			if !ok {
+				fmt.Fprintln(w)
+				return nil, errPolicyAborted
+			}
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "":
+				decision = suggested
+			case "a", decisionAllow:
+				decision = decisionAllow
+			case "c", decisionConfirm:
+				decision = decisionConfirm
+			case "d", decisionDeny:
+				decision = decisionDeny
+			}
+		}
+
+		switch decision {
+		case decisionAllow:
+			policy.Allow["tools"] = append(policy.Allow["tools"], name)
+		case decisionConfirm:
+			policy.Allow["tools"] = append(policy.Allow["tools"], name)
+			policy.Confirm["tools"] = append(policy.Confirm["tools"], name)
+		case decisionDeny:
+			policy.Deny["tools"] = append(policy.Deny["tools"], name)
+		}
+	}
+
+	// Without allow patterns the guard allows every tool it does not deny, including those the
+	// server adds later
+	if len(policy.Allow["tools"]) == 0 {
+		policy.Deny["tools"] = []string{"*"}
+	}
+	return policy, nil
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/f/mcptools/pkg/guard"
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/record"
+	"github.com/spf13/cobra"
+)
+
+func guardSimulateCmd() *cobra.Command {
+	var policyPath, tracePath string
+
+	cmd := &cobra.Command{
+		Use:   "simulate --policy policy.yaml --trace session.jsonl",
+		Short: "Show what a policy would have blocked in a recorded session",
+		Long: `Replay a session recorded with --record against a guard policy, without contacting any
+server, and report the requests the policy would have blocked and the tools, prompts and
+resources it would have hidden from listings. Use it to tune a policy before enforcing it
+with mcp guard --policy.
+
+A policy lists allow and deny patterns by entity type:
+
+  allow:
+    tools: ["read_*", "list_*"]
+  deny:
+    tools: ["*_secret"]
+    resources: ["*.env"]
+
+Example:
+  mcp guard simulate --policy policy.yaml --trace session.jsonl`,
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			if policyPath == "" || tracePath == "" {
+				return usageError("--policy and --trace are required", "Example: mcp guard simulate --policy policy.yaml --trace session.jsonl")
+			}
+
+			policy, err := guard.LoadPolicy(policyPath)
+			if err != nil {
+				return err
+			}
+			entries, err := record.Load(tracePath)
+			if err != nil {
+				return err
+			}
+
+			simulation := guard.Simulate(policy, entries)
+			if jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+				output, formatErr := jsonutils.Format(ConvertJSONToMap(simulation), FormatOption)
+				if formatErr != nil {
+					return formatErr
+				}
+				fmt.Fprintln(thisCmd.OutOrStdout(), output)
+				return nil
+			}
+
+			printSimulation(thisCmd.OutOrStdout(), tracePath, simulation)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&policyPath, "policy", "", "Policy file to simulate")
+	cmd.Flags().StringVar(&tracePath, "trace", "", "Session recorded with --record")
+
+	return cmd
+}
+
+// printSimulation writes the outcome of a simulation for people.
+func printSimulation(w io.Writer, tracePath string, simulation guard.Simulation) {
+	fmt.Fprintf(w, "Replayed %d requests from %s, %d of them subject to the policy\n", simulation.Requests, tracePath, simulation.Checked)
+
+	if len(simulation.Blocked) == 0 {
+		fmt.Fprintln(w, "No requests would have been blocked")
+	} else {
+		fmt.Fprintf(w, "\nBlocked requests (%d):\n", len(simulation.Blocked))
+		printDecisions(w, simulation.Blocked)
+	}
+
+	if len(simulation.Hidden) > 0 {
+		fmt.Fprintf(w, "\nHidden from listings (%d):\n", len(simulation.Hidden))
+		printDecisions(w, simulation.Hidden)
+	}
+}
+
+func printDecisions(w io.Writer, decisions []guard.Decision) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, decision := range decisions {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", decision.Time.Format(time.RFC3339), decision.Method, decision.Entity, decision.Name)
+	}
+	_ = tw.Flush()
+}
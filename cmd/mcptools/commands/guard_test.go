@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -141,3 +142,41 @@ func TestProcessPatternString(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorPolicy(t *testing.T) {
+	tools := []any{
+		map[string]any{"name": "read_file", "description": "Read a file\nwith details", "annotations": map[string]any{"readOnlyHint": true}},
+		map[string]any{"name": "move_file", "annotations": map[string]any{"destructiveHint": true}},
+		map[string]any{"name": "write_file"},
+		map[string]any{"name": "delete_file"},
+	}
+	answers := make(chan string, 5)
+	for _, answer := range []string{"", "", "maybe", "allow", "d"} {
+		answers <- answer
+	}
+	close(answers)
+
+	var out strings.Builder
+	policy, err := authorPolicy(&out, answers, tools)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"read_file", "move_file", "write_file"}, policy.Allow["tools"])
+	assert.Equal(t, []string{"move_file"}, policy.Confirm["tools"])
+	assert.Equal(t, []string{"delete_file"}, policy.Deny["tools"])
+	assert.Contains(t, out.String(), "[1/4] read_file (read-only)\n  Read a file\n  allow, confirm or deny? [A/c/d]")
+	assert.Contains(t, out.String(), "[2/4] move_file (destructive)\n  allow, confirm or deny? [a/C/d]")
+
+	// Running out of answers writes nothing
+	answers = make(chan string, 1)
+	answers <- "a"
+	close(answers)
+	_, err = authorPolicy(&out, answers, tools)
+	assert.ErrorIs(t, err, errPolicyAborted)
+
+	// Denying every tool also blocks those the server adds later
+	answers = make(chan string, 1)
+	answers <- "d"
+	close(answers)
+	policy, err = authorPolicy(&out, answers, tools[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"*"}, policy.Deny["tools"])
+}
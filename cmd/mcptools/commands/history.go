@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/f/mcptools/pkg/usage"
+	"github.com/spf13/cobra"
+)
+
+// HistoryCmd creates the history command.
+func HistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Query recorded tool calls",
+		Long: `Query the tool calls recorded once usage recording is enabled with mcp stats enable.
+
+Calls are stored in the SQLite database $HOME/.mcpt/usage.db, which the CLI and long-running
+commands such as mcp bridge share. A query is an SQL expression over the columns time (UTC, as
+2026-10-17T09:30:00.000Z), server, tool, duration_ms, status ('ok' or 'error') and slo_ms, the
+latency objective of the tool set with mcp alias slo (0 if it had none).
+
+Examples:
+  mcp history query "tool='read_file' AND status='error'"
+  mcp history query --limit 20 "server='github' AND duration_ms > 1000"
+  mcp history query "time >= '2026-10-01'" -f json
+
+  # Calls slower than the SLO of their tool
+  mcp history query "slo_ms > 0 AND duration_ms > slo_ms"
+
+  # Keep calls for 90 days, or forever
+  mcp history retain 90d
+  mcp history retain off`,
+	}
+
+	cmd.AddCommand(historyQueryCmd())
+	cmd.AddCommand(historyRetainCmd())
+	return cmd
+}
+
+func historyQueryCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "query [expression]",
+		Short: "List the recorded calls matching an SQL expression, newest first",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(thisCmd *cobra.Command, args []string) {
+			where := ""
+			if len(args) == 1 {
+				where = args[0]
+			}
+			records, err := usage.Query(thisCmd.Context(), where, limit)
+			if err != nil {
+				exitWithError(withHint(err, "Columns are time, server, tool, duration_ms, status and slo_ms, e.g. \"tool='read_file' AND status='error'\""))
+			}
+
+			if formatErr := FormatAndPrintResponse(thisCmd, map[string]any{"calls": ConvertJSONToSlice(records)}, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 100, "Maximum number of calls to list, 0 for all")
+	return cmd
+}
+
+func historyRetainCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "retain [age|off]",
+		Short:        "Show or set how long recorded calls are kept, e.g. 90d",
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				age, err := usage.Retention()
+				if err != nil {
+					return err
+				}
+				if age == 0 {
+					fmt.Fprintln(thisCmd.OutOrStdout(), "Recorded calls are kept forever")
+				} else {
+					fmt.Fprintf(thisCmd.OutOrStdout(), "Recorded calls are kept for %s\n", formatAge(age))
+				}
+				return nil
+			}
+
+			age, err := usage.ParseRetention(args[0])
+			if err != nil {
+				return usageError(err.Error(), "Example: mcp history retain 90d")
+			}
+			if err = usage.SetRetention(age); err != nil {
+				return err
+			}
+			if age == 0 {
+				fmt.Fprintln(thisCmd.OutOrStdout(), "Recorded calls will be kept forever")
+			} else {
+				fmt.Fprintf(thisCmd.OutOrStdout(), "Recorded calls older than %s deleted, and will be from now on\n", formatAge(age))
+			}
+			return nil
+		},
+	}
+}
+
+// formatAge formats a retention in days when it is a whole number of them.
+func formatAge(age time.Duration) string {
+	const day = 24 * time.Hour
+	if age%day == 0 {
+		return fmt.Sprintf("%d days", age/day)
+	}
+	return age.String()
+}
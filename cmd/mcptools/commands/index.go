@@ -0,0 +1,313 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/index"
+	"github.com/spf13/cobra"
+)
+
+// IndexCmd creates the index command.
+func IndexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Search the contents of server resources from a local full-text index",
+		Long: `Read every resource of a server once into a local full-text index, then search their
+contents instantly, without reading them again.
+
+The index is the SQLite database $HOME/.mcpt/index.db. Only text resources are indexed. Searches
+match every word of the query, stemmed so that "running" finds "run", and rank matches in
+resource names first.
+
+Build remembers how each server was indexed, so refresh can pick up changes without repeating
+it: only resources that changed are indexed and embedded again, and those gone are removed. Use
+refresh --every to refresh on a schedule, and --watch to stay connected and update resources as
+servers report changes to them. Search results tell when each resource was indexed, and
+search --max-stale leaves out those not refreshed within a duration.
+
+With --embed, build also embeds the resources so that search --semantic finds them by meaning,
+even without shared words. Embeddings come from the OpenAI-compatible endpoint configured by
+MCPT_EMBEDDINGS_URL, MCPT_EMBEDDINGS_MODEL and MCPT_EMBEDDINGS_KEY (or OPENAI_API_KEY); point it
+at Ollama or another local server to keep resources on your machine.
+
+Use --index to keep an index elsewhere, such as one per project, and serve any index to agents
+as an MCP retrieval server with mcp serve-index.
+
+Examples:
+  mcp index build -- npx -y @modelcontextprotocol/server-filesystem ~/docs
+  mcp index build docs
+  mcp index search "rate limit"
+  mcp index search --server docs --raw "throttl* OR backoff"
+  mcp index search --max-stale 1h "rate limit"
+  mcp index build --embed docs
+  mcp index search --semantic "refund policy"
+  mcp index refresh
+  mcp index refresh --every 1h --watch docs
+  mcp index build --index ./index docs
+  mcp serve-index ./index
+  mcp index list
+  mcp index remove docs`,
+	}
+
+	cmd.PersistentFlags().StringVar(&indexPath, "index", "", "Index database, or directory holding index.db, instead of $HOME/.mcpt/index.db")
+
+	cmd.AddCommand(indexBuildCmd())
+	cmd.AddCommand(indexRefreshCmd())
+	cmd.AddCommand(indexSearchCmd())
+	cmd.AddCommand(indexListCmd())
+	cmd.AddCommand(indexRemoveCmd())
+	return cmd
+}
+
+func indexBuildCmd() *cobra.Command {
+	var name string
+	var maxBytes int
+	var embed bool
+
+	cmd := &cobra.Command{
+		Use:          "build [--name name] [--max-bytes n] [--embed] -- command args...",
+		Short:        "Read the resources of a server into the index, updating those that changed",
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return usageError("a server command is required", "Example: mcp index build -- npx -y @modelcontextprotocol/server-filesystem ~/docs")
+			}
+			if name == "" {
+				name = serverName(args)
+			}
+
+			mcpClient, err := CreateClientFunc(args)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = mcpClient.Close() }()
+
+			ix, err := openIndex(indexPath)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = ix.Close() }()
+			if err = ix.SetServer(context.Background(), name, args, maxBytes); err != nil {
+				return fmt.Errorf("failed to write index: %w", err)
+			}
+			_, err = updateIndex(context.Background(), ix, mcpClient, name, maxBytes, embed, thisCmd.OutOrStdout(), thisCmd.ErrOrStderr())
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Name to index the server under, its alias or command line by default")
+	cmd.Flags().IntVar(&maxBytes, "max-bytes", index.DefaultMaxBytes, "Index at most this many bytes of each resource")
+	cmd.Flags().BoolVar(&embed, "embed", false, "Also embed the resources for search --semantic")
+	// Flags after the server command belong to it
+	cmd.Flags().SetInterspersed(false)
+	return cmd
+}
+
+func indexSearchCmd() *cobra.Command {
+	var server string
+	var limit int
+	var raw, semantic bool
+	var maxStale time.Duration
+
+	cmd := &cobra.Command{
+		Use:          "search [--server name] [--limit n] [--max-stale duration] [--raw | --semantic] query",
+		Short:        "List the indexed resources matching a query, with the matching text",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			ix, err := openIndex(indexPath)
+			if err != nil {
+				exitWithError(err)
+			}
+			defer func() { _ = ix.Close() }()
+
+			query := strings.Join(args, " ")
+			opts := index.SearchOptions{Server: server, Limit: limit, Raw: raw}
+			var hits []index.Hit
+			if semantic {
+				embedder, embedErr := newEmbedder()
+				if embedErr != nil {
+					exitWithError(embedErr)
+				}
+				hits, err = ix.SemanticSearch(thisCmd.Context(), query, embedder, index.ModelKey(embedder), opts)
+			} else {
+				hits, err = ix.Search(thisCmd.Context(), query, opts)
+			}
+			if err != nil {
+				hint := "Build the index first with: mcp index build -- <server>"
+				switch {
+				case errors.Is(err, index.ErrNoEmbeddings):
+					hint = "Embed the resources with the current MCPT_EMBEDDINGS_URL and MCPT_EMBEDDINGS_MODEL: mcp index build --embed -- <server>"
+				case semantic:
+					hint = "Configure the embeddings endpoint with MCPT_EMBEDDINGS_URL, MCPT_EMBEDDINGS_MODEL and MCPT_EMBEDDINGS_KEY"
+				case raw:
+					hint = "Raw queries use the SQLite FTS5 syntax, e.g. \"rate NEAR limit\" or \"throttl* OR backoff\""
+				}
+				exitWithError(withHint(err, hint))
+			}
+			if maxStale > 0 {
+				hits = dropStaleHits(hits, maxStale)
+			}
+
+			if formatErr := FormatAndPrintResponse(thisCmd, map[string]any{"hits": ConvertJSONToSlice(hits)}, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "", "Only search the resources of this indexed server")
+	cmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of resources to list, 0 for all")
+	cmd.Flags().BoolVar(&raw, "raw", false, "Pass the query as SQLite FTS5 syntax, with OR, NEAR and prefix* operators")
+	cmd.Flags().BoolVar(&semantic, "semantic", false, "Find resources by meaning, using the embeddings of mcp index build --embed")
+	cmd.Flags().DurationVar(&maxStale, "max-stale", 0, "Leave out resources not known to be current for this long, e.g. 1h")
+	cmd.MarkFlagsMutuallyExclusive("raw", "semantic")
+	return cmd
+}
+
+// dropStaleHits returns the hits whose text was known to be current within maxStale, warning
+// about the others.
+func dropStaleHits(hits []index.Hit, maxStale time.Duration) []index.Hit {
+	now := time.Now()
+	fresh := hits[:0]
+	for _, hit := range hits {
+		if now.Sub(hit.CheckedAt()) <= maxStale {
+			fresh = append(fresh, hit)
+		}
+	}
+	if dropped := len(hits) - len(fresh); dropped > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d matching resources not refreshed within %s were left out; refresh them with: mcp index refresh\n", dropped, maxStale)
+	}
+	return fresh
+}
+
+func indexListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the indexed servers",
+		Args:  cobra.NoArgs,
+		Run: func(thisCmd *cobra.Command, _ []string) {
+			ix, err := openIndex(indexPath)
+			if err != nil {
+				exitWithError(err)
+			}
+			defer func() { _ = ix.Close() }()
+
+			summaries, err := ix.Servers(thisCmd.Context())
+			if err != nil {
+				exitWithError(err)
+			}
+			if formatErr := FormatAndPrintResponse(thisCmd, map[string]any{"indexed": ConvertJSONToSlice(summaries)}, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+}
+
+func indexRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "remove name",
+		Short:        "Remove the resources of a server from the index",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			ix, err := openIndex(indexPath)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = ix.Close() }()
+
+			n, err := ix.Remove(thisCmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return fmt.Errorf("%s is not indexed; list the indexed servers with: mcp index list", args[0])
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Removed %d resources of %s from the index\n", n, args[0])
+			return nil
+		},
+	}
+}
+
+// updateIndex reads the resources of a server through src and updates its index, embedding
+// them if embed is set. It reports unreadable resources on errOut and what changed on out.
+func updateIndex(ctx context.Context, ix *index.Index, src index.Source, name string, maxBytes int, embed bool,
+	out, errOut io.Writer) ([]index.Document, error) {
+	opts, err := indexUpdateOptions(embed, errOut)
+	if err != nil {
+		return nil, err
+	}
+
+	read, failed := 0, 0
+	docs, err := index.Crawl(ctx, src, index.CrawlOptions{
+		MaxBytes: maxBytes,
+		Progress: func(uri string, readErr error) {
+			read++
+			if readErr != nil {
+				failed++
+				fmt.Fprintf(errOut, "Skipping %s: %v\n", uri, readErr)
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ix.Update(ctx, name, docs, opts)
+	if err != nil {
+		return nil, indexUpdateError(err, embed)
+	}
+	fmt.Fprintf(out, "Indexed %d of %d resources of %s", len(docs), read, name)
+	if skipped := read - len(docs); skipped > 0 {
+		fmt.Fprintf(out, " (%d unreadable, %d without text)", failed, skipped-failed)
+	}
+	fmt.Fprintf(out, ": %d added, %d changed, %d removed, %d unchanged\n",
+		result.Added, result.Changed, result.Removed, result.Unchanged)
+	return docs, nil
+}
+
+// indexUpdateOptions returns the options to update an index with, embedding resources with the
+// configured embeddings endpoint if embed is set.
+func indexUpdateOptions(embed bool, errOut io.Writer) (index.UpdateOptions, error) {
+	if !embed {
+		return index.UpdateOptions{}, nil
+	}
+	embedder, err := newEmbedder()
+	if err != nil {
+		return index.UpdateOptions{}, err
+	}
+	return index.UpdateOptions{
+		Embedder: embedder,
+		Model:    index.ModelKey(embedder),
+		Progress: func(done, total int) {
+			fmt.Fprintf(errOut, "Embedded %d of %d chunks\n", done, total)
+		},
+	}, nil
+}
+
+// indexUpdateError explains a failure to update an index.
+func indexUpdateError(err error, embed bool) error {
+	if embed {
+		return withHint(fmt.Errorf("failed to embed resources: %w", err),
+			"Configure the embeddings endpoint with MCPT_EMBEDDINGS_URL, MCPT_EMBEDDINGS_MODEL and MCPT_EMBEDDINGS_KEY")
+	}
+	return fmt.Errorf("failed to write index: %w", err)
+}
+
+// indexPath is the index the index commands use, set with --index.
+var indexPath string
+
+// openIndex opens the index at path, or the default index if path is empty.
+func openIndex(path string) (*index.Index, error) {
+	path, err := index.ResolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return index.Open(path)
+}
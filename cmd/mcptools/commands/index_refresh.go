@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/f/mcptools/pkg/index"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+func indexRefreshCmd() *cobra.Command {
+	var every time.Duration
+	var watch bool
+
+	cmd := &cobra.Command{
+		Use:          "refresh [--every duration] [--watch] [name...]",
+		Short:        "Index again the resources of indexed servers that changed, once, on a schedule or as they change",
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			ix, err := openIndex(indexPath)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = ix.Close() }()
+
+			servers, err := refreshedServers(context.Background(), ix, args)
+			if err != nil {
+				return err
+			}
+			r := &indexRefresher{ix: ix, out: thisCmd.OutOrStdout(), errOut: thisCmd.ErrOrStderr()}
+			if every <= 0 && !watch {
+				for _, s := range servers {
+					if err = r.refresh(context.Background(), s, nil); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return r.run(ctx, servers, every, watch)
+		},
+	}
+
+	cmd.Flags().DurationVar(&every, "every", 0, "Refresh again at this interval, such as 30m or 6h, until interrupted")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Stay connected and update resources as the servers report changes to them")
+	return cmd
+}
+
+// refreshedServers returns the indexed servers called names, or all of them if names is empty.
+func refreshedServers(ctx context.Context, ix *index.Index, names []string) ([]index.Server, error) {
+	if len(names) == 0 {
+		servers, err := ix.IndexedServers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(servers) == 0 {
+			return nil, withHint(errors.New("no servers are indexed"), "Build the index first with: mcp index build -- <server>")
+		}
+		return servers, nil
+	}
+
+	servers := make([]index.Server, 0, len(names))
+	for _, name := range names {
+		s, err := ix.Server(ctx, name)
+		if errors.Is(err, index.ErrServerNotIndexed) {
+			return nil, withHint(err, "List the indexed servers with: mcp index list")
+		}
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
+
+// indexRefresher refreshes the index of servers.
+type indexRefresher struct {
+	ix          *index.Index
+	out, errOut io.Writer
+}
+
+// indexChange is a change reported by a watched server: to the resource at uri, or to its list
+// of resources if uri is empty.
+type indexChange struct {
+	server index.Server
+	uri    string
+}
+
+// refresh indexes again the resources of s that changed, through mcpClient, or a new client
+// if it is nil. Watched clients are subscribed to the resources.
+func (r *indexRefresher) refresh(ctx context.Context, s index.Server, mcpClient *client.Client) error {
+	if len(s.Command) == 0 {
+		return withHint(fmt.Errorf("%s was indexed before its command was recorded", s.Name),
+			fmt.Sprintf("Build it again with: mcp index build --name %q -- <server>", s.Name))
+	}
+	watched := mcpClient != nil
+	if !watched {
+		var err error
+		if mcpClient, err = CreateClientFunc(s.Command); err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", s.Name, err)
+		}
+		defer func() { _ = mcpClient.Close() }()
+	}
+
+	docs, err := updateIndex(ctx, r.ix, mcpClient, s.Name, s.MaxBytes, s.Model != "", r.out, r.errOut)
+	if err != nil || !watched {
+		return err
+	}
+	if resources := mcpClient.GetServerCapabilities().Resources; resources == nil || !resources.Subscribe {
+		return nil
+	}
+	for _, doc := range docs {
+		subscribe := mcp.SubscribeRequest{}
+		subscribe.Params.URI = doc.URI
+		if err = mcpClient.Subscribe(ctx, subscribe); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", doc.URI, err)
+		}
+	}
+	return nil
+}
+
+// refreshResource indexes again the resource at uri of s, which reported a change to it.
+func (r *indexRefresher) refreshResource(ctx context.Context, s index.Server, mcpClient *client.Client, uri string) error {
+	resource := mcp.Resource{URI: uri}
+	if indexed, err := r.ix.Document(ctx, s.Name, uri); err == nil {
+		resource.Name, resource.MIMEType = indexed.Name, indexed.MIMEType
+	}
+	doc, ok, err := index.ReadDocument(ctx, mcpClient, resource, s.MaxBytes)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", uri, err)
+	}
+	if !ok {
+		return nil
+	}
+
+	opts := index.UpdateOptions{Partial: true}
+	if s.Model != "" {
+		if opts, err = indexUpdateOptions(true, r.errOut); err != nil {
+			return err
+		}
+		opts.Partial = true
+	}
+	result, err := r.ix.Update(ctx, s.Name, []index.Document{doc}, opts)
+	if err != nil {
+		return indexUpdateError(err, s.Model != "")
+	}
+	if result.Unchanged == 0 {
+		fmt.Fprintf(r.out, "Indexed %s of %s again\n", uri, s.Name)
+	}
+	return nil
+}
+
+// run refreshes servers every interval, if set, and with watch as they report changes, until
+// ctx is done. Failures are reported without stopping.
+func (r *indexRefresher) run(ctx context.Context, servers []index.Server, every time.Duration, watch bool) error {
+	changes := make(chan indexChange)
+	clients := map[string]*client.Client{}
+	if watch {
+		for _, s := range servers {
+			if len(s.Command) == 0 {
+				continue
+			}
+			mcpClient, err := CreateClientFunc(s.Command)
+			if err != nil {
+				return fmt.Errorf("failed to connect to %s: %w", s.Name, err)
+			}
+			defer func() { _ = mcpClient.Close() }()
+			mcpClient.OnNotification(func(notification mcp.JSONRPCNotification) {
+				change := indexChange{server: s}
+				switch notification.Method {
+				case mcp.MethodNotificationResourceUpdated:
+					if change.uri, _ = notification.Params.AdditionalFields["uri"].(string); change.uri == "" {
+						return
+					}
+				case mcp.MethodNotificationResourcesListChanged:
+				default:
+					return
+				}
+				// Notifications arrive on the reader of the client, which refreshing needs
+				go func() {
+					select {
+					case changes <- change:
+					case <-ctx.Done():
+					}
+				}()
+			})
+			clients[s.Name] = mcpClient
+		}
+	}
+
+	refreshAll := func() {
+		for _, s := range servers {
+			if err := r.refresh(ctx, s, clients[s.Name]); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(r.errOut, "Error refreshing %s: %v\n", s.Name, err)
+			}
+		}
+	}
+	refreshAll()
+
+	var ticks <-chan time.Time
+	if every > 0 {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticks:
+			refreshAll()
+		case change := <-changes:
+			var err error
+			if change.uri == "" {
+				err = r.refresh(ctx, change.server, clients[change.server.Name])
+			} else {
+				err = r.refreshResource(ctx, change.server, clients[change.server.Name], change.uri)
+			}
+			if err != nil && ctx.Err() == nil {
+				fmt.Fprintf(r.errOut, "Error refreshing %s: %v\n", change.server.Name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// InfoCmd creates the info command.
+func InfoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "info [command args...]",
+		Short: "Show what a server announced when initialized",
+		Long: `Show the initialize result of a server: its name and version, the protocol version it
+negotiated, the capabilities it declares, including experimental ones, and its instructions.
+
+Use --experimental to declare experimental capabilities of the client, e.g. to test a draft
+protocol extension, and see how the server answers.
+
+Examples:
+  mcp info npx -y @modelcontextprotocol/server-filesystem ~
+  mcp info --experimental '{"myFeature":{"enabled":true}}' ./server`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
+				_ = thisCmd.Help()
+				return
+			}
+
+			parsedArgs := ProcessFlags(args)
+
+			mcpClient, err := CreateClientFunc(parsedArgs)
+			if err != nil {
+				exitWithError(withHint(err, "Example: mcp info npx -y @modelcontextprotocol/server-filesystem ~"))
+			}
+			defer func() { _ = mcpClient.Close() }()
+
+			result := serverInitializeResult(mcpClient)
+			if result == nil {
+				exitWithError(errors.New("the server was not initialized, so it announced nothing"))
+			}
+
+			if jsonutils.ParseFormat(FormatOption) == jsonutils.FormatTable {
+				writeInfoTable(thisCmd.OutOrStdout(), result)
+				return
+			}
+			if formatErr := FormatAndPrintResponse(thisCmd, ConvertJSONToMap(result), nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+}
+
+// writeInfoTable prints an initialize result with one line per field, and one per
+// experimental capability.
+func writeInfoTable(w io.Writer, result *mcp.InitializeResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Server\t%s %s\n", result.ServerInfo.Name, result.ServerInfo.Version)
+	fmt.Fprintf(tw, "Protocol version\t%s\n", result.ProtocolVersion)
+	fmt.Fprintf(tw, "Capabilities\t%s\n", describeCapabilities(result.Capabilities))
+
+	names := make([]string, 0, len(result.Capabilities.Experimental))
+	for name := range result.Capabilities.Experimental {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	label := "Experimental"
+	for _, name := range names {
+		value, _ := json.Marshal(result.Capabilities.Experimental[name])
+		fmt.Fprintf(tw, "%s\t%s: %s\n", label, name, value)
+		label = ""
+	}
+
+	if result.Instructions != "" {
+		label = "Instructions"
+		for _, line := range strings.Split(strings.TrimSpace(result.Instructions), "\n") {
+			fmt.Fprintf(tw, "%s\t%s\n", label, line)
+			label = ""
+		}
+	}
+	_ = tw.Flush()
+}
+
+// describeCapabilities lists the standard capabilities, each with its options that are set.
+func describeCapabilities(capabilities mcp.ServerCapabilities) string {
+	var parts []string
+	if capabilities.Tools != nil {
+		parts = append(parts, capability("tools", capabilities.Tools))
+	}
+	if capabilities.Resources != nil {
+		parts = append(parts, capability("resources", capabilities.Resources))
+	}
+	if capabilities.Prompts != nil {
+		parts = append(parts, capability("prompts", capabilities.Prompts))
+	}
+	if capabilities.Logging != nil {
+		parts = append(parts, "logging")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// capability names a capability followed by the options it sets, as in "resources (subscribe)".
+func capability(name string, options any) string {
+	var fields map[string]bool
+	data, _ := json.Marshal(options)
+	_ = json.Unmarshal(data, &fields)
+
+	var set []string
+	for option, enabled := range fields {
+		if enabled {
+			set = append(set, option)
+		}
+	}
+	if len(set) == 0 {
+		return name
+	}
+	sort.Strings(set)
+	return name + " (" + strings.Join(set, ", ") + ")"
+}
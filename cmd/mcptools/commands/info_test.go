@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestWriteInfoTable(t *testing.T) {
+	result := &mcp.InitializeResult{
+		ProtocolVersion: "2025-03-26",
+		ServerInfo:      mcp.Implementation{Name: "probe", Version: "1.2.0"},
+		Instructions:    "Call search first.\nThen fetch.",
+	}
+	result.Capabilities.Tools = &struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	}{ListChanged: true}
+	result.Capabilities.Resources = &struct {
+		Subscribe   bool `json:"subscribe,omitempty"`
+		ListChanged bool `json:"listChanged,omitempty"`
+	}{Subscribe: true}
+	result.Capabilities.Experimental = map[string]any{
+		"zeta":      true,
+		"myFeature": map[string]any{"enabled": true},
+	}
+
+	var buf bytes.Buffer
+	writeInfoTable(&buf, result)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	want := []string{
+		"Server            probe 1.2.0",
+		"Protocol version  2025-03-26",
+		"Capabilities      tools (listChanged), resources (subscribe)",
+		`Experimental      myFeature: {"enabled":true}`,
+		"                  zeta: true",
+		"Instructions      Call search first.",
+		"                  Then fetch.",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("writeInfoTable() printed %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i := range want {
+		assertEquals(t, strings.TrimRight(lines[i], " "), want[i])
+	}
+}
+
+func TestDescribeCapabilitiesNone(t *testing.T) {
+	assertEquals(t, describeCapabilities(mcp.ServerCapabilities{}), "none")
+}
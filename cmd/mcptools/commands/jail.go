@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"github.com/f/mcptools/pkg/jail"
+	"github.com/spf13/cobra"
+)
+
+// JailExecCmd creates the hidden command --fs-jail runs servers through on Linux systems
+// without bubblewrap. It confines itself to the roots with Landlock, then executes the server.
+func JailExecCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:                jail.ExecCommand + " root... -- command [args...]",
+		Short:              "Run a server confined to directories",
+		Hidden:             true,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			roots, command, commandArgs, err := jail.ParseExecArgs(args)
+			if err != nil {
+				return err
+			}
+			return jail.Exec(roots, command, commandArgs)
+		},
+	}
+}
+
+// jailCommand returns the command line that runs a stdio server confined to the --fs-jail
+// roots.
+func jailCommand(command string, args []string) (string, []string, error) {
+	roots, err := jail.ParseRoots(FsJail)
+	if err != nil {
+		return "", nil, err
+	}
+	return jail.Command(roots, command, args)
+}
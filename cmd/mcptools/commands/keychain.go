@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/f/mcptools/pkg/keychain"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// KeychainCmd creates the keychain command.
+func KeychainCmd() *cobra.Command {
+	var backend string
+
+	cmd := &cobra.Command{
+		Use:   "keychain",
+		Short: "Store secrets in the system keychain",
+		Long: `Store secrets such as API keys and passwords in the secret store of the system: the macOS
+Keychain, the Windows Credential Manager, or libsecret (GNOME Keyring, KWallet) on Linux.
+Where none is available, secrets are kept in $HOME/.mcpt/secrets.enc, encrypted with the
+passphrase in MCPT_KEYCHAIN_PASSPHRASE. MCPT_KEYCHAIN or --backend picks a backend explicitly:
+keychain, wincred, libsecret or file.
+
+Stored secrets are referred to as keychain:<name> instead of being written out, in
+--auth-header, in the password of --auth-user and in the --env values of mcp run-wasm.
+
+Examples:
+  # Store a token, typed without echo
+  mcp keychain set github
+
+  # Store a token from another program
+  op read op://dev/github/token | mcp keychain set github
+
+  # Use it
+  mcp tools --auth-header keychain:github https://api.example.com/mcp
+  mcp run-wasm --env GITHUB_TOKEN=keychain:github server.wasm`,
+	}
+	cmd.PersistentFlags().StringVar(&backend, "backend", "", "Keychain backend: keychain, wincred, libsecret or file (default: the system's)")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:          "set name",
+		Short:        "Store a secret, read from the terminal or stdin",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			store, err := keychain.Open(backend)
+			if err != nil {
+				return err
+			}
+			if err = keychain.ValidateName(args[0]); err != nil {
+				return err
+			}
+			secret, err := readSecret(args[0])
+			if err != nil {
+				return err
+			}
+			if err = store.Set(args[0], secret); err != nil {
+				return err
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Stored %s in the %s keychain\n", args[0], store.Backend())
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:          "get name",
+		Short:        "Print a secret",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			store, err := keychain.Open(backend)
+			if err != nil {
+				return err
+			}
+			secret, err := store.Get(args[0])
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+			fmt.Fprintln(thisCmd.OutOrStdout(), secret)
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:          "delete name",
+		Short:        "Remove a secret",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			store, err := keychain.Open(backend)
+			if err != nil {
+				return err
+			}
+			if err = store.Delete(args[0]); err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Deleted %s from the %s keychain\n", args[0], store.Backend())
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// readSecret reads a secret from the terminal without echoing it, or from stdin when it is not
+// a terminal.
+func readSecret(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Secret for %s: ", name)
+		secret, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the secret: %w", err)
+		}
+		return string(secret), nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(os.Stdin, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the secret: %w", err)
+	}
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		return "", errors.New("no secret was given on stdin")
+	}
+	return secret, nil
+}
@@ -0,0 +1,18 @@
+//go:build lite || nollm
+
+package commands
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// llmIncluded reports whether this build includes the language model integrations.
+const llmIncluded = false
+
+// callToolWithFix fails, as this build leaves out the language model that proposes fixes.
+func callToolWithFix(context.Context, *client.Client, string, map[string]any) (*mcp.CallToolResult, error) {
+	return nil, notIncludedError(FlagFix, "llm")
+}
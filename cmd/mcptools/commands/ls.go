@@ -0,0 +1,319 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/protocol"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// lsType is a kind of entity listed by mcp ls.
+type lsType struct {
+	// name selects the type with --type, and key holds its entities in the output.
+	name    string
+	key     string
+	title   string
+	feature string
+	// optional types are missing from some servers declaring their feature.
+	optional bool
+	list     func(ctx context.Context, mcpClient *client.Client) ([]any, error)
+}
+
+// lsTypes are the entity types of mcp ls, in the order they are printed.
+var lsTypes = []lsType{
+	{name: "tools", key: "tools", title: "TOOLS", feature: featureTools, list: listToolsRaw},
+	{name: "resources", key: "resources", title: "RESOURCES", feature: featureResources,
+		list: func(ctx context.Context, mcpClient *client.Client) ([]any, error) {
+			resp, err := mcpClient.ListResources(ctx, mcp.ListResourcesRequest{})
+			if err != nil {
+				return nil, err
+			}
+			return ConvertJSONToSlice(resp.Resources), nil
+		}},
+	{name: "templates", key: "resourceTemplates", title: "RESOURCE TEMPLATES", feature: featureResources, optional: true,
+		list: func(ctx context.Context, mcpClient *client.Client) ([]any, error) {
+			resp, err := mcpClient.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+			if err != nil {
+				return nil, err
+			}
+			return ConvertJSONToSlice(resp.ResourceTemplates), nil
+		}},
+	{name: "prompts", key: "prompts", title: "PROMPTS", feature: featurePrompts,
+		list: func(ctx context.Context, mcpClient *client.Client) ([]any, error) {
+			resp, err := mcpClient.ListPrompts(ctx, mcp.ListPromptsRequest{})
+			if err != nil {
+				return nil, err
+			}
+			return ConvertJSONToSlice(resp.Prompts), nil
+		}},
+}
+
+// lsGroup is the listing of one entity type. unsupported is set when the server does not
+// declare the type's feature and rejected the listing.
+type lsGroup struct {
+	lsType
+	entities    []any
+	err         error
+	unsupported bool
+}
+
+// LsCmd creates the ls command.
+func LsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls [--type tools,resources,templates,prompts] [--limit n] [--] command args...",
+		Short: "List everything a server offers: tools, resources, resource templates and prompts",
+		Long: `List the tools, resources, resource templates and prompts of a server at once. They are
+fetched concurrently, following pagination to the end, and printed grouped by type. Use --type
+to list only some of the types.
+
+In the table format each group shows its first --limit entities (10 by default) and how many
+more there are; --limit 0 shows all of them. JSON output always holds every entity, with the
+errors of the listings that failed under "errors". Types the server does not declare are
+listed empty.
+
+Arguments after -- are passed to the server as they are, even those looking like mcp flags.
+
+Examples:
+  mcp ls -- npx -y @modelcontextprotocol/server-filesystem ~
+  mcp ls --type tools,prompts fs
+  mcp ls --limit 0 --format json https://example.com/mcp`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
+				return thisCmd.Help()
+			}
+
+			types, limit, serverArgs, err := parseLsArgs(args)
+			if err != nil {
+				return err
+			}
+			selected, err := parseLsTypes(types)
+			if err != nil {
+				return err
+			}
+
+			mcpClient, err := CreateClientFunc(serverArgs)
+			if err != nil {
+				return withHint(err, "Example: mcp ls -- npx -y @modelcontextprotocol/server-filesystem ~")
+			}
+			defer func() { _ = mcpClient.Close() }()
+
+			groups := listAll(context.Background(), mcpClient, selected)
+
+			if jsonutils.ParseFormat(FormatOption) == jsonutils.FormatTable {
+				writeLsTable(thisCmd.OutOrStdout(), groups, limit, serverArgs)
+			} else {
+				for _, group := range groups {
+					if group.unsupported {
+						warnUnsupported(group.feature, strings.ToLower(group.title))
+					}
+				}
+				if formatErr := FormatAndPrintResponse(thisCmd, lsOutput(groups), nil); formatErr != nil {
+					return formatErr
+				}
+			}
+
+			var failed []string
+			for _, group := range groups {
+				if group.err != nil {
+					failed = append(failed, group.name)
+				}
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("failed to list %s", strings.Join(failed, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+// parseLsArgs splits the arguments of mcp ls into the --type and --limit values and the server
+// command, applying the client flags. Flags may come before or after the server command, but
+// arguments after -- all go to the server.
+func parseLsArgs(args []string) (types string, limit int, serverArgs []string, err error) {
+	var verbatim []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, verbatim = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	limit = 10
+	parsedArgs := ProcessFlags(args)
+	for i := 0; i < len(parsedArgs); i++ {
+		arg := parsedArgs[i]
+		if arg != FlagType && arg != FlagLimit {
+			serverArgs = append(serverArgs, arg)
+			continue
+		}
+		if i+1 == len(parsedArgs) {
+			return "", 0, nil, usageError(fmt.Sprintf("%s needs a value", arg), "Example: mcp ls --type tools --limit 0 fs")
+		}
+		i++
+		if arg == FlagType {
+			types = parsedArgs[i]
+			continue
+		}
+		if limit, err = strconv.Atoi(parsedArgs[i]); err != nil || limit < 0 {
+			return "", 0, nil, usageError(fmt.Sprintf("invalid limit: %s", parsedArgs[i]), "--limit takes a number of entities, or 0 for all of them")
+		}
+	}
+
+	serverArgs = append(serverArgs, verbatim...)
+	if len(serverArgs) == 0 {
+		return "", 0, nil, usageError("a server command is required", "Example: mcp ls -- npx -y @modelcontextprotocol/server-filesystem ~")
+	}
+	return types, limit, serverArgs, nil
+}
+
+// parseLsTypes returns the types selected by a --type value, or all of them for "".
+func parseLsTypes(value string) ([]lsType, error) {
+	if strings.TrimSpace(value) == "" {
+		return lsTypes, nil
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, t := range lsTypes {
+			known = known || t.name == name
+		}
+		if !known {
+			return nil, usageError(fmt.Sprintf("unknown type: %s (supported: tools, resources, templates, prompts)", name),
+				"Example: mcp ls --type tools,prompts fs")
+		}
+		wanted[name] = true
+	}
+
+	var selected []lsType
+	for _, t := range lsTypes {
+		if wanted[t.name] {
+			selected = append(selected, t)
+		}
+	}
+	return selected, nil
+}
+
+// listAll lists the entities of each type concurrently.
+func listAll(ctx context.Context, mcpClient *client.Client, types []lsType) []lsGroup {
+	groups := make([]lsGroup, len(types))
+	var wg sync.WaitGroup
+	for i, t := range types {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			listCtx, capture := protocol.CaptureErrors(ctx)
+			entities, err := t.list(listCtx, mcpClient)
+			err = capture.Attach(err)
+			group := lsGroup{lsType: t, entities: entities, err: err}
+			switch {
+			case unsupported(mcpClient, t.feature, err) != nil:
+				group.err = nil
+				group.unsupported = true
+			case t.optional && methodNotFound(err):
+				group.err = nil
+			}
+			groups[i] = group
+		}()
+	}
+	wg.Wait()
+	return groups
+}
+
+// lsOutput is the JSON output of mcp ls: the entities of each type under its key, and the
+// errors of the listings that failed under "errors".
+func lsOutput(groups []lsGroup) map[string]any {
+	output := map[string]any{}
+	errs := map[string]any{}
+	for _, group := range groups {
+		entities := group.entities
+		if entities == nil {
+			entities = []any{}
+		}
+		output[group.key] = entities
+		if group.err != nil {
+			errs[group.key] = group.err.Error()
+		}
+	}
+	if len(errs) > 0 {
+		output["errors"] = errs
+	}
+	return output
+}
+
+// writeLsTable prints each group with its first limit entities, one per line with its
+// description, and the number left out.
+func writeLsTable(w io.Writer, groups []lsGroup, limit int, serverArgs []string) {
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%d)\n", group.title, len(group.entities))
+
+		switch {
+		case group.err != nil:
+			fmt.Fprintf(w, "  failed: %v\n", group.err)
+			continue
+		case group.unsupported:
+			fmt.Fprintf(w, "  none: the server does not declare the %s capability\n", group.feature)
+			continue
+		case len(group.entities) == 0:
+			fmt.Fprintln(w, "  none")
+			continue
+		}
+
+		shown := group.entities
+		if limit > 0 && len(shown) > limit {
+			shown = shown[:limit]
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, entity := range shown {
+			name, description := lsEntry(entity)
+			fmt.Fprintf(tw, "  %s\t%s\n", name, description)
+		}
+		_ = tw.Flush()
+
+		if more := len(group.entities) - len(shown); more > 0 {
+			fmt.Fprintf(w, "  ... and %d more, see all with: mcp ls --type %s --limit 0 -- %s\n",
+				more, group.name, strings.Join(serverArgs, " "))
+		}
+	}
+}
+
+// lsEntry returns how an entity is named in the table, with the first line of its
+// description cut to fit on one line.
+func lsEntry(entity any) (string, string) {
+	fields, _ := entity.(map[string]any)
+	name, _ := fields["name"].(string)
+	for _, key := range []string{"uri", "uriTemplate"} {
+		if uri, _ := fields[key].(string); uri != "" {
+			if name == "" || name == uri {
+				name = uri
+			} else {
+				name = uri + " (" + name + ")"
+			}
+		}
+	}
+
+	description, _ := fields["description"].(string)
+	description, _, _ = strings.Cut(strings.TrimSpace(description), "\n")
+	if runes := []rune(description); len(runes) > 60 {
+		description = string(runes[:57]) + "..."
+	}
+	return name, description
+}
@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/protocol"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestLsCmdGroupsTypes(t *testing.T) {
+	responses := map[string]map[string]any{
+		"tools/list": {"tools": []any{
+			map[string]any{"name": "read_file", "description": "Read a file\nin full", "inputSchema": map[string]any{"type": "object"}},
+			map[string]any{"name": "write_file", "inputSchema": map[string]any{"type": "object"}},
+		}},
+		"resources/list":           {"resources": []any{map[string]any{"uri": "file:///README.md", "name": "README"}}},
+		"resources/templates/list": {"resourceTemplates": []any{map[string]any{"uriTemplate": "file:///{path}", "name": "file"}}},
+		"prompts/list":             {"prompts": []any{map[string]any{"name": "review", "description": "Review code"}}},
+	}
+	cleanup := setupMockClient(func(method string, _ any) (map[string]any, error) {
+		return responses[method], nil
+	})
+	defer cleanup()
+
+	cmd := LsCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--limit", "1", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{"TOOLS (2)", "read_file  Read a file\n", "... and 1 more", "RESOURCES (1)",
+		"file:///README.md (README)", "RESOURCE TEMPLATES (1)", "file:///{path} (file)", "PROMPTS (1)", "review  Review code"} {
+		assertContains(t, output, want)
+	}
+	if strings.Contains(output, "write_file") {
+		t.Errorf("output shows tools beyond the limit:\n%s", output)
+	}
+}
+
+func TestParseLsTypes(t *testing.T) {
+	types, err := parseLsTypes("prompts, TOOLS")
+	if err != nil {
+		t.Fatalf("parseLsTypes() error = %v", err)
+	}
+	if len(types) != 2 || types[0].name != "tools" || types[1].name != "prompts" {
+		t.Errorf("parseLsTypes() = %v, want tools and prompts in listing order", types)
+	}
+	if _, err = parseLsTypes("tools,servers"); err == nil {
+		t.Error("parseLsTypes() accepted an unknown type")
+	}
+}
+
+func TestParseLsArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		types      string
+		limit      int
+		serverArgs []string
+		wantErr    bool
+	}{
+		{name: "defaults", args: []string{"fs"}, limit: 10, serverArgs: []string{"fs"}},
+		{name: "flags before the server", args: []string{"--type", "tools", "--limit", "0", "npx", "-y", "server"},
+			types: "tools", serverArgs: []string{"npx", "-y", "server"}},
+		{name: "flags after the server", args: []string{"npx", "-y", "server", "--limit", "3", "--type", "prompts"},
+			types: "prompts", limit: 3, serverArgs: []string{"npx", "-y", "server"}},
+		{name: "flags around --", args: []string{"--limit", "5", "--", "server", "--limit", "x", "--type", "y", "--"},
+			limit: 5, serverArgs: []string{"server", "--limit", "x", "--type", "y", "--"}},
+		{name: "invalid limit", args: []string{"--limit", "many", "fs"}, wantErr: true},
+		{name: "negative limit", args: []string{"fs", "--limit", "-1"}, wantErr: true},
+		{name: "missing limit", args: []string{"fs", "--limit"}, wantErr: true},
+		{name: "missing type", args: []string{"--type"}, wantErr: true},
+		{name: "no server", args: []string{"--type", "tools", "--"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			types, limit, serverArgs, err := parseLsArgs(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseLsArgs(%q) succeeded, want an error", tt.args)
+				}
+				return
+			}
+			if err != nil || types != tt.types || limit != tt.limit || !reflect.DeepEqual(serverArgs, tt.serverArgs) {
+				t.Errorf("parseLsArgs(%q) = %q, %d, %q, %v; want %q, %d, %q",
+					tt.args, types, limit, serverArgs, err, tt.types, tt.limit, tt.serverArgs)
+			}
+		})
+	}
+}
+
+func TestLsCmdPassesServerArgs(t *testing.T) {
+	cleanup := setupMockClient(func(_ string, _ any) (map[string]any, error) {
+		return map[string]any{"tools": []any{}}, nil
+	})
+	defer cleanup()
+	mockCreate := CreateClientFunc
+	var got []string
+	CreateClientFunc = func(args []string, opts ...client.ClientOption) (*client.Client, error) {
+		got = args
+		return mockCreate(args, opts...)
+	}
+
+	cmd := LsCmd()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetArgs([]string{"--type", "tools", "--", "server", "--type", "prompts", "--limit", "-1"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+	if want := []string{"server", "--type", "prompts", "--limit", "-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("server args = %q, want %q", got, want)
+	}
+
+	cmd = LsCmd()
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"--limit", "-1", "server"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid limit") {
+		t.Errorf("cmd.Execute() error = %v, want an invalid limit", err)
+	}
+}
+
+// sameMessageTransport declares resources and fails their listings with the same message, but
+// the method-not-found code only for resource templates.
+type sameMessageTransport struct {
+	MockTransport
+}
+
+func (t *sameMessageTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	response := &transport.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: request.ID}
+	if request.Method == "initialize" {
+		response.Result = json.RawMessage(`{"protocolVersion":"2025-03-26","capabilities":{"resources":{}},"serverInfo":{"name":"test","version":"1"}}`)
+		return response, nil
+	}
+	code := -32603
+	if request.Method == "resources/templates/list" {
+		code = codeMethodNotFound
+	}
+	response.Error = &struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}{Code: code, Message: "unavailable"}
+	return response, nil
+}
+
+func TestListAllKeepsErrorCodesApart(t *testing.T) {
+	mcpClient := client.NewClient(protocol.NewErrorTransport(&sameMessageTransport{}, rpcErrors))
+	if _, err := mcpClient.Initialize(context.Background(), mcp.InitializeRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Resources and resource templates, listed concurrently
+	types := lsTypes[1:3]
+	for i := 0; i < 20; i++ {
+		groups := listAll(context.Background(), mcpClient, types)
+		if groups[0].err == nil {
+			t.Fatal("the failed resources listing was taken for a missing method")
+		}
+		if groups[1].err != nil {
+			t.Fatalf("templates error = %v, want the missing method ignored", groups[1].err)
+		}
+	}
+}
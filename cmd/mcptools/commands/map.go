@@ -0,0 +1,285 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/spf13/cobra"
+)
+
+// Map flags.
+const (
+	FlagStdinAs       = "--stdin-as"
+	FlagParallel      = "--parallel"
+	FlagParallelShort = "-P"
+	FlagOnError       = "--on-error"
+)
+
+// Failure policies of map.
+const (
+	OnErrorContinue = "continue"
+	OnErrorStop     = "stop"
+)
+
+// mapJob is a line of input to call a tool with.
+type mapJob struct {
+	input string
+	index int
+}
+
+// mapResult is the outcome of the call made for a line.
+type mapResult struct {
+	result  map[string]any
+	err     error
+	input   string
+	index   int
+	skipped bool
+}
+
+// MapCmd creates the map command.
+func MapCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "map --stdin-as param [--params json] [--parallel n] [--on-error continue|stop] call tool [--] [command args...]",
+		Short: "Call a tool once for each line read from stdin",
+		Long: `Read lines from stdin and call a tool once for each, with the line as the value of the
+parameter named by --stdin-as, like xargs. Other parameters are given with --params. Blank
+lines are skipped.
+
+Calls are made over a single connection to the server, --parallel at a time (1 by default).
+Results are printed in the order of the input: with a header naming the line in table
+format, and as one JSON object per line with the input and the result or error in json and
+pretty formats. A call fails if the request fails or the tool reports an error; with
+--on-error stop no more calls are made after the first failure. The command fails if any
+call failed.
+
+Examples:
+  ls *.md | mcp map --stdin-as path call read_file -- npx -y @modelcontextprotocol/server-filesystem ~
+  cat urls.txt | mcp map --stdin-as url --params '{"max_length": 500}' -P 4 call fetch -- fetch
+  git diff --name-only | mcp map --stdin-as path --on-error stop call lint_file -f json linter`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			if len(args) == 0 || (len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort)) {
+				_ = thisCmd.Help()
+				return
+			}
+
+			const example = "Example: ls *.md | mcp map --stdin-as path call read_file -- npx -y @modelcontextprotocol/server-filesystem ~"
+
+			var (
+				param      string
+				paramsJSON string
+				tool       string
+				serverArgs []string
+			)
+			parallel := 1
+			onError := OnErrorContinue
+
+			parsedArgs := ProcessFlags(args)
+			for i := 0; i < len(parsedArgs); i++ {
+				switch {
+				case parsedArgs[i] == FlagStdinAs && i+1 < len(parsedArgs):
+					param = parsedArgs[i+1]
+					i++
+				case (parsedArgs[i] == FlagParams || parsedArgs[i] == FlagParamsShort) && i+1 < len(parsedArgs):
+					paramsJSON = parsedArgs[i+1]
+					i++
+				case (parsedArgs[i] == FlagParallel || parsedArgs[i] == FlagParallelShort) && i+1 < len(parsedArgs):
+					n, err := strconv.Atoi(parsedArgs[i+1])
+					if err != nil || n <= 0 {
+						exitWithError(usageError(fmt.Sprintf("invalid %s: %s", FlagParallel, parsedArgs[i+1]), example))
+					}
+					parallel = n
+					i++
+				case parsedArgs[i] == FlagOnError && i+1 < len(parsedArgs):
+					onError = parsedArgs[i+1]
+					if onError != OnErrorContinue && onError != OnErrorStop {
+						exitWithError(usageError(fmt.Sprintf("invalid %s: %s, expected continue or stop", FlagOnError, onError), example))
+					}
+					i++
+				case tool == "" && parsedArgs[i] == "call":
+					if i+1 >= len(parsedArgs) {
+						exitWithError(usageError("a tool to call is required", example))
+					}
+					tool = parsedArgs[i+1]
+					i++
+				case tool == "":
+					exitWithError(usageError(fmt.Sprintf("unexpected argument %q: expected call <tool>", parsedArgs[i]), example))
+				case parsedArgs[i] == "--":
+					serverArgs = append(serverArgs, parsedArgs[i+1:]...)
+					i = len(parsedArgs)
+				default:
+					serverArgs = append(serverArgs, parsedArgs[i])
+				}
+			}
+
+			if param == "" {
+				exitWithError(usageError(FlagStdinAs+" is required to name the parameter the lines are passed as", example))
+			}
+			if tool == "" {
+				exitWithError(usageError("a tool to call is required", example))
+			}
+			if len(serverArgs) == 0 && DiscoverOption == "" {
+				exitWithError(usageError("command to execute is required when using stdio transport", example))
+			}
+
+			params := map[string]any{}
+			if paramsJSON != "" {
+				if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+					exitWithError(usageError(fmt.Sprintf("invalid JSON for params: %v", err), example))
+				}
+			}
+			params = applyAliasDefaults(serverArgs, tool, params)
+
+			mcpClient, err := CreateClientFunc(serverArgs)
+			if err != nil {
+				exitWithError(err)
+			}
+			defer func() { _ = mcpClient.Close() }()
+
+			if params, err = expandGlobs(context.Background(), mcpClient, params); err != nil {
+				exitWithError(err)
+			}
+
+			call := func(ctx context.Context, input string) (map[string]any, error) {
+				callParams := make(map[string]any, len(params)+1)
+				for key, value := range params {
+					callParams[key] = value
+				}
+				callParams[param] = input
+
+				result, err := callToolRaw(ctx, mcpClient, tool, callParams)
+				if err != nil {
+					return nil, err
+				}
+				if isError, _ := result["isError"].(bool); isError {
+					return result, fmt.Errorf("tool reported an error")
+				}
+				return result, nil
+			}
+
+			calls, failed, stopped := mapLines(thisCmd.InOrStdin(), parallel, onError == OnErrorStop, call, func(r mapResult) {
+				printMapResult(thisCmd.OutOrStdout(), r)
+			})
+
+			if failed > 0 {
+				if stopped {
+					exitWithError(fmt.Errorf("stopped after %d of %d calls failed", failed, calls))
+				}
+				exitWithError(fmt.Errorf("%d of %d calls failed", failed, calls))
+			}
+		},
+	}
+}
+
+// mapLines calls call for each non-blank line of input, parallel calls at a time, and passes
+// the results to print in the order of the input. With stop, no more calls are made once one
+// failed. It returns the number of calls made and failed, and whether it stopped early.
+func mapLines(input io.Reader, parallel int, stop bool,
+	call func(ctx context.Context, input string) (map[string]any, error), print func(mapResult),
+) (calls, failed int, stopped bool) {
+	jobs := make(chan mapJob)
+	results := make(chan mapResult)
+	var halted atomic.Bool
+
+	go func() {
+		defer close(jobs)
+		scanner := bufio.NewScanner(input)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for index := 0; scanner.Scan() && !halted.Load(); {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			jobs <- mapJob{input: line, index: index}
+			index++
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read stdin: %v\n", err)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range parallel {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				r := mapResult{input: job.input, index: job.index}
+				if halted.Load() {
+					r.skipped = true
+				} else {
+					r.result, r.err = call(context.Background(), job.input)
+					if r.err != nil && stop {
+						halted.Store(true)
+					}
+				}
+				results <- r
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Results arrive as calls finish, and are held back until those before them are printed
+	pending := map[int]mapResult{}
+	next := 0
+	for r := range results {
+		pending[r.index] = r
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if r.skipped {
+				continue
+			}
+			calls++
+			if r.err != nil {
+				failed++
+			}
+			print(r)
+		}
+	}
+	return calls, failed, halted.Load()
+}
+
+// printMapResult writes the result of the call made for a line.
+func printMapResult(w io.Writer, r mapResult) {
+	if jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+		line := map[string]any{"input": r.input}
+		if r.result != nil {
+			line["result"] = r.result
+		}
+		if r.err != nil {
+			line["error"] = r.err.Error()
+		}
+		data, _ := json.Marshal(line)
+		fmt.Fprintln(w, string(data))
+		return
+	}
+
+	fmt.Fprintf(w, "==> %s <==\n", r.input)
+	if r.result != nil {
+		if output, err := jsonutils.Format(r.result, FormatOption); err == nil {
+			fmt.Fprintln(w, output)
+		}
+	}
+	if r.err != nil && r.result == nil {
+		fmt.Fprintf(w, "error: %v\n", r.err)
+	}
+	fmt.Fprintln(w)
+}
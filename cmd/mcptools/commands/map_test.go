@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMapCmdRun(t *testing.T) {
+	defer func() { FormatOption = "table" }()
+
+	cleanup := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method != "tools/call" {
+			return map[string]any{}, nil
+		}
+		arguments, _ := params.(map[string]any)["arguments"].(map[string]any)
+		return map[string]any{
+			"content": []any{map[string]any{"type": "text", "text": fmt.Sprintf("read %v as %v", arguments["path"], arguments["encoding"])}},
+		}, nil
+	})
+	defer cleanup()
+
+	cmd := MapCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetIn(strings.NewReader("a.md\n\nb.md\nc.md\n"))
+	cmd.SetArgs([]string{"--stdin-as", "path", "--params", `{"encoding":"utf8"}`, "-P", "2", "-f", "json", "call", "read_file", "--", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("output = %s, want a line for each input", buf.String())
+	}
+	for i, input := range []string{"a.md", "b.md", "c.md"} {
+		if !strings.Contains(lines[i], `"input":"`+input+`"`) || !strings.Contains(lines[i], "read "+input+" as utf8") {
+			t.Errorf("line %d = %s, want the result for %s", i+1, lines[i], input)
+		}
+	}
+}
+
+func TestMapLines(t *testing.T) {
+	call := func(_ context.Context, input string) (map[string]any, error) {
+		if input == "bad" {
+			return nil, errors.New("failed")
+		}
+		return map[string]any{"input": input}, nil
+	}
+
+	var printed []string
+	record := func(r mapResult) { printed = append(printed, r.input) }
+
+	calls, failed, stopped := mapLines(strings.NewReader("1\nbad\n2\n3\n"), 1, false, call, record)
+	if calls != 4 || failed != 1 || stopped || strings.Join(printed, ",") != "1,bad,2,3" {
+		t.Errorf("continue: calls=%d failed=%d stopped=%v printed=%v", calls, failed, stopped, printed)
+	}
+
+	printed = nil
+	calls, failed, stopped = mapLines(strings.NewReader("1\nbad\n2\n3\n"), 1, true, call, record)
+	if calls != 2 || failed != 1 || !stopped || strings.Join(printed, ",") != "1,bad" {
+		t.Errorf("stop: calls=%d failed=%d stopped=%v printed=%v", calls, failed, stopped, printed)
+	}
+}
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// formatCSV is the format value selecting CSV output in commands producing a table.
+const formatCSV = "csv"
+
+// capabilitySampling is the experimental capability some servers announce to say their tools
+// send sampling requests, which clients must support to use them.
+const capabilitySampling = "sampling"
+
+// matrixRow is what a server supports, as reported by mcp matrix. Counts are nil when the
+// server does not announce the capability.
+type matrixRow struct {
+	Server          string `json:"server"`
+	ProtocolVersion string `json:"protocolVersion"`
+	ServerInfo      string `json:"serverInfo"`
+	Tools           *int   `json:"tools"`
+	Resources       *int   `json:"resources"`
+	Subscribe       bool   `json:"subscribe"`
+	Prompts         *int   `json:"prompts"`
+	Logging         bool   `json:"logging"`
+	Sampling        bool   `json:"sampling"`
+	Error           string `json:"error,omitempty"`
+}
+
+// MatrixCmd creates the matrix command.
+func MatrixCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "matrix [alias...]",
+		Short: "Compare the capabilities of configured servers",
+		Long: `Connect to configured servers and compare what they support: the protocol version they
+negotiated, the number of tools, resources and prompts they offer, and whether they support
+resource subscriptions and logging. Without aliases, every registered alias is compared.
+
+The sampling column marks servers announcing the experimental "sampling" capability, which
+says their tools send sampling requests that the client must answer. MCP has no standard way
+for servers to declare this, so servers that do not announce it may still need sampling.
+
+Besides table, json and pretty, the matrix can be printed as CSV with --format csv.
+
+Examples:
+  mcp matrix
+  mcp matrix fs github --format csv`,
+		SilenceUsage: true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			servers, err := matrixServers(args)
+			if err != nil {
+				exitWithError(err)
+			}
+
+			results := forEachServer(servers, func(ctx context.Context, mcpClient *client.Client, name string) (matrixRow, error) {
+				return buildMatrixRow(ctx, mcpClient, name), nil
+			})
+
+			rows := make([]matrixRow, len(results))
+			for i, result := range results {
+				rows[i] = result.Value
+				rows[i].Server = result.Name
+				if result.Err != nil {
+					rows[i].Error = result.Err.Error()
+				}
+			}
+
+			if strings.EqualFold(FormatOption, formatCSV) {
+				if csvErr := writeMatrixCSV(thisCmd.OutOrStdout(), rows); csvErr != nil {
+					exitWithError(csvErr)
+				}
+				return
+			}
+
+			if formatErr := FormatAndPrintResponse(thisCmd, map[string]any{"matrix": ConvertJSONToSlice(rows)}, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+}
+
+// matrixServers returns the aliases to compare: the given names, or every registered alias.
+func matrixServers(names []string) ([]namedServer, error) {
+	aliases, _, err := alias.LoadAll(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aliases: %w", err)
+	}
+
+	if len(names) == 0 {
+		if len(aliases) == 0 {
+			return nil, withHint(fmt.Errorf("no aliases registered"), "Register servers with mcp alias add, or name them: mcp matrix fs github")
+		}
+		for name := range aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	servers := make([]namedServer, len(names))
+	for i, name := range names {
+		a, ok := aliases[name]
+		if !ok {
+			return nil, withHint(fmt.Errorf("alias %q does not exist", name), "List the registered aliases with mcp alias list")
+		}
+		servers[i] = namedServer{Name: name, Args: ParseCommandString(a.Command)}
+	}
+	return servers, nil
+}
+
+// buildMatrixRow describes what the server behind mcpClient supports. Listings that fail leave
+// their count out.
+func buildMatrixRow(ctx context.Context, mcpClient *client.Client, name string) matrixRow {
+	row := matrixRowFromInit(name, serverInitializeResult(mcpClient), mcpClient.GetServerCapabilities())
+
+	if row.Tools != nil {
+		row.Tools = nil
+		if tools, err := mcpClient.ListTools(ctx, mcp.ListToolsRequest{}); err == nil {
+			n := len(tools.Tools)
+			row.Tools = &n
+		}
+	}
+	if row.Resources != nil {
+		row.Resources = nil
+		if resources, err := mcpClient.ListResources(ctx, mcp.ListResourcesRequest{}); err == nil {
+			n := len(resources.Resources)
+			row.Resources = &n
+		}
+	}
+	if row.Prompts != nil {
+		row.Prompts = nil
+		if prompts, err := mcpClient.ListPrompts(ctx, mcp.ListPromptsRequest{}); err == nil {
+			n := len(prompts.Prompts)
+			row.Prompts = &n
+		}
+	}
+	return row
+}
+
+// matrixRowFromInit describes what a server announced in its initialize result. Counts of the
+// capabilities it announced are set to zero, to be filled in by listing them. Without an
+// initialize result, the capabilities known to the client are used.
+func matrixRowFromInit(name string, result *mcp.InitializeResult, capabilities mcp.ServerCapabilities) matrixRow {
+	row := matrixRow{Server: name}
+	if result != nil {
+		row.ProtocolVersion = result.ProtocolVersion
+		row.ServerInfo = strings.TrimSpace(result.ServerInfo.Name + " " + result.ServerInfo.Version)
+		capabilities = result.Capabilities
+	}
+
+	zero := func() *int { n := 0; return &n }
+	if capabilities.Tools != nil {
+		row.Tools = zero()
+	}
+	if capabilities.Resources != nil {
+		row.Resources = zero()
+		row.Subscribe = capabilities.Resources.Subscribe
+	}
+	if capabilities.Prompts != nil {
+		row.Prompts = zero()
+	}
+	row.Logging = capabilities.Logging != nil
+	_, row.Sampling = capabilities.Experimental[capabilitySampling]
+	return row
+}
+
+// writeMatrixCSV writes the matrix as CSV with a header row. Counts of capabilities a server
+// does not announce are empty.
+func writeMatrixCSV(w io.Writer, rows []matrixRow) error {
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"server", "protocolVersion", "serverInfo", "tools", "resources", "subscribe", "prompts", "logging", "sampling", "error"})
+
+	count := func(n *int) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.Itoa(*n)
+	}
+	for _, row := range rows {
+		_ = out.Write([]string{
+			row.Server, row.ProtocolVersion, row.ServerInfo,
+			count(row.Tools), count(row.Resources), strconv.FormatBool(row.Subscribe),
+			count(row.Prompts), strconv.FormatBool(row.Logging), strconv.FormatBool(row.Sampling),
+			row.Error,
+		})
+	}
+
+	out.Flush()
+	return out.Error()
+}
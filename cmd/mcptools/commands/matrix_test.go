@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMatrixRowFromInit(t *testing.T) {
+	result := &mcp.InitializeResult{
+		ProtocolVersion: "2025-03-26",
+		ServerInfo:      mcp.Implementation{Name: "files", Version: "1.2.0"},
+	}
+	result.Capabilities.Experimental = map[string]any{capabilitySampling: map[string]any{}}
+	result.Capabilities.Logging = &struct{}{}
+	result.Capabilities.Resources = &struct {
+		Subscribe   bool `json:"subscribe,omitempty"`
+		ListChanged bool `json:"listChanged,omitempty"`
+	}{Subscribe: true}
+
+	row := matrixRowFromInit("fs", result, mcp.ServerCapabilities{})
+	if row.ProtocolVersion != "2025-03-26" || row.ServerInfo != "files 1.2.0" {
+		t.Errorf("unexpected protocol or server info: %+v", row)
+	}
+	if row.Tools != nil || row.Prompts != nil || row.Resources == nil {
+		t.Errorf("expected only resources to be counted, got %+v", row)
+	}
+	if !row.Subscribe || !row.Logging || !row.Sampling {
+		t.Errorf("expected subscribe, logging and sampling, got %+v", row)
+	}
+
+	// Without a handshake, the capabilities known to the client are used
+	var capabilities mcp.ServerCapabilities
+	capabilities.Tools = &struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	}{}
+	row = matrixRowFromInit("fs", nil, capabilities)
+	if row.Tools == nil || row.ProtocolVersion != "" {
+		t.Errorf("expected tools from the client capabilities, got %+v", row)
+	}
+}
+
+func TestMatrixOutput(t *testing.T) {
+	three, zero := 3, 0
+	rows := []matrixRow{
+		{Server: "fs", ProtocolVersion: "2024-11-05", Tools: &three, Resources: &zero, Subscribe: true},
+		{Server: "broken", Error: "initialization timed out"},
+	}
+
+	var csvOut bytes.Buffer
+	if err := writeMatrixCSV(&csvOut, rows); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := `server,protocolVersion,serverInfo,tools,resources,subscribe,prompts,logging,sampling,error
+fs,2024-11-05,,3,0,true,,false,false,
+broken,,,,,false,,false,false,initialization timed out
+`
+	if csvOut.String() != wantCSV {
+		t.Errorf("unexpected CSV:\n%s\nwant:\n%s", csvOut.String(), wantCSV)
+	}
+
+	table, err := jsonutils.Format(map[string]any{"matrix": ConvertJSONToSlice(rows)}, "table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got:\n%s", table)
+	}
+	for _, want := range []string{"fs", "2024-11-05", "3", "yes"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("expected %q in %q", want, lines[1])
+		}
+	}
+	if !strings.Contains(lines[2], "error: initialization timed out") {
+		t.Errorf("expected the error in %q", lines[2])
+	}
+}
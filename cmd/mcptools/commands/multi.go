@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/protocol"
+	"github.com/mark3labs/mcp-go/client"
+)
+
+// Limits of commands that work with several servers at once.
+const (
+	// maxParallelServers bounds how many servers are started and initialized at the same time.
+	maxParallelServers = 8
+	// serverWorkTimeout bounds the work done on each server once it is initialized.
+	serverWorkTimeout = 30 * time.Second
+)
+
+// namedServer is one of several servers a command works with.
+type namedServer struct {
+	Name string
+	Args []string
+}
+
+// serverResult is the outcome of the work done on one server.
+type serverResult[T any] struct {
+	Value T
+	Err   error
+	Name  string
+}
+
+// forEachServer connects to servers concurrently, at most maxParallelServers at a time, and
+// runs fn on each. Every server has its own initialize and work timeouts, and a server that
+// fails does not stop the others. Results are returned in the order of servers.
+func forEachServer[T any](servers []namedServer, fn func(ctx context.Context, mcpClient *client.Client, name string) (T, error)) []serverResult[T] {
+	results := make([]serverResult[T], len(servers))
+	slots := make(chan struct{}, maxParallelServers)
+	var wg sync.WaitGroup
+
+	for i, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			result := &results[i]
+			result.Name = server.Name
+
+			mcpClient, err := CreateClientFunc(server.Args)
+			if err != nil {
+				result.Err = err
+				return
+			}
+			defer func() { _ = mcpClient.Close() }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), serverWorkTimeout)
+			defer cancel()
+			ctx, capture := protocol.CaptureErrors(ctx)
+			result.Value, result.Err = fn(ctx, mcpClient, server.Name)
+			result.Err = capture.Attach(result.Err)
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestForEachServer(t *testing.T) {
+	var running, peak atomic.Int32
+	originalFunc := CreateClientFunc
+	defer func() { CreateClientFunc = originalFunc }()
+	CreateClientFunc = func(args []string, _ ...client.ClientOption) (*client.Client, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if args[0] == "broken" {
+			return nil, errors.New("initialization timed out")
+		}
+		c := client.NewClient(&MockTransport{})
+		_, _ = c.Initialize(context.Background(), mcp.InitializeRequest{})
+		return c, nil
+	}
+
+	servers := make([]namedServer, 2*maxParallelServers)
+	for i := range servers {
+		servers[i] = namedServer{Name: fmt.Sprintf("s%d", i), Args: []string{"ok"}}
+	}
+	servers[3].Args = []string{"broken"}
+
+	results := forEachServer(servers, func(_ context.Context, _ *client.Client, name string) (string, error) {
+		return "listed " + name, nil
+	})
+
+	for i, result := range results {
+		if result.Name != servers[i].Name {
+			t.Errorf("result %d is for %s, want %s", i, result.Name, servers[i].Name)
+		}
+		switch {
+		case i == 3 && result.Err == nil:
+			t.Errorf("expected an error for the broken server")
+		case i != 3 && (result.Err != nil || result.Value != "listed "+result.Name):
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+	if p := peak.Load(); p < 2 || p > maxParallelServers {
+		t.Errorf("%d servers were initialized at once, want between 2 and %d", p, maxParallelServers)
+	}
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// outputFile collects the output of the command while --output-file is in effect, outputPath
+// is where it goes, and stdout is the standard output it replaces.
+var (
+	outputFile *os.File
+	outputPath string
+	stdout     *os.File
+)
+
+// outputFileFromArgs returns the value of the last --output-file flag in the arguments of a
+// command that parses its own flags. Arguments after "--" belong to the server.
+func outputFileFromArgs(args []string) (string, bool) {
+	path, found := "", false
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			break
+		}
+		if args[i] == FlagOutputFile && i+1 < len(args) {
+			path, found = args[i+1], true
+			i++
+		}
+	}
+	return path, found
+}
+
+// startOutputFile redirects stdout to a temporary file next to path, which FinishOutputFile
+// moves into place. Writing next to path keeps the final rename atomic, so readers of path never
+// see partial output. An empty path or "-" leaves stdout alone.
+func startOutputFile(path string) error {
+	if path == "" || path == "-" || outputFile != nil {
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	file, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	outputFile, outputPath, stdout = file, path, os.Stdout
+	os.Stdout = file
+	return nil
+}
+
+// FinishOutputFile restores stdout after --output-file redirected it. If the command succeeded,
+// the output replaces the file atomically, keeping the mode of an existing file; otherwise it is
+// discarded and the file is left as it was.
+func FinishOutputFile(succeeded bool) error {
+	if outputFile == nil {
+		return nil
+	}
+	file := outputFile
+	os.Stdout, outputFile = stdout, nil
+
+	if !succeeded {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil
+	}
+
+	err := file.Sync()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// Temporary files are private; give new files the mode shell redirection would
+		mode := os.FileMode(0o644)
+		if info, statErr := os.Stat(outputPath); statErr == nil {
+			mode = info.Mode().Perm()
+		} else if !errors.Is(statErr, os.ErrNotExist) {
+			err = statErr
+		}
+		if err == nil {
+			err = os.Chmod(file.Name(), mode) // #nosec G302 - output files are as readable as redirected stdout
+		}
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), outputPath)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
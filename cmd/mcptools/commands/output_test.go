@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.json")
+	if err := os.WriteFile(path, []byte("previous\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed command leaves the file as it was
+	if err := startOutputFile(path); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("partial")
+	if err := FinishOutputFile(false); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path, "previous\n")
+
+	if err := startOutputFile(path); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(`{"tools":[]}`)
+	// Nothing reaches the file before the command finishes
+	assertFile(t, path, "previous\n")
+	if err := FinishOutputFile(true); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path, "{\"tools\":[]}\n")
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("expected the mode of the replaced file to be kept, got %v", info.Mode())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("expected no temporary files to be left, got %d entries", len(entries))
+	}
+	if os.Stdout != stdout {
+		t.Error("stdout was not restored")
+	}
+}
+
+func TestOutputFileFromArgs(t *testing.T) {
+	path, ok := outputFileFromArgs([]string{"read_file", "--output-file", "a.json", "-f", "json", "server"})
+	if !ok || path != "a.json" {
+		t.Errorf("outputFileFromArgs() = %q, %v", path, ok)
+	}
+	if _, ok = outputFileFromArgs([]string{"query", "--", "server", "--output-file", "x"}); ok {
+		t.Error("arguments after -- belong to the server")
+	}
+
+	// The flag is not passed on to the server
+	if got := ProcessFlags([]string{"server", "--output-file", "a.json", "arg"}); !reflect.DeepEqual(got, []string{"server", "arg"}) {
+		t.Errorf("ProcessFlags() = %v", got)
+	}
+}
+
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("%s = %q, want %q", path, data, want)
+	}
+}
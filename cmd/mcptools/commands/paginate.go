@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/f/mcptools/pkg/workflow"
+	"github.com/mark3labs/mcp-go/client"
+)
+
+// cursorFollow says where a tool returns the cursor of its next page and which parameter
+// takes it, as in result.nextCursor=params.cursor.
+type cursorFollow struct {
+	from string
+	to   []string
+}
+
+// parseFollowCursor parses a --follow-cursor value such as result.nextCursor=params.cursor.
+func parseFollowCursor(spec string) (cursorFollow, error) {
+	from, to, ok := strings.Cut(spec, "=")
+	from, fromOK := strings.CutPrefix(from, "result.")
+	to, toOK := strings.CutPrefix(to, "params.")
+	if !ok || !fromOK || !toOK || from == "" || to == "" {
+		return cursorFollow{}, fmt.Errorf("invalid %s %q: expected result.<path>=params.<name>", FlagFollowCursor, spec)
+	}
+	return cursorFollow{from: from, to: strings.Split(to, ".")}, nil
+}
+
+// cursor returns the cursor of the next page in a result: at the path in the result, in its
+// structured content, or in JSON returned as text content. ok is false on the last page.
+func (f cursorFollow) cursor(result map[string]any) (any, bool) {
+	candidates := []any{result, result["structuredContent"]}
+	content, _ := result["content"].([]any)
+	for _, item := range content {
+		if text, _ := item.(map[string]any)["text"].(string); text != "" {
+			var decoded any
+			if json.Unmarshal([]byte(text), &decoded) == nil {
+				candidates = append(candidates, decoded)
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		value, found := workflow.Lookup(candidate, f.from)
+		if !found {
+			continue
+		}
+		switch v := value.(type) {
+		case nil:
+			return nil, false
+		case string:
+			return v, v != ""
+		default:
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// set returns a copy of params with the cursor set.
+func (f cursorFollow) set(params map[string]any, cursor any) map[string]any {
+	copied := make(map[string]any, len(params)+1)
+	for key, value := range params {
+		copied[key] = value
+	}
+	parent := copied
+	for _, key := range f.to[:len(f.to)-1] {
+		nested, _ := parent[key].(map[string]any)
+		child := make(map[string]any, len(nested)+1)
+		for k, v := range nested {
+			child[k] = v
+		}
+		parent[key] = child
+		parent = child
+	}
+	parent[f.to[len(f.to)-1]] = cursor
+	return copied
+}
+
+// callToolPages calls a tool again with the cursor of each page until the last page, or
+// maxPages pages, and returns the pages.
+func callToolPages(ctx context.Context, mcpClient *client.Client, tool string, params map[string]any,
+	follow cursorFollow, maxPages int,
+) ([]map[string]any, error) {
+	var pages []map[string]any
+	seen := map[string]bool{}
+
+	for {
+		page, err := callToolRaw(ctx, mcpClient, tool, params)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", len(pages)+1, err)
+		}
+		pages = append(pages, page)
+		if isError, _ := page["isError"].(bool); isError {
+			break
+		}
+
+		cursor, more := follow.cursor(page)
+		if !more {
+			break
+		}
+		// A server handing out the same cursor again would be paged forever
+		key := fmt.Sprint(cursor)
+		if seen[key] {
+			fmt.Fprintf(os.Stderr, "Warning: %s returned cursor %v again; stopped after %d pages\n", tool, cursor, len(pages))
+			break
+		}
+		seen[key] = true
+		if len(pages) >= maxPages {
+			fmt.Fprintf(os.Stderr, "Warning: stopped after %d pages, more are available; raise %s to fetch them\n", len(pages), FlagMaxPages)
+			break
+		}
+		params = follow.set(params, cursor)
+	}
+
+	return pages, nil
+}
+
+// mergePages concatenates the content of pages, and the arrays of their structured content.
+// Other fields are taken from the last page.
+func mergePages(pages []map[string]any) map[string]any {
+	merged := map[string]any{}
+	for key, value := range pages[len(pages)-1] {
+		merged[key] = value
+	}
+
+	var content []any
+	structured := map[string]any{}
+	for _, page := range pages {
+		items, _ := page["content"].([]any)
+		content = append(content, items...)
+
+		fields, _ := page["structuredContent"].(map[string]any)
+		for key, value := range fields {
+			if items, isArray := value.([]any); isArray {
+				previous, _ := structured[key].([]any)
+				structured[key] = append(previous, items...)
+			} else {
+				structured[key] = value
+			}
+		}
+	}
+	if content != nil {
+		merged["content"] = content
+	}
+	if _, ok := merged["structuredContent"]; ok {
+		merged["structuredContent"] = structured
+	}
+	return merged
+}
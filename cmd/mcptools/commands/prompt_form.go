@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// errPromptCancelled is returned when the form for the arguments of a prompt is abandoned.
+var errPromptCancelled = errors.New("prompt cancelled")
+
+// promptArguments converts parameters to the arguments of a prompt, which are strings. Strings
+// are kept as they are and other values are formatted.
+func promptArguments(params map[string]any) map[string]string {
+	if len(params) == 0 {
+		return nil
+	}
+	args := make(map[string]string, len(params))
+	for name, value := range params {
+		if text, ok := value.(string); ok {
+			args[name] = text
+			continue
+		}
+		args[name] = fmt.Sprint(value)
+	}
+	return args
+}
+
+// findPrompt returns the prompt called name as listed by the server, or nil if it is not listed.
+func findPrompt(mcpClient *client.Client, name string) (*mcp.Prompt, error) {
+	resp, err := mcpClient.ListPrompts(context.Background(), mcp.ListPromptsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range resp.Prompts {
+		if resp.Prompts[i].Name == name {
+			return &resp.Prompts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// askPromptArguments fills in the declared arguments of prompt missing from args, asking for
+// each with ask. Required arguments are asked again until they have a value; optional ones are
+// left out when the answer is empty.
+func askPromptArguments(w io.Writer, prompt *mcp.Prompt, args map[string]string, ask func(label string) (string, error)) (map[string]string, error) {
+	if args == nil {
+		args = map[string]string{}
+	}
+
+	header := false
+	for _, argument := range prompt.Arguments {
+		if _, ok := args[argument.Name]; ok {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(w, "Arguments of %s:\n", prompt.Name)
+			header = true
+		}
+
+		label := argument.Name
+		if argument.Required {
+			label += " (required)"
+		} else {
+			label += " (optional)"
+		}
+		if argument.Description != "" {
+			fmt.Fprintf(w, "  %s: %s\n", label, argument.Description)
+		}
+
+		for {
+			value, err := ask("  " + label + ": ")
+			if err != nil {
+				return nil, errPromptCancelled
+			}
+			value = strings.TrimSpace(value)
+			if value == "" && argument.Required {
+				fmt.Fprintf(w, "  %s is required\n", argument.Name)
+				continue
+			}
+			if value != "" {
+				args[argument.Name] = value
+			}
+			break
+		}
+	}
+	return args, nil
+}
+
+// printPromptPreview writes the messages of a prompt the way they would be sent to a model: the
+// role of each message followed by its content.
+func printPromptPreview(w io.Writer, result *mcp.GetPromptResult) {
+	if result.Description != "" {
+		fmt.Fprintln(w, result.Description)
+		fmt.Fprintln(w)
+	}
+	if len(result.Messages) == 0 {
+		fmt.Fprintln(w, "(no messages)")
+		return
+	}
+	for i, message := range result.Messages {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "[%s]\n", message.Role)
+		fmt.Fprintln(w, promptContentText(message.Content))
+	}
+}
+
+// promptContentText returns the text of the content of a prompt message, describing content
+// that is not text.
+func promptContentText(content mcp.Content) string {
+	switch c := content.(type) {
+	case mcp.TextContent:
+		return c.Text
+	case mcp.ImageContent:
+		return fmt.Sprintf("(image, %s)", c.MIMEType)
+	case mcp.AudioContent:
+		return fmt.Sprintf("(audio, %s)", c.MIMEType)
+	case mcp.EmbeddedResource:
+		if text, ok := c.Resource.(mcp.TextResourceContents); ok {
+			return text.Text
+		}
+		return "(embedded resource)"
+	case mcp.ResourceLink:
+		return fmt.Sprintf("(resource link, %s)", c.URI)
+	default:
+		return fmt.Sprintf("(%T content)", content)
+	}
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestPromptArguments(t *testing.T) {
+	got := promptArguments(map[string]any{"city": "Paris", "days": float64(3), "metric": true})
+	want := map[string]string{"city": "Paris", "days": "3", "metric": "true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("promptArguments() = %v, want %v", got, want)
+	}
+	if promptArguments(nil) != nil {
+		t.Error("expected no arguments for no parameters")
+	}
+}
+
+func TestAskPromptArguments(t *testing.T) {
+	prompt := &mcp.Prompt{
+		Name: "weather",
+		Arguments: []mcp.PromptArgument{
+			{Name: "city", Description: "City to forecast", Required: true},
+			{Name: "units", Description: "metric or imperial"},
+			{Name: "days", Required: true},
+		},
+	}
+
+	answers := []string{"", "Paris", "", "3"}
+	var labels []string
+	ask := func(label string) (string, error) {
+		labels = append(labels, label)
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}
+
+	var out bytes.Buffer
+	got, err := askPromptArguments(&out, prompt, map[string]string{"days": "5"}, ask)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// days was given, units was skipped and city was asked again after an empty answer
+	want := map[string]string{"city": "Paris", "days": "5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("askPromptArguments() = %v, want %v", got, want)
+	}
+	wantLabels := []string{"  city (required): ", "  city (required): ", "  units (optional): "}
+	if !reflect.DeepEqual(labels, wantLabels) {
+		t.Errorf("asked %q, want %q", labels, wantLabels)
+	}
+	for _, expected := range []string{"Arguments of weather:", "City to forecast", "city is required"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestAskPromptArgumentsCancelled(t *testing.T) {
+	prompt := &mcp.Prompt{Name: "p", Arguments: []mcp.PromptArgument{{Name: "a", Required: true}}}
+	_, err := askPromptArguments(&bytes.Buffer{}, prompt, nil, func(string) (string, error) {
+		return "", errors.New("aborted")
+	})
+	if !errors.Is(err, errPromptCancelled) {
+		t.Errorf("expected errPromptCancelled, got %v", err)
+	}
+}
+
+func TestPrintPromptPreview(t *testing.T) {
+	var out bytes.Buffer
+	printPromptPreview(&out, &mcp.GetPromptResult{
+		Description: "Forecast",
+		Messages: []mcp.PromptMessage{
+			{Role: mcp.RoleUser, Content: mcp.NewTextContent("Weather in Paris?")},
+			{Role: mcp.RoleAssistant, Content: mcp.NewImageContent("AAAA", "image/png")},
+		},
+	})
+
+	want := "Forecast\n\n[user]\nWeather in Paris?\n\n[assistant]\n(image, image/png)\n"
+	if out.String() != want {
+		t.Errorf("unexpected preview:\n%q\nwant:\n%q", out.String(), want)
+	}
+}
+
+func TestShellPromptForm(t *testing.T) {
+	oldFormat := FormatOption
+	FormatOption = "table"
+	defer func() { FormatOption = oldFormat }()
+
+	cmd, buf, cleanupSetup := setupTestCommand(t, "prompt:weather\nParis\n/q\n")
+	defer cleanupSetup()
+
+	var gotParams any
+	cleanupClient := setupMockClient(func(method string, params any) (map[string]any, error) {
+		switch method {
+		case "prompts/list":
+			return map[string]any{"prompts": []any{map[string]any{
+				"name":      "weather",
+				"arguments": []any{map[string]any{"name": "city", "required": true}},
+			}}}, nil
+		case "prompts/get":
+			gotParams = params
+			return map[string]any{"messages": []any{map[string]any{
+				"role":    "user",
+				"content": map[string]any{"type": "text", "text": "Weather in Paris?"},
+			}}}, nil
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanupClient()
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := json.Marshal(gotParams)
+	if !strings.Contains(string(data), `"arguments":{"city":"Paris"}`) {
+		t.Errorf("expected the city argument to be sent, got %s", data)
+	}
+	if !strings.Contains(buf.String(), "[user]\nWeather in Paris?") {
+		t.Errorf("expected the messages to be previewed, got:\n%s", buf.String())
+	}
+}
@@ -2,8 +2,6 @@ package commands
 
 import (
 	"context"
-	"fmt"
-	"os"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
@@ -26,12 +24,11 @@ func PromptsCmd() *cobra.Command {
 
 			mcpClient, err := CreateClientFunc(parsedArgs)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				fmt.Fprintf(os.Stderr, "Example: mcp prompts npx -y @modelcontextprotocol/server-filesystem ~\n")
-				os.Exit(1)
+				exitWithError(withHint(err, "Example: mcp prompts npx -y @modelcontextprotocol/server-filesystem ~"))
 			}
 
 			resp, listErr := mcpClient.ListPrompts(context.Background(), mcp.ListPromptsRequest{})
+			listErr = degradeList(mcpClient, featurePrompts, listErr)
 
 			var prompts []any
 			if listErr == nil && resp != nil {
@@ -39,9 +36,9 @@ func PromptsCmd() *cobra.Command {
 			}
 
 			promptsMap := map[string]any{"prompts": prompts}
+			translateDescriptions(promptsMap)
 			if formatErr := FormatAndPrintResponse(thisCmd, promptsMap, listErr); formatErr != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
-				os.Exit(1)
+				exitWithError(formatErr)
 			}
 		},
 	}
@@ -2,9 +2,14 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 
+	"github.com/f/mcptools/pkg/download"
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/objstore"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
 )
@@ -12,8 +17,24 @@ import (
 // ReadResourceCmd creates the read-resource command.
 func ReadResourceCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:                "read-resource resource [command args...]",
-		Short:              "Read a resource on the MCP server",
+		Use:   "read-resource resource [command args...]",
+		Short: "Read a resource on the MCP server",
+		Long: `Read a resource on the MCP server and print it. With --output (-o) the resource is read in
+chunks and saved to a file, resuming where an interrupted download stopped, or streamed to
+object storage when the output is an s3://bucket/key or gs://bucket/key URL. The object only
+appears once the whole resource was read and its checksum verified.
+
+Credentials for s3:// are AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, or the
+AWS_PROFILE profile of ~/.aws/credentials, with the region in AWS_REGION or ~/.aws/config;
+AWS_ENDPOINT_URL_S3 points them at S3-compatible storage. Credentials for gs:// are an HMAC key
+in GCS_HMAC_ACCESS_KEY_ID and GCS_HMAC_SECRET, or Application Default Credentials: the file in
+GOOGLE_APPLICATION_CREDENTIALS or written by gcloud auth application-default login, or the
+metadata server on Google Cloud.
+
+Examples:
+  mcp read-resource test://static/resource/1 npx -y @modelcontextprotocol/server-everything
+  mcp read-resource -o dump.sql --verify sha256:9f86d0... db://dump https://example.com/mcp
+  mcp read-resource -o s3://backups/2026/dump.sql db://dump https://example.com/mcp`,
 		DisableFlagParsing: true,
 		SilenceUsage:       true,
 		Run: func(thisCmd *cobra.Command, args []string) {
@@ -23,22 +44,26 @@ func ReadResourceCmd() *cobra.Command {
 			}
 
 			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: resource name is required")
-				fmt.Fprintln(
-					os.Stderr,
-					"Example: mcp read-resource test://static/resource/1 npx -y @modelcontextprotocol/server-filesystem ~",
-				)
-				os.Exit(1)
+				exitWithError(usageError("resource name is required", "Example: mcp read-resource test://static/resource/1 npx -y @modelcontextprotocol/server-filesystem ~"))
 			}
 
 			cmdArgs := args
 			parsedArgs := []string{}
 			resourceName := ""
+			outputPath := ""
+			verifySum := ""
+			dedup := false
+			chunkSize := download.DefaultChunkSize
 
 			i := 0
 			resourceExtracted := false
 
 			for i < len(cmdArgs) {
+				if n := processClientFlag(cmdArgs, i); n > 0 {
+					i += n
+					continue
+				}
+
 				switch {
 				case (cmdArgs[i] == FlagFormat || cmdArgs[i] == FlagFormatShort) && i+1 < len(cmdArgs):
 					FormatOption = cmdArgs[i+1]
@@ -46,6 +71,22 @@ func ReadResourceCmd() *cobra.Command {
 				case (cmdArgs[i] == FlagParams || cmdArgs[i] == FlagParamsShort) && i+1 < len(cmdArgs):
 					ParamsString = cmdArgs[i+1]
 					i += 2
+				case (cmdArgs[i] == FlagOutput || cmdArgs[i] == FlagOutputShort) && i+1 < len(cmdArgs):
+					outputPath = cmdArgs[i+1]
+					i += 2
+				case cmdArgs[i] == FlagDedup:
+					dedup = true
+					i++
+				case cmdArgs[i] == FlagVerify && i+1 < len(cmdArgs):
+					verifySum = cmdArgs[i+1]
+					i += 2
+				case cmdArgs[i] == FlagChunkSize && i+1 < len(cmdArgs):
+					size, err := strconv.Atoi(cmdArgs[i+1])
+					if err != nil || size <= 0 {
+						exitWithError(usageError(fmt.Sprintf("invalid chunk size: %s", cmdArgs[i+1]), ""))
+					}
+					chunkSize = size
+					i += 2
 				case !resourceExtracted:
 					resourceName = cmdArgs[i]
 					resourceExtracted = true
@@ -57,23 +98,37 @@ func ReadResourceCmd() *cobra.Command {
 			}
 
 			if resourceName == "" {
-				fmt.Fprintln(os.Stderr, "Error: resource name is required")
-				fmt.Fprintln(
-					os.Stderr,
-					"Example: mcp read-resource test://static/resource/1 npx -y @modelcontextprotocol/server-filesystem ~",
-				)
-				os.Exit(1)
+				exitWithError(usageError("resource name is required", "Example: mcp read-resource test://static/resource/1 npx -y @modelcontextprotocol/server-filesystem ~"))
+			}
+
+			if (verifySum != "" || dedup) && outputPath == "" {
+				exitWithError(usageError("--verify and --dedup require --output", ""))
+			}
+			if dedup && objstore.IsURL(outputPath) {
+				exitWithError(usageError("--dedup requires a local --output, not object storage", ""))
+			}
+
+			if outputPath != "" {
+				opts := download.Options{
+					Verify:    verifySum,
+					ChunkSize: chunkSize,
+					Retries:   download.DefaultRetries,
+				}
+				if err := downloadResource(resourceName, outputPath, opts, dedup, parsedArgs); err != nil {
+					exitWithError(err)
+				}
+				return
 			}
 
 			mcpClient, clientErr := CreateClientFunc(parsedArgs)
 			if clientErr != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", clientErr)
-				os.Exit(1)
+				exitWithError(clientErr)
 			}
 
 			request := mcp.ReadResourceRequest{}
 			request.Params.URI = resourceName
 			resp, execErr := mcpClient.ReadResource(context.Background(), request)
+			execErr = checkSupported(mcpClient, featureResources, execErr)
 
 			var responseMap map[string]any
 			if execErr == nil && resp != nil {
@@ -83,9 +138,89 @@ func ReadResourceCmd() *cobra.Command {
 			}
 
 			if formatErr := FormatAndPrintResponse(thisCmd, responseMap, execErr); formatErr != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
-				os.Exit(1)
+				exitWithError(formatErr)
 			}
 		},
 	}
 }
+
+// downloadResource reads a resource in chunks into outputPath, reconnecting to the server and
+// resuming from the last complete chunk when the connection drops. The SHA-256 checksum of the
+// file is printed and verified against the expected checksum and the one announced by the server.
+// With dedup, the file is hard linked to a blob in the content-addressed store.
+func downloadResource(uri, outputPath string, opts download.Options, dedup bool, serverArgs []string) error {
+	connect := func() (download.ReadFunc, func(), error) {
+		mcpClient, err := CreateClientFunc(serverArgs)
+		if err != nil {
+			return nil, nil, err
+		}
+		return download.NewTransportReader(mcpClient.GetTransport()), func() { _ = mcpClient.Close() }, nil
+	}
+	if objstore.IsURL(outputPath) {
+		return uploadResource(uri, outputPath, opts, connect)
+	}
+
+	result, err := download.Resource(context.Background(), connect, uri, outputPath, opts)
+	if errors.Is(err, download.ErrChecksumMismatch) {
+		return fmt.Errorf("download of %s rejected: %w", uri, err)
+	}
+	if err != nil {
+		return fmt.Errorf("download interrupted after %d bytes, run the command again to resume: %w", result.Size, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s to %s\n", jsonutils.FormatBytes(result.Size), outputPath)
+	printChecksum(result, opts)
+
+	if !dedup {
+		return nil
+	}
+
+	storePath, err := download.GetStorePath()
+	if err != nil {
+		return err
+	}
+	existing, err := download.NewStore(storePath).Link(outputPath, result.SHA256)
+	if err != nil {
+		return err
+	}
+	if existing {
+		fmt.Fprintf(os.Stderr, "Linked to existing blob in %s\n", storePath)
+	}
+	return nil
+}
+
+// uploadResource streams a resource read in chunks into an object in S3 or GCS, without a local
+// copy. The upload is only completed once the checksum is verified, so a failed or rejected
+// read leaves no object behind.
+func uploadResource(uri, dest string, opts download.Options, connect download.ConnectFunc) error {
+	ctx := context.Background()
+	w, err := objstore.Create(ctx, dest)
+	if err != nil {
+		return withHint(err, "See the credentials read for object storage with: mcp read-resource --help")
+	}
+
+	result, err := download.Stream(ctx, connect, uri, w, opts)
+	if err != nil {
+		w.Abort()
+		if errors.Is(err, download.ErrChecksumMismatch) {
+			return fmt.Errorf("upload of %s rejected: %w", uri, err)
+		}
+		return fmt.Errorf("upload of %s to %s failed after %d bytes: %w", uri, dest, result.Size, err)
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("upload of %s to %s failed: %w", uri, dest, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Uploaded %s to %s\n", jsonutils.FormatBytes(result.Size), dest)
+	printChecksum(result, opts)
+	return nil
+}
+
+// printChecksum reports the SHA-256 checksum of a download, and whether it was verified.
+func printChecksum(result download.Result, opts download.Options) {
+	fmt.Fprintf(os.Stderr, "SHA-256: %s", result.SHA256)
+	if opts.Verify != "" || result.ServerSHA256 != "" {
+		fmt.Fprint(os.Stderr, " (verified)")
+	}
+	fmt.Fprintln(os.Stderr)
+}
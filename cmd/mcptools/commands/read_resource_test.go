@@ -2,7 +2,15 @@ package commands
 
 import (
 	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/f/mcptools/pkg/download"
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func TestResourceReadCmd_RunHelp(t *testing.T) {
@@ -67,3 +75,35 @@ func TestReadResourceCmdRun_Success(t *testing.T) {
 	assertContains(t, output, "text/plain")
 	assertContains(t, output, "bar")
 }
+
+func TestUploadResource(t *testing.T) {
+	var uploaded []string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		uploaded = append(uploaded, r.Method+" "+r.URL.Path+" "+string(body))
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "id")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+
+	connect := func() (download.ReadFunc, func(), error) {
+		return func(_ context.Context, uri string, _ map[string]any) (*mcp.ReadResourceResult, error) {
+			return &mcp.ReadResourceResult{
+				Contents: []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, Text: "dump"}},
+			}, nil
+		}, func() {}, nil
+	}
+
+	err := uploadResource("db://dump", "s3://backups/dump.sql", download.Options{Verify: strings.Repeat("0", 64)}, connect)
+	if err == nil || len(uploaded) != 0 {
+		t.Errorf("upload of a mismatching resource: error = %v, requests = %v", err, uploaded)
+	}
+
+	if err = uploadResource("db://dump", "s3://backups/dump.sql", download.Options{}, connect); err != nil {
+		t.Fatalf("uploadResource() error = %v", err)
+	}
+	if len(uploaded) != 1 || uploaded[0] != "PUT /backups/dump.sql dump" {
+		t.Errorf("requests = %v", uploaded)
+	}
+}
@@ -0,0 +1,290 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+	"github.com/f/mcptools/pkg/record"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// ReplayCmd creates the replay command.
+func ReplayCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay recording.jsonl [command args...]",
+		Short: "Replay a recorded session against a server",
+		Long: `Replay the requests of a session recorded with --record and report which responses differ
+from the recorded ones.
+
+Values that look like credentials (known token prefixes, JWTs, long random strings and fields
+such as password or api_key) are replaced in recordings by mcpt-secret-... tokens. The secrets
+are kept in the local vault at $HOME/.mcpt/vault.json, so recordings can be shared without
+leaking them and replayed on the machine that made them.
+
+The server defaults to the one the session was recorded with. With --record, the replayed
+session is recorded to another file, stamped with the times of the recording it replays, so
+replaying the same session against the same server records the same thing each time.
+
+Examples:
+  # Record a session
+  mcp call read_file --params '{"path":"README.md"}' --record session.jsonl npx -y @modelcontextprotocol/server-filesystem ~
+
+  # Replay it against the same server
+  mcp replay session.jsonl
+
+  # Replay it against another build of the server
+  mcp replay session.jsonl node ./dist/index.js ~
+
+  # Record the replay, to compare it with another one
+  mcp replay session.jsonl --record replayed.jsonl`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
+				return thisCmd.Help()
+			}
+
+			parsedArgs := ProcessFlags(args)
+			if len(parsedArgs) == 0 {
+				return fmt.Errorf("a recording is required")
+			}
+
+			entries, err := record.Load(parsedArgs[0])
+			if err != nil {
+				return err
+			}
+
+			vaultPath, err := record.GetVaultPath()
+			if err != nil {
+				return err
+			}
+			vault, err := record.OpenVault(vaultPath)
+			if err != nil {
+				return err
+			}
+
+			serverArgs := parsedArgs[1:]
+			if len(serverArgs) == 0 {
+				if serverArgs, err = recordedServer(entries, vault); err != nil {
+					return err
+				}
+			}
+
+			// Replaying must not append to the recording being replayed
+			if RecordPath != "" {
+				if sameFile(RecordPath, parsedArgs[0]) {
+					return usageError("cannot record a replay to the recording it replays",
+						"mcp replay session.jsonl --record replayed.jsonl")
+				}
+				recordingClock = clock.NewReplay(entryTimes(entries))
+			}
+			mcpClient, err := CreateClientFunc(serverArgs)
+			if err != nil {
+				return fmt.Errorf("failed to create client: %w", err)
+			}
+			defer func() { _ = mcpClient.Close() }()
+
+			differences, err := replayRequests(thisCmd, entries, vault, mcpClient.GetTransport())
+			if err != nil {
+				return err
+			}
+			if differences > 0 {
+				fmt.Fprintf(os.Stderr, "%d responses differ from the recording\n", differences)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
+
+// recordingClock stamps the entries of recordings made with --record. Replays set it to follow
+// the times of the recording they replay.
+var recordingClock clock.Clock = clock.Real
+
+// recordingVault is the vault shared by the recordings of a command, so that concurrent
+// sessions do not overwrite each other's secrets.
+var (
+	recordingVault      *record.Vault
+	recordingVaultMutex sync.Mutex
+)
+
+// startRecording opens the recording requested with --record for a session with the server
+// run by args.
+func startRecording(args []string) (*record.Recorder, error) {
+	vault, err := openRecordingVault()
+	if err != nil {
+		return nil, err
+	}
+	recorder, err := record.Open(RecordPath, vault)
+	if err != nil {
+		return nil, err
+	}
+	recorder.SetClock(recordingClock)
+	if err = recorder.Start(args); err != nil {
+		_ = recorder.Close()
+		return nil, err
+	}
+	return recorder, nil
+}
+
+// entryTimes returns the times of the entries of a recording.
+func entryTimes(entries []record.Entry) []time.Time {
+	times := make([]time.Time, len(entries))
+	for i, entry := range entries {
+		times[i] = entry.Time
+	}
+	return times
+}
+
+// sameFile reports whether two paths name the same existing file.
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// openRecordingVault opens the vault shared by the recordings of the command.
+func openRecordingVault() (*record.Vault, error) {
+	recordingVaultMutex.Lock()
+	defer recordingVaultMutex.Unlock()
+
+	if recordingVault == nil {
+		vaultPath, err := record.GetVaultPath()
+		if err != nil {
+			return nil, err
+		}
+		if recordingVault, err = record.OpenVault(vaultPath); err != nil {
+			return nil, err
+		}
+	}
+	return recordingVault, nil
+}
+
+// recordedServer returns the server command of the first session in a recording.
+func recordedServer(entries []record.Entry, vault *record.Vault) ([]string, error) {
+	for _, entry := range entries {
+		if entry.Direction != record.DirectionStart {
+			continue
+		}
+		server := make([]string, len(entry.Server))
+		for i, arg := range entry.Server {
+			value, err := vault.Detokenize(arg)
+			if err != nil {
+				return nil, err
+			}
+			server[i], _ = value.(string)
+		}
+		return server, nil
+	}
+	return nil, fmt.Errorf("the recording does not name a server; pass one after the recording")
+}
+
+// recordedMessage holds the fields of a recorded message needed to replay it.
+type recordedMessage struct {
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+	Method string          `json:"method"`
+	ID     json.RawMessage `json:"id"`
+}
+
+// replayRequests sends every recorded request except the handshake and compares the responses,
+// tokenized like the recording, with the recorded ones. It returns the number that differ.
+func replayRequests(cmd *cobra.Command, entries []record.Entry, vault *record.Vault, t transport.Interface) (int, error) {
+	responses := map[string]recordedMessage{}
+	var requests []recordedMessage
+	for _, entry := range entries {
+		var msg recordedMessage
+		if entry.Message == nil || json.Unmarshal(entry.Message, &msg) != nil || len(msg.ID) == 0 {
+			continue
+		}
+		switch {
+		case entry.Direction == record.DirectionSent && msg.Method != "" && msg.Method != string(mcp.MethodInitialize):
+			requests = append(requests, msg)
+		case entry.Direction == record.DirectionReceived && msg.Method == "":
+			responses[string(msg.ID)] = msg
+		}
+	}
+
+	differences := 0
+	for i, msg := range requests {
+		var params any
+		if len(msg.Params) > 0 {
+			if err := json.Unmarshal(msg.Params, &params); err != nil {
+				return 0, err
+			}
+		}
+		params, err := vault.Detokenize(params)
+		if err != nil {
+			return 0, err
+		}
+
+		response, err := t.SendRequest(context.Background(), transport.JSONRPCRequest{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      mcp.NewRequestId(fmt.Sprintf("mcpt-replay-%d", i+1)),
+			Method:  msg.Method,
+			Params:  params,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", msg.Method, err)
+		}
+
+		var got recordedMessage
+		if got.Result, err = tokenizeRaw(vault, response.Result); err != nil {
+			return 0, err
+		}
+		if response.Error != nil {
+			data, _ := json.Marshal(response.Error)
+			if got.Error, err = tokenizeRaw(vault, data); err != nil {
+				return 0, err
+			}
+		}
+
+		status := "same"
+		want := responses[string(msg.ID)]
+		if !sameJSON(got.Result, want.Result) || !sameJSON(got.Error, want.Error) {
+			status = "differs"
+			differences++
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "[%d] %s: %s\n", i+1, msg.Method, status)
+	}
+
+	return differences, nil
+}
+
+// tokenizeRaw replaces the secrets in a JSON document the way recordings do.
+func tokenizeRaw(vault *record.Vault, data json.RawMessage) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	value, err := vault.Tokenize(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// sameJSON reports whether two JSON documents hold the same value, treating absent and null
+// alike.
+func sameJSON(a, b json.RawMessage) bool {
+	var x, y any
+	if len(a) > 0 {
+		_ = json.Unmarshal(a, &x)
+	}
+	if len(b) > 0 {
+		_ = json.Unmarshal(b, &y)
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -2,8 +2,6 @@ package commands
 
 import (
 	"context"
-	"fmt"
-	"os"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
@@ -26,12 +24,11 @@ func ResourcesCmd() *cobra.Command {
 
 			mcpClient, err := CreateClientFunc(parsedArgs)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				fmt.Fprintf(os.Stderr, "Example: mcp resources npx -y @modelcontextprotocol/server-filesystem ~\n")
-				os.Exit(1)
+				exitWithError(withHint(err, "Example: mcp resources npx -y @modelcontextprotocol/server-filesystem ~"))
 			}
 
 			resp, listErr := mcpClient.ListResources(context.Background(), mcp.ListResourcesRequest{})
+			listErr = degradeList(mcpClient, featureResources, listErr)
 
 			var resources []any
 			if listErr == nil && resp != nil {
@@ -40,8 +37,7 @@ func ResourcesCmd() *cobra.Command {
 
 			resourcesMap := map[string]any{"resources": resources}
 			if formatErr := FormatAndPrintResponse(thisCmd, resourcesMap, listErr); formatErr != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
-				os.Exit(1)
+				exitWithError(formatErr)
 			}
 		},
 	}
@@ -4,21 +4,73 @@ Package commands implements individual commands for the MCP CLI.
 package commands
 
 import (
+	"os"
+	"time"
+
+	"github.com/f/mcptools/pkg/httpclient"
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/record"
 	"github.com/spf13/cobra"
 )
 
 // flags.
 const (
-	FlagFormat      = "--format"
-	FlagFormatShort = "-f"
-	FlagParams      = "--params"
-	FlagParamsShort = "-p"
-	FlagHelp        = "--help"
-	FlagHelpShort   = "-h"
-	FlagServerLogs  = "--server-logs"
-	FlagTransport   = "--transport"
-	FlagAuthUser    = "--auth-user"
-	FlagAuthHeader  = "--auth-header"
+	FlagFormat       = "--format"
+	FlagFormatShort  = "-f"
+	FlagParams       = "--params"
+	FlagParamsShort  = "-p"
+	FlagHelp         = "--help"
+	FlagHelpShort    = "-h"
+	FlagServerLogs   = "--server-logs"
+	FlagTransport    = "--transport"
+	FlagAuthUser     = "--auth-user"
+	FlagAuthHeader   = "--auth-header"
+	FlagAuthToken    = "--auth-token-file"
+	FlagDeviceAuth   = "--device-auth"
+	FlagVerifyServer = "--verify-server"
+	FlagFsJail       = "--fs-jail"
+	FlagStats        = "--stats"
+	FlagStrict       = "--strict"
+	FlagQuirks       = "--quirks"
+	FlagNoInit       = "--no-initialize"
+	FlagClientInfo   = "--client-info"
+	FlagClientName   = "--client-name"
+	FlagClientVer    = "--client-version"
+	FlagExperimental = "--experimental"
+	FlagIDPrefix     = "--id-prefix"
+	FlagCompression  = "--compression"
+	FlagRateRetries  = "--rate-limit-retries"
+	FlagOutput       = "--output"
+	FlagOutputShort  = "-o"
+	FlagChunkSize    = "--chunk-size"
+	FlagVerify       = "--verify"
+	FlagDedup        = "--dedup"
+	FlagLimit        = "--limit"
+	FlagType         = "--type"
+	FlagSemantic     = "--semantic"
+	FlagNoDeprecated = "--no-deprecated"
+	FlagK8sContext   = "--k8s-context"
+	FlagK8sNamespace = "--k8s-namespace"
+	FlagK8sContainer = "--k8s-container"
+	FlagPostProcess  = "--post-process"
+	FlagRecord       = "--record"
+	FlagMatch        = "--match"
+	FlagCursor       = "--cursor"
+	FlagLazySchemas  = "--lazy-schemas"
+	FlagCodec        = "--codec"
+	FlagPreferIPv4   = "--prefer-ipv4"
+	FlagPreferIPv6   = "--prefer-ipv6"
+	FlagDiscover     = "--discover"
+	FlagOutputFile   = "--output-file"
+	FlagNotifyAfter  = "--notify-after"
+	FlagBell         = "--bell"
+	FlagFollowCursor = "--follow-cursor"
+	FlagMaxPages     = "--max-pages"
+	FlagAggregate    = "--aggregate"
+	FlagFix          = "--fix"
+	FlagTranslate    = "--translate"
+	FlagGlossary     = "--glossary"
+	FlagOriginal     = "--original"
 )
 
 // entity types.
@@ -30,9 +82,10 @@ const (
 
 // transport types.
 const (
-	TransportSSE   = "sse"
-	TransportHTTP  = "http"
-	TransportStdio = "stdio"
+	TransportSSE      = "sse"
+	TransportHTTP     = "http"
+	TransportLongPoll = "longpoll"
+	TransportStdio    = "stdio"
 )
 
 var (
@@ -44,13 +97,109 @@ var (
 	ParamsString string
 	// ShowServerLogs is a flag to show server logs.
 	ShowServerLogs bool
-	// TransportOption is the transport option for HTTP connections, valid values are "sse" and "http".
+	// TransportOption is the transport option for HTTP connections, valid values are "sse", "http"
+	// and "longpoll". SSE falls back to long-polling if the event stream cannot be opened.
 	// Default is "http" (streamable HTTP).
 	TransportOption = "http"
 	// AuthUser contains username:password for basic authentication.
 	AuthUser string
 	// AuthHeader is a custom Authorization header.
 	AuthHeader string
+	// AuthTokenFile is an OAuth token file whose bearer token is sent to HTTP servers, renewed
+	// with its refresh token before it expires.
+	AuthTokenFile string
+	// DeviceAuth authenticates to HTTP gateways with the device credential registered by
+	// mcp auth device.
+	DeviceAuth bool
+	// ShowStats is a flag to print message statistics for the session when the command finishes.
+	ShowStats bool
+	// StrictMode is a flag to fail on any deviation from the MCP protocol instead of tolerating it.
+	StrictMode bool
+	// QuirksOption is a comma-separated list of workarounds to enable for non-conformant servers.
+	QuirksOption string
+	// NoInitialize is a flag to skip the MCP initialize handshake, e.g. for raw JSON-RPC services.
+	NoInitialize bool
+	// ClientInfoOption overrides the client info sent during initialization, in
+	// name=...,version=... format.
+	ClientInfoOption string
+	// ClientNameOption and ClientVersionOption set the name and version of the client info,
+	// taking precedence over ClientInfoOption. MCPT_CLIENT_NAME and MCPT_CLIENT_VERSION set them
+	// for every command.
+	ClientNameOption    string
+	ClientVersionOption string
+	// ExperimentalOption is a JSON object of entries added to the experimental capabilities sent
+	// during initialization, to test draft protocol extensions.
+	ExperimentalOption string
+	// IDPrefix switches request IDs to strings made of this prefix and a counter. The placeholder
+	// ${uuid} is replaced by a random UUID for the session.
+	IDPrefix string
+	// CompressionOption is a comma-separated list of content encodings to accept on HTTP
+	// transports, or "none" to disable compression.
+	CompressionOption = "gzip,deflate,zstd"
+	// RateLimitRetries is how many times requests to HTTP servers that were rate limited are
+	// retried, after waiting as long as the server asks or backing off. 0 disables retries.
+	RateLimitRetries = httpclient.DefaultMaxRetries
+	// HideDeprecated is a flag to hide tools marked deprecated by the server from listings, and
+	// to block them in guard mode.
+	HideDeprecated bool
+	// K8sContext, K8sNamespace and K8sContainer select where k8s: servers are run with kubectl
+	// exec. Empty values use kubectl's defaults.
+	K8sContext   string
+	K8sNamespace string
+	K8sContainer string
+	// PostProcessScript is a Lua script whose process(result, info) function reshapes every
+	// response before it is printed.
+	PostProcessScript string
+	// VerifyServer is a flag to record what stdio servers resolve to on first use, and to check
+	// later runs against the record, see mcp trust.
+	VerifyServer bool
+	// FsJail lists the directories stdio servers are confined to, as path[:rw]. Servers run
+	// unconfined if it is empty.
+	FsJail []string
+	// RecordPath is a file to append the session to, with secrets replaced by tokens from the
+	// local vault.
+	RecordPath string
+	// SessionTimeline, if set, keeps the messages of sessions in memory with secrets tokenized,
+	// for the share links of mcp web.
+	SessionTimeline *record.Timeline
+	// LazySchemas is a flag to declare the experimental lazySchemas capability, so gateways that
+	// support it list tool stubs and leave full schemas to be fetched on demand.
+	LazySchemas bool
+	// CodecOption is the wire format of stdio servers, valid values are "json" and "msgpack".
+	// Default is "json" (newline-delimited JSON).
+	CodecOption = "json"
+	// PreferIPv4 and PreferIPv6 make HTTP transports try addresses of that family first when a
+	// host has both. The other family is still tried if the preferred one does not connect.
+	PreferIPv4 bool
+	PreferIPv6 bool
+	// DiscoverOption locates the server instead of naming it, with dns-srv:<name> or
+	// mdns:<service>. Discovered endpoints are tried in order until one can be reached.
+	DiscoverOption string
+	// OutputFileOption writes the output of the command to a file instead of stdout. The file
+	// is replaced atomically once the command succeeds; "-" means stdout.
+	OutputFileOption string
+	// NotifyOption and BellOption show a desktop notification and ring the terminal bell when a
+	// call that took at least NotifyAfter finishes, so users can switch away from slow calls.
+	NotifyOption bool
+	BellOption   bool
+	NotifyAfter  = 10 * time.Second
+	// FollowCursorOption pages through tools that return their own cursors, in
+	// result.<path>=params.<name> form. MaxPages bounds the pages fetched.
+	FollowCursorOption string
+	MaxPages           = 10
+	// AggregateOption combines the pages fetched with FollowCursorOption into a single
+	// document, e.g. concat:.items or count.
+	AggregateOption string
+	// FixOption asks a language model to correct the parameters of tool calls the server
+	// rejects, and calls the tool again with them once the user accepts.
+	FixOption bool
+	// TranslateOption is the language to render tool and prompt descriptions in, e.g. de, or
+	// auto for the language of the locale. MCPT_TRANSLATE sets it for every command.
+	// GlossaryPath is a file of fixed translations, and OriginalOption shows the descriptions
+	// as the server sent them.
+	TranslateOption string
+	GlossaryPath    string
+	OriginalOption  bool
 )
 
 // RootCmd creates the root command.
@@ -60,14 +209,62 @@ func RootCmd() *cobra.Command {
 		Short: "MCP is a command line interface for interacting with MCP servers",
 		Long: `MCP is a command line interface for interacting with Model Context Protocol (MCP) servers.
 It allows you to discover and call tools, list resources, and interact with MCP-compatible services.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			telemetryCommand = cmd
+
+			// Keep stderr parseable when failures are reported as JSON
+			if jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+				cmd.SilenceUsage = true
+			}
+
+			// Commands parsing their own flags have not seen --output-file yet
+			if cmd.DisableFlagParsing {
+				if path, ok := outputFileFromArgs(args); ok {
+					OutputFileOption = path
+				}
+			}
+			return startOutputFile(OutputFileOption)
+		},
+		PersistentPostRun: func(_ *cobra.Command, _ []string) {
+			PrintSessionStats(os.Stderr)
+		},
 	}
 
 	cmd.PersistentFlags().StringVarP(&FormatOption, "format", "f", "table", "Output format (table, json, pretty)")
 	cmd.PersistentFlags().
 		StringVarP(&ParamsString, "params", "p", "{}", "JSON string of parameters to pass to the tool (for call command)")
-	cmd.PersistentFlags().StringVar(&TransportOption, "transport", "http", "HTTP transport type (http, sse)")
+	cmd.PersistentFlags().StringVar(&TransportOption, "transport", "http", "HTTP transport type (http, sse, longpoll)")
 	cmd.PersistentFlags().StringVar(&AuthUser, "auth-user", "", "Basic authentication in username:password format")
 	cmd.PersistentFlags().StringVar(&AuthHeader, "auth-header", "", "Custom Authorization header (e.g., 'Bearer token' or 'Basic base64credentials')")
+	cmd.PersistentFlags().StringVar(&AuthTokenFile, "auth-token-file", "", "OAuth token file whose bearer token is renewed before it expires")
+	cmd.PersistentFlags().BoolVar(&VerifyServer, "verify-server", false, "Record the hash of stdio servers on first run and warn when they change (see 'mcp trust')")
+	cmd.PersistentFlags().StringArrayVar(&FsJail, "fs-jail", nil, "Confine stdio servers' filesystem access to this directory, read-only unless given as path:rw (repeatable)")
+	cmd.PersistentFlags().BoolVar(&DeviceAuth, "device-auth", false, "Authenticate with the device credential registered by 'mcp auth device'")
+	cmd.PersistentFlags().BoolVar(&ShowStats, "stats", false, "Print message size and count statistics for the session")
+	cmd.PersistentFlags().BoolVar(&StrictMode, "strict", false, "Fail on any protocol deviation by the server")
+	cmd.PersistentFlags().StringVar(&QuirksOption, "quirks", "", "Comma-separated workarounds for non-conformant stdio servers (banner, string-ids)")
+	cmd.PersistentFlags().BoolVar(&NoInitialize, "no-initialize", false, "Skip the initialize handshake")
+	cmd.PersistentFlags().StringVar(&IDPrefix, "id-prefix", "", "Send string request IDs with this prefix (e.g., 'mcpt-${uuid}-')")
+	cmd.PersistentFlags().StringVar(&CompressionOption, "compression", "gzip,deflate,zstd", "Content encodings accepted on HTTP transports (gzip, deflate, zstd, none)")
+	cmd.PersistentFlags().IntVar(&RateLimitRetries, "rate-limit-retries", httpclient.DefaultMaxRetries, "Times a request rate limited by an HTTP server is retried, honoring Retry-After (0 to disable)")
+	cmd.PersistentFlags().StringVar(&K8sContext, "k8s-context", "", "Kubeconfig context for k8s: servers")
+	cmd.PersistentFlags().StringVar(&K8sNamespace, "k8s-namespace", "", "Namespace of the pod for k8s: servers")
+	cmd.PersistentFlags().StringVar(&K8sContainer, "k8s-container", "", "Container to exec into for k8s: servers")
+	cmd.PersistentFlags().StringVar(&PostProcessScript, "post-process", "", "Lua script whose process(result, info) function reshapes responses before printing")
+	cmd.PersistentFlags().StringVar(&RecordPath, "record", "", "Record the session to a file, tokenizing secrets (replay with 'mcp replay')")
+	cmd.PersistentFlags().BoolVar(&LazySchemas, "lazy-schemas", false, "Ask servers that support it to list tool stubs and send full schemas on demand")
+	cmd.PersistentFlags().StringVar(&CodecOption, "codec", "json", "Wire format of stdio servers (json, msgpack)")
+	cmd.PersistentFlags().BoolVar(&PreferIPv4, "prefer-ipv4", false, "Try IPv4 addresses first when connecting to HTTP servers")
+	cmd.PersistentFlags().BoolVar(&PreferIPv6, "prefer-ipv6", false, "Try IPv6 addresses first when connecting to HTTP servers")
+	cmd.PersistentFlags().StringVar(&DiscoverOption, "discover", "", "Find the server with DNS SRV or mDNS (e.g., 'dns-srv:_mcp._tcp.example.com', 'mdns:_mcp._tcp')")
+	cmd.PersistentFlags().StringVar(&OutputFileOption, "output-file", "", "Write the output to this file, replacing it atomically once the command succeeds ('-' for stdout)")
+	cmd.PersistentFlags().StringVar(&TranslateOption, "translate", "", "Language to render tool and prompt descriptions in (e.g., 'de', or 'auto' for the locale's)")
+	cmd.PersistentFlags().StringVar(&GlossaryPath, "glossary", "", "JSON or YAML file mapping descriptions to their translations")
+	cmd.PersistentFlags().BoolVar(&OriginalOption, "original", false, "Show descriptions as the server sent them, without translation")
+	cmd.PersistentFlags().StringVar(&ClientInfoOption, "client-info", "", "Client info sent on initialize (e.g., 'name=my-agent,version=2.0,protocol=2025-03-26')")
+	cmd.PersistentFlags().StringVar(&ClientNameOption, "client-name", "", "Client name sent on initialize, instead of mcptools or $MCPT_CLIENT_NAME")
+	cmd.PersistentFlags().StringVar(&ClientVersionOption, "client-version", "", "Client version sent on initialize, instead of 1.0.0 or $MCPT_CLIENT_VERSION")
+	cmd.PersistentFlags().StringVar(&ExperimentalOption, "experimental", "", "JSON object of experimental capabilities to declare on initialize (e.g., '{\"myFeature\":{\"enabled\":true}}')")
 
 	return cmd
 }
@@ -0,0 +1,571 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/f/mcptools/pkg/aggregate"
+	"github.com/f/mcptools/pkg/notifier"
+	"github.com/f/mcptools/pkg/workflow"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Run flags.
+const (
+	FlagResume  = "--resume"
+	FlagJournal = "--journal"
+	FlagSet     = "--set"
+	FlagMatrix  = "--matrix"
+
+	FlagApproveWebhook = "--approve-webhook"
+	FlagNotify         = "--notify"
+	FlagNotifyTemplate = "--notify-template"
+)
+
+// runSettings are the settings shared by the runs of a workflow.
+type runSettings struct {
+	// terminal is held while a run asks something on the terminal
+	terminal chan struct{}
+	notifier *notifier.Notifier
+	workflow string
+	webhook  string
+	args     []string
+	resume   bool
+}
+
+// matrixRun is the outcome of one run of a workflow matrix.
+type matrixRun struct {
+	Inputs map[string]string `json:"inputs"`
+	Run    string            `json:"run"`
+	Status string            `json:"status"`
+	Error  string            `json:"error,omitempty"`
+	Steps  int               `json:"steps"`
+	result map[string]any
+}
+
+// RunCmd creates the run command.
+func RunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "run [--resume] [--journal file] [--set name=value]... [--matrix name=a,b]... [--approve-webhook url] [--notify url]... [--aggregate mode] workflow.yaml [command args...]",
+		Short: "Run the tool calls of a workflow file",
+		Long: `Run the steps of a workflow file one after the other, each calling a tool of the server.
+Parameters may refer to inputs as {{ inputs.<name> }} and to the results of earlier steps as
+{{ steps.<id>.result.<path> }}, e.g. {{ steps.search.result.content[0].text }}; paths are also
+looked up in the structured content of results. mcp suggest-chain writes workflows in this
+format:
+
+  inputs:
+    dir:
+      description: Directory to search
+      default: "."
+  steps:
+    - id: search
+      tool: search_files
+      params:
+        path: "{{ inputs.dir }}"
+        pattern: "*.go"
+    - id: read
+      tool: read_file
+      params:
+        path: "{{ steps.search.result.content[0].text }}"
+
+Inputs are set with --set name=value, or take their default; inputs marked required: true
+must be set. Values that are valid JSON, such as 3, true or ["a"], are decoded.
+
+Steps marked approve: true wait for approval before they run, for tools with effects that
+must go through change management. They are approved or rejected on the terminal, from
+another terminal with mcp approve <run-id> <step>, or by the webhook given with
+--approve-webhook, which is posted the run, step, tool and parameters and may answer
+{"approved": true} or {"approved": false, "reason": "..."}.
+
+With --notify url, a message is posted when a run completes or fails: to Slack for incoming
+webhook URLs on hooks.slack.com or URLs prefixed with slack:, and as JSON with the run's
+workflow, label, status, steps and error to other URLs. --notify-template sets the message
+as a Go template over those fields, e.g. "{{ .Workflow }}: {{ .Kind }}".
+
+With --matrix name=a,b the workflow runs once for each value, in parallel, each run with its
+own server and journal; several --matrix flags run every combination of their values. A
+report of all runs is printed, and the command fails if any run failed. --aggregate combines
+the results of the runs into a single document instead, in the modes of mcp call: e.g.
+concat:.items, count, sum:.total, merge or reduce:<script.lua>.
+
+Every step is journaled to disk as it starts and ends (to workflow.yaml.journal, or --journal).
+When a run is interrupted or a step fails, run it again with --resume: completed steps are
+skipped and their journaled results reused, so each call completes exactly once. A step that
+was interrupted while it ran may have had its effect already; it is run again if its tool is
+annotated as idempotent or read-only, and otherwise only after you confirm it.
+
+The result of the last step is printed.
+
+Examples:
+  mcp run workflow.yaml npx -y @modelcontextprotocol/server-filesystem ~
+  mcp run --resume workflow.yaml fs
+  mcp run --set env=staging --matrix region=us,eu deploy.yaml ops
+  mcp run --approve-webhook https://example.com/approvals deploy.yaml ops
+  mcp run --notify https://hooks.slack.com/services/T000/B000/XXXX deploy.yaml ops`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
+				_ = thisCmd.Help()
+				return
+			}
+
+			const example = "Example: mcp run workflow.yaml npx -y @modelcontextprotocol/server-filesystem ~"
+
+			var (
+				journalPath string
+				path        string
+				axes        []workflow.Axis
+				targets     []notifier.Target
+				template    string
+				mode        *aggregate.Mode
+			)
+			settings := runSettings{terminal: make(chan struct{}, 1)}
+			values := map[string]string{}
+			for i := 0; i < len(args); {
+				switch {
+				case path == "" && args[i] == FlagResume:
+					settings.resume = true
+					i++
+				case path == "" && args[i] == FlagApproveWebhook && i+1 < len(args):
+					settings.webhook = args[i+1]
+					i += 2
+				case path == "" && args[i] == FlagNotify && i+1 < len(args):
+					target, err := notifier.ParseTarget(args[i+1])
+					if err != nil {
+						exitWithError(usageError(err.Error(), "Example: mcp run --notify https://hooks.slack.com/services/T000/B000/XXXX workflow.yaml fs"))
+					}
+					targets = append(targets, target)
+					i += 2
+				case path == "" && (args[i] == FlagFormat || args[i] == FlagFormatShort) && i+1 < len(args):
+					FormatOption = args[i+1]
+					i += 2
+				case path == "" && args[i] == FlagAggregate && i+1 < len(args):
+					parsed, err := aggregate.Parse(args[i+1])
+					if err != nil {
+						exitWithError(usageError(err.Error(), "Example: mcp run --matrix region=us,eu --aggregate concat:.items workflow.yaml fs"))
+					}
+					mode = &parsed
+					i += 2
+				case path == "" && args[i] == FlagNotifyTemplate && i+1 < len(args):
+					template = args[i+1]
+					i += 2
+				case path == "" && args[i] == FlagJournal && i+1 < len(args):
+					journalPath = args[i+1]
+					i += 2
+				case path == "" && args[i] == FlagSet && i+1 < len(args):
+					name, value, ok := strings.Cut(args[i+1], "=")
+					if !ok || name == "" {
+						exitWithError(usageError(fmt.Sprintf("invalid input %q: expected name=value", args[i+1]), example))
+					}
+					values[name] = value
+					i += 2
+				case path == "" && args[i] == FlagMatrix && i+1 < len(args):
+					axis, err := workflow.ParseAxis(args[i+1])
+					if err != nil {
+						exitWithError(usageError(err.Error(), "Example: mcp run --matrix region=us,eu workflow.yaml fs"))
+					}
+					axes = append(axes, axis)
+					i += 2
+				default:
+					if n := processClientFlag(args, i); n > 0 {
+						i += n
+						continue
+					}
+					if path == "" {
+						path = args[i]
+					} else {
+						settings.args = append(settings.args, args[i])
+					}
+					i++
+				}
+			}
+			if path == "" {
+				exitWithError(usageError("a workflow file is required", example))
+			}
+			if journalPath == "" {
+				journalPath = path + ".journal"
+			}
+			if mode != nil && len(axes) == 0 {
+				exitWithError(usageError(FlagAggregate+" combines the results of runs, so it needs "+FlagMatrix,
+					"Example: mcp run --matrix region=us,eu --aggregate concat:.items workflow.yaml fs"))
+			}
+
+			wf, err := workflow.Load(path)
+			if err != nil {
+				exitWithError(err)
+			}
+			settings.workflow = path
+			if len(targets) > 0 {
+				if settings.notifier, err = notifier.New(targets, template); err != nil {
+					exitWithError(err)
+				}
+			}
+
+			if len(axes) > 0 {
+				runs := runMatrix(wf, settings, journalPath, values, axes)
+				failed := 0
+				var documents []any
+				for _, run := range runs {
+					if run.Status != workflow.StatusCompleted {
+						failed++
+						continue
+					}
+					documents = append(documents, resultDocument(run.result))
+				}
+
+				if mode != nil {
+					// Failed runs are reported on stderr, and left out of the combined document
+					for _, run := range runs {
+						if run.Status != workflow.StatusCompleted {
+							fmt.Fprintf(os.Stderr, "[%s] failed: %s\n", run.Run, run.Error)
+						}
+					}
+					if aggregateErr := printAggregate(thisCmd, *mode, documents); aggregateErr != nil {
+						exitWithError(aggregateErr)
+					}
+				} else if formatErr := FormatAndPrintResponse(thisCmd, map[string]any{"runs": ConvertJSONToSlice(runs)}, nil); formatErr != nil {
+					exitWithError(formatErr)
+				}
+				if failed > 0 {
+					exitWithError(withHint(fmt.Errorf("%d of %d runs failed", failed, len(runs)),
+						"Fix the cause and run the same command again with --resume to continue where the runs stopped"))
+				}
+				return
+			}
+
+			inputs, err := wf.Bind(values)
+			if err != nil {
+				exitWithError(withHint(err, "Set inputs with --set name=value"))
+			}
+			result, _, err := runWorkflow(wf, settings, journalPath, inputs, "")
+			if err != nil {
+				exitWithError(withHint(err, fmt.Sprintf("Fix the cause and continue where the run stopped with: mcp run --resume %s", path)))
+			}
+			if formatErr := FormatAndPrintResponse(thisCmd, result, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+}
+
+// runWorkflow runs wf against the server started with the args of settings, journaling to
+// journalPath, and returns the result of the last step and the number of steps completed.
+// Runs of a matrix are labeled, which prefixes their progress and questions.
+func runWorkflow(wf *workflow.Workflow, settings runSettings, journalPath string, inputs map[string]any,
+	label string,
+) (result map[string]any, done int, err error) {
+	defer func() {
+		event := notifier.Event{Kind: notifier.WorkflowCompleted, Workflow: settings.workflow,
+			Run: workflow.RunID(journalPath), Label: label, Steps: done}
+		if err != nil {
+			event.Kind, event.Error = notifier.WorkflowFailed, err.Error()
+		}
+		if notifyErr := settings.notifier.Notify(context.Background(), event); notifyErr != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", notifyErr)
+		}
+	}()
+
+	var progress io.Writer = os.Stderr
+	prefix := ""
+	if label != "" {
+		prefix = "[" + label + "] "
+		progress = &prefixWriter{w: os.Stderr, prefix: prefix}
+	}
+
+	journal, err := workflow.OpenJournal(journalPath, settings.resume)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = journal.Close() }()
+	if settings.resume && journal.Loaded() == 0 {
+		fmt.Fprintf(progress, "Nothing to resume in %s; starting from the first step\n", journalPath)
+	}
+
+	approvalsDir, err := workflow.GetApprovalsPath()
+	if err != nil {
+		return nil, 0, err
+	}
+	approver := &approver{
+		approvals: workflow.Approvals{Dir: approvalsDir},
+		run:       workflow.RunID(journalPath),
+		webhook:   settings.webhook,
+		terminal:  settings.terminal,
+		progress:  progress,
+		prefix:    prefix,
+	}
+
+	mcpClient, err := CreateClientFunc(settings.args)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = mcpClient.Close() }()
+
+	ctx := context.Background()
+	runner := &workflow.Runner{
+		Call: func(ctx context.Context, tool string, params map[string]any) (map[string]any, error) {
+			return callToolRaw(ctx, mcpClient, tool, params)
+		},
+		Idempotent: func(tool string) bool {
+			return toolIsIdempotent(ctx, mcpClient, tool)
+		},
+		Confirm: func(step workflow.Step) (bool, error) {
+			settings.terminal <- struct{}{}
+			defer func() { <-settings.terminal }()
+			fmt.Fprint(os.Stderr, prefix)
+			return confirmRerun(step)
+		},
+		Approve:  approver.approve,
+		Inputs:   inputs,
+		Journal:  journal,
+		Progress: progress,
+	}
+
+	result, err = runner.Run(ctx, wf)
+	return result, journal.Done(), err
+}
+
+// runMatrix runs wf once for every combination of the values of axes, at most
+// maxParallelServers at a time. Each run has its own journal, named after its values, and
+// prefixes its progress with them.
+func runMatrix(wf *workflow.Workflow, settings runSettings, journalPath string, values map[string]string,
+	axes []workflow.Axis,
+) []matrixRun {
+	combinations := workflow.Expand(values, axes)
+	runs := make([]matrixRun, len(combinations))
+	slots := make(chan struct{}, maxParallelServers)
+	var wg sync.WaitGroup
+
+	for i, combination := range combinations {
+		run := &runs[i]
+		run.Run = workflow.Label(combination, axes)
+		run.Inputs = combination
+
+		inputs, err := wf.Bind(combination)
+		if err != nil {
+			run.Status, run.Error = workflow.StatusFailed, err.Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			result, done, runErr := runWorkflow(wf, settings, matrixJournalPath(journalPath, combination, axes), inputs, run.Run)
+			run.Steps, run.result = done, result
+			run.Status = workflow.StatusCompleted
+			if runErr != nil {
+				run.Status, run.Error = workflow.StatusFailed, runErr.Error()
+			}
+		}()
+	}
+
+	wg.Wait()
+	return runs
+}
+
+// matrixJournalPath returns the journal of one run of a matrix, e.g. workflow.yaml.journal.region=eu.
+func matrixJournalPath(journalPath string, values map[string]string, axes []workflow.Axis) string {
+	unsafe := func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '=')
+	}
+	path := journalPath
+	for _, axis := range axes {
+		part := []rune(axis.Name + "=" + values[axis.Name])
+		for i, r := range part {
+			if unsafe(r) {
+				part[i] = '_'
+			}
+		}
+		path += "." + string(part)
+	}
+	return path
+}
+
+// prefixWriter writes each line of progress with a prefix naming the run it belongs to.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	if _, err := fmt.Fprintf(p.w, "%s%s", p.prefix, data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// approver waits for the approval of steps. A step is approved or rejected on the terminal,
+// from another terminal with mcp approve, or by a webhook, whichever decides first.
+type approver struct {
+	approvals workflow.Approvals
+	terminal  chan struct{}
+	progress  io.Writer
+	run       string
+	webhook   string
+	prefix    string
+}
+
+func (a *approver) approve(ctx context.Context, step workflow.Step, params map[string]any) error {
+	approval := workflow.Approval{Run: a.run, Step: step.ID, Tool: step.Tool, Params: params}
+	if err := a.approvals.Request(approval); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.progress, "Approve with: mcp approve %s %s (or reject with --reject)\n", a.run, step.ID)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if a.webhook != "" {
+		go func() {
+			decided, approved, reason, err := workflow.NotifyWebhook(ctx, &http.Client{}, a.webhook, approval)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(a.progress, "%v\n", err)
+				}
+				return
+			}
+			if decided {
+				_, _ = a.approvals.Decide(a.run, step.ID, approved, "webhook", reason)
+			}
+		}()
+	}
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		go func() {
+			select {
+			case a.terminal <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-a.terminal }()
+
+			shown, _ := json.Marshal(params)
+			fmt.Fprintf(os.Stderr, "%sRun step %s: %s %s? [y/N] ", a.prefix, step.ID, step.Tool, shown)
+			select {
+			case answer, ok := <-terminalLines():
+				if !ok || ctx.Err() != nil {
+					return
+				}
+				answer = strings.ToLower(strings.TrimSpace(answer))
+				_, _ = a.approvals.Decide(a.run, step.ID, answer == "y" || answer == "yes", currentUser(), "")
+			case <-ctx.Done():
+				fmt.Fprintln(os.Stderr)
+			}
+		}()
+	}
+
+	decision, err := a.approvals.Wait(ctx, a.run, step.ID)
+	if err != nil {
+		return err
+	}
+	if decision.Decision != workflow.DecisionApproved {
+		if decision.Reason != "" {
+			return fmt.Errorf("rejected by %s: %s", decision.DecidedBy, decision.Reason)
+		}
+		return fmt.Errorf("rejected by %s", decision.DecidedBy)
+	}
+	fmt.Fprintf(a.progress, "Approved by %s\n", decision.DecidedBy)
+	return nil
+}
+
+// currentUser names the user deciding on an approval.
+func currentUser() string {
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	if name := os.Getenv("USERNAME"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+var (
+	stdinOnce  sync.Once
+	stdinLines chan string
+)
+
+// terminalLines returns the lines read from standard input. Questions share a single reader,
+// so a question given up on does not swallow the answer to the next one.
+func terminalLines() <-chan string {
+	stdinOnce.Do(func() {
+		stdinLines = make(chan string)
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				stdinLines <- scanner.Text()
+			}
+			close(stdinLines)
+		}()
+	})
+	return stdinLines
+}
+
+// callToolRaw calls a tool and returns its result as a generic map, keeping fields such as
+// structuredContent that the client library does not know about.
+func callToolRaw(ctx context.Context, mcpClient *client.Client, tool string, params map[string]any) (map[string]any, error) {
+	raw, err := sendRawRequest(ctx, mcpClient, string(mcp.MethodToolsCall), map[string]any{
+		"name":      tool,
+		"arguments": params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]any
+	if err = json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse result of %s: %w", tool, err)
+	}
+	return result, nil
+}
+
+// toolIsIdempotent reports whether the server annotates a tool as idempotent or read-only.
+// Tools it cannot look up are assumed not to be.
+func toolIsIdempotent(ctx context.Context, mcpClient *client.Client, name string) bool {
+	tools, err := listToolsRaw(ctx, mcpClient)
+	if err != nil {
+		return false
+	}
+	for _, t := range tools {
+		tool, ok := t.(map[string]any)
+		if !ok || tool["name"] != name {
+			continue
+		}
+		annotations, _ := tool["annotations"].(map[string]any)
+		idempotent, _ := annotations["idempotentHint"].(bool)
+		readOnly, _ := annotations["readOnlyHint"].(bool)
+		return idempotent || readOnly
+	}
+	return false
+}
+
+// confirmRerun asks on the terminal whether to run an interrupted step again. Without a
+// terminal to ask on, it is not run.
+func confirmRerun(step workflow.Step) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "Step %s (%s) was interrupted and may have run already; run in a terminal to confirm running it again\n",
+			step.ID, step.Tool)
+		return false, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Step %s (%s) was interrupted and may have run already. Run it again? [y/N] ", step.ID, step.Tool)
+	answer, ok := <-terminalLines()
+	if !ok {
+		return false, nil
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
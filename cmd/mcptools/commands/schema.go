@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/f/mcptools/pkg/contract"
+	"github.com/spf13/cobra"
+)
+
+// SchemaCmd creates the schema command.
+func SchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema [command]",
+		Short: "Print the JSON Schema of a command's JSON output",
+		Long: `Print the JSON Schema of the output a command prints with --format json or pretty.
+
+JSON output is an object with an apiVersion (currently ` + contract.APIVersion + `) and a kind
+next to the command's fields. Within an apiVersion, fields are only ever added, so automation
+written against it keeps working; removing, renaming or retyping a field requires a new
+apiVersion. Output is checked against its schema before it is printed. Failures are printed
+on stderr as an object of kind Error, whose schema is printed by mcp schema error.
+
+Without a command, the commands with a JSON output contract are listed.
+
+Examples:
+  mcp schema
+  mcp schema tools
+  mcp schema stats tools
+  mcp schema error`,
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			out := thisCmd.OutOrStdout()
+			if len(args) == 0 {
+				w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "COMMAND\tKIND")
+				for _, command := range contract.Commands() {
+					kind, _ := contract.Kind(command)
+					fmt.Fprintf(w, "%s\t%s\n", command, kind)
+				}
+				return w.Flush()
+			}
+
+			command := strings.Join(args, " ")
+			kind, ok := contract.Kind(command)
+			if !ok {
+				return fmt.Errorf("command %q has no JSON output contract; run mcp schema to list them", command)
+			}
+			schema, err := contract.Schema(kind)
+			if err != nil {
+				return err
+			}
+			_, err = out.Write(schema)
+			return err
+		},
+	}
+}
@@ -0,0 +1,284 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/selftest"
+	"github.com/spf13/cobra"
+)
+
+// selftestReadyTimeout bounds how long a server started for the self-test has to listen.
+const selftestReadyTimeout = 10 * time.Second
+
+// selftestConfig is the serve-config server of the self-test.
+const selftestConfig = `name: selftest
+tools:
+  - name: greet
+    description: Greet someone
+    inputSchema:
+      type: object
+      properties:
+        name: {type: string}
+      required: [name]
+    response: Hello {{name}}!
+`
+
+// selftestTarget is a built-in server the self-test runs the conformance suite against.
+type selftestTarget struct {
+	name string
+	// transports lists the transports the server is tested over.
+	transports []string
+	// args returns the arguments of mcp starting the server, with files it needs in dir.
+	args   func(dir string) ([]string, error)
+	expect selftest.Expect
+}
+
+var selftestTargets = []selftestTarget{
+	{
+		name:       "mock",
+		transports: []string{"stdio"},
+		args: func(string) ([]string, error) {
+			return []string{"mock",
+				"tool", "echo", "Echo tool",
+				"prompt", "welcome", "Welcome prompt", "Hello {{name}}!",
+				"resource", "docs:readme", "Readme", "# Self-test"}, nil
+		},
+		expect: selftest.Expect{
+			Tool: "echo", ToolText: "i am echo mock tool",
+			Prompt: "welcome", PromptArguments: map[string]string{"name": "Ada"}, PromptText: "Hello Ada!",
+			Resource: "docs:readme", ResourceText: "# Self-test",
+		},
+	},
+	{
+		name:       "config",
+		transports: []string{"stdio", TransportHTTP},
+		args: func(dir string) ([]string, error) {
+			path := filepath.Join(dir, "tools.yaml")
+			return []string{"serve-config", path}, os.WriteFile(path, []byte(selftestConfig), 0o600)
+		},
+		expect: selftest.Expect{Tool: "greet", Arguments: map[string]any{"name": "Ada"}, ToolText: "Hello Ada!"},
+	},
+	{
+		name:       "sqlite",
+		transports: []string{"stdio", TransportHTTP},
+		args: func(dir string) ([]string, error) {
+			return []string{"serve-sqlite", filepath.Join(dir, "selftest.db")}, nil
+		},
+		expect: selftest.Expect{
+			Tool: "query", Arguments: map[string]any{"sql": "select 6*7 as answer"}, ToolText: "42",
+			Resource: "sqlite://schema", ResourceText: "The database is empty",
+		},
+	},
+}
+
+// selftestRow is a check of the self-test report.
+type selftestRow struct {
+	Server     string `json:"server"`
+	Transport  string `json:"transport"`
+	Check      string `json:"check"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+// SelftestCmd creates the selftest command.
+func SelftestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "selftest [server...]",
+		Short: "Check this installation against the built-in servers",
+		Long: `Start the built-in servers as subprocesses and run the client conformance suite against
+them over each transport they serve: the handshake, ping, listing and calling tools, getting
+prompts, reading resources, and the errors of unknown tools and methods.
+
+The servers are mock (stdio), config and sqlite (stdio and streamable HTTP). Without names,
+all of them are tested. The command exits with status 1 if a check fails, so it can run in CI
+or to check that an installation works.
+
+Examples:
+  mcp selftest
+  mcp selftest config --format json`,
+		SilenceUsage: true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			targets, err := selftestSelect(args)
+			if err != nil {
+				exitWithError(err)
+			}
+			executable, err := os.Executable()
+			if err != nil {
+				exitWithError(fmt.Errorf("failed to find the mcp executable: %w", err))
+			}
+			dir, err := os.MkdirTemp("", "mcp-selftest-")
+			if err != nil {
+				exitWithError(err)
+			}
+			defer func() { _ = os.RemoveAll(dir) }()
+
+			var rows []selftestRow
+			failed := false
+			for _, target := range targets {
+				serverArgs, argsErr := target.args(dir)
+				if argsErr != nil {
+					exitWithError(argsErr)
+				}
+				for _, transport := range target.transports {
+					results := runSelftest(executable, serverArgs, transport, target.expect)
+					failed = failed || selftest.Failed(results)
+					for _, result := range results {
+						rows = append(rows, selftestRow{
+							Server:     target.name,
+							Transport:  transport,
+							Check:      result.Check,
+							Status:     result.Status,
+							Detail:     result.Detail,
+							DurationMS: result.Duration.Milliseconds(),
+						})
+					}
+				}
+			}
+
+			if jsonutils.ParseFormat(FormatOption) == jsonutils.FormatTable {
+				printSelftestReport(thisCmd.OutOrStdout(), rows)
+			} else if formatErr := FormatAndPrintResponse(thisCmd, map[string]any{"selftest": ConvertJSONToSlice(rows)}, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// selftestSelect returns the targets of the given names, or all of them.
+func selftestSelect(names []string) ([]selftestTarget, error) {
+	if len(names) == 0 {
+		return selftestTargets, nil
+	}
+	var targets []selftestTarget
+	for _, name := range names {
+		i := slices.IndexFunc(selftestTargets, func(target selftestTarget) bool { return target.name == name })
+		if i < 0 {
+			return nil, usageError(fmt.Sprintf("unknown server %q", name), "mcp selftest mock config sqlite")
+		}
+		targets = append(targets, selftestTargets[i])
+	}
+	return targets, nil
+}
+
+// runSelftest starts the server of serverArgs over transport and runs the suite against it. A
+// server that cannot be connected to fails the handshake.
+func runSelftest(executable string, serverArgs []string, transport string, expect selftest.Expect) []selftest.Result {
+	start := time.Now()
+	clientArgs := append([]string{executable}, serverArgs...)
+	if transport == TransportHTTP {
+		url, stop, err := startSelftestServer(executable, serverArgs)
+		if err != nil {
+			return []selftest.Result{{Check: "handshake", Status: selftest.StatusFail, Detail: err.Error(), Duration: time.Since(start)}}
+		}
+		defer stop()
+		clientArgs = []string{url}
+		TransportOption = TransportHTTP
+	}
+
+	mcpClient, err := CreateClientFunc(clientArgs)
+	if err != nil {
+		return []selftest.Result{{Check: "handshake", Status: selftest.StatusFail, Detail: err.Error(), Duration: time.Since(start)}}
+	}
+	defer func() { _ = mcpClient.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverWorkTimeout)
+	defer cancel()
+	results := selftest.Run(ctx, mcpClient, serverInitializeResult(mcpClient), expect)
+	results[0].Duration += time.Since(start) - sumDurations(results)
+	return results
+}
+
+// sumDurations adds up the durations of results.
+func sumDurations(results []selftest.Result) time.Duration {
+	var total time.Duration
+	for _, result := range results {
+		total += result.Duration
+	}
+	return total
+}
+
+// startSelftestServer starts the server of serverArgs on streamable HTTP at a free local port,
+// and returns its URL once it listens, and a function stopping it.
+func startSelftestServer(executable string, serverArgs []string) (string, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+
+	args := append([]string{serverArgs[0], "--http", addr}, serverArgs[1:]...)
+	// #nosec G204 - the self-test runs this executable with fixed arguments
+	cmd := exec.Command(executable, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Start(); err != nil {
+		return "", nil, err
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	stop := func() {
+		_ = cmd.Process.Kill()
+		<-exited
+	}
+
+	deadline := time.Now().Add(selftestReadyTimeout)
+	for {
+		conn, dialErr := net.DialTimeout("tcp", addr, time.Second)
+		if dialErr == nil {
+			_ = conn.Close()
+			return "http://" + addr + "/mcp", stop, nil
+		}
+		select {
+		case <-exited:
+			return "", nil, fmt.Errorf("the server exited: %s", strings.TrimSpace(stderr.String()))
+		case <-time.After(50 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			stop()
+			return "", nil, errors.New("the server did not listen in time")
+		}
+	}
+}
+
+// printSelftestReport prints the checks by server and transport, and a summary.
+func printSelftestReport(w io.Writer, rows []selftestRow) {
+	counts := map[string]int{}
+	group := ""
+	for _, row := range rows {
+		if name := row.Server + " (" + row.Transport + ")"; name != group {
+			if group != "" {
+				fmt.Fprintln(w)
+			}
+			group = name
+			fmt.Fprintln(w, name)
+		}
+		counts[row.Status]++
+		line := fmt.Sprintf("  %-4s  %-15s %5dms", strings.ToUpper(row.Status), row.Check, row.DurationMS)
+		if row.Detail != "" {
+			line += "  " + row.Detail
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped\n",
+		counts[selftest.StatusPass], counts[selftest.StatusFail], counts[selftest.StatusSkip])
+}
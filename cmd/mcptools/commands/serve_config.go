@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/f/mcptools/pkg/serve"
+	"github.com/spf13/cobra"
+)
+
+// ServeConfigCmd creates the serve-config command.
+func ServeConfigCmd() *cobra.Command {
+	var httpAddr string
+
+	cmd := &cobra.Command{
+		Use:   "serve-config [--http addr] config.yaml",
+		Short: "Serve tools declared in a YAML file as an MCP server",
+		Long: `Serve tools declared in a YAML file as an MCP server, without writing any server code.
+
+Each tool declares an input schema and is backed by exactly one of:
+- command: a shell command; arguments are shell-quoted into {{name}} placeholders and
+  also passed as JSON in the MCP_ARGUMENTS environment variable
+- http: a request with method, url, headers and body; arguments are URL-escaped in the url
+- response: a static text response
+
+The server speaks stdio by default, or streamable HTTP with --http.
+
+Example config:
+  name: ops
+  tools:
+    - name: disk_usage
+      description: Show disk usage of a path
+      inputSchema:
+        type: object
+        properties:
+          path: {type: string}
+        required: [path]
+      command: du -sh {{path}}
+    - name: weather
+      description: Current weather for a city
+      inputSchema:
+        type: object
+        properties:
+          city: {type: string}
+      http:
+        url: https://wttr.in/{{city}}?format=3
+      timeout: 10s
+
+Examples:
+  mcp serve-config tools.yaml
+  mcp serve-config --http :8080 tools.yaml
+  mcp tools mcp serve-config tools.yaml`,
+		Args: cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			cfg, err := serve.LoadConfig(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			s, err := serve.NewConfigServer(cfg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Fprintf(os.Stderr, "Loaded %d tool(s) from %s\n", len(cfg.Tools), args[0])
+			if err = serve.Run(s, httpAddr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&httpAddr, "http", "", "Serve over streamable HTTP at this address instead of stdio")
+
+	return cmd
+}
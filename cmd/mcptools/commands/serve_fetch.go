@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/f/mcptools/pkg/serve"
+	"github.com/spf13/cobra"
+)
+
+// ServeFetchCmd creates the serve-fetch command.
+func ServeFetchCmd() *cobra.Command {
+	var (
+		httpAddr  string
+		domains   []string
+		maxBytes  int64
+		maxLength int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve-fetch --allow-domain domain... [--http addr]",
+		Short: "Serve a web fetching tool restricted to allowed domains",
+		Long: `Serve a fetch_url tool that downloads web pages and returns them as Markdown.
+
+Only the domains given with --allow-domain, and their subdomains, can be fetched; redirects to
+other domains are refused. Responses larger than --max-bytes are rejected, and long pages are
+returned in parts of --max-length characters.
+
+Examples:
+  mcp serve-fetch --allow-domain docs.example.com
+  mcp serve-fetch --allow-domain go.dev --allow-domain pkg.go.dev --http :8080
+  mcp call fetch_url --params '{"url":"https://go.dev/doc/"}' mcp serve-fetch --allow-domain go.dev`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			s, err := serve.NewFetchServer(serve.FetchOptions{
+				AllowedDomains: domains,
+				MaxBytes:       maxBytes,
+				MaxLength:      maxLength,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				fmt.Fprintln(os.Stderr, "Example: mcp serve-fetch --allow-domain docs.example.com")
+				os.Exit(1)
+			}
+
+			if err = serve.Run(s, httpAddr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&domains, "allow-domain", nil, "Domain that may be fetched, with its subdomains (repeatable)")
+	cmd.Flags().Int64Var(&maxBytes, "max-bytes", serve.DefaultFetchMaxBytes, "Largest response body to download")
+	cmd.Flags().IntVar(&maxLength, "max-length", serve.DefaultFetchMaxLength, "Default number of characters returned per call")
+	cmd.Flags().StringVar(&httpAddr, "http", "", "Serve over streamable HTTP at this address instead of stdio")
+
+	return cmd
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/f/mcptools/pkg/serve"
+	"github.com/spf13/cobra"
+)
+
+// ServeGitCmd creates the serve-git command.
+func ServeGitCmd() *cobra.Command {
+	var (
+		httpAddr   string
+		allowWrite bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve-git [--allow-write] [--http addr] [repository]",
+		Short: "Serve a git repository as an MCP server",
+		Long: `Serve a git repository as an MCP server, without installing a separate git server.
+
+The server exposes read-only tools scoped to the repository: status, log, diff, show, blame and
+grep. With --allow-write it also exposes add and commit. The repository defaults to the current
+directory.
+
+Examples:
+  mcp serve-git
+  mcp serve-git /path/to/repo
+  mcp serve-git --http :8080 /path/to/repo
+  mcp call grep --params '{"pattern":"func main"}' mcp serve-git /path/to/repo`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			repo := "."
+			if len(args) == 1 {
+				repo = args[0]
+			}
+
+			s, err := serve.NewGitServer(repo, serve.GitOptions{AllowWrite: allowWrite})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err = serve.Run(s, httpAddr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&allowWrite, "allow-write", false, "Also expose the add and commit tools")
+	cmd.Flags().StringVar(&httpAddr, "http", "", "Serve over streamable HTTP at this address instead of stdio")
+
+	return cmd
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/f/mcptools/pkg/index"
+	"github.com/f/mcptools/pkg/serve"
+	"github.com/spf13/cobra"
+)
+
+// ServeIndexCmd creates the serve-index command.
+func ServeIndexCmd() *cobra.Command {
+	var httpAddr string
+
+	cmd := &cobra.Command{
+		Use:   "serve-index [--http addr] [index]",
+		Short: "Serve a resource index as an MCP retrieval server",
+		Long: `Serve an index built by mcp index build as an MCP retrieval server, so agents can look up the
+resources of other servers without reading them all: a simple self-hosted RAG backend.
+
+The server exposes:
+- a search tool returning the best matching chunk of text of each resource, by keywords or,
+  with semantic, by meaning
+- a get_chunk tool returning a chunk of a resource by number, to read around a match
+- a list_indexed_servers tool
+
+The index is a database or a directory holding index.db, $HOME/.mcpt/index.db by default.
+Rebuilding it while it is served takes effect from the next request. Semantic searches embed
+queries with the endpoint configured by MCPT_EMBEDDINGS_URL, MCPT_EMBEDDINGS_MODEL and
+MCPT_EMBEDDINGS_KEY, which must be the one the index was built with.
+
+Examples:
+  mcp index build --index ./index --embed -- npx -y @modelcontextprotocol/server-filesystem ~/docs
+  mcp serve-index ./index
+  mcp serve-index --http :8080 ./index
+  mcp call search --params '{"query":"refund policy","semantic":true}' mcp serve-index ./index`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			}
+			resolved, err := index.ResolvePath(path)
+			if err == nil {
+				if _, statErr := os.Stat(resolved); statErr != nil {
+					err = fmt.Errorf("no index at %s; build one with: mcp index build --index %s -- <server>", resolved, resolved)
+				}
+			}
+			var ix *index.Index
+			if err == nil {
+				ix, err = index.Open(resolved)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer func() { _ = ix.Close() }()
+
+			// Without embeddings, the index is served for keyword searches only
+			opts := serve.IndexOptions{}
+			if embedder, embedErr := newEmbedder(); embedErr == nil {
+				opts.Embedder, opts.Model = embedder, index.ModelKey(embedder)
+			}
+			s := serve.NewIndexServer(ix, opts)
+			if err = serve.Run(s, httpAddr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&httpAddr, "http", "", "Serve over streamable HTTP at this address instead of stdio")
+	return cmd
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/f/mcptools/pkg/serve"
+	"github.com/spf13/cobra"
+)
+
+// ServeScriptCmd creates the serve-script command.
+func ServeScriptCmd() *cobra.Command {
+	var (
+		httpAddr string
+		noReload bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve-script [--http addr] [--no-reload] tools.lua",
+		Short: "Serve functions of a Lua script as MCP tools",
+		Long: `Serve functions of a Lua script as MCP tools, for quick tool prototyping without a server
+project.
+
+Every global function preceded by a comment block containing @tool becomes a tool. The comment
+text is the tool description, and @param lines declare the input schema: a name (ending in ? if
+optional), a JSON schema type and a description. The function receives the arguments as a table;
+strings are returned as text, other values as JSON.
+
+The script is reloaded whenever it changes, unless --no-reload is set.
+
+Example script:
+  --- Add two numbers.
+  -- @tool
+  -- @param a number The first number
+  -- @param b? number An optional second number
+  function add(args)
+    return args.a + (args.b or 0)
+  end
+
+Examples:
+  mcp serve-script tools.lua
+  mcp serve-script --http :8080 tools.lua
+  mcp call add --params '{"a":1,"b":2}' mcp serve-script tools.lua`,
+		Args: cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			s, err := serve.NewScriptServer(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Loaded %s\n", scriptToolNames(s.Tools()))
+
+			if !noReload {
+				go s.Watch(context.Background(), func(tools []serve.ScriptTool) {
+					fmt.Fprintf(os.Stderr, "Reloaded %s\n", scriptToolNames(tools))
+				}, func(err error) {
+					fmt.Fprintf(os.Stderr, "Error reloading %s, keeping previous tools: %v\n", args[0], err)
+				})
+			}
+
+			if err = serve.Run(s.MCPServer(), httpAddr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&noReload, "no-reload", false, "Do not reload the script when it changes")
+	cmd.Flags().StringVar(&httpAddr, "http", "", "Serve over streamable HTTP at this address instead of stdio")
+
+	return cmd
+}
+
+// scriptToolNames describes the tools of a script for log messages.
+func scriptToolNames(tools []serve.ScriptTool) string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return fmt.Sprintf("%d tool(s): %s", len(tools), strings.Join(names, ", "))
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/f/mcptools/pkg/serve"
+	"github.com/spf13/cobra"
+)
+
+// ServeSQLiteCmd creates the serve-sqlite command.
+func ServeSQLiteCmd() *cobra.Command {
+	var (
+		httpAddr string
+		allow    string
+		readOnly bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve-sqlite [--read-only] [--allow kinds] [--http addr] database.db",
+		Short: "Serve a SQLite database as an MCP server",
+		Long: `Serve a SQLite database as an MCP server.
+
+The server exposes:
+- a query tool that runs a single SQL statement, with ? placeholders bound from params
+- the sqlite://schema resource with the CREATE statements of the database
+- a sqlite://tables/<name> resource with the rows of each table and view
+
+The query tool only accepts the statement kinds in the allowlist (the first keyword of the
+statement, or the write that follows a WITH clause). By default SELECT, WITH and EXPLAIN are allowed, plus INSERT, UPDATE and DELETE
+unless --read-only is set. With --read-only the database is also opened read-only, so SQLite
+rejects any write.
+
+Examples:
+  mcp serve-sqlite --read-only data.db
+  mcp serve-sqlite --allow select,insert data.db
+  mcp serve-sqlite --http :8080 data.db
+  mcp call query --params '{"sql":"SELECT * FROM users WHERE id = ?","params":[1]}' mcp serve-sqlite data.db`,
+		Args: cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			opts := serve.SQLiteOptions{ReadOnly: readOnly}
+			if allow != "" {
+				opts.Allow = strings.Split(allow, ",")
+			}
+
+			db, err := serve.OpenSQLite(args[0], opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer func() { _ = db.Close() }()
+
+			s, err := db.MCPServer(context.Background(), "sqlite-"+filepath.Base(args[0]))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if err = serve.Run(s, httpAddr); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&readOnly, "read-only", false, "Open the database read-only and only allow read statements")
+	cmd.Flags().StringVar(&allow, "allow", "", "Comma-separated statement kinds the query tool accepts (e.g. select,insert)")
+	cmd.Flags().StringVar(&httpAddr, "http", "", "Serve over streamable HTTP at this address instead of stdio")
+
+	return cmd
+}
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/notifyview"
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/peterh/liner"
@@ -33,6 +35,11 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 
 			i := 0
 			for i < len(cmdArgs) {
+				if n := processClientFlag(cmdArgs, i); n > 0 {
+					i += n
+					continue
+				}
+
 				switch {
 				case (cmdArgs[i] == FlagFormat || cmdArgs[i] == FlagFormatShort) && i+1 < len(cmdArgs):
 					FormatOption = cmdArgs[i+1]
@@ -52,18 +59,20 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 				}
 			}
 
-			if len(parsedArgs) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: command to execute is required when using the shell")
-				fmt.Fprintln(os.Stderr, "Example: mcp shell npx -y @modelcontextprotocol/server-filesystem ~")
-				os.Exit(1)
+			if len(parsedArgs) == 0 && DiscoverOption == "" {
+				exitWithError(usageError("command to execute is required when using the shell", "Example: mcp shell npx -y @modelcontextprotocol/server-filesystem ~"))
 			}
 
 			mcpClient, clientErr := CreateClientFunc(parsedArgs)
 			if clientErr != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", clientErr)
-				os.Exit(1)
+				exitWithError(clientErr)
 			}
 
+			notifications := notifyview.NewLog(0)
+			notificationFilter := notifyview.Filter{}
+			recordNotifications(mcpClient, notifications)
+			calls := &callHistory{}
+
 			fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > MCP Tools Shell (%s)\n", Version)
 			fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Connected to Server: %s\n", strings.Join(parsedArgs, " "))
 			fmt.Fprintf(thisCmd.OutOrStdout(), "\nmcp > Type '/h' for help or '/q' to quit\n")
@@ -117,6 +126,7 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 				case "tools":
 					var listToolsResult *mcp.ListToolsResult
 					listToolsResult, listErr = mcpClient.ListTools(context.Background(), mcp.ListToolsRequest{})
+					listErr = degradeList(mcpClient, featureTools, listErr)
 
 					var tools []any
 					if listErr == nil && listToolsResult != nil {
@@ -124,13 +134,14 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 					}
 
 					resp = map[string]any{"tools": tools}
-					if formatErr := FormatAndPrintResponse(thisCmd, resp, listErr); formatErr != nil {
-						fmt.Fprintf(os.Stderr, "%v\n", formatErr)
+					if formatErr := formatAndPrintOutput(thisCmd, "tools", resp, listErr); formatErr != nil {
+						PrintError(os.Stderr, formatErr)
 						continue
 					}
 				case "resources":
 					var listResourcesResult *mcp.ListResourcesResult
 					listResourcesResult, listErr = mcpClient.ListResources(context.Background(), mcp.ListResourcesRequest{})
+					listErr = degradeList(mcpClient, featureResources, listErr)
 
 					var resources []any
 					if listErr == nil && listResourcesResult != nil {
@@ -138,13 +149,14 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 					}
 
 					resp = map[string]any{"resources": resources}
-					if formatErr := FormatAndPrintResponse(thisCmd, resp, listErr); formatErr != nil {
-						fmt.Fprintf(os.Stderr, "%v\n", formatErr)
+					if formatErr := formatAndPrintOutput(thisCmd, "resources", resp, listErr); formatErr != nil {
+						PrintError(os.Stderr, formatErr)
 						continue
 					}
 				case "prompts":
 					var listPromptsResult *mcp.ListPromptsResult
 					listPromptsResult, listErr = mcpClient.ListPrompts(context.Background(), mcp.ListPromptsRequest{})
+					listErr = degradeList(mcpClient, featurePrompts, listErr)
 
 					var prompts []any
 					if listErr == nil && listPromptsResult != nil {
@@ -152,8 +164,8 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 					}
 
 					resp = map[string]any{"prompts": prompts}
-					if formatErr := FormatAndPrintResponse(thisCmd, resp, listErr); formatErr != nil {
-						fmt.Fprintf(os.Stderr, "%v\n", formatErr)
+					if formatErr := formatAndPrintOutput(thisCmd, "prompts", resp, listErr); formatErr != nil {
+						PrintError(os.Stderr, formatErr)
 						continue
 					}
 				case "format":
@@ -171,18 +183,39 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 					} else {
 						fmt.Fprintln(thisCmd.OutOrStdout(), "Invalid format. Use: table, json, or pretty")
 					}
+				case "build":
+					if len(commandArgs) != 1 {
+						fmt.Fprintln(thisCmd.OutOrStdout(), "Usage: build <tool>")
+						continue
+					}
+					if err := buildCommand(thisCmd, mcpClient, parsedArgs, commandArgs[0], recallPrompt(line), calls); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						continue
+					}
+				case "notifications":
+					if err := notificationsCommand(thisCmd, mcpClient, notifications, &notificationFilter, commandArgs); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						continue
+					}
+				case "calls":
+					callsCommand(thisCmd, calls)
+				case "diff":
+					if err := diffCommand(thisCmd, calls, commandArgs); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						continue
+					}
 				case "call":
 					if len(commandArgs) < 1 {
 						fmt.Fprintln(thisCmd.OutOrStdout(), "Usage: call <entity> [--params '{...}']")
 						continue
 					}
-					err := callCommand(thisCmd, mcpClient, commandArgs)
+					err := callCommand(thisCmd, mcpClient, parsedArgs, commandArgs, line.Prompt, calls)
 					if err != nil {
 						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 						continue
 					}
 				default:
-					if err := callCommand(thisCmd, mcpClient, append([]string{command}, commandArgs...)); err != nil {
+					if err := callCommand(thisCmd, mcpClient, parsedArgs, append([]string{command}, commandArgs...), line.Prompt, calls); err != nil {
 						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 						continue
 					}
@@ -192,7 +225,10 @@ func ShellCmd() *cobra.Command { //nolint:gocyclo
 	}
 }
 
-func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, commandArgs []string) error {
+// callCommand calls a tool, reads a resource or gets a prompt in the shell. The declared
+// arguments of a prompt that were not given are asked for with ask, and the messages of the
+// prompt are previewed in table format. The call is kept in calls to compare it with others.
+func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, serverArgs, commandArgs []string, ask func(string) (string, error), calls *callHistory) error {
 	entityName := commandArgs[0]
 	entityType := EntityTypeTool
 	parts := strings.SplitN(entityName, ":", 2)
@@ -232,6 +268,8 @@ func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, commandArgs [
 	}
 
 	var resp map[string]any
+	var sent any
+	var preview *mcp.GetPromptResult
 	var execErr error
 
 	switch entityType {
@@ -239,8 +277,10 @@ func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, commandArgs [
 		var toolResponse *mcp.CallToolResult
 		request := mcp.CallToolRequest{}
 		request.Params.Name = entityName
-		request.Params.Arguments = params
+		request.Params.Arguments = applyAliasDefaults(serverArgs, entityName, params)
+		sent = request.Params.Arguments
 		toolResponse, execErr = mcpClient.CallTool(context.Background(), request)
+		warnIfResultDeprecated(entityName, toolResponse)
 		if execErr == nil && toolResponse != nil {
 			resp = ConvertJSONToMap(toolResponse)
 		} else {
@@ -260,7 +300,21 @@ func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, commandArgs [
 		var promptResponse *mcp.GetPromptResult
 		request := mcp.GetPromptRequest{}
 		request.Params.Name = entityName
+		request.Params.Arguments = promptArguments(params)
+		if ask != nil {
+			// Servers that do not list the prompt get the arguments as given
+			if prompt, findErr := findPrompt(mcpClient, entityName); findErr == nil && prompt != nil {
+				request.Params.Arguments, execErr = askPromptArguments(thisCmd.OutOrStdout(), prompt, request.Params.Arguments, ask)
+				if execErr != nil {
+					return execErr
+				}
+			}
+		}
+		sent = request.Params.Arguments
 		promptResponse, execErr = mcpClient.GetPrompt(context.Background(), request)
+		if execErr == nil && promptResponse != nil && jsonutils.ParseFormat(FormatOption) == jsonutils.FormatTable {
+			preview = promptResponse
+		}
 		if execErr == nil && promptResponse != nil {
 			resp = ConvertJSONToMap(promptResponse)
 		} else {
@@ -270,11 +324,16 @@ func callCommand(thisCmd *cobra.Command, mcpClient *client.Client, commandArgs [
 		fmt.Fprintf(os.Stderr, "Error: unsupported entity type: %s\n", entityType)
 	}
 
+	calls.add(commandArgs[0], sent, resp, execErr)
 	if execErr != nil {
 		return execErr
 	}
+	if preview != nil {
+		printPromptPreview(thisCmd.OutOrStdout(), preview)
+		return nil
+	}
 
-	formatErr := FormatAndPrintResponse(thisCmd, resp, nil)
+	formatErr := formatAndPrintOutput(thisCmd, "call", resp, nil)
 	if formatErr != nil {
 		return fmt.Errorf("error formatting output: %w", formatErr)
 	}
@@ -315,6 +374,10 @@ func setUpCompleter(line *liner.State) {
 			"resources",
 			"prompts",
 			"call",
+			"build",
+			"calls",
+			"diff",
+			"notifications",
 			"format",
 			"help",
 			"exit",
@@ -338,11 +401,15 @@ func printShellHelp(thisCmd *cobra.Command) {
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  resources                  List available resources")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  prompts                    List available prompts")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  call <entity> [--params '{...}']  Call a tool, resource, or prompt")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  build <tool>               Build the params of a tool call field by field")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  calls                      List the calls made in this session")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  diff [call] [call]         Compare two calls side by side, the last two by default")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  notifications [level]      Follow notifications from the server, from level up")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  format [json|pretty|table] Get or set output format")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "Direct Tool Calling:")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  <tool_name> {\"param\": \"value\"}  Call a tool directly with JSON parameters")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  resource:<name>            Read a resource directly")
-	fmt.Fprintln(thisCmd.OutOrStdout(), "  prompt:<name>              Get a prompt directly")
+	fmt.Fprintln(thisCmd.OutOrStdout(), "  prompt:<name> [{...}]      Get a prompt, asking for missing arguments")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "Special Commands:")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  /h, /help                  Show this help")
 	fmt.Fprintln(thisCmd.OutOrStdout(), "  /q, /quit, exit            Exit the shell")
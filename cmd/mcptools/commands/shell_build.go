@@ -0,0 +1,396 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/contract"
+	"github.com/f/mcptools/pkg/paramhistory"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+)
+
+// errBuildCancelled is returned when the request builder is abandoned.
+var errBuildCancelled = errors.New("request cancelled")
+
+// recalledEntries is the number of earlier calls offered to start a request from.
+const recalledEntries = 5
+
+// askFunc asks for a line of input. recall holds earlier answers, newest first, for the user
+// to bring back with the arrow keys.
+type askFunc func(prompt string, recall []string) (string, error)
+
+// builderField is a top-level param of a tool.
+type builderField struct {
+	name     string
+	schema   map[string]any
+	required bool
+}
+
+// requestBuilder walks through the params of a tool field by field, validating each value
+// against its schema and showing the params built so far.
+type requestBuilder struct {
+	w       io.Writer
+	ask     askFunc
+	tool    string
+	fields  []builderField
+	history []paramhistory.Entry
+	params  map[string]any
+}
+
+// toolFields returns the params of an input schema, required ones first, then by name.
+func toolFields(schema map[string]any) []builderField {
+	properties, _ := schema["properties"].(map[string]any)
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]any); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	fields := make([]builderField, 0, len(properties))
+	for name, property := range properties {
+		propertySchema, _ := property.(map[string]any)
+		if propertySchema == nil {
+			propertySchema = map[string]any{}
+		}
+		fields = append(fields, builderField{name: name, schema: propertySchema, required: required[name]})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].required != fields[j].required {
+			return fields[i].required
+		}
+		return fields[i].name < fields[j].name
+	})
+	return fields
+}
+
+// build asks for the params of the tool and returns them once every required field is set.
+func (b *requestBuilder) build() (map[string]any, error) {
+	b.params = map[string]any{}
+	if err := b.recall(); err != nil {
+		return nil, err
+	}
+	if len(b.fields) == 0 {
+		return b.params, nil
+	}
+
+	fmt.Fprintf(b.w, "Params of %s (Enter keeps a value, - clears it, < goes back, > finishes, ? describes, ↑ recalls earlier values):\n", b.tool)
+	for i := 0; i < len(b.fields); {
+		field := b.fields[i]
+		answer, err := b.ask(b.label(field), b.recalledValues(field.name))
+		if err != nil {
+			return nil, errBuildCancelled
+		}
+
+		switch answer = strings.TrimSpace(answer); answer {
+		case "":
+			if _, ok := b.params[field.name]; !ok && field.required {
+				fmt.Fprintf(b.w, "  %s is required\n", field.name)
+				continue
+			}
+		case "-":
+			if field.required {
+				fmt.Fprintf(b.w, "  %s is required\n", field.name)
+				continue
+			}
+			delete(b.params, field.name)
+		case "<":
+			i = max(i-1, 0)
+			continue
+		case ">":
+			if missing := b.missing(); len(missing) > 0 {
+				fmt.Fprintf(b.w, "  Still required: %s\n", strings.Join(missing, ", "))
+				continue
+			}
+			return b.params, nil
+		case "?":
+			describeField(b.w, field)
+			continue
+		default:
+			value, parseErr := parseFieldValue(field.schema, answer)
+			if parseErr == nil {
+				parseErr = validateField(field, value)
+			}
+			if parseErr != nil {
+				fmt.Fprintf(b.w, "  %v\n", parseErr)
+				continue
+			}
+			b.params[field.name] = value
+		}
+
+		b.show()
+		i++
+	}
+	return b.params, nil
+}
+
+// recall offers to start from the params of an earlier call.
+func (b *requestBuilder) recall() error {
+	if len(b.history) == 0 {
+		return nil
+	}
+	entries := b.history[:min(len(b.history), recalledEntries)]
+	fmt.Fprintf(b.w, "Earlier calls of %s:\n", b.tool)
+	for i, entry := range entries {
+		params, _ := json.Marshal(entry.Params)
+		fmt.Fprintf(b.w, "  %d. %s  (%s)\n", i+1, params, entry.Time.Local().Format("2006-01-02 15:04"))
+	}
+
+	for {
+		answer, err := b.ask(fmt.Sprintf("Start from [1-%d, Enter for none]: ", len(entries)), nil)
+		if err != nil {
+			return errBuildCancelled
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return nil
+		}
+		var n int
+		if _, scanErr := fmt.Sscanf(answer, "%d", &n); scanErr == nil && n >= 1 && n <= len(entries) {
+			for name, value := range entries[n-1].Params {
+				b.params[name] = value
+			}
+			b.show()
+			return nil
+		}
+		fmt.Fprintf(b.w, "  Pick a number from 1 to %d\n", len(entries))
+	}
+}
+
+func (b *requestBuilder) label(field builderField) string {
+	label := "  " + field.name + " (" + schemaTypeName(field.schema)
+	if field.required {
+		label += ", required"
+	}
+	label += ")"
+	if value, ok := b.params[field.name]; ok {
+		label += " [" + formatFieldValue(value) + "]"
+	} else if def, ok := field.schema["default"]; ok {
+		label += " [default " + formatFieldValue(def) + "]"
+	}
+	return label + ": "
+}
+
+// recalledValues returns the values field had in earlier calls, newest first.
+func (b *requestBuilder) recalledValues(name string) []string {
+	var values []string
+	seen := map[string]bool{}
+	for _, entry := range b.history {
+		value, ok := entry.Params[name]
+		if !ok {
+			continue
+		}
+		text := formatFieldValue(value)
+		if !seen[text] {
+			seen[text] = true
+			values = append(values, text)
+		}
+	}
+	return values
+}
+
+func (b *requestBuilder) missing() []string {
+	var missing []string
+	for _, field := range b.fields {
+		if _, ok := b.params[field.name]; field.required && !ok {
+			missing = append(missing, field.name)
+		}
+	}
+	return missing
+}
+
+// show writes the params built so far.
+func (b *requestBuilder) show() {
+	params, _ := json.Marshal(b.params)
+	fmt.Fprintf(b.w, "  → %s\n", params)
+}
+
+func describeField(w io.Writer, field builderField) {
+	if description, _ := field.schema["description"].(string); description != "" {
+		fmt.Fprintf(w, "  %s\n", description)
+	}
+	if enum, ok := field.schema["enum"].([]any); ok {
+		options := make([]string, len(enum))
+		for i, option := range enum {
+			options[i] = formatFieldValue(option)
+		}
+		fmt.Fprintf(w, "  One of: %s\n", strings.Join(options, ", "))
+	}
+	schema, _ := json.Marshal(field.schema)
+	fmt.Fprintf(w, "  Schema: %s\n", schema)
+}
+
+// schemaTypeName describes the type of a schema, such as "string" or "array of integer".
+func schemaTypeName(schema map[string]any) string {
+	if _, ok := schema["enum"]; ok {
+		return "enum"
+	}
+	switch t := schema["type"].(type) {
+	case string:
+		if items, ok := schema["items"].(map[string]any); ok && t == "array" {
+			return "array of " + schemaTypeName(items)
+		}
+		return t
+	case []any:
+		names := make([]string, len(t))
+		for i, name := range t {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return "any"
+}
+
+// schemaAllowsString reports whether a schema accepts strings.
+func schemaAllowsString(schema map[string]any) bool {
+	switch t := schema["type"].(type) {
+	case string:
+		return t == "string"
+	case []any:
+		for _, name := range t {
+			if name == "string" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseFieldValue turns an answer into the value of a field: strings as typed, yes and no for
+// booleans, comma-separated lists for arrays of strings, and JSON for everything else, or text
+// for fields without a type that is not JSON.
+func parseFieldValue(schema map[string]any, text string) (any, error) {
+	if schemaAllowsString(schema) {
+		// Fields that can also be something else take JSON when it parses
+		var value any
+		if _, several := schema["type"].([]any); several && json.Unmarshal([]byte(text), &value) == nil {
+			if _, isString := value.(string); !isString {
+				return value, nil
+			}
+		}
+		return text, nil
+	}
+
+	switch schema["type"] {
+	case "boolean":
+		switch strings.ToLower(text) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		if !strings.HasPrefix(text, "[") && schemaAllowsString(items) {
+			values := []any{}
+			for _, item := range strings.Split(text, ",") {
+				values = append(values, strings.TrimSpace(item))
+			}
+			return values, nil
+		}
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		if _, typed := schema["type"]; !typed {
+			return text, nil
+		}
+		return nil, fmt.Errorf("not valid JSON for %s: %w", schemaTypeName(schema), err)
+	}
+	return value, nil
+}
+
+// validateField checks the value of a field against its schema.
+func validateField(field builderField, value any) error {
+	schema := map[string]any{"type": "object", "properties": map[string]any{field.name: field.schema}}
+	return contract.Validate(schema, map[string]any{field.name: value})
+}
+
+// formatFieldValue writes a value the way it is typed: strings as they are, the rest as JSON.
+func formatFieldValue(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// buildCommand builds the params of a call to a tool in the shell, then calls it with them if
+// the user confirms, remembering them for the next call.
+func buildCommand(thisCmd *cobra.Command, mcpClient *client.Client, serverArgs []string, tool string, ask askFunc, calls *callHistory) error {
+	described, err := describeTool(context.Background(), mcpClient, tool)
+	if err != nil {
+		return err
+	}
+	definition, _ := described.(map[string]any)
+	schema, _ := definition["inputSchema"].(map[string]any)
+
+	history, err := paramhistory.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		history = paramhistory.History{}
+	}
+	server := serverName(serverArgs)
+
+	builder := &requestBuilder{
+		w:       thisCmd.OutOrStdout(),
+		ask:     ask,
+		tool:    tool,
+		fields:  toolFields(schema),
+		history: history.List(server, tool),
+	}
+	params, err := builder.build()
+	if err != nil {
+		return err
+	}
+
+	pretty, _ := json.MarshalIndent(params, "", "  ")
+	fmt.Fprintf(thisCmd.OutOrStdout(), "%s\n", pretty)
+	answer, err := ask(fmt.Sprintf("Call %s with these params? [Y/n] ", tool), nil)
+	if err != nil {
+		return errBuildCancelled
+	}
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "" && answer != "y" && answer != "yes" {
+		return nil
+	}
+
+	history.Add(server, tool, params, time.Now())
+	if saveErr := paramhistory.Save(history); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", saveErr)
+	}
+
+	compact, _ := json.Marshal(params)
+	return callCommand(thisCmd, mcpClient, serverArgs, []string{tool, string(compact)}, nil, calls)
+}
+
+// recallPrompt asks for a line with liner, with the recalled answers in place of the history of
+// the shell while it does.
+func recallPrompt(line *liner.State) askFunc {
+	return func(prompt string, recall []string) (string, error) {
+		var saved bytes.Buffer
+		_, _ = line.WriteHistory(&saved)
+		line.ClearHistory()
+		defer func() {
+			line.ClearHistory()
+			_, _ = line.ReadHistory(&saved)
+		}()
+
+		// Newest last, so ↑ brings it back first
+		for i := len(recall) - 1; i >= 0; i-- {
+			line.AppendHistory(recall[i])
+		}
+		return line.Prompt(prompt)
+	}
+}
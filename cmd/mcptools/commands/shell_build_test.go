@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/f/mcptools/pkg/paramhistory"
+)
+
+// scriptedAsk answers with answers in turn, recording the prompts and recalled values.
+type scriptedAsk struct {
+	answers []string
+	prompts []string
+	recalls [][]string
+}
+
+func (s *scriptedAsk) ask(prompt string, recall []string) (string, error) {
+	s.prompts = append(s.prompts, prompt)
+	s.recalls = append(s.recalls, recall)
+	if len(s.answers) == 0 {
+		return "", errors.New("aborted")
+	}
+	answer := s.answers[0]
+	s.answers = s.answers[1:]
+	return answer, nil
+}
+
+var buildTestSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"path":      map[string]any{"type": "string", "description": "File to search"},
+		"limit":     map[string]any{"type": "integer", "default": 10.0},
+		"recursive": map[string]any{"type": "boolean"},
+		"mode":      map[string]any{"type": "string", "enum": []any{"fast", "exact"}},
+		"tags":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	},
+	"required": []any{"path", "mode"},
+}
+
+func TestToolFields(t *testing.T) {
+	var names []string
+	for _, field := range toolFields(buildTestSchema) {
+		names = append(names, field.name)
+	}
+	want := []string{"mode", "path", "limit", "recursive", "tags"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("toolFields() = %v, want required fields first: %v", names, want)
+	}
+}
+
+func TestParseFieldValue(t *testing.T) {
+	tests := []struct {
+		schema map[string]any
+		text   string
+		want   any
+	}{
+		{map[string]any{"type": "string"}, "hello world", "hello world"},
+		{map[string]any{"type": "string"}, "42", "42"},
+		{map[string]any{"type": "integer"}, "42", 42.0},
+		{map[string]any{"type": "boolean"}, "yes", true},
+		{map[string]any{"type": "boolean"}, "false", false},
+		{map[string]any{"type": "array", "items": map[string]any{"type": "string"}}, "a, b", []any{"a", "b"}},
+		{map[string]any{"type": "array", "items": map[string]any{"type": "string"}}, `["a,b"]`, []any{"a,b"}},
+		{map[string]any{"type": "object"}, `{"a": 1}`, map[string]any{"a": 1.0}},
+		{map[string]any{"type": []any{"string", "null"}}, "null", nil},
+		{map[string]any{"type": []any{"string", "null"}}, "text", "text"},
+		{map[string]any{}, "untyped", "untyped"},
+	}
+	for _, tt := range tests {
+		got, err := parseFieldValue(tt.schema, tt.text)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFieldValue(%v, %q) = %#v, %v, want %#v", tt.schema, tt.text, got, err, tt.want)
+		}
+	}
+	if _, err := parseFieldValue(map[string]any{"type": "integer"}, "many"); err == nil {
+		t.Error("parseFieldValue() accepted text for an integer")
+	}
+}
+
+func TestRequestBuilder(t *testing.T) {
+	script := &scriptedAsk{answers: []string{
+		"",         // mode is required
+		"slow",     // not in the enum
+		"fast",     // mode
+		"notes.md", // path
+		"ten",      // not an integer
+		"2.5",      // not an integer either
+		"<",        // back to path
+		"",         // keep notes.md
+		"5",        // limit
+		"?",        // describe recursive
+		"y",        // recursive
+		">",        // skip tags
+	}}
+	var out bytes.Buffer
+	builder := &requestBuilder{w: &out, ask: script.ask, tool: "search", fields: toolFields(buildTestSchema)}
+	got, err := builder.build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{"mode": "fast", "path": "notes.md", "limit": 5.0, "recursive": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("build() = %v, want %v", got, want)
+	}
+	for _, expected := range []string{
+		"mode is required",
+		"is not one of",
+		"not valid JSON for integer",
+		"expected integer, got number",
+		`→ {"mode":"fast","path":"notes.md"}`,
+		`  limit (integer) [default 10]: `,
+		`  path (string, required) [notes.md]: `,
+		"Schema: {\"type\":\"boolean\"}",
+	} {
+		if !strings.Contains(out.String()+strings.Join(script.prompts, "\n"), expected) {
+			t.Errorf("expected output or prompts to contain %q, got:\n%s\n%s", expected, out.String(), strings.Join(script.prompts, "\n"))
+		}
+	}
+}
+
+func TestRequestBuilderRecall(t *testing.T) {
+	history := []paramhistory.Entry{
+		{Params: map[string]any{"mode": "exact", "path": "b.md"}, Time: time.Now()},
+		{Params: map[string]any{"mode": "fast", "path": "a.md", "tags": []any{"x"}}, Time: time.Now().Add(-time.Hour)},
+	}
+	script := &scriptedAsk{answers: []string{"3", "2", "", "c.md", ">"}}
+	var out bytes.Buffer
+	builder := &requestBuilder{w: &out, ask: script.ask, tool: "search", fields: toolFields(buildTestSchema), history: history}
+	got, err := builder.build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second call was recalled, and path changed
+	want := map[string]any{"mode": "fast", "path": "c.md", "tags": []any{"x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("build() = %v, want %v", got, want)
+	}
+	if !strings.Contains(out.String(), "Pick a number from 1 to 2") {
+		t.Errorf("expected an out of range pick to be refused, got:\n%s", out.String())
+	}
+	// Values of path in earlier calls, newest first, are recalled when asking for it
+	if recall := script.recalls[3]; !reflect.DeepEqual(recall, []string{"b.md", "a.md"}) {
+		t.Errorf("recalled %v for path, want b.md then a.md", recall)
+	}
+
+	cancelled := &requestBuilder{w: &out, ask: (&scriptedAsk{}).ask, tool: "search", fields: toolFields(buildTestSchema)}
+	if _, err = cancelled.build(); !errors.Is(err, errBuildCancelled) {
+		t.Errorf("expected errBuildCancelled, got %v", err)
+	}
+}
+
+func TestShellBuild(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cmd, buf, cleanupSetup := setupTestCommand(t, "build search\nfast\nnotes.md\n>\n\n/q\n")
+	defer cleanupSetup()
+
+	var gotParams any
+	cleanupClient := setupMockClient(func(method string, params any) (map[string]any, error) {
+		switch method {
+		case "tools/list":
+			return map[string]any{"tools": []any{map[string]any{"name": "search", "inputSchema": buildTestSchema}}}, nil
+		case "tools/call":
+			gotParams = params
+			return map[string]any{"content": []any{map[string]any{"type": "text", "text": "found"}}}, nil
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanupClient()
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := json.Marshal(gotParams)
+	if !strings.Contains(string(data), `"arguments":{"mode":"fast","path":"notes.md"}`) {
+		t.Errorf("expected the built params to be sent, got %s", data)
+	}
+	assertContains(t, buf.String(), `→ {"mode":"fast","path":"notes.md"}`)
+
+	history, err := paramhistory.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []paramhistory.Entry
+	for _, tools := range history {
+		entries = append(entries, tools["search"]...)
+	}
+	if len(entries) != 1 || entries[0].Params["path"] != "notes.md" {
+		t.Errorf("history = %+v, want the call remembered", history)
+	}
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/f/mcptools/pkg/sidediff"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// maxShellCalls is the number of calls of a shell session kept to compare.
+const maxShellCalls = 100
+
+// diffWidth is the width of the side by side view when the output is not a terminal.
+const diffWidth = 120
+
+// shellCall is a call made in the shell: what was called, with which params, and what came back.
+type shellCall struct {
+	n      int
+	entity string
+	params any
+	result map[string]any
+	err    error
+	time   time.Time
+}
+
+// callHistory keeps the latest calls of a shell session, numbered from 1. A nil history keeps
+// nothing.
+type callHistory struct {
+	calls []shellCall
+	count int
+}
+
+func (h *callHistory) add(entity string, params any, result map[string]any, err error) {
+	if h == nil {
+		return
+	}
+	h.count++
+	h.calls = append(h.calls, shellCall{n: h.count, entity: entity, params: params, result: result, err: err, time: time.Now()})
+	if len(h.calls) > maxShellCalls {
+		h.calls = h.calls[len(h.calls)-maxShellCalls:]
+	}
+}
+
+// find returns the call numbered n.
+func (h *callHistory) find(n int) (shellCall, error) {
+	for _, call := range h.calls {
+		if call.n == n {
+			return call, nil
+		}
+	}
+	if len(h.calls) == 0 {
+		return shellCall{}, fmt.Errorf("no call #%d, no calls were made yet", n)
+	}
+	return shellCall{}, fmt.Errorf("no call #%d, calls #%d to #%d are kept", n, h.calls[0].n, h.calls[len(h.calls)-1].n)
+}
+
+// outcome is what the call returned, or its error.
+func (c shellCall) outcome() any {
+	if c.err != nil {
+		return map[string]any{"error": c.err.Error()}
+	}
+	return c.result
+}
+
+// callsCommand lists the calls of the session to pick two to compare.
+func callsCommand(thisCmd *cobra.Command, history *callHistory) {
+	if len(history.calls) == 0 {
+		fmt.Fprintln(thisCmd.OutOrStdout(), "No calls yet")
+		return
+	}
+	for _, call := range history.calls {
+		status := "ok"
+		if call.err != nil {
+			status = "error"
+		} else if isError, _ := call.result["isError"].(bool); isError {
+			status = "tool error"
+		}
+		params, _ := json.Marshal(call.params)
+		fmt.Fprintf(thisCmd.OutOrStdout(), "#%-3d %s  %-5s  %s %s\n", call.n, call.time.Format("15:04:05"), status, call.entity, params)
+	}
+}
+
+// diffCommand shows two calls of the session side by side, their params and then their
+// results. Without numbers it compares the last two calls, and with one number that call and
+// the last.
+func diffCommand(thisCmd *cobra.Command, history *callHistory, args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("usage: diff [call] [call]")
+	}
+	numbers := make([]int, len(args))
+	for i, arg := range args {
+		n, err := strconv.Atoi(trimCallNumber(arg))
+		if err != nil {
+			return fmt.Errorf("invalid call number %q, list them with calls", arg)
+		}
+		numbers[i] = n
+	}
+	if len(numbers) < 2 {
+		if len(history.calls) < 2-len(numbers) {
+			return fmt.Errorf("make at least two calls to compare them")
+		}
+		last := history.calls[len(history.calls)-1].n
+		if len(numbers) == 0 {
+			numbers = []int{last - 1, last}
+		} else {
+			numbers = append(numbers, last)
+		}
+	}
+
+	left, err := history.find(numbers[0])
+	if err != nil {
+		return err
+	}
+	right, err := history.find(numbers[1])
+	if err != nil {
+		return err
+	}
+
+	width, color := diffWidth, false
+	if outFd := int(os.Stdout.Fd()); term.IsTerminal(outFd) {
+		if w, _, sizeErr := term.GetSize(outFd); sizeErr == nil {
+			width = w
+		}
+		color = true
+	}
+
+	out := thisCmd.OutOrStdout()
+	leftTitle := fmt.Sprintf("#%d %s", left.n, left.entity)
+	rightTitle := fmt.Sprintf("#%d %s", right.n, right.entity)
+	for _, section := range []struct {
+		name        string
+		left, right any
+	}{
+		{"Params", left.params, right.params},
+		{"Result", left.outcome(), right.outcome()},
+	} {
+		rows := sidediff.JSON(section.left, section.right)
+		if sidediff.Equal(rows) {
+			fmt.Fprintf(out, "%s: identical\n", section.name)
+			continue
+		}
+		fmt.Fprintf(out, "%s:\n", section.name)
+		sidediff.Render(out, leftTitle, rightTitle, rows, width, color)
+	}
+	return nil
+}
+
+// trimCallNumber accepts call numbers written as listed, like #3.
+func trimCallNumber(arg string) string {
+	if len(arg) > 1 && arg[0] == '#' {
+		return arg[1:]
+	}
+	return arg
+}
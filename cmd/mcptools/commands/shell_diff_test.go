@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestShellDiff(t *testing.T) {
+	cmd, buf, cleanupSetup := setupTestCommand(t, "diff\nsearch {\"q\":\"cats\",\"limit\":5}\nsearch {\"q\":\"dogs\",\"limit\":5}\ncalls\ndiff\ndiff #1 #1\ndiff 7\n/q\n")
+	defer cleanupSetup()
+
+	cleanupClient := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method == "tools/call" {
+			text := "1 cat"
+			if data, _ := json.Marshal(params); strings.Contains(string(data), "dogs") {
+				text = "3 dogs"
+			}
+			return map[string]any{"content": []any{map[string]any{"type": "text", "text": text}}}, nil
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanupClient()
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{
+		`#1   `,
+		`ok     search {"limit":5,"q":"cats"}`,
+		`  #1 search`,
+		`~   "q": "cats"`,
+		`"text": "1 cat",`,
+		`"text": "3 dogs",`,
+		"Params: identical",
+		"Result: identical",
+	} {
+		assertContains(t, output, expected)
+	}
+}
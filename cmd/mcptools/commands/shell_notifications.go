@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/notifyview"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// recordNotifications keeps the notifications the server sends in log.
+func recordNotifications(mcpClient *client.Client, log *notifyview.Log) {
+	mcpClient.OnNotification(func(notification mcp.JSONRPCNotification) {
+		log.Add(notifyview.NewEntry(notification.Method, notification.Params.AdditionalFields, time.Now()))
+	})
+}
+
+// notificationsCommand shows the notifications received in the shell: in a pane following new
+// ones on a terminal, and as a list otherwise or in builds without the tui feature. A level asks the server to send log messages from
+// that level up and shows only those. The filter is kept for the next time.
+func notificationsCommand(thisCmd *cobra.Command, mcpClient *client.Client, log *notifyview.Log, filter *notifyview.Filter, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: notifications [%s]", strings.Join(notifyview.Levels, "|"))
+	}
+	if len(args) == 1 {
+		level := notifyview.LevelIndex(strings.ToLower(args[0]))
+		if level < 0 {
+			return fmt.Errorf("unknown level %q, use one of: %s", args[0], strings.Join(notifyview.Levels, ", "))
+		}
+		request := mcp.SetLevelRequest{}
+		request.Params.Level = mcp.LoggingLevel(notifyview.Levels[level])
+		if err := mcpClient.SetLevel(context.Background(), request); err != nil {
+			return fmt.Errorf("failed to set the logging level: %w", err)
+		}
+		filter.MinLevel = level
+	}
+
+	inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !tuiIncluded || !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+		entries, _ := log.Entries()
+		shown := filter.Apply(entries)
+		if len(shown) == 0 {
+			fmt.Fprintln(thisCmd.OutOrStdout(), "No notifications yet")
+		}
+		for _, entry := range shown {
+			fmt.Fprintln(thisCmd.OutOrStdout(), notifyview.Format(entry))
+		}
+		return nil
+	}
+
+	return followNotifications(log, filter)
+}
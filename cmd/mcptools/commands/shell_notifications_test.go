@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestShellNotifications(t *testing.T) {
+	cmd, buf, cleanupSetup := setupTestCommand(t, "notifications warning\nnotifications loud\n/q\n")
+	defer cleanupSetup()
+
+	var gotLevel any
+	cleanupClient := setupMockClient(func(method string, params any) (map[string]any, error) {
+		if method == "logging/setLevel" {
+			gotLevel = params
+		}
+		return map[string]any{}, nil
+	})
+	defer cleanupClient()
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if params, ok := gotLevel.(mcp.SetLevelParams); !ok || params.Level != mcp.LoggingLevelWarning {
+		t.Errorf("expected the server to be asked for warnings, got %#v", gotLevel)
+	}
+	assertContains(t, buf.String(), "No notifications yet")
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/f/mcptools/pkg/usage"
+	"github.com/spf13/cobra"
+)
+
+// StatsCmd creates the stats command.
+func StatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show local tool usage analytics",
+		Long: `Show which tools are actually called, to guide which servers and tools to keep enabled
+for agents.
+
+Recording is opt-in and local only: once enabled, every tool call made through mcp (call, shell,
+web and any other command) is stored in the SQLite database $HOME/.mcpt/usage.db. Nothing is
+sent anywhere. Query individual calls with mcp history.
+
+Examples:
+  # Start recording tool calls
+  mcp stats enable
+
+  # Show call counts, error rates, average latency and SLO breaches per tool
+  mcp stats tools
+
+  # Pool anonymized usage across a team
+  mcp stats share -o alice.json
+  mcp stats pool alice.json bob.json carol.json
+
+  # Stop recording and delete the recorded calls
+  mcp stats disable
+  mcp stats reset`,
+	}
+
+	cmd.AddCommand(statsToolsCmd())
+	cmd.AddCommand(statsShareCmd())
+	cmd.AddCommand(statsPoolCmd())
+	cmd.AddCommand(&cobra.Command{
+		Use:   "enable",
+		Short: "Start recording tool calls",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := usage.Enable(); err != nil {
+				return err
+			}
+			fmt.Println("Tool usage recording enabled")
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "disable",
+		Short: "Stop recording tool calls",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := usage.Disable(); err != nil {
+				return err
+			}
+			fmt.Println("Tool usage recording disabled")
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "reset",
+		Short: "Delete all recorded tool calls",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := usage.Reset(); err != nil {
+				return err
+			}
+			fmt.Println("Tool usage records deleted")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func statsToolsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tools",
+		Short: "Show call counts, error rates, average latency and SLO breaches per tool",
+		Run: func(thisCmd *cobra.Command, _ []string) {
+			records, err := usage.Load()
+			if err != nil {
+				exitWithError(err)
+			}
+
+			if len(records) == 0 && !usage.Enabled() {
+				fmt.Fprintln(os.Stderr, "Tool usage recording is disabled. Enable it with: mcp stats enable")
+				return
+			}
+
+			summaries := ConvertJSONToSlice(usage.Summarize(records))
+			if formatErr := FormatAndPrintResponse(thisCmd, map[string]any{"usage": summaries}, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/f/mcptools/pkg/usage"
+	"github.com/spf13/cobra"
+)
+
+func statsShareCmd() *cobra.Command {
+	var since, outputPath string
+	var bucket int
+	var epsilon float64
+
+	cmd := &cobra.Command{
+		Use:   "share [--since 30d] [--bucket 5] [--epsilon 1] [-o file]",
+		Short: "Export anonymized tool usage to pool with other developers",
+		Long: `Export how often each tool was called, anonymized to be pooled with the usage of other
+developers by mcp stats pool.
+
+The export has no user name, host, arguments or time of individual calls. Servers are named by
+the host of their URL, the package run by npx or uvx, or the name of their executable, so
+paths and credentials on the command line are left out. Each count gets random Laplace noise
+of scale 1/epsilon and is rounded to a multiple of the bucket, so whether a given call was made
+cannot be told from the export; tools whose count rounds to zero are left out.
+
+Examples:
+  mcp stats share -o stats.json
+  mcp stats share --since 7d --bucket 10 --epsilon 0.5`,
+		Args: cobra.NoArgs,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			if bucket <= 0 {
+				return errors.New("--bucket must be positive")
+			}
+			if epsilon < 0 {
+				return errors.New("--epsilon must not be negative")
+			}
+			age, err := usage.ParseRetention(since)
+			if err != nil {
+				return err
+			}
+
+			records, err := usage.Load()
+			if err != nil {
+				return err
+			}
+			if len(records) == 0 && !usage.Enabled() {
+				return errors.New("tool usage recording is disabled, enable it with: mcp stats enable")
+			}
+
+			opts := usage.ShareOptions{Bucket: bucket, Epsilon: epsilon}
+			if age > 0 {
+				opts.Since = time.Now().Add(-age)
+			}
+			data, err := json.MarshalIndent(usage.Anonymize(records, opts), "", "  ")
+			if err != nil {
+				return err
+			}
+			data = append(data, '\n')
+
+			if outputPath == "" || outputPath == "-" {
+				_, err = thisCmd.OutOrStdout().Write(data)
+				return err
+			}
+			if err = os.WriteFile(outputPath, data, 0o600); err != nil {
+				return fmt.Errorf("failed to write usage share: %w", err)
+			}
+			fmt.Fprintf(thisCmd.ErrOrStderr(), "Wrote %s\n", outputPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "30d", "Only count calls made within this period, such as 7d or off for all calls")
+	cmd.Flags().IntVar(&bucket, "bucket", usage.DefaultBucket, "Round counts to a multiple of this")
+	cmd.Flags().Float64Var(&epsilon, "epsilon", usage.DefaultEpsilon, "Privacy budget of the noise added to counts; smaller adds more, 0 adds none")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "File to write the export to instead of stdout")
+
+	return cmd
+}
+
+func statsPoolCmd() *cobra.Command {
+	var minContributors int
+
+	cmd := &cobra.Command{
+		Use:   "pool [--min-contributors 3] share.json...",
+		Short: "Sum anonymized usage exported by several developers",
+		Long: `Sum the exports of mcp stats share to show which tools matter across a team: calls, errors
+and how many developers use each tool.
+
+Tools used by fewer developers than --min-contributors are left out, so a tool only one
+person uses cannot point back at them.
+
+Examples:
+  mcp stats pool shares/*.json
+  mcp stats pool --min-contributors 5 -f json shares/*.json`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(thisCmd *cobra.Command, args []string) {
+			shares := make([]usage.Share, 0, len(args))
+			for _, path := range args {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					exitWithError(err)
+				}
+				var share usage.Share
+				if err = json.Unmarshal(data, &share); err != nil {
+					exitWithError(fmt.Errorf("%s is not a usage share: %w", path, err))
+				}
+				shares = append(shares, share)
+			}
+
+			pooled, err := usage.Pool(shares, minContributors)
+			if err != nil {
+				exitWithError(err)
+			}
+			if formatErr := FormatAndPrintResponse(thisCmd, map[string]any{"pooled": ConvertJSONToSlice(pooled)}, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&minContributors, "min-contributors", usage.DefaultMinContributors, "Leave out tools used by fewer developers")
+
+	return cmd
+}
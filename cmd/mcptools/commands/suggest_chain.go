@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/f/mcptools/pkg/chain"
+	"github.com/spf13/cobra"
+)
+
+// SuggestChainCmd creates the suggest-chain command.
+func SuggestChainCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "suggest-chain from-tool to-tool [command args...]",
+		Short: "Suggest how to feed the output of one tool into another",
+		Long: `Suggest how to feed the output of one tool into the parameters of another by matching the
+fields of the output schema of the first tool to the input schema of the second, by name,
+related names (e.g. path and file) and type.
+
+A workflow skeleton calling both tools is printed as YAML, with each suggested mapping as a
+{{ steps.<tool>.result.<field> }} reference and the remaining parameters left empty. Tools
+without an output schema are assumed to return text, which is proposed for the first required
+string parameter.
+
+Once the remaining parameters are filled in, run the workflow with mcp run.
+
+Example:
+  mcp suggest-chain search_files read_file npx -y @modelcontextprotocol/server-filesystem ~`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
+				_ = thisCmd.Help()
+				return
+			}
+
+			const example = "Example: mcp suggest-chain search_files read_file npx -y @modelcontextprotocol/server-filesystem ~"
+
+			var names, parsedArgs []string
+			for i := 0; i < len(args); {
+				if n := processClientFlag(args, i); n > 0 {
+					i += n
+					continue
+				}
+				if len(names) < 2 {
+					names = append(names, args[i])
+				} else {
+					parsedArgs = append(parsedArgs, args[i])
+				}
+				i++
+			}
+			if len(names) < 2 {
+				exitWithError(usageError("two tool names are required", example))
+			}
+
+			mcpClient, err := CreateClientFunc(parsedArgs)
+			if err != nil {
+				exitWithError(withHint(err, example))
+			}
+
+			tools, err := listToolsRaw(context.Background(), mcpClient)
+			if err != nil {
+				exitWithError(err)
+			}
+
+			schemas := map[string]map[string]any{}
+			for _, tool := range tools {
+				if toolMap, ok := tool.(map[string]any); ok {
+					if name, ok := toolMap["name"].(string); ok {
+						schemas[name] = toolMap
+					}
+				}
+			}
+
+			from, to := schemas[names[0]], schemas[names[1]]
+			for i, tool := range []map[string]any{from, to} {
+				if tool == nil {
+					exitWithError(withHint(fmt.Errorf("tool %q not found", names[i]), "List the tools of the server with mcp tools"))
+				}
+			}
+
+			outputSchema, _ := from["outputSchema"].(map[string]any)
+			if outputSchema == nil {
+				fmt.Fprintf(os.Stderr, "Note: %s has no output schema; assuming it returns text\n", names[0])
+			}
+			fromInputSchema, _ := from["inputSchema"].(map[string]any)
+			toInputSchema, _ := to["inputSchema"].(map[string]any)
+
+			toInputs := chain.Fields(toInputSchema)
+			mappings := chain.Suggest(chain.Fields(outputSchema), toInputs)
+			for _, m := range mappings {
+				fmt.Fprintf(os.Stderr, "%s.result.%s → %s.params.%s (%s)\n", names[0], m.Source, names[1], m.Param, m.Reason)
+			}
+			if len(mappings) == 0 {
+				fmt.Fprintf(os.Stderr, "No fields of %s match the parameters of %s\n", names[0], names[1])
+			}
+
+			workflow, err := chain.Workflow(names[0], names[1], chain.Fields(fromInputSchema), toInputs, mappings)
+			if err != nil {
+				exitWithError(err)
+			}
+			_, _ = thisCmd.OutOrStdout().Write(workflow)
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/f/mcptools/pkg/teamsync"
+	"github.com/spf13/cobra"
+)
+
+// SyncCmd creates the sync command.
+func SyncCmd() *cobra.Command {
+	var opts teamsync.Options
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Pull a team's shared configuration from a git repository",
+		Long: `Pull shared configuration from a git repository into $HOME/.mcpt.
+
+The repository may contain:
+  aliases.json    server aliases, in the format of $HOME/.mcpt/aliases.json; or aliases.yaml
+                  or aliases.toml, the same in YAML or TOML
+  anonymize.json  anonymization rules for recordings
+  templates/      project templates for mcp new
+
+Nothing else in the repository is copied. Aliases are merged one by one, so personal aliases
+are kept. Items that changed only in the repository since the last sync are updated; items
+that were also changed or deleted locally are reported as conflicts and keep the local
+version unless --force is given.
+
+The remote and branch are remembered, so later syncs need no flags.
+
+Examples:
+  # Pull the team's configuration
+  mcp sync --remote git@github.com:org/mcp-config.git
+
+  # See what the next sync would change
+  mcp sync --dry-run
+
+  # Take the repository's version of conflicting items
+  mcp sync --force`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			changes, err := teamsync.Run(opts)
+			if err != nil {
+				return err
+			}
+
+			out := thisCmd.OutOrStdout()
+			if len(changes) == 0 {
+				fmt.Fprintln(out, "Already up to date")
+				return nil
+			}
+
+			conflicts := 0
+			for _, change := range changes {
+				switch change.Kind {
+				case teamsync.Added:
+					fmt.Fprintf(out, "+ %s\n", change.Item)
+				case teamsync.Updated:
+					fmt.Fprintf(out, "~ %s\n", change.Item)
+				case teamsync.Removed:
+					fmt.Fprintf(out, "- %s\n", change.Item)
+				case teamsync.Conflict:
+					conflicts++
+					fmt.Fprintf(out, "! %s changed locally, kept the local version\n", change.Item)
+				}
+			}
+			if conflicts > 0 {
+				fmt.Fprintf(out, "%d conflict(s); run with --force to take the repository's version\n", conflicts)
+			}
+			if opts.DryRun {
+				fmt.Fprintln(out, "Dry run: nothing was changed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Remote, "remote", "", "Git repository to pull from (default: the remote of the previous sync)")
+	cmd.Flags().StringVar(&opts.Branch, "branch", "", "Branch to pull (default: the repository's default branch)")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Take the repository's version of conflicting items")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show the changes without making them")
+
+	return cmd
+}
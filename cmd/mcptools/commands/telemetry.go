@@ -0,0 +1,212 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/telemetry"
+	"github.com/spf13/cobra"
+)
+
+// telemetryCommand is the command being run, for exitWithError to pass to RecordTelemetry when it
+// exits before the command returns.
+var telemetryCommand *cobra.Command
+
+// RecordTelemetry counts a run of cmd that failed with err, if not nil, when telemetry is enabled.
+// Only the command path and the class of the error are kept, never arguments or messages.
+func RecordTelemetry(cmd *cobra.Command, err error) {
+	if cmd == nil {
+		return
+	}
+	class := ""
+	if err != nil {
+		class = NewErrorReport(err).Code
+	}
+	// Telemetry must never get in the way of the command
+	_ = telemetry.Record(cmd.CommandPath(), class)
+}
+
+// TelemetryCmd creates the telemetry command.
+func TelemetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Manage opt-in usage telemetry",
+		Long: `Manage opt-in usage telemetry, which helps the maintainers see which commands are used and
+how they fail.
+
+Telemetry is off until enabled, and setting DO_NOT_TRACK turns it off regardless. Once enabled,
+mcp counts the runs of each command, such as "mcp call", and the class of their errors, such as
+timeout or connection_failed, in $HOME/.mcpt/telemetry.json. Arguments, parameters, server names,
+messages and results are never kept. Nothing is sent anywhere: export the counts to look at them
+or to share them, e.g. by attaching them to an issue.
+
+Examples:
+  mcp telemetry enable
+  mcp telemetry status
+  mcp telemetry export -o telemetry.json
+
+  # Stop counting and delete the counts
+  mcp telemetry disable`,
+	}
+
+	cmd.AddCommand(telemetryStatusCmd())
+	cmd.AddCommand(&cobra.Command{
+		Use:   "enable",
+		Short: "Start counting command runs and errors",
+		Args:  cobra.NoArgs,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			if err := telemetry.Enable(); err != nil {
+				return err
+			}
+			fmt.Fprintln(thisCmd.OutOrStdout(), "Telemetry enabled; the counts stay on this machine until you export them")
+			if telemetry.DoNotTrack() {
+				fmt.Fprintln(os.Stderr, "Warning: DO_NOT_TRACK is set, so nothing is counted while it is")
+			}
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "disable",
+		Short: "Stop counting and delete the counts",
+		Args:  cobra.NoArgs,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			if err := telemetry.Disable(); err != nil {
+				return err
+			}
+			fmt.Fprintln(thisCmd.OutOrStdout(), "Telemetry disabled and its counts deleted")
+			return nil
+		},
+	})
+	cmd.AddCommand(telemetryExportCmd())
+
+	return cmd
+}
+
+func telemetryStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether telemetry is enabled and what it counted",
+		Args:  cobra.NoArgs,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			counts, err := telemetry.Load()
+			if err != nil {
+				return err
+			}
+			path, err := telemetry.GetDataPath()
+			if err != nil {
+				return err
+			}
+
+			enabled := telemetry.Enabled()
+			if jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+				status := map[string]any{
+					"enabled":    enabled,
+					"doNotTrack": telemetry.DoNotTrack(),
+					"path":       path,
+					"runs":       counts.Runs(),
+					"commands":   ConvertJSONToMap(counts.Commands),
+				}
+				if !counts.Since.IsZero() {
+					status["since"] = counts.Since
+				}
+				return FormatAndPrintResponse(thisCmd, status, nil)
+			}
+
+			w := thisCmd.OutOrStdout()
+			switch {
+			case enabled:
+				fmt.Fprintf(w, "Telemetry is enabled, counting in %s\n", path)
+			case telemetry.DoNotTrack():
+				fmt.Fprintln(w, "Telemetry is disabled by DO_NOT_TRACK")
+			default:
+				fmt.Fprintln(w, "Telemetry is disabled. Enable it with: mcp telemetry enable")
+			}
+			if len(counts.Commands) == 0 {
+				return nil
+			}
+
+			fmt.Fprintf(w, "%d runs since %s\n\n", counts.Runs(), jsonutils.FormatTimestamp(counts.Since, time.Now()))
+			for _, name := range counts.CommandNames() {
+				c := counts.Commands[name]
+				fmt.Fprintf(w, "  %-24s %5d runs", name, c.Runs)
+				if len(c.Errors) > 0 {
+					fmt.Fprintf(w, "  errors: %s", formatErrorClasses(c.Errors))
+				}
+				fmt.Fprintln(w)
+			}
+			return nil
+		},
+	}
+}
+
+// formatErrorClasses formats error counts by class as "timeout 2, usage 1", most frequent first.
+func formatErrorClasses(errorCounts map[string]int) string {
+	classes := make([]string, 0, len(errorCounts))
+	for class := range errorCounts {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if errorCounts[classes[i]] != errorCounts[classes[j]] {
+			return errorCounts[classes[i]] > errorCounts[classes[j]]
+		}
+		return classes[i] < classes[j]
+	})
+
+	parts := make([]string, len(classes))
+	for i, class := range classes {
+		parts[i] = fmt.Sprintf("%s %d", class, errorCounts[class])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func telemetryExportCmd() *cobra.Command {
+	var outputPath string
+
+	cmd := &cobra.Command{
+		Use:   "export [-o file]",
+		Short: "Export the counts as JSON to inspect or share",
+		Long: `Export the counts collected by telemetry as JSON, with the version of mcp and the operating
+system and architecture it runs on. The export holds nothing else, so it can be read in full
+before it is shared.
+
+Examples:
+  mcp telemetry export
+  mcp telemetry export -o telemetry.json`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, _ []string) error {
+			counts, err := telemetry.Load()
+			if err != nil {
+				return err
+			}
+			if len(counts.Commands) == 0 && !telemetry.Enabled() {
+				return withHint(errors.New("telemetry is disabled and has nothing to export"), "Enable it with: mcp telemetry enable")
+			}
+
+			data, err := json.MarshalIndent(telemetry.Export(counts, Version, time.Now()), "", "  ")
+			if err != nil {
+				return err
+			}
+			data = append(data, '\n')
+
+			if outputPath == "" || outputPath == "-" {
+				_, err = thisCmd.OutOrStdout().Write(data)
+				return err
+			}
+			if err = os.WriteFile(outputPath, data, 0o600); err != nil {
+				return fmt.Errorf("failed to write telemetry export: %w", err)
+			}
+			fmt.Fprintf(thisCmd.ErrOrStderr(), "Wrote %s\n", outputPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "File to write the export to instead of stdout")
+	return cmd
+}
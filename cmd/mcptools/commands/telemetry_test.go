@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/telemetry"
+	"github.com/spf13/cobra"
+)
+
+func TestTelemetryCmd(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DO_NOT_TRACK", "")
+	originalFormat := FormatOption
+	FormatOption = "table"
+	defer func() { FormatOption = originalFormat }()
+
+	run := func(args ...string) (string, error) {
+		cmd := TelemetryCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := run("export"); err == nil {
+		t.Error("expected an error for exporting while disabled")
+	}
+	if _, err := run("enable"); err != nil {
+		t.Fatal(err)
+	}
+
+	call := &cobra.Command{Use: "call"}
+	(&cobra.Command{Use: "mcp"}).AddCommand(call)
+	RecordTelemetry(call, nil)
+	RecordTelemetry(call, usageError("tool name is required", "mcp call read_file server"))
+	RecordTelemetry(call, errors.New("secret payload"))
+
+	out, err := run("status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "mcp call") || !strings.Contains(out, "errors: error 1, usage 1") {
+		t.Errorf("status = %q", out)
+	}
+
+	out, err = run("export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "secret") || strings.Contains(out, "read_file") {
+		t.Errorf("export leaks error messages: %s", out)
+	}
+	var report telemetry.Report
+	if err = json.Unmarshal([]byte(out), &report); err != nil || report.Commands["mcp call"].Runs != 3 {
+		t.Errorf("export = %s, %v", out, err)
+	}
+
+	if _, err = run("disable"); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ = run("status"); !strings.Contains(out, "disabled") || strings.Contains(out, "mcp call") {
+		t.Errorf("status after disable = %q", out)
+	}
+}
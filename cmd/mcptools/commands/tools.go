@@ -2,9 +2,14 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
 )
@@ -12,8 +17,19 @@ import (
 // ToolsCmd creates the tools command.
 func ToolsCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:                "tools [command args...]",
-		Short:              "List available tools on the MCP server",
+		Use:   "tools [--match pattern] [--limit n] [--cursor cursor] [command args...]",
+		Short: "List available tools on the MCP server",
+		Long: `List the tools of an MCP server.
+
+Large gateways can expose thousands of tools. Use --match to list only tools whose name matches
+a glob pattern (a pattern without * matches names containing it), and --limit to stop after n
+tools; the cursor to pass to --cursor for the next tools is printed. Both are sent to the server
+as hints in the _meta of tools/list, and applied by mcp for servers that ignore them.
+
+Examples:
+  mcp tools npx -y @modelcontextprotocol/server-filesystem ~
+  mcp tools --match 'github_*' --limit 20 https://gateway.example.com/mcp
+  mcp tools --match issue --limit 20 --cursor eyJwYWdlIjoyfQ https://gateway.example.com/mcp`,
 		DisableFlagParsing: true,
 		SilenceUsage:       true,
 		Run: func(thisCmd *cobra.Command, args []string) {
@@ -22,26 +38,149 @@ func ToolsCmd() *cobra.Command {
 				return
 			}
 
+			var opts toolsListOptions
+			serverArgs := []string{}
 			parsedArgs := ProcessFlags(args)
-			mcpClient, err := CreateClientFunc(parsedArgs)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				fmt.Fprintf(os.Stderr, "Example: mcp tools npx -y @modelcontextprotocol/server-filesystem ~\n")
-				os.Exit(1)
+			for i := 0; i < len(parsedArgs); i++ {
+				switch {
+				case parsedArgs[i] == FlagMatch && i+1 < len(parsedArgs):
+					opts.match = parsedArgs[i+1]
+					i++
+				case parsedArgs[i] == FlagLimit && i+1 < len(parsedArgs):
+					n, err := strconv.Atoi(parsedArgs[i+1])
+					if err != nil || n <= 0 {
+						exitWithError(usageError(fmt.Sprintf("invalid limit: %s", parsedArgs[i+1]), ""))
+					}
+					opts.limit = n
+					i++
+				case parsedArgs[i] == FlagCursor && i+1 < len(parsedArgs):
+					opts.cursor = parsedArgs[i+1]
+					i++
+				default:
+					serverArgs = append(serverArgs, parsedArgs[i])
+				}
 			}
 
-			resp, listErr := mcpClient.ListTools(context.Background(), mcp.ListToolsRequest{})
+			mcpClient, err := CreateClientFunc(serverArgs)
+			if err != nil {
+				exitWithError(withHint(err, "Example: mcp tools npx -y @modelcontextprotocol/server-filesystem ~"))
+			}
 
-			var tools []any
-			if listErr == nil && resp != nil {
-				tools = ConvertJSONToSlice(resp.Tools)
+			// List tools raw so that deprecation markers in annotations and _meta are kept
+			var (
+				tools      []any
+				nextCursor string
+				listErr    error
+			)
+			if opts.match == "" && opts.limit == 0 && opts.cursor == "" {
+				tools, listErr = listToolsRaw(context.Background(), mcpClient)
+			} else {
+				tools, nextCursor, listErr = listToolsPartial(context.Background(), mcpClient, opts)
+			}
+			listErr = degradeList(mcpClient, featureTools, listErr)
+			if listErr == nil {
+				tools = warnDeprecatedTools(tools, HideDeprecated)
 			}
 
 			toolsMap := map[string]any{"tools": tools}
+			translateDescriptions(toolsMap)
+			if nextCursor != "" {
+				toolsMap["nextCursor"] = nextCursor
+			}
 			if formatErr := FormatAndPrintResponse(thisCmd, toolsMap, listErr); formatErr != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", formatErr)
-				os.Exit(1)
+				exitWithError(formatErr)
+			}
+			if nextCursor != "" && FormatOption == "table" {
+				fmt.Fprintf(os.Stderr, "More tools available: --cursor %s\n", nextCursor)
 			}
 		},
 	}
 }
+
+// toolsListOptions selects part of the tools of a server.
+type toolsListOptions struct {
+	match  string
+	cursor string
+	limit  int
+}
+
+// matches reports whether a tool name matches the --match pattern. Patterns without a wildcard
+// match names containing them.
+func (o toolsListOptions) matches(name string) bool {
+	if o.match == "" {
+		return true
+	}
+	pattern := o.match
+	if !strings.ContainsAny(pattern, "*?[") {
+		pattern = "*" + pattern + "*"
+	}
+	match, _ := filepath.Match(pattern, name)
+	return match
+}
+
+// listToolsPartial lists the tools selected by opts, following pagination cursors only until
+// the limit is reached. It returns the cursor of the page after the last one fetched, if any.
+// The match and limit are sent as hints in _meta; tools are also filtered here, since most
+// servers ignore them.
+func listToolsPartial(ctx context.Context, mcpClient *client.Client, opts toolsListOptions) ([]any, string, error) {
+	var tools []any
+	cursor := opts.cursor
+
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		meta := map[string]any{}
+		if opts.match != "" {
+			meta["match"] = opts.match
+		}
+		if opts.limit > 0 {
+			meta["limit"] = opts.limit - len(tools)
+		}
+		if len(meta) > 0 {
+			params["_meta"] = meta
+		}
+
+		raw, err := sendRawRequest(ctx, mcpClient, string(mcp.MethodToolsList), params)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var page struct {
+			NextCursor string `json:"nextCursor"`
+			Tools      []any  `json:"tools"`
+		}
+		if err = json.Unmarshal(raw, &page); err != nil {
+			return nil, "", fmt.Errorf("failed to parse tools list: %w", err)
+		}
+
+		for _, tool := range page.Tools {
+			if opts.matches(toolName(tool)) {
+				tools = append(tools, tool)
+			}
+		}
+
+		if page.NextCursor == cursor {
+			page.NextCursor = ""
+		}
+		if opts.limit > 0 && len(tools) >= opts.limit {
+			if dropped := len(tools) - opts.limit; dropped > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: the server ignored --limit; %d more matching tools of the last page were left out; raise --limit to list them\n", dropped)
+				tools = tools[:opts.limit]
+			}
+			return tools, page.NextCursor, nil
+		}
+		if page.NextCursor == "" {
+			return tools, "", nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// toolName returns the name of a tool listed as a generic map.
+func toolName(tool any) string {
+	m, _ := tool.(map[string]any)
+	name, _ := m["name"].(string)
+	return name
+}
@@ -2,6 +2,9 @@ package commands
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -60,3 +63,90 @@ func TestToolsCmdRun(t *testing.T) {
 	assertContains(t, output, "test-tool")
 	assertContains(t, output, "A test tool")
 }
+
+func TestToolsCmdRun_MatchAndLimit(t *testing.T) {
+	origFormatOption := FormatOption
+	defer func() { FormatOption = origFormatOption }()
+
+	// The server ignores the hints and pages through its tools two at a time
+	pages := map[string]map[string]any{
+		"": {
+			"tools":      []any{map[string]any{"name": "create_issue"}, map[string]any{"name": "list_repos"}},
+			"nextCursor": "2",
+		},
+		"2": {
+			"tools":      []any{map[string]any{"name": "close_issue"}, map[string]any{"name": "get_issue"}},
+			"nextCursor": "3",
+		},
+		"3": {
+			"tools": []any{map[string]any{"name": "delete_issue"}},
+		},
+	}
+	var cursors []string
+	cleanup := setupMockClient(func(_ string, params any) (map[string]any, error) {
+		p, _ := params.(map[string]any)
+		meta, _ := p["_meta"].(map[string]any)
+		if meta["match"] != "issue" {
+			t.Errorf("expected the match hint in _meta, got %v", p)
+		}
+		cursor, _ := p["cursor"].(string)
+		cursors = append(cursors, cursor)
+		return pages[cursor], nil
+	})
+	defer cleanup()
+
+	cmd := ToolsCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--match", "issue", "--limit", "2", "-f", "json", "server"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("cmd.Execute() error = %v", err)
+	}
+
+	output := buf.String()
+	assertContains(t, output, "create_issue")
+	assertContains(t, output, "close_issue")
+	assertContains(t, output, `"nextCursor":"3"`)
+	if strings.Contains(output, "list_repos") || strings.Contains(output, "delete_issue") {
+		t.Errorf("unexpected tools in output: %s", output)
+	}
+	if len(cursors) != 2 {
+		t.Errorf("expected two pages to be fetched, got cursors %q", cursors)
+	}
+}
+
+func TestToolsCmdRun_Translate(t *testing.T) {
+	defer func() { TranslateOption, GlossaryPath, OriginalOption = "", "", false }()
+	t.Setenv("MCPT_LLM_URL", "")
+	t.Setenv("MCPT_LLM_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+
+	glossary := filepath.Join(t.TempDir(), "de.yaml")
+	if err := os.WriteFile(glossary, []byte(`"A test tool": "Ein Testwerkzeug"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cleanup := setupMockClient(func(string, any) (map[string]any, error) {
+		return map[string]any{"tools": []any{map[string]any{"name": "test-tool", "description": "A test tool"}}}, nil
+	})
+	defer cleanup()
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--translate", "de", "--glossary", glossary, "server"}, "Ein Testwerkzeug"},
+		{[]string{"--translate", "de", "--glossary", glossary, "--original", "server"}, "A test tool"},
+	} {
+		cmd := ToolsCmd()
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetArgs(tt.args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("cmd.Execute() error = %v", err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("%v: output = %s, want %q", tt.args, buf.String(), tt.want)
+		}
+	}
+}
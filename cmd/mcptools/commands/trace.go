@@ -0,0 +1,354 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/anonymize"
+	"github.com/f/mcptools/pkg/record"
+	"github.com/spf13/cobra"
+)
+
+// TraceCmd creates the trace command.
+func TraceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trace",
+		Short: "Work with sessions recorded with --record",
+		Long: `Work with sessions recorded with --record.
+
+Examples:
+  # Export a recording with emails and IP addresses anonymized, to attach to a bug report
+  mcp trace export -o shared.jsonl session.jsonl
+
+  # Use custom anonymization rules
+  mcp trace export --rules anonymize.json session.jsonl
+
+  # Convert the timings of a recording for ui.perfetto.dev or chrome://tracing
+  mcp trace export --format perfetto -o session.trace.json session.jsonl
+
+  # Convert the history of the MCP Inspector to a recording, to replay it against its server
+  mcp trace import -o session.jsonl inspector-export.json -- npx -y @modelcontextprotocol/server-filesystem ~
+  mcp replay session.jsonl
+
+  # Turn a recording into a script of mcp commands to attach to a bug report
+  mcp trace to-script -o repro.sh session.jsonl`,
+	}
+
+	cmd.AddCommand(traceExportCmd())
+	cmd.AddCommand(traceImportCmd())
+	cmd.AddCommand(traceToScriptCmd())
+
+	return cmd
+}
+
+func traceExportCmd() *cobra.Command {
+	var rulesPath, outputPath string
+
+	cmd := &cobra.Command{
+		Use:   "export [--rules file] [--format perfetto] [-o file] recording.jsonl",
+		Short: "Export a recording with personal data anonymized",
+		Long: `Export a recording so it can be shared outside the organization. Email addresses and IP
+addresses are replaced by stable placeholders such as [email-1]; secrets are already tokenized
+when recording.
+
+With --format perfetto (or chrome), the recording is converted to a trace in the Chrome Trace
+Event Format instead, to inspect the timing of requests in ui.perfetto.dev or chrome://tracing.
+Each session, e.g. each server a workflow talks to, is a track; each request is a slice from
+the request to its response, named after the method and tool or prompt, and each
+notification an instant.
+
+Rules are read from --rules, or from $HOME/.mcpt/anonymize.json if it exists:
+
+  {
+    "emails": true,
+    "ips": true,
+    "names": ["Ada Lovelace"],
+    "namesFile": "employees.txt",
+    "patterns": [{"pattern": "ACME-[0-9]+", "label": "ticket"}]
+  }
+
+namesFile holds one name per line, e.g. exported from a directory or an NER tool.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			entries, err := record.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			rules, err := anonymize.LoadRules(rulesPath)
+			if err != nil {
+				return err
+			}
+			anonymizer, err := anonymize.New(rules)
+			if err != nil {
+				return err
+			}
+
+			out := thisCmd.OutOrStdout()
+			if outputPath != "" {
+				// #nosec G304 - the output path is provided explicitly by the user
+				file, createErr := os.OpenFile(outputPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+				if createErr != nil {
+					return fmt.Errorf("failed to create output file: %w", createErr)
+				}
+				defer func() { _ = file.Close() }()
+				out = file
+			}
+
+			if FormatOption == FormatPerfetto || FormatOption == FormatChrome {
+				return exportTrace(out, entries, anonymizer)
+			}
+			return exportRecording(out, entries, anonymizer)
+		},
+	}
+
+	cmd.Flags().StringVar(&rulesPath, "rules", "", "Anonymization rules file (default $HOME/.mcpt/anonymize.json)")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "File to write the export to instead of stdout")
+
+	return cmd
+}
+
+// Trace formats of trace export.
+const (
+	FormatPerfetto = "perfetto"
+	FormatChrome   = "chrome"
+)
+
+// exportRecording writes entries to w as a recording, anonymized by anonymizer.
+func exportRecording(w io.Writer, entries []record.Entry, anonymizer *anonymize.Anonymizer) error {
+	if err := anonymizeEntries(entries, anonymizer); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err = bw.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// exportTrace writes entries to w as a trace in the Chrome Trace Event Format, anonymized by
+// anonymizer.
+func exportTrace(w io.Writer, entries []record.Entry, anonymizer *anonymize.Anonymizer) error {
+	if err := anonymizeEntries(entries, anonymizer); err != nil {
+		return err
+	}
+	data, err := json.Marshal(record.ToTrace(entries))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// anonymizeEntries anonymizes the servers and messages of entries in place.
+func anonymizeEntries(entries []record.Entry, anonymizer *anonymize.Anonymizer) error {
+	for i := range entries {
+		for j, arg := range entries[i].Server {
+			entries[i].Server[j] = anonymizer.String(arg)
+		}
+		message, err := anonymizer.JSON(entries[i].Message)
+		if err != nil {
+			return err
+		}
+		entries[i].Message = message
+	}
+	return nil
+}
+
+func traceImportCmd() *cobra.Command {
+	var from, outputPath string
+
+	cmd := &cobra.Command{
+		Use:   "import [--from auto|inspector|jsonl] [-o file] capture [-- command args...]",
+		Short: "Convert traffic captured by another tool to a recording",
+		Long: `Convert traffic captured by another tool to a recording, so it can be replayed, exported
+and simulated against guard policies like a session recorded with --record.
+
+Captures are read as:
+
+  inspector  The request history of the MCP Inspector: an array of {"request", "response"}
+             pairs, or an object holding it as requestHistory or history, with the
+             notifications it showed as notifications or serverNotifications.
+  jsonl      One JSON-RPC message or batch per line, or an object wrapping it as message or
+             data with a time or timestamp and a direction such as sent or received.
+
+The default, auto, tells them apart from the content. Messages without a direction are
+assigned one from their method, and messages without a time, such as those of the Inspector,
+are spaced a millisecond apart, so durations in exported traces are not meaningful for them.
+Secrets are tokenized as when recording.
+
+The server of the session is the command after --, which replay runs by default.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			// #nosec G304 - the capture path is provided explicitly by the user
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read capture: %w", err)
+			}
+			messages, err := record.ParseCapture(data, from, time.Now())
+			if err != nil {
+				return err
+			}
+			if len(messages) == 0 {
+				return fmt.Errorf("no messages in %s", args[0])
+			}
+
+			vault, err := openRecordingVault()
+			if err != nil {
+				return err
+			}
+
+			out := thisCmd.OutOrStdout()
+			if outputPath != "" {
+				// #nosec G304 - the output path is provided explicitly by the user
+				file, createErr := os.OpenFile(outputPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+				if createErr != nil {
+					return fmt.Errorf("failed to create output file: %w", createErr)
+				}
+				defer func() { _ = file.Close() }()
+				out = file
+			}
+
+			recorder := record.NewRecorder(out, vault)
+			if err = recorder.StartAt(messages[0].Time, args[1:]); err != nil {
+				return err
+			}
+			for _, message := range messages {
+				if err = recorder.RecordAt(message.Time, message.Direction, message.Message); err != nil {
+					return err
+				}
+			}
+			if outputPath != "" {
+				fmt.Fprintf(os.Stderr, "Imported %d messages to %s\n", len(messages), outputPath)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", record.CaptureAuto, "Format of the capture: auto, inspector or jsonl")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "File to write the recording to instead of stdout")
+
+	return cmd
+}
+
+func traceToScriptCmd() *cobra.Command {
+	var outputPath string
+	var paramsFiles, revealSecrets bool
+
+	cmd := &cobra.Command{
+		Use:   "to-script [--params-files] [--reveal-secrets] [-o script.sh] recording.jsonl",
+		Short: "Convert a recording to a shell script of equivalent mcp commands",
+		Long: `Convert the requests of a recording to a bash script of mcp commands that send them again,
+to rerun an interaction or share it as a reproduction case: tools/call becomes mcp call,
+resources/read mcp read-resource, prompts/get mcp get-prompt, and the list requests mcp tools,
+resources and prompts. Other requests, which no command sends, are kept as comments, and
+requests that failed are followed by the recorded error.
+
+The script runs each session against the server it was recorded with, or against the command
+given to it as arguments, with the mcp found in $PATH or $MCP.
+
+Params are inlined, or with --params-files written to one JSON file per call in a directory
+next to the script, named after it, which suits large or hand-edited params.
+
+Secrets stay the mcpt-secret-... tokens of the recording, so the script can be shared. Use
+--reveal-secrets to put them back from the local vault, for a script run on this machine.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if paramsFiles && outputPath == "" {
+				return fmt.Errorf("--params-files needs --output, to write the params next to the script")
+			}
+			entries, err := record.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if revealSecrets {
+				if err = detokenizeEntries(entries); err != nil {
+					return err
+				}
+			}
+
+			options := record.ScriptOptions{Source: filepath.Base(args[0])}
+			if paramsFiles {
+				options.ParamsDir = strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath)) + "-params"
+			}
+			script := record.ToScript(entries, options)
+
+			if outputPath == "" {
+				_, err = io.WriteString(thisCmd.OutOrStdout(), script.Text)
+				return err
+			}
+			// #nosec G306 - the script is meant to be run
+			if err = os.WriteFile(outputPath, []byte(script.Text), 0o700); err != nil {
+				return fmt.Errorf("failed to write script: %w", err)
+			}
+			dir := filepath.Dir(outputPath)
+			for name, data := range script.Files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+					return err
+				}
+				if err = os.WriteFile(path, data, 0o600); err != nil {
+					return fmt.Errorf("failed to write params: %w", err)
+				}
+			}
+			fmt.Fprintf(os.Stderr, "Wrote %d commands to %s\n", script.Commands, outputPath)
+			if script.Skipped > 0 {
+				fmt.Fprintf(os.Stderr, "%d requests no mcp command sends are left as comments\n", script.Skipped)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "File to write the script to instead of stdout")
+	cmd.Flags().BoolVar(&paramsFiles, "params-files", false, "Write the params of each call to a JSON file next to the script")
+	cmd.Flags().BoolVar(&revealSecrets, "reveal-secrets", false, "Put the secrets of the recording back from the local vault")
+
+	return cmd
+}
+
+// detokenizeEntries puts the secrets of the servers and messages of entries back in place.
+func detokenizeEntries(entries []record.Entry) error {
+	vault, err := openRecordingVault()
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		for j, arg := range entries[i].Server {
+			value, detokenizeErr := vault.Detokenize(arg)
+			if detokenizeErr != nil {
+				return detokenizeErr
+			}
+			entries[i].Server[j], _ = value.(string)
+		}
+		if entries[i].Message == nil {
+			continue
+		}
+		var message any
+		if err = json.Unmarshal(entries[i].Message, &message); err != nil {
+			return err
+		}
+		if message, err = vault.Detokenize(message); err != nil {
+			return err
+		}
+		if entries[i].Message, err = json.Marshal(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
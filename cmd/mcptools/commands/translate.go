@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/f/mcptools/pkg/llm"
+	"github.com/f/mcptools/pkg/translate"
+)
+
+// translateDescriptions renders the descriptions of a tools or prompts listing in the language
+// selected with --translate or MCPT_TRANSLATE, unless --original is given. Descriptions that
+// cannot be translated are left as they are, with a warning.
+func translateDescriptions(listing map[string]any) {
+	lang := translate.Language(cmp.Or(TranslateOption, os.Getenv("MCPT_TRANSLATE")))
+	if OriginalOption || lang == "" || listing == nil {
+		return
+	}
+
+	translator, err := newTranslator(lang)
+	if err == nil {
+		err = translator.Descriptions(context.Background(), listing)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: descriptions not translated: %v\n", err)
+	}
+}
+
+// newTranslator creates a translator into lang, with the glossary given with --glossary or
+// found in $HOME/.mcpt/glossaries/<lang>.yaml, and the model configured in the environment.
+func newTranslator(lang string) (*translate.Translator, error) {
+	translator := &translate.Translator{Lang: lang}
+
+	glossaryPath := GlossaryPath
+	if glossaryPath == "" {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			if path := filepath.Join(homeDir, ".mcpt", "glossaries", lang+".yaml"); fileExists(path) {
+				glossaryPath = path
+			}
+		}
+	}
+	if glossaryPath != "" {
+		glossary, err := translate.LoadGlossary(glossaryPath)
+		if err != nil {
+			return nil, err
+		}
+		translator.Glossary = glossary
+	}
+
+	if llm.Configured() && !llmIncluded {
+		fmt.Fprintf(os.Stderr, "Warning: only translating with the glossary: %v\n",
+			notIncludedError("Translating with a language model", "llm"))
+	} else if llm.Configured() {
+		model := llm.NewFromEnv()
+		translator.Model = model
+		translator.CacheKey = model.URL + "\x00" + model.Model
+		if cachePath, err := translate.GetCachePath(); err == nil {
+			translator.CachePath = cachePath
+		}
+	}
+
+	return translator, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/trust"
+	"github.com/spf13/cobra"
+)
+
+// TrustCmd creates the trust command.
+func TrustCmd() *cobra.Command {
+	var list, forget bool
+
+	cmd := &cobra.Command{
+		Use:   "trust [alias or command...]",
+		Short: "Pin what a stdio server resolves to, refusing to run it when it changes",
+		Long: `Pin what a stdio server resolves to, as a defense against supply-chain swaps of servers
+installed on the fly by npx and similar runners.
+
+With --verify-server, the first run of a stdio server records the SHA-256 of its executable,
+of the script an interpreter such as node or python runs, and for npx and bunx the package
+version and registry integrity hash the package resolves to (asking npm view). Later runs are
+compared with the record: changes are reported as warnings and accepted, unless the server was
+pinned with mcp trust, in which case it is refused until it is trusted again.
+
+Records are kept in $HOME/.mcpt/trust.json, by alias or command line.
+
+Examples:
+  # Pin a server, or accept its change after reviewing it
+  mcp trust fs
+  mcp trust npx -y @modelcontextprotocol/server-filesystem ~
+
+  # Check servers on every run
+  mcp tools --verify-server fs
+
+  # List records, or remove one
+  mcp trust --list
+  mcp trust --forget fs`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			var serverArgs []string
+			for _, arg := range args {
+				switch arg {
+				case "--list":
+					list = true
+				case "--forget":
+					forget = true
+				case "-h", "--help":
+					return thisCmd.Help()
+				default:
+					serverArgs = append(serverArgs, arg)
+				}
+			}
+
+			out := thisCmd.OutOrStdout()
+			store, err := trust.Load()
+			if err != nil {
+				return err
+			}
+			if list {
+				names := make([]string, 0, len(store))
+				for name := range store {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					state := "recorded"
+					if store[name].Pinned {
+						state = "pinned"
+					}
+					fmt.Fprintf(out, "%s\t%s\t%s\tlast seen %s\n", name, state, describeIdentity(store[name].Identity),
+						store[name].LastSeen.Local().Format(time.DateTime))
+				}
+				return nil
+			}
+			if len(serverArgs) == 0 {
+				return usageError("an alias or server command is required", "Example: mcp trust npx -y @modelcontextprotocol/server-filesystem ~")
+			}
+
+			name, command, commandArgs := trustedServer(serverArgs)
+			if forget {
+				if _, ok := store[name]; !ok {
+					return fmt.Errorf("no trust record for %s", name)
+				}
+				delete(store, name)
+				if err = store.Save(); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Forgot %s\n", name)
+				return nil
+			}
+
+			previous, found := store[name]
+			record, err := store.Pin(context.Background(), name, command, commandArgs)
+			if err != nil {
+				return err
+			}
+			if err = store.Save(); err != nil {
+				return err
+			}
+			if found {
+				for _, change := range previous.Changes(record.Identity) {
+					fmt.Fprintf(out, "  %s\n", change)
+				}
+			}
+			fmt.Fprintf(out, "Pinned %s: %s\n", name, describeIdentity(record.Identity))
+			return nil
+		},
+	}
+	return cmd
+}
+
+// trustedServer returns the name a server is recorded under, its alias or command line, and
+// the command that starts it.
+func trustedServer(args []string) (string, string, []string) {
+	name := strings.Join(args, " ")
+	if len(args) == 1 {
+		if server, found := alias.GetServerCommand(args[0]); found {
+			args = ParseCommandString(server)
+		}
+	}
+	return name, args[0], args[1:]
+}
+
+func describeIdentity(identity trust.Identity) string {
+	parts := []string{identity.Binary + " sha256:" + shortHash(identity.SHA256)}
+	if identity.Script != "" {
+		parts = append(parts, identity.Script+" sha256:"+shortHash(identity.ScriptSHA256))
+	}
+	if identity.Package != "" {
+		parts = append(parts, identity.Package)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// verifyServer checks a stdio server against its trust record before it is started, recording
+// it on first use. Pinned servers that changed are refused.
+func verifyServer(name, command string, args []string) error {
+	store, err := trust.Load()
+	if err != nil {
+		return err
+	}
+	outcome, changes, err := store.Check(context.Background(), name, command, args)
+	if err != nil {
+		return fmt.Errorf("failed to verify server: %w", err)
+	}
+
+	switch outcome {
+	case trust.Refused:
+		return fmt.Errorf("server %s changed since it was pinned:\n  %s\nif the change is expected, trust it again with 'mcp trust %s'",
+			name, strings.Join(changes, "\n  "), name)
+	case trust.New:
+		fmt.Fprintf(os.Stderr, "Trusting %s on first use: %s\n", name, describeIdentity(store[name].Identity))
+	case trust.Changed:
+		fmt.Fprintf(os.Stderr, "Warning: server %s changed since it was last run:\n  %s\n", name, strings.Join(changes, "\n  "))
+	case trust.Unchanged:
+	}
+	return store.Save()
+}
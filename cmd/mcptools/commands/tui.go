@@ -0,0 +1,57 @@
+//go:build !lite && !notui
+
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/f/mcptools/pkg/browse"
+	"github.com/f/mcptools/pkg/notifyview"
+	"golang.org/x/term"
+)
+
+// tuiIncluded reports whether this build includes the interactive terminal interfaces.
+const tuiIncluded = true
+
+// runBrowser browses the resource tree at root interactively on the terminal, previewing
+// resources read with read.
+func runBrowser(root *browse.Node, read browse.ReadFunc) error {
+	inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer func() { _ = term.Restore(inFd, state) }()
+
+	browser := browse.New(root, read)
+	browser.Clipboard = os.Stdout
+	return browser.Run(os.Stdin, os.Stdout, terminalSize(outFd))
+}
+
+// followNotifications shows the notifications in log in a pane following new ones on the
+// terminal. The filter changed in the pane is kept for the next time.
+func followNotifications(log *notifyview.Log, filter *notifyview.Filter) error {
+	inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer func() { _ = term.Restore(inFd, state) }()
+
+	pane := notifyview.NewPane(log, *filter)
+	err = pane.Run(os.Stdin, os.Stdout, terminalSize(outFd))
+	*filter = pane.Filter()
+	return err
+}
+
+// terminalSize returns the size of the terminal at fd, or 80x24 when it cannot be read.
+func terminalSize(fd int) func() (int, int) {
+	return func() (int, int) {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			return 80, 24
+		}
+		return width, height
+	}
+}
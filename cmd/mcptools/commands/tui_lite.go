@@ -0,0 +1,21 @@
+//go:build lite || notui
+
+package commands
+
+import (
+	"github.com/f/mcptools/pkg/browse"
+	"github.com/f/mcptools/pkg/notifyview"
+)
+
+// tuiIncluded reports whether this build includes the interactive terminal interfaces.
+const tuiIncluded = false
+
+// runBrowser fails, as this build leaves out the interactive browser.
+func runBrowser(*browse.Node, browse.ReadFunc) error {
+	return notIncludedError("the interactive browser", "tui")
+}
+
+// followNotifications fails, as this build leaves out the notifications pane.
+func followNotifications(*notifyview.Log, *notifyview.Filter) error {
+	return notIncludedError("the notifications pane", "tui")
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/f/mcptools/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+// TxnCmd creates the txn command.
+func TxnCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "txn [--set name=value]... plan.yaml [command args...]",
+		Short: "Run the tool calls of a plan, undoing them if one fails",
+		Long: `Run the steps of a plan one after the other, like mcp run, as a transaction: each step may
+declare a compensating call that undoes it, and when a step fails, the compensations of the
+steps completed before it run in reverse order. This gives best-effort atomicity to changes
+made with several tool calls:
+
+  inputs:
+    repo:
+      required: true
+  steps:
+    - id: branch
+      tool: create_branch
+      params:
+        repo: "{{ inputs.repo }}"
+        name: release
+      compensate:
+        tool: delete_branch
+        params:
+          repo: "{{ inputs.repo }}"
+          name: release
+    - id: pr
+      tool: create_pull_request
+      params:
+        repo: "{{ inputs.repo }}"
+        head: release
+      compensate:
+        tool: close_pull_request
+        params:
+          number: "{{ steps.pr.result.number }}"
+    - id: notify
+      tool: post_message
+      params:
+        text: "Release PR opened"
+
+Plans are workflows (see mcp run), and compensations refer to inputs and to the results of
+their step and earlier ones the same way. Compensations are best effort: one failing does not
+stop the others, and steps without one are left as they are. Every rollback is reported, and
+the command fails either way. The step that failed is not compensated; nor is one interrupted
+with Ctrl-C, which also rolls back the steps before it.
+
+Transactions are not journaled and cannot be resumed, and steps needing approval are refused.
+
+The result of the last step is printed.
+
+Examples:
+  mcp txn --set repo=f/mcptools release.yaml github
+  mcp txn plan.yaml npx -y @modelcontextprotocol/server-filesystem ~`,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		Run: func(thisCmd *cobra.Command, args []string) {
+			if len(args) == 1 && (args[0] == FlagHelp || args[0] == FlagHelpShort) {
+				_ = thisCmd.Help()
+				return
+			}
+
+			const example = "Example: mcp txn plan.yaml npx -y @modelcontextprotocol/server-filesystem ~"
+
+			var path string
+			var serverArgs []string
+			values := map[string]string{}
+			for i := 0; i < len(args); {
+				switch {
+				case path == "" && (args[i] == FlagFormat || args[i] == FlagFormatShort) && i+1 < len(args):
+					FormatOption = args[i+1]
+					i += 2
+				case path == "" && args[i] == FlagSet && i+1 < len(args):
+					name, value, ok := strings.Cut(args[i+1], "=")
+					if !ok || name == "" {
+						exitWithError(usageError(fmt.Sprintf("invalid input %q: expected name=value", args[i+1]), example))
+					}
+					values[name] = value
+					i += 2
+				default:
+					if n := processClientFlag(args, i); n > 0 {
+						i += n
+						continue
+					}
+					if path == "" {
+						path = args[i]
+					} else {
+						serverArgs = append(serverArgs, args[i])
+					}
+					i++
+				}
+			}
+			if path == "" {
+				exitWithError(usageError("a plan file is required", example))
+			}
+
+			wf, err := workflow.Load(path)
+			if err != nil {
+				exitWithError(err)
+			}
+			inputs, err := wf.Bind(values)
+			if err != nil {
+				exitWithError(withHint(err, "Set inputs with --set name=value"))
+			}
+
+			mcpClient, err := CreateClientFunc(serverArgs)
+			if err != nil {
+				exitWithError(withHint(err, example))
+			}
+			defer func() { _ = mcpClient.Close() }()
+
+			// Ctrl-C fails the step that runs, which rolls back the steps before it
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			txn := &workflow.Transaction{
+				Call: func(ctx context.Context, tool string, params map[string]any) (map[string]any, error) {
+					return callToolRaw(ctx, mcpClient, tool, params)
+				},
+				Inputs:   inputs,
+				Progress: os.Stderr,
+			}
+
+			result, err := txn.Run(ctx, wf)
+			var rollbackErr *workflow.RollbackError
+			switch {
+			case errors.As(err, &rollbackErr) && !rollbackErr.RolledBack():
+				_ = mcpClient.Close()
+				exitWithError(withHint(err, "Undo the steps that were not rolled back by hand"))
+			case err != nil:
+				_ = mcpClient.Close()
+				exitWithError(withHint(err, fmt.Sprintf("Fix the cause and run the transaction again with: mcp txn %s", path)))
+			}
+			if formatErr := FormatAndPrintResponse(thisCmd, result, nil); formatErr != nil {
+				exitWithError(formatErr)
+			}
+		},
+	}
+}
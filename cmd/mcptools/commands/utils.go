@@ -2,16 +2,38 @@ package commands
 
 import (
 	"bufio"
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/codec"
+	"github.com/f/mcptools/pkg/contract"
+	"github.com/f/mcptools/pkg/discover"
+	"github.com/f/mcptools/pkg/httpclient"
 	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/f/mcptools/pkg/keychain"
+	"github.com/f/mcptools/pkg/kube"
+	"github.com/f/mcptools/pkg/npipe"
+	"github.com/f/mcptools/pkg/oauth"
+	"github.com/f/mcptools/pkg/protocol"
+	"github.com/f/mcptools/pkg/record"
+	"github.com/f/mcptools/pkg/script"
+	"github.com/f/mcptools/pkg/stats"
+	"github.com/f/mcptools/pkg/stdio"
+	"github.com/f/mcptools/pkg/usage"
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -22,11 +44,52 @@ import (
 // sentinel errors.
 var (
 	ErrCommandRequired = fmt.Errorf("command to execute is required when using stdio transport")
+	ErrInitTimeout     = fmt.Errorf("initialization timed out")
 )
 
+// SessionStats holds the message statistics of the current session when --stats is enabled.
+// Commands that connect to several servers collect the statistics of all of them.
+var SessionStats *stats.Session
+
+// sessionStatsMutex guards the creation of SessionStats by clients created concurrently.
+var sessionStatsMutex sync.Mutex
+
+// invalidRateLimitRetries holds a --rate-limit-retries value ProcessFlags could not parse, reported
+// when an HTTP client is created.
+var invalidRateLimitRetries string
+
+// sessionStatsPrinted records that PrintSessionStats has run, so the statistics are printed
+// once whichever way the command ends.
+var sessionStatsPrinted bool
+
+// PrintSessionStats prints the statistics of the session to w when --stats is enabled, whether
+// the command succeeded or failed. Only the first call prints.
+func PrintSessionStats(w io.Writer) {
+	sessionStatsMutex.Lock()
+	defer sessionStatsMutex.Unlock()
+	if !ShowStats || SessionStats == nil || sessionStatsPrinted {
+		return
+	}
+	sessionStatsPrinted = true
+	SessionStats.Print(w)
+}
+
+// initResults holds the initialize result of each client created by CreateClient, for
+// commands reporting what servers announced during the handshake.
+var initResults sync.Map
+
+// serverInitializeResult returns the initialize result of mcpClient, or nil if it was created
+// without a handshake.
+func serverInitializeResult(mcpClient *client.Client) *mcp.InitializeResult {
+	value, _ := initResults.Load(mcpClient)
+	result, _ := value.(*mcp.InitializeResult)
+	return result
+}
+
 // IsHTTP returns true if the string is a valid HTTP URL.
 func IsHTTP(str string) bool {
-	return strings.HasPrefix(str, "http://") || strings.HasPrefix(str, "https://") || strings.HasPrefix(str, "localhost:")
+	return strings.HasPrefix(str, "http://") || strings.HasPrefix(str, "https://") || strings.HasPrefix(str, "localhost:") ||
+		strings.HasPrefix(str, "[")
 }
 
 // buildAuthHeader builds an Authorization header from the available auth options.
@@ -43,7 +106,10 @@ func buildAuthHeader(originalURL string) (string, string, error) {
 
 		parts := strings.SplitN(AuthUser, ":", 2)
 		username := parts[0]
-		password := parts[1]
+		password, err := keychain.Resolve(parts[1])
+		if err != nil {
+			return "", originalURL, err
+		}
 
 		// Allow empty username or password, but not both
 		if username != "" || password != "" {
@@ -57,7 +123,8 @@ func buildAuthHeader(originalURL string) (string, string, error) {
 
 	// Check for custom auth header
 	if AuthHeader != "" {
-		return AuthHeader, cleanURL, nil
+		header, err := keychain.Resolve(AuthHeader)
+		return header, cleanURL, err
 	}
 
 	// Extract credentials from URL if embedded
@@ -88,29 +155,198 @@ func buildAuthHeader(originalURL string) (string, string, error) {
 // CreateClientFunc is the function used to create MCP clients.
 // This can be replaced in tests to use a mock transport.
 var CreateClientFunc = func(args []string, _ ...client.ClientOption) (*client.Client, error) {
+	if len(args) == 0 && DiscoverOption != "" {
+		return createDiscoveredClient(DiscoverOption)
+	}
+	return createClient(args)
+}
+
+// createDiscoveredClient connects to the endpoints found with --discover in order, failing over
+// to the next one when an endpoint cannot be reached.
+func createDiscoveredClient(spec string) (*client.Client, error) {
+	endpoints, err := discover.Lookup(context.Background(), spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for i, endpoint := range endpoints {
+		c, clientErr := createClient([]string{endpoint.URL()})
+		if clientErr == nil {
+			return c, nil
+		}
+		lastErr = clientErr
+		if i < len(endpoints)-1 {
+			fmt.Fprintf(os.Stderr, "Warning: %s is unreachable (%v), trying the next discovered endpoint\n", endpoint.URL(), clientErr)
+		}
+	}
+	return nil, fmt.Errorf("no discovered endpoint is reachable: %w", lastErr)
+}
+
+// createClient creates a client for the server run or served at args and starts its session.
+func createClient(args []string) (*client.Client, error) {
 	if len(args) == 0 {
 		return nil, ErrCommandRequired
 	}
 
 	// Check if the first argument is an alias
 	if len(args) == 1 {
-		server, found := alias.GetServerCommand(args[0])
-		if found {
-			args = ParseCommandString(server)
+		if a, found := alias.Get(args[0]); found {
+			var c *client.Client
+			var err error
+			if len(a.Mirrors) > 0 {
+				c, err = createMirroredClient(args[0], a)
+			} else {
+				c, err = startClient(args[0], a, ParseCommandString(a.Command))
+			}
+			if err == nil {
+				refreshDocsCache(args[0], c)
+			}
+			return c, err
+		}
+	}
+	return startClient(strings.Join(args, " "), alias.ServerAlias{}, args)
+}
+
+// mirrorWinners caches the endpoint of each alias with mirrors that answered first, so the rest
+// of the session uses it without racing again.
+var mirrorWinners sync.Map
+
+// createMirroredClient connects to an alias with mirrors: to its command and mirrors in order,
+// failing over to the next one when an endpoint cannot be reached, or in race mode to all of
+// them at once, keeping the first to initialize. The endpoint used is cached for the session.
+func createMirroredClient(name string, a alias.ServerAlias) (*client.Client, error) {
+	if cached, ok := mirrorWinners.Load(name); ok {
+		c, err := startClient(name, a, ParseCommandString(cached.(string)))
+		if err == nil {
+			return c, nil
 		}
+		mirrorWinners.Delete(name)
+		fmt.Fprintf(os.Stderr, "Warning: %s is unreachable (%v), trying every endpoint of %s again\n", cached, err, name)
 	}
 
+	endpoints := a.Endpoints()
 	var c *client.Client
+	var endpoint string
+	var err error
+	if a.Race {
+		c, endpoint, err = raceEndpoints(name, a, endpoints)
+	} else {
+		c, endpoint, err = failOverEndpoints(name, a, endpoints)
+	}
+	if err != nil {
+		return nil, err
+	}
+	mirrorWinners.Store(name, endpoint)
+	return c, nil
+}
+
+// failOverEndpoints connects to the first of the endpoints of an alias that can be reached.
+func failOverEndpoints(name string, a alias.ServerAlias, endpoints []string) (*client.Client, string, error) {
+	var lastErr error
+	for i, endpoint := range endpoints {
+		c, err := startClient(name, a, ParseCommandString(endpoint))
+		if err == nil {
+			return c, endpoint, nil
+		}
+		lastErr = err
+		if i < len(endpoints)-1 {
+			fmt.Fprintf(os.Stderr, "Warning: %s is unreachable (%v), trying the next endpoint of %s\n", endpoint, err, name)
+		}
+	}
+	return nil, "", fmt.Errorf("no endpoint of %s is reachable: %w", name, lastErr)
+}
+
+// raceEndpoints connects to all the endpoints of an alias at once and returns the first to
+// initialize. The clients of the others are closed as they come up.
+func raceEndpoints(name string, a alias.ServerAlias, endpoints []string) (*client.Client, string, error) {
+	type attempt struct {
+		client   *client.Client
+		endpoint string
+		err      error
+	}
+	attempts := make(chan attempt, len(endpoints))
+	for _, endpoint := range endpoints {
+		go func() {
+			c, err := startClient(name, a, ParseCommandString(endpoint))
+			attempts <- attempt{client: c, endpoint: endpoint, err: err}
+		}()
+	}
+
+	var errs []error
+	for range endpoints {
+		first := <-attempts
+		if first.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", first.endpoint, first.err))
+			continue
+		}
+		go func(losers int) {
+			for range losers {
+				if late := <-attempts; late.err == nil {
+					_ = late.client.Close()
+				}
+			}
+		}(len(endpoints) - 1 - len(errs))
+		return first.client, first.endpoint, nil
+	}
+	return nil, "", fmt.Errorf("no endpoint of %s is reachable: %w", name, errors.Join(errs...))
+}
+
+// startClient creates a client for the server run or served at args, known as serverName, and
+// starts its session. serverAlias is the alias the server was given as, if any.
+func startClient(serverName string, serverAlias alias.ServerAlias, args []string) (*client.Client, error) {
+	var t transport.Interface
+	var stdioTransport *stdio.Transport
 	var err error
 
-	if len(args) == 1 && IsHTTP(args[0]) {
+	var validator *protocol.Validator
+	if StrictMode {
+		validator = protocol.NewValidator()
+	}
+
+	quirks, err := protocol.LookupQuirks(QuirksOption)
+	if err != nil {
+		return nil, err
+	}
+
+	wireCodec, err := codec.Lookup(CodecOption)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 1 && npipe.IsURL(args[0]) {
+		if wireCodec.Name() != "json" {
+			return nil, fmt.Errorf("the %s codec is only supported for stdio servers", wireCodec.Name())
+		}
+		addr, pipeErr := npipe.ParseURL(args[0])
+		if pipeErr != nil {
+			return nil, pipeErr
+		}
+		pipeOpts := []npipe.Option{npipe.WithWarnings(os.Stderr)}
+		if validator != nil {
+			pipeOpts = append(pipeOpts, npipe.WithFilter(strictFilter(validator)))
+		}
+		t = npipe.New(addr, pipeOpts...)
+		if validator != nil {
+			t = protocol.NewStrictTransport(t, validator)
+		}
+	} else if len(args) == 1 && IsHTTP(args[0]) {
+		if wireCodec.Name() != "json" {
+			return nil, fmt.Errorf("the %s codec is only supported for stdio servers", wireCodec.Name())
+		}
+
 		// Validate transport option for HTTP URLs
-		if TransportOption != TransportHTTP && TransportOption != TransportSSE {
-			return nil, fmt.Errorf("invalid transport option: %s (supported: http, sse)", TransportOption)
+		if TransportOption != TransportHTTP && TransportOption != TransportSSE && TransportOption != TransportLongPoll {
+			return nil, fmt.Errorf("invalid transport option: %s (supported: http, sse, longpoll)", TransportOption)
+		}
+
+		serverURL, urlErr := httpclient.NormalizeURL(args[0])
+		if urlErr != nil {
+			return nil, urlErr
 		}
 
 		// Build authentication header
-		authHeader, cleanURL, authErr := buildAuthHeader(args[0])
+		authHeader, cleanURL, authErr := buildAuthHeader(serverURL)
 		if authErr != nil {
 			return nil, fmt.Errorf("failed to parse authentication: %w", authErr)
 		}
@@ -120,54 +356,200 @@ var CreateClientFunc = func(args []string, _ ...client.ClientOption) (*client.Cl
 
 		// Add authentication header if provided
 		if authHeader != "" {
+			if AuthTokenFile != "" {
+				return nil, fmt.Errorf("%s cannot be used with other credentials", FlagAuthToken)
+			}
 			headers["Authorization"] = authHeader
 		}
+		if DeviceAuth {
+			if authHeader != "" || AuthTokenFile != "" {
+				return nil, fmt.Errorf("%s cannot be used with other credentials", FlagDeviceAuth)
+			}
+			if headers["Authorization"], err = deviceAuthHeader(cleanURL); err != nil {
+				return nil, err
+			}
+		}
 
 		// Add Accept header required by MCP streamable HTTP and SSE transports
 		// Many MCP servers require clients to accept both JSON responses and event streams
 		headers["Accept"] = "application/json, text/event-stream"
 
-		if TransportOption == TransportSSE {
-			// For SSE transport, use transport.ClientOption
-			c, err = client.NewSSEMCPClient(cleanURL, transport.WithHeaders(headers))
-		} else {
+		// Negotiate compressed responses, which matters for large resources on slow links
+		encodings, encErr := httpclient.ParseEncodings(CompressionOption)
+		if encErr != nil {
+			return nil, encErr
+		}
+		if retriesErr := rateLimitRetriesError(); retriesErr != nil {
+			return nil, retriesErr
+		}
+		preference, prefErr := addressPreference()
+		if prefErr != nil {
+			return nil, prefErr
+		}
+		// Rate-limited requests are retried with the compression undone, so JSON-RPC rate limit
+		// errors can be recognized
+		httpClient := &http.Client{
+			Transport: httpclient.NewRetryTransport(
+				httpclient.NewCompressionTransport(httpclient.NewTransport(preference), encodings),
+				RateLimitRetries, os.Stderr),
+		}
+		if AuthTokenFile != "" {
+			source, tokenErr := oauth.NewSource(AuthTokenFile, os.Stderr)
+			if tokenErr != nil {
+				return nil, tokenErr
+			}
+			httpClient.Transport = oauth.NewTransport(httpClient.Transport, source)
+		}
+		// Strict mode checks the messages as the server sent them, not as mcp-go decoded them
+		if validator != nil {
+			httpClient.Transport = protocol.NewStrictRoundTripper(httpClient.Transport, validator)
+		}
+
+		switch TransportOption {
+		case TransportSSE:
+			// For SSE transport, use transport.ClientOption. Proxies in restrictive networks
+			// block event streams, so fall back to long-polling if the stream cannot be opened.
+			t, err = transport.NewSSE(cleanURL, transport.WithHeaders(headers), transport.WithHTTPClient(httpClient))
+			if err == nil {
+				t = httpclient.NewFallback(t, httpclient.NewLongPoll(httpclient.LongPollURL(cleanURL), headers, httpClient), os.Stderr)
+			}
+		case TransportLongPoll:
+			t = httpclient.NewLongPoll(cleanURL, headers, httpClient)
+		default:
 			// For StreamableHTTP transport, use transport.StreamableHTTPCOption
-			c, err = client.NewStreamableHttpClient(cleanURL, transport.WithHTTPHeaders(headers))
+			t, err = transport.NewStreamableHTTP(cleanURL, transport.WithHTTPHeaders(headers),
+				transport.WithHTTPBasicClient(httpClient))
 		}
 
 		if err != nil {
 			return nil, err
 		}
-		err = c.Start(context.Background())
+
+		if validator != nil {
+			t = protocol.NewStrictTransport(t, validator)
+		}
 	} else {
-		c, err = client.NewStdioMCPClient(args[0], nil, args[1:]...)
+		opts := []stdio.Option{stdio.WithCodec(wireCodec)}
+		if len(quirks) > 0 {
+			opts = append(opts, stdio.WithFilter(quirksFilter(quirks)))
+		}
+		if validator != nil {
+			opts = append(opts, stdio.WithFilter(strictFilter(validator)))
+		} else {
+			// Banners and npm warnings printed before the first message are skipped, unless
+			// strict mode is to report them
+			opts = append(opts, stdio.WithPreamble(stdio.MaxPreambleLines, os.Stderr))
+		}
+		// Integration tests inject faults to exercise retries, quirks and strict mode
+		if spec := os.Getenv(stdio.FaultsEnv); spec != "" {
+			faults, faultsErr := stdio.ParseFaults(spec)
+			if faultsErr != nil {
+				return nil, fmt.Errorf("%s: %w", stdio.FaultsEnv, faultsErr)
+			}
+			opts = append(opts, stdio.WithFaults(faults))
+		}
+
+		if VerifyServer && !kube.IsTarget(args[0]) {
+			if trustErr := verifyServer(serverName, args[0], args[1:]); trustErr != nil {
+				return nil, trustErr
+			}
+		}
+		command, commandArgs, cmdErr := serverCommand(args)
+		if cmdErr != nil {
+			return nil, cmdErr
+		}
+
+		stdioTransport = stdio.New(command, commandArgs, opts...)
+		t = stdioTransport
+	}
+
+	// Send string request IDs when a prefix is configured
+	if IDPrefix != "" {
+		t = protocol.NewIDTransport(t, IDPrefix)
+	}
+
+	// Keep the code and data of server errors for structured error reports
+	t = protocol.NewErrorTransport(t, rpcErrors)
+
+	// Record the session with secrets tokenized so it can be shared and replayed
+	if RecordPath != "" {
+		recorder, recErr := startRecording(args)
+		if recErr != nil {
+			return nil, recErr
+		}
+		t = record.NewTransport(t, recorder)
+	}
+
+	// Keep the session in memory for the share links of the web interface
+	if SessionTimeline != nil {
+		vault, vaultErr := openRecordingVault()
+		if vaultErr != nil {
+			return nil, vaultErr
+		}
+		recorder := record.NewRecorder(SessionTimeline, vault)
+		if startErr := recorder.Start(args); startErr != nil {
+			return nil, startErr
+		}
+		t = record.NewTransport(t, recorder)
 	}
 
+	// Record tool calls for local usage analytics once the user opted in, and warn about calls
+	// slower than the SLOs of the alias
+	if enabled := usage.Enabled(); enabled || len(serverAlias.SLOs) > 0 {
+		usageOpts := []usage.TransportOption{usage.WithSLO(serverAlias.SLO, os.Stderr)}
+		if !enabled {
+			usageOpts = append(usageOpts, usage.WithoutRecording())
+		}
+		t = usage.NewTransport(t, serverName, usageOpts...)
+	}
+
+	// Wrap the transport to collect message statistics when requested
+	if ShowStats {
+		sessionStatsMutex.Lock()
+		if SessionStats == nil {
+			SessionStats = stats.NewSession()
+		}
+		session := SessionStats
+		sessionStatsMutex.Unlock()
+		t = stats.NewTransport(t, session)
+	}
+
+	initRequest, err := buildInitializeRequest()
 	if err != nil {
 		return nil, err
 	}
 
-	stdErr, ok := client.GetStderr(c)
-	if ok && ShowServerLogs {
+	var clientOpts []client.ClientOption
+	if NoInitialize {
+		// Mark the session as initialized so requests can be sent without a handshake
+		clientOpts = append(clientOpts, client.WithSession())
+	}
+
+	c := client.NewClient(t, clientOpts...)
+	if err = c.Start(context.Background()); err != nil {
+		return nil, err
+	}
+
+	if stdioTransport != nil && ShowServerLogs {
 		go func() {
-			scanner := bufio.NewScanner(stdErr)
+			scanner := bufio.NewScanner(stdioTransport.Stderr())
 			for scanner.Scan() {
 				fmt.Printf("[>] %s\n", scanner.Text())
 			}
 		}()
 	}
 
+	if NoInitialize {
+		return c, nil
+	}
+
 	done := make(chan error, 1)
 
 	go func() {
-		initRequest := mcp.InitializeRequest{}
-		initRequest.Params.ProtocolVersion = "2024-11-05"
-		initRequest.Params.Capabilities = mcp.ClientCapabilities{}
-		initRequest.Params.ClientInfo = mcp.Implementation{
-			Name:    "mcptools",
-			Version: "1.0.0",
-		}
-		_, err := c.Initialize(context.Background(), initRequest)
+		result, err := c.Initialize(context.Background(), initRequest)
+		if err == nil {
+			initResults.Store(c, result)
+		}
 		done <- err
 	}()
 
@@ -177,12 +559,99 @@ var CreateClientFunc = func(args []string, _ ...client.ClientOption) (*client.Cl
 			return nil, fmt.Errorf("init error: %w", err)
 		}
 	case <-time.After(10 * time.Second):
-		return nil, fmt.Errorf("initialization timed out")
+		_ = c.Close()
+		return nil, ErrInitTimeout
 	}
 
 	return c, nil
 }
 
+// applyAliasDefaults fills in the default parameters configured for tool when the server is
+// given as an alias.
+func applyAliasDefaults(serverArgs []string, tool string, params map[string]any) map[string]any {
+	if len(serverArgs) != 1 {
+		return params
+	}
+
+	a, found := alias.Get(serverArgs[0])
+	if !found {
+		return params
+	}
+
+	return a.ApplyDefaults(tool, params)
+}
+
+// serverCommand returns the command line that starts a stdio server. Servers given as
+// k8s:<target> run inside a pod, with their stdio piped through kubectl exec. Local servers are
+// confined to the --fs-jail directories.
+func serverCommand(args []string) (string, []string, error) {
+	if !kube.IsTarget(args[0]) {
+		if len(FsJail) > 0 {
+			return jailCommand(args[0], args[1:])
+		}
+		return args[0], args[1:], nil
+	}
+	if len(FsJail) > 0 {
+		return "", nil, fmt.Errorf("%s cannot be used with %s servers", FlagFsJail, kube.Prefix)
+	}
+
+	return kube.Command(args, kube.Options{
+		Context:   K8sContext,
+		Namespace: K8sNamespace,
+		Container: K8sContainer,
+	})
+}
+
+// buildInitializeRequest builds the initialize request, applying the client identity set with
+// MCPT_CLIENT_NAME and MCPT_CLIENT_VERSION, then --client-info, then --client-name and
+// --client-version, each overriding the one before.
+func buildInitializeRequest() (mcp.InitializeRequest, error) {
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = "2024-11-05"
+	initRequest.Params.Capabilities = mcp.ClientCapabilities{}
+	if LazySchemas {
+		initRequest.Params.Capabilities.Experimental = map[string]any{capabilityLazySchemas: map[string]any{}}
+	}
+	if ExperimentalOption != "" {
+		var experimental map[string]any
+		if err := json.Unmarshal([]byte(ExperimentalOption), &experimental); err != nil {
+			return initRequest, fmt.Errorf("invalid %s: expected a JSON object: %w", FlagExperimental, err)
+		}
+		if initRequest.Params.Capabilities.Experimental == nil {
+			initRequest.Params.Capabilities.Experimental = map[string]any{}
+		}
+		for name, value := range experimental {
+			initRequest.Params.Capabilities.Experimental[name] = value
+		}
+	}
+	initRequest.Params.ClientInfo = mcp.Implementation{
+		Name:    cmp.Or(os.Getenv("MCPT_CLIENT_NAME"), "mcptools"),
+		Version: cmp.Or(os.Getenv("MCPT_CLIENT_VERSION"), "1.0.0"),
+	}
+
+	overrides, err := parseKeyValueOption(ClientInfoOption)
+	if err != nil {
+		return initRequest, fmt.Errorf("invalid client info: %w", err)
+	}
+
+	for key, value := range overrides {
+		switch key {
+		case "name":
+			initRequest.Params.ClientInfo.Name = value
+		case "version":
+			initRequest.Params.ClientInfo.Version = value
+		case "protocol":
+			initRequest.Params.ProtocolVersion = value
+		default:
+			return initRequest, fmt.Errorf("invalid client info: unknown key %q (supported: name, version, protocol)", key)
+		}
+	}
+	initRequest.Params.ClientInfo.Name = cmp.Or(ClientNameOption, initRequest.Params.ClientInfo.Name)
+	initRequest.Params.ClientInfo.Version = cmp.Or(ClientVersionOption, initRequest.Params.ClientInfo.Version)
+
+	return initRequest, nil
+}
+
 // ProcessFlags processes command line flags, sets the format option, and returns the remaining
 // arguments. Supported format options: json, pretty, and table.
 // Supported transport options: http and sse.
@@ -195,6 +664,11 @@ func ProcessFlags(args []string) []string {
 
 	i := 0
 	for i < len(args) {
+		if n := processClientFlag(args, i); n > 0 {
+			i += n
+			continue
+		}
+
 		switch {
 		case (args[i] == FlagFormat || args[i] == FlagFormatShort) && i+1 < len(args):
 			FormatOption = args[i+1]
@@ -211,6 +685,9 @@ func ProcessFlags(args []string) []string {
 		case args[i] == FlagAuthHeader && i+1 < len(args):
 			AuthHeader = args[i+1]
 			i += 2
+		case args[i] == FlagNoDeprecated:
+			HideDeprecated = true
+			i++
 		default:
 			parsedArgs = append(parsedArgs, args[i])
 			i++
@@ -220,11 +697,337 @@ func ProcessFlags(args []string) []string {
 	return parsedArgs
 }
 
+// processClientFlag applies args[i] if it is one of the client flags shared by every command
+// that connects to a server. It returns the number of arguments consumed, or 0 if args[i] is
+// not such a flag.
+func processClientFlag(args []string, i int) int {
+	switch args[i] {
+	case FlagStats:
+		ShowStats = true
+		return 1
+	case FlagStrict:
+		StrictMode = true
+		return 1
+	case FlagQuirks:
+		if i+1 < len(args) {
+			QuirksOption = args[i+1]
+			return 2
+		}
+	case FlagNoInit:
+		NoInitialize = true
+		return 1
+	case FlagLazySchemas:
+		LazySchemas = true
+		return 1
+	case FlagClientInfo:
+		if i+1 < len(args) {
+			ClientInfoOption = args[i+1]
+			return 2
+		}
+	case FlagClientName:
+		if i+1 < len(args) {
+			ClientNameOption = args[i+1]
+			return 2
+		}
+	case FlagClientVer:
+		if i+1 < len(args) {
+			ClientVersionOption = args[i+1]
+			return 2
+		}
+	case FlagExperimental:
+		if i+1 < len(args) {
+			ExperimentalOption = args[i+1]
+			return 2
+		}
+	case FlagOutputFile:
+		// Applied before the command runs, see RootCmd
+		if i+1 < len(args) {
+			return 2
+		}
+	case FlagIDPrefix:
+		if i+1 < len(args) {
+			IDPrefix = args[i+1]
+			return 2
+		}
+	case FlagDeviceAuth:
+		DeviceAuth = true
+		return 1
+	case FlagVerifyServer:
+		VerifyServer = true
+		return 1
+	case FlagFsJail:
+		if i+1 < len(args) {
+			FsJail = append(FsJail, args[i+1])
+			return 2
+		}
+	case FlagAuthToken:
+		if i+1 < len(args) {
+			AuthTokenFile = args[i+1]
+			return 2
+		}
+	case FlagCompression:
+		if i+1 < len(args) {
+			CompressionOption = args[i+1]
+			return 2
+		}
+	case FlagRateRetries:
+		if i+1 < len(args) {
+			invalidRateLimitRetries = ""
+			if n, err := strconv.Atoi(args[i+1]); err == nil {
+				RateLimitRetries = n
+			} else {
+				invalidRateLimitRetries = args[i+1]
+			}
+			return 2
+		}
+	case FlagK8sContext:
+		if i+1 < len(args) {
+			K8sContext = args[i+1]
+			return 2
+		}
+	case FlagK8sNamespace:
+		if i+1 < len(args) {
+			K8sNamespace = args[i+1]
+			return 2
+		}
+	case FlagK8sContainer:
+		if i+1 < len(args) {
+			K8sContainer = args[i+1]
+			return 2
+		}
+	case FlagPostProcess:
+		if i+1 < len(args) {
+			PostProcessScript = args[i+1]
+			return 2
+		}
+	case FlagRecord:
+		if i+1 < len(args) {
+			RecordPath = args[i+1]
+			return 2
+		}
+	case FlagPreferIPv4:
+		PreferIPv4 = true
+		return 1
+	case FlagPreferIPv6:
+		PreferIPv6 = true
+		return 1
+	case FlagDiscover:
+		if i+1 < len(args) {
+			DiscoverOption = args[i+1]
+			return 2
+		}
+	case FlagCodec:
+		if i+1 < len(args) {
+			CodecOption = args[i+1]
+			return 2
+		}
+	case FlagTranslate:
+		if i+1 < len(args) {
+			TranslateOption = args[i+1]
+			return 2
+		}
+	case FlagGlossary:
+		if i+1 < len(args) {
+			GlossaryPath = args[i+1]
+			return 2
+		}
+	case FlagOriginal:
+		OriginalOption = true
+		return 1
+	}
+
+	return 0
+}
+
+// rateLimitRetriesError returns a usage error if --rate-limit-retries is not a count of retries.
+func rateLimitRetriesError() error {
+	value := invalidRateLimitRetries
+	if value == "" {
+		if RateLimitRetries >= 0 {
+			return nil
+		}
+		value = strconv.Itoa(RateLimitRetries)
+	}
+	return usageError(fmt.Sprintf("invalid %s: %s", FlagRateRetries, value),
+		"Example: mcp tools --rate-limit-retries 10 https://example.com/mcp")
+}
+
+// addressPreference returns the address family selected with --prefer-ipv4 or --prefer-ipv6.
+func addressPreference() (httpclient.Preference, error) {
+	switch {
+	case PreferIPv4 && PreferIPv6:
+		return httpclient.PreferAny, fmt.Errorf("%s and %s cannot be used together", FlagPreferIPv4, FlagPreferIPv6)
+	case PreferIPv4:
+		return httpclient.PreferIPv4, nil
+	case PreferIPv6:
+		return httpclient.PreferIPv6, nil
+	default:
+		return httpclient.PreferAny, nil
+	}
+}
+
+// quirksFilter applies the fixes of the given quirks to every message received from the server.
+func quirksFilter(quirks []protocol.Quirk) stdio.Filter {
+	return func(dir stdio.Direction, line []byte) ([]byte, error) {
+		if dir == stdio.Outgoing {
+			return line, nil
+		}
+		for _, q := range quirks {
+			if line = q.Fix(line); line == nil {
+				return nil, nil
+			}
+		}
+		return line, nil
+	}
+}
+
+// strictFilter adapts a protocol validator to the raw message filter of the stdio transport.
+func strictFilter(validator *protocol.Validator) stdio.Filter {
+	return func(dir stdio.Direction, line []byte) ([]byte, error) {
+		if dir == stdio.Outgoing {
+			return line, validator.CheckOutgoing(line)
+		}
+		return line, validator.CheckIncoming(line)
+	}
+}
+
+// rawRequestID numbers the requests sent by sendRawRequest. They use string IDs so they never
+// collide with the integer IDs of the mcp-go client.
+var rawRequestID atomic.Int64
+
+// sendRawRequest sends a request directly on the client's transport and returns the raw result.
+// Unlike the typed client methods, fields unknown to mcp-go such as _meta are preserved.
+func sendRawRequest(ctx context.Context, mcpClient *client.Client, method string, params any) (json.RawMessage, error) {
+	request := transport.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(fmt.Sprintf("mcpt-%d", rawRequestID.Add(1))),
+		Method:  method,
+		Params:  params,
+	}
+
+	response, err := mcpClient.GetTransport().SendRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("%s", response.Error.Message)
+	}
+
+	return response.Result, nil
+}
+
+// listToolsRaw lists all tools of the server as generic maps, following pagination cursors.
+func listToolsRaw(ctx context.Context, mcpClient *client.Client) ([]any, error) {
+	var tools []any
+	cursor := ""
+
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		raw, err := sendRawRequest(ctx, mcpClient, string(mcp.MethodToolsList), params)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			NextCursor string `json:"nextCursor"`
+			Tools      []any  `json:"tools"`
+		}
+		if err = json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse tools list: %w", err)
+		}
+
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// warnDeprecatedTools prints a warning for every deprecated tool in tools and, if hide is set,
+// returns tools without them.
+func warnDeprecatedTools(tools []any, hide bool) []any {
+	filtered := make([]any, 0, len(tools))
+	for _, t := range tools {
+		tool, ok := t.(map[string]any)
+		if !ok {
+			filtered = append(filtered, t)
+			continue
+		}
+
+		message, deprecated := protocol.Deprecation(tool)
+		if !deprecated {
+			filtered = append(filtered, t)
+			continue
+		}
+
+		if !hide {
+			printDeprecationWarning(tool["name"], message)
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// warnIfResultDeprecated prints a warning if the server flagged a tool call result as coming
+// from a deprecated tool in its _meta field. Checking the listing instead would cost an extra
+// request on every call.
+func warnIfResultDeprecated(name string, result *mcp.CallToolResult) {
+	if result == nil {
+		return
+	}
+	if message, deprecated := protocol.Deprecation(map[string]any{"_meta": result.Meta}); deprecated {
+		printDeprecationWarning(name, message)
+	}
+}
+
+// printDeprecationWarning prints a warning that a tool is deprecated to stderr.
+func printDeprecationWarning(name any, message string) {
+	if message != "" {
+		fmt.Fprintf(os.Stderr, "Warning: tool %v is deprecated: %s\n", name, message)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: tool %v is deprecated\n", name)
+	}
+}
+
 // FormatAndPrintResponse formats and prints an MCP response in the format specified by
-// FormatOption.
+// FormatOption, after passing it through the --post-process script if one is set.
 func FormatAndPrintResponse(cmd *cobra.Command, resp any, err error) error {
+	return formatAndPrintOutput(cmd, strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "), resp, err)
+}
+
+// formatAndPrintOutput is FormatAndPrintResponse for the output of command, e.g. "stats tools".
+// JSON output of commands with an output contract is wrapped and checked by contract.Wrap.
+func formatAndPrintOutput(cmd *cobra.Command, command string, resp any, err error) error {
 	if err != nil {
-		return fmt.Errorf("error: %w", err)
+		return err
+	}
+
+	if PostProcessScript != "" {
+		resp, err = script.PostProcess(context.Background(), PostProcessScript, resp, map[string]any{
+			"command": cmd.Name(),
+			"format":  FormatOption,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Scripts returning text decide the output entirely
+		if text, ok := resp.(string); ok {
+			fmt.Fprintln(cmd.OutOrStdout(), text)
+			return nil
+		}
+	}
+
+	if _, ok := contract.Kind(command); ok && PostProcessScript == "" &&
+		jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+		if resp, err = contract.Wrap(command, resp); err != nil {
+			return err
+		}
 	}
 
 	output, err := jsonutils.Format(resp, FormatOption)
@@ -275,3 +1078,13 @@ func ConvertJSONToMap(jsonData any) map[string]any {
 	_ = json.Unmarshal(data, &promptMap)
 	return promptMap
 }
+
+// serverName returns the name a server is known by: its alias, or else its command line.
+func serverName(args []string) string {
+	if len(args) == 1 {
+		if _, found := alias.GetServerCommand(args[0]); found {
+			return args[0]
+		}
+	}
+	return strings.Join(args, " ")
+}
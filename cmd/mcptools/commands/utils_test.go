@@ -3,10 +3,13 @@ package commands
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/f/mcptools/pkg/stats"
 	"github.com/spf13/cobra"
 )
 
@@ -190,3 +193,215 @@ nested  {"key":"value"}`[1:] // remove first newline
 		})
 	}
 }
+
+func TestFormatAndPrintResponsePostProcess(t *testing.T) {
+	originalFormat, originalScript := FormatOption, PostProcessScript
+	defer func() { FormatOption, PostProcessScript = originalFormat, originalScript }()
+
+	dir := t.TempDir()
+	writeScript := func(name, source string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	resp := map[string]any{"content": []any{map[string]any{"type": "text", "text": `{"temp_c": 20}`}}}
+
+	tests := []struct {
+		name     string
+		script   string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name: "reshape result",
+			script: writeScript("reshape.lua", `function process(result, info)
+  local data = json.decode(result.content[1].text)
+  return {temp_f = data.temp_c * 9 / 5 + 32, command = info.command}
+end`),
+			expected: `{"command":"call","temp_f":68}`,
+		},
+		{
+			name:     "text result printed as is",
+			script:   writeScript("text.lua", `function process(result) return "it is warm" end`),
+			expected: "it is warm",
+		},
+		{
+			name:    "missing process function",
+			script:  writeScript("empty.lua", `x = 1`),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{Use: "call"}
+			cmd.SetOut(buf)
+
+			FormatOption = "json"
+			PostProcessScript = tt.script
+
+			err := FormatAndPrintResponse(cmd, resp, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatAndPrintResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				assertEquals(t, strings.TrimSpace(buf.String()), tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatAndPrintResponseContract(t *testing.T) {
+	originalFormat := FormatOption
+	defer func() { FormatOption = originalFormat }()
+
+	// Subcommands are named by their path, so stats tools is not taken for tools
+	root := &cobra.Command{Use: "mcp"}
+	stats := &cobra.Command{Use: "stats"}
+	tools := &cobra.Command{Use: "tools"}
+	root.AddCommand(stats)
+	stats.AddCommand(tools)
+
+	buf := new(bytes.Buffer)
+	tools.SetOut(buf)
+	FormatOption = "json"
+
+	usage := map[string]any{"usage": []any{map[string]any{
+		"server": "fs", "tool": "read_file", "calls": 2, "errors": 1, "errorRate": 0.5, "avgLatencyMs": 3,
+	}}}
+	if err := FormatAndPrintResponse(tools, usage, nil); err != nil {
+		t.Fatalf("FormatAndPrintResponse() error = %v", err)
+	}
+	assertContains(t, buf.String(), `"apiVersion":"mcptools/v1"`)
+	assertContains(t, buf.String(), `"kind":"UsageSummary"`)
+
+	if err := FormatAndPrintResponse(tools, map[string]any{"usage": "broken"}, nil); err == nil {
+		t.Error("expected output breaking the contract to be rejected")
+	}
+}
+
+func TestBuildInitializeRequest(t *testing.T) {
+	originalClientInfo := ClientInfoOption
+	defer func() { ClientInfoOption = originalClientInfo }()
+
+	ClientInfoOption = ""
+	req, err := buildInitializeRequest()
+	if err != nil {
+		t.Fatalf("buildInitializeRequest() error = %v", err)
+	}
+	assertEquals(t, req.Params.ClientInfo.Name, "mcptools")
+
+	ClientInfoOption = "name=probe,version=2.0,protocol=2025-03-26"
+	req, err = buildInitializeRequest()
+	if err != nil {
+		t.Fatalf("buildInitializeRequest() error = %v", err)
+	}
+	assertEquals(t, req.Params.ClientInfo.Name, "probe")
+	assertEquals(t, req.Params.ClientInfo.Version, "2.0")
+	assertEquals(t, req.Params.ProtocolVersion, "2025-03-26")
+
+	ClientInfoOption = "colour=blue"
+	if _, err = buildInitializeRequest(); err == nil {
+		t.Error("expected an error for an unknown client info key")
+	}
+}
+
+func TestBuildInitializeRequestClientIdentity(t *testing.T) {
+	originalInfo, originalName, originalVersion := ClientInfoOption, ClientNameOption, ClientVersionOption
+	defer func() {
+		ClientInfoOption, ClientNameOption, ClientVersionOption = originalInfo, originalName, originalVersion
+	}()
+
+	t.Setenv("MCPT_CLIENT_NAME", "env-agent")
+	t.Setenv("MCPT_CLIENT_VERSION", "0.9")
+	ClientInfoOption, ClientNameOption, ClientVersionOption = "", "", ""
+	req, err := buildInitializeRequest()
+	if err != nil {
+		t.Fatalf("buildInitializeRequest() error = %v", err)
+	}
+	assertEquals(t, req.Params.ClientInfo.Name, "env-agent")
+	assertEquals(t, req.Params.ClientInfo.Version, "0.9")
+
+	// --client-info overrides the environment, and --client-name and --client-version override both
+	ClientInfoOption, ClientNameOption = "name=info-agent,version=2.0", "cursor"
+	if req, err = buildInitializeRequest(); err != nil {
+		t.Fatalf("buildInitializeRequest() error = %v", err)
+	}
+	assertEquals(t, req.Params.ClientInfo.Name, "cursor")
+	assertEquals(t, req.Params.ClientInfo.Version, "2.0")
+
+	parsed := ProcessFlags([]string{"--client-version", "1.2.3", "server"})
+	if len(parsed) != 1 || ClientVersionOption != "1.2.3" {
+		t.Errorf("ProcessFlags() = %v with client version %q, want the flag consumed", parsed, ClientVersionOption)
+	}
+}
+
+func TestBuildInitializeRequestExperimental(t *testing.T) {
+	originalExperimental, originalLazy := ExperimentalOption, LazySchemas
+	defer func() { ExperimentalOption, LazySchemas = originalExperimental, originalLazy }()
+
+	ExperimentalOption, LazySchemas = `{"myFeature":{"enabled":true}}`, true
+	req, err := buildInitializeRequest()
+	if err != nil {
+		t.Fatalf("buildInitializeRequest() error = %v", err)
+	}
+	experimental := req.Params.Capabilities.Experimental
+	if feature, ok := experimental["myFeature"].(map[string]any); !ok || feature["enabled"] != true {
+		t.Errorf("experimental[myFeature] = %v, want {enabled: true}", experimental["myFeature"])
+	}
+	if _, ok := experimental[capabilityLazySchemas]; !ok {
+		t.Errorf("experimental = %v, want the lazy schemas capability kept", experimental)
+	}
+
+	for _, value := range []string{`[1]`, `{"myFeature":`} {
+		ExperimentalOption = value
+		if _, err = buildInitializeRequest(); err == nil {
+			t.Errorf("buildInitializeRequest() with --experimental %s: expected an error", value)
+		}
+	}
+}
+
+func TestPrintSessionStats(t *testing.T) {
+	originalShow, originalSession, originalPrinted := ShowStats, SessionStats, sessionStatsPrinted
+	defer func() { ShowStats, SessionStats, sessionStatsPrinted = originalShow, originalSession, originalPrinted }()
+
+	ShowStats, SessionStats, sessionStatsPrinted = true, stats.NewSession(), false
+	var buf bytes.Buffer
+	// Once from exitWithError or main, then from PersistentPostRun
+	PrintSessionStats(&buf)
+	PrintSessionStats(&buf)
+	if got := strings.Count(buf.String(), "Session statistics:"); got != 1 {
+		t.Errorf("PrintSessionStats() printed the statistics %d times, want once:\n%s", got, buf.String())
+	}
+
+	ShowStats, sessionStatsPrinted = false, false
+	buf.Reset()
+	PrintSessionStats(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("PrintSessionStats() without --stats printed %q", buf.String())
+	}
+}
+
+func TestProcessFlagsRateLimitRetries(t *testing.T) {
+	originalRetries, originalInvalid := RateLimitRetries, invalidRateLimitRetries
+	defer func() { RateLimitRetries, invalidRateLimitRetries = originalRetries, originalInvalid }()
+
+	for _, value := range []string{"many", "-1"} {
+		parsed := ProcessFlags([]string{"--rate-limit-retries", value, "https://example.com/mcp"})
+		if len(parsed) != 1 {
+			t.Errorf("ProcessFlags() = %v, want the flag consumed", parsed)
+		}
+		if err := rateLimitRetriesError(); err == nil || !strings.Contains(err.Error(), value) {
+			t.Errorf("rateLimitRetriesError() with %s = %v, want a usage error", value, err)
+		}
+	}
+
+	ProcessFlags([]string{"--rate-limit-retries", "3", "https://example.com/mcp"})
+	if err := rateLimitRetriesError(); err != nil || RateLimitRetries != 3 {
+		t.Errorf("rateLimitRetriesError() = %v with %d retries, want 3", err, RateLimitRetries)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/f/mcptools/pkg/jsonutils"
 	"github.com/spf13/cobra"
 )
 
@@ -25,11 +26,41 @@ var TemplatesPath = getHomeDirectory() + "/.mcpt/templates"
 
 // VersionCmd creates the version command.
 func VersionCmd() *cobra.Command {
-	return &cobra.Command{
+	var features bool
+
+	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Print the version information",
+		Long: `Print the version information. With --features, also describe this build: the optional
+features it includes, and the keychain backends available on this system. Lite builds, made
+with go build -tags lite for constrained environments such as Termux or small containers, leave
+out every optional feature and keep every client command.
+
+With --format json, the description is printed as JSON for scripts and support requests.
+
+Examples:
+  mcp version
+  mcp version --features
+  mcp version --features --format json`,
 		Run: func(cmd *cobra.Command, _ []string) {
-			fmt.Fprintf(cmd.OutOrStdout(), "MCP Tools version %s\n", Version)
+			if !features {
+				fmt.Fprintf(cmd.OutOrStdout(), "MCP Tools version %s\n", Version)
+				return
+			}
+
+			info := Build()
+			if jsonutils.ParseFormat(FormatOption) != jsonutils.FormatTable {
+				output, err := jsonutils.Format(ConvertJSONToMap(info), FormatOption)
+				if err != nil {
+					exitWithError(err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), output)
+				return
+			}
+			printBuild(cmd.OutOrStdout(), info)
 		},
 	}
+
+	cmd.Flags().BoolVar(&features, "features", false, "Describe the optional features and keychain backends of this build")
+	return cmd
 }
@@ -2,6 +2,8 @@ package commands
 
 import (
 	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -50,3 +52,66 @@ func TestVersionCmdWorks(t *testing.T) {
 		t.Error("Expected Run function to be defined")
 	}
 }
+
+func TestVersionCmdFeatures(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	oldFormat := FormatOption
+	FormatOption = "table"
+	defer func() { FormatOption = oldFormat }()
+
+	cmd := VersionCmd()
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--features"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute version command: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "FEATURE") {
+		t.Errorf("Expected a table of features, got %q", output)
+	}
+	for _, feature := range Features() {
+		if !strings.Contains(output, feature.Name+" ") {
+			t.Errorf("Expected feature %q in the output, got %q", feature.Name, output)
+		}
+	}
+	if strings.Contains(output, "(lite)") != Lite() {
+		t.Errorf("Expected (lite) in the output only of lite builds, got %q", output)
+	}
+}
+
+func TestVersionCmdFeaturesJSON(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	oldFormat := FormatOption
+	FormatOption = "json"
+	defer func() { FormatOption = oldFormat }()
+
+	cmd := VersionCmd()
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--features"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Failed to execute version command: %v", err)
+	}
+
+	var info BuildInfo
+	if err := json.Unmarshal(buf.Bytes(), &info); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), err)
+	}
+	if len(info.Features) != len(Features()) || info.OS == "" || info.GoVersion == "" {
+		t.Errorf("Expected the features and platform of the build, got %+v", info)
+	}
+	defaults := 0
+	for _, backend := range info.KeychainBackends {
+		if backend.Name == "file" && !backend.Available {
+			t.Error("Expected the file keychain backend to be available")
+		}
+		if backend.Default {
+			defaults++
+		}
+	}
+	if defaults != 1 {
+		t.Errorf("Expected one default keychain backend, got %+v", info.KeychainBackends)
+	}
+}
@@ -0,0 +1,101 @@
+//go:build !lite && !nowasm
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/f/mcptools/pkg/keychain"
+	"github.com/f/mcptools/pkg/wasm"
+	"github.com/spf13/cobra"
+)
+
+// wasmIncluded reports whether this build includes the WebAssembly runtime of mcp run-wasm.
+const wasmIncluded = true
+
+// RunWasmCmd creates the run-wasm command.
+func RunWasmCmd() *cobra.Command {
+	var (
+		dirs     []string
+		envs     []string
+		memoryMB uint32
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run-wasm [--dir host[:guest][:ro]]... [--env KEY[=VALUE]]... server.wasm [args...]",
+		Short: "Run a WASI-compiled MCP server in a sandbox",
+		Long: `Run an MCP server compiled to WebAssembly (WASI preview 1) in an embedded runtime, speaking
+stdio like any other server. This is a sandboxed way to run untrusted community servers.
+
+The module only gets the capabilities you grant it:
+- no filesystem access, except directories mounted with --dir (read-only with :ro)
+- no environment variables, except those passed with --env
+- no network access, since WASI preview 1 has no sockets
+- at most --memory megabytes of memory
+
+Compiled modules are cached in $HOME/.mcpt/wasm-cache so later runs start quickly.
+
+Examples:
+  mcp run-wasm server.wasm
+  mcp run-wasm --dir ./docs:/docs:ro --env API_KEY server.wasm --verbose
+  mcp tools mcp run-wasm server.wasm
+  mcp alias add sandboxed mcp run-wasm --dir ~/notes:/notes:ro notes.wasm`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			opts := wasm.Options{
+				Stdin:         os.Stdin,
+				Stdout:        os.Stdout,
+				Stderr:        os.Stderr,
+				Args:          args[1:],
+				MemoryLimitMB: memoryMB,
+			}
+
+			for _, dir := range dirs {
+				mount, err := wasm.ParseMount(dir)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				opts.Mounts = append(opts.Mounts, mount)
+			}
+
+			for _, env := range envs {
+				key, value, found := strings.Cut(env, "=")
+				if !found {
+					value = os.Getenv(key)
+				}
+				secret, err := keychain.Resolve(value)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %s: %v\n", key, err)
+					os.Exit(1)
+				}
+				opts.Env = append(opts.Env, key+"="+secret)
+			}
+
+			if cacheDir, err := wasm.GetCachePath(); err == nil {
+				opts.CacheDir = cacheDir
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err := wasm.Run(ctx, args[0], opts); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	// Flags after the module belong to the module
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().StringArrayVar(&dirs, "dir", nil, "Host directory the module may access, as host[:guest][:ro] (repeatable)")
+	cmd.Flags().StringArrayVar(&envs, "env", nil, "Environment variable for the module, as KEY=VALUE, KEY=keychain:<name> or KEY to pass the host value (repeatable)")
+	cmd.Flags().Uint32Var(&memoryMB, "memory", 256, "Memory limit for the module in megabytes")
+
+	return cmd
+}
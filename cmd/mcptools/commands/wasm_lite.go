@@ -0,0 +1,23 @@
+//go:build lite || nowasm
+
+package commands
+
+import "github.com/spf13/cobra"
+
+// wasmIncluded reports whether this build includes the WebAssembly runtime of mcp run-wasm.
+const wasmIncluded = false
+
+// RunWasmCmd creates the run-wasm command, which fails as this build leaves out the WebAssembly
+// runtime.
+func RunWasmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:                "run-wasm",
+		Short:              "Run a WASI-compiled MCP server in a sandbox (not included in this build)",
+		Hidden:             true,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(*cobra.Command, []string) error {
+			return notIncludedError("mcp run-wasm", "wasm")
+		},
+	}
+}
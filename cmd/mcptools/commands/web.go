@@ -1,24 +1,70 @@
+//go:build !lite && !noweb
+
 package commands
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/f/mcptools/pkg/preset"
+	"github.com/f/mcptools/pkg/record"
+	"github.com/f/mcptools/pkg/share"
+	"github.com/f/mcptools/pkg/webauth"
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/spf13/cobra"
 )
 
+// webIncluded reports whether this build includes the web interface of mcp web.
+const webIncluded = true
+
 // WebCmd creates the web command.
 func WebCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:                "web [command args...]",
-		Short:              "Start a web interface for MCP commands",
+	cmd := &cobra.Command{
+		Use:   "web [--port port] [--bind host] [--users file | --oidc-issuer url] [--share] [--share-url url] [command args...]",
+		Short: "Start a web interface for MCP commands",
+		Long: `Start a web interface to browse and call the tools, resources and prompts of a server.
+
+The interface only listens on localhost unless --bind sets another host, such as 0.0.0.0 for
+every interface. Other machines can then use the server through it, so users must sign in:
+
+  --users file         with HTTP basic authentication, against the password hashes in file,
+                       which mcp web user adds users to
+  --oidc-issuer url    with an OpenID Connect provider, as the client --oidc-client-id, with
+                       its secret in MCPT_OIDC_CLIENT_SECRET. The provider sends users back to
+                       /auth/callback on the host they opened, or to --oidc-redirect-url.
+                       --oidc-allow limits who may sign in to a list of email addresses,
+                       subjects and @domains. Users sign out at /auth/logout.
+
+While users sign in, their tool calls, resource reads, prompt gets, share links and sign-ins
+are written to the audit log ($HOME/.mcpt/logs/web-audit.log, or --audit-log) with their user
+name and address. The log is chained like that of mcp bridge, signed with the key in
+--audit-key if set, and checked with mcp audit verify.
+
+With --share, the interface can create read-only share links to the message timeline of its
+session, live, or of a session recorded with --record, to debug a server together with a
+colleague. Each link has its own random token and can be revoked. Values that look like secrets
+are tokenized as in recordings. Sharing listens on every interface unless --bind is set, and
+unless users sign in, the interface itself then only answers this machine. Share links use its
+first private address unless --share-url sets another base URL, such as that of a tunnel.
+
+Examples:
+  mcp web npx -y @modelcontextprotocol/server-filesystem ~
+  mcp web user alice
+  mcp web --bind 0.0.0.0 --users ~/.mcpt/web-users.json docs
+  MCPT_OIDC_CLIENT_SECRET=... mcp web --bind 0.0.0.0 --oidc-issuer https://id.example.com \
+      --oidc-client-id mcp-inspector --oidc-allow @example.com docs
+  mcp web --share docs
+  mcp web --share-url https://debug.example.com docs`,
 		DisableFlagParsing: true,
 		SilenceUsage:       true,
 		Run: func(thisCmd *cobra.Command, args []string) {
@@ -30,34 +76,110 @@ func WebCmd() *cobra.Command {
 			cmdArgs := args
 			parsedArgs := []string{}
 			port := "41999" // Default port
+			bind := ""
+			bindSet := false
+			sharing := false
+			shareURL := ""
+			var authOpts webAuthOptions
 
 			for i := 0; i < len(cmdArgs); i++ {
+				if n := processClientFlag(cmdArgs, i); n > 0 {
+					i += n - 1
+					continue
+				}
+
 				switch {
 				case (cmdArgs[i] == "--port" || cmdArgs[i] == "-p") && i+1 < len(cmdArgs):
 					port = cmdArgs[i+1]
 					i++
+				case cmdArgs[i] == "--bind" && i+1 < len(cmdArgs):
+					bind, bindSet = cmdArgs[i+1], true
+					i++
+				case cmdArgs[i] == "--users" && i+1 < len(cmdArgs):
+					authOpts.usersPath = cmdArgs[i+1]
+					i++
+				case cmdArgs[i] == "--oidc-issuer" && i+1 < len(cmdArgs):
+					authOpts.oidcIssuer = cmdArgs[i+1]
+					i++
+				case cmdArgs[i] == "--oidc-client-id" && i+1 < len(cmdArgs):
+					authOpts.oidcClientID = cmdArgs[i+1]
+					i++
+				case cmdArgs[i] == "--oidc-redirect-url" && i+1 < len(cmdArgs):
+					authOpts.oidcRedirect = cmdArgs[i+1]
+					i++
+				case cmdArgs[i] == "--oidc-allow" && i+1 < len(cmdArgs):
+					authOpts.oidcAllow = append(authOpts.oidcAllow, splitPatterns(cmdArgs[i+1])...)
+					i++
+				case cmdArgs[i] == "--audit-log" && i+1 < len(cmdArgs):
+					authOpts.auditPath = cmdArgs[i+1]
+					i++
+				case cmdArgs[i] == "--audit-key" && i+1 < len(cmdArgs):
+					authOpts.auditKey = cmdArgs[i+1]
+					i++
 				case cmdArgs[i] == FlagServerLogs:
 					ShowServerLogs = true
+				case cmdArgs[i] == "--share":
+					sharing = true
+				case cmdArgs[i] == "--share-url" && i+1 < len(cmdArgs):
+					sharing = true
+					shareURL = strings.TrimSuffix(cmdArgs[i+1], "/")
+					i++
 				default:
 					parsedArgs = append(parsedArgs, cmdArgs[i])
 				}
 			}
 
-			if len(parsedArgs) == 0 {
+			if len(parsedArgs) == 0 && DiscoverOption == "" {
 				fmt.Fprintln(os.Stderr, "Error: command to execute is required when using the web interface")
 				fmt.Fprintln(os.Stderr, "Example: mcp web npx -y @modelcontextprotocol/server-filesystem ~")
 				os.Exit(1)
 			}
 
+			// Share links must be reachable from other machines
+			if !bindSet && !sharing {
+				bind = "localhost"
+			}
+			exposed := !isLoopbackHost(bind)
+			if exposed && !sharing && !authOpts.enabled() {
+				exitWithError(withHint(
+					fmt.Errorf("--bind %s lets other machines call the server's tools, so users must sign in", bind),
+					"use --users file (see mcp web user --help) or --oidc-issuer url"))
+			}
+
+			auditFile, auditPath, err := authOpts.openAudit()
+			if err != nil {
+				exitWithError(err)
+			}
+			var auditLog *webauth.AuditLog
+			if auditFile != nil {
+				defer func() { _ = auditFile.Close() }()
+				auditLog = webauth.NewAuditLog(auditFile)
+			}
+			authenticator, err := authOpts.authenticator(thisCmd.Context(), auditLog)
+			if err != nil {
+				exitWithError(err)
+			}
+
+			if sharing {
+				SessionTimeline = &record.Timeline{}
+			}
 			mcpClient, clientErr := CreateClientFunc(parsedArgs)
 			if clientErr != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", clientErr)
 				os.Exit(1)
 			}
 
+			// Show where other machines reach the interface, when they may
+			displayHost := "localhost"
+			if exposed && authOpts.enabled() {
+				displayHost = bind
+				if ip := net.ParseIP(bind); bind == "" || ip != nil && ip.IsUnspecified() {
+					displayHost = shareHost()
+				}
+			}
 			fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Starting MCP Tools Web Interface (%s)\n", Version)
 			fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Connected to Server: %s\n", strings.Join(parsedArgs, " "))
-			fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Web server running at http://localhost:%s\n", port)
+			fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Web server running at http://%s\n", net.JoinHostPort(displayHost, port))
 
 			// Web server handler
 			mux := http.NewServeMux()
@@ -73,17 +195,52 @@ func WebCmd() *cobra.Command {
 			mux.HandleFunc("/api/tools", handleTools(clientCache))
 			mux.HandleFunc("/api/resources", handleResources(clientCache))
 			mux.HandleFunc("/api/prompts", handlePrompts(clientCache))
-			mux.HandleFunc("/api/call", handleCall(clientCache))
+			mux.HandleFunc("/api/call", handleCall(clientCache, auditLog))
+			mux.HandleFunc("/api/presets", handlePresets(serverName(parsedArgs)))
+
+			// The interface answers signed in users, or only this machine while sharing
+			var inspector http.Handler = mux
+			switch {
+			case authenticator != nil:
+				_, oidc := authenticator.(*webauth.OIDC)
+				mux.HandleFunc("/api/user", handleUser(oidc))
+				inspector = authenticator.Wrap(mux)
+				fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Users sign in; their calls are audited in %s\n", auditPath)
+			case exposed:
+				inspector = localOnly(mux)
+			}
+
+			handler := inspector
+			if sharing {
+				if shareURL == "" {
+					shareURL = "http://" + net.JoinHostPort(shareHost(), port)
+				}
+				shares := share.NewServer()
+				mux.HandleFunc("/api/shares", handleShares(shares, SessionTimeline, shareURL, serverName(parsedArgs), auditLog))
+				root := http.NewServeMux()
+				root.Handle(share.PathPrefix, shares)
+				root.Handle("/", inspector)
+				handler = root
+				if authenticator != nil {
+					fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Share links are served at %s%s…\n", shareURL, share.PathPrefix)
+				} else {
+					fmt.Fprintf(thisCmd.OutOrStdout(), "mcp > Share links are served at %s%s…; the interface only answers this machine\n",
+						shareURL, share.PathPrefix)
+				}
+			}
 
 			// Start the server
 			//nolint:gosec // Timeouts not implemented for this development/internal tool
-			err := http.ListenAndServe(":"+port, mux)
+			err = http.ListenAndServe(net.JoinHostPort(bind, port), handler)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error starting web server: %v\n", err)
 				os.Exit(1)
 			}
 		},
 	}
+
+	cmd.AddCommand(webUserCmd())
+	return cmd
 }
 
 // MCPClientCache provides thread-safe access to the MCP client.
@@ -122,6 +279,10 @@ func handleIndex() http.HandlerFunc {
 <body class="h-screen flex bg-gray-50 text-gray-900 antialiased">
     <div id="sidebar" class="w-64 bg-white border-r border-gray-200 p-4 overflow-y-auto">
         <h1 class="text-xl font-semibold text-gray-800">MCP Tools</h1>
+        <p id="signed-in" class="hidden mt-1 text-xs text-gray-500">
+            Signed in as <span id="signed-in-user" class="font-medium text-gray-700"></span>
+            <a id="sign-out" href="/auth/logout" class="hidden ml-1 text-blue-600 hover:underline">Sign out</a>
+        </p>
 
         <h2 class="mt-6 mb-2 text-sm font-medium text-gray-600 uppercase tracking-wider">Tools</h2>
         <ul id="tools-list" class="space-y-1"></ul>
@@ -131,6 +292,15 @@ func handleIndex() http.HandlerFunc {
 
         <h2 class="mt-6 mb-2 text-sm font-medium text-gray-600 uppercase tracking-wider">Prompts</h2>
         <ul id="prompts-list" class="space-y-1"></ul>
+
+        <div id="share-panel" class="hidden">
+            <h2 class="mt-6 mb-2 text-sm font-medium text-gray-600 uppercase tracking-wider">Share</h2>
+            <button id="share-live-btn" type="button" class="w-full mb-2 px-3 py-2 text-sm text-white bg-blue-600 rounded-md hover:bg-blue-700">Share live session</button>
+            <label class="block mb-2 text-xs text-gray-500">Share a recording
+                <input id="share-recording-input" type="file" accept=".jsonl,.json" class="block mt-1 w-full text-xs">
+            </label>
+            <ul id="shares-list" class="space-y-2 text-sm"></ul>
+        </div>
     </div>
 
     <div id="main" class="flex-1 p-6 overflow-y-auto">
@@ -140,6 +310,15 @@ func handleIndex() http.HandlerFunc {
         <div id="tool-panel" class="hidden bg-white border border-gray-200 rounded-lg shadow-sm p-6 mb-6">
             <h2 class="text-lg font-medium text-gray-700 mb-4">Parameters:</h2>
 
+            <div id="preset-bar" class="hidden mb-4">
+                <div class="flex items-center gap-2">
+                    <select id="preset-select" class="px-3 py-2 border border-gray-300 rounded-md text-sm"><option value="">Presets…</option></select>
+                    <button id="preset-save-btn" type="button" class="px-3 py-2 text-sm border border-gray-300 rounded-md hover:bg-gray-100">Save as preset</button>
+                    <button id="preset-delete-btn" type="button" class="px-3 py-2 text-sm border border-gray-300 rounded-md hover:bg-gray-100">Delete</button>
+                    <button id="preset-link-btn" type="button" class="px-3 py-2 text-sm border border-gray-300 rounded-md hover:bg-gray-100">Copy link</button>
+                </div>
+            </div>
+
             <div class="tab-container flex border-b border-gray-200 mb-4">
                 <div class="tab active px-4 py-2 border-t border-l border-r border-gray-200 rounded-t-md bg-white text-blue-600 font-medium" id="form-tab">Form</div>
                 <div class="tab px-4 py-2 border-t border-l border-r border-gray-200 rounded-t-md bg-gray-50 text-gray-500" id="json-tab">JSON</div>
@@ -151,6 +330,8 @@ func handleIndex() http.HandlerFunc {
                 <textarea id="params-area" class="w-full min-h-[100px] p-3 border border-gray-300 rounded-md font-mono">{}</textarea>
             </div>
 
+            <p id="validation-summary" class="hidden mb-3 text-sm text-red-600"></p>
+
             <button id="execute-btn" class="px-4 py-2 bg-blue-600 text-white font-medium rounded-md hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:ring-opacity-50">Execute</button>
         </div>
 
@@ -184,6 +365,8 @@ func handleIndex() http.HandlerFunc {
                 // Ensure formatted tab is visible by default
                 document.getElementById('formatted-output-container').classList.remove('hidden');
                 document.getElementById('raw-output-container').classList.add('hidden');
+
+                openSharedLink((data.result && data.result.tools) || []);
             })
             .catch(err => console.error('Error fetching tools:', err));
 
@@ -214,7 +397,7 @@ func handleIndex() http.HandlerFunc {
                         const li = document.createElement('li');
                         li.className = 'py-2 px-3 cursor-pointer text-orange-600 hover:bg-orange-50 rounded-md transition-colors duration-150';
                         li.textContent = prompt.name;
-                        li.onclick = () => callPrompt(prompt.name);
+                        li.onclick = () => showPrompt(prompt);
                         promptsList.appendChild(li);
                     });
                 }
@@ -304,8 +487,49 @@ func handleIndex() http.HandlerFunc {
         // Current tool being edited
         let currentTool = null;
 
+        // Show a prompt, with a form for its arguments if it declares any
+        function showPrompt(prompt) {
+            if (!prompt.arguments || prompt.arguments.length === 0) {
+                currentTool = null;
+                callPrompt(prompt.name, {});
+                return;
+            }
+
+            // Describe the arguments, which are all strings, as a schema to reuse the tool form
+            const properties = {};
+            const required = [];
+            prompt.arguments.forEach(arg => {
+                properties[arg.name] = { type: 'string', description: arg.description || '' };
+                if (arg.required) {
+                    required.push(arg.name);
+                }
+            });
+            const form = {
+                name: prompt.name,
+                description: prompt.description,
+                inputSchema: { type: 'object', properties: properties, required: required }
+            };
+
+            showTool(form, (name, params) => {
+                // Leave out optional arguments that were not filled in
+                const args = {};
+                Object.keys(params).forEach(key => {
+                    if (params[key] !== '' && params[key] !== undefined && params[key] !== null) {
+                        args[key] = String(params[key]);
+                    }
+                });
+                const missing = required.filter(key => !(key in args));
+                if (missing.length > 0) {
+                    alert('Missing required arguments: ' + missing.join(', '));
+                    return;
+                }
+                callPrompt(name, args);
+            });
+            document.getElementById('main-title').textContent = 'Prompt: ' + prompt.name;
+        }
+
         // Show tool details
-        function showTool(tool) {
+        function showTool(tool, execute = callTool) {
             currentTool = tool;
             document.getElementById('main-title').textContent = tool.name;
 
@@ -320,21 +544,21 @@ func handleIndex() http.HandlerFunc {
 
             document.getElementById('tool-panel').classList.remove('hidden');
 
-            // Create form based on schema
+            // Create form based on schema, filled in with the defaults of the parameters
             createFormFromSchema(tool);
-
-            // Set default JSON parameters
-            let defaultParams = {};
-            if (tool.parameters && tool.parameters.properties) {
-                Object.keys(tool.parameters.properties).forEach(key => {
-                    defaultParams[key] = "";
-                });
-            } else if (tool.inputSchema && tool.inputSchema.properties) {
-                Object.keys(tool.inputSchema.properties).forEach(key => {
-                    defaultParams[key] = "";
-                });
+            touchedFields.clear();
+            showValidation({});
+            document.getElementById('params-area').value = JSON.stringify(collectFormValues(tool), null, 2);
+
+            // Presets are saved for tools, not prompts
+            currentPresets = {};
+            document.getElementById('preset-select').innerHTML = '<option value="">Presets…</option>';
+            if (execute === callTool) {
+                document.getElementById('preset-bar').classList.remove('hidden');
+                loadPresets(tool.name);
+            } else {
+                document.getElementById('preset-bar').classList.add('hidden');
             }
-            document.getElementById('params-area').value = JSON.stringify(defaultParams, null, 2);
 
             // Display initial information about the tool
             displayFormattedOutput({ tool: tool });
@@ -343,6 +567,7 @@ func handleIndex() http.HandlerFunc {
             // Set up execute button
             document.getElementById('execute-btn').onclick = () => {
                 let params = {};
+                let errors = {};
 
                 // Check if we're using the form or JSON editor
                 if (document.getElementById('form-container').classList.contains('hidden')) {
@@ -355,11 +580,20 @@ func handleIndex() http.HandlerFunc {
                     }
                 } else {
                     // Using form - collect values and update JSON view
-                    params = collectFormValues(tool);
+                    const form = readForm(tool);
+                    params = form.params;
+                    errors = form.errors;
                     document.getElementById('params-area').value = JSON.stringify(params, null, 2);
                 }
 
-                callTool(tool.name, params);
+                // Only call with arguments the schema accepts
+                errors = validateParams(tool, params, errors);
+                showValidation(errors);
+                if (Object.keys(errors).length > 0) {
+                    return;
+                }
+
+                execute(tool.name, params);
             };
         }
 
@@ -383,74 +617,231 @@ func handleIndex() http.HandlerFunc {
             }
         }
 
+        // Schema of the arguments of a tool, or null if it takes none
+        function toolSchema(tool) {
+            if (tool.parameters && tool.parameters.properties) {
+                return tool.parameters;
+            } else if (tool.inputSchema && tool.inputSchema.properties) {
+                return tool.inputSchema;
+            }
+            return null;
+        }
+
+        // Whether a parameter is an array of objects, edited as a list of item forms
+        function isObjectArray(prop) {
+            return prop.type === 'array' && prop.items && prop.items.type === 'object' && prop.items.properties;
+        }
+
+        // Whether a parameter takes the content of a file, as its schema declares
+        function isContentParam(prop) {
+            return prop.type === 'string' && (prop.contentEncoding === 'base64' || !!prop.contentMediaType ||
+                prop.format === 'binary' || prop.format === 'byte' || prop.format === 'data-url');
+        }
+
+        const inputClass = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500';
+
+        // Create the input of a parameter from its schema: a dropdown for enums and booleans, a
+        // multiple choice for arrays of enums, a number input for numbers, and a text input typed
+        // by the format of strings. Choices hold their values as JSON, to keep their types.
+        function createInput(prop, id, required, onChange) {
+            let input;
+            const choices = prop.enum || (prop.type === 'boolean' ? [true, false] : null);
+            if (choices) {
+                input = document.createElement('select');
+                if (!required || prop.default === undefined) {
+                    const empty = document.createElement('option');
+                    empty.value = '';
+                    empty.textContent = required ? 'Select…' : '(not set)';
+                    input.appendChild(empty);
+                }
+                choices.forEach(choice => input.appendChild(createOption(choice)));
+            } else if (prop.type === 'array' && prop.items && prop.items.enum) {
+                input = document.createElement('select');
+                input.multiple = true;
+                input.size = Math.min(prop.items.enum.length, 6);
+                prop.items.enum.forEach(choice => input.appendChild(createOption(choice)));
+            } else if (prop.type === 'number' || prop.type === 'integer') {
+                input = document.createElement('input');
+                input.type = 'number';
+                input.step = prop.type === 'integer' ? '1' : 'any';
+                if (prop.minimum !== undefined) input.min = prop.minimum;
+                if (prop.maximum !== undefined) input.max = prop.maximum;
+            } else if (prop.type === 'object' || prop.type === 'array' || isContentParam(prop)) {
+                input = document.createElement('textarea');
+                input.rows = 4;
+                input.placeholder = prop.type === 'object' ? 'Enter JSON object' :
+                    prop.type === 'array' ? 'Enter one item per line' : 'Choose a file or enter its content';
+            } else {
+                input = document.createElement('input');
+                input.type = { password: 'password', email: 'email', uri: 'url', date: 'date' }[prop.format] || 'text';
+                if (prop.maxLength !== undefined) input.maxLength = prop.maxLength;
+            }
+
+            input.id = id;
+            input.className = inputClass + (input.tagName === 'TEXTAREA' ? ' font-mono' : '');
+            if (Array.isArray(prop.examples) && prop.examples.length > 0 && input.tagName !== 'SELECT') {
+                input.placeholder = 'e.g. ' + (typeof prop.examples[0] === 'string' ? prop.examples[0] : JSON.stringify(prop.examples[0]));
+            }
+            if (prop.default !== undefined) {
+                setInputValue(input, prop, prop.default);
+            }
+            input.addEventListener('input', onChange);
+            if (input.tagName === 'SELECT') {
+                input.addEventListener('change', onChange);
+            }
+            return input;
+        }
+
+        function createOption(choice) {
+            const option = document.createElement('option');
+            option.value = JSON.stringify(choice);
+            option.textContent = typeof choice === 'string' ? choice : JSON.stringify(choice);
+            return option;
+        }
+
+        // Add a file picker filling the input of a content parameter with the content of a file:
+        // base64 encoded, as a data URL or as text, as the schema of the parameter asks.
+        function addFilePicker(formGroup, input, prop, onChange) {
+            const picker = document.createElement('input');
+            picker.type = 'file';
+            picker.className = 'block mt-2 text-sm text-gray-600';
+            if (prop.contentMediaType) picker.accept = prop.contentMediaType;
+            const base64 = prop.contentEncoding === 'base64' || prop.format === 'byte' || prop.format === 'binary';
+
+            picker.addEventListener('change', () => {
+                const file = picker.files[0];
+                if (!file) return;
+                const reader = new FileReader();
+                reader.onload = () => {
+                    let content = reader.result;
+                    if (base64) {
+                        content = content.substring(content.indexOf(',') + 1);
+                    }
+                    input.value = content;
+                    onChange();
+                };
+                reader.onerror = () => alert('Error reading ' + file.name + ': ' + reader.error);
+                if (base64 || prop.format === 'data-url') {
+                    reader.readAsDataURL(file);
+                } else {
+                    reader.readAsText(file);
+                }
+            });
+            formGroup.appendChild(picker);
+        }
+
+        // Set the value of the input of a parameter
+        function setInputValue(input, prop, value) {
+            if (input.tagName === 'SELECT' && input.multiple) {
+                const selected = (Array.isArray(value) ? value : []).map(v => JSON.stringify(v));
+                Array.from(input.options).forEach(option => {
+                    option.selected = selected.includes(option.value);
+                });
+            } else if (input.tagName === 'SELECT') {
+                input.value = value === undefined || value === null ? '' : JSON.stringify(value);
+            } else if (prop.type === 'array' && Array.isArray(value)) {
+                input.value = value.map(v => typeof v === 'string' ? v : JSON.stringify(v)).join('\n');
+            } else if (typeof value === 'object' && value !== null) {
+                input.value = JSON.stringify(value, null, 2);
+            } else {
+                input.value = value;
+            }
+        }
+
+        // Read the value of the input of a parameter, undefined if it is not filled in, with an
+        // error if it cannot be read as the type of the parameter
+        function readInputValue(input, prop) {
+            if (input.tagName === 'SELECT' && input.multiple) {
+                const values = Array.from(input.selectedOptions).map(option => JSON.parse(option.value));
+                return { value: values.length > 0 ? values : undefined };
+            }
+            if (input.tagName === 'SELECT') {
+                return { value: input.value === '' ? undefined : JSON.parse(input.value) };
+            }
+
+            const text = input.value;
+            if (text.trim() === '') {
+                return { value: undefined };
+            }
+            switch (prop.type) {
+                case 'number':
+                case 'integer': {
+                    const value = Number(text);
+                    return isNaN(value) ? { error: 'Enter a number' } : { value: value };
+                }
+                case 'object':
+                    try {
+                        return { value: JSON.parse(text) };
+                    } catch (e) {
+                        return { error: 'Invalid JSON: ' + e.message };
+                    }
+                case 'array': {
+                    const items = text.split('\n').map(item => item.trim()).filter(item => item !== '');
+                    const itemType = prop.items && prop.items.type;
+                    if (itemType === 'number' || itemType === 'integer') {
+                        const numbers = items.map(Number);
+                        return numbers.some(isNaN) ? { error: 'Enter one number per line' } : { value: numbers };
+                    }
+                    return { value: items };
+                }
+                default:
+                    return { value: text };
+            }
+        }
+
+        // Parameters the user changed since the form was created, whose errors are shown as they type
+        const touchedFields = new Set();
+
+        // Update the JSON editor and the errors shown after a parameter changed
+        function onFieldChange(propName) {
+            touchedFields.add(propName);
+            if (!currentTool) return;
+
+            const form = readForm(currentTool);
+            document.getElementById('params-area').value = JSON.stringify(form.params, null, 2);
+            const errors = validateParams(currentTool, form.params, form.errors);
+            const shown = {};
+            Object.keys(errors).forEach(name => {
+                if (touchedFields.has(name)) shown[name] = errors[name];
+            });
+            showValidation(shown, false);
+        }
+
         // Populate form fields from JSON data
         function populateFormFromJSON(jsonData) {
             if (!currentTool || !jsonData) return;
 
-            // Get schema
-            let schema = null;
-            if (currentTool.parameters && currentTool.parameters.properties) {
-                schema = currentTool.parameters;
-            } else if (currentTool.inputSchema && currentTool.inputSchema.properties) {
-                schema = currentTool.inputSchema;
-            }
-
+            const schema = toolSchema(currentTool);
             if (!schema) return;
 
             const properties = schema.properties;
-
             for (const propName in properties) {
                 const prop = properties[propName];
                 const value = jsonData[propName];
 
                 if (value === undefined) continue;
 
-                // Handle array of objects separately
-                if (prop.type === 'array' && prop.items && prop.items.type === 'object' && prop.items.properties) {
+                if (isObjectArray(prop)) {
                     const arrayContainer = document.getElementById('array-container-' + propName);
                     if (!arrayContainer) continue;
 
-                    // Clear existing items
+                    // Replace the items with those of the JSON data
                     arrayContainer.innerHTML = '';
-
-                    // Add new items based on the JSON data
                     if (Array.isArray(value)) {
                         value.forEach((itemData, index) => {
-                            // Add new item to the DOM
                             addArrayItem(propName, prop.items);
-
-                            // Set values for each field
                             for (const fieldName in prop.items.properties) {
-                                if (itemData[fieldName] !== undefined) {
-                                    const input = document.getElementById('param-' + propName + '-' + index + '-' + fieldName);
-                                    if (input) {
-                                        if (typeof itemData[fieldName] === 'boolean') {
-                                            input.value = itemData[fieldName].toString();
-                                        } else {
-                                            input.value = itemData[fieldName];
-                                        }
-                                    }
+                                const input = document.getElementById('param-' + propName + '-' + index + '-' + fieldName);
+                                if (input && itemData && itemData[fieldName] !== undefined) {
+                                    setInputValue(input, prop.items.properties[fieldName], itemData[fieldName]);
                                 }
                             }
                         });
                     }
                 } else {
-                    // Handle regular inputs
                     const input = document.getElementById('param-' + propName);
-                    if (!input) continue;
-
-                    if (prop.type === 'array' && Array.isArray(value)) {
-                        // For textarea arrays, join with newlines
-                        input.value = value.join('\n');
-                    } else if (prop.type === 'object' && typeof value === 'object') {
-                        // For object inputs, stringify the JSON
-                        input.value = JSON.stringify(value, null, 2);
-                    } else if (typeof value === 'boolean') {
-                        // For boolean selects, convert to string
-                        input.value = value.toString();
-                    } else {
-                        // For all other types
-                        input.value = value;
+                    if (input) {
+                        setInputValue(input, prop, value);
                     }
                 }
             }
@@ -461,14 +852,7 @@ func handleIndex() http.HandlerFunc {
             const formContainer = document.getElementById('form-container');
             formContainer.innerHTML = '';
 
-            // Check for schema in either parameters or inputSchema
-            let schema = null;
-            if (tool.parameters && tool.parameters.properties) {
-                schema = tool.parameters;
-            } else if (tool.inputSchema && tool.inputSchema.properties) {
-                schema = tool.inputSchema;
-            }
-
+            const schema = toolSchema(tool);
             if (!schema) {
                 formContainer.innerHTML = '<p class="text-gray-500 italic">No parameters required for this tool.</p>';
                 return;
@@ -504,125 +888,43 @@ func handleIndex() http.HandlerFunc {
                     description.textContent = prop.description;
                     formGroup.appendChild(description);
                 }
+                formContainer.appendChild(formGroup);
 
-                // Handle different types of inputs
-                if (prop.type === 'array') {
-                    // Create array container
+                if (isObjectArray(prop)) {
                     const arrayContainer = document.createElement('div');
                     arrayContainer.className = 'mb-4';
                     arrayContainer.id = 'array-container-' + propName;
                     formGroup.appendChild(arrayContainer);
 
-                    // Check if this is an array of objects with schema defined
-                    const isObjectArray = prop.items && prop.items.type === 'object' && prop.items.properties;
-
-                    if (isObjectArray) {
-                        // Store the item schema for later use when adding new items
-                        arrayContainer.dataset.itemSchema = JSON.stringify(prop.items);
-
-                        // Add button for adding new items
-                        const addButton = document.createElement('button');
-                        addButton.type = 'button';
-                        addButton.className = 'flex items-center px-3 py-2 mt-2 bg-green-600 text-white rounded-md hover:bg-green-700 focus:outline-none focus:ring-2 focus:ring-green-500';
-                        addButton.innerHTML = '<svg class="w-5 h-5 mr-1" fill="none" stroke="currentColor" viewBox="0 0 24 24" xmlns="http://www.w3.org/2000/svg"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M12 6v6m0 0v6m0-6h6m-6 0H6"></path></svg> Add Item';
-                        addButton.onclick = () => {
-                            addArrayItem(propName, prop.items);
-                            updateJSONFromForm(); // Update JSON when adding items
-                        };
-
-                        const arrayActions = document.createElement('div');
-                        arrayActions.className = 'mt-2';
-                        arrayActions.appendChild(addButton);
-                        formGroup.appendChild(arrayActions);
-
-                        // Make sure to append the formGroup to the DOM before adding items
-                        formContainer.appendChild(formGroup);
-
-                        // Add initial empty item
+                    // Add button for adding new items
+                    const addButton = document.createElement('button');
+                    addButton.type = 'button';
+                    addButton.className = 'flex items-center px-3 py-2 mt-2 bg-green-600 text-white rounded-md hover:bg-green-700 focus:outline-none focus:ring-2 focus:ring-green-500';
+                    addButton.innerHTML = '<svg class="w-5 h-5 mr-1" fill="none" stroke="currentColor" viewBox="0 0 24 24" xmlns="http://www.w3.org/2000/svg"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M12 6v6m0 0v6m0-6h6m-6 0H6"></path></svg> Add Item';
+                    addButton.onclick = () => {
                         addArrayItem(propName, prop.items);
-                    } else {
-                        // Simple array - use textarea with one item per line
-                        const textarea = document.createElement('textarea');
-                        textarea.id = 'param-' + propName;
-                        textarea.name = propName;
-                        textarea.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500 font-mono';
-                        textarea.placeholder = 'Enter one item per line';
-                        textarea.rows = 4;
-
-                        // Add event listener to update JSON when textarea changes
-                        textarea.addEventListener('input', () => updateJSONFromForm());
+                        onFieldChange(propName);
+                    };
 
-                        formGroup.appendChild(textarea);
+                    const arrayActions = document.createElement('div');
+                    arrayActions.className = 'mt-2';
+                    arrayActions.appendChild(addButton);
+                    formGroup.appendChild(arrayActions);
 
-                        formContainer.appendChild(formGroup);
-                    }
+                    // Add initial empty item
+                    addArrayItem(propName, prop.items);
                 } else {
-                    // Create input based on type (non-array)
-                    let input;
-                    switch (prop.type) {
-                        case 'boolean':
-                            input = document.createElement('select');
-                            input.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500';
-
-                            const trueOption = document.createElement('option');
-                            trueOption.value = 'true';
-                            trueOption.textContent = 'true';
-
-                            const falseOption = document.createElement('option');
-                            falseOption.value = 'false';
-                            falseOption.textContent = 'false';
-
-                            input.appendChild(trueOption);
-                            input.appendChild(falseOption);
-                            break;
-
-                        case 'number':
-                        case 'integer':
-                            input = document.createElement('input');
-                            input.type = 'number';
-                            input.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500';
-                            if (prop.minimum !== undefined) input.min = prop.minimum;
-                            if (prop.maximum !== undefined) input.max = prop.maximum;
-                            break;
-
-                        case 'object':
-                            input = document.createElement('textarea');
-                            input.placeholder = 'Enter JSON object';
-                            input.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500 font-mono';
-                            input.rows = 4;
-                            break;
-
-                        default: // string or any other type
-                            if (prop.enum) {
-                                input = document.createElement('select');
-                                input.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500';
-
-                                prop.enum.forEach(option => {
-                                    const optionEl = document.createElement('option');
-                                    optionEl.value = option;
-                                    optionEl.textContent = option;
-                                    input.appendChild(optionEl);
-                                });
-                            } else {
-                                input = document.createElement('input');
-                                input.type = 'text';
-                                input.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500';
-                                if (prop.format === 'password') input.type = 'password';
-                            }
-                    }
-
-                    input.id = 'param-' + propName;
+                    const input = createInput(prop, 'param-' + propName, required.includes(propName), () => onFieldChange(propName));
                     input.name = propName;
-
-                    // Add event listener to update JSON when input changes
-                    input.addEventListener('input', () => updateJSONFromForm());
-                    if (input.tagName === 'SELECT') {
-                        input.addEventListener('change', () => updateJSONFromForm());
-                    }
-
                     formGroup.appendChild(input);
-                    formContainer.appendChild(formGroup);
+                    if (isContentParam(prop)) {
+                        addFilePicker(formGroup, input, prop, () => onFieldChange(propName));
+                    }
                 }
+
+                const error = document.createElement('p');
+                error.className = 'field-error hidden mt-1 text-xs text-red-600';
+                formGroup.appendChild(error);
             }
         }
 
@@ -633,7 +935,7 @@ func handleIndex() http.HandlerFunc {
 
             // Create item container
             const itemDiv = document.createElement('div');
-            itemDiv.className = 'relative p-4 mb-4 bg-gray-50 border border-gray-200 rounded-lg';
+            itemDiv.className = 'array-item relative p-4 mb-4 bg-gray-50 border border-gray-200 rounded-lg';
             itemDiv.dataset.index = itemIndex;
 
             // Add remove button
@@ -645,13 +947,13 @@ func handleIndex() http.HandlerFunc {
                 itemDiv.remove();
                 // Update indices for remaining items
                 updateArrayItemIndices(propName);
-                // Update JSON when removing items
-                updateJSONFromForm();
+                onFieldChange(propName);
             };
             itemDiv.appendChild(removeButton);
 
             // Create form fields based on the item schema
             if (itemSchema && itemSchema.properties) {
+                const required = itemSchema.required || [];
                 for (const fieldName in itemSchema.properties) {
                     const fieldProp = itemSchema.properties[fieldName];
                     const fieldGroup = document.createElement('div');
@@ -663,7 +965,7 @@ func handleIndex() http.HandlerFunc {
                     label.textContent = fieldName;
                     label.className = 'block text-sm font-medium text-gray-700 mb-1';
 
-                    if (itemSchema.required && itemSchema.required.includes(fieldName)) {
+                    if (required.includes(fieldName)) {
                         const requiredSpan = document.createElement('span');
                         requiredSpan.className = 'text-red-600 font-bold';
                         requiredSpan.textContent = ' *';
@@ -679,64 +981,14 @@ func handleIndex() http.HandlerFunc {
                         fieldGroup.appendChild(description);
                     }
 
-                    // Input
-                    let input;
-                    switch (fieldProp.type) {
-                        case 'boolean':
-                            input = document.createElement('select');
-                            input.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500';
-
-                            const trueOption = document.createElement('option');
-                            trueOption.value = 'true';
-                            trueOption.textContent = 'true';
-
-                            const falseOption = document.createElement('option');
-                            falseOption.value = 'false';
-                            falseOption.textContent = 'false';
-
-                            input.appendChild(trueOption);
-                            input.appendChild(falseOption);
-                            break;
-
-                        case 'number':
-                        case 'integer':
-                            input = document.createElement('input');
-                            input.type = 'number';
-                            input.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500';
-                            if (fieldProp.minimum !== undefined) input.min = fieldProp.minimum;
-                            if (fieldProp.maximum !== undefined) input.max = fieldProp.maximum;
-                            break;
-
-                        default: // string, object, or any other type
-                            if (fieldProp.enum) {
-                                input = document.createElement('select');
-                                input.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500';
-
-                                fieldProp.enum.forEach(option => {
-                                    const optionEl = document.createElement('option');
-                                    optionEl.value = option;
-                                    optionEl.textContent = option;
-                                    input.appendChild(optionEl);
-                                });
-                            } else {
-                                input = document.createElement('input');
-                                input.type = 'text';
-                                input.className = 'w-full px-3 py-2 border border-gray-300 rounded-md shadow-sm focus:outline-none focus:ring-blue-500 focus:border-blue-500';
-                                if (fieldProp.format === 'password') input.type = 'password';
-                            }
-                    }
-
-                    input.id = 'param-' + propName + '-' + itemIndex + '-' + fieldName;
+                    const input = createInput(fieldProp, 'param-' + propName + '-' + itemIndex + '-' + fieldName,
+                        required.includes(fieldName), () => onFieldChange(propName));
                     input.name = propName + '-' + itemIndex + '-' + fieldName;
                     input.dataset.field = fieldName;
-
-                    // Add event listener to update JSON when item field changes
-                    input.addEventListener('input', () => updateJSONFromForm());
-                    if (input.tagName === 'SELECT') {
-                        input.addEventListener('change', () => updateJSONFromForm());
-                    }
-
                     fieldGroup.appendChild(input);
+                    if (isContentParam(fieldProp)) {
+                        addFilePicker(fieldGroup, input, fieldProp, () => onFieldChange(propName));
+                    }
                     itemDiv.appendChild(fieldGroup);
                 }
             }
@@ -756,76 +1008,44 @@ func handleIndex() http.HandlerFunc {
                 const inputs = item.querySelectorAll('input, select, textarea');
                 inputs.forEach(input => {
                     const fieldName = input.dataset.field;
+                    if (!fieldName) return;
                     input.id = 'param-' + propName + '-' + index + '-' + fieldName;
                     input.name = propName + '-' + index + '-' + fieldName;
                 });
             });
         }
 
-        // Collect values from form
-        function collectFormValues(tool) {
+        // Read the arguments filled in the form, with the parameters whose input cannot be read
+        function readForm(tool) {
             const params = {};
+            const errors = {};
 
-            // Check for schema in either parameters or inputSchema
-            let schema = null;
-            if (tool.parameters && tool.parameters.properties) {
-                schema = tool.parameters;
-            } else if (tool.inputSchema && tool.inputSchema.properties) {
-                schema = tool.inputSchema;
-            }
-
+            const schema = toolSchema(tool);
             if (!schema) {
-                return params;
+                return { params: params, errors: errors };
             }
 
             const properties = schema.properties;
-
             for (const propName in properties) {
                 const prop = properties[propName];
 
-                if (prop.type === 'array' && prop.items && prop.items.type === 'object' && prop.items.properties) {
-                    // Handle array of objects using the specialized UI
+                if (isObjectArray(prop)) {
                     const container = document.getElementById('array-container-' + propName);
                     if (!container) continue;
 
-                    const items = container.querySelectorAll('.array-item');
                     const arrayValues = [];
-
-                    items.forEach(item => {
+                    container.querySelectorAll('.array-item').forEach((item, position) => {
                         const itemIndex = item.dataset.index;
                         const itemValue = {};
-
-                        // Collect all field values for this item
                         for (const fieldName in prop.items.properties) {
                             const input = document.getElementById('param-' + propName + '-' + itemIndex + '-' + fieldName);
                             if (!input) continue;
 
-                            let value = input.value;
-
-                            // Convert types appropriately
-                            const fieldProp = prop.items.properties[fieldName];
-                            switch (fieldProp.type) {
-                                case 'boolean':
-                                    value = value === 'true';
-                                    break;
-
-                                case 'number':
-                                case 'integer':
-                                    if (value !== '') {
-                                        value = fieldProp.type === 'integer' ? parseInt(value, 10) : parseFloat(value);
-                                    } else {
-                                        continue; // Skip empty values
-                                    }
-                                    break;
-
-                                default:
-                                    // For strings, just use as-is
-                                    break;
-                            }
-
-                            // Only add non-empty values
-                            if (value !== '' && value !== undefined) {
-                                itemValue[fieldName] = value;
+                            const read = readInputValue(input, prop.items.properties[fieldName]);
+                            if (read.error) {
+                                errors[propName] = 'Item ' + (position + 1) + ', ' + fieldName + ': ' + read.error;
+                            } else if (read.value !== undefined) {
+                                itemValue[fieldName] = read.value;
                             }
                         }
 
@@ -834,68 +1054,383 @@ func handleIndex() http.HandlerFunc {
                             arrayValues.push(itemValue);
                         }
                     });
-
-                    params[propName] = arrayValues;
+                    if (arrayValues.length > 0) {
+                        params[propName] = arrayValues;
+                    }
                 } else {
-                    // Handle regular inputs or simple arrays
                     const input = document.getElementById('param-' + propName);
-
                     if (!input) continue;
 
-                    let value = input.value;
+                    const read = readInputValue(input, prop);
+                    if (read.error) {
+                        errors[propName] = read.error;
+                    } else if (read.value !== undefined) {
+                        params[propName] = read.value;
+                    }
+                }
+            }
 
-                    // Convert types appropriately
-                    switch (prop.type) {
-                        case 'boolean':
-                            value = value === 'true';
-                            break;
+            return { params: params, errors: errors };
+        }
 
-                        case 'number':
-                        case 'integer':
-                            if (value !== '') {
-                                value = prop.type === 'integer' ? parseInt(value, 10) : parseFloat(value);
-                            } else {
-                                continue; // Skip empty values
-                            }
-                            break;
-
-                        case 'array':
-                            if (value) {
-                                // Split by new lines and filter empty lines
-                                value = value.split('\n')
-                                    .map(item => item.trim())
-                                    .filter(item => item !== '');
-                            } else {
-                                value = [];
-                            }
-                            break;
-
-                        case 'object':
-                            if (value) {
-                                try {
-                                    value = JSON.parse(value);
-                                } catch (e) {
-                                    alert('Invalid JSON in field "' + propName + '": ' + e.message);
-                                    return null;
-                                }
-                            } else {
-                                value = {};
-                            }
-                            break;
+        // Collect values from form
+        function collectFormValues(tool) {
+            return readForm(tool).params;
+        }
+
+        // Whether a value has one of the JSON Schema types given
+        function matchesType(type, value) {
+            if (type === undefined) return true;
+            if (Array.isArray(type)) return type.some(t => matchesType(t, value));
+            switch (type) {
+                case 'string': return typeof value === 'string';
+                case 'number': return typeof value === 'number';
+                case 'integer': return Number.isInteger(value);
+                case 'boolean': return typeof value === 'boolean';
+                case 'array': return Array.isArray(value);
+                case 'object': return typeof value === 'object' && value !== null && !Array.isArray(value);
+                case 'null': return value === null;
+                default: return true;
+            }
+        }
+
+        // Check a value against the constraints of its schema, returning what is wrong with it, or
+        // an empty string if nothing is
+        function validateValue(prop, value, required) {
+            if (value === undefined) {
+                return required ? 'Required' : '';
+            }
+            if (prop.enum && !prop.enum.some(choice => JSON.stringify(choice) === JSON.stringify(value))) {
+                return 'Must be one of: ' + prop.enum.map(choice => JSON.stringify(choice)).join(', ');
+            }
+            if (!matchesType(prop.type, value)) {
+                return prop.type === 'integer' && typeof value === 'number' ? 'Must be a whole number' : 'Must be of type ' + [].concat(prop.type).join(' or ');
+            }
 
-                        default:
-                            // For strings, just use as-is
-                            break;
+            if (typeof value === 'number') {
+                if (prop.minimum !== undefined && value < prop.minimum) return 'Must be at least ' + prop.minimum;
+                if (prop.maximum !== undefined && value > prop.maximum) return 'Must be at most ' + prop.maximum;
+                if (typeof prop.exclusiveMinimum === 'number' && value <= prop.exclusiveMinimum) return 'Must be more than ' + prop.exclusiveMinimum;
+                if (typeof prop.exclusiveMaximum === 'number' && value >= prop.exclusiveMaximum) return 'Must be less than ' + prop.exclusiveMaximum;
+            }
+
+            if (typeof value === 'string') {
+                if (prop.minLength !== undefined && value.length < prop.minLength) return 'Must be at least ' + prop.minLength + ' characters';
+                if (prop.maxLength !== undefined && value.length > prop.maxLength) return 'Must be at most ' + prop.maxLength + ' characters';
+                if (prop.pattern) {
+                    let pattern = null;
+                    try {
+                        pattern = new RegExp(prop.pattern, 'u');
+                    } catch (e) {
+                        // Patterns JavaScript cannot read are left to the server
                     }
+                    if (pattern && !pattern.test(value)) return 'Must match ' + prop.pattern;
+                }
+            }
 
-                    // Only add non-empty values
-                    if (value !== '' && value !== undefined) {
-                        params[propName] = value;
+            if (Array.isArray(value)) {
+                if (prop.minItems !== undefined && value.length < prop.minItems) return 'Must have at least ' + prop.minItems + ' items';
+                if (prop.maxItems !== undefined && value.length > prop.maxItems) return 'Must have at most ' + prop.maxItems + ' items';
+                if (prop.items) {
+                    for (let i = 0; i < value.length; i++) {
+                        const problem = validateItem(prop.items, value[i]);
+                        if (problem) return 'Item ' + (i + 1) + ': ' + problem;
                     }
                 }
             }
 
-            return params;
+            if (typeof value === 'object' && value !== null && !Array.isArray(value) && prop.properties) {
+                const problem = validateItem(prop, value);
+                if (problem) return problem;
+            }
+            return '';
+        }
+
+        // Check an item of an array, or the fields of an object, against their schema
+        function validateItem(schema, value) {
+            if (!schema.properties || typeof value !== 'object' || value === null || Array.isArray(value)) {
+                return validateValue(schema, value, false);
+            }
+            const required = schema.required || [];
+            for (const fieldName in schema.properties) {
+                const problem = validateValue(schema.properties[fieldName], value[fieldName], required.includes(fieldName));
+                if (problem) return fieldName + ': ' + problem;
+            }
+            return '';
+        }
+
+        // Check arguments against the schema of a tool, adding what is wrong with each parameter
+        // to errors, by name
+        function validateParams(tool, params, errors = {}) {
+            const schema = toolSchema(tool);
+            if (!schema) return errors;
+
+            const required = schema.required || [];
+            for (const propName in schema.properties) {
+                if (errors[propName]) continue;
+                const problem = validateValue(schema.properties[propName], params[propName], required.includes(propName));
+                if (problem) errors[propName] = problem;
+            }
+            return errors;
+        }
+
+        // Show what is wrong with the arguments under their fields and, with summary, next to the
+        // execute button
+        function showValidation(errors, summary = true) {
+            document.querySelectorAll('#form-container [data-prop-name]').forEach(group => {
+                const error = group.querySelector('.field-error');
+                if (!error) return;
+                const problem = errors[group.dataset.propName];
+                error.textContent = problem || '';
+                error.classList.toggle('hidden', !problem);
+            });
+
+            const summaryElement = document.getElementById('validation-summary');
+            const names = Object.keys(errors);
+            if (summary && names.length > 0) {
+                summaryElement.textContent = 'Fix the arguments before executing: ' + names.map(name => name + ' (' + errors[name] + ')').join('; ');
+                summaryElement.classList.remove('hidden');
+            } else {
+                summaryElement.classList.add('hidden');
+            }
+        }
+
+        // Presets of the tool being edited, by name
+        let currentPresets = {};
+
+        // Load the presets of a tool into the preset menu, selecting the one called selected
+        function loadPresets(toolName, selected = '') {
+            fetch('/api/presets?tool=' + encodeURIComponent(toolName))
+                .then(response => response.json())
+                .then(data => {
+                    if (!currentTool || currentTool.name !== toolName) return;
+
+                    const select = document.getElementById('preset-select');
+                    select.innerHTML = '<option value="">Presets…</option>';
+                    currentPresets = {};
+                    ((data.result && data.result.presets) || []).forEach(preset => {
+                        currentPresets[preset.name] = preset;
+                        const option = document.createElement('option');
+                        option.value = preset.name;
+                        option.textContent = preset.name;
+                        select.appendChild(option);
+                    });
+                    select.value = selected in currentPresets ? selected : '';
+                })
+                .catch(err => console.error('Error fetching presets:', err));
+        }
+
+        // Fill the form and the JSON editor with arguments, replacing those filled in before
+        function applyParams(tool, params) {
+            createFormFromSchema(tool);
+            populateFormFromJSON(params);
+            document.getElementById('params-area').value = JSON.stringify(params, null, 2);
+            touchedFields.clear();
+            showValidation({});
+        }
+
+        // Arguments filled in the form, or in the JSON editor if it is shown
+        function currentParams(tool) {
+            if (document.getElementById('form-container').classList.contains('hidden')) {
+                return JSON.parse(document.getElementById('params-area').value);
+            }
+            return collectFormValues(tool);
+        }
+
+        document.getElementById('preset-select').addEventListener('change', event => {
+            const preset = currentPresets[event.target.value];
+            if (preset && currentTool) {
+                applyParams(currentTool, preset.params || {});
+            }
+        });
+
+        document.getElementById('preset-save-btn').addEventListener('click', () => {
+            if (!currentTool) return;
+
+            let params;
+            try {
+                params = currentParams(currentTool);
+            } catch (e) {
+                alert('Error parsing JSON parameters: ' + e.message);
+                return;
+            }
+            const name = (prompt('Save the arguments as the preset:', document.getElementById('preset-select').value) || '').trim();
+            if (!name) return;
+
+            const toolName = currentTool.name;
+            fetch('/api/presets', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ name: name, tool: toolName, params: params })
+            })
+                .then(response => response.json())
+                .then(data => {
+                    if (data.error) {
+                        alert('Error saving preset: ' + data.error);
+                        return;
+                    }
+                    loadPresets(toolName, name);
+                })
+                .catch(err => alert('Error saving preset: ' + err.message));
+        });
+
+        document.getElementById('preset-delete-btn').addEventListener('click', () => {
+            const name = document.getElementById('preset-select').value;
+            if (!currentTool || !name) {
+                alert('Select a preset to delete');
+                return;
+            }
+            if (!confirm('Delete the preset "' + name + '"?')) return;
+
+            const toolName = currentTool.name;
+            fetch('/api/presets?tool=' + encodeURIComponent(toolName) + '&name=' + encodeURIComponent(name), { method: 'DELETE' })
+                .then(response => response.json())
+                .then(data => {
+                    if (data.error) {
+                        alert('Error deleting preset: ' + data.error);
+                        return;
+                    }
+                    loadPresets(toolName);
+                })
+                .catch(err => alert('Error deleting preset: ' + err.message));
+        });
+
+        document.getElementById('preset-link-btn').addEventListener('click', () => {
+            const preset = currentPresets[document.getElementById('preset-select').value];
+            if (!preset) {
+                alert('Select or save a preset to share it');
+                return;
+            }
+            copyLink(window.location.origin + window.location.pathname + '?tool=' + encodeURIComponent(preset.tool) + '&preset=' + preset.link, 'the preset');
+        });
+
+        // Copy a link to the clipboard, or show it to copy where the clipboard is unavailable
+        function copyLink(url, what) {
+            if (navigator.clipboard && window.isSecureContext) {
+                navigator.clipboard.writeText(url)
+                    .then(() => alert('Link to ' + what + ' copied'))
+                    .catch(() => prompt('Copy the link to ' + what + ':', url));
+            } else {
+                prompt('Copy the link to ' + what + ':', url);
+            }
+        }
+
+        // Show the share links of sessions, when sharing is on
+        function loadShares() {
+            fetch('/api/shares')
+                .then(response => response.ok ? response.json() : null)
+                .then(data => {
+                    if (!data || !data.result) return;
+                    document.getElementById('share-panel').classList.remove('hidden');
+
+                    const list = document.getElementById('shares-list');
+                    list.innerHTML = '';
+                    data.result.shares.forEach(link => {
+                        const li = document.createElement('li');
+                        li.className = 'p-2 bg-gray-50 border border-gray-200 rounded-md';
+
+                        const anchor = document.createElement('a');
+                        anchor.href = link.url;
+                        anchor.target = '_blank';
+                        anchor.rel = 'noreferrer';
+                        anchor.className = 'block text-blue-600 hover:underline break-words';
+                        anchor.textContent = link.name;
+                        li.appendChild(anchor);
+
+                        const actions = document.createElement('div');
+                        actions.className = 'mt-1 flex gap-3 text-xs';
+                        const copyButton = document.createElement('button');
+                        copyButton.type = 'button';
+                        copyButton.className = 'text-gray-600 hover:text-gray-900';
+                        copyButton.textContent = 'Copy link';
+                        copyButton.onclick = () => copyLink(link.url, link.name);
+                        const revokeButton = document.createElement('button');
+                        revokeButton.type = 'button';
+                        revokeButton.className = 'text-red-600 hover:text-red-800';
+                        revokeButton.textContent = 'Revoke';
+                        revokeButton.onclick = () => {
+                            if (!confirm('Revoke the link to ' + link.name + '? Whoever has it will no longer see the session.')) return;
+                            fetch('/api/shares?token=' + encodeURIComponent(link.token), { method: 'DELETE' })
+                                .then(() => loadShares())
+                                .catch(err => alert('Error revoking link: ' + err.message));
+                        };
+                        actions.appendChild(copyButton);
+                        actions.appendChild(revokeButton);
+                        li.appendChild(actions);
+                        list.appendChild(li);
+                    });
+                })
+                .catch(err => console.error('Error fetching share links:', err));
+        }
+
+        // Create a share link, of the live session or of a recording, and copy it
+        function createShare(body) {
+            fetch('/api/shares', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(body)
+            })
+                .then(response => response.json())
+                .then(data => {
+                    if (data.error) {
+                        alert('Error sharing session: ' + data.error);
+                        return;
+                    }
+                    loadShares();
+                    copyLink(data.result.url, data.result.name);
+                })
+                .catch(err => alert('Error sharing session: ' + err.message));
+        }
+
+        document.getElementById('share-live-btn').addEventListener('click', () => createShare({}));
+
+        document.getElementById('share-recording-input').addEventListener('change', event => {
+            const file = event.target.files[0];
+            if (!file) return;
+            file.text()
+                .then(text => createShare({ name: 'Recording ' + file.name, recording: text }))
+                .catch(err => alert('Error reading ' + file.name + ': ' + err.message));
+            event.target.value = '';
+        });
+
+        loadShares();
+
+        // Show who is signed in, when users sign in
+        fetch('/api/user')
+            .then(response => response.ok ? response.json() : null)
+            .then(data => {
+                if (!data || !data.result || !data.result.user) return;
+                document.getElementById('signed-in-user').textContent = data.result.user;
+                document.getElementById('signed-in').classList.remove('hidden');
+                if (data.result.logout) document.getElementById('sign-out').classList.remove('hidden');
+            })
+            .catch(() => {});
+
+        // Open the tool of a shared link, filled in with its preset
+        function openSharedLink(tools) {
+            const query = new URLSearchParams(window.location.search);
+            const toolName = query.get('tool');
+            if (!toolName) return;
+
+            const tool = tools.find(t => t.name === toolName);
+            if (!tool) {
+                alert('This server has no tool ' + toolName);
+                return;
+            }
+            showTool(tool);
+
+            const link = query.get('preset');
+            if (!link) return;
+            fetch('/api/presets?link=' + encodeURIComponent(link))
+                .then(response => response.json())
+                .then(data => {
+                    if (data.error) {
+                        alert('Error opening preset: ' + data.error);
+                        return;
+                    }
+                    applyParams(tool, data.result.params || {});
+                })
+                .catch(err => alert('Error opening preset: ' + err.message));
         }
 
         // Call a tool with parameters
@@ -957,11 +1492,13 @@ func handleIndex() http.HandlerFunc {
             });
         }
 
-        // Call a prompt
-        function callPrompt(name) {
+        // Get a prompt with arguments and preview its messages
+        function callPrompt(name, args) {
             document.getElementById('main-title').textContent = 'Prompt: ' + name;
-            document.getElementById('tool-description').classList.add('hidden');
-            document.getElementById('tool-panel').classList.add('hidden');
+            if (!currentTool) {
+                document.getElementById('tool-description').classList.add('hidden');
+                document.getElementById('tool-panel').classList.add('hidden');
+            }
 
             fetch('/api/call', {
                 method: 'POST',
@@ -970,13 +1507,18 @@ func handleIndex() http.HandlerFunc {
                 },
                 body: JSON.stringify({
                     type: 'prompt',
-                    name: name
+                    name: name,
+                    params: args
                 })
             })
             .then(response => response.json())
             .then(data => {
                 document.getElementById('raw-output-container').textContent = JSON.stringify(data, null, 2);
-                displayFormattedOutput(data);
+                if (data.result && data.result.messages) {
+                    displayPromptMessages(data.result);
+                } else {
+                    displayFormattedOutput(data);
+                }
                 // Activate formatted tab
                 document.getElementById('formatted-tab').click();
             })
@@ -988,6 +1530,44 @@ func handleIndex() http.HandlerFunc {
             });
         }
 
+        // Display the messages of a prompt the way they would be sent to a model
+        function displayPromptMessages(result) {
+            const container = document.getElementById('formatted-output-container');
+            container.innerHTML = '';
+
+            if (result.description) {
+                const description = document.createElement('p');
+                description.className = 'text-gray-600 mb-4';
+                description.textContent = result.description;
+                container.appendChild(description);
+            }
+
+            result.messages.forEach(message => {
+                const block = document.createElement('div');
+                block.className = 'mb-4 p-3 rounded-md border ' +
+                    (message.role === 'assistant' ? 'bg-gray-50 border-gray-200' : 'bg-blue-50 border-blue-200');
+
+                const role = document.createElement('div');
+                role.className = 'text-xs font-medium uppercase tracking-wider text-gray-500 mb-1';
+                role.textContent = message.role;
+                block.appendChild(role);
+
+                const content = document.createElement('div');
+                content.className = 'whitespace-pre-wrap';
+                const c = message.content || {};
+                if (c.type === 'text') {
+                    content.textContent = c.text;
+                } else if (c.type === 'resource' && c.resource && c.resource.text !== undefined) {
+                    content.textContent = c.resource.text;
+                } else {
+                    content.textContent = '(' + (c.type || 'unknown') + ' content' + (c.mimeType ? ', ' + c.mimeType : '') + ')';
+                }
+                block.appendChild(content);
+
+                container.appendChild(block);
+            });
+        }
+
         // Display formatted output
         function displayFormattedOutput(data) {
             const container = document.getElementById('formatted-output-container');
@@ -1238,8 +1818,9 @@ func handlePrompts(cache *MCPClientCache) http.HandlerFunc {
 	}
 }
 
-// handleCall handles API requests for calling tools/resources/prompts.
-func handleCall(cache *MCPClientCache) http.HandlerFunc {
+// handleCall handles API requests for calling tools/resources/prompts, writing each call to
+// auditLog.
+func handleCall(cache *MCPClientCache, auditLog *webauth.AuditLog) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -1268,6 +1849,7 @@ func handleCall(cache *MCPClientCache) http.HandlerFunc {
 		cache.mutex.Lock()
 		defer cache.mutex.Unlock()
 
+		start := time.Now()
 		switch requestData.Type {
 		case EntityTypeTool:
 			var toolResponse *mcp.CallToolResult
@@ -1276,18 +1858,22 @@ func handleCall(cache *MCPClientCache) http.HandlerFunc {
 			request.Params.Arguments = requestData.Params
 			toolResponse, callErr = cache.client.CallTool(context.Background(), request)
 			resp = ConvertJSONToMap(toolResponse)
+			auditLog.Record(r, string(mcp.MethodToolsCall), requestData.Name, start, callErr)
 		case EntityTypeRes:
 			var resourceResponse *mcp.ReadResourceResult
 			request := mcp.ReadResourceRequest{}
 			request.Params.URI = requestData.Name
 			resourceResponse, callErr = cache.client.ReadResource(context.Background(), request)
 			resp = ConvertJSONToMap(resourceResponse)
+			auditLog.Record(r, string(mcp.MethodResourcesRead), requestData.Name, start, callErr)
 		case EntityTypePrompt:
 			var promptResponse *mcp.GetPromptResult
 			request := mcp.GetPromptRequest{}
 			request.Params.Name = requestData.Name
+			request.Params.Arguments = promptArguments(requestData.Params)
 			promptResponse, callErr = cache.client.GetPrompt(context.Background(), request)
 			resp = ConvertJSONToMap(promptResponse)
+			auditLog.Record(r, string(mcp.MethodPromptsGet), requestData.Name, start, callErr)
 		default:
 			w.WriteHeader(http.StatusBadRequest)
 			//nolint:errcheck,gosec // No need to handle error from Encode in this context
@@ -1313,3 +1899,183 @@ func handleCall(cache *MCPClientCache) http.HandlerFunc {
 		})
 	}
 }
+
+// handlePresets handles API requests for the presets of the forms of tools of server: listing
+// them, or decoding a shared one with link, saving and deleting them.
+func handlePresets(server string) http.HandlerFunc {
+	writeJSON := func(w http.ResponseWriter, status int, body map[string]interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		//nolint:errcheck,gosec // No need to handle error from Encode in this context
+		json.NewEncoder(w).Encode(body)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		presets, err := preset.Load()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if link := r.URL.Query().Get("link"); link != "" {
+				shared, decodeErr := preset.Decode(link)
+				if decodeErr != nil {
+					writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": decodeErr.Error()})
+					return
+				}
+				writeJSON(w, http.StatusOK, map[string]interface{}{"result": shared})
+				return
+			}
+
+			list := presets.List(server, r.URL.Query().Get("tool"))
+			result := make([]map[string]interface{}, 0, len(list))
+			for _, p := range list {
+				link, encodeErr := preset.Encode(p)
+				if encodeErr != nil {
+					writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": encodeErr.Error()})
+					return
+				}
+				result = append(result, map[string]interface{}{"name": p.Name, "tool": p.Tool, "params": p.Params, "link": link})
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"presets": result}})
+
+		case http.MethodPost:
+			var p preset.Preset
+			if decodeErr := json.NewDecoder(r.Body).Decode(&p); decodeErr != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "Invalid request: " + decodeErr.Error()})
+				return
+			}
+			p.Name = strings.TrimSpace(p.Name)
+			if p.Name == "" || p.Tool == "" {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "a preset needs a name and a tool"})
+				return
+			}
+			presets.Set(server, p)
+			if err = preset.Save(presets); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"result": p})
+
+		case http.MethodDelete:
+			tool, name := r.URL.Query().Get("tool"), r.URL.Query().Get("name")
+			if !presets.Delete(server, tool, name) {
+				writeJSON(w, http.StatusNotFound, map[string]interface{}{"error": fmt.Sprintf("no preset %q for %s", name, tool)})
+				return
+			}
+			if err = preset.Save(presets); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}})
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// handleShares handles API requests for the share links of sessions: listing them, creating
+// one to the live session, or to a recording sent as its JSON lines, and revoking them. Links
+// are given as URLs under baseURL. Creating and revoking links is written to auditLog.
+func handleShares(shares *share.Server, live *record.Timeline, baseURL, server string, auditLog *webauth.AuditLog) http.HandlerFunc {
+	writeJSON := func(w http.ResponseWriter, status int, body map[string]interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		//nolint:errcheck,gosec // No need to handle error from Encode in this context
+		json.NewEncoder(w).Encode(body)
+	}
+	withURL := func(link share.Link) map[string]interface{} {
+		return map[string]interface{}{"token": link.Token, "name": link.Name, "live": link.Live, "url": baseURL + link.Path}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		switch r.Method {
+		case http.MethodGet:
+			links := shares.Links()
+			result := make([]map[string]interface{}, len(links))
+			for i, link := range links {
+				result[i] = withURL(link)
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"shares": result}})
+
+		case http.MethodPost:
+			var requestData struct {
+				Name      string `json:"name"`
+				Recording string `json:"recording"`
+			}
+			if err := json.NewDecoder(io.LimitReader(r.Body, 64<<20)).Decode(&requestData); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "Invalid request: " + err.Error()})
+				return
+			}
+
+			var source share.Source = live
+			name := "Live session with " + server
+			if requestData.Recording != "" {
+				entries, err := record.Read(strings.NewReader(requestData.Recording))
+				if err == nil && len(entries) == 0 {
+					err = errors.New("no messages")
+				}
+				if err != nil {
+					writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "not a recording made with --record: " + err.Error()})
+					return
+				}
+				source, name = share.Recording(entries), "Recorded session"
+			}
+			if requestData.Name != "" {
+				name = requestData.Name
+			}
+
+			link, err := shares.Add(name, source)
+			auditLog.Record(r, "shares/create", name, start, err)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"result": withURL(link)})
+
+		case http.MethodDelete:
+			if !shares.Revoke(r.URL.Query().Get("token")) {
+				writeJSON(w, http.StatusNotFound, map[string]interface{}{"error": "no such share link"})
+				return
+			}
+			auditLog.Record(r, "shares/revoke", r.URL.Query().Get("token"), start, nil)
+			writeJSON(w, http.StatusOK, map[string]interface{}{"result": map[string]interface{}{}})
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// localOnly lets only requests from this machine through to h.
+func localOnly(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			http.Error(w, "This MCP Tools web interface only answers its own machine while sharing sessions; ask for a share link",
+				http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// shareHost returns the address other machines can reach this one at: its first private IPv4
+// address, or else its host name.
+func shareHost() string {
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil && ipNet.IP.IsPrivate() {
+				return ipNet.IP.String()
+			}
+		}
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "localhost"
+}
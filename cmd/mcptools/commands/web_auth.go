@@ -0,0 +1,182 @@
+//go:build !lite && !noweb
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/f/mcptools/pkg/audit"
+	"github.com/f/mcptools/pkg/webauth"
+	"github.com/spf13/cobra"
+)
+
+// webAuthOptions configures how users sign in to the web interface.
+type webAuthOptions struct {
+	usersPath    string
+	oidcIssuer   string
+	oidcClientID string
+	oidcRedirect string
+	oidcAllow    []string
+	auditPath    string
+	auditKey     string
+}
+
+// enabled reports whether users must sign in.
+func (o webAuthOptions) enabled() bool {
+	return o.usersPath != "" || o.oidcIssuer != ""
+}
+
+// openAudit opens the audit log when users sign in or --audit-log is set, and returns it with
+// its path, or nil.
+func (o webAuthOptions) openAudit() (*audit.File, string, error) {
+	if !o.enabled() && o.auditPath == "" {
+		return nil, "", nil
+	}
+	path := o.auditPath
+	if path == "" {
+		var err error
+		if path, err = webauth.GetAuditLogPath(); err != nil {
+			return nil, "", err
+		}
+	}
+	var key []byte
+	if o.auditKey != "" {
+		var err error
+		if key, err = audit.LoadKey(o.auditKey); err != nil {
+			return nil, "", err
+		}
+	}
+	file, err := audit.OpenFile(path, key)
+	return file, path, err
+}
+
+// authenticator returns the authenticator signing users in, or nil if they do not sign in.
+func (o webAuthOptions) authenticator(ctx context.Context, log *webauth.AuditLog) (webauth.Authenticator, error) {
+	switch {
+	case o.usersPath != "" && o.oidcIssuer != "":
+		return nil, errors.New("sign users in with either --users or --oidc-issuer, not both")
+	case o.usersPath != "":
+		users, err := webauth.LoadUsers(o.usersPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, withHint(fmt.Errorf("%s has no users", o.usersPath), "add one with: mcp web user --users "+o.usersPath+" name")
+		}
+		return webauth.NewBasic(users, "MCP Tools", log.Login)
+	case o.oidcIssuer != "":
+		return webauth.NewOIDC(ctx, webauth.OIDCConfig{
+			Issuer:       o.oidcIssuer,
+			ClientID:     o.oidcClientID,
+			ClientSecret: os.Getenv("MCPT_OIDC_CLIENT_SECRET"),
+			RedirectURL:  o.oidcRedirect,
+			Allow:        o.oidcAllow,
+			OnLogin:      log.Login,
+		})
+	}
+	return nil, nil
+}
+
+// isLoopbackHost reports whether a listen host only accepts connections from this machine.
+// The empty host listens on every interface.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// handleUser handles API requests for the signed in user, and whether they can sign out.
+func handleUser(canSignOut bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck,gosec // No need to handle error from Encode in this context
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{"user": webauth.User(r.Context()), "logout": canSignOut},
+		})
+	}
+}
+
+func webUserCmd() *cobra.Command {
+	var usersPath string
+	var remove, list bool
+
+	cmd := &cobra.Command{
+		Use:   "user [--users file] [--delete] name | --list",
+		Short: "Add a user of the web interface, or change their password",
+		Long: `Add a user who signs in to mcp web --users with a password, or change their password.
+The password is read from the terminal without echo, or from stdin. Only a salted PBKDF2 hash
+of it is kept in the users file ($HOME/.mcpt/web-users.json by default).
+
+Examples:
+  mcp web user alice
+  mcp web user --users /etc/mcpt/users.json --delete bob
+  mcp web user --list`,
+		Args: func(_ *cobra.Command, args []string) error {
+			if list {
+				return cobra.NoArgs(nil, args)
+			}
+			return cobra.ExactArgs(1)(nil, args)
+		},
+		SilenceUsage: true,
+		RunE: func(thisCmd *cobra.Command, args []string) error {
+			if usersPath == "" {
+				var err error
+				if usersPath, err = webauth.GetUsersPath(); err != nil {
+					return err
+				}
+			}
+			users, err := webauth.LoadUsers(usersPath)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case list:
+				for _, name := range users.Names() {
+					fmt.Fprintln(thisCmd.OutOrStdout(), name)
+				}
+				return nil
+			case remove:
+				if _, ok := users[args[0]]; !ok {
+					return fmt.Errorf("%s has no user %s", usersPath, args[0])
+				}
+				delete(users, args[0])
+				if err = users.Save(usersPath); err != nil {
+					return err
+				}
+				fmt.Fprintf(thisCmd.OutOrStdout(), "Deleted user %s\n", args[0])
+				return nil
+			}
+
+			name := args[0]
+			if name == "" || strings.ContainsAny(name, ":") {
+				return fmt.Errorf("invalid user name %q: it must not be empty or contain a colon", name)
+			}
+			password, err := readSecret(name)
+			if err != nil {
+				return err
+			}
+			if users[name], err = webauth.HashPassword(password); err != nil {
+				return err
+			}
+			if err = users.Save(usersPath); err != nil {
+				return err
+			}
+			fmt.Fprintf(thisCmd.OutOrStdout(), "Saved the password of %s in %s\n", name, usersPath)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&usersPath, "users", "", "Users file (default $HOME/.mcpt/web-users.json)")
+	cmd.Flags().BoolVar(&remove, "delete", false, "Delete the user")
+	cmd.Flags().BoolVar(&list, "list", false, "List the users")
+	return cmd
+}
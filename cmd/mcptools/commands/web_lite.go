@@ -0,0 +1,22 @@
+//go:build lite || noweb
+
+package commands
+
+import "github.com/spf13/cobra"
+
+// webIncluded reports whether this build includes the web interface of mcp web.
+const webIncluded = false
+
+// WebCmd creates the web command, which fails as this build leaves out the web interface.
+func WebCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:                "web",
+		Short:              "Start a web interface for MCP commands (not included in this build)",
+		Hidden:             true,
+		DisableFlagParsing: true,
+		SilenceUsage:       true,
+		RunE: func(*cobra.Command, []string) error {
+			return notIncludedError("mcp web", "web")
+		},
+	}
+}
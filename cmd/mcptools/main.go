@@ -27,23 +27,70 @@ func main() {
 	rootCmd := commands.RootCmd()
 	rootCmd.AddCommand(
 		commands.VersionCmd(),
+		commands.LsCmd(),
+		commands.InfoCmd(),
 		commands.ToolsCmd(),
+		commands.DescribeCmd(),
+		commands.DocsCmd(),
 		commands.ResourcesCmd(),
+		commands.BrowseCmd(),
 		commands.PromptsCmd(),
 		commands.CallCmd(),
 		commands.GetPromptCmd(),
 		commands.ReadResourceCmd(),
+		commands.FindCmd(),
+		commands.SuggestChainCmd(),
+		commands.RunCmd(),
+		commands.TxnCmd(),
+		commands.ApproveCmd(),
+		commands.MapCmd(),
+		commands.MatrixCmd(),
+		commands.SchemaCmd(),
+		commands.StatsCmd(),
+		commands.HistoryCmd(),
+		commands.IndexCmd(),
 		commands.ShellCmd(),
 		commands.WebCmd(),
 		commands.MockCmd(),
+		commands.ServeConfigCmd(),
+		commands.ServeSQLiteCmd(),
+		commands.ServeGitCmd(),
+		commands.ServeFetchCmd(),
+		commands.ServeScriptCmd(),
+		commands.ServeIndexCmd(),
+		commands.RunWasmCmd(),
+		commands.BridgeCmd(),
 		commands.ProxyCmd(),
 		commands.AliasCmd(),
+		commands.SyncCmd(),
+		commands.ConfigCmd(),
 		commands.ConfigsCmd(),
 		commands.NewCmd(),
 		commands.GuardCmd(),
+		commands.AdminCmd(),
+		commands.AuthCmd(),
+		commands.KeychainCmd(),
+		commands.TrustCmd(),
+		commands.ConsentCmd(),
+		commands.JailExecCmd(),
+		commands.AuditCmd(),
+		commands.ReplayCmd(),
+		commands.TraceCmd(),
+		commands.SelftestCmd(),
+		commands.TelemetryCmd(),
 	)
 
-	if err := rootCmd.Execute(); err != nil {
+	// Errors are printed here so they can be reported as JSON with --format json
+	rootCmd.SilenceErrors = true
+	cmd, err := rootCmd.ExecuteC()
+	if finishErr := commands.FinishOutputFile(err == nil); err == nil {
+		err = finishErr
+	}
+	commands.RecordTelemetry(cmd, err)
+	if err != nil {
+		// Cobra skips PersistentPostRun, which prints --stats, for failed commands
+		commands.PrintSessionStats(os.Stderr)
+		commands.PrintError(os.Stderr, err)
 		os.Exit(1)
 	}
 }
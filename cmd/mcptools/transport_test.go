@@ -46,6 +46,8 @@ func TestIsHTTP(t *testing.T) {
 		{"http://localhost:3000", true},
 		{"https://example.com", true},
 		{"localhost:3000", true},
+		{"[::1]:3000", true},
+		{"http://[2001:db8::1]:8080/mcp", true},
 		{"stdio", false},
 		{"", false},
 		{"file:///path", false},
@@ -0,0 +1,143 @@
+// Package accesslog exports a sample of the requests served by a gateway as PII-safe access
+// logs for usage analytics: users are replaced by pseudonyms, personal data in targets is
+// anonymized, and no request or response bodies are included. Records are written as JSON lines
+// to segments that are rotated by age and size and then shipped to a local directory, S3 or GCS.
+package accesslog
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/f/mcptools/pkg/anonymize"
+)
+
+// Schema identifies the version of the record format, see schema.json.
+const Schema = "mcptools.accesslog/v1"
+
+// JSONSchema is the JSON Schema of records.
+//
+//go:embed schema.json
+var JSONSchema []byte
+
+// Request is a request served by the gateway, as seen before scrubbing.
+type Request struct {
+	Time     time.Time
+	Tenant   string
+	User     string
+	Method   string
+	Target   string
+	Upstream string
+	Status   string
+	Duration time.Duration
+}
+
+// Record is one line of an exported access log.
+type Record struct {
+	Schema string    `json:"schema"`
+	Time   time.Time `json:"time"`
+	Tenant string    `json:"tenant,omitempty"`
+	// User is a pseudonym of the user, stable for as long as the salt is.
+	User     string `json:"user,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Target   string `json:"target,omitempty"`
+	Upstream string `json:"upstream,omitempty"`
+	Status   string `json:"status"`
+	// DurationMS is how long the request took, in milliseconds.
+	DurationMS int64 `json:"duration_ms"`
+	// SampleRate is the share of requests exported, so that counts can be scaled back up.
+	SampleRate float64 `json:"sample_rate"`
+}
+
+// Scrubber turns requests into records without personal data.
+type Scrubber struct {
+	// Salt keys the pseudonyms of users. Records made with different salts cannot be joined.
+	Salt []byte
+	// Anonymizer replaces personal data such as emails and IP addresses in targets.
+	Anonymizer *anonymize.Anonymizer
+}
+
+// Scrub returns the record of a request.
+func (s *Scrubber) Scrub(request Request, sampleRate float64) Record {
+	record := Record{
+		Schema:     Schema,
+		Time:       request.Time.UTC(),
+		Tenant:     request.Tenant,
+		Method:     request.Method,
+		Target:     s.target(request.Target),
+		Upstream:   request.Upstream,
+		Status:     request.Status,
+		DurationMS: request.Duration.Milliseconds(),
+		SampleRate: sampleRate,
+	}
+	if request.User != "" {
+		record.User = s.pseudonym(request.Tenant, request.User)
+	}
+	return record
+}
+
+// pseudonym returns a stable name for a user that does not reveal who it is.
+func (s *Scrubber) pseudonym(tenant, user string) string {
+	mac := hmac.New(sha256.New, s.Salt)
+	mac.Write([]byte(tenant + "\x00" + user))
+	return "u-" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// target drops the query and fragment of resource URIs, which often carry identifiers and
+// tokens, and anonymizes the rest.
+func (s *Scrubber) target(target string) string {
+	if u, err := url.Parse(target); err == nil && u.Scheme != "" {
+		u.RawQuery, u.Fragment, u.User = "", "", nil
+		target = u.String()
+	}
+	if s.Anonymizer != nil {
+		target = s.Anonymizer.String(target)
+	}
+	return target
+}
+
+// GetSaltPath returns the path of the default salt of user pseudonyms.
+func GetSaltPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "analytics.salt"), nil
+}
+
+// LoadSalt reads the salt of user pseudonyms from path, creating a random one there first if
+// the file does not exist, so that pseudonyms stay the same across restarts.
+func LoadSalt(path string) ([]byte, error) {
+	// #nosec G304 - the salt path is provided by the user or generated internally
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		salt := make([]byte, 32)
+		if _, err = rand.Read(salt); err != nil {
+			return nil, err
+		}
+		data = []byte(hex.EncodeToString(salt))
+		if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create salt: %w", err)
+		}
+		if err = os.WriteFile(path, data, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to create salt: %w", err)
+		}
+		return data, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read salt: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("salt file %s is empty", path)
+	}
+	return bytes.TrimSpace(data), nil
+}
@@ -0,0 +1,101 @@
+package accesslog
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/f/mcptools/pkg/anonymize"
+	"github.com/f/mcptools/pkg/contract"
+)
+
+func TestScrub(t *testing.T) {
+	anonymizer, err := anonymize.New(anonymize.Rules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scrubber := &Scrubber{Salt: []byte("salt"), Anonymizer: anonymizer}
+
+	request := Request{
+		Time:     time.Date(2026, 10, 17, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600)),
+		Tenant:   "acme",
+		User:     "alice@acme.com",
+		Method:   "resources/read",
+		Target:   "crm://contacts/bob@example.com?token=s3cret",
+		Status:   "ok",
+		Duration: 1500 * time.Millisecond,
+	}
+	record := scrubber.Scrub(request, 0.25)
+
+	if record.User == "" || strings.Contains(record.User, "alice") || record.User != scrubber.Scrub(request, 1).User {
+		t.Errorf("User = %q, want a stable pseudonym", record.User)
+	}
+	if record.Target != "crm://contacts/[email-1]" {
+		t.Errorf("Target = %q, want the email anonymized and the query dropped", record.Target)
+	}
+	if record.Time.Location() != time.UTC || record.DurationMS != 1500 || record.SampleRate != 0.25 {
+		t.Errorf("Scrub() = %+v", record)
+	}
+
+	// Records follow the documented schema
+	var schema map[string]any
+	if err = json.Unmarshal(JSONSchema, &schema); err != nil {
+		t.Fatalf("schema.json: %v", err)
+	}
+	data, _ := json.Marshal(record)
+	var decoded any
+	_ = json.Unmarshal(data, &decoded)
+	if err = contract.Validate(schema, decoded); err != nil {
+		t.Errorf("record does not match schema.json: %v", err)
+	}
+}
+
+func TestExporter(t *testing.T) {
+	dest := t.TempDir()
+	var errs []error
+	exporter, err := NewExporter(Options{
+		Sink:        &DirSink{Dir: dest},
+		SpoolDir:    t.TempDir(),
+		SampleRate:  1,
+		RotateBytes: 300,
+		Scrubber:    &Scrubber{Salt: []byte("salt")},
+		OnError:     func(err error) { errs = append(errs, err) },
+	})
+	if err != nil {
+		t.Fatalf("NewExporter() error = %v", err)
+	}
+
+	for range 5 {
+		exporter.Add(Request{Time: time.Now(), User: "alice", Method: "tools/call", Target: "read_file", Status: "ok"})
+	}
+	if err = exporter.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dest, "access-*.jsonl"))
+	if len(segments) < 2 {
+		t.Errorf("shipped %d segments, want the log rotated by size", len(segments))
+	}
+	lines := 0
+	for _, segment := range segments {
+		f, err := os.Open(segment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines++
+			if strings.Contains(scanner.Text(), "alice") {
+				t.Errorf("record %s names the user", scanner.Text())
+			}
+		}
+		_ = f.Close()
+	}
+	if lines != 5 || len(errs) > 0 {
+		t.Errorf("shipped %d records with errors %v, want 5", lines, errs)
+	}
+}
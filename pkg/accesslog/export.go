@@ -0,0 +1,211 @@
+package accesslog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of Options.
+const (
+	DefaultRotateEvery = time.Hour
+	DefaultRotateBytes = 64 << 20
+)
+
+// Options configures an Exporter.
+type Options struct {
+	// Sink receives the segments once they are rotated.
+	Sink Sink
+	// SpoolDir holds the segment being written and those not shipped yet.
+	SpoolDir string
+	// SampleRate is the share of requests exported, between 0 and 1.
+	SampleRate float64
+	// RotateEvery and RotateBytes bound the age and size of a segment. They default to
+	// DefaultRotateEvery and DefaultRotateBytes.
+	RotateEvery time.Duration
+	RotateBytes int64
+	Scrubber    *Scrubber
+	// OnError reports segments that could not be written or shipped. Failed segments stay in
+	// the spool directory and are shipped again on the next rotation.
+	OnError func(error)
+}
+
+// Exporter writes a sample of requests to segments and ships them to a sink on rotation.
+type Exporter struct {
+	opts    Options
+	file    *os.File
+	opened  time.Time
+	written int64
+	// segments numbers the segments of this run, so that their names are unique
+	segments int
+	mu       sync.Mutex
+	shipNow  chan struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewExporter creates an exporter. Segments left in the spool directory by earlier runs are
+// shipped first.
+func NewExporter(opts Options) (*Exporter, error) {
+	if opts.Sink == nil {
+		return nil, errors.New("access log export needs a destination")
+	}
+	if opts.SampleRate <= 0 || opts.SampleRate > 1 {
+		return nil, fmt.Errorf("invalid sample rate %g: expected a number above 0 and at most 1", opts.SampleRate)
+	}
+	if opts.RotateEvery <= 0 {
+		opts.RotateEvery = DefaultRotateEvery
+	}
+	if opts.RotateBytes <= 0 {
+		opts.RotateBytes = DefaultRotateBytes
+	}
+	if opts.Scrubber == nil {
+		opts.Scrubber = &Scrubber{}
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(error) {}
+	}
+	if err := os.MkdirAll(opts.SpoolDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	e := &Exporter{opts: opts, done: make(chan struct{}), shipNow: make(chan struct{}, 1)}
+	// Segments left by earlier runs go first
+	e.shipNow <- struct{}{}
+
+	// Segments are shipped in the background, so requests never wait for uploads, and rotated
+	// on age even when no requests come in
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(min(opts.RotateEvery, time.Minute))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.done:
+				return
+			case <-e.shipNow:
+				e.ship()
+			case <-ticker.C:
+				e.mu.Lock()
+				if e.file != nil && time.Since(e.opened) >= e.opts.RotateEvery {
+					e.rotate()
+				}
+				e.mu.Unlock()
+			}
+		}
+	}()
+
+	return e, nil
+}
+
+// Add exports a request if it is sampled.
+func (e *Exporter) Add(request Request) {
+	// #nosec G404 - sampling needs no cryptographic randomness
+	if e.opts.SampleRate < 1 && rand.Float64() >= e.opts.SampleRate {
+		return
+	}
+	data, err := json.Marshal(e.opts.Scrubber.Scrub(request, e.opts.SampleRate))
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		if err = e.open(); err != nil {
+			e.opts.OnError(err)
+			return
+		}
+	}
+	n, err := e.file.Write(data)
+	e.written += int64(n)
+	if err != nil {
+		e.opts.OnError(fmt.Errorf("failed to write access log: %w", err))
+	}
+	if e.written >= e.opts.RotateBytes || time.Since(e.opened) >= e.opts.RotateEvery {
+		e.rotate()
+	}
+}
+
+// Close rotates the current segment and ships it with any others not shipped yet.
+func (e *Exporter) Close() error {
+	e.mu.Lock()
+	if e.file != nil {
+		e.rotate()
+	}
+	e.mu.Unlock()
+
+	close(e.done)
+	e.wg.Wait()
+	e.ship()
+	return nil
+}
+
+// open starts a segment. The caller holds the lock.
+func (e *Exporter) open() error {
+	now := time.Now().UTC()
+	e.segments++
+	name := fmt.Sprintf("access-%s-%d-%d%s", now.Format("20060102T150405.000Z"), os.Getpid(), e.segments, partialSuffix)
+	// #nosec G304 - the spool directory is provided by the user or generated internally
+	file, err := os.OpenFile(filepath.Join(e.opts.SpoolDir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create access log segment: %w", err)
+	}
+	e.file, e.opened, e.written = file, now, 0
+	return nil
+}
+
+// partialSuffix marks the segment being written, which is not shipped.
+const partialSuffix = ".jsonl.partial"
+
+// rotate closes the current segment and has it shipped. The caller holds the lock.
+func (e *Exporter) rotate() {
+	path := e.file.Name()
+	if err := e.file.Close(); err != nil {
+		e.opts.OnError(fmt.Errorf("failed to close access log segment: %w", err))
+	}
+	e.file = nil
+	if err := os.Rename(path, strings.TrimSuffix(path, ".partial")); err != nil {
+		e.opts.OnError(fmt.Errorf("failed to close access log segment: %w", err))
+	}
+	select {
+	case e.shipNow <- struct{}{}:
+	default:
+	}
+}
+
+// ship sends the closed segments in the spool directory to the sink, oldest first, and removes
+// those that were shipped. It stops at the first failure, leaving the rest for the next time.
+func (e *Exporter) ship() {
+	segments, _ := filepath.Glob(filepath.Join(e.opts.SpoolDir, "access-*.jsonl"))
+	sort.Strings(segments)
+	for _, segment := range segments {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		err := e.opts.Sink.Put(ctx, segment)
+		cancel()
+		if err != nil {
+			e.opts.OnError(fmt.Errorf("failed to ship %s: %w", filepath.Base(segment), err))
+			return
+		}
+		_ = os.Remove(segment)
+	}
+}
+
+// GetSpoolDir returns the default spool directory of access log segments.
+func GetSpoolDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "logs", "access"), nil
+}
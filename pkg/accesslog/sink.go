@@ -0,0 +1,101 @@
+package accesslog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/objstore"
+)
+
+// Sink stores rotated segments.
+type Sink interface {
+	// Put stores the segment at path.
+	Put(ctx context.Context, path string) error
+}
+
+// ParseSink parses a destination: s3://bucket/prefix, gs://bucket/prefix or a local directory.
+// Object storage credentials are found as described in package objstore.
+func ParseSink(dest string) (Sink, error) {
+	if !strings.Contains(dest, "://") {
+		if dest == "" {
+			return nil, fmt.Errorf("access log destination is empty")
+		}
+		return &DirSink{Dir: dest}, nil
+	}
+
+	loc, err := objstore.Parse(dest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access log destination %q: expected s3://, gs:// or a directory", dest)
+	}
+	store, err := objstore.Open(loc)
+	if err != nil {
+		return nil, fmt.Errorf("no credentials for %s: %w", dest, err)
+	}
+	return &ObjectSink{Store: store, Prefix: strings.Trim(loc.Key, "/")}, nil
+}
+
+// DirSink moves segments into a local directory, e.g. one watched by a log shipper.
+type DirSink struct {
+	Dir string
+}
+
+// Put implements Sink.
+func (s *DirSink) Put(_ context.Context, segment string) error {
+	if err := os.MkdirAll(s.Dir, 0o750); err != nil {
+		return err
+	}
+	target := filepath.Join(s.Dir, filepath.Base(segment))
+	if err := os.Rename(segment, target); err == nil {
+		return nil
+	}
+
+	// Renaming fails across file systems, so copy instead
+	// #nosec G304 - segments are in the spool directory
+	data, err := os.ReadFile(segment)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0o600)
+}
+
+// ObjectSink uploads segments to S3 or GCS, under prefix/yyyy/mm/dd/ of the day the segment
+// was started.
+type ObjectSink struct {
+	Store  *objstore.Store
+	Prefix string
+}
+
+// Put implements Sink.
+func (s *ObjectSink) Put(ctx context.Context, segment string) error {
+	// #nosec G304 - segments are in the spool directory
+	file, err := os.Open(segment)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	w := s.Store.Create(ctx, path.Join(s.Prefix, segmentDay(filepath.Base(segment)), filepath.Base(segment)))
+	w.ContentType = "application/x-ndjson"
+	if _, err = io.Copy(w, file); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
+
+// segmentDay returns yyyy/mm/dd of the day a segment named access-<time>-<pid>-<n>.jsonl was
+// started, or of today if the name has no time.
+func segmentDay(name string) string {
+	if stamp := strings.TrimPrefix(name, "access-"); len(stamp) >= 8 {
+		if started, err := time.Parse("20060102", stamp[:8]); err == nil {
+			return started.Format("2006/01/02")
+		}
+	}
+	return time.Now().UTC().Format("2006/01/02")
+}
@@ -0,0 +1,58 @@
+package accesslog
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestObjectSinkPut(t *testing.T) {
+	var path, auth string
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		received, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "id")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL_S3", server.URL)
+	sink, err := ParseSink("s3://logs/mcp/access")
+	if err != nil {
+		t.Fatalf("ParseSink() error = %v", err)
+	}
+
+	segment := filepath.Join(t.TempDir(), "access-20261017T120000.000Z-42.jsonl")
+	if err = os.WriteFile(segment, []byte("{}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = sink.Put(t.Context(), segment); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if path != "/logs/mcp/access/2026/10/17/access-20261017T120000.000Z-42.jsonl" {
+		t.Errorf("uploaded to %s", path)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=id/") || string(received) != "{}\n" {
+		t.Errorf("upload: Authorization = %q, body = %q", auth, received)
+	}
+}
+
+func TestParseSink(t *testing.T) {
+	t.Setenv("GCS_HMAC_ACCESS_KEY_ID", "")
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filepath.Join(t.TempDir(), "missing.json"))
+	if sink, err := ParseSink("/var/log/mcp"); err != nil || sink.(*DirSink).Dir != "/var/log/mcp" {
+		t.Errorf("ParseSink(dir) = %v, %v", sink, err)
+	}
+	for _, invalid := range []string{"ftp://host/logs", "s3:///prefix", "gs://bucket/prefix"} {
+		if _, err := ParseSink(invalid); err == nil {
+			t.Errorf("ParseSink(%q) succeeded, want an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Package admin implements the local admin endpoint of long-running guard and bridge processes,
+used to adjust their policies temporarily without a restart.
+*/
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Override actions.
+const (
+	ActionAllow = "allow"
+	ActionDeny  = "deny"
+)
+
+// Errors returned by the admin endpoint.
+var (
+	ErrInvalidOverride  = errors.New("invalid override")
+	ErrOverrideNotFound = errors.New("override not found")
+)
+
+// Override temporarily allows or denies the tools, prompts or resources matching a pattern.
+type Override struct {
+	Expires time.Time `json:"expires,omitempty"`
+	Action  string    `json:"action"`
+	Entity  string    `json:"entity"`
+	Pattern string    `json:"pattern"`
+	ID      int       `json:"id"`
+}
+
+// Expired reports whether the override no longer applies at now.
+func (o Override) Expired(now time.Time) bool {
+	return !o.Expires.IsZero() && !now.Before(o.Expires)
+}
+
+// String describes the override for logs.
+func (o Override) String() string {
+	s := fmt.Sprintf("#%d %s %s %s", o.ID, o.Action, o.Entity, o.Pattern)
+	if !o.Expires.IsZero() {
+		s += " until " + o.Expires.Format(time.RFC3339)
+	}
+	return s
+}
+
+// Overrides is the set of overrides of a running process. It is safe for concurrent use.
+type Overrides struct {
+	byID   map[int]Override
+	now    func() time.Time
+	nextID int
+	mu     sync.Mutex
+}
+
+// NewOverrides creates an empty set of overrides.
+func NewOverrides() *Overrides {
+	return &Overrides{byID: make(map[int]Override), now: time.Now}
+}
+
+// Add validates o, gives it an ID and adds it. Later overrides win over earlier ones.
+func (s *Overrides) Add(o Override) (Override, error) {
+	if o.Action != ActionAllow && o.Action != ActionDeny {
+		return o, fmt.Errorf("%w: action must be %s or %s", ErrInvalidOverride, ActionAllow, ActionDeny)
+	}
+	if o.Entity == "" || o.Pattern == "" {
+		return o, fmt.Errorf("%w: entity and pattern are required", ErrInvalidOverride)
+	}
+	if _, err := filepath.Match(o.Pattern, ""); err != nil {
+		return o, fmt.Errorf("%w: %w", ErrInvalidOverride, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	o.ID = s.nextID
+	s.byID[o.ID] = o
+	return o, nil
+}
+
+// Remove deletes the override with id.
+func (s *Overrides) Remove(id int) (Override, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.byID[id]
+	if !ok {
+		return o, fmt.Errorf("%w: #%d", ErrOverrideNotFound, id)
+	}
+	delete(s.byID, id)
+	return o, nil
+}
+
+// List returns the overrides that still apply, oldest first.
+func (s *Overrides) List() []Override {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active()
+}
+
+// Decide returns whether the newest override matching name allows it. The second result is
+// false if no override matches, in which case the process's own policy applies.
+func (s *Overrides) Decide(entity, name string) (bool, bool) {
+	o, matched := s.Match(entity, name)
+	return o.Action == ActionAllow, matched
+}
+
+// Match returns the newest override matching name, which decides whether it is allowed.
+func (s *Overrides) Match(entity, name string) (Override, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := s.active()
+	for i := len(active) - 1; i >= 0; i-- {
+		o := active[i]
+		if o.Entity != entity {
+			continue
+		}
+		if match, _ := filepath.Match(o.Pattern, name); match {
+			return o, true
+		}
+	}
+	return Override{}, false
+}
+
+// active drops expired overrides and returns the rest by ID. The caller holds the lock.
+func (s *Overrides) active() []Override {
+	now := s.now()
+	list := make([]Override, 0, len(s.byID))
+	for id, o := range s.byID {
+		if o.Expired(now) {
+			delete(s.byID, id)
+			continue
+		}
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// GetSocketPath returns the default admin socket path, creating its directory if needed.
+func GetSocketPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	configDir := filepath.Join(homeDir, ".mcpt")
+	if err = os.MkdirAll(configDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	return filepath.Join(configDir, "admin.sock"), nil
+}
@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOverridesDecide(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	overrides := NewOverrides()
+	overrides.now = func() time.Time { return now }
+
+	if _, matched := overrides.Decide("tool", "delete_file"); matched {
+		t.Fatal("empty overrides matched a tool")
+	}
+
+	_, _ = overrides.Add(Override{Action: ActionDeny, Entity: "tool", Pattern: "delete_*"})
+	_, _ = overrides.Add(Override{Action: ActionAllow, Entity: "tool", Pattern: "delete_file", Expires: now.Add(10 * time.Minute)})
+
+	if allowed, matched := overrides.Decide("tool", "delete_file"); !matched || !allowed {
+		t.Errorf("delete_file: allowed = %v, matched = %v; the newest override should allow it", allowed, matched)
+	}
+	if allowed, matched := overrides.Decide("tool", "delete_dir"); !matched || allowed {
+		t.Errorf("delete_dir: allowed = %v, matched = %v; want denied", allowed, matched)
+	}
+	if _, matched := overrides.Decide("prompt", "delete_file"); matched {
+		t.Error("tool override matched a prompt")
+	}
+
+	now = now.Add(10 * time.Minute)
+	if allowed, _ := overrides.Decide("tool", "delete_file"); allowed {
+		t.Error("expired override still allows delete_file")
+	}
+	if got := len(overrides.List()); got != 1 {
+		t.Errorf("List() returned %d overrides after expiry, want 1", got)
+	}
+}
+
+func TestOverridesAddValidates(t *testing.T) {
+	overrides := NewOverrides()
+	for _, o := range []Override{
+		{Action: "maybe", Entity: "tool", Pattern: "x"},
+		{Action: ActionAllow, Entity: "tool"},
+		{Action: ActionAllow, Entity: "tool", Pattern: "["},
+	} {
+		if _, err := overrides.Add(o); err == nil {
+			t.Errorf("Add(%+v) succeeded, want an error", o)
+		}
+	}
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mcpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	socket := filepath.Join(dir, "admin.sock")
+
+	var audit []string
+	overrides := NewOverrides()
+	server, err := Listen(socket, overrides, func(msg string) { audit = append(audit, msg) })
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer func() { _ = server.Close() }()
+	if info, statErr := os.Stat(socket); statErr != nil {
+		t.Fatal(statErr)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("admin socket mode = %v, want %v", info.Mode().Perm(), os.FileMode(0o600))
+	}
+
+	if _, err = Listen(socket, NewOverrides(), func(string) {}); err == nil {
+		t.Error("second Listen() on a live socket succeeded")
+	}
+
+	client := NewClient(socket)
+	added, err := client.Add(ActionAllow, "tool", "delete_file", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if allowed, _ := overrides.Decide("tool", "delete_file"); !allowed {
+		t.Error("override added through the socket does not apply")
+	}
+
+	if _, err = client.Add("maybe", "tool", "x", 0); err == nil || !strings.Contains(err.Error(), "action must be") {
+		t.Errorf("invalid Add() error = %v", err)
+	}
+
+	list, err := client.List()
+	if err != nil || len(list) != 1 || list[0].ID != added.ID {
+		t.Fatalf("List() = %v, %v", list, err)
+	}
+
+	if _, err = client.Remove(added.ID); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err = client.Remove(added.ID); err == nil {
+		t.Error("removing a missing override succeeded")
+	}
+
+	if len(audit) != 2 || !strings.Contains(audit[0], "added override #1 allow tool delete_file until") ||
+		!strings.Contains(audit[1], "removed override #1") {
+		t.Errorf("unexpected audit messages %q", audit)
+	}
+}
+
+func TestServerDebug(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mcpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	plain, err := Listen(filepath.Join(dir, "plain.sock"), NewOverrides(), func(string) {})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer func() { _ = plain.Close() }()
+	var out bytes.Buffer
+	if err = NewClient(filepath.Join(dir, "plain.sock")).Debug("/debug/metrics", &out); err == nil ||
+		!strings.Contains(err.Error(), "--admin-debug") {
+		t.Errorf("Debug() without WithDebug: error = %v", err)
+	}
+
+	debug, err := Listen(filepath.Join(dir, "debug.sock"), NewOverrides(), func(string) {}, WithDebug())
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer func() { _ = debug.Close() }()
+	client := NewClient(filepath.Join(dir, "debug.sock"))
+
+	if err = client.Debug("/debug/metrics", &out); err != nil {
+		t.Fatalf("Debug(metrics) error = %v", err)
+	}
+	if !strings.Contains(out.String(), "# TYPE go_sched_goroutines_goroutines gauge\ngo_sched_goroutines_goroutines ") {
+		t.Errorf("runtime metrics lack the goroutine count:\n%.500s", out.String())
+	}
+
+	out.Reset()
+	if err = client.Debug("/debug/pprof/heap", &out); err != nil || out.Len() == 0 {
+		t.Errorf("Debug(heap) = %d bytes, %v", out.Len(), err)
+	}
+}
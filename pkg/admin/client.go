@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Client talks to the admin API of a running process.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient creates a client for the admin socket at path.
+func NewClient(path string) *Client {
+	return &Client{http: &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}}
+}
+
+// Add adds an override that expires after ttl, or never if ttl is zero.
+func (c *Client) Add(action, entity, pattern string, ttl time.Duration) (Override, error) {
+	req := addRequest{Action: action, Entity: entity, Pattern: pattern}
+	if ttl > 0 {
+		req.TTL = ttl.String()
+	}
+
+	var o Override
+	err := c.do(http.MethodPost, "/overrides", req, &o)
+	return o, err
+}
+
+// List returns the active overrides.
+func (c *Client) List() ([]Override, error) {
+	var list []Override
+	err := c.do(http.MethodGet, "/overrides", nil, &list)
+	return list, err
+}
+
+// Remove removes the override with id.
+func (c *Client) Remove(id int) (Override, error) {
+	var o Override
+	err := c.do(http.MethodDelete, "/overrides/"+strconv.Itoa(id), nil, &o)
+	return o, err
+}
+
+// Debug copies the debug endpoint at path, such as /debug/pprof/heap or /debug/metrics, to w.
+func (c *Client) Debug(path string, w io.Writer) error {
+	// CPU profiles and traces take as long as they were asked to
+	client := *c.http
+	client.Timeout = 0
+	resp, err := client.Get("http://admin" + path)
+	if err != nil {
+		return fmt.Errorf("failed to reach admin socket (is the process running with --admin?): %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errors.New("debug endpoints are disabled: start the process with --admin-debug")
+	case resp.StatusCode >= http.StatusBadRequest:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+func (c *Client) do(method, path string, body, out any) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, "http://admin"+path, &payload)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach admin socket (is the process running with --admin?): %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return errors.New(apiErr.Error)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ListenOption configures the admin server.
+type ListenOption func(*http.ServeMux)
+
+// WithDebug serves the profiles of net/http/pprof under /debug/pprof/ and the Go runtime
+// metrics in the Prometheus text format at /debug/metrics, to debug the CPU and memory use of
+// the process itself.
+func WithDebug() ListenOption {
+	return func(mux *http.ServeMux) {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		mux.HandleFunc("/debug/metrics", handleRuntimeMetrics)
+	}
+}
+
+func handleRuntimeMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = WriteRuntimeMetrics(w)
+}
+
+// WriteRuntimeMetrics writes the counters and gauges of runtime/metrics in the Prometheus text
+// format, named after the metric, e.g. /gc/heap/allocs:bytes as go_gc_heap_allocs_bytes.
+// Histograms, such as GC pause times, are left out; their distribution is in the profiles.
+func WriteRuntimeMetrics(w io.Writer) error {
+	descriptions := metrics.All()
+	samples := make([]metrics.Sample, 0, len(descriptions))
+	byName := make(map[string]metrics.Description, len(descriptions))
+	for _, d := range descriptions {
+		if d.Kind == metrics.KindUint64 || d.Kind == metrics.KindFloat64 {
+			samples = append(samples, metrics.Sample{Name: d.Name})
+			byName[d.Name] = d
+		}
+	}
+	metrics.Read(samples)
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Name < samples[j].Name })
+
+	for _, sample := range samples {
+		d := byName[sample.Name]
+		name := promName(d.Name)
+		kind := "gauge"
+		if d.Cumulative {
+			kind = "counter"
+		}
+
+		var value string
+		switch sample.Value.Kind() {
+		case metrics.KindUint64:
+			value = fmt.Sprint(sample.Value.Uint64())
+		case metrics.KindFloat64:
+			value = fmt.Sprint(sample.Value.Float64())
+		default:
+			// Metrics unsupported by this Go version read as KindBad
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n",
+			name, strings.ReplaceAll(d.Description, "\n", " "), name, kind, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promName converts a runtime metric name such as /sched/goroutines:goroutines to a Prometheus
+// name such as go_sched_goroutines_goroutines.
+func promName(name string) string {
+	var b strings.Builder
+	b.WriteString("go")
+	for _, r := range name {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package admin
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// listenUnix listens on the Unix socket at path, created with no access for the group and
+// others so no other user can connect before its mode is set.
+func listenUnix(path string) (net.Listener, error) {
+	// The umask is process-wide, but only restricts the files created while it is set
+	mask := unix.Umask(0o077)
+	defer unix.Umask(mask)
+	return net.Listen("unix", path)
+}
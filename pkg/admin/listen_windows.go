@@ -0,0 +1,11 @@
+//go:build windows
+
+package admin
+
+import "net"
+
+// listenUnix listens on the Unix socket at path. Windows has no umask; the socket is only
+// reachable by the users allowed into its directory.
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
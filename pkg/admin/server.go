@@ -0,0 +1,141 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server serves the admin API on a Unix socket:
+//
+//	GET    /overrides       list the active overrides
+//	POST   /overrides       add an override, with its TTL in a "ttl" field such as "10m"
+//	DELETE /overrides/{id}  remove an override
+//
+// and, with WithDebug, the pprof profiles and runtime metrics of the process.
+type Server struct {
+	overrides *Overrides
+	audit     func(string)
+	listener  net.Listener
+	http      *http.Server
+}
+
+// addRequest is the body of POST /overrides.
+type addRequest struct {
+	Action  string `json:"action"`
+	Entity  string `json:"entity"`
+	Pattern string `json:"pattern"`
+	TTL     string `json:"ttl,omitempty"`
+}
+
+// Listen starts serving the admin API for overrides on the Unix socket at path. Every change is
+// described to audit. A socket left behind by an earlier process is replaced.
+func Listen(path string, overrides *Overrides, audit func(string), opts ...ListenOption) (*Server, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("admin socket %s is in use by another process", path)
+	}
+	_ = os.Remove(path)
+
+	listener, err := listenUnix(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on admin socket: %w", err)
+	}
+	if err = os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict admin socket: %w", err)
+	}
+
+	s := &Server{overrides: overrides, audit: audit, listener: listener}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/overrides", s.handleOverrides)
+	mux.HandleFunc("/overrides/", s.handleOverride)
+	for _, opt := range opts {
+		opt(mux)
+	}
+	s.http = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() { _ = s.http.Serve(listener) }()
+	return s, nil
+}
+
+// Close stops the server and removes its socket.
+func (s *Server) Close() error {
+	err := s.http.Shutdown(context.Background())
+	_ = os.Remove(s.listener.Addr().String())
+	return err
+}
+
+func (s *Server) handleOverrides(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.overrides.List())
+	case http.MethodPost:
+		var req addRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		o := Override{Action: req.Action, Entity: req.Entity, Pattern: req.Pattern}
+		if req.TTL != "" {
+			ttl, err := time.ParseDuration(req.TTL)
+			if err != nil || ttl <= 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("%w: invalid ttl %q", ErrInvalidOverride, req.TTL))
+				return
+			}
+			o.Expires = time.Now().Add(ttl).Truncate(time.Second)
+		}
+
+		o, err := s.overrides.Add(o)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		s.audit("Admin added override " + o.String())
+		writeJSON(w, http.StatusCreated, o)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (s *Server) handleOverride(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/overrides/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid override id: %w", err))
+		return
+	}
+
+	o, err := s.overrides.Remove(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	s.audit("Admin removed override " + o.String())
+	writeJSON(w, http.StatusOK, o)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
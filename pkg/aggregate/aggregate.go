@@ -0,0 +1,158 @@
+// Package aggregate combines several documents, such as the pages of a paged tool or the
+// results of the runs of a workflow matrix, into a single one.
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/f/mcptools/pkg/script"
+	"github.com/f/mcptools/pkg/workflow"
+)
+
+// Modes of aggregation.
+const (
+	// Concat concatenates the arrays at a path of each document.
+	Concat = "concat"
+	// Count counts the documents, or the items of the arrays at a path of each document.
+	Count = "count"
+	// Merge merges objects, concatenating arrays; later documents win for other values.
+	Merge = "merge"
+	// Sum adds up the numbers at a path of each document.
+	Sum = "sum"
+	// Reduce folds the documents with the reduce(acc, doc, index) function of a Lua script.
+	Reduce = "reduce"
+)
+
+// Mode is how documents are aggregated, written as mode or mode:argument, e.g. concat:.items.
+type Mode struct {
+	Kind string
+	// Path is the path in each document of concat, count and sum, e.g. .items, or the script
+	// of reduce.
+	Path string
+}
+
+// String returns the mode as it is written.
+func (m Mode) String() string {
+	if m.Path == "" {
+		return m.Kind
+	}
+	return m.Kind + ":" + m.Path
+}
+
+// Parse parses a mode such as concat:.items, count, merge, sum:.total or reduce:script.lua.
+func Parse(spec string) (Mode, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+	mode := Mode{Kind: kind, Path: path}
+
+	switch kind {
+	case Concat, Sum, Reduce:
+		if path == "" {
+			return Mode{}, fmt.Errorf("invalid aggregation %q: %s needs a %s", spec, kind, argument(kind))
+		}
+	case Count:
+	case Merge:
+		if path != "" {
+			return Mode{}, fmt.Errorf("invalid aggregation %q: merge takes no path", spec)
+		}
+	default:
+		return Mode{}, fmt.Errorf("invalid aggregation %q: expected concat:<path>, count[:<path>], merge, sum:<path> or reduce:<script.lua>", spec)
+	}
+	return mode, nil
+}
+
+func argument(kind string) string {
+	if kind == Reduce {
+		return "Lua script, e.g. reduce:totals.lua"
+	}
+	return "path, e.g. " + kind + ":.items"
+}
+
+// Apply aggregates documents, as decoded from JSON.
+func (m Mode) Apply(ctx context.Context, documents []any) (any, error) {
+	switch m.Kind {
+	case Concat:
+		combined := []any{}
+		for i, doc := range documents {
+			items, err := m.array(doc, i)
+			if err != nil {
+				return nil, err
+			}
+			combined = append(combined, items...)
+		}
+		return combined, nil
+	case Count:
+		if m.Path == "" {
+			return len(documents), nil
+		}
+		count := 0
+		for i, doc := range documents {
+			items, err := m.array(doc, i)
+			if err != nil {
+				return nil, err
+			}
+			count += len(items)
+		}
+		return count, nil
+	case Sum:
+		var sum float64
+		for i, doc := range documents {
+			value, found := lookup(doc, m.Path)
+			n, isNumber := value.(float64)
+			if !found || !isNumber {
+				return nil, fmt.Errorf("document %d has no number at %s", i+1, m.Path)
+			}
+			sum += n
+		}
+		return sum, nil
+	case Merge:
+		var merged any
+		for _, doc := range documents {
+			merged = merge(merged, doc)
+		}
+		return merged, nil
+	case Reduce:
+		return script.Reduce(ctx, m.Path, documents)
+	}
+	return nil, fmt.Errorf("unknown aggregation %q", m.Kind)
+}
+
+// array returns the array at the path of the mode in the document at index i.
+func (m Mode) array(doc any, i int) ([]any, error) {
+	value, found := lookup(doc, m.Path)
+	items, isArray := value.([]any)
+	if !found || (!isArray && value != nil) {
+		return nil, fmt.Errorf("document %d has no array at %s", i+1, m.Path)
+	}
+	return items, nil
+}
+
+// lookup follows a path such as .items or .data.rows[0] through doc; . is the whole document.
+func lookup(doc any, path string) (any, bool) {
+	return workflow.Lookup(doc, strings.TrimPrefix(path, "."))
+}
+
+// merge merges b into a: objects key by key, arrays by concatenation, and otherwise b wins.
+func merge(a, b any) any {
+	switch bv := b.(type) {
+	case map[string]any:
+		av, ok := a.(map[string]any)
+		if !ok {
+			av = map[string]any{}
+		}
+		merged := make(map[string]any, len(av)+len(bv))
+		for key, value := range av {
+			merged[key] = value
+		}
+		for key, value := range bv {
+			merged[key] = merge(merged[key], value)
+		}
+		return merged
+	case []any:
+		if av, ok := a.([]any); ok {
+			return append(append([]any{}, av...), bv...)
+		}
+	}
+	return b
+}
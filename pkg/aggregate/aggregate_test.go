@@ -0,0 +1,88 @@
+package aggregate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func pages() []any {
+	return []any{
+		map[string]any{"items": []any{"a", "b"}, "total": float64(2), "meta": map[string]any{"source": "p1"}},
+		map[string]any{"items": []any{"c"}, "total": float64(1), "meta": map[string]any{"page": float64(2)}},
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		spec string
+		want any
+	}{
+		{"concat:.items", []any{"a", "b", "c"}},
+		{"count", 2},
+		{"count:.items", 3},
+		{"sum:.total", float64(3)},
+		{"merge", map[string]any{
+			"items": []any{"a", "b", "c"},
+			"total": float64(1),
+			"meta":  map[string]any{"source": "p1", "page": float64(2)},
+		}},
+	}
+	for _, tt := range tests {
+		mode, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+		}
+		got, err := mode.Apply(context.Background(), pages())
+		if err != nil {
+			t.Fatalf("%s: Apply() error = %v", tt.spec, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Apply() = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestApplyMissingPath(t *testing.T) {
+	for _, spec := range []string{"concat:.rows", "sum:.items"} {
+		mode, _ := Parse(spec)
+		if _, err := mode.Apply(context.Background(), pages()); err == nil {
+			t.Errorf("%s: Apply() succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "concat", "sum:", "merge:.items", "reduce", "average:.total"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestReduce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reduce.lua")
+	source := `function reduce(acc, doc, index)
+  acc = acc or {pages = 0, items = 0}
+  acc.pages = index
+  acc.items = acc.items + #doc.items
+  return acc
+end`
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mode, err := Parse("reduce:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := mode.Apply(context.Background(), pages())
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if want := map[string]any{"pages": float64(2), "items": float64(3)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+}
@@ -4,21 +4,83 @@ Package alias implements server alias functionality for MCP.
 package alias
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/f/mcptools/pkg/configfile"
 )
 
+// AllTools is the Defaults key whose parameters apply to every tool of a server.
+const AllTools = "*"
+
 // ServerAlias represents a single server command alias.
 type ServerAlias struct {
-	Command string `json:"command"`
+	// Defaults maps tool names, or AllTools, to parameters filled in when a call leaves them out.
+	Defaults map[string]map[string]any `json:"defaults,omitempty"`
+	// SLOs maps tool names, or AllTools, to the latency calls should stay under, e.g. "500ms".
+	SLOs    map[string]string `json:"slo,omitempty"`
+	Command string            `json:"command"`
+	// Mirrors are commands or URLs of equivalent servers, tried after Command when it cannot be
+	// reached, or with Race, at the same time as it.
+	Mirrors []string `json:"mirrors,omitempty"`
+	// Race makes clients connect to Command and Mirrors at once and use the first to answer.
+	Race bool `json:"race,omitempty"`
+}
+
+// Endpoints returns the command of the alias followed by its mirrors.
+func (a ServerAlias) Endpoints() []string {
+	return append([]string{a.Command}, a.Mirrors...)
+}
+
+// SLO returns the latency objective of tool, or 0 if it has none. The tool's own SLO wins over
+// the AllTools one, and SLOs that are not valid durations are ignored.
+func (a ServerAlias) SLO(tool string) time.Duration {
+	for _, key := range []string{tool, AllTools} {
+		if value, ok := a.SLOs[key]; ok {
+			if slo, err := ParseSLO(value); err == nil {
+				return slo
+			}
+		}
+	}
+	return 0
+}
+
+// ParseSLO parses a latency objective, a duration of at least a millisecond such as 500ms or 2s.
+func ParseSLO(value string) (time.Duration, error) {
+	slo, err := time.ParseDuration(value)
+	if err != nil || slo < time.Millisecond {
+		return 0, fmt.Errorf("invalid SLO %q: expected a duration of at least 1ms such as 500ms or 2s", value)
+	}
+	return slo, nil
+}
+
+// ApplyDefaults returns params with the alias's default parameters for tool filled in.
+// Parameters given explicitly always win, and tool-specific defaults win over AllTools ones.
+func (a ServerAlias) ApplyDefaults(tool string, params map[string]any) map[string]any {
+	if len(a.Defaults[tool]) == 0 && len(a.Defaults[AllTools]) == 0 {
+		return params
+	}
+
+	merged := make(map[string]any, len(params))
+	for _, defaults := range []map[string]any{a.Defaults[AllTools], a.Defaults[tool]} {
+		for key, value := range defaults {
+			merged[key] = value
+		}
+	}
+	for key, value := range params {
+		merged[key] = value
+	}
+
+	return merged
 }
 
 // Aliases stores command aliases for MCP servers.
 type Aliases map[string]ServerAlias
 
-// GetConfigPath returns the path to the aliases configuration file.
+// GetConfigPath returns the path to the aliases configuration file: aliases.json, or
+// aliases.yaml or aliases.toml if the aliases are kept in YAML or TOML.
 func GetConfigPath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -31,7 +93,7 @@ func GetConfigPath() (string, error) {
 		return "", fmt.Errorf("failed to create config directory: %w", mkdirErr)
 	}
 
-	return filepath.Join(configDir, "aliases.json"), nil
+	return configfile.Find(configDir, "aliases")
 }
 
 // Load loads server aliases from the configuration file.
@@ -57,7 +119,7 @@ func Load() (Aliases, error) {
 		return aliases, nil
 	}
 
-	if unmarshalErr := json.Unmarshal(configFile, &aliases); unmarshalErr != nil {
+	if unmarshalErr := configfile.Unmarshal(configFile, configfile.FormatOf(configPath), &aliases); unmarshalErr != nil {
 		return nil, fmt.Errorf("failed to parse alias config file: %w", unmarshalErr)
 	}
 
@@ -71,12 +133,14 @@ func Save(aliases Aliases) error {
 		return err
 	}
 
-	configJSON, err := json.MarshalIndent(aliases, "", "  ")
+	// Keep the comments of hand-edited YAML files
+	previous, _ := os.ReadFile(configPath) // #nosec G304 - configPath is generated internally by GetConfigPath
+	configData, err := configfile.Update(previous, aliases, configfile.FormatOf(configPath))
 	if err != nil {
 		return fmt.Errorf("failed to marshal alias config: %w", err)
 	}
 
-	writeErr := os.WriteFile(configPath, configJSON, 0o600) // #nosec G304 - configPath is generated internally by GetConfigPath
+	writeErr := os.WriteFile(configPath, configData, 0o600) // #nosec G304 - configPath is generated internally by GetConfigPath
 	if writeErr != nil {
 		return fmt.Errorf("failed to write alias config file: %w", writeErr)
 	}
@@ -84,9 +148,21 @@ func Save(aliases Aliases) error {
 	return nil
 }
 
-// GetServerCommand retrieves the server command for a given alias.
+// Get retrieves a registered alias, local or published in the alias registry.
+func Get(aliasName string) (ServerAlias, bool) {
+	aliases, _, err := LoadAll(os.Stderr)
+	if err != nil {
+		return ServerAlias{}, false
+	}
+
+	alias, exists := aliases[aliasName]
+	return alias, exists
+}
+
+// GetServerCommand retrieves the server command for a given alias, local or published in the
+// alias registry.
 func GetServerCommand(aliasName string) (string, bool) {
-	aliases, err := Load()
+	aliases, _, err := LoadAll(os.Stderr)
 	if err != nil {
 		return "", false
 	}
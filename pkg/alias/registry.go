@@ -0,0 +1,408 @@
+package alias
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/configfile"
+)
+
+// registry backends.
+const (
+	BackendConsul = "consul"
+	BackendEtcd   = "etcd"
+)
+
+// DefaultPrefix is the key prefix aliases are published under when none is configured.
+const DefaultPrefix = "mcptools/aliases/"
+
+// DefaultRefresh is how long fetched aliases are used before the registry is asked again.
+const DefaultRefresh = 5 * time.Minute
+
+// Registry configures a remote key-value store that publishes aliases for an organization.
+// Every key under Prefix is an alias named after the rest of the key. Its value is either a
+// server command or a ServerAlias object.
+type Registry struct {
+	Backend string `json:"backend"`
+	Address string `json:"address"`
+	Prefix  string `json:"prefix,omitempty"`
+	Token   string `json:"token,omitempty"`
+	// Refresh is how long fetched aliases are cached, e.g. "10m". It defaults to DefaultRefresh.
+	Refresh string `json:"refresh,omitempty"`
+}
+
+// registryCache holds the aliases last fetched from the registry.
+type registryCache struct {
+	Fetched  time.Time `json:"fetched"`
+	Registry Registry  `json:"registry"`
+	Aliases  Aliases   `json:"aliases"`
+}
+
+// httpClient is used to fetch aliases from registries.
+var httpClient = &http.Client{Timeout: 5 * time.Second}
+
+func configFile(name string) (string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), name), nil
+}
+
+// GetRegistryPath returns the path to the registry configuration file: registry.json, or
+// registry.yaml or registry.toml if it is kept in YAML or TOML.
+func GetRegistryPath() (string, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return configfile.Find(filepath.Dir(configPath), "registry")
+}
+
+// LoadRegistry loads the registry configuration. It returns nil if no registry is configured.
+func LoadRegistry() (*Registry, error) {
+	path, err := GetRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - path is generated internally by GetRegistryPath
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry config file: %w", err)
+	}
+
+	var registry Registry
+	if err = configfile.Unmarshal(data, configfile.FormatOf(path), &registry); err != nil {
+		return nil, fmt.Errorf("failed to parse registry config file: %w", err)
+	}
+	return &registry, nil
+}
+
+// SaveRegistry saves the registry configuration, or removes it if registry is nil.
+func SaveRegistry(registry *Registry) error {
+	path, err := GetRegistryPath()
+	if err != nil {
+		return err
+	}
+
+	if registry == nil {
+		if removeErr := os.Remove(path); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove registry config file: %w", removeErr)
+		}
+		return clearCache()
+	}
+
+	if err = registry.validate(); err != nil {
+		return err
+	}
+	previous, _ := os.ReadFile(path) // #nosec G304 - path is generated internally by GetRegistryPath
+	data, err := configfile.Update(previous, registry, configfile.FormatOf(path))
+	if err != nil {
+		return fmt.Errorf("failed to marshal registry config: %w", err)
+	}
+	if writeErr := os.WriteFile(path, data, 0o600); writeErr != nil {
+		return fmt.Errorf("failed to write registry config file: %w", writeErr)
+	}
+	return clearCache()
+}
+
+func (r Registry) validate() error {
+	if r.Backend != BackendConsul && r.Backend != BackendEtcd {
+		return fmt.Errorf("unknown registry backend: %s (supported: %s, %s)", r.Backend, BackendConsul, BackendEtcd)
+	}
+	if !strings.HasPrefix(r.Address, "http://") && !strings.HasPrefix(r.Address, "https://") {
+		return fmt.Errorf("registry address must be an http:// or https:// URL: %s", r.Address)
+	}
+	if _, err := r.refresh(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r Registry) prefix() string {
+	if r.Prefix == "" {
+		return DefaultPrefix
+	}
+	return r.Prefix
+}
+
+func (r Registry) refresh() (time.Duration, error) {
+	if r.Refresh == "" {
+		return DefaultRefresh, nil
+	}
+	d, err := time.ParseDuration(r.Refresh)
+	if err != nil {
+		return 0, fmt.Errorf("invalid registry refresh interval: %w", err)
+	}
+	return d, nil
+}
+
+// Fetch reads the aliases published in the registry.
+func (r Registry) Fetch() (Aliases, error) {
+	var entries map[string][]byte
+	var err error
+	switch r.Backend {
+	case BackendConsul:
+		entries, err = r.fetchConsul()
+	case BackendEtcd:
+		entries, err = r.fetchEtcd()
+	default:
+		err = r.validate()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make(Aliases, len(entries))
+	for key, value := range entries {
+		name := strings.TrimPrefix(key, r.prefix())
+		if name == "" || strings.Contains(name, "/") {
+			continue
+		}
+
+		var a ServerAlias
+		if json.Unmarshal(value, &a) != nil || a.Command == "" {
+			a = ServerAlias{Command: strings.TrimSpace(string(value))}
+		}
+		if a.Command != "" {
+			aliases[name] = a
+		}
+	}
+	return aliases, nil
+}
+
+// fetchConsul reads the keys under the prefix with Consul's KV API.
+func (r Registry) fetchConsul() (map[string][]byte, error) {
+	req, err := http.NewRequest(http.MethodGet,
+		strings.TrimSuffix(r.Address, "/")+"/v1/kv/"+strings.TrimPrefix(r.prefix(), "/")+"?recurse=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.Token != "" {
+		req.Header.Set("X-Consul-Token", r.Token)
+	}
+
+	var pairs []struct {
+		Key   string
+		Value []byte
+	}
+	if err = r.do(req, &pairs); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string][]byte, len(pairs))
+	for _, pair := range pairs {
+		entries[pair.Key] = pair.Value
+	}
+	return entries, nil
+}
+
+// fetchEtcd reads the keys under the prefix with the JSON gateway of etcd's v3 API.
+func (r Registry) fetchEtcd() (map[string][]byte, error) {
+	prefix := []byte(r.prefix())
+	body, err := json.Marshal(map[string][]byte{"key": prefix, "range_end": prefixEnd(prefix)})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(r.Address, "/")+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.Token != "" {
+		req.Header.Set("Authorization", r.Token)
+	}
+
+	var response struct {
+		Kvs []struct {
+			Key   []byte `json:"key"`
+			Value []byte `json:"value"`
+		} `json:"kvs"`
+	}
+	if err = r.do(req, &response); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string][]byte, len(response.Kvs))
+	for _, kv := range response.Kvs {
+		entries[string(kv.Key)] = kv.Value
+	}
+	return entries, nil
+}
+
+// prefixEnd returns the smallest key greater than every key starting with prefix, which etcd
+// takes as the end of a prefix range.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// Every byte is 0xff, so the range runs to the end of the keyspace
+	return []byte{0}
+}
+
+func (r Registry) do(req *http.Request, v any) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s registry: %w", r.Backend, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Consul answers 404 when no key has the prefix
+	if resp.StatusCode == http.StatusNotFound && r.Backend == BackendConsul {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s registry returned status %d: %s", r.Backend, resp.StatusCode, bytes.TrimSpace(body))
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s registry response: %w", r.Backend, err)
+	}
+	return nil
+}
+
+func getCachePath() (string, error) {
+	return configFile("registry-cache.json")
+}
+
+func clearCache() error {
+	path, err := getCachePath()
+	if err != nil {
+		return err
+	}
+	if removeErr := os.Remove(path); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove registry cache: %w", removeErr)
+	}
+	return nil
+}
+
+// LoadRemote returns the aliases published in the configured registry, or nil if none is
+// configured. Fetched aliases are cached for the registry's refresh interval. If the registry
+// cannot be reached, the cached aliases are used however old they are, and warnings, if not
+// nil, is told so.
+func LoadRemote(warnings io.Writer) (Aliases, error) {
+	registry, err := LoadRegistry()
+	if err != nil || registry == nil {
+		return nil, err
+	}
+	refresh, err := registry.refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath, err := getCachePath()
+	if err != nil {
+		return nil, err
+	}
+	var cache registryCache
+	cached := false
+	if data, readErr := os.ReadFile(cachePath); readErr == nil { // #nosec G304 - cachePath is generated internally
+		cached = json.Unmarshal(data, &cache) == nil && cache.Registry == *registry
+	}
+	if cached && time.Since(cache.Fetched) < refresh {
+		return cache.Aliases, nil
+	}
+
+	return refreshCache(*registry, cachePath, cache, cached, warnings)
+}
+
+// Sync fetches the aliases of the configured registry now, regardless of the cache.
+func Sync() (Aliases, error) {
+	registry, err := LoadRegistry()
+	if err != nil {
+		return nil, err
+	}
+	if registry == nil {
+		return nil, errors.New("no alias registry is configured")
+	}
+	cachePath, err := getCachePath()
+	if err != nil {
+		return nil, err
+	}
+	return refreshCache(*registry, cachePath, registryCache{}, false, nil)
+}
+
+func refreshCache(registry Registry, cachePath string, cache registryCache, cached bool, warnings io.Writer) (Aliases, error) {
+	aliases, err := registry.Fetch()
+	if err != nil {
+		if !cached {
+			return nil, err
+		}
+		if warnings != nil {
+			fmt.Fprintf(warnings, "Warning: %v; using aliases cached %s\n", err, cache.Fetched.Format(time.RFC3339))
+		}
+		return cache.Aliases, nil
+	}
+
+	data, err := json.MarshalIndent(registryCache{Fetched: time.Now(), Registry: registry, Aliases: aliases}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(cachePath, data, 0o600)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write registry cache: %w", err)
+	}
+	return aliases, nil
+}
+
+// Source tells where an alias returned by LoadAll is defined.
+type Source string
+
+// alias sources.
+const (
+	SourceLocal    Source = "local"
+	SourceRegistry Source = "registry"
+)
+
+// LoadAll returns the local aliases together with those of the configured registry, and where
+// each one comes from. Local aliases win over registry aliases of the same name. Problems
+// reaching the registry are reported on warnings and leave only the local aliases.
+func LoadAll(warnings io.Writer) (Aliases, map[string]Source, error) {
+	aliases, err := Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sources := make(map[string]Source, len(aliases))
+	for name := range aliases {
+		sources[name] = SourceLocal
+	}
+
+	remote, err := LoadRemote(warnings)
+	if err != nil && warnings != nil {
+		fmt.Fprintf(warnings, "Warning: alias registry unavailable: %v\n", err)
+	}
+	for name, a := range remote {
+		if _, local := aliases[name]; !local {
+			aliases[name] = a
+			sources[name] = SourceRegistry
+		}
+	}
+	return aliases, sources, nil
+}
+
+// Names returns the names of aliases in sorted order.
+func (a Aliases) Names() []string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,110 @@
+package alias
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/kv/mcptools/aliases/":
+			if r.Header.Get("X-Consul-Token") != "secret" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"Key": "mcptools/aliases/fs", "Value": []byte("npx -y @modelcontextprotocol/server-filesystem ~")},
+				{"Key": "mcptools/aliases/gh", "Value": []byte(`{"command":"gh-mcp","defaults":{"*":{"owner":"acme"}}}`)},
+				{"Key": "mcptools/aliases/nested/x", "Value": []byte("ignored")},
+			})
+		case "/v3/kv/range":
+			var request map[string][]byte
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			if string(request["key"]) != "/mcp/" || string(request["range_end"]) != "/mcp0" {
+				t.Errorf("etcd range = %q..%q", request["key"], request["range_end"])
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"kvs": []map[string]any{
+				{"key": []byte("/mcp/fs"), "value": []byte("mcp-fs")},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	aliases, err := Registry{Backend: BackendConsul, Address: server.URL, Token: "secret"}.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch() from Consul error = %v", err)
+	}
+	if len(aliases) != 2 || aliases["fs"].Command != "npx -y @modelcontextprotocol/server-filesystem ~" ||
+		aliases["gh"].Command != "gh-mcp" || aliases["gh"].Defaults["*"]["owner"] != "acme" {
+		t.Errorf("Consul aliases = %+v", aliases)
+	}
+
+	aliases, err = Registry{Backend: BackendEtcd, Address: server.URL, Prefix: "/mcp/"}.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch() from etcd error = %v", err)
+	}
+	if len(aliases) != 1 || aliases["fs"].Command != "mcp-fs" {
+		t.Errorf("etcd aliases = %+v", aliases)
+	}
+
+	// Consul answers 404 for an empty prefix
+	if aliases, err = (Registry{Backend: BackendConsul, Address: server.URL, Prefix: "none/"}).Fetch(); err != nil || len(aliases) != 0 {
+		t.Errorf("Fetch() of an empty prefix = %v, %v", aliases, err)
+	}
+}
+
+func TestLoadAllUsesCacheWhenRegistryIsDown(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	up := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"Key": "mcptools/aliases/fs", "Value": []byte("remote-fs")},
+			{"Key": "mcptools/aliases/gh", "Value": []byte("remote-gh")},
+		})
+	}))
+	defer server.Close()
+
+	if err := Save(Aliases{"gh": {Command: "local-gh"}}); err != nil {
+		t.Fatal(err)
+	}
+	// Refresh on every load, so the second load has to reach the registry
+	if err := SaveRegistry(&Registry{Backend: BackendConsul, Address: server.URL, Refresh: "0s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, state := range []bool{true, false} {
+		up = state
+		var warnings bytes.Buffer
+		aliases, sources, err := LoadAll(&warnings)
+		if err != nil {
+			t.Fatalf("LoadAll() error = %v", err)
+		}
+		if aliases["fs"].Command != "remote-fs" || sources["fs"] != SourceRegistry {
+			t.Errorf("registry up=%v: fs = %+v from %s", up, aliases["fs"], sources["fs"])
+		}
+		if aliases["gh"].Command != "local-gh" || sources["gh"] != SourceLocal {
+			t.Errorf("registry up=%v: the local gh alias should win, got %+v", up, aliases["gh"])
+		}
+		if !up && !strings.Contains(warnings.String(), "using aliases cached") {
+			t.Errorf("expected a warning about the cache, got %q", warnings.String())
+		}
+	}
+
+	// Local aliases are saved without the registry's
+	local, err := Load()
+	if err != nil || len(local) != 1 {
+		t.Errorf("Load() = %v, %v", local, err)
+	}
+}
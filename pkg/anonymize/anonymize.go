@@ -0,0 +1,217 @@
+// Package anonymize replaces personal data such as email addresses, IP addresses and names in
+// exported diagnostics, so they can be shared outside the organization that produced them.
+//
+// Every distinct value is replaced by a stable placeholder such as [email-1], so the same user
+// or host can still be followed through an export without revealing who or what it is.
+package anonymize
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	ipv4Pattern  = regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)
+	// ipv6Pattern finds candidate IPv6 addresses, which are checked with net.ParseIP so that
+	// times such as 12:30:45 are left alone.
+	ipv6Pattern = regexp.MustCompile(`[0-9A-Fa-f]{0,4}:[0-9A-Fa-f]{0,4}:[0-9A-Fa-f:]*`)
+)
+
+// Pattern is a custom rule replacing the matches of a regular expression.
+type Pattern struct {
+	Pattern string `json:"pattern"`
+	// Label names the placeholders, e.g. "ticket" for [ticket-1]. It defaults to "redacted".
+	Label string `json:"label,omitempty"`
+}
+
+// Rules configures what is anonymized.
+type Rules struct {
+	Emails *bool `json:"emails,omitempty"`
+	IPs    *bool `json:"ips,omitempty"`
+	// Names are replaced wherever they appear as whole words, ignoring case.
+	Names []string `json:"names,omitempty"`
+	// NamesFile is a file with one name per line, e.g. exported from a directory or an
+	// NER tool. Relative paths are relative to the rules file.
+	NamesFile string    `json:"namesFile,omitempty"`
+	Patterns  []Pattern `json:"patterns,omitempty"`
+}
+
+// GetRulesPath returns the path of the default rules file.
+func GetRulesPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "anonymize.json"), nil
+}
+
+// LoadRules reads rules from path. A missing file at the default path is not an error: emails
+// and IP addresses are then anonymized.
+func LoadRules(path string) (Rules, error) {
+	var rules Rules
+	if path == "" {
+		var err error
+		if path, err = GetRulesPath(); err != nil {
+			return rules, err
+		}
+		if _, err = os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return rules, nil
+		}
+	}
+
+	// #nosec G304 - the rules path is provided by the user or generated internally
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, fmt.Errorf("failed to read anonymization rules: %w", err)
+	}
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("failed to parse anonymization rules: %w", err)
+	}
+
+	if rules.NamesFile != "" {
+		namesPath := rules.NamesFile
+		if !filepath.IsAbs(namesPath) {
+			namesPath = filepath.Join(filepath.Dir(path), namesPath)
+		}
+		// #nosec G304 - the names file is configured by the user
+		names, readErr := os.ReadFile(namesPath)
+		if readErr != nil {
+			return rules, fmt.Errorf("failed to read names file: %w", readErr)
+		}
+		for _, name := range strings.Split(string(names), "\n") {
+			if name = strings.TrimSpace(name); name != "" && !strings.HasPrefix(name, "#") {
+				rules.Names = append(rules.Names, name)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// rule replaces the matches of a pattern with placeholders labelled label.
+type rule struct {
+	pattern *regexp.Regexp
+	valid   func(match string) bool
+	label   string
+}
+
+// Anonymizer applies rules. Placeholders are numbered per label in the order values are seen
+// and stay the same for the lifetime of the Anonymizer.
+type Anonymizer struct {
+	placeholders map[string]string
+	counts       map[string]int
+	rules        []rule
+	mu           sync.Mutex
+}
+
+// New compiles rules into an Anonymizer.
+func New(rules Rules) (*Anonymizer, error) {
+	a := &Anonymizer{placeholders: map[string]string{}, counts: map[string]int{}}
+
+	// Emails go first, so that names are not replaced inside of them
+	if rules.Emails == nil || *rules.Emails {
+		a.rules = append(a.rules, rule{pattern: emailPattern, label: "email"})
+	}
+	if rules.IPs == nil || *rules.IPs {
+		a.rules = append(a.rules,
+			rule{pattern: ipv4Pattern, label: "ip"},
+			rule{pattern: ipv6Pattern, label: "ip", valid: func(match string) bool { return net.ParseIP(match) != nil }})
+	}
+
+	for _, p := range rules.Patterns {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid anonymization pattern %q: %w", p.Pattern, err)
+		}
+		label := p.Label
+		if label == "" {
+			label = "redacted"
+		}
+		a.rules = append(a.rules, rule{pattern: re, label: label})
+	}
+
+	if len(rules.Names) > 0 {
+		// Longer names first, so "Ann Lee" is replaced as a whole rather than as "Ann"
+		names := append([]string(nil), rules.Names...)
+		sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = regexp.QuoteMeta(name)
+		}
+		re := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+		a.rules = append(a.rules, rule{pattern: re, label: "name"})
+	}
+
+	return a, nil
+}
+
+// String returns s with every match of the rules replaced by its placeholder.
+func (a *Anonymizer) String(s string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, r := range a.rules {
+		s = r.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if r.valid != nil && !r.valid(match) {
+				return match
+			}
+			return a.placeholder(r.label, match)
+		})
+	}
+	return s
+}
+
+// Value returns a copy of a decoded JSON value with every string in it anonymized.
+func (a *Anonymizer) Value(value any) any {
+	switch v := value.(type) {
+	case string:
+		return a.String(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = a.Value(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = a.Value(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// JSON anonymizes a JSON document.
+func (a *Anonymizer) JSON(data json.RawMessage) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(a.Value(value))
+}
+
+// placeholder returns the placeholder of a value. The caller holds the lock.
+func (a *Anonymizer) placeholder(label, value string) string {
+	key := label + "\x00" + strings.ToLower(value)
+	if p, ok := a.placeholders[key]; ok {
+		return p
+	}
+	a.counts[label]++
+	p := fmt.Sprintf("[%s-%d]", label, a.counts[label])
+	a.placeholders[key] = p
+	return p
+}
@@ -0,0 +1,74 @@
+package anonymize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnonymizerString(t *testing.T) {
+	a, err := New(Rules{
+		Names:    []string{"Ann", "Ann Lee"},
+		Patterns: []Pattern{{Pattern: `ACME-\d+`, Label: "ticket"}},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"mail ann.lee@acme.com or bob@acme.com", "mail [email-1] or [email-2]"},
+		{"ANN.LEE@acme.com again", "[email-1] again"},
+		{"from 10.0.0.12 and fe80::1:2 at 12:30", "from [ip-1] and [ip-2] at 12:30"},
+		{"Ann Lee and ann filed ACME-42", "[name-1] and [name-2] filed [ticket-1]"},
+		{"Annabel on v1.2.3", "Annabel on v1.2.3"},
+	}
+	for _, tt := range tests {
+		if got := a.String(tt.in); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAnonymizerJSON(t *testing.T) {
+	off := false
+	a, err := New(Rules{IPs: &off})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := a.JSON([]byte(`{"user":"a@b.io","host":"10.0.0.1","n":3,"list":["a@b.io"]}`))
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	want := `{"host":"10.0.0.1","list":["[email-1]"],"n":3,"user":"[email-1]"}`
+	if string(got) != want {
+		t.Errorf("JSON() = %s, want %s", got, want)
+	}
+}
+
+func TestLoadRulesNamesFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "names.txt"), []byte("# staff\nGrace Hopper\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "rules.json")
+	if err := os.WriteFile(path, []byte(`{"names":["Alan"],"namesFile":"names.txt"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if len(rules.Names) != 2 || rules.Names[1] != "Grace Hopper" {
+		t.Errorf("Names = %q", rules.Names)
+	}
+
+	t.Setenv("HOME", t.TempDir())
+	if _, err = LoadRules(""); err != nil {
+		t.Errorf("LoadRules() without a rules file: error = %v", err)
+	}
+}
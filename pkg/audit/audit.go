@@ -0,0 +1,262 @@
+/*
+Package audit implements a tamper-evident, append-only log of JSON records. Each record is
+chained to the one before it by a SHA-256 hash and can be signed with a secret key, so edits,
+insertions and removals are detected by Verify.
+*/
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Fields added to every record.
+const (
+	FieldSeq  = "seq"
+	FieldPrev = "prev"
+	FieldHash = "hash"
+	FieldSig  = "sig"
+)
+
+// ErrTampered is wrapped by the errors Verify returns for a broken chain or signature.
+var ErrTampered = errors.New("audit log has been tampered with")
+
+// Writer appends chained records to a log. Each line written to it must be a JSON object; it
+// is written with the fields seq, prev, hash and, with a key, sig added.
+type Writer struct {
+	w    io.Writer
+	key  []byte
+	prev string
+	seq  int64
+	mu   sync.Mutex
+}
+
+// NewWriter starts a new chain on w. Records are signed if key is not empty.
+func NewWriter(w io.Writer, key []byte) *Writer {
+	return &Writer{w: w, key: key}
+}
+
+// File is a Writer appending to a file.
+type File struct {
+	*Writer
+	file *os.File
+}
+
+// OpenFile opens the log at path for appending, continuing the chain of its last record.
+func OpenFile(path string, key []byte) (*File, error) {
+	// #nosec G304 - the audit log path is provided by the user or generated internally
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	w := NewWriter(file, key)
+	last, err := lastLine(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if len(last) > 0 {
+		var tail struct {
+			Hash string `json:"hash"`
+			Seq  int64  `json:"seq"`
+		}
+		if err = json.Unmarshal(last, &tail); err != nil || tail.Hash == "" {
+			_ = file.Close()
+			return nil, fmt.Errorf("%s does not end with a chained record; rotate it before appending", path)
+		}
+		w.prev, w.seq = tail.Hash, tail.Seq
+	}
+
+	return &File{Writer: w, file: file}, nil
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	return f.file.Close()
+}
+
+// Write implements io.Writer. p holds one or more newline-terminated JSON objects.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal(line, &record); err != nil {
+			return 0, fmt.Errorf("audit record is not a JSON object: %w", err)
+		}
+
+		seq, _ := json.Marshal(w.seq + 1)
+		prev, _ := json.Marshal(w.prev)
+		record[FieldSeq] = seq
+		record[FieldPrev] = prev
+		delete(record, FieldHash)
+		delete(record, FieldSig)
+
+		hash, err := recordHash(record)
+		if err != nil {
+			return 0, err
+		}
+		record[FieldHash], _ = json.Marshal(hash)
+		if len(w.key) > 0 {
+			record[FieldSig], _ = json.Marshal(sign(w.key, hash))
+		}
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return 0, err
+		}
+		if _, err = w.w.Write(append(data, '\n')); err != nil {
+			return 0, err
+		}
+		w.seq++
+		w.prev = hash
+	}
+
+	return len(p), nil
+}
+
+// Result summarizes a verified log.
+type Result struct {
+	// Head is the hash of the last record. Recording it elsewhere also detects removal of
+	// records from the end of the log.
+	Head    string
+	Records int64
+	Signed  bool
+}
+
+// Verify checks the chain of every record read from r, and their signatures if key is not
+// empty. It stops at the first broken record.
+func Verify(r io.Reader, key []byte) (Result, error) {
+	var result Result
+	reader := bufio.NewReader(r)
+
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			if verifyErr := result.verify(data, key); verifyErr != nil {
+				return result, fmt.Errorf("line %d: %w", line, verifyErr)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+}
+
+func (r *Result) verify(line, key []byte) error {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(line, &record); err != nil {
+		return fmt.Errorf("%w: not a JSON object", ErrTampered)
+	}
+
+	var (
+		seq        int64
+		prev, hash string
+		sig        string
+	)
+	if json.Unmarshal(record[FieldSeq], &seq) != nil || json.Unmarshal(record[FieldPrev], &prev) != nil ||
+		json.Unmarshal(record[FieldHash], &hash) != nil {
+		return fmt.Errorf("%w: record is not chained", ErrTampered)
+	}
+	if seq != r.Records+1 {
+		return fmt.Errorf("%w: expected record %d, found %d", ErrTampered, r.Records+1, seq)
+	}
+	if prev != r.Head {
+		return fmt.Errorf("%w: record %d does not follow the previous record", ErrTampered, seq)
+	}
+
+	if raw, ok := record[FieldSig]; ok {
+		_ = json.Unmarshal(raw, &sig)
+	}
+	delete(record, FieldHash)
+	delete(record, FieldSig)
+
+	want, err := recordHash(record)
+	if err != nil {
+		return err
+	}
+	if hash != want {
+		return fmt.Errorf("%w: record %d was modified", ErrTampered, seq)
+	}
+	if len(key) > 0 {
+		if sig == "" {
+			return fmt.Errorf("%w: record %d is not signed", ErrTampered, seq)
+		}
+		if !hmac.Equal([]byte(sig), []byte(sign(key, hash))) {
+			return fmt.Errorf("%w: record %d has an invalid signature", ErrTampered, seq)
+		}
+		r.Signed = true
+	}
+
+	r.Records = seq
+	r.Head = hash
+	return nil
+}
+
+// recordHash hashes a record without its hash and signature. Marshalling a map sorts its keys,
+// so the hash does not depend on field order.
+func recordHash(record map[string]json.RawMessage) (string, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func sign(key []byte, hash string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(hash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LoadKey reads a signing key from a file, ignoring surrounding whitespace.
+func LoadKey(path string) ([]byte, error) {
+	// #nosec G304 - the key file path is provided explicitly by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit key: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return nil, fmt.Errorf("audit key file %s is empty", path)
+	}
+	return []byte(key), nil
+}
+
+// lastLine returns the last non-empty line of f.
+func lastLine(f *os.File) ([]byte, error) {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return nil, err
+	}
+
+	// Records are small, so reading back a bounded tail is enough to find the last one
+	const tailSize = 1 << 20
+	offset := max(info.Size()-tailSize, 0)
+	buf := make([]byte, info.Size()-offset)
+	if _, err = f.ReadAt(buf, offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	buf = bytes.TrimRight(buf, "\n")
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		buf = buf[i+1:]
+	}
+	return buf, nil
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRecords(t *testing.T, w *Writer, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if _, err := w.Write([]byte(line + "\n")); err != nil {
+			t.Fatalf("Write(%s) error = %v", line, err)
+		}
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	key := []byte("secret")
+	var buf bytes.Buffer
+	writeRecords(t, NewWriter(&buf, key),
+		`{"tenant":"acme","method":"tools/call","target":"read_file"}`,
+		`{"tenant":"acme","method":"tools/call","target":"<delete_file>"}`,
+		`{"tenant":"globex","method":"tools/list"}`,
+	)
+	log := buf.String()
+
+	result, err := Verify(strings.NewReader(log), key)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Records != 3 || !result.Signed || result.Head == "" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	lines := strings.SplitAfter(log, "\n")
+	tests := map[string]string{
+		"edited":    strings.Replace(log, "read_file", "list_dir", 1),
+		"removed":   lines[0] + lines[2],
+		"reordered": lines[1] + lines[0] + lines[2],
+		"inserted":  lines[0] + lines[0] + lines[1] + lines[2],
+	}
+	for name, tampered := range tests {
+		if _, err = Verify(strings.NewReader(tampered), key); !errors.Is(err, ErrTampered) {
+			t.Errorf("%s log: Verify() error = %v, want ErrTampered", name, err)
+		}
+	}
+
+	// Recomputing the hashes of an edited record still breaks the signature
+	if _, err = Verify(strings.NewReader(log), []byte("other")); !errors.Is(err, ErrTampered) {
+		t.Errorf("Verify() with the wrong key: error = %v, want ErrTampered", err)
+	}
+}
+
+func TestOpenFileContinuesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for i := 0; i < 2; i++ {
+		f, err := OpenFile(path, nil)
+		if err != nil {
+			t.Fatalf("OpenFile() error = %v", err)
+		}
+		writeRecords(t, f.Writer, `{"n":1}`, `{"n":2}`)
+		_ = f.Close()
+	}
+
+	data, _ := os.ReadFile(path)
+	result, err := Verify(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Records != 4 || result.Signed {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	if err = os.WriteFile(path, []byte(`{"status":"ok"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = OpenFile(path, nil); err == nil {
+		t.Error("OpenFile() continued a log that is not chained")
+	}
+}
@@ -0,0 +1,189 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+// ErrCircuitOpen is returned for requests not sent to a server because its circuit breaker is
+// open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// ErrUpstreamTimeout is returned for requests a server did not answer within the call timeout.
+var ErrUpstreamTimeout = errors.New("upstream server timed out")
+
+// Default circuit breaker settings.
+const (
+	DefaultBreakerFailures = 5
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// Circuit breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// Breaker isolates a server that keeps failing. Once a number of consecutive requests fail
+// because the server exited or timed out, the breaker opens and requests fail fast until a
+// cooldown is over. Then a single probe request is let through: if it succeeds the breaker
+// closes, otherwise it opens again.
+type Breaker struct {
+	name        string
+	failures    int
+	cooldown    time.Duration
+	state       string
+	consecutive int
+	openedAt    time.Time
+	probing     bool
+	clock       clock.Clock
+	mu          sync.Mutex
+}
+
+// NewBreaker creates a closed breaker for the server called name, timing its cooldown on c, or
+// the wall clock if c is nil. It returns nil, which lets every request through, if failures is
+// not positive.
+func NewBreaker(name string, failures int, cooldown time.Duration, c clock.Clock) *Breaker {
+	if failures <= 0 {
+		return nil
+	}
+	return &Breaker{name: name, failures: failures, cooldown: cooldown, state: BreakerClosed, clock: clock.OrReal(c)}
+}
+
+// State returns the state of the breaker.
+func (b *Breaker) State() string {
+	if b == nil {
+		return BreakerClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.clock.Now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Allow returns an error wrapping ErrCircuitOpen if a request must not be sent. Every allowed
+// request must be followed by a call to Record with its outcome.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		wait := b.cooldown - b.clock.Now().Sub(b.openedAt)
+		if wait > 0 {
+			return fmt.Errorf("%w: %s failed %d times in a row, retrying in %s",
+				ErrCircuitOpen, b.name, b.consecutive, wait.Round(time.Second))
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w: %s failed %d times in a row and is being probed",
+				ErrCircuitOpen, b.name, b.consecutive)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record updates the breaker with the outcome of an allowed request. Only failures of the
+// server count: errors of the request itself, such as a client going away, are ignored.
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	probe := b.probing
+	b.probing = false
+	switch {
+	case err == nil:
+		if b.state != BreakerClosed {
+			fmt.Fprintf(os.Stderr, "bridge: %s recovered, closing its circuit\n", b.name)
+		}
+		b.state = BreakerClosed
+		b.consecutive = 0
+	case errors.Is(err, ErrUpstreamClosed) || errors.Is(err, ErrUpstreamTimeout):
+		b.consecutive++
+		if probe || (b.state == BreakerClosed && b.consecutive >= b.failures) {
+			fmt.Fprintf(os.Stderr, "bridge: %s failed %d times in a row, opening its circuit for %s\n",
+				b.name, b.consecutive, b.cooldown)
+			b.state = BreakerOpen
+			b.openedAt = b.clock.Now()
+		}
+	}
+}
+
+// call sends a request to upstream through its circuit breaker, giving up after the call
+// timeout if one is set.
+func (b *Bridge) call(ctx context.Context, upstream *Upstream, method string, params map[string]any) (*Message, error) {
+	breaker := b.breakers[upstream]
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	callCtx := ctx
+	if b.callTimeout > 0 {
+		var cancel context.CancelCauseFunc
+		callCtx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		// The timeout is timed on the bridge's clock, so tests can run it out without waiting
+		timeout := b.clock.After(b.callTimeout)
+		go func() {
+			select {
+			case <-timeout:
+				cancel(ErrUpstreamTimeout)
+			case <-callCtx.Done():
+			}
+		}()
+	}
+
+	response, err := upstream.Call(callCtx, method, params)
+	if err != nil && ctx.Err() == nil && errors.Is(context.Cause(callCtx), ErrUpstreamTimeout) {
+		err = fmt.Errorf("%w after %s", ErrUpstreamTimeout, b.callTimeout)
+	}
+	breaker.Record(err)
+	return response, err
+}
+
+// writeBreakers writes the state of each circuit breaker in the Prometheus text exposition
+// format.
+func (b *Bridge) writeBreakers(w io.Writer) {
+	var names []string
+	open := map[string]int{}
+	for _, breaker := range b.breakers {
+		if breaker == nil {
+			continue
+		}
+		names = append(names, breaker.name)
+		if breaker.State() != BreakerClosed {
+			open[breaker.name] = 1
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	_, _ = io.WriteString(w, "# HELP mcptools_bridge_circuit_open Whether the circuit breaker of a server is open or half-open.\n")
+	_, _ = io.WriteString(w, "# TYPE mcptools_bridge_circuit_open gauge\n")
+	for _, name := range names {
+		_, _ = fmt.Fprintf(w, "mcptools_bridge_circuit_open{server=\"%s\"} %d\n", escapeLabel(name), open[name])
+	}
+}
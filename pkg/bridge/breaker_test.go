@@ -0,0 +1,150 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+func TestBreaker(t *testing.T) {
+	now := clock.NewFake(time.Unix(0, 0))
+	breaker := NewBreaker("fs", 2, time.Minute, now)
+
+	failure := fmt.Errorf("call: %w", ErrUpstreamClosed)
+	for i := 0; i < 2; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Allow() error = %v before the breaker opened", err)
+		}
+		breaker.Record(failure)
+	}
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("State() = %s after two failures, want open", state)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) || !strings.Contains(err.Error(), "fs failed 2 times in a row") {
+		t.Errorf("Allow() error = %v, want an open circuit naming fs", err)
+	}
+
+	// After the cooldown one probe is let through at a time
+	now.Advance(time.Minute)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() error = %v, want a probe", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Allow() error = %v during a probe, want an open circuit", err)
+	}
+	breaker.Record(failure)
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("State() = %s after a failed probe, want open", state)
+	}
+
+	now.Advance(time.Minute)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() error = %v, want a probe", err)
+	}
+	breaker.Record(nil)
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("State() = %s after a successful probe, want closed", state)
+	}
+}
+
+func TestBreakerIgnoresRequestErrors(t *testing.T) {
+	breaker := NewBreaker("fs", 1, time.Minute, nil)
+	_ = breaker.Allow()
+	breaker.Record(context.Canceled)
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("State() = %s after a cancelled request, want closed", state)
+	}
+
+	if NewBreaker("fs", 0, time.Minute, nil) != nil {
+		t.Error("NewBreaker() with no failure threshold should disable the breaker")
+	}
+}
+
+func TestBridgeOpensCircuit(t *testing.T) {
+	t.Setenv("BRIDGE_TEST_UPSTREAM", "1")
+	upstream, err := StartUpstream(os.Args[0], nil)
+	if err != nil {
+		t.Fatalf("StartUpstream() error = %v", err)
+	}
+	t.Cleanup(func() { _ = upstream.Close() })
+
+	now := clock.NewFake(time.Unix(0, 0))
+	b, err := New(context.Background(), upstream, Options{
+		AuditLog:        &bytes.Buffer{},
+		Keys:            Keys{"key-alice": {Tenant: "acme", User: "alice"}},
+		CallTimeout:     time.Minute,
+		BreakerFailures: 2,
+		BreakerCooldown: time.Hour,
+		Clock:           now,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	server := httptest.NewServer(b)
+	t.Cleanup(server.Close)
+
+	errorMessage := func(msg map[string]any) string {
+		e, _ := msg["error"].(map[string]any)
+		text, _ := e["message"].(string)
+		return text
+	}
+
+	// Each call the server never answers runs out the call timeout once the clock is advanced
+	for i := 0; i < 2; i++ {
+		answered := make(chan map[string]any, 1)
+		go func() {
+			answered <- postUnchecked(server.URL, "key-alice", "", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hang"}}`)
+		}()
+		waitForWaiters(t, now, 1)
+		now.Advance(time.Minute)
+		if got := errorMessage(<-answered); !strings.Contains(got, "timed out") {
+			t.Fatalf("error = %q, want a timeout", got)
+		}
+	}
+
+	// The server would answer echo, so an open circuit means it was never sent
+	_, msg := post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}`)
+	if got := errorMessage(msg); !strings.Contains(got, "circuit open") || !strings.Contains(got, "retrying in 1h0m0s") {
+		t.Errorf("error = %q, want an open circuit", got)
+	}
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	metrics, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if !strings.Contains(string(metrics), `mcptools_bridge_circuit_open{server="upstream server"} 1`) {
+		t.Errorf("metrics do not report the open circuit:\n%s", metrics)
+	}
+
+	// Once the cooldown is over, a successful probe closes the circuit
+	now.Advance(time.Hour)
+	_, msg = post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo"}}`)
+	if msg["result"] == nil {
+		t.Errorf("response = %v, want a result once the circuit closed", msg)
+	}
+}
+
+// waitForWaiters waits until n waits are pending on the fake clock, so it is advanced only once
+// the code under test waits on it.
+func waitForWaiters(t *testing.T, c *clock.Fake, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Waiters() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d waits on the clock, want %d", c.Waiters(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,720 @@
+// Package bridge shares one stdio MCP server between many downstream clients over HTTP. Each
+// client authenticates with an API key, and every request forwarded on its behalf is stamped
+// with the key's tenant and user, which also label the audit log and metrics.
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/admin"
+	"github.com/f/mcptools/pkg/clock"
+)
+
+// maxRequestBytes limits the size of a request body sent by a client.
+const maxRequestBytes = 10 << 20
+
+// longPollWait is how long a long-polling request waits for notifications. It stays below the
+// idle timeouts of common proxies.
+const longPollWait = 25 * time.Second
+
+// resubscribeTimeout bounds each subscription made again for a session from another bridge.
+const resubscribeTimeout = 10 * time.Second
+
+// Request outcomes used in the audit log and metrics.
+const (
+	StatusOK            = "ok"
+	StatusError         = "error"
+	StatusUnauthorized  = "unauthorized"
+	StatusQuotaExceeded = "quota_exceeded"
+	StatusDenied        = "denied"
+	// StatusUnexpected marks egress from a server that was declared local-only.
+	StatusUnexpected = "unexpected"
+)
+
+// AuditRecord is one line of the audit log.
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	Tenant     string    `json:"tenant,omitempty"`
+	User       string    `json:"user,omitempty"`
+	Key        string    `json:"key,omitempty"`
+	Method     string    `json:"method,omitempty"`
+	Target     string    `json:"target,omitempty"`
+	Upstream   string    `json:"upstream,omitempty"`
+	Status     string    `json:"status"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// Options configures a Bridge.
+type Options struct {
+	// AuditLog receives one JSON AuditRecord per line. If nil, nothing is logged.
+	AuditLog io.Writer
+	// Keys lists the API keys clients may use.
+	Keys Keys
+	// Quotas limits the sessions of each role. Roles without a quota are unlimited.
+	Quotas Quotas
+	// Overrides, if set, can deny tools, prompts and resources while the bridge runs.
+	Overrides *admin.Overrides
+	// NotificationBuffer is the number of server notifications queued for each session's
+	// stream. It defaults to notify.DefaultSize.
+	NotificationBuffer int
+	// Canary, if set, receives a share of the calls instead of the upstream server.
+	Canary *Canary
+	// Shadow, if set, receives a copy of every request, and its responses are compared with
+	// those of the upstream server.
+	Shadow *Shadow
+	// CallTimeout bounds how long a server may take to answer a request. Zero means no limit.
+	CallTimeout time.Duration
+	// BreakerFailures is the number of consecutive failures after which requests to a server
+	// fail fast for BreakerCooldown, see Breaker. Zero disables circuit breakers.
+	BreakerFailures int
+	// BreakerCooldown defaults to DefaultBreakerCooldown.
+	BreakerCooldown time.Duration
+	// MaxConcurrent bounds the requests in flight to the server. Once it is reached, requests
+	// wait, and interactive ones are sent before batch ones. Zero means no limit.
+	MaxConcurrent int
+	// BatchTools are patterns of the tools whose calls are batch requests.
+	BatchTools []string
+	// Interleave sends the requests of a session to the server as they come. By default they
+	// are sent one at a time in the order they arrived, while sessions still run in parallel.
+	Interleave bool
+	// Sessions, if set, keeps sessions instead of the bridge's memory, so bridges sharing it
+	// accept each other's sessions.
+	Sessions SessionStore
+	// Analytics, if set, is called with the audit record of every request, e.g. to export a
+	// sample of them for usage analytics.
+	Analytics func(AuditRecord)
+	// Clock times requests, sessions and circuit breakers, and Rand routes calls to the canary.
+	// They default to the wall clock and a randomly seeded source.
+	Clock clock.Clock
+	Rand  clock.Rand
+}
+
+// Bridge is an http.Handler serving the upstream server at /mcp and metrics at /metrics.
+type Bridge struct {
+	upstream    *Upstream
+	audit       io.Writer
+	keys        Keys
+	quotas      Quotas
+	overrides   *admin.Overrides
+	canary      *Canary
+	shadow      *Shadow
+	breakers    map[*Upstream]*Breaker
+	scheduler   *scheduler
+	batchTools  []string
+	interleave  bool
+	analytics   func(AuditRecord)
+	clock       clock.Clock
+	rand        clock.Rand
+	sessions    *sessions
+	metrics     *Metrics
+	mux         *http.ServeMux
+	initial     json.RawMessage
+	buffer      int
+	callTimeout time.Duration
+	draining    chan struct{}
+	drainOnce   sync.Once
+	auditMu     sync.Mutex
+}
+
+// New initializes the upstream server once on behalf of all clients and returns a bridge to it.
+// Canary and shadow servers are initialized too, but clients only see the upstream server's
+// answer.
+func New(ctx context.Context, upstream *Upstream, opts Options) (*Bridge, error) {
+	if len(opts.Keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	for _, pattern := range opts.BatchTools {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid batch tool pattern %q: %w", pattern, err)
+		}
+	}
+
+	initial, err := initialize(ctx, upstream)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Canary != nil {
+		if _, err = initialize(ctx, opts.Canary.Upstream); err != nil {
+			return nil, fmt.Errorf("canary %s: %w", opts.Canary.Name, err)
+		}
+	}
+	if opts.Shadow != nil {
+		if _, err = initialize(ctx, opts.Shadow.Upstream); err != nil {
+			return nil, fmt.Errorf("shadow %s: %w", opts.Shadow.Name, err)
+		}
+		opts.Shadow.slots = make(chan struct{}, shadowConcurrency)
+	}
+
+	b := &Bridge{
+		upstream:    upstream,
+		audit:       opts.AuditLog,
+		keys:        opts.Keys,
+		quotas:      opts.Quotas,
+		overrides:   opts.Overrides,
+		canary:      opts.Canary,
+		shadow:      opts.Shadow,
+		breakers:    map[*Upstream]*Breaker{},
+		scheduler:   newScheduler(opts.MaxConcurrent),
+		batchTools:  opts.BatchTools,
+		interleave:  opts.Interleave,
+		analytics:   opts.Analytics,
+		clock:       clock.OrReal(opts.Clock),
+		rand:        clock.OrSystem(opts.Rand),
+		sessions:    newSessions(opts.Sessions, clock.OrReal(opts.Clock)),
+		metrics:     NewMetrics(),
+		mux:         http.NewServeMux(),
+		initial:     initial,
+		buffer:      opts.NotificationBuffer,
+		callTimeout: opts.CallTimeout,
+		draining:    make(chan struct{}),
+	}
+	cooldown := opts.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	newBreaker := func(name string) *Breaker {
+		return NewBreaker(name, opts.BreakerFailures, cooldown, b.clock)
+	}
+	b.breakers[upstream] = newBreaker("upstream server")
+	if b.canary != nil {
+		b.breakers[b.canary.Upstream] = newBreaker("canary " + b.canary.Name)
+	}
+	if b.shadow != nil {
+		b.breakers[b.shadow.Upstream] = newBreaker("shadow " + b.shadow.Name)
+	}
+	broadcast := func(msg *Message) {
+		if dropped := b.sessions.broadcast(msg); dropped > 0 {
+			b.metrics.DropNotifications(dropped)
+		}
+	}
+	upstream.SetNotificationHandler(broadcast)
+	b.sessions.adopt = b.resubscribe
+	b.mux.HandleFunc("/mcp", b.handleMCP)
+	b.mux.HandleFunc("/metrics", b.handleMetrics)
+	b.mux.HandleFunc("/healthz", b.handleHealth)
+	if b.canary != nil {
+		b.canary.Upstream.SetNotificationHandler(broadcast)
+		b.mux.HandleFunc("/canary", b.handleCanary)
+	}
+	if b.shadow != nil {
+		b.mux.HandleFunc("/shadow", b.handleShadow)
+	}
+
+	return b, nil
+}
+
+// initialize performs the MCP handshake with a server and returns its initialize result.
+func initialize(ctx context.Context, upstream *Upstream) (json.RawMessage, error) {
+	response, err := upstream.Call(ctx, "initialize", map[string]any{
+		"protocolVersion": "2024-11-05",
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "mcptools-bridge", "version": "1.0.0"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize server: %w", err)
+	}
+	if len(response.Error) > 0 {
+		return nil, fmt.Errorf("failed to initialize server: %s", response.Error)
+	}
+	if err = upstream.Notify("notifications/initialized", nil); err != nil {
+		return nil, err
+	}
+	return response.Result, nil
+}
+
+// Metrics returns the bridge's request counters.
+func (b *Bridge) Metrics() *Metrics {
+	return b.metrics
+}
+
+// ServeHTTP implements http.Handler.
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mux.ServeHTTP(w, r)
+}
+
+func (b *Bridge) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = b.metrics.WritePrometheus(w)
+	b.writeBreakers(w)
+}
+
+// Drain makes the bridge refuse new sessions, fail its health check and end notification
+// streams, so a load balancer moves clients elsewhere while requests in flight finish.
+func (b *Bridge) Drain() {
+	b.drainOnce.Do(func() { close(b.draining) })
+}
+
+// Draining reports whether Drain was called.
+func (b *Bridge) Draining() bool {
+	select {
+	case <-b.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// untilDrained returns a copy of ctx that is also canceled when the bridge starts draining.
+func (b *Bridge) untilDrained(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-b.draining:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func (b *Bridge) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	if b.Draining() {
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = fmt.Fprintln(w, "ok")
+}
+
+func (b *Bridge) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete && r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "only GET, POST and DELETE are supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := b.clock.Now()
+	key := requestKey(r)
+	id, ok := b.keys[key]
+	if key == "" || !ok {
+		b.record(AuditRecord{Status: StatusUnauthorized}, Identity{}, start)
+		http.Error(w, "missing or invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := r.Header.Get(SessionHeader)
+	if r.Method == http.MethodDelete {
+		removed, err := b.sessions.remove(r.Context(), sessionID, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if !removed {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method == http.MethodGet {
+		sess, found, err := b.sessions.get(r.Context(), sessionID, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if !found {
+			http.Error(w, "initialize a session and send its "+SessionHeader+" header to receive notifications", http.StatusNotFound)
+			return
+		}
+		// Clients that only accept JSON long-poll, as proxies keep event streams from them
+		if accept := r.Header.Get("Accept"); strings.Contains(accept, "application/json") &&
+			!strings.Contains(accept, "text/event-stream") {
+			b.pollNotifications(w, r, sess)
+		} else {
+			b.streamNotifications(w, r, sess)
+		}
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	var request Message
+	if err == nil {
+		err = json.Unmarshal(body, &request)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusOK, errorResponse(nil, -32700, "parse error: "+err.Error()))
+		return
+	}
+
+	entry := AuditRecord{Key: Fingerprint(key), Method: request.Method, Target: target(request)}
+
+	// Notifications, including notifications/initialized, only concern the client's own session
+	if len(request.ID) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// The server was initialized once by the bridge, so each client gets the same answer
+	if request.Method == "initialize" {
+		if b.Draining() {
+			b.record(entry.withStatus(StatusError), id, start)
+			w.Header().Set("Connection", "close")
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse(request.ID, -32000,
+				"the bridge is shutting down and accepts no new sessions"))
+			return
+		}
+		quota, _ := b.quotas.For(id)
+		sessionID, err = b.sessions.create(r.Context(), id, quota)
+		if err != nil {
+			b.record(entry.withStatus(StatusError), id, start)
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse(request.ID, -32000, err.Error()))
+			return
+		}
+		w.Header().Set(SessionHeader, sessionID)
+		b.record(entry.withStatus(StatusOK), id, start)
+		writeJSON(w, http.StatusOK, &Message{JSONRPC: "2.0", ID: request.ID, Result: b.initial})
+		return
+	}
+
+	var sess *session
+	// wait and release keep the requests of a session in order
+	wait := func(context.Context) error { return nil }
+	release := func() {}
+	if sessionID != "" {
+		if sess, ok, err = b.sessions.get(r.Context(), sessionID, id); err != nil {
+			b.record(entry.withStatus(StatusError), id, start)
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse(request.ID, -32000, err.Error()))
+			return
+		}
+		if !ok {
+			b.record(entry.withStatus(StatusError), id, start)
+			writeJSON(w, http.StatusNotFound, errorResponse(request.ID, -32000, "unknown session "+sessionID))
+			return
+		}
+		if !b.interleave {
+			var done func()
+			wait, done = sess.order.enter()
+			defer done()
+			release = done
+		}
+	} else if _, limited := b.quotas.For(id); limited {
+		b.record(entry.withStatus(StatusError), id, start)
+		writeJSON(w, http.StatusBadRequest, errorResponse(request.ID, -32000,
+			"sessions of this key have a quota: initialize first and send the "+SessionHeader+" header"))
+		return
+	}
+
+	if entity := entityType(request.Method); entity != "" && b.overrides != nil {
+		if allowed, matched := b.overrides.Decide(entity, entry.Target); matched && !allowed {
+			b.record(entry.withStatus(StatusDenied), id, start)
+			writeJSON(w, http.StatusOK, errorResponse(request.ID, -32000,
+				fmt.Sprintf("%s %s is denied by an admin override", entity, entry.Target)))
+			return
+		}
+	}
+
+	ctx := r.Context()
+	if sess != nil {
+		if err = b.sessions.use(ctx, sess, 1, int64(len(body))); err != nil {
+			b.endSession(w, entry, id, start, request.ID, err)
+			return
+		}
+		if remaining := b.sessions.remaining(sess); remaining > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, remaining)
+			defer cancel()
+		}
+	}
+
+	if err = wait(ctx); err != nil {
+		b.record(entry.withStatus(StatusError), id, start)
+		writeJSON(w, http.StatusOK, errorResponse(request.ID, -32000,
+			"gave up waiting for earlier requests of the session: "+err.Error()))
+		return
+	}
+	if err = b.schedule(ctx, b.class(request)); err != nil {
+		b.record(entry.withStatus(StatusError), id, start)
+		writeJSON(w, http.StatusOK, errorResponse(request.ID, -32000, "gave up waiting for the server: "+err.Error()))
+		return
+	}
+	params := stampMeta(request.Params, id)
+	compare := b.mirror(entry, params)
+	forwarded := b.clock.Now()
+	response, err := b.forward(ctx, &entry, params)
+	compare(response, clock.Since(b.clock, forwarded))
+	b.scheduler.release()
+	release()
+	if err != nil {
+		if sess != nil && errors.Is(err, context.DeadlineExceeded) {
+			if useErr := b.sessions.use(r.Context(), sess, 0, 0); useErr != nil {
+				b.endSession(w, entry, id, start, request.ID, useErr)
+				return
+			}
+		}
+		b.record(entry.withStatus(StatusError), id, start)
+		writeJSON(w, http.StatusOK, errorResponse(request.ID, -32000, err.Error()))
+		return
+	}
+
+	response.ID = request.ID
+	if sess != nil {
+		data, _ := json.Marshal(response)
+		if err = b.sessions.use(r.Context(), sess, 0, int64(len(data))); err != nil {
+			b.endSession(w, entry, id, start, request.ID, err)
+			return
+		}
+		if len(response.Error) == 0 {
+			b.recordSubscription(r.Context(), sess, request)
+		}
+	}
+
+	status := StatusOK
+	if len(response.Error) > 0 {
+		status = StatusError
+	}
+	b.record(entry.withStatus(status), id, start)
+	writeJSON(w, http.StatusOK, response)
+}
+
+// forward sends a request to the upstream chosen for it and records which one in entry. Calls
+// the canary cannot answer because it exited, timed out or has an open circuit are retried on
+// the stable server, so a failing canary costs errors in its statistics rather than failed
+// requests.
+func (b *Bridge) forward(ctx context.Context, entry *AuditRecord, params map[string]any) (*Message, error) {
+	upstream, name := b.pick(entry.Method)
+	start := b.clock.Now()
+	response, err := b.call(ctx, upstream, entry.Method, params)
+	if b.canary == nil || entityType(entry.Method) == "" {
+		return response, err
+	}
+
+	entry.Upstream = name
+	if !errors.Is(err, ErrCircuitOpen) {
+		b.metrics.ObserveUpstream(name, err != nil || failed(response), clock.Since(b.clock, start))
+	}
+	if name == UpstreamCanary && (errors.Is(err, ErrUpstreamClosed) || errors.Is(err, ErrUpstreamTimeout) ||
+		errors.Is(err, ErrCircuitOpen)) {
+		entry.Upstream = UpstreamStable
+		start = b.clock.Now()
+		response, err = b.call(ctx, b.upstream, entry.Method, params)
+		b.metrics.ObserveUpstream(UpstreamStable, err != nil || failed(response), clock.Since(b.clock, start))
+	}
+	return response, err
+}
+
+// streamNotifications sends the server's notifications to a session as server-sent events until
+// the client disconnects, the session ends or the bridge drains. Notifications wait in a bounded queue, so a slow
+// client loses the oldest ones instead of holding up the server.
+func (b *Bridge) streamNotifications(w http.ResponseWriter, r *http.Request, sess *session) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	stream := b.sessions.listen(sess, b.buffer)
+	defer b.sessions.unlisten(sess, stream)
+	ctx, cancel := b.untilDrained(r.Context())
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		msg, ok := stream.Pop(ctx)
+		if !ok {
+			return
+		}
+		data, err := json.Marshal(msg)
+		if err != nil {
+			continue
+		}
+		if _, err = fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// pollNotifications answers a long-polling client, which cannot receive server-sent events, with
+// the notifications queued for its session as a JSON array. It waits up to longPollWait for the
+// first one and returns an empty array if none arrives.
+func (b *Bridge) pollNotifications(w http.ResponseWriter, r *http.Request, sess *session) {
+	stream := b.sessions.queue(sess, b.buffer)
+
+	ctx, cancel := b.untilDrained(r.Context())
+	defer cancel()
+	ctx, cancelWait := context.WithTimeout(ctx, longPollWait)
+	defer cancelWait()
+
+	messages := []*Message{}
+	if msg, ok := stream.Pop(ctx); ok {
+		messages = append(messages, msg)
+		for stream.Len() > 0 {
+			if msg, ok = stream.Pop(r.Context()); !ok {
+				break
+			}
+			messages = append(messages, msg)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	_ = json.NewEncoder(w).Encode(messages)
+}
+
+// endSession answers a request whose session went over its quota. Like any terminated session,
+// it is reported as not found so clients know to start a new one. If the session store failed
+// instead, the request fails without ending the session.
+func (b *Bridge) endSession(w http.ResponseWriter, entry AuditRecord, id Identity, start time.Time,
+	requestID json.RawMessage, err error,
+) {
+	if errors.Is(err, ErrSessionStore) {
+		b.record(entry.withStatus(StatusError), id, start)
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse(requestID, -32000, err.Error()))
+		return
+	}
+	b.record(entry.withStatus(StatusQuotaExceeded), id, start)
+	writeJSON(w, http.StatusNotFound, errorResponse(requestID, -32000, "session terminated: "+err.Error()))
+}
+
+// recordSubscription keeps the resource subscriptions of a session in the session store, so a
+// bridge the client reconnects to can subscribe its own server.
+func (b *Bridge) recordSubscription(ctx context.Context, sess *session, request Message) {
+	if request.Method != "resources/subscribe" && request.Method != "resources/unsubscribe" {
+		return
+	}
+	uri, _ := request.Params["uri"].(string)
+	if uri == "" {
+		return
+	}
+	if err := b.sessions.subscribe(ctx, sess, uri, request.Method == "resources/subscribe"); err != nil {
+		fmt.Fprintf(os.Stderr, "bridge: failed to record subscription to %s: %v\n", uri, err)
+	}
+}
+
+// resubscribe subscribes the server to the resources a session subscribed to through another
+// bridge, when the session's client reconnects to this one.
+func (b *Bridge) resubscribe(record SessionRecord) {
+	for _, uri := range record.Subscriptions {
+		ctx, cancel := context.WithTimeout(context.Background(), resubscribeTimeout)
+		_, err := b.call(ctx, b.upstream, "resources/subscribe", map[string]any{"uri": uri})
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bridge: failed to resubscribe to %s for an adopted session: %v\n", uri, err)
+		}
+	}
+}
+
+// record writes an audit entry and updates the metrics.
+func (b *Bridge) record(entry AuditRecord, id Identity, start time.Time) {
+	duration := clock.Since(b.clock, start)
+	b.metrics.Observe(id, entry.Method, entry.Status, duration)
+
+	entry.Time = start.UTC()
+	entry.Tenant = id.Tenant
+	entry.User = id.User
+	entry.DurationMS = duration.Milliseconds()
+	b.writeAudit(entry)
+	if b.analytics != nil {
+		b.analytics(entry)
+	}
+}
+
+// LogAdmin writes a change made through the admin API to the audit log.
+func (b *Bridge) LogAdmin(change string) {
+	b.writeAudit(AuditRecord{Time: b.clock.Now().UTC(), Method: "admin", Target: change, Status: StatusOK})
+}
+
+// LogEgress writes an outbound connection made by a server to the audit log, as unexpected if
+// the server was declared local-only.
+func (b *Bridge) LogEgress(server, destination string, unexpected bool) {
+	status := StatusOK
+	if unexpected {
+		status = StatusUnexpected
+	}
+	b.writeAudit(AuditRecord{Time: b.clock.Now().UTC(), Method: "egress", Target: destination, Upstream: server, Status: status})
+}
+
+func (b *Bridge) writeAudit(entry AuditRecord) {
+	if b.audit == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	b.auditMu.Lock()
+	defer b.auditMu.Unlock()
+	_, _ = b.audit.Write(append(data, '\n'))
+}
+
+func (e AuditRecord) withStatus(status string) AuditRecord {
+	e.Status = status
+	return e
+}
+
+// entityType returns the kind of entity a method operates on, or "" for other methods.
+func entityType(method string) string {
+	switch method {
+	case "tools/call":
+		return "tool"
+	case "prompts/get":
+		return "prompt"
+	case "resources/read":
+		return "resource"
+	}
+	return ""
+}
+
+// target returns the tool, prompt or resource a request is about, if any.
+func target(request Message) string {
+	for _, key := range []string{"name", "uri"} {
+		if value, ok := request.Params[key].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func errorResponse(id json.RawMessage, code int, text string) *Message {
+	data, _ := json.Marshal(map[string]any{"code": code, "message": text})
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Message{JSONRPC: "2.0", ID: id, Error: data}
+}
+
+func writeJSON(w http.ResponseWriter, status int, msg *Message) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(msg)
+}
+
+// GetAuditLogPath returns the default audit log path, creating its directory if needed.
+func GetAuditLogPath() (string, error) {
+	return logPath("bridge-audit.log")
+}
+
+// GetShadowLogPath returns the default path of the log of differences found by a shadow
+// server, creating its directory if needed.
+func GetShadowLogPath() (string, error) {
+	return logPath("bridge-shadow.log")
+}
+
+func logPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	logDir := filepath.Join(homeDir, ".mcpt", "logs")
+	if err = os.MkdirAll(logDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	return filepath.Join(logDir, name), nil
+}
@@ -0,0 +1,450 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+	"github.com/f/mcptools/pkg/httpclient"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// TestMain lets the test binary act as the upstream server: it answers every request with the
+// method and params it received, and the value of BRIDGE_TEST_SERVER. Calling the tool "touch"
+// first sends two updates of the resource given as its uri argument. Calls to "hang" are never
+// answered, and calling "crash" makes the server exit when it is the canary. With
+// BRIDGE_TEST_LINGER set, the server does not exit when its input is closed.
+func TestMain(m *testing.M) {
+	if os.Getenv("BRIDGE_TEST_UPSTREAM") == "1" {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			var msg Message
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || len(msg.ID) == 0 {
+				continue
+			}
+			if msg.Params["name"] == "touch" {
+				args, _ := msg.Params["arguments"].(map[string]any)
+				for i := 0; i < 2; i++ {
+					data, _ := json.Marshal(Message{JSONRPC: "2.0", Method: "notifications/resources/updated",
+						Params: map[string]any{"uri": args["uri"]}})
+					_, _ = os.Stdout.Write(append(data, '\n'))
+				}
+			}
+			if msg.Params["name"] == "hang" {
+				continue
+			}
+			if msg.Params["name"] == "crash" && os.Getenv("BRIDGE_TEST_SERVER") == "canary" {
+				os.Exit(1)
+			}
+			result, _ := json.Marshal(map[string]any{"method": msg.Method, "params": msg.Params,
+				"server": os.Getenv("BRIDGE_TEST_SERVER")})
+			data, _ := json.Marshal(Message{JSONRPC: "2.0", ID: msg.ID, Result: result})
+			_, _ = os.Stdout.Write(append(data, '\n'))
+		}
+		if os.Getenv("BRIDGE_TEST_LINGER") == "1" {
+			select {}
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func newTestBridge(t *testing.T, audit *bytes.Buffer, quotas Quotas) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(newBridge(t, audit, quotas, nil))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newBridge(t *testing.T, audit *bytes.Buffer, quotas Quotas, store SessionStore) *Bridge {
+	t.Helper()
+
+	t.Setenv("BRIDGE_TEST_UPSTREAM", "1")
+	upstream, err := StartUpstream(os.Args[0], nil)
+	if err != nil {
+		t.Fatalf("StartUpstream() error = %v", err)
+	}
+	t.Cleanup(func() { _ = upstream.Close() })
+
+	b, err := New(context.Background(), upstream, Options{
+		AuditLog: audit,
+		Keys: Keys{
+			"key-alice": {Tenant: "acme", User: "alice"},
+			"key-bob":   {Tenant: "globex", User: "bob"},
+			"key-agent": {Tenant: "acme", User: "bot", Role: "agent"},
+		},
+		Quotas:   quotas,
+		Sessions: store,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return b
+}
+
+func post(t *testing.T, url, key, body string) (*http.Response, map[string]any) {
+	t.Helper()
+	return postSession(t, url, key, "", body)
+}
+
+func postSession(t *testing.T, url, key, sessionID, body string) (*http.Response, map[string]any) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, url+"/mcp", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	if sessionID != "" {
+		req.Header.Set(SessionHeader, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var msg map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&msg)
+	return resp, msg
+}
+
+// postUnchecked posts like postSession from goroutines other than the test's, returning a nil
+// message if the request fails.
+func postUnchecked(url, key, sessionID, body string) map[string]any {
+	req, _ := http.NewRequest(http.MethodPost, url+"/mcp", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+key)
+	if sessionID != "" {
+		req.Header.Set(SessionHeader, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var msg map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&msg)
+	return msg
+}
+
+func TestBridgeStampsTenantMeta(t *testing.T) {
+	var audit bytes.Buffer
+	server := newTestBridge(t, &audit, nil)
+
+	body := `{"jsonrpc":"2.0","id":"a1","method":"tools/call","params":{"name":"echo",` +
+		`"_meta":{"progressToken":7,"mcptools/tenant":"spoofed"}}}`
+	resp, msg := post(t, server.URL, "key-alice", body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if msg["id"] != "a1" {
+		t.Errorf("response id = %v, want the client's id", msg["id"])
+	}
+
+	result, _ := msg["result"].(map[string]any)
+	params, _ := result["params"].(map[string]any)
+	meta, _ := params["_meta"].(map[string]any)
+	if meta[MetaTenant] != "acme" || meta[MetaUser] != "alice" {
+		t.Errorf("forwarded _meta = %v, want tenant acme and user alice", meta)
+	}
+	if meta["progressToken"] != float64(7) {
+		t.Errorf("client _meta was not kept: %v", meta)
+	}
+
+	var record AuditRecord
+	if err := json.Unmarshal(audit.Bytes(), &record); err != nil {
+		t.Fatalf("invalid audit log %q: %v", audit.String(), err)
+	}
+	if record.Tenant != "acme" || record.User != "alice" || record.Target != "echo" ||
+		record.Status != StatusOK || record.Key != Fingerprint("key-alice") {
+		t.Errorf("unexpected audit record %+v", record)
+	}
+	if strings.Contains(audit.String(), "key-alice") {
+		t.Error("audit log contains the raw API key")
+	}
+}
+
+func TestBridgeRejectsUnknownKeys(t *testing.T) {
+	server := newTestBridge(t, &bytes.Buffer{}, nil)
+
+	for _, key := range []string{"", "wrong"} {
+		resp, _ := post(t, server.URL, key, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("key %q: status = %d, want 401", key, resp.StatusCode)
+		}
+	}
+}
+
+func TestBridgeMetricsLabels(t *testing.T) {
+	server := newTestBridge(t, &bytes.Buffer{}, nil)
+
+	post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	post(t, server.URL, "key-bob", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	post(t, server.URL, "key-bob", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var out bytes.Buffer
+	_, _ = out.ReadFrom(resp.Body)
+
+	for _, want := range []string{
+		`mcptools_bridge_requests_total{tenant="globex",user="bob",method="tools/list",status="ok"} 2`,
+		`mcptools_bridge_requests_total{tenant="acme",user="alice",method="initialize",status="ok"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestBridgeSessionQuota(t *testing.T) {
+	var quotas Quotas
+	if err := json.Unmarshal([]byte(`{"agent":{"max_calls":2,"max_duration":"1h"}}`), &quotas); err != nil {
+		t.Fatalf("failed to parse quotas: %v", err)
+	}
+	server := newTestBridge(t, &bytes.Buffer{}, quotas)
+
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+	if resp, _ := post(t, server.URL, "key-agent", call); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("call without a session: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, _ := post(t, server.URL, "key-agent", `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}`)
+	sessionID := resp.Header.Get(SessionHeader)
+	if sessionID == "" {
+		t.Fatal("initialize did not return a session ID")
+	}
+	if resp, _ = postSession(t, server.URL, "key-alice", sessionID, call); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("session used with another key: status = %d, want 404", resp.StatusCode)
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp, msg := postSession(t, server.URL, "key-agent", sessionID, call); msg["result"] == nil {
+			t.Fatalf("call %d within quota failed: %d %v", i+1, resp.StatusCode, msg)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		resp, msg := postSession(t, server.URL, "key-agent", sessionID, call)
+		errObj, _ := msg["error"].(map[string]any)
+		text, _ := errObj["message"].(string)
+		if resp.StatusCode != http.StatusNotFound || !strings.Contains(text, "more than 2 calls") {
+			t.Errorf("call over quota: status = %d, error = %q", resp.StatusCode, text)
+		}
+	}
+
+	// Unlimited roles keep working without sessions
+	if _, msg := post(t, server.URL, "key-alice", call); msg["result"] == nil {
+		t.Errorf("call without quota failed: %v", msg)
+	}
+}
+
+func TestSessionDurationQuota(t *testing.T) {
+	now := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newSessions(nil, now)
+	id := Identity{Tenant: "acme", User: "bot", Role: "agent"}
+	sessionID, err := s.create(context.Background(), id, Quota{MaxDuration: Duration(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, _, _ := s.get(context.Background(), sessionID, id)
+
+	now.Advance(45 * time.Minute)
+	if err = s.use(context.Background(), sess, 1, 0); err != nil {
+		t.Fatalf("use() after 45m error = %v", err)
+	}
+	if remaining := s.remaining(sess); remaining != 15*time.Minute {
+		t.Errorf("remaining() = %s, want 15m", remaining)
+	}
+	now.Advance(time.Hour)
+	if err = s.use(context.Background(), sess, 1, 0); !errors.Is(err, ErrQuotaExceeded) || !strings.Contains(err.Error(), "open for more than 1h0m0s") {
+		t.Errorf("use() after 1h45m error = %v, want the duration quota exceeded", err)
+	}
+}
+
+func TestBridgeStreamsNotifications(t *testing.T) {
+	server := newTestBridge(t, &bytes.Buffer{}, nil)
+
+	resp, _ := post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}`)
+	sessionID := resp.Header.Get(SessionHeader)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/mcp", nil)
+	req.Header.Set("Authorization", "Bearer key-alice")
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("stream without a session: %v, want 404", err)
+	}
+
+	req.Header.Set(SessionHeader, sessionID)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil || stream.StatusCode != http.StatusOK {
+		t.Fatalf("failed to open the stream: %v", err)
+	}
+	defer func() { _ = stream.Body.Close() }()
+
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"touch","arguments":{"uri":"file:///a"}}}`
+	if _, msg := postSession(t, server.URL, "key-alice", sessionID, call); msg["result"] == nil {
+		t.Fatalf("call failed: %v", msg)
+	}
+
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data: ")
+		if !found {
+			continue
+		}
+		var msg Message
+		if err = json.Unmarshal([]byte(data), &msg); err != nil {
+			t.Fatalf("invalid event %q: %v", data, err)
+		}
+		if msg.Method != "notifications/resources/updated" || msg.Params["uri"] != "file:///a" {
+			t.Errorf("unexpected notification %+v", msg)
+		}
+		return
+	}
+	t.Fatalf("stream ended without a notification: %v", scanner.Err())
+}
+
+func TestBridgeLongPollsNotifications(t *testing.T) {
+	server := newTestBridge(t, &bytes.Buffer{}, nil)
+
+	client := httpclient.NewLongPoll(server.URL+"/mcp", map[string]string{"Authorization": "Bearer key-alice"}, nil)
+	defer func() { _ = client.Close() }()
+
+	received := make(chan mcp.JSONRPCNotification, 10)
+	client.SetNotificationHandler(func(n mcp.JSONRPCNotification) { received <- n })
+
+	ctx := context.Background()
+	if _, err := client.SendRequest(ctx, transport.JSONRPCRequest{JSONRPC: "2.0", ID: mcp.NewRequestId(int64(0)),
+		Method: "initialize", Params: map[string]any{}}); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	// Notifications sent before the first poll reaches the bridge are not kept, so keep touching
+	// the resource until one arrives
+	timeout := time.After(5 * time.Second)
+	for i := 1; ; i++ {
+		response, err := client.SendRequest(ctx, transport.JSONRPCRequest{JSONRPC: "2.0", ID: mcp.NewRequestId(int64(i)),
+			Method: "tools/call", Params: map[string]any{"name": "touch", "arguments": map[string]any{"uri": "file:///a"}}})
+		if err != nil || response.Result == nil {
+			t.Fatalf("call failed: %v", err)
+		}
+
+		select {
+		case n := <-received:
+			if n.Method != "notifications/resources/updated" || n.Params.AdditionalFields["uri"] != "file:///a" {
+				t.Errorf("unexpected notification %+v", n)
+			}
+			return
+		case <-time.After(200 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("no notification was long-polled")
+		}
+	}
+}
+
+func TestBridgeDrain(t *testing.T) {
+	b := newBridge(t, &bytes.Buffer{}, nil, nil)
+	server := httptest.NewServer(b)
+	defer server.Close()
+
+	resp, _ := post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}`)
+	sessionID := resp.Header.Get(SessionHeader)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/mcp", nil)
+	req.Header.Set("Authorization", "Bearer key-alice")
+	req.Header.Set(SessionHeader, sessionID)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil || stream.StatusCode != http.StatusOK {
+		t.Fatalf("failed to open the stream: %v", err)
+	}
+	defer func() { _ = stream.Body.Close() }()
+
+	b.Drain()
+
+	ended := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, stream.Body)
+		close(ended)
+	}()
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the notification stream was not ended by draining")
+	}
+
+	if resp, _ = post(t, server.URL, "key-bob", `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}`); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("initialize while draining: status = %d, want 503", resp.StatusCode)
+	}
+	health, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("GET /healthz failed: %v", err)
+	}
+	_ = health.Body.Close()
+	if health.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("/healthz while draining: status = %d, want 503", health.StatusCode)
+	}
+
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+	if _, msg := postSession(t, server.URL, "key-alice", sessionID, call); msg["result"] == nil {
+		t.Errorf("call of an existing session failed while draining: %v", msg)
+	}
+}
+
+func TestUpstreamShutdown(t *testing.T) {
+	grace := shutdownGrace
+	shutdownGrace = 200 * time.Millisecond
+	t.Cleanup(func() { shutdownGrace = grace })
+	// Binaries built with the race detector sleep for a second before exiting by default
+	t.Setenv("GORACE", strings.TrimSpace(os.Getenv("GORACE")+" atexit_sleep_ms=0"))
+
+	for _, linger := range []string{"0", "1"} {
+		t.Setenv("BRIDGE_TEST_UPSTREAM", "1")
+		t.Setenv("BRIDGE_TEST_LINGER", linger)
+		upstream, err := StartUpstream(os.Args[0], nil)
+		if err != nil {
+			t.Fatalf("StartUpstream() error = %v", err)
+		}
+
+		start := time.Now()
+		err = upstream.Shutdown()
+		if linger == "0" && err != nil {
+			t.Errorf("server exiting on closed input: Shutdown() error = %v", err)
+		}
+		if linger == "1" && err == nil {
+			t.Error("lingering server: Shutdown() error = nil, want the SIGTERM it was stopped with")
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("linger %s: Shutdown() took %s", linger, elapsed)
+		}
+		_ = upstream.Close()
+	}
+}
+
+func TestBridgeLogsEgress(t *testing.T) {
+	var audit bytes.Buffer
+	b := newBridge(t, &audit, nil, nil)
+
+	b.LogEgress("fs", "tcp 93.184.216.34:443", true)
+	var record AuditRecord
+	if err := json.Unmarshal(audit.Bytes(), &record); err != nil {
+		t.Fatalf("invalid audit log %q: %v", audit.String(), err)
+	}
+	if record.Method != "egress" || record.Upstream != "fs" || record.Target != "tcp 93.184.216.34:443" ||
+		record.Status != StatusUnexpected {
+		t.Errorf("unexpected audit record %+v", record)
+	}
+}
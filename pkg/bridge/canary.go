@@ -0,0 +1,100 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Upstreams a request can be routed to, used to label audit records and metrics.
+const (
+	UpstreamStable = "stable"
+	UpstreamCanary = "canary"
+)
+
+// Canary routes a share of the tool calls, prompt gets and resource reads of a bridge to an
+// alternate version of its server. Listings always come from the stable server, so clients see
+// one consistent set of tools.
+type Canary struct {
+	// Name identifies the alternate server in logs and reports, e.g. its alias.
+	Name string
+	// Upstream is the alternate server. The bridge initializes it like the stable one.
+	Upstream *Upstream
+	// Percent is the share of calls sent to the alternate server, from 0 to 100.
+	Percent float64
+}
+
+// CanarySpec is a parsed --canary flag value.
+type CanarySpec struct {
+	Server  string
+	Percent float64
+	Target  string
+}
+
+// ParseCanary parses a canary spec of the form server=percent%:target, e.g. fs=10%:fs-v2, which
+// sends 10% of the calls for fs to fs-v2.
+func ParseCanary(spec string) (CanarySpec, error) {
+	server, rest, ok := strings.Cut(spec, "=")
+	if !ok || server == "" {
+		return CanarySpec{}, fmt.Errorf("invalid canary %q: expected server=percent%%:target, e.g. fs=10%%:fs-v2", spec)
+	}
+	percent, target, ok := strings.Cut(rest, ":")
+	if !ok || target == "" {
+		return CanarySpec{}, fmt.Errorf("invalid canary %q: expected server=percent%%:target, e.g. fs=10%%:fs-v2", spec)
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSuffix(percent, "%"), 64)
+	if err != nil || value < 0 || value > 100 {
+		return CanarySpec{}, fmt.Errorf("invalid canary percentage %q: expected a number from 0 to 100", percent)
+	}
+	return CanarySpec{Server: server, Percent: value, Target: target}, nil
+}
+
+// pick chooses the upstream for a request: the canary for its share of the calls, and the
+// stable server for everything else.
+func (b *Bridge) pick(method string) (*Upstream, string) {
+	if b.canary == nil || entityType(method) == "" {
+		return b.upstream, UpstreamStable
+	}
+	if b.rand.Float64()*100 < b.canary.Percent {
+		return b.canary.Upstream, UpstreamCanary
+	}
+	return b.upstream, UpstreamStable
+}
+
+// CanaryReport compares the stable and canary servers of a bridge.
+type CanaryReport struct {
+	Canary    string                   `json:"canary"`
+	Percent   float64                  `json:"percent"`
+	Upstreams map[string]UpstreamStats `json:"upstreams"`
+}
+
+func (b *Bridge) handleCanary(w http.ResponseWriter, _ *http.Request) {
+	report := CanaryReport{
+		Canary:    b.canary.Name,
+		Percent:   b.canary.Percent,
+		Upstreams: b.metrics.Upstreams(),
+	}
+	for _, name := range []string{UpstreamStable, UpstreamCanary} {
+		if _, ok := report.Upstreams[name]; !ok {
+			report.Upstreams[name] = UpstreamStats{}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// failed reports whether a response is an error, including tool results flagged with isError.
+func failed(response *Message) bool {
+	if len(response.Error) > 0 {
+		return true
+	}
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	_ = json.Unmarshal(response.Result, &result)
+	return result.IsError
+}
@@ -0,0 +1,158 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+func TestParseCanary(t *testing.T) {
+	spec, err := ParseCanary("fs=12.5%:fs-v2")
+	if err != nil {
+		t.Fatalf("ParseCanary() error = %v", err)
+	}
+	if spec != (CanarySpec{Server: "fs", Percent: 12.5, Target: "fs-v2"}) {
+		t.Errorf("ParseCanary() = %+v", spec)
+	}
+
+	for _, invalid := range []string{"fs", "fs=10%", "=10%:fs-v2", "fs=ten:fs-v2", "fs=150%:fs-v2", "fs=-1%:fs-v2"} {
+		if _, err = ParseCanary(invalid); err == nil {
+			t.Errorf("ParseCanary(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func newCanaryBridge(t *testing.T, audit *bytes.Buffer, percent float64) *httptest.Server {
+	t.Helper()
+
+	t.Setenv("BRIDGE_TEST_UPSTREAM", "1")
+	t.Setenv("BRIDGE_TEST_SERVER", "stable")
+	upstream, err := StartUpstream(os.Args[0], nil)
+	if err != nil {
+		t.Fatalf("StartUpstream() error = %v", err)
+	}
+	t.Cleanup(func() { _ = upstream.Close() })
+
+	t.Setenv("BRIDGE_TEST_SERVER", "canary")
+	canary, err := StartUpstream(os.Args[0], nil)
+	if err != nil {
+		t.Fatalf("StartUpstream() error = %v", err)
+	}
+	t.Cleanup(func() { _ = canary.Close() })
+
+	b, err := New(context.Background(), upstream, Options{
+		AuditLog: audit,
+		Keys:     Keys{"key-alice": {Tenant: "acme", User: "alice"}},
+		Canary:   &Canary{Name: "fs-v2", Upstream: canary, Percent: percent},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	server := httptest.NewServer(b)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func answeredBy(msg map[string]any) any {
+	result, _ := msg["result"].(map[string]any)
+	return result["server"]
+}
+
+func canaryReport(t *testing.T, url string) CanaryReport {
+	t.Helper()
+
+	resp, err := http.Get(url + "/canary")
+	if err != nil {
+		t.Fatalf("GET /canary failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var report CanaryReport
+	if err = json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("invalid canary report: %v", err)
+	}
+	return report
+}
+
+func TestCanaryRoutesCalls(t *testing.T) {
+	var audit bytes.Buffer
+	server := newCanaryBridge(t, &audit, 100)
+
+	_, msg := post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo"}}`)
+	if got := answeredBy(msg); got != "canary" {
+		t.Errorf("tools/call answered by %v, want canary", got)
+	}
+	_, msg = post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if got := answeredBy(msg); got != "stable" {
+		t.Errorf("tools/list answered by %v, want stable", got)
+	}
+
+	if !strings.Contains(audit.String(), `"method":"tools/call","target":"echo","upstream":"canary"`) {
+		t.Errorf("audit log does not name the canary:\n%s", audit.String())
+	}
+
+	report := canaryReport(t, server.URL)
+	if report.Canary != "fs-v2" || report.Percent != 100 {
+		t.Errorf("report = %+v", report)
+	}
+	if report.Upstreams[UpstreamCanary].Calls != 1 || report.Upstreams[UpstreamStable].Calls != 0 {
+		t.Errorf("upstreams = %+v, want one canary call", report.Upstreams)
+	}
+}
+
+func TestCanarySeededRouting(t *testing.T) {
+	routes := func() []string {
+		b := &Bridge{
+			upstream: &Upstream{},
+			canary:   &Canary{Name: "fs-v2", Upstream: &Upstream{}, Percent: 50},
+			rand:     clock.Seeded(3),
+		}
+		var names []string
+		for range 20 {
+			_, name := b.pick("tools/call")
+			names = append(names, name)
+		}
+		return names
+	}
+
+	first, second := routes(), routes()
+	if !slices.Equal(first, second) {
+		t.Errorf("routes = %v and %v, want the same with the same seed", first, second)
+	}
+	if !slices.Contains(first, UpstreamCanary) || !slices.Contains(first, UpstreamStable) {
+		t.Errorf("routes = %v, want both upstreams at 50%%", first)
+	}
+}
+
+func TestCanaryZeroPercent(t *testing.T) {
+	server := newCanaryBridge(t, &bytes.Buffer{}, 0)
+
+	_, msg := post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo"}}`)
+	if got := answeredBy(msg); got != "stable" {
+		t.Errorf("tools/call answered by %v, want stable", got)
+	}
+}
+
+func TestCanaryFallsBackWhenItExits(t *testing.T) {
+	server := newCanaryBridge(t, &bytes.Buffer{}, 100)
+
+	// The canary exits while handling the call, which is then answered by the stable server
+	_, msg := post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"crash"}}`)
+	if got := answeredBy(msg); got != "stable" {
+		t.Fatalf("response = %v, want one from the stable server", msg)
+	}
+
+	report := canaryReport(t, server.URL)
+	if canary := report.Upstreams[UpstreamCanary]; canary.Calls != 1 || canary.Errors != 1 || canary.ErrorRate != 1 {
+		t.Errorf("canary stats = %+v, want one failed call", canary)
+	}
+}
@@ -0,0 +1,99 @@
+package bridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Keys in the _meta object of forwarded requests.
+const (
+	MetaTenant = "mcptools/tenant"
+	MetaUser   = "mcptools/user"
+)
+
+// ErrNoKeys is returned when a keys file defines no API keys.
+var ErrNoKeys = errors.New("at least one API key is required")
+
+// Identity is the tenant and user an API key belongs to. The role selects the quota of the
+// key's sessions.
+type Identity struct {
+	Tenant string `json:"tenant"`
+	User   string `json:"user"`
+	Role   string `json:"role,omitempty"`
+}
+
+func (id Identity) role() string {
+	if id.Role == "" {
+		return DefaultRole
+	}
+	return id.Role
+}
+
+// Keys maps API keys to the identity of their owner.
+type Keys map[string]Identity
+
+// LoadKeys reads a JSON file mapping API keys to identities:
+//
+//	{"sk-acme-1": {"tenant": "acme", "user": "alice", "role": "agent"}}
+func LoadKeys(path string) (Keys, error) {
+	// #nosec G304 - the keys file path is provided explicitly by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keys file: %w", err)
+	}
+
+	var keys Keys
+	if err = json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse keys file: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	for key, identity := range keys {
+		if identity.Tenant == "" {
+			return nil, fmt.Errorf("key %s has no tenant", Fingerprint(key))
+		}
+	}
+
+	return keys, nil
+}
+
+// Fingerprint identifies an API key in logs without revealing it.
+func Fingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
+// requestKey returns the API key sent as a bearer token or in the X-API-Key header.
+func requestKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > len("Bearer ") && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// stampMeta adds the identity to the _meta object of params, replacing any identity the client
+// sent itself so tenants cannot impersonate each other.
+func stampMeta(params map[string]any, id Identity) map[string]any {
+	if params == nil {
+		params = map[string]any{}
+	}
+	meta, ok := params["_meta"].(map[string]any)
+	if !ok {
+		meta = map[string]any{}
+	}
+	meta[MetaTenant] = id.Tenant
+	if id.User != "" {
+		meta[MetaUser] = id.User
+	} else {
+		delete(meta, MetaUser)
+	}
+	params["_meta"] = meta
+	return params
+}
@@ -0,0 +1,253 @@
+package bridge
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricKey holds the labels of a request counter.
+type metricKey struct {
+	Tenant string
+	User   string
+	Method string
+	Status string
+}
+
+// Metrics counts forwarded requests by tenant, user, method and status.
+type Metrics struct {
+	counts    map[metricKey]int64
+	durations map[metricKey]time.Duration
+	upstreams map[string]*UpstreamStats
+	shadow    ShadowStats
+	queued    map[string]int64
+	waited    map[string]time.Duration
+	dropped   int64
+	mu        sync.Mutex
+}
+
+// queueTotals holds the scheduled requests of a class and the time they waited.
+type queueTotals struct {
+	class string
+	count int64
+	wait  time.Duration
+}
+
+// UpstreamStats compares the calls routed to one upstream of a bridge with a canary.
+type UpstreamStats struct {
+	Calls         int64   `json:"calls"`
+	Errors        int64   `json:"errors"`
+	ErrorRate     float64 `json:"errorRate"`
+	MeanLatencyMS float64 `json:"meanLatencyMs"`
+	duration      time.Duration
+}
+
+// NewMetrics creates an empty set of counters.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		counts:    make(map[metricKey]int64),
+		durations: make(map[metricKey]time.Duration),
+		upstreams: make(map[string]*UpstreamStats),
+		queued:    make(map[string]int64),
+		waited:    make(map[string]time.Duration),
+	}
+}
+
+// Observe records one request.
+func (m *Metrics) Observe(id Identity, method, status string, duration time.Duration) {
+	key := metricKey{Tenant: id.Tenant, User: id.User, Method: method, Status: status}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[key]++
+	m.durations[key] += duration
+}
+
+// ObserveUpstream records one call routed to upstream, which failed or not.
+func (m *Metrics) ObserveUpstream(upstream string, failed bool, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.upstreams[upstream]
+	if !ok {
+		stats = &UpstreamStats{}
+		m.upstreams[upstream] = stats
+	}
+	stats.Calls++
+	if failed {
+		stats.Errors++
+	}
+	stats.duration += duration
+	stats.ErrorRate = float64(stats.Errors) / float64(stats.Calls)
+	stats.MeanLatencyMS = float64(stats.duration.Microseconds()) / 1000 / float64(stats.Calls)
+}
+
+// Upstreams returns the calls recorded for each upstream.
+func (m *Metrics) Upstreams() map[string]UpstreamStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	upstreams := make(map[string]UpstreamStats, len(m.upstreams))
+	for name, stats := range m.upstreams {
+		upstreams[name] = *stats
+	}
+	return upstreams
+}
+
+// ObserveQueue records how long a request of class waited for its turn.
+func (m *Metrics) ObserveQueue(class string, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queued[class]++
+	m.waited[class] += wait
+}
+
+// ObserveShadow records the outcome of one mirrored request and how long the upstream and
+// shadow servers took to answer it.
+func (m *Metrics) ObserveShadow(outcome string, upstream, shadow time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := &m.shadow
+	switch outcome {
+	case ShadowDropped:
+		stats.Dropped++
+		return
+	case ShadowMatch:
+		stats.Matches++
+	case ShadowMismatch:
+		stats.Mismatches++
+	case ShadowError:
+		stats.Errors++
+	}
+	stats.Requests++
+	stats.upstream += upstream
+	stats.shadow += shadow
+	stats.UpstreamMeanLatencyMS = float64(stats.upstream.Microseconds()) / 1000 / float64(stats.Requests)
+	stats.ShadowMeanLatencyMS = float64(stats.shadow.Microseconds()) / 1000 / float64(stats.Requests)
+}
+
+// Shadow returns the mirrored requests recorded so far.
+func (m *Metrics) Shadow() ShadowStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shadow
+}
+
+// DropNotifications records n notifications dropped because a client read them too slowly.
+func (m *Metrics) DropNotifications(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropped += int64(n)
+}
+
+// Count returns the number of requests recorded with the given labels.
+func (m *Metrics) Count(id Identity, method, status string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[metricKey{Tenant: id.Tenant, User: id.User, Method: method, Status: status}]
+}
+
+// WritePrometheus writes the counters in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	keys := make([]metricKey, 0, len(m.counts))
+	for key := range m.counts {
+		keys = append(keys, key)
+	}
+	counts := make([]int64, len(keys))
+	durations := make([]time.Duration, len(keys))
+	sort.Slice(keys, func(i, j int) bool { return keys[i].labels() < keys[j].labels() })
+	for i, key := range keys {
+		counts[i] = m.counts[key]
+		durations[i] = m.durations[key]
+	}
+	dropped := m.dropped
+	var queued []queueTotals
+	for _, class := range []string{ClassInteractive, ClassBatch} {
+		if count, ok := m.queued[class]; ok {
+			queued = append(queued, queueTotals{class: class, count: count, wait: m.waited[class]})
+		}
+	}
+	m.mu.Unlock()
+	upstreams := m.Upstreams()
+	shadow := m.Shadow()
+	names := make([]string, 0, len(upstreams))
+	for name := range upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("# HELP mcptools_bridge_requests_total Requests forwarded by the bridge.\n")
+	b.WriteString("# TYPE mcptools_bridge_requests_total counter\n")
+	for i, key := range keys {
+		fmt.Fprintf(&b, "mcptools_bridge_requests_total{%s} %d\n", key.labels(), counts[i])
+	}
+	b.WriteString("# HELP mcptools_bridge_request_duration_seconds_total Time spent on forwarded requests.\n")
+	b.WriteString("# TYPE mcptools_bridge_request_duration_seconds_total counter\n")
+	for i, key := range keys {
+		fmt.Fprintf(&b, "mcptools_bridge_request_duration_seconds_total{%s} %g\n", key.labels(), durations[i].Seconds())
+	}
+	b.WriteString("# HELP mcptools_bridge_notifications_dropped_total Server notifications dropped because a client read them too slowly.\n")
+	b.WriteString("# TYPE mcptools_bridge_notifications_dropped_total counter\n")
+	fmt.Fprintf(&b, "mcptools_bridge_notifications_dropped_total %d\n", dropped)
+	if len(names) > 0 {
+		b.WriteString("# HELP mcptools_bridge_upstream_calls_total Calls routed to the stable and canary servers.\n")
+		b.WriteString("# TYPE mcptools_bridge_upstream_calls_total counter\n")
+		for _, name := range names {
+			stats := upstreams[name]
+			fmt.Fprintf(&b, "mcptools_bridge_upstream_calls_total{upstream=\"%s\",status=\"ok\"} %d\n", escapeLabel(name), stats.Calls-stats.Errors)
+			fmt.Fprintf(&b, "mcptools_bridge_upstream_calls_total{upstream=\"%s\",status=\"error\"} %d\n", escapeLabel(name), stats.Errors)
+		}
+		b.WriteString("# HELP mcptools_bridge_upstream_duration_seconds_total Time spent on calls routed to the stable and canary servers.\n")
+		b.WriteString("# TYPE mcptools_bridge_upstream_duration_seconds_total counter\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "mcptools_bridge_upstream_duration_seconds_total{upstream=\"%s\"} %g\n", escapeLabel(name), upstreams[name].duration.Seconds())
+		}
+	}
+
+	if len(queued) > 0 {
+		b.WriteString("# HELP mcptools_bridge_queued_requests_total Requests scheduled while the number of requests in flight was limited.\n")
+		b.WriteString("# TYPE mcptools_bridge_queued_requests_total counter\n")
+		for _, q := range queued {
+			fmt.Fprintf(&b, "mcptools_bridge_queued_requests_total{class=\"%s\"} %d\n", q.class, q.count)
+		}
+		b.WriteString("# HELP mcptools_bridge_queue_wait_seconds_total Time requests waited for their turn.\n")
+		b.WriteString("# TYPE mcptools_bridge_queue_wait_seconds_total counter\n")
+		for _, q := range queued {
+			fmt.Fprintf(&b, "mcptools_bridge_queue_wait_seconds_total{class=\"%s\"} %g\n", q.class, q.wait.Seconds())
+		}
+	}
+	if shadow.Requests+shadow.Dropped > 0 {
+		b.WriteString("# HELP mcptools_bridge_shadow_requests_total Requests mirrored to the shadow server, by outcome.\n")
+		b.WriteString("# TYPE mcptools_bridge_shadow_requests_total counter\n")
+		for _, outcome := range []struct {
+			name  string
+			count int64
+		}{{ShadowMatch, shadow.Matches}, {ShadowMismatch, shadow.Mismatches}, {ShadowError, shadow.Errors}, {ShadowDropped, shadow.Dropped}} {
+			fmt.Fprintf(&b, "mcptools_bridge_shadow_requests_total{outcome=\"%s\"} %d\n", outcome.name, outcome.count)
+		}
+		b.WriteString("# HELP mcptools_bridge_shadow_duration_seconds_total Time the upstream and shadow servers spent on mirrored requests.\n")
+		b.WriteString("# TYPE mcptools_bridge_shadow_duration_seconds_total counter\n")
+		fmt.Fprintf(&b, "mcptools_bridge_shadow_duration_seconds_total{server=\"upstream\"} %g\n", shadow.upstream.Seconds())
+		fmt.Fprintf(&b, "mcptools_bridge_shadow_duration_seconds_total{server=\"shadow\"} %g\n", shadow.shadow.Seconds())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (k metricKey) labels() string {
+	return fmt.Sprintf(`tenant="%s",user="%s",method="%s",status="%s"`,
+		escapeLabel(k.Tenant), escapeLabel(k.User), escapeLabel(k.Method), escapeLabel(k.Status))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
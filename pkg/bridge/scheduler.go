@@ -0,0 +1,171 @@
+package bridge
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+// Classes of requests, scheduled by priority when the server is busy.
+const (
+	ClassInteractive = "interactive"
+	ClassBatch       = "batch"
+)
+
+// scheduler bounds the requests in flight to the server. Once the limit is reached, requests
+// wait in a queue per class, and interactive requests are let through before batch ones.
+type scheduler struct {
+	limit   int
+	running int
+	queues  map[string][]chan struct{}
+	mu      sync.Mutex
+}
+
+func newScheduler(limit int) *scheduler {
+	if limit <= 0 {
+		return nil
+	}
+	return &scheduler{limit: limit, queues: map[string][]chan struct{}{}}
+}
+
+// acquire waits until a request of class may be sent, or ctx is done. Every successful acquire
+// must be followed by a release.
+func (s *scheduler) acquire(ctx context.Context, class string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	ahead := len(s.queues[ClassInteractive])
+	if class == ClassBatch {
+		ahead += len(s.queues[ClassBatch])
+	}
+	if s.running < s.limit && ahead == 0 {
+		s.running++
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	s.queues[class] = append(s.queues[class], ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-ready:
+			// Let through just as the request gave up, so pass its turn on
+			s.running--
+			s.next()
+		default:
+			queue := s.queues[class]
+			for i, ch := range queue {
+				if ch == ready {
+					s.queues[class] = append(queue[:i:i], queue[i+1:]...)
+					break
+				}
+			}
+		}
+		return ctx.Err()
+	}
+}
+
+// release ends a request and lets the next waiting one through.
+func (s *scheduler) release() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	s.next()
+}
+
+// next lets the longest waiting interactive request through, or else the longest waiting batch
+// request, if the limit allows.
+func (s *scheduler) next() {
+	for _, class := range []string{ClassInteractive, ClassBatch} {
+		queue := s.queues[class]
+		if s.running >= s.limit || len(queue) == 0 {
+			continue
+		}
+		s.queues[class] = queue[1:]
+		s.running++
+		close(queue[0])
+	}
+}
+
+// fifo orders the requests of a session, so each is sent to the server only once the one
+// that arrived before it was answered. Some servers misbehave when a client's requests
+// interleave.
+type fifo struct {
+	last chan struct{}
+	mu   sync.Mutex
+}
+
+// enter takes a place at the back of the queue. wait blocks until the requests ahead are done
+// or ctx is, and done, which must always be called, lets the next request through.
+func (q *fifo) enter() (wait func(context.Context) error, done func()) {
+	q.mu.Lock()
+	ahead := q.last
+	mine := make(chan struct{})
+	q.last = mine
+	q.mu.Unlock()
+
+	turn := ahead == nil
+	wait = func(ctx context.Context) error {
+		if turn {
+			return nil
+		}
+		select {
+		case <-ahead:
+			turn = true
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	done = sync.OnceFunc(func() {
+		if turn {
+			close(mine)
+			return
+		}
+		// Gave up before its turn, so the next request still waits for those ahead
+		go func() {
+			<-ahead
+			close(mine)
+		}()
+	})
+	return wait, done
+}
+
+// class returns the class of a request: calls to tools matching a batch pattern are batch
+// requests, and everything else is interactive.
+func (b *Bridge) class(request Message) string {
+	if request.Method != "tools/call" {
+		return ClassInteractive
+	}
+	name, _ := request.Params["name"].(string)
+	for _, pattern := range b.batchTools {
+		if match, _ := filepath.Match(pattern, name); match {
+			return ClassBatch
+		}
+	}
+	return ClassInteractive
+}
+
+// schedule waits for the turn of a request of class, recording how long it waited.
+func (b *Bridge) schedule(ctx context.Context, class string) error {
+	if b.scheduler == nil {
+		return nil
+	}
+	start := b.clock.Now()
+	err := b.scheduler.acquire(ctx, class)
+	b.metrics.ObserveQueue(class, clock.Since(b.clock, start))
+	return err
+}
@@ -0,0 +1,205 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+// waitQueued waits until class has n requests waiting in s.
+func waitQueued(t *testing.T, s *scheduler, class string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		queued := len(s.queues[class])
+		s.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d %s requests queued, want %d", queued, class, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerPrefersInteractive(t *testing.T) {
+	s := newScheduler(1)
+	ctx := context.Background()
+	if err := s.acquire(ctx, ClassBatch); err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan string, 2)
+	wait := func(class string) {
+		if err := s.acquire(ctx, class); err == nil {
+			order <- class
+		}
+	}
+	go wait(ClassBatch)
+	waitQueued(t, s, ClassBatch, 1)
+	go wait(ClassInteractive)
+	waitQueued(t, s, ClassInteractive, 1)
+
+	s.release()
+	if first := <-order; first != ClassInteractive {
+		t.Errorf("%s request went first, want the interactive one", first)
+	}
+	s.release()
+	if second := <-order; second != ClassBatch {
+		t.Errorf("%s request went second, want the batch one", second)
+	}
+	s.release()
+
+	if s.running != 0 {
+		t.Errorf("%d requests running after all were released", s.running)
+	}
+}
+
+func TestSchedulerGivesUp(t *testing.T) {
+	s := newScheduler(1)
+	if err := s.acquire(context.Background(), ClassInteractive); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.acquire(ctx, ClassBatch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire() error = %v, want a deadline", err)
+	}
+	waitQueued(t, s, ClassBatch, 0)
+
+	s.release()
+	if err := s.acquire(context.Background(), ClassBatch); err != nil {
+		t.Errorf("acquire() error = %v after the slot was released", err)
+	}
+}
+
+func TestBridgeClassifiesBatchTools(t *testing.T) {
+	b := &Bridge{batchTools: []string{"export_*"}}
+	tests := map[string]Message{
+		ClassBatch:       {Method: "tools/call", Params: map[string]any{"name": "export_all"}},
+		ClassInteractive: {Method: "tools/call", Params: map[string]any{"name": "search"}},
+	}
+	for want, request := range tests {
+		if got := b.class(request); got != want {
+			t.Errorf("class(%v) = %s, want %s", request.Params["name"], got, want)
+		}
+	}
+	if got := b.class(Message{Method: "tools/list"}); got != ClassInteractive {
+		t.Errorf("class(tools/list) = %s, want interactive", got)
+	}
+}
+
+func TestFIFO(t *testing.T) {
+	var q fifo
+	wait1, done1 := q.enter()
+	wait2, done2 := q.enter()
+	wait3, done3 := q.enter()
+	if err := wait1(context.Background()); err != nil {
+		t.Fatalf("first wait() error = %v", err)
+	}
+
+	// The second request gives up, but the third still waits for the first
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := wait2(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("second wait() error = %v, want it canceled", err)
+	}
+	done2()
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := wait3(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("third wait() error = %v before the first was done, want a deadline", err)
+	}
+
+	done1()
+	done1()
+	if err := wait3(context.Background()); err != nil {
+		t.Errorf("third wait() error = %v after the first was done", err)
+	}
+	done3()
+}
+
+func TestBridgeOrdersSessionRequests(t *testing.T) {
+	t.Setenv("BRIDGE_TEST_UPSTREAM", "1")
+	upstream, err := StartUpstream(os.Args[0], nil)
+	if err != nil {
+		t.Fatalf("StartUpstream() error = %v", err)
+	}
+	t.Cleanup(func() { _ = upstream.Close() })
+
+	now := clock.NewFake(time.Unix(0, 0))
+	b, err := New(context.Background(), upstream, Options{
+		AuditLog:    &bytes.Buffer{},
+		Keys:        Keys{"key-alice": {Tenant: "acme", User: "alice"}},
+		CallTimeout: time.Minute,
+		Clock:       now,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	server := httptest.NewServer(b)
+	t.Cleanup(server.Close)
+
+	initialize := `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}`
+	resp, _ := post(t, server.URL, "key-alice", initialize)
+	first := resp.Header.Get(SessionHeader)
+	resp, _ = post(t, server.URL, "key-alice", initialize)
+	second := resp.Header.Get(SessionHeader)
+
+	b.sessions.mu.Lock()
+	order := &b.sessions.byID[first].order
+	b.sessions.mu.Unlock()
+	last := func() chan struct{} {
+		order.mu.Lock()
+		defer order.mu.Unlock()
+		return order.last
+	}
+
+	// A call the server never answers holds up the later requests of its session until it times
+	// out on the clock
+	hung := make(chan map[string]any, 1)
+	go func() {
+		hung <- postUnchecked(server.URL, "key-alice", first, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hang"}}`)
+	}()
+	waitForWaiters(t, now, 1)
+
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+	if _, msg := postSession(t, server.URL, "key-alice", second, call); msg["result"] == nil {
+		t.Fatalf("call of another session failed: %v", msg)
+	}
+
+	ahead := last()
+	later := make(chan map[string]any, 1)
+	go func() { later <- postUnchecked(server.URL, "key-alice", first, call) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for last() == ahead {
+		if time.Now().After(deadline) {
+			t.Fatal("the later call of the session never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case msg := <-later:
+		t.Fatalf("later call of the session was answered before the earlier one ended: %v", msg)
+	case msg := <-hung:
+		t.Fatalf("hanging call was answered before it timed out: %v", msg)
+	default:
+	}
+
+	now.Advance(time.Minute)
+	if msg := <-hung; msg["error"] == nil {
+		t.Errorf("hanging call = %v, want a timeout", msg)
+	}
+	if msg := <-later; msg["result"] == nil {
+		t.Errorf("later call of the session failed: %v", msg)
+	}
+}
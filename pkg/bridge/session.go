@@ -0,0 +1,304 @@
+package bridge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+	"github.com/f/mcptools/pkg/notify"
+)
+
+// SessionHeader carries the session ID issued in response to initialize.
+const SessionHeader = "Mcp-Session-Id"
+
+// DefaultRole is the role of identities that do not name one.
+const DefaultRole = "default"
+
+// ErrQuotaExceeded is wrapped by the error that terminates a session over its quota.
+var ErrQuotaExceeded = errors.New("session quota exceeded")
+
+// ErrSessionStore is wrapped by errors of the session store, such as Redis being unreachable.
+var ErrSessionStore = errors.New("session store unavailable")
+
+// Quota limits what one session may do. Zero fields are unlimited.
+type Quota struct {
+	MaxCalls    int64    `json:"max_calls,omitempty"`
+	MaxBytes    int64    `json:"max_bytes,omitempty"`
+	MaxDuration Duration `json:"max_duration,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "10m" in JSON.
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"10m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Quotas maps roles to the quota of their sessions.
+type Quotas map[string]Quota
+
+// LoadQuotas reads a JSON file mapping roles to quotas:
+//
+//	{"agent": {"max_calls": 100, "max_bytes": 1048576, "max_duration": "10m"}}
+func LoadQuotas(path string) (Quotas, error) {
+	// #nosec G304 - the quotas file path is provided explicitly by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quotas file: %w", err)
+	}
+
+	var quotas Quotas
+	if err = json.Unmarshal(data, &quotas); err != nil {
+		return nil, fmt.Errorf("failed to parse quotas file: %w", err)
+	}
+	return quotas, nil
+}
+
+// For returns the quota of id's role, and whether there is one.
+func (q Quotas) For(id Identity) (Quota, bool) {
+	quota, ok := q[id.role()]
+	return quota, ok
+}
+
+// session is an initialized client connected to this bridge. Its usage is kept in the session
+// store, so it can be shared with other bridges.
+type session struct {
+	stream   *notify.Queue[*Message]
+	id       string
+	identity Identity
+	quota    Quota
+	started  time.Time
+	order    fifo
+}
+
+// exceeded returns which limit of its quota a session went over, or "" if none.
+func exceeded(record SessionRecord, now time.Time) string {
+	quota := record.Quota
+	switch {
+	case quota.MaxCalls > 0 && record.Calls > quota.MaxCalls:
+		return fmt.Sprintf("more than %d calls", quota.MaxCalls)
+	case quota.MaxBytes > 0 && record.Bytes > quota.MaxBytes:
+		return fmt.Sprintf("more than %d bytes transferred", quota.MaxBytes)
+	case quota.MaxDuration > 0 && now.Sub(record.Started) > time.Duration(quota.MaxDuration):
+		return fmt.Sprintf("open for more than %s", time.Duration(quota.MaxDuration))
+	}
+	return ""
+}
+
+// remaining returns how long the session may still run, or 0 if it has no time limit.
+func (s *session) remaining(now time.Time) time.Duration {
+	if s.quota.MaxDuration <= 0 {
+		return 0
+	}
+	return time.Duration(s.quota.MaxDuration) - now.Sub(s.started)
+}
+
+// sessions holds the sessions of a bridge: their state in a store, and the notification streams
+// of those whose clients are connected to this bridge.
+type sessions struct {
+	store SessionStore
+	// adopt is called with the record of a session created by another bridge sharing the store
+	// when its client first reaches this one.
+	adopt func(SessionRecord)
+	byID  map[string]*session
+	clock clock.Clock
+	mu    sync.Mutex
+}
+
+func newSessions(store SessionStore, c clock.Clock) *sessions {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &sessions{store: store, byID: make(map[string]*session), clock: c}
+}
+
+// create starts a session for id and returns its ID.
+func (s *sessions) create(ctx context.Context, id Identity, quota Quota) (string, error) {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	sessionID := hex.EncodeToString(buf)
+
+	sess := &session{id: sessionID, identity: id, quota: quota, started: s.clock.Now()}
+	record := SessionRecord{Identity: id, Quota: quota, Started: sess.started}
+	if err := s.store.Create(ctx, sessionID, record); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSessionStore, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byID[sessionID] = sess
+	return sessionID, nil
+}
+
+// get returns the session with sessionID if it belongs to id. Sessions created by other bridges
+// are looked up in the store.
+func (s *sessions) get(ctx context.Context, sessionID string, id Identity) (*session, bool, error) {
+	s.mu.Lock()
+	sess, ok := s.byID[sessionID]
+	s.mu.Unlock()
+	if ok {
+		if sess.identity != id {
+			return nil, false, nil
+		}
+		return sess, true, nil
+	}
+	if sessionID == "" {
+		return nil, false, nil
+	}
+
+	record, found, err := s.store.Get(ctx, sessionID)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrSessionStore, err)
+	}
+	if !found || record.Identity != id {
+		return nil, false, nil
+	}
+
+	s.mu.Lock()
+	if sess, ok = s.byID[sessionID]; ok {
+		s.mu.Unlock()
+		return sess, true, nil
+	}
+	sess = &session{id: sessionID, identity: id, quota: record.Quota, started: record.Started}
+	s.byID[sessionID] = sess
+	s.mu.Unlock()
+
+	if s.adopt != nil {
+		s.adopt(record)
+	}
+	return sess, true, nil
+}
+
+// use records a call transferring n bytes and returns an error once the session is over its
+// quota. The first error terminates the session, and later calls get the same error.
+func (s *sessions) use(ctx context.Context, sess *session, calls, n int64) error {
+	record, err := s.store.Use(ctx, sess.id, calls, n)
+	if errors.Is(err, errUnknownSession) {
+		// Ended through another bridge, or expired
+		s.forget(sess.id)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSessionStore, err)
+	}
+
+	if record.Ended == "" {
+		if record.Ended = exceeded(record, s.clock.Now()); record.Ended == "" {
+			return nil
+		}
+		if err = s.store.End(ctx, sess.id, record.Ended); err != nil {
+			return fmt.Errorf("%w: %w", ErrSessionStore, err)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrQuotaExceeded, record.Ended)
+}
+
+// remaining returns how long a session may still run.
+func (s *sessions) remaining(sess *session) time.Duration {
+	return sess.remaining(s.clock.Now())
+}
+
+// remove ends the session with sessionID if it belongs to id.
+func (s *sessions) remove(ctx context.Context, sessionID string, id Identity) (bool, error) {
+	if _, ok, err := s.get(ctx, sessionID, id); !ok || err != nil {
+		return false, err
+	}
+	if err := s.store.Delete(ctx, sessionID); err != nil {
+		return false, fmt.Errorf("%w: %w", ErrSessionStore, err)
+	}
+	s.forget(sessionID)
+	return true, nil
+}
+
+// forget closes the stream of a session and drops it from this bridge.
+func (s *sessions) forget(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.byID[sessionID]; ok {
+		if sess.stream != nil {
+			sess.stream.Close()
+		}
+		delete(s.byID, sessionID)
+	}
+}
+
+// subscribe records that a session subscribed to a resource, or unsubscribed from it, so
+// bridges adopting the session subscribe their server too.
+func (s *sessions) subscribe(ctx context.Context, sess *session, uri string, subscribed bool) error {
+	if err := s.store.Subscribe(ctx, sess.id, uri, subscribed); err != nil {
+		return fmt.Errorf("%w: %w", ErrSessionStore, err)
+	}
+	return nil
+}
+
+// listen opens a notification stream for a session holding up to size notifications. A stream
+// the session already had is closed.
+func (s *sessions) listen(sess *session, size int) *notify.Queue[*Message] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess.stream != nil {
+		sess.stream.Close()
+	}
+	sess.stream = notify.NewQueue[*Message](size)
+	return sess.stream
+}
+
+// queue returns the notification stream of a session, opening one holding up to size
+// notifications if it has none. Unlike listen it keeps an open stream, so long-polling clients
+// get the notifications sent between their polls.
+func (s *sessions) queue(sess *session, size int) *notify.Queue[*Message] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess.stream == nil {
+		sess.stream = notify.NewQueue[*Message](size)
+	}
+	return sess.stream
+}
+
+// unlisten closes stream and detaches it from its session, unless it was replaced already.
+func (s *sessions) unlisten(sess *session, stream *notify.Queue[*Message]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream.Close()
+	if sess.stream == stream {
+		sess.stream = nil
+	}
+}
+
+// broadcast queues a server notification on the stream of every session that has one, without
+// blocking. It returns the number of notifications dropped from full streams.
+func (s *sessions) broadcast(msg *Message) int {
+	key := notify.Key(msg.Method, msg.Params)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dropped := 0
+	for _, sess := range s.byID {
+		if sess.stream != nil {
+			dropped += sess.stream.Push(key, msg)
+		}
+	}
+	return dropped
+}
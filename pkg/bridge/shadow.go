@@ -0,0 +1,233 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+// Outcomes of a mirrored request, used to label metrics.
+const (
+	ShadowMatch    = "match"
+	ShadowMismatch = "mismatch"
+	ShadowError    = "error"
+	ShadowDropped  = "dropped"
+)
+
+// shadowTimeout bounds how long a mirrored request may take before it counts as failed.
+const shadowTimeout = time.Minute
+
+// shadowConcurrency bounds the mirrored requests in flight, so a slow shadow server cannot pile
+// up work in the bridge. Requests beyond it are not mirrored.
+const shadowConcurrency = 64
+
+// maxDiffPaths bounds the paths listed in a ShadowRecord.
+const maxDiffPaths = 20
+
+// Shadow mirrors every request a bridge forwards to a second server and compares the responses.
+// Its responses never reach clients, and its notifications and failures are ignored.
+type Shadow struct {
+	// Name identifies the shadow server in logs and reports, e.g. its alias.
+	Name string
+	// Upstream is the shadow server. The bridge initializes it like the upstream server, and it
+	// has its own circuit breaker: while it is open, requests are not mirrored.
+	Upstream *Upstream
+	// Log receives a JSON ShadowRecord per line for every response that differs. If nil,
+	// differences are only counted.
+	Log io.Writer
+
+	slots chan struct{}
+	logMu sync.Mutex
+}
+
+// ShadowRecord is one line of the shadow log: a request whose responses differ.
+type ShadowRecord struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Target     string    `json:"target,omitempty"`
+	Paths      []string  `json:"paths"`
+	Upstream   *Message  `json:"upstream"`
+	Shadow     *Message  `json:"shadow,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	UpstreamMS int64     `json:"upstream_ms"`
+	ShadowMS   int64     `json:"shadow_ms"`
+}
+
+// ShadowStats compares the shadow server with the upstream server.
+type ShadowStats struct {
+	Requests              int64   `json:"requests"`
+	Matches               int64   `json:"matches"`
+	Mismatches            int64   `json:"mismatches"`
+	Errors                int64   `json:"errors"`
+	Dropped               int64   `json:"dropped"`
+	UpstreamMeanLatencyMS float64 `json:"upstreamMeanLatencyMs"`
+	ShadowMeanLatencyMS   float64 `json:"shadowMeanLatencyMs"`
+	upstream              time.Duration
+	shadow                time.Duration
+}
+
+// ShadowReport is served at /shadow.
+type ShadowReport struct {
+	Shadow string `json:"shadow"`
+	ShadowStats
+}
+
+// mirror sends a request to the shadow server in the background and returns the function the
+// caller hands the upstream server's response to, once it has it, for comparison. Without a
+// shadow server it does nothing.
+func (b *Bridge) mirror(entry AuditRecord, params map[string]any) func(*Message, time.Duration) {
+	if b.shadow == nil {
+		return func(*Message, time.Duration) {}
+	}
+
+	select {
+	case b.shadow.slots <- struct{}{}:
+	default:
+		b.metrics.ObserveShadow(ShadowDropped, 0, 0)
+		return func(*Message, time.Duration) {}
+	}
+
+	type primary struct {
+		response *Message
+		duration time.Duration
+	}
+	primaries := make(chan primary, 1)
+
+	go func() {
+		defer func() { <-b.shadow.slots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+		defer cancel()
+		start := b.clock.Now()
+		response, err := b.call(ctx, b.shadow.Upstream, entry.Method, params)
+		duration := clock.Since(b.clock, start)
+
+		p := <-primaries
+		if errors.Is(err, ErrCircuitOpen) {
+			b.metrics.ObserveShadow(ShadowDropped, 0, 0)
+			return
+		}
+		if p.response == nil {
+			// The upstream server did not answer, so there is nothing to compare with
+			return
+		}
+
+		record := ShadowRecord{
+			Time:       start.UTC(),
+			Method:     entry.Method,
+			Target:     entry.Target,
+			Upstream:   p.response,
+			UpstreamMS: p.duration.Milliseconds(),
+			ShadowMS:   duration.Milliseconds(),
+		}
+		switch {
+		case err != nil:
+			record.Error = err.Error()
+			b.metrics.ObserveShadow(ShadowError, p.duration, duration)
+		default:
+			response.ID = nil
+			record.Shadow = response
+			record.Paths = diffResponses(p.response, response)
+			if len(record.Paths) == 0 {
+				b.metrics.ObserveShadow(ShadowMatch, p.duration, duration)
+				return
+			}
+			b.metrics.ObserveShadow(ShadowMismatch, p.duration, duration)
+		}
+		b.shadow.write(record)
+	}()
+
+	return func(response *Message, duration time.Duration) {
+		// The caller goes on to change the response, so the comparison gets its own copy
+		if response != nil {
+			copied := *response
+			copied.ID = nil
+			response = &copied
+		}
+		primaries <- primary{response: response, duration: duration}
+	}
+}
+
+func (s *Shadow) write(record ShadowRecord) {
+	if s.Log == nil {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	_, _ = s.Log.Write(append(data, '\n'))
+}
+
+func (b *Bridge) handleShadow(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ShadowReport{Shadow: b.shadow.Name, ShadowStats: b.metrics.Shadow()})
+}
+
+// diffResponses returns the paths at which the results or errors of two responses differ, such
+// as result.content[0].text, or nil if they hold the same values.
+func diffResponses(a, b *Message) []string {
+	var paths []string
+	diffJSON("result", decodeRaw(a.Result), decodeRaw(b.Result), &paths)
+	diffJSON("error", decodeRaw(a.Error), decodeRaw(b.Error), &paths)
+	if len(paths) > maxDiffPaths {
+		paths = append(paths[:maxDiffPaths], fmt.Sprintf("... %d more", len(paths)-maxDiffPaths))
+	}
+	return paths
+}
+
+func decodeRaw(data json.RawMessage) any {
+	var value any
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &value)
+	}
+	return value
+}
+
+func diffJSON(path string, a, b any, paths *[]string) {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(x)+len(y))
+		for key := range x {
+			keys = append(keys, key)
+		}
+		for key := range y {
+			if _, found := x[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			diffJSON(path+"."+key, x[key], y[key], paths)
+		}
+		return
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			break
+		}
+		for i := range x {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), x[i], y[i], paths)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*paths = append(*paths, path)
+	}
+}
@@ -0,0 +1,145 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to write from the goroutines comparing shadow responses.
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newShadowBridge(t *testing.T, shadowServer string, log *syncBuffer) *httptest.Server {
+	t.Helper()
+
+	t.Setenv("BRIDGE_TEST_UPSTREAM", "1")
+	t.Setenv("BRIDGE_TEST_SERVER", "stable")
+	upstream, err := StartUpstream(os.Args[0], nil)
+	if err != nil {
+		t.Fatalf("StartUpstream() error = %v", err)
+	}
+	t.Cleanup(func() { _ = upstream.Close() })
+
+	t.Setenv("BRIDGE_TEST_SERVER", shadowServer)
+	shadow, err := StartUpstream(os.Args[0], nil)
+	if err != nil {
+		t.Fatalf("StartUpstream() error = %v", err)
+	}
+	t.Cleanup(func() { _ = shadow.Close() })
+
+	b, err := New(context.Background(), upstream, Options{
+		AuditLog: &bytes.Buffer{},
+		Keys:     Keys{"key-alice": {Tenant: "acme", User: "alice"}},
+		Shadow:   &Shadow{Name: "fs-v2", Upstream: shadow, Log: log},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	server := httptest.NewServer(b)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// waitShadowReport polls /shadow until n mirrored requests were compared.
+func waitShadowReport(t *testing.T, url string, n int64) ShadowReport {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(url + "/shadow")
+		if err != nil {
+			t.Fatalf("GET /shadow failed: %v", err)
+		}
+		var report ShadowReport
+		err = json.NewDecoder(resp.Body).Decode(&report)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatalf("invalid shadow report: %v", err)
+		}
+		if report.Requests >= n || time.Now().After(deadline) {
+			return report
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestShadowLogsDifferences(t *testing.T) {
+	log := &syncBuffer{}
+	server := newShadowBridge(t, "rewrite", log)
+
+	_, msg := post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":"c1","method":"tools/call","params":{"name":"echo"}}`)
+	if got := answeredBy(msg); got != "stable" || msg["id"] != "c1" {
+		t.Errorf("response = %v, want the bridged server's with the client's id", msg)
+	}
+
+	report := waitShadowReport(t, server.URL, 1)
+	if report.Shadow != "fs-v2" || report.Requests != 1 || report.Mismatches != 1 {
+		t.Errorf("report = %+v, want one mismatch", report)
+	}
+
+	var record ShadowRecord
+	if err := json.Unmarshal([]byte(strings.TrimSpace(log.String())), &record); err != nil {
+		t.Fatalf("invalid shadow log %q: %v", log.String(), err)
+	}
+	if record.Method != "tools/call" || record.Target != "echo" || !reflect.DeepEqual(record.Paths, []string{"result.server"}) {
+		t.Errorf("record = %+v, want a difference in result.server of tools/call echo", record)
+	}
+}
+
+func TestShadowCountsMatches(t *testing.T) {
+	log := &syncBuffer{}
+	server := newShadowBridge(t, "stable", log)
+
+	post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}`)
+
+	report := waitShadowReport(t, server.URL, 2)
+	if report.Matches != 2 || report.Mismatches != 0 {
+		t.Errorf("report = %+v, want two matches", report)
+	}
+	if log.String() != "" {
+		t.Errorf("shadow log = %q, want nothing for matching responses", log.String())
+	}
+}
+
+func TestDiffResponses(t *testing.T) {
+	a := &Message{Result: json.RawMessage(`{"content":[{"type":"text","text":"a"}],"isError":false}`)}
+	b := &Message{Result: json.RawMessage(`{"content":[{"type":"text","text":"b"}],"extra":1}`)}
+
+	want := []string{"result.content[0].text", "result.extra", "result.isError"}
+	if got := diffResponses(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffResponses() = %v, want %v", got, want)
+	}
+	if got := diffResponses(a, a); got != nil {
+		t.Errorf("diffResponses() of equal responses = %v, want nil", got)
+	}
+
+	failed := &Message{Error: json.RawMessage(`{"code":-32000,"message":"boom"}`)}
+	if got := diffResponses(a, failed); !reflect.DeepEqual(got, []string{"result", "error"}) {
+		t.Errorf("diffResponses() = %v, want result and error", got)
+	}
+}
@@ -0,0 +1,291 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/redis"
+)
+
+// DefaultSessionTTL is how long a session kept in Redis lives after it was last used.
+const DefaultSessionTTL = 24 * time.Hour
+
+// SessionRecord is the state of a session kept in a SessionStore, shared by the bridges using
+// the store. Notification streams stay with the bridge a client is connected to.
+type SessionRecord struct {
+	Started       time.Time `json:"started"`
+	Identity      Identity  `json:"identity"`
+	Quota         Quota     `json:"quota"`
+	Ended         string    `json:"ended,omitempty"`
+	Subscriptions []string  `json:"subscriptions,omitempty"`
+	Calls         int64     `json:"calls"`
+	Bytes         int64     `json:"bytes"`
+}
+
+// SessionStore keeps the sessions of one or more bridges. Bridges sharing a store accept each
+// other's session IDs, so clients behind a load balancer can reconnect to any of them.
+type SessionStore interface {
+	// Create stores a new session.
+	Create(ctx context.Context, sessionID string, record SessionRecord) error
+	// Get returns a session, and whether it exists.
+	Get(ctx context.Context, sessionID string) (SessionRecord, bool, error)
+	// Use adds to the calls and bytes of a session and returns its updated record.
+	Use(ctx context.Context, sessionID string, calls, n int64) (SessionRecord, error)
+	// End records why a session was terminated, unless it was already.
+	End(ctx context.Context, sessionID, reason string) error
+	// Subscribe adds a resource to the subscriptions of a session, or removes it.
+	Subscribe(ctx context.Context, sessionID, uri string, subscribed bool) error
+	// Delete removes a session.
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// errUnknownSession is returned by stores for sessions that do not exist.
+var errUnknownSession = errors.New("unknown session")
+
+// OpenSessionStore opens the session store at a URL, redis://[user:password@]host[:port][/db]
+// or rediss:// for Redis over TLS, and checks that it is reachable.
+func OpenSessionStore(ctx context.Context, rawURL string) (SessionStore, error) {
+	client, err := redis.Open(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = client.Do(ctx, "PING"); err != nil {
+		return nil, fmt.Errorf("session store: %w", err)
+	}
+	return &RedisStore{Client: client, TTL: DefaultSessionTTL}, nil
+}
+
+// MemoryStore keeps sessions in memory. It is the store of a bridge that shares no sessions.
+type MemoryStore struct {
+	records map[string]*SessionRecord
+	mu      sync.Mutex
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*SessionRecord)}
+}
+
+// Create implements SessionStore.
+func (m *MemoryStore) Create(_ context.Context, sessionID string, record SessionRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[sessionID] = &record
+	return nil
+}
+
+// Get implements SessionStore.
+func (m *MemoryStore) Get(_ context.Context, sessionID string) (SessionRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[sessionID]
+	if !ok {
+		return SessionRecord{}, false, nil
+	}
+	copied := *record
+	copied.Subscriptions = slices.Clone(record.Subscriptions)
+	return copied, true, nil
+}
+
+// Use implements SessionStore.
+func (m *MemoryStore) Use(_ context.Context, sessionID string, calls, n int64) (SessionRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[sessionID]
+	if !ok {
+		return SessionRecord{}, errUnknownSession
+	}
+	record.Calls += calls
+	record.Bytes += n
+	copied := *record
+	copied.Subscriptions = slices.Clone(record.Subscriptions)
+	return copied, nil
+}
+
+// End implements SessionStore.
+func (m *MemoryStore) End(_ context.Context, sessionID, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if record, ok := m.records[sessionID]; ok && record.Ended == "" {
+		record.Ended = reason
+	}
+	return nil
+}
+
+// Subscribe implements SessionStore.
+func (m *MemoryStore) Subscribe(_ context.Context, sessionID, uri string, subscribed bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[sessionID]
+	if !ok {
+		return errUnknownSession
+	}
+	index := slices.Index(record.Subscriptions, uri)
+	switch {
+	case subscribed && index < 0:
+		record.Subscriptions = append(record.Subscriptions, uri)
+	case !subscribed && index >= 0:
+		record.Subscriptions = slices.Delete(record.Subscriptions, index, index+1)
+	}
+	return nil
+}
+
+// Delete implements SessionStore.
+func (m *MemoryStore) Delete(_ context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, sessionID)
+	return nil
+}
+
+// RedisStore keeps sessions in Redis, so bridges sharing the server share sessions. Each
+// session is a hash at mcptools:session:<id> holding its record and counters, with its
+// subscriptions in a set at mcptools:session:<id>:subscriptions. Both expire TTL after the
+// session was last used.
+type RedisStore struct {
+	Client *redis.Client
+	TTL    time.Duration
+}
+
+func (s *RedisStore) key(sessionID string) string {
+	return "mcptools:session:" + sessionID
+}
+
+func (s *RedisStore) ttl() string {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return strconv.FormatInt(int64(ttl/time.Second), 10)
+}
+
+// Create implements SessionStore.
+func (s *RedisStore) Create(ctx context.Context, sessionID string, record SessionRecord) error {
+	data, err := json.Marshal(SessionRecord{Started: record.Started, Identity: record.Identity, Quota: record.Quota})
+	if err != nil {
+		return err
+	}
+	key := s.key(sessionID)
+	_, err = s.exec(ctx,
+		[]string{"HSET", key, "record", string(data), "calls", "0", "bytes", "0"},
+		[]string{"EXPIRE", key, s.ttl()})
+	return err
+}
+
+// Get implements SessionStore.
+func (s *RedisStore) Get(ctx context.Context, sessionID string) (SessionRecord, bool, error) {
+	key := s.key(sessionID)
+	replies, err := s.exec(ctx, []string{"HGETALL", key}, []string{"SMEMBERS", key + ":subscriptions"})
+	if err != nil {
+		return SessionRecord{}, false, err
+	}
+	return s.decode(replies[0], replies[1])
+}
+
+// Use implements SessionStore.
+func (s *RedisStore) Use(ctx context.Context, sessionID string, calls, n int64) (SessionRecord, error) {
+	key := s.key(sessionID)
+	replies, err := s.exec(ctx,
+		[]string{"HINCRBY", key, "calls", strconv.FormatInt(calls, 10)},
+		[]string{"HINCRBY", key, "bytes", strconv.FormatInt(n, 10)},
+		[]string{"EXPIRE", key, s.ttl()},
+		[]string{"EXPIRE", key + ":subscriptions", s.ttl()},
+		[]string{"HGETALL", key},
+		[]string{"SMEMBERS", key + ":subscriptions"})
+	if err != nil {
+		return SessionRecord{}, err
+	}
+	record, found, err := s.decode(replies[4], replies[5])
+	if err == nil && !found {
+		// HINCRBY created a hash without a record, as the session expired in the meantime
+		_, _ = s.Client.Do(ctx, "DEL", key)
+		err = errUnknownSession
+	}
+	return record, err
+}
+
+// End implements SessionStore.
+func (s *RedisStore) End(ctx context.Context, sessionID, reason string) error {
+	_, err := s.Client.Do(ctx, "HSETNX", s.key(sessionID), "ended", reason)
+	return err
+}
+
+// Subscribe implements SessionStore.
+func (s *RedisStore) Subscribe(ctx context.Context, sessionID, uri string, subscribed bool) error {
+	key := s.key(sessionID) + ":subscriptions"
+	if !subscribed {
+		_, err := s.Client.Do(ctx, "SREM", key, uri)
+		return err
+	}
+	_, err := s.exec(ctx, []string{"SADD", key, uri}, []string{"EXPIRE", key, s.ttl()})
+	return err
+}
+
+// Delete implements SessionStore.
+func (s *RedisStore) Delete(ctx context.Context, sessionID string) error {
+	key := s.key(sessionID)
+	_, err := s.Client.Do(ctx, "DEL", key, key+":subscriptions")
+	return err
+}
+
+// exec runs commands in a MULTI/EXEC transaction and returns their replies.
+func (s *RedisStore) exec(ctx context.Context, commands ...[]string) ([]any, error) {
+	pipeline := append([][]string{{"MULTI"}}, commands...)
+	pipeline = append(pipeline, []string{"EXEC"})
+	replies, err := s.Client.Pipeline(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range replies {
+		if replyErr, ok := reply.(redis.Error); ok {
+			return nil, replyErr
+		}
+	}
+	results, ok := replies[len(replies)-1].([]any)
+	if !ok || len(results) != len(commands) {
+		return nil, fmt.Errorf("redis: transaction was aborted")
+	}
+	for _, result := range results {
+		if replyErr, isErr := result.(redis.Error); isErr {
+			return nil, replyErr
+		}
+	}
+	return results, nil
+}
+
+// decode builds a record from the HGETALL reply of a session hash and the SMEMBERS reply of its
+// subscriptions.
+func (s *RedisStore) decode(hash, members any) (SessionRecord, bool, error) {
+	fields, _ := hash.([]any)
+	values := map[string]string{}
+	for i := 0; i+1 < len(fields); i += 2 {
+		name, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		values[name] = value
+	}
+	if values["record"] == "" {
+		return SessionRecord{}, false, nil
+	}
+
+	var record SessionRecord
+	if err := json.Unmarshal([]byte(values["record"]), &record); err != nil {
+		return SessionRecord{}, false, fmt.Errorf("invalid session record: %w", err)
+	}
+	record.Calls, _ = strconv.ParseInt(values["calls"], 10, 64)
+	record.Bytes, _ = strconv.ParseInt(values["bytes"], 10, 64)
+	record.Ended = values["ended"]
+	items, _ := members.([]any)
+	for _, item := range items {
+		if uri, ok := item.(string); ok {
+			record.Subscriptions = append(record.Subscriptions, uri)
+		}
+	}
+	slices.Sort(record.Subscriptions)
+	return record, true, nil
+}
@@ -0,0 +1,99 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/f/mcptools/pkg/redis"
+	"github.com/f/mcptools/pkg/redis/redistest"
+)
+
+func TestBridgesShareSessions(t *testing.T) {
+	redisServer := redistest.NewServer(t)
+	store, err := OpenSessionStore(context.Background(), redisServer.URL())
+	if err != nil {
+		t.Fatalf("OpenSessionStore() error = %v", err)
+	}
+
+	var quotas Quotas
+	if err = json.Unmarshal([]byte(`{"agent":{"max_calls":2}}`), &quotas); err != nil {
+		t.Fatalf("failed to parse quotas: %v", err)
+	}
+	first := httptest.NewServer(newBridge(t, &bytes.Buffer{}, quotas, store))
+	defer first.Close()
+	second := httptest.NewServer(newBridge(t, &bytes.Buffer{}, quotas, store))
+	defer second.Close()
+
+	resp, _ := post(t, first.URL, "key-agent", `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}`)
+	sessionID := resp.Header.Get(SessionHeader)
+	if sessionID == "" {
+		t.Fatal("initialize did not return a session ID")
+	}
+	if ttl := redisServer.TTL("mcptools:session:" + sessionID); ttl != int64(DefaultSessionTTL.Seconds()) {
+		t.Errorf("session TTL = %d, want %d", ttl, int64(DefaultSessionTTL.Seconds()))
+	}
+
+	subscribe := `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"file:///a"}}`
+	if _, msg := postSession(t, first.URL, "key-agent", sessionID, subscribe); msg["result"] == nil {
+		t.Fatalf("subscribe failed: %v", msg)
+	}
+
+	// The session continues on the second bridge, with the calls made on the first counted
+	req, _ := http.NewRequest(http.MethodGet, second.URL+"/mcp", nil)
+	req.Header.Set("Authorization", "Bearer key-agent")
+	req.Header.Set(SessionHeader, sessionID)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil || stream.StatusCode != http.StatusOK {
+		t.Fatalf("stream on the second bridge: %v, want 200", err)
+	}
+	_ = stream.Body.Close()
+
+	if resp, _ = postSession(t, second.URL, "key-alice", sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("session of another key: status = %d, want 404", resp.StatusCode)
+	}
+	if _, msg := postSession(t, second.URL, "key-agent", sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`); msg["result"] == nil {
+		t.Fatalf("call on the second bridge failed: %v", msg)
+	}
+	if resp, _ = postSession(t, first.URL, "key-agent", sessionID, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("third call of a session limited to 2: status = %d, want 404", resp.StatusCode)
+	}
+
+	record, found, err := store.Get(context.Background(), sessionID)
+	if err != nil || !found {
+		t.Fatalf("Get() = %v, %v", found, err)
+	}
+	if record.Calls != 3 || record.Ended != "more than 2 calls" || len(record.Subscriptions) != 1 ||
+		record.Subscriptions[0] != "file:///a" || record.Identity.User != "bot" {
+		t.Errorf("unexpected session record %+v", record)
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, second.URL+"/mcp", nil)
+	req.Header.Set("Authorization", "Bearer key-agent")
+	req.Header.Set(SessionHeader, sessionID)
+	if resp, err = http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE on the second bridge: %v, want 204", err)
+	}
+	if _, found, _ = store.Get(context.Background(), sessionID); found {
+		t.Error("deleted session is still in the store")
+	}
+}
+
+func TestSessionStoreUnavailable(t *testing.T) {
+	if _, err := OpenSessionStore(context.Background(), "redis://127.0.0.1:1"); err == nil {
+		t.Error("OpenSessionStore() of an unreachable server succeeded")
+	}
+
+	// The store becomes unreachable while the bridge runs
+	client, _ := redis.Open("redis://127.0.0.1:1")
+	server := httptest.NewServer(newBridge(t, &bytes.Buffer{}, nil, &RedisStore{Client: client}))
+	defer server.Close()
+
+	resp, msg := post(t, server.URL, "key-alice", `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}`)
+	if resp.StatusCode != http.StatusServiceUnavailable || msg["error"] == nil {
+		t.Errorf("initialize without a store: status = %d, %v, want 503 and an error", resp.StatusCode, msg)
+	}
+}
@@ -0,0 +1,239 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/f/mcptools/pkg/stdio"
+)
+
+// shutdownGrace is how long Shutdown waits for the server to exit after closing its input, and
+// again after SIGTERM.
+var shutdownGrace = 5 * time.Second
+
+// ErrUpstreamClosed is returned for requests that cannot complete because the server exited.
+var ErrUpstreamClosed = errors.New("upstream server exited")
+
+// Message is a JSON-RPC message as seen by the bridge. Params are kept decoded so _meta can be
+// added; results and errors are passed through untouched.
+type Message struct {
+	Params  map[string]any  `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// Upstream is a stdio MCP server shared by all downstream clients. Requests are given
+// bridge-wide IDs so responses can be routed back to the client that sent them.
+type Upstream struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	pending  map[string]chan *Message
+	notify   func(*Message)
+	done     chan struct{}
+	waitErr  error
+	nextID   int64
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+	waitOnce sync.Once
+}
+
+// StartUpstream launches command with args and starts reading its responses.
+func StartUpstream(command string, args []string) (*Upstream, error) {
+	// #nosec G204 - the command is provided explicitly by the user
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	u := &Upstream{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[string]chan *Message),
+		done:    make(chan struct{}),
+	}
+	go u.read(stdout)
+
+	return u, nil
+}
+
+// Call sends a request to the server and waits for its response. The response carries the
+// bridge-wide ID; callers restore the client's own ID before replying.
+func (u *Upstream) Call(ctx context.Context, method string, params map[string]any) (*Message, error) {
+	u.mu.Lock()
+	u.nextID++
+	id := strconv.FormatInt(u.nextID, 10)
+	reply := make(chan *Message, 1)
+	u.pending[id] = reply
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		delete(u.pending, id)
+		u.mu.Unlock()
+	}()
+
+	if err := u.send(&Message{JSONRPC: "2.0", ID: json.RawMessage(id), Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-reply:
+		return response, nil
+	case <-u.done:
+		return nil, ErrUpstreamClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Notify sends a notification to the server.
+func (u *Upstream) Notify(method string, params map[string]any) error {
+	return u.send(&Message{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// SetNotificationHandler makes handler receive the notifications sent by the server. It is
+// called from the loop reading the server's output, so it must not block.
+func (u *Upstream) SetNotificationHandler(handler func(*Message)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.notify = handler
+}
+
+// Done is closed once the server's output ends.
+func (u *Upstream) Done() <-chan struct{} {
+	return u.done
+}
+
+// PID returns the process ID of the server.
+func (u *Upstream) PID() int {
+	return u.cmd.Process.Pid
+}
+
+// Close stops the server.
+func (u *Upstream) Close() error {
+	_ = u.stdin.Close()
+	if u.cmd.Process != nil {
+		_ = u.cmd.Process.Kill()
+	}
+	return u.wait()
+}
+
+// Shutdown stops the server the way the MCP stdio transport asks clients to: it closes the
+// server's input and waits for it to exit, sends SIGTERM if it has not within shutdownGrace,
+// and kills it if it still has not after another shutdownGrace.
+func (u *Upstream) Shutdown() error {
+	_ = u.stdin.Close()
+	if u.exited(shutdownGrace) {
+		return u.wait()
+	}
+	// SIGTERM is not supported on Windows, where the server is killed right away
+	if err := u.cmd.Process.Signal(syscall.SIGTERM); err == nil && u.exited(shutdownGrace) {
+		return u.wait()
+	}
+	_ = u.cmd.Process.Kill()
+	return u.wait()
+}
+
+// exited reports whether the server closed its output, as it does on exit, within timeout.
+func (u *Upstream) exited(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-u.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// wait waits for the server to exit, once, and returns how it exited.
+func (u *Upstream) wait() error {
+	u.waitOnce.Do(func() { u.waitErr = u.cmd.Wait() })
+	return u.waitErr
+}
+
+func (u *Upstream) send(msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	u.writeMu.Lock()
+	defer u.writeMu.Unlock()
+
+	if _, err = u.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%w: %w", ErrUpstreamClosed, err)
+	}
+	return nil
+}
+
+// read routes responses to the waiting callers until the server's output ends.
+func (u *Upstream) read(stdout io.Reader) {
+	defer close(u.done)
+
+	reader := bufio.NewReader(stdio.NewReader(stdout))
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			u.handle(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (u *Upstream) handle(line []byte) {
+	var msg Message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		fmt.Fprintf(os.Stderr, "bridge: ignoring invalid message from server: %v\n", err)
+		return
+	}
+
+	switch {
+	case msg.Method != "" && len(msg.ID) > 0:
+		// Requests from the server, e.g. sampling, cannot be tied to one downstream client
+		_ = u.send(&Message{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error:   json.RawMessage(`{"code":-32601,"message":"method not supported by the bridge"}`),
+		})
+	case msg.Method != "":
+		u.mu.Lock()
+		notify := u.notify
+		u.mu.Unlock()
+		if notify != nil {
+			notify(&msg)
+		}
+	default:
+		u.mu.Lock()
+		reply, ok := u.pending[string(msg.ID)]
+		u.mu.Unlock()
+		if ok {
+			reply <- &msg
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package browse
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSegments(t *testing.T) {
+	tests := map[string][]string{
+		"file:///etc/hosts":        {"file://", "etc", "hosts"},
+		"test://static/resource/1": {"test://", "static", "resource", "1"},
+		"memo://":                  {"memo://", "/"},
+		"urn:isbn:0451450523":      {"urn:", "isbn:0451450523"},
+		"plain":                    {"plain"},
+	}
+	for uri, want := range tests {
+		if got := Segments(uri); !reflect.DeepEqual(got, want) {
+			t.Errorf("Segments(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+func testTree() *Node {
+	return BuildTree([]Resource{
+		{URI: "file:///tmp/b.txt", Name: "b"},
+		{URI: "file:///tmp/a.txt", Name: "a"},
+		{URI: "file:///readme.md", Name: "readme"},
+		{URI: "test://static/1", Name: "one"},
+	})
+}
+
+func TestBuildTree(t *testing.T) {
+	var buf bytes.Buffer
+	Print(&buf, testTree())
+
+	want := `file://
+  tmp
+    a.txt  file:///tmp/a.txt
+    b.txt  file:///tmp/b.txt
+  readme.md  file:///readme.md
+test://
+  static
+    1  test://static/1
+`
+	if buf.String() != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestBuildTreeDuplicateURI(t *testing.T) {
+	root := BuildTree([]Resource{{URI: "a://x"}, {URI: "a://x"}, {URI: "a://x/y"}})
+	x := root.Children[0].Children[0]
+	if x.Resource == nil || len(x.Children) != 2 {
+		t.Fatalf("expected x to keep its resource and hold 2 children, got %+v", x)
+	}
+}
+
+func TestBrowserNavigation(t *testing.T) {
+	var read []string
+	b := New(testTree(), func(uri string) (string, error) {
+		read = append(read, uri)
+		return "hello\nworld", nil
+	})
+
+	if got := b.Selected().Name; got != "file://" {
+		t.Fatalf("expected file:// selected first, got %s", got)
+	}
+
+	// Expand file://, move into it and expand tmp
+	b.Handle(KeyRight)
+	b.Handle(KeyRight)
+	if got := b.Selected().Name; got != "tmp" {
+		t.Fatalf("expected tmp selected, got %s", got)
+	}
+	b.Handle(KeyEnter)
+	b.Handle(KeyDown)
+	if got := b.Selected().Name; got != "a.txt" {
+		t.Fatalf("expected a.txt selected, got %s", got)
+	}
+
+	b.Handle(KeyRight)
+	if !reflect.DeepEqual(read, []string{"file:///tmp/a.txt"}) {
+		t.Errorf("expected a.txt to be read, got %v", read)
+	}
+	if b.Preview() != "hello\nworld" {
+		t.Errorf("unexpected preview %q", b.Preview())
+	}
+
+	// Left goes to the parent, then collapses it
+	b.Handle(KeyLeft)
+	if got := b.Selected().Name; got != "tmp" {
+		t.Fatalf("expected tmp selected, got %s", got)
+	}
+	b.Handle(KeyLeft)
+	b.Handle(KeyEnd)
+	if got := b.Selected().Name; got != "test://" {
+		t.Errorf("expected test:// last, got %s", got)
+	}
+
+	if b.Handle(KeyQuit) {
+		t.Error("expected quit to stop the browser")
+	}
+}
+
+func TestBrowserReadError(t *testing.T) {
+	b := New(BuildTree([]Resource{{URI: "x://y"}}), func(string) (string, error) {
+		return "", errors.New("boom")
+	})
+	b.Handle(KeyRight)
+	b.Handle(KeyRight)
+	b.Handle(KeyRight)
+	if !strings.Contains(b.Status(), "boom") || b.Preview() != "" {
+		t.Errorf("expected the error in the status, got %q", b.Status())
+	}
+}
+
+func TestBrowserCopy(t *testing.T) {
+	var clipboard bytes.Buffer
+	b := New(BuildTree([]Resource{{URI: "x://y"}}), nil)
+	b.Clipboard = &clipboard
+
+	b.Handle(KeyCopy)
+	if clipboard.Len() != 0 {
+		t.Error("expected groups not to be copied")
+	}
+
+	b.Handle(KeyRight)
+	b.Handle(KeyRight)
+	b.Handle(KeyCopy)
+	if got := clipboard.String(); got != "\x1b]52;c;eDovL3k=\a" {
+		t.Errorf("unexpected clipboard sequence %q", got)
+	}
+	if b.Status() != "Copied x://y" {
+		t.Errorf("unexpected status %q", b.Status())
+	}
+}
+
+func TestRender(t *testing.T) {
+	b := New(testTree(), func(string) (string, error) { return "line 1\nline 2", nil })
+	screen := b.Render(40, 10)
+	lines := strings.Split(screen, "\r\n")
+	if len(lines) != 10 {
+		t.Fatalf("expected 10 lines, got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], "> ▸ file://") {
+		t.Errorf("expected the cursor on file://, got %q", lines[0])
+	}
+
+	b.Handle(KeyRight)
+	b.Handle(KeyDown)
+	b.Handle(KeyDown)
+	b.Handle(KeyEnter)
+	lines = strings.Split(b.Render(40, 10), "\r\n")
+	if len(lines) != 10 {
+		t.Fatalf("expected 10 lines, got %d", len(lines))
+	}
+	if !strings.Contains(b.Render(40, 10), "line 2") {
+		t.Errorf("expected the preview on screen:\n%s", b.Render(40, 10))
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	keys := bufio.NewReader(strings.NewReader("\x1b[A\x1b[Bjkq\r\x1b[5~c"))
+	want := []Key{KeyUp, KeyDown, KeyDown, KeyUp, KeyQuit, KeyEnter, KeyNone, KeyCopy}
+	for i, w := range want {
+		got, err := ReadKey(keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != w {
+			t.Errorf("key %d: got %v, want %v", i, got, w)
+		}
+	}
+}
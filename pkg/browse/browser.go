@@ -0,0 +1,352 @@
+package browse
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Key is a key pressed in the browser.
+type Key int
+
+// Keys the browser responds to.
+const (
+	KeyNone Key = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyEnter
+	KeyHome
+	KeyEnd
+	KeyCopy
+	KeyQuit
+)
+
+// ReadKey reads a key press from a terminal in raw mode. Arrow keys arrive as escape
+// sequences; j, k, h and l move like them, c and y copy, and q, Escape and Ctrl-C quit.
+func ReadKey(r *bufio.Reader) (Key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return KeyNone, err
+	}
+
+	switch b {
+	case 0x1b:
+		if r.Buffered() == 0 {
+			return KeyQuit, nil
+		}
+		next, _ := r.ReadByte()
+		if next != '[' && next != 'O' {
+			return KeyNone, nil
+		}
+		code, _ := r.ReadByte()
+		switch code {
+		case 'A':
+			return KeyUp, nil
+		case 'B':
+			return KeyDown, nil
+		case 'C':
+			return KeyRight, nil
+		case 'D':
+			return KeyLeft, nil
+		case 'H':
+			return KeyHome, nil
+		case 'F':
+			return KeyEnd, nil
+		}
+		// Skip the rest of sequences like "\x1b[5~"
+		for code >= '0' && code <= '9' && r.Buffered() > 0 {
+			code, _ = r.ReadByte()
+		}
+		return KeyNone, nil
+	case 'k':
+		return KeyUp, nil
+	case 'j':
+		return KeyDown, nil
+	case 'h':
+		return KeyLeft, nil
+	case 'l':
+		return KeyRight, nil
+	case '\r', '\n':
+		return KeyEnter, nil
+	case 'g':
+		return KeyHome, nil
+	case 'G':
+		return KeyEnd, nil
+	case 'c', 'y':
+		return KeyCopy, nil
+	case 'q', 0x03:
+		return KeyQuit, nil
+	}
+	return KeyNone, nil
+}
+
+// ReadFunc returns the text content of a resource.
+type ReadFunc func(uri string) (string, error)
+
+// Browser is the state of an interactive browsing session: the tree, the rows it shows and the
+// selected row, and the preview of the last resource opened.
+type Browser struct {
+	root    *Node
+	rows    []*Node
+	cursor  int
+	offset  int
+	read    ReadFunc
+	preview string
+	status  string
+
+	// Clipboard receives the escape sequence that copies a URI to the clipboard of the terminal.
+	Clipboard io.Writer
+}
+
+// New creates a browser for the tree of root, previewing resources with read.
+func New(root *Node, read ReadFunc) *Browser {
+	b := &Browser{root: root, read: read}
+	b.refresh()
+	return b
+}
+
+// Selected returns the node at the cursor, or nil if the tree is empty.
+func (b *Browser) Selected() *Node {
+	if b.cursor < 0 || b.cursor >= len(b.rows) {
+		return nil
+	}
+	return b.rows[b.cursor]
+}
+
+// Preview returns the content of the last resource opened.
+func (b *Browser) Preview() string {
+	return b.preview
+}
+
+// Status returns the message about the last action.
+func (b *Browser) Status() string {
+	return b.status
+}
+
+// refresh flattens the expanded part of the tree into rows.
+func (b *Browser) refresh() {
+	b.rows = b.rows[:0]
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		for _, c := range n.Children {
+			b.rows = append(b.rows, c)
+			if c.Expanded {
+				walk(c)
+			}
+		}
+	}
+	walk(b.root)
+	if b.cursor >= len(b.rows) {
+		b.cursor = len(b.rows) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// Handle applies a key press and reports whether the browser should keep running.
+func (b *Browser) Handle(key Key) bool {
+	node := b.Selected()
+	switch key {
+	case KeyQuit:
+		return false
+	case KeyUp:
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case KeyDown:
+		if b.cursor < len(b.rows)-1 {
+			b.cursor++
+		}
+	case KeyHome:
+		b.cursor = 0
+	case KeyEnd:
+		b.cursor = len(b.rows) - 1
+	case KeyRight, KeyEnter:
+		switch {
+		case node == nil:
+		case !node.IsLeaf() && !node.Expanded:
+			node.Expanded = true
+			b.refresh()
+		case !node.IsLeaf() && key == KeyRight:
+			b.cursor++
+		case !node.IsLeaf():
+			node.Expanded = false
+			b.refresh()
+		case node.Resource != nil:
+			b.open(node.Resource)
+		}
+	case KeyLeft:
+		switch {
+		case node == nil:
+		case node.Expanded:
+			node.Expanded = false
+			b.refresh()
+		case node.Parent != nil && node.Parent != b.root:
+			for i, row := range b.rows {
+				if row == node.Parent {
+					b.cursor = i
+					break
+				}
+			}
+		}
+	case KeyCopy:
+		b.copy(node)
+	}
+	return true
+}
+
+// open previews the content of a resource.
+func (b *Browser) open(resource *Resource) {
+	if b.read == nil {
+		return
+	}
+	content, err := b.read(resource.URI)
+	if err != nil {
+		b.preview, b.status = "", fmt.Sprintf("Failed to read %s: %v", resource.URI, err)
+		return
+	}
+	b.preview, b.status = content, resource.URI
+}
+
+// copy puts the URI of the selected resource on the clipboard with an OSC 52 escape sequence,
+// which most terminals support, also over SSH.
+func (b *Browser) copy(node *Node) {
+	if node == nil || node.Resource == nil {
+		b.status = "Select a resource to copy its URI"
+		return
+	}
+	if b.Clipboard == nil {
+		b.status = node.Resource.URI
+		return
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte(node.Resource.URI))
+	if _, err := fmt.Fprintf(b.Clipboard, "\x1b]52;c;%s\a", encoded); err != nil {
+		b.status = fmt.Sprintf("Failed to copy: %v", err)
+		return
+	}
+	b.status = "Copied " + node.Resource.URI
+}
+
+// helpLine lists the keys at the bottom of the screen.
+const helpLine = "↑/↓ move  → expand/preview  ← collapse  c copy URI  q quit"
+
+// Render draws the browser on a screen of width by height: the tree at the top, the preview
+// below it and the status and keys at the bottom. Lines are separated by "\r\n" for terminals
+// in raw mode.
+func (b *Browser) Render(width, height int) string {
+	if width < 20 {
+		width = 20
+	}
+	if height < 6 {
+		height = 6
+	}
+
+	// The tree gets half the screen when there is a preview, and all of it otherwise
+	treeHeight := height - 2
+	if b.preview != "" {
+		treeHeight = (height - 3) / 2
+	}
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+treeHeight {
+		b.offset = b.cursor - treeHeight + 1
+	}
+
+	lines := make([]string, 0, height)
+	for i := b.offset; i < len(b.rows) && i < b.offset+treeHeight; i++ {
+		lines = append(lines, b.row(i, width))
+	}
+	if len(b.rows) == 0 {
+		lines = append(lines, "No resources")
+	}
+	for len(lines) < treeHeight {
+		lines = append(lines, "")
+	}
+
+	if b.preview != "" {
+		lines = append(lines, strings.Repeat("─", width))
+		previewHeight := height - 2 - len(lines)
+		for _, line := range strings.Split(b.preview, "\n") {
+			if previewHeight == 0 {
+				break
+			}
+			lines = append(lines, truncate(strings.TrimRight(line, "\r"), width))
+			previewHeight--
+		}
+		for ; previewHeight > 0; previewHeight-- {
+			lines = append(lines, "")
+		}
+	}
+
+	lines = append(lines, truncate(b.status, width), truncate(helpLine, width))
+	return strings.Join(lines, "\r\n")
+}
+
+// row renders the node at index i of the rows.
+func (b *Browser) row(i, width int) string {
+	node := b.rows[i]
+	marker := "  "
+	switch {
+	case !node.IsLeaf() && node.Expanded:
+		marker = "▾ "
+	case !node.IsLeaf():
+		marker = "▸ "
+	}
+	line := strings.Repeat("  ", node.depth()) + marker + node.Name
+	if node.Resource != nil && node.Resource.Name != "" && node.Resource.Name != node.Name {
+		line += "  " + node.Resource.Name
+	}
+
+	cursor := "  "
+	if i == b.cursor {
+		cursor = "> "
+	}
+	line = truncate(cursor+line, width)
+	if i == b.cursor {
+		return "\x1b[7m" + line + "\x1b[0m"
+	}
+	return line
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// Run shows the browser on out until the user quits, reading keys from in, which must be a
+// terminal in raw mode. size returns the current size of the terminal.
+func (b *Browser) Run(in io.Reader, out io.Writer, size func() (int, int)) error {
+	keys := bufio.NewReader(in)
+
+	// Use the alternate screen so the browser leaves the scrollback as it was
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	for {
+		width, height := size()
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+b.Render(width, height))
+
+		key, err := ReadKey(keys)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !b.Handle(key) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package browse lets people explore the resources of a server as a tree built from their URIs.
+package browse
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Resource is a resource listed by a server.
+type Resource struct {
+	URI         string
+	Name        string
+	MimeType    string
+	Description string
+}
+
+// Node is a segment of a URI in the tree. Nodes for the last segment of a URI hold its
+// resource; other nodes only group their children.
+type Node struct {
+	Name     string
+	Resource *Resource
+	Children []*Node
+	Parent   *Node
+	Expanded bool
+}
+
+// IsLeaf reports whether n has no children.
+func (n *Node) IsLeaf() bool {
+	return len(n.Children) == 0
+}
+
+// depth returns the number of ancestors of n below the root.
+func (n *Node) depth() int {
+	d := 0
+	for p := n.Parent; p != nil && p.Parent != nil; p = p.Parent {
+		d++
+	}
+	return d
+}
+
+// Segments splits a URI into the segments of its tree path: the scheme, the host and each path
+// segment. "file:///etc/hosts" gives file://, etc and hosts, and "test://static/resource/1"
+// gives test://, static, resource and 1. URIs without // keep everything after the scheme as
+// one segment.
+func Segments(uri string) []string {
+	scheme, rest, found := strings.Cut(uri, "://")
+	if !found {
+		if scheme, rest, found = strings.Cut(uri, ":"); !found || scheme == "" {
+			return []string{uri}
+		}
+		return []string{scheme + ":", rest}
+	}
+
+	segments := []string{scheme + "://"}
+	for _, part := range strings.Split(rest, "/") {
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	if len(segments) == 1 {
+		// Nothing after the scheme, e.g. "memo://"
+		segments = append(segments, "/")
+	}
+	return segments
+}
+
+// BuildTree builds the tree of resources by URI segments. Children are sorted with groups
+// before resources, then by name, and only the first level is expanded.
+func BuildTree(resources []Resource) *Node {
+	root := &Node{Expanded: true}
+	for i := range resources {
+		node := root
+		for _, segment := range Segments(resources[i].URI) {
+			node = child(node, segment)
+		}
+		if node.Resource != nil {
+			// The same URI listed twice, or a URI that is also the prefix of others, keeps a
+			// leaf of its own
+			node = addChild(node, "(resource)")
+		}
+		node.Resource = &resources[i]
+	}
+	sortTree(root)
+	return root
+}
+
+func child(parent *Node, name string) *Node {
+	for _, c := range parent.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return addChild(parent, name)
+}
+
+func addChild(parent *Node, name string) *Node {
+	c := &Node{Name: name, Parent: parent}
+	parent.Children = append(parent.Children, c)
+	return c
+}
+
+func sortTree(n *Node) {
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.IsLeaf() != b.IsLeaf() {
+			return !a.IsLeaf()
+		}
+		return a.Name < b.Name
+	})
+	for _, c := range n.Children {
+		sortTree(c)
+	}
+}
+
+// Print writes the whole tree as indented text, for output that is not a terminal.
+func Print(w io.Writer, root *Node) {
+	var walk func(n *Node, indent string)
+	walk = func(n *Node, indent string) {
+		for _, c := range n.Children {
+			line := indent + c.Name
+			if c.Resource != nil {
+				line += "  " + c.Resource.URI
+				if c.Resource.MimeType != "" {
+					line += " (" + c.Resource.MimeType + ")"
+				}
+			}
+			fmt.Fprintln(w, line)
+			walk(c, indent+"  ")
+		}
+	}
+	walk(root, "")
+}
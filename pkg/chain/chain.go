@@ -0,0 +1,285 @@
+// Package chain suggests how to feed the output of one tool into the input of another by
+// matching the fields of their schemas.
+package chain
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// textOutput is the source used for tools without an output schema: the text of their first
+// content item.
+const textOutput = "content[0].text"
+
+// Field is a field of a schema, with its dotted path from the root of the schema. Items of
+// arrays are written as name[].
+type Field struct {
+	Path        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// Fields flattens the properties of an object schema into fields, depth first and by name.
+func Fields(schema map[string]any) []Field {
+	var fields []Field
+	collect(schema, "", true, &fields)
+	return fields
+}
+
+func collect(schema map[string]any, prefix string, required bool, fields *[]Field) {
+	properties, _ := schema["properties"].(map[string]any)
+	requiredNames := map[string]bool{}
+	if list, ok := schema["required"].([]any); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				requiredNames[s] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, _ := properties[name].(map[string]any)
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		field := Field{
+			Path:     path,
+			Type:     schemaType(property),
+			Required: required && requiredNames[name],
+		}
+		field.Description, _ = property["description"].(string)
+		*fields = append(*fields, field)
+
+		switch field.Type {
+		case "object":
+			collect(property, path, field.Required, fields)
+		case "array":
+			if items, ok := property["items"].(map[string]any); ok && schemaType(items) == "object" {
+				collect(items, path+"[]", false, fields)
+			}
+		}
+	}
+}
+
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		// The first non-null type of a union such as ["string", "null"]
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// Mapping feeds a field of the output of the first tool into a parameter of the second.
+type Mapping struct {
+	Param  string
+	Source string
+	Reason string
+	Score  int
+}
+
+// synonyms groups words that name the same thing in different tools.
+var synonyms = [][]string{
+	{"path", "file", "filename", "filepath", "file_path"},
+	{"url", "uri", "link", "href"},
+	{"content", "contents", "text", "body", "data"},
+	{"name", "title", "label"},
+	{"query", "q", "search", "term"},
+	{"dir", "directory", "folder"},
+	{"message", "msg"},
+}
+
+// normalize lowercases a name and drops separators, so filePath, file_path and file-path match.
+func normalize(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// lastSegment returns the last name of a field path, without array markers.
+func lastSegment(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		path = path[i+1:]
+	}
+	return strings.TrimSuffix(path, "[]")
+}
+
+// score rates how likely source feeds param, from 0 for unrelated fields to 100 for fields of
+// the same name and type.
+func score(source, param Field) (int, string) {
+	a, b := normalize(lastSegment(source.Path)), normalize(lastSegment(param.Path))
+
+	points, reason := 0, ""
+	switch {
+	case a == b:
+		points, reason = 80, "same name"
+	case sameGroup(a, b):
+		points, reason = 60, "related names"
+	case len(a) >= 2 && len(b) >= 2 && (strings.HasSuffix(a, b) || strings.HasSuffix(b, a)):
+		// userId and id, or repoName and name
+		points, reason = 40, "similar names"
+	default:
+		return 0, ""
+	}
+
+	switch {
+	case source.Type == "" || param.Type == "":
+	case source.Type == param.Type:
+		points += 20
+	case source.Type == "integer" && param.Type == "number":
+		points += 10
+	case param.Type == "string" && (source.Type == "integer" || source.Type == "number"):
+		points += 5
+	default:
+		return 0, ""
+	}
+	return points, reason
+}
+
+func sameGroup(a, b string) bool {
+	for _, group := range synonyms {
+		inA, inB := false, false
+		for _, word := range group {
+			word = normalize(word)
+			inA = inA || a == word
+			inB = inB || b == word
+		}
+		if inA && inB {
+			return true
+		}
+	}
+	return false
+}
+
+// Suggest proposes a source for each top-level parameter of the second tool. outputs are the
+// fields of the output schema of the first tool; without them, the text output of the first tool
+// is proposed for the first required string parameter. Parameters without a plausible source
+// are left out.
+func Suggest(outputs, inputs []Field) []Mapping {
+	var mappings []Mapping
+	for _, param := range inputs {
+		if strings.Contains(param.Path, ".") || strings.Contains(param.Path, "[]") {
+			continue
+		}
+		best := Mapping{Param: param.Path}
+		for _, source := range outputs {
+			if points, reason := score(source, param); points > best.Score {
+				best.Source, best.Reason, best.Score = source.Path, reason, points
+			}
+		}
+		if best.Score > 0 {
+			mappings = append(mappings, best)
+		}
+	}
+
+	if len(outputs) == 0 {
+		for _, param := range inputs {
+			if param.Required && (param.Type == "string" || param.Type == "") && !strings.Contains(param.Path, ".") {
+				return []Mapping{{Param: param.Path, Source: textOutput, Reason: "text output", Score: 10}}
+			}
+		}
+	}
+	return mappings
+}
+
+// Workflow renders a workflow skeleton calling from and then to. Each parameter of to is set
+// from its mapping as a {{ steps.<tool>.result.<field> }} reference, or left empty for the
+// author to fill in; comments explain each choice.
+func Workflow(from, to string, fromInputs, toInputs []Field, mappings []Mapping) ([]byte, error) {
+	byParam := map[string]Mapping{}
+	for _, m := range mappings {
+		byParam[m.Param] = m
+	}
+
+	// Step IDs must be unique when a tool is chained into itself
+	toID := to
+	if to == from {
+		toID = to + "_2"
+	}
+
+	steps := &yaml.Node{Kind: yaml.SequenceNode}
+	steps.Content = append(steps.Content,
+		step(from, from, fromInputs, func(Field) (string, string) { return "", "" }),
+		step(toID, to, toInputs, func(param Field) (string, string) {
+			m, ok := byParam[param.Path]
+			if !ok {
+				return "", ""
+			}
+			return fmt.Sprintf("{{ steps.%s.result.%s }}", from, m.Source),
+				fmt.Sprintf("from %s.result.%s (%s)", from, m.Source, m.Reason)
+		}),
+	)
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	doc.HeadComment = fmt.Sprintf("Workflow skeleton chaining %s into %s; review every mapping", from, to)
+	doc.Content = append(doc.Content, scalar("steps"), steps)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{doc}}); err != nil {
+		return nil, fmt.Errorf("failed to render workflow: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// step renders the step id calling tool with its top-level parameters, valued by value.
+func step(id, tool string, inputs []Field, value func(Field) (string, string)) *yaml.Node {
+	params := &yaml.Node{Kind: yaml.MappingNode}
+	for _, param := range inputs {
+		if strings.Contains(param.Path, ".") || strings.Contains(param.Path, "[]") {
+			continue
+		}
+		v, comment := value(param)
+		if comment == "" {
+			comment = "TODO"
+			if param.Required {
+				comment = "TODO, required"
+			}
+		}
+		if param.Description != "" {
+			comment += ": " + param.Description
+		}
+		node := scalar(v)
+		node.Style = yaml.DoubleQuotedStyle
+		node.LineComment = comment
+		params.Content = append(params.Content, scalar(param.Path), node)
+	}
+
+	s := &yaml.Node{Kind: yaml.MappingNode}
+	s.Content = append(s.Content, scalar("id"), scalar(id), scalar("tool"), scalar(tool))
+	if len(params.Content) > 0 {
+		s.Content = append(s.Content, scalar("params"), params)
+	}
+	return s
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
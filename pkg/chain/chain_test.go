@@ -0,0 +1,106 @@
+package chain
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func schema(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFields(t *testing.T) {
+	fields := Fields(schema(t, `{
+		"type": "object",
+		"required": ["match"],
+		"properties": {
+			"match": {"type": "object", "required": ["path"], "properties": {
+				"path": {"type": "string"},
+				"line": {"type": ["integer", "null"]}
+			}},
+			"items": {"type": "array", "items": {"type": "object", "properties": {"id": {"type": "string"}}}}
+		}
+	}`))
+
+	want := []Field{
+		{Path: "items", Type: "array"},
+		{Path: "items[].id", Type: "string"},
+		{Path: "match", Type: "object", Required: true},
+		{Path: "match.line", Type: "integer"},
+		{Path: "match.path", Type: "string", Required: true},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Fields() =\n%+v\nwant\n%+v", fields, want)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	outputs := []Field{
+		{Path: "result.path", Type: "string"},
+		{Path: "result.size", Type: "integer"},
+		{Path: "result.ownerId", Type: "string"},
+	}
+	inputs := []Field{
+		{Path: "file", Type: "string", Required: true},
+		{Path: "id", Type: "string"},
+		{Path: "size", Type: "number"},
+		{Path: "encoding", Type: "string"},
+		{Path: "size", Type: "boolean"},
+	}
+
+	got := Suggest(outputs, inputs)
+	want := []Mapping{
+		{Param: "file", Source: "result.path", Reason: "related names", Score: 80},
+		{Param: "id", Source: "result.ownerId", Reason: "similar names", Score: 60},
+		{Param: "size", Source: "result.size", Reason: "same name", Score: 90},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSuggestTextOutput(t *testing.T) {
+	inputs := []Field{{Path: "limit", Type: "integer", Required: true}, {Path: "content", Type: "string", Required: true}}
+	got := Suggest(nil, inputs)
+	want := []Mapping{{Param: "content", Source: textOutput, Reason: "text output", Score: 10}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWorkflow(t *testing.T) {
+	fromInputs := []Field{{Path: "pattern", Type: "string", Required: true, Description: "Glob to search"}}
+	toInputs := []Field{{Path: "file", Type: "string", Required: true}, {Path: "encoding", Type: "string"}}
+	mappings := []Mapping{{Param: "file", Source: "path", Reason: "related names", Score: 80}}
+
+	out, err := Workflow("search", "read", fromInputs, toInputs, mappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Workflow skeleton chaining search into read; review every mapping
+steps:
+  - id: search
+    tool: search
+    params:
+      pattern: "" # TODO, required: Glob to search
+  - id: read
+    tool: read
+    params:
+      file: "{{ steps.search.result.path }}" # from search.result.path (related names)
+      encoding: "" # TODO
+`
+	if string(out) != want {
+		t.Errorf("unexpected workflow:\n%s\nwant:\n%s", out, want)
+	}
+	if strings.Contains(string(out), "\t") {
+		t.Error("expected no tabs in YAML")
+	}
+}
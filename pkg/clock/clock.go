@@ -0,0 +1,221 @@
+/*
+Package clock abstracts wall time and randomness behind interfaces, so that code that
+timestamps, waits, backs off or samples can run deterministically: in tests, with a Fake clock
+and a Seeded source, and when replaying sessions, with a Replay clock that follows the times of
+a recording.
+*/
+package clock
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel that receives the current time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the wall clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// OrReal returns c, or the wall clock if c is nil, so that a nil Clock in options means real
+// time.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// Since returns the time elapsed on c since t.
+func Since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Until returns the time left on c until t.
+func Until(c Clock, t time.Time) time.Duration {
+	return t.Sub(c.Now())
+}
+
+// Sleep waits for d to elapse on c, or for ctx to be done, in which case it returns the error of
+// ctx.
+func Sleep(ctx context.Context, c Clock, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.After(d):
+		return nil
+	}
+}
+
+// Fake is a Clock that only moves when told to. Waits end once the clock is advanced past them.
+// It is safe for concurrent use.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+// waiter is a wait on a Fake clock.
+type waiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewFake returns a Fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now returns the time the clock is set to.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives the time once the clock is advanced by d. Waits of zero
+// or less end right away.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.waiters = append(f.waiters, waiter{at: f.now.Add(d), c: c})
+	return c
+}
+
+// Advance moves the clock forward by d, ending the waits that are then over.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set(f.now.Add(d))
+}
+
+// Set sets the clock to t, ending the waits that are then over. Setting it back in time does
+// not restart waits that ended.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set(t)
+}
+
+func (f *Fake) set(t time.Time) {
+	f.now = t
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+	over := 0
+	for over < len(f.waiters) && !f.waiters[over].at.After(t) {
+		f.waiters[over].c <- t
+		over++
+	}
+	f.waiters = f.waiters[over:]
+}
+
+// Waiters returns the number of waits that have not ended, so a test can advance the clock once
+// the code under test waits.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// Replay is a Clock following the times of a recording: each call of Now returns the next of
+// them, and the last one once they run out. Waits end right away, having no wall time to take.
+// It is safe for concurrent use.
+type Replay struct {
+	mu    sync.Mutex
+	times []time.Time
+	next  int
+}
+
+// NewReplay returns a clock telling times in turn.
+func NewReplay(times []time.Time) *Replay {
+	return &Replay{times: times}
+}
+
+// Now returns the next recorded time, or the zero time if there are none.
+func (r *Replay) Now() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.times) == 0 {
+		return time.Time{}
+	}
+	t := r.times[min(r.next, len(r.times)-1)]
+	r.next++
+	return t
+}
+
+// After returns a channel that has already received the current time.
+func (r *Replay) After(time.Duration) <-chan time.Time {
+	c := make(chan time.Time, 1)
+	c <- r.Now()
+	return c
+}
+
+// Rand is a source of pseudo-random numbers, as returned by Seeded or the math/rand/v2
+// functions.
+type Rand interface {
+	// Float64 returns a number in [0.0, 1.0).
+	Float64() float64
+	// Int64N returns a number in [0, n). It panics if n <= 0.
+	Int64N(n int64) int64
+}
+
+// System is the randomly seeded source of the math/rand/v2 functions.
+var System Rand = systemRand{}
+
+type systemRand struct{}
+
+// #nosec G404 - callers need no cryptographic randomness
+func (systemRand) Float64() float64 { return rand.Float64() }
+
+// #nosec G404 - callers need no cryptographic randomness
+func (systemRand) Int64N(n int64) int64 { return rand.Int64N(n) }
+
+// OrSystem returns r, or the randomly seeded source if r is nil.
+func OrSystem(r Rand) Rand {
+	if r == nil {
+		return System
+	}
+	return r
+}
+
+// Seeded returns a source producing the same numbers for the same seed. It is safe for
+// concurrent use, though the numbers concurrent callers get then depend on their order.
+func Seeded(seed uint64) Rand {
+	// #nosec G404 - the point is reproducible numbers
+	return &lockedRand{r: rand.New(rand.NewPCG(seed, seed))}
+}
+
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+func (l *lockedRand) Int64N(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int64N(n)
+}
@@ -0,0 +1,90 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFake(t *testing.T) {
+	c := NewFake(epoch)
+	short, long := c.After(time.Second), c.After(time.Minute)
+	if c.Waiters() != 2 {
+		t.Fatalf("Waiters() = %d, want 2", c.Waiters())
+	}
+
+	c.Advance(30 * time.Second)
+	select {
+	case at := <-short:
+		if !at.Equal(epoch.Add(30 * time.Second)) {
+			t.Errorf("the wait ended at %s", at)
+		}
+	default:
+		t.Fatal("a wait of 1s did not end after 30s")
+	}
+	select {
+	case <-long:
+		t.Fatal("a wait of 1m ended after 30s")
+	default:
+	}
+
+	c.Set(epoch.Add(time.Hour))
+	<-long
+	if c.Waiters() != 0 || Since(c, epoch) != time.Hour || Until(c, epoch) != -time.Hour {
+		t.Errorf("after an hour: %d waiters, since %s", c.Waiters(), Since(c, epoch))
+	}
+	<-c.After(0)
+}
+
+func TestSleep(t *testing.T) {
+	c := NewFake(epoch)
+	done := make(chan error, 1)
+	go func() { done <- Sleep(context.Background(), c, time.Second) }()
+	for c.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("Sleep() = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Sleep(ctx, c, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("Sleep() = %v, want the context's error", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	times := []time.Time{epoch, epoch.Add(time.Second)}
+	c := NewReplay(times)
+	for i, want := range []time.Time{epoch, epoch.Add(time.Second), epoch.Add(time.Second)} {
+		if got := c.Now(); !got.Equal(want) {
+			t.Errorf("Now() #%d = %s, want %s", i+1, got, want)
+		}
+	}
+	if got := <-c.After(time.Hour); !got.Equal(epoch.Add(time.Second)) {
+		t.Errorf("After() = %s", got)
+	}
+	if got := NewReplay(nil).Now(); !got.IsZero() {
+		t.Errorf("Now() without times = %s, want the zero time", got)
+	}
+}
+
+func TestSeeded(t *testing.T) {
+	a, b := Seeded(42), Seeded(42)
+	for range 10 {
+		if x, y := a.Int64N(1000), b.Int64N(1000); x != y {
+			t.Fatalf("sources seeded alike differ: %d and %d", x, y)
+		}
+		if x, y := a.Float64(), b.Float64(); x != y || x < 0 || x >= 1 {
+			t.Fatalf("sources seeded alike differ: %f and %f", x, y)
+		}
+	}
+	if OrReal(nil) != Real || OrSystem(nil) != System {
+		t.Error("nil does not default to real time and the system source")
+	}
+}
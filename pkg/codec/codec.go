@@ -0,0 +1,71 @@
+// Package codec converts between the JSON-RPC messages mcp-go works with and the wire format of
+// a stdio server. Newline-delimited JSON is the MCP default; some gateways frame messages as
+// MessagePack instead for efficiency.
+package codec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Codec reads and writes messages in a wire format. Messages are always exchanged with the
+// caller as JSON documents.
+type Codec interface {
+	// Name is the name the codec is selected by.
+	Name() string
+	// ReadMessage reads the next message from r and returns it as JSON. Like
+	// bufio.Reader.ReadBytes, it may return a partial message along with an error.
+	ReadMessage(r *bufio.Reader) ([]byte, error)
+	// WriteMessage writes the JSON message msg to w in a single call.
+	WriteMessage(w io.Writer, msg []byte) error
+}
+
+// codecs holds the available codecs by name.
+var codecs = map[string]Codec{
+	"json":    JSON{},
+	"msgpack": MessagePack{},
+}
+
+// Lookup returns the codec called name. An empty name selects JSON.
+func Lookup(name string) (Codec, error) {
+	if name == "" {
+		return JSON{}, nil
+	}
+	c, ok := codecs[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec: %s (supported: %s)", name, strings.Join(Names(), ", "))
+	}
+	return c, nil
+}
+
+// Names returns the names of the available codecs.
+func Names() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSON is the newline-delimited JSON framing defined by the MCP stdio transport.
+type JSON struct{}
+
+// Name implements Codec.
+func (JSON) Name() string {
+	return "json"
+}
+
+// ReadMessage implements Codec. The message is returned with its line terminator.
+func (JSON) ReadMessage(r *bufio.Reader) ([]byte, error) {
+	return r.ReadBytes('\n')
+}
+
+// WriteMessage implements Codec.
+func (JSON) WriteMessage(w io.Writer, msg []byte) error {
+	_, err := w.Write(append(msg, '\n'))
+	return err
+}
@@ -0,0 +1,397 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// maxDepth bounds the nesting of MessagePack values, so a corrupt stream cannot exhaust the
+// stack.
+const maxDepth = 512
+
+// ErrUnsupportedType is returned for MessagePack extension types, which have no JSON equivalent.
+var ErrUnsupportedType = errors.New("unsupported MessagePack type")
+
+// MessagePack frames every message as a single MessagePack value. MessagePack values are
+// self-delimiting, so no separator is written between messages.
+//
+// Integers are kept exact in both directions, binary strings are converted to base64 strings
+// as encoding/json does for byte slices, and extension types are rejected.
+type MessagePack struct{}
+
+// Name implements Codec.
+func (MessagePack) Name() string {
+	return "msgpack"
+}
+
+// ReadMessage implements Codec.
+func (MessagePack) ReadMessage(r *bufio.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := decodeValue(r, &buf, 0); err != nil {
+		if errors.Is(err, io.EOF) && buf.Len() > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteMessage implements Codec.
+func (MessagePack) WriteMessage(w io.Writer, msg []byte) error {
+	data, err := EncodeMessagePack(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// EncodeMessagePack converts a JSON document to MessagePack.
+func EncodeMessagePack(msg []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(msg))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON message: %w", err)
+	}
+	return appendValue(nil, value)
+}
+
+// DecodeMessagePack converts a MessagePack value to JSON.
+func DecodeMessagePack(data []byte) ([]byte, error) {
+	return MessagePack{}.ReadMessage(bufio.NewReader(bytes.NewReader(data)))
+}
+
+func appendValue(b []byte, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		return appendNumber(b, v)
+	case string:
+		return appendString(b, v), nil
+	case []any:
+		b = appendHeader(b, len(v), 0x90, 16, 0xdc)
+		for _, item := range v {
+			var err error
+			if b, err = appendValue(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b = appendHeader(b, len(v), 0x80, 16, 0xde)
+		for _, key := range keys {
+			b = appendString(b, key)
+			var err error
+			if b, err = appendValue(b, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, value)
+	}
+}
+
+// appendNumber uses the smallest integer format that holds n exactly, and float64 otherwise.
+func appendNumber(b []byte, n json.Number) ([]byte, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return appendInt(b, i), nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return appendUint(b, u), nil
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %s: %w", n, err)
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+}
+
+func appendInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendUint(b, uint64(i))
+	case i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+	}
+}
+
+func appendUint(b []byte, u uint64) []byte {
+	switch {
+	case u <= 0x7f:
+		return append(b, byte(u))
+	case u <= math.MaxUint8:
+		return append(b, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(u))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), u)
+	}
+}
+
+func appendString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendHeader writes the header of an array or map of n items: a fix format below fixLimit,
+// then the 16-bit format and the 32-bit one that follows it.
+func appendHeader(b []byte, n int, fix byte, fixLimit int, format16 byte) []byte {
+	switch {
+	case n < fixLimit:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, format16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, format16+1), uint32(n))
+	}
+}
+
+// decodeValue reads one MessagePack value from r and writes it to buf as JSON.
+func decodeValue(r *bufio.Reader, buf *bytes.Buffer, depth int) error {
+	if depth > maxDepth {
+		return errors.New("MessagePack value nested too deeply")
+	}
+
+	format, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case format <= 0x7f:
+		buf.WriteString(strconv.Itoa(int(format)))
+		return nil
+	case format >= 0xe0:
+		buf.WriteString(strconv.Itoa(int(int8(format))))
+		return nil
+	case format&0xf0 == 0x80:
+		return decodeMap(r, buf, int(format&0x0f), depth)
+	case format&0xf0 == 0x90:
+		return decodeArray(r, buf, int(format&0x0f), depth)
+	case format&0xe0 == 0xa0:
+		return decodeString(r, buf, int(format&0x1f))
+	}
+
+	switch format {
+	case 0xc0:
+		buf.WriteString("null")
+	case 0xc2:
+		buf.WriteString("false")
+	case 0xc3:
+		buf.WriteString("true")
+	case 0xc4, 0xc5, 0xc6:
+		n, lenErr := readLength(r, 1<<(format-0xc4))
+		if lenErr != nil {
+			return lenErr
+		}
+		data, readErr := readN(r, n)
+		if readErr != nil {
+			return readErr
+		}
+		writeJSONString(buf, base64.StdEncoding.EncodeToString(data))
+	case 0xca:
+		data, readErr := readN(r, 4)
+		if readErr != nil {
+			return readErr
+		}
+		return writeFloat(buf, float64(math.Float32frombits(binary.BigEndian.Uint32(data))), 32)
+	case 0xcb:
+		data, readErr := readN(r, 8)
+		if readErr != nil {
+			return readErr
+		}
+		return writeFloat(buf, math.Float64frombits(binary.BigEndian.Uint64(data)), 64)
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, readErr := readUint(r, 1<<(format-0xcc))
+		if readErr != nil {
+			return readErr
+		}
+		buf.WriteString(strconv.FormatUint(u, 10))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (format - 0xd0)
+		u, readErr := readUint(r, size)
+		if readErr != nil {
+			return readErr
+		}
+		// Sign-extend from the encoded width
+		shift := 64 - 8*size
+		buf.WriteString(strconv.FormatInt(int64(u<<shift)>>shift, 10))
+	case 0xd9, 0xda, 0xdb:
+		n, lenErr := readLength(r, 1<<(format-0xd9))
+		if lenErr != nil {
+			return lenErr
+		}
+		return decodeString(r, buf, n)
+	case 0xdc, 0xdd:
+		n, lenErr := readLength(r, 2<<(format-0xdc))
+		if lenErr != nil {
+			return lenErr
+		}
+		return decodeArray(r, buf, n, depth)
+	case 0xde, 0xdf:
+		n, lenErr := readLength(r, 2<<(format-0xde))
+		if lenErr != nil {
+			return lenErr
+		}
+		return decodeMap(r, buf, n, depth)
+	default:
+		return fmt.Errorf("%w: 0x%02x", ErrUnsupportedType, format)
+	}
+	return nil
+}
+
+func decodeArray(r *bufio.Reader, buf *bytes.Buffer, n int, depth int) error {
+	buf.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := decodeValue(r, buf, depth+1); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func decodeMap(r *bufio.Reader, buf *bytes.Buffer, n int, depth int) error {
+	buf.WriteByte('{')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		// JSON only has string keys, so other keys are written as their JSON text
+		var key bytes.Buffer
+		if err := decodeValue(r, &key, depth+1); err != nil {
+			return unexpectedEOF(err)
+		}
+		if key.Len() > 0 && key.Bytes()[0] == '"' {
+			buf.Write(key.Bytes())
+		} else {
+			writeJSONString(buf, key.String())
+		}
+		buf.WriteByte(':')
+
+		if err := decodeValue(r, buf, depth+1); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func decodeString(r *bufio.Reader, buf *bytes.Buffer, n int) error {
+	data, err := readN(r, n)
+	if err != nil {
+		return err
+	}
+	writeJSONString(buf, string(data))
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	data, _ := json.Marshal(s)
+	buf.Write(data)
+}
+
+func writeFloat(buf *bytes.Buffer, f float64, bitSize int) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("MessagePack float %v has no JSON representation", f)
+	}
+	buf.WriteString(strconv.FormatFloat(f, 'g', -1, bitSize))
+	return nil
+}
+
+func readUint(r *bufio.Reader, size int) (uint64, error) {
+	data, err := readN(r, size)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range data {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+func readLength(r *bufio.Reader, size int) (int, error) {
+	u, err := readUint(r, size)
+	if err != nil {
+		return 0, err
+	}
+	if u > math.MaxInt32 {
+		return 0, fmt.Errorf("MessagePack length %d is too large", u)
+	}
+	return int(u), nil
+}
+
+// readN reads n bytes. Large lengths are read incrementally, so a corrupt length does not
+// allocate more memory than the stream actually holds.
+func readN(r *bufio.Reader, n int) ([]byte, error) {
+	if n <= 1<<16 {
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return data, nil
+	}
+
+	var data bytes.Buffer
+	if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return data.Bytes(), nil
+}
+
+// unexpectedEOF reports the end of the stream in the middle of a value as io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessagePackRoundTrip(t *testing.T) {
+	tests := []string{
+		`{"id":1,"jsonrpc":"2.0","method":"tools/list"}`,
+		`{"id":"mcpt-7","jsonrpc":"2.0","result":{"n":[-1,-33,-200,-40000,-3000000000,255,65535,4294967296,18446744073709551615]}}`,
+		`{"a":1.5,"b":-0.25,"c":1e+300,"d":true,"e":false,"f":null,"g":[],"h":{}}`,
+		`{"text":"` + strings.Repeat("ünïcødé ", 10000) + `"}`,
+	}
+
+	for _, want := range tests {
+		data, err := EncodeMessagePack([]byte(want))
+		if err != nil {
+			t.Fatalf("EncodeMessagePack(%.40s) error = %v", want, err)
+		}
+		got, err := DecodeMessagePack(data)
+		if err != nil {
+			t.Fatalf("DecodeMessagePack(%.40s) error = %v", want, err)
+		}
+		if string(got) != want {
+			t.Errorf("round trip = %.200s, want %.200s", got, want)
+		}
+	}
+}
+
+func TestMessagePackStream(t *testing.T) {
+	var stream bytes.Buffer
+	for _, msg := range []string{`{"id":1}`, `{"id":2}`} {
+		if err := (MessagePack{}).WriteMessage(&stream, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A truncated third message
+	stream.Write([]byte{0x81, 0xa2, 'i'})
+
+	r := bufio.NewReader(&stream)
+	for _, want := range []string{`{"id":1}`, `{"id":2}`} {
+		got, err := MessagePack{}.ReadMessage(r)
+		if err != nil || string(got) != want {
+			t.Fatalf("ReadMessage() = %s, %v, want %s", got, err, want)
+		}
+	}
+	if _, err := (MessagePack{}).ReadMessage(r); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadMessage() on a truncated message: error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := (MessagePack{}).ReadMessage(r); !errors.Is(err, io.EOF) {
+		t.Errorf("ReadMessage() at the end: error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestMessagePackDecodeFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"binary as base64", []byte{0xc4, 0x03, 'a', 'b', 'c'}, `"YWJj"`},
+		{"float32", []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, `1.5`},
+		{"integer keys", []byte{0x81, 0x01, 0xa1, 'x'}, `{"1":"x"}`},
+		{"int16", []byte{0xd1, 0xff, 0x00}, `-256`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeMessagePack(tt.data)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("DecodeMessagePack() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := DecodeMessagePack([]byte{0xd4, 0x01, 0x00}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("DecodeMessagePack() of an extension: error = %v, want %v", err, ErrUnsupportedType)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if c, err := Lookup(""); err != nil || c.Name() != "json" {
+		t.Errorf("Lookup(\"\") = %v, %v", c, err)
+	}
+	if c, err := Lookup("MsgPack"); err != nil || c.Name() != "msgpack" {
+		t.Errorf("Lookup(\"MsgPack\") = %v, %v", c, err)
+	}
+	if _, err := Lookup("cbor"); err == nil {
+		t.Error("Lookup(\"cbor\") should fail")
+	}
+}
@@ -0,0 +1,153 @@
+package configfile
+
+import (
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// comment holds the comments on a key: the lines before it and the one after its value.
+type comment struct {
+	head, line string
+}
+
+// commentKey returns the key of the comments on the key at path.
+func commentKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// collectComments returns the comments of a file in format by the path of the key they are on.
+// Comments of TOML arrays of tables are left out.
+func collectComments(data []byte, format Format) (map[string]comment, error) {
+	comments := map[string]comment{}
+	switch format {
+	case YAML:
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+		collectYAMLComments(&node, nil, comments)
+	case TOML:
+		if err := collectTOMLComments(data, comments); err != nil {
+			return nil, err
+		}
+	}
+	return comments, nil
+}
+
+func collectYAMLComments(node *yaml.Node, path []string, comments map[string]comment) {
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			// Comments at the top of the file go on its first key
+			if node.HeadComment != "" && child.Kind == yaml.MappingNode && len(child.Content) > 0 {
+				child.Content[0].HeadComment = joinComments(node.HeadComment, child.Content[0].HeadComment)
+			}
+			collectYAMLComments(child, path, comments)
+		}
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := append(path[:len(path):len(path)], key.Value)
+		c := comment{head: key.HeadComment, line: key.LineComment}
+		if c.line == "" {
+			c.line = value.LineComment
+		}
+		if c.head != "" || c.line != "" {
+			comments[commentKey(keyPath)] = c
+		}
+		collectYAMLComments(value, keyPath, comments)
+	}
+}
+
+func collectTOMLComments(data []byte, comments map[string]comment) error {
+	parser := unstable.Parser{KeepComments: true}
+	parser.Reset(data)
+
+	var table, pending []string
+	inArrayTable := false
+	for parser.NextExpression() {
+		expr := parser.Expression()
+		var path []string
+		switch expr.Kind {
+		case unstable.Comment:
+			pending = append(pending, string(expr.Data))
+			continue
+		case unstable.ArrayTable:
+			inArrayTable, pending = true, nil
+			continue
+		case unstable.Table:
+			inArrayTable = false
+			table = tomlKey(expr)
+			path = table
+		case unstable.KeyValue:
+			if inArrayTable {
+				pending = nil
+				continue
+			}
+			path = append(table[:len(table):len(table)], tomlKey(expr)...)
+		default:
+			continue
+		}
+
+		c := comment{head: strings.Join(pending, "\n")}
+		if next := expr.Next(); next != nil && next.Kind == unstable.Comment {
+			c.line = string(next.Data)
+		}
+		if c.head != "" || c.line != "" {
+			comments[commentKey(path)] = c
+		}
+		pending = nil
+	}
+	return parser.Error()
+}
+
+// tomlKey returns the parts of the dotted key of a table or key-value expression.
+func tomlKey(expr *unstable.Node) []string {
+	var key []string
+	it := expr.Key()
+	for it.Next() {
+		key = append(key, string(it.Node().Data))
+	}
+	return key
+}
+
+// applyComments sets comments on the keys of a YAML node.
+func applyComments(node *yaml.Node, path []string, comments map[string]comment) {
+	if len(comments) == 0 {
+		return
+	}
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			applyComments(child, path, comments)
+		}
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := append(path[:len(path):len(path)], key.Value)
+		if c, ok := comments[commentKey(keyPath)]; ok {
+			key.HeadComment = c.head
+			if value.Kind == yaml.ScalarNode {
+				value.LineComment = c.line
+			} else {
+				key.LineComment = c.line
+			}
+		}
+		applyComments(value, keyPath, comments)
+	}
+}
+
+func joinComments(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n\n" + b
+}
@@ -0,0 +1,236 @@
+// Package configfile reads and writes configuration files in JSON, YAML or TOML, chosen by their
+// extension, and converts them from one format to another.
+//
+// Values are decoded and encoded through JSON, so the json tags of the types they are read into
+// apply in every format.
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of a configuration file.
+type Format string
+
+// Supported formats.
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+	TOML Format = "toml"
+)
+
+// ParseFormat parses a format name: json, yaml, yml or toml.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return JSON, nil
+	case "yaml", "yml":
+		return YAML, nil
+	case "toml":
+		return TOML, nil
+	}
+	return "", fmt.Errorf("unsupported config format %q: expected json, yaml or toml", name)
+}
+
+// FormatOf returns the format of a file by its extension. Files with other extensions are JSON.
+func FormatOf(path string) Format {
+	format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return JSON
+	}
+	return format
+}
+
+// extensions are the extensions of configuration files, in the order Find looks for them.
+var extensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// Find returns the path of the configuration file named base in dir, in whichever format it
+// exists, e.g. dir/aliases.yaml for base aliases, or the JSON path if there is none. Several
+// files in different formats are an error, since which one is used would not be obvious.
+func Find(dir, base string) (string, error) {
+	var found []string
+	for _, ext := range extensions {
+		path := filepath.Join(dir, base+ext)
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return filepath.Join(dir, base+".json"), nil
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("several %s files in %s (%s): keep only one", base, dir, strings.Join(baseNames(found), ", "))
+}
+
+// Path returns the path of the configuration file named base in dir, in format.
+func Path(dir, base string, format Format) string {
+	return filepath.Join(dir, base+"."+string(format))
+}
+
+func baseNames(paths []string) []string {
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+	}
+	return names
+}
+
+// Unmarshal decodes data in format into v.
+func Unmarshal(data []byte, format Format, v any) error {
+	if format == JSON {
+		return json.Unmarshal(data, v)
+	}
+	generic, err := decode(data, format)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Marshal encodes v in format.
+func Marshal(v any, format Format) ([]byte, error) {
+	return Update(nil, v, format)
+}
+
+// Update encodes v in format to replace previous, the content of the file in the same format,
+// if any. In YAML, the comments of previous are kept on the keys v still has; other formats
+// lose them.
+func Update(previous []byte, v any, format Format) ([]byte, error) {
+	if format == JSON {
+		return json.MarshalIndent(v, "", "  ")
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	generic, err := decode(raw, JSON)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments map[string]comment
+	if len(previous) > 0 && format == YAML {
+		if comments, err = collectComments(previous, YAML); err != nil {
+			comments = nil
+		}
+	}
+	return encode(generic, format, comments)
+}
+
+// Convert converts data from one format to another. Comments of YAML and TOML files are carried
+// over to YAML, on the keys they precede or follow; dropped reports whether other conversions
+// left comments out.
+func Convert(data []byte, from, to Format) (converted []byte, dropped bool, err error) {
+	if from == to {
+		return data, false, nil
+	}
+	generic, err := decode(data, from)
+	if err != nil {
+		return nil, false, err
+	}
+	comments, err := collectComments(data, from)
+	if err != nil {
+		return nil, false, err
+	}
+	converted, err = encode(generic, to, comments)
+	return converted, len(comments) > 0 && to != YAML, err
+}
+
+// decode decodes data in format into maps, slices and scalars, with JSON numbers as int64 or
+// float64 like the other formats.
+func decode(data []byte, format Format) (any, error) {
+	var generic any
+	switch format {
+	case JSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&generic); err != nil {
+			return nil, err
+		}
+		return numbers(generic), nil
+	case YAML:
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+	case TOML:
+		if err := toml.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+	if generic == nil {
+		generic = map[string]any{}
+	}
+	return generic, nil
+}
+
+// numbers replaces the JSON numbers in v with int64 or float64 values, and drops null values
+// from maps, which TOML cannot represent.
+func numbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, value := range v {
+			if value == nil {
+				delete(v, key)
+			} else {
+				v[key] = numbers(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = numbers(value)
+		}
+	}
+	return v
+}
+
+// encode encodes generic in format, with comments on the keys of YAML documents.
+func encode(generic any, format Format, comments map[string]comment) ([]byte, error) {
+	switch format {
+	case JSON:
+		return json.MarshalIndent(generic, "", "  ")
+	case TOML:
+		if _, ok := generic.(map[string]any); !ok {
+			return nil, errors.New("TOML files must hold a table at the top level")
+		}
+		return toml.Marshal(generic)
+	case YAML:
+		var node yaml.Node
+		if err := node.Encode(generic); err != nil {
+			return nil, err
+		}
+		applyComments(&node, nil, comments)
+
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&node); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), encoder.Close()
+	}
+	return nil, fmt.Errorf("unsupported config format %q", format)
+}
@@ -0,0 +1,109 @@
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAlias struct {
+	Command  string         `json:"command"`
+	Defaults map[string]any `json:"defaults,omitempty"`
+	Race     bool           `json:"race,omitempty"`
+}
+
+func TestUnmarshalFormats(t *testing.T) {
+	want := map[string]testAlias{"fs": {Command: "fs-server", Defaults: map[string]any{"limit": float64(10)}, Race: true}}
+	files := map[Format]string{
+		JSON: `{"fs": {"command": "fs-server", "defaults": {"limit": 10}, "race": true}}`,
+		YAML: "fs:\n  command: fs-server\n  defaults:\n    limit: 10\n  race: true\n",
+		TOML: "[fs]\ncommand = \"fs-server\"\nrace = true\n\n[fs.defaults]\nlimit = 10\n",
+	}
+	for format, data := range files {
+		var got map[string]testAlias
+		if err := Unmarshal([]byte(data), format, &got); err != nil {
+			t.Fatalf("%s: Unmarshal() error = %v", format, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Unmarshal() = %+v, want %+v", format, got, want)
+		}
+	}
+}
+
+func TestConvertKeepsComments(t *testing.T) {
+	toml := `# Servers of the team
+[fs]
+command = "npx -y fs" # local
+
+[gh]
+# Needs a token
+command = "gh-server"
+`
+	converted, dropped, err := Convert([]byte(toml), TOML, YAML)
+	if err != nil || dropped {
+		t.Fatalf("Convert() dropped = %v, error = %v", dropped, err)
+	}
+	for _, comment := range []string{"# Servers of the team\nfs:", "command: npx -y fs # local", "  # Needs a token\n  command: gh-server"} {
+		if !strings.Contains(string(converted), comment) {
+			t.Errorf("Convert() =\n%s\nwant it to contain %q", converted, comment)
+		}
+	}
+
+	back, dropped, err := Convert(converted, YAML, TOML)
+	if err != nil || !dropped {
+		t.Fatalf("Convert() to TOML dropped = %v, error = %v, want the comments reported dropped", dropped, err)
+	}
+	var before, after any
+	if err = Unmarshal([]byte(toml), TOML, &before); err != nil {
+		t.Fatal(err)
+	}
+	if err = Unmarshal(back, TOML, &after); err != nil || !reflect.DeepEqual(before, after) {
+		t.Errorf("round trip = %v (%v), want %v", after, err, before)
+	}
+}
+
+func TestConvertNumbers(t *testing.T) {
+	converted, _, err := Convert([]byte(`{"a": {"port": 8080, "ratio": 0.5, "unset": null}}`), JSON, TOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[a]\nport = 8080\nratio = 0.5\n"; string(converted) != want {
+		t.Errorf("Convert() = %q, want %q", converted, want)
+	}
+}
+
+func TestUpdateKeepsYAMLComments(t *testing.T) {
+	previous := "# Mine\nfs:\n  command: old # pinned\ngone:\n  # Removed\n  command: x\n"
+	updated, err := Update([]byte(previous), map[string]testAlias{"fs": {Command: "new"}, "gh": {Command: "gh-server"}}, YAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Mine\nfs:\n  command: new # pinned\ngh:\n  command: gh-server\n"
+	if string(updated) != want {
+		t.Errorf("Update() =\n%s\nwant\n%s", updated, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	if path, err := Find(dir, "aliases"); err != nil || path != filepath.Join(dir, "aliases.json") {
+		t.Errorf("Find() = %q, %v, want the JSON path when there is no file", path, err)
+	}
+
+	yamlPath := filepath.Join(dir, "aliases.yml")
+	if err := os.WriteFile(yamlPath, []byte("{}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := Find(dir, "aliases"); err != nil || path != yamlPath || FormatOf(path) != YAML {
+		t.Errorf("Find() = %q, %v, want %q", path, err, yamlPath)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "aliases.toml"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Find(dir, "aliases"); err == nil {
+		t.Error("Find() succeeded with two aliases files, want an error")
+	}
+}
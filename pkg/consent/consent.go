@@ -0,0 +1,189 @@
+/*
+Package consent remembers the answers users give when asked to let a request through, such as
+"always allow read_file on fs" or "never allow exec", so they are not asked again.
+
+Consents are kept in the keychain, as a single JSON entry, so they get the protection of the
+secret store of the operating system.
+*/
+package consent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/keychain"
+)
+
+// SecretName is the name of the keychain entry holding the consents.
+const SecretName = "consents"
+
+// AnyServer is the server of consents that apply to every server.
+const AnyServer = "*"
+
+// Decisions of a consent.
+const (
+	Allow = "allow"
+	Deny  = "deny"
+)
+
+// Consent is a remembered decision about requests for an entity of a server, such as a tool.
+type Consent struct {
+	Server   string    `json:"server"`
+	Entity   string    `json:"entity"`
+	Name     string    `json:"name"`
+	Decision string    `json:"decision"`
+	Time     time.Time `json:"time"`
+}
+
+// Store keeps consents in a keychain.
+type Store struct {
+	keychain keychain.Keychain
+	mu       sync.Mutex
+}
+
+// New returns a store keeping consents in kc.
+func New(kc keychain.Keychain) *Store {
+	return &Store{keychain: kc}
+}
+
+// Open returns a store keeping consents in the default keychain.
+func Open() (*Store, error) {
+	kc, err := keychain.Open("")
+	if err != nil {
+		return nil, err
+	}
+	return New(kc), nil
+}
+
+// List returns the consents, sorted by server, entity and name.
+func (s *Store) List() ([]Consent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Lookup returns the consent for an entity of server. A consent for the server wins over one
+// for AnyServer.
+func (s *Store) Lookup(server, entity, name string) (Consent, bool, error) {
+	consents, err := s.List()
+	if err != nil {
+		return Consent{}, false, err
+	}
+
+	var found *Consent
+	for i, c := range consents {
+		if c.Entity != entity || c.Name != name {
+			continue
+		}
+		if c.Server == server {
+			return c, true, nil
+		}
+		if c.Server == AnyServer {
+			found = &consents[i]
+		}
+	}
+	if found == nil {
+		return Consent{}, false, nil
+	}
+	return *found, true, nil
+}
+
+// Remember stores a consent, replacing the one for the same entity of the same server.
+func (s *Store) Remember(c Consent) error {
+	if c.Decision != Allow && c.Decision != Deny {
+		return fmt.Errorf("invalid decision %q: expected %s or %s", c.Decision, Allow, Deny)
+	}
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	consents, err := s.load()
+	if err != nil {
+		return err
+	}
+	kept := consents[:0]
+	for _, existing := range consents {
+		if existing.Server != c.Server || existing.Entity != c.Entity || existing.Name != c.Name {
+			kept = append(kept, existing)
+		}
+	}
+	return s.save(append(kept, c))
+}
+
+// Reset forgets the consents for server and name, where "" matches any, and returns how many
+// were forgotten.
+func (s *Store) Reset(server, name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	consents, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	kept := make([]Consent, 0, len(consents))
+	for _, c := range consents {
+		if (server == "" || c.Server == server) && (name == "" || c.Name == name) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	forgotten := len(consents) - len(kept)
+	if forgotten == 0 {
+		return 0, nil
+	}
+	return forgotten, s.save(kept)
+}
+
+// load reads the consents from the keychain. The caller must hold mu.
+func (s *Store) load() ([]Consent, error) {
+	secret, err := s.keychain.Get(SecretName)
+	if errors.Is(err, keychain.ErrNotFound) {
+		return []Consent{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read consents: %w", err)
+	}
+
+	var consents []Consent
+	if err = json.Unmarshal([]byte(secret), &consents); err != nil {
+		return nil, fmt.Errorf("failed to parse consents: %w", err)
+	}
+	sort.Slice(consents, func(i, j int) bool {
+		a, b := consents[i], consents[j]
+		if a.Server != b.Server {
+			return a.Server < b.Server
+		}
+		if a.Entity != b.Entity {
+			return a.Entity < b.Entity
+		}
+		return a.Name < b.Name
+	})
+	return consents, nil
+}
+
+// save writes the consents to the keychain, deleting its entry when there are none. The caller
+// must hold mu.
+func (s *Store) save(consents []Consent) error {
+	if len(consents) == 0 {
+		if err := s.keychain.Delete(SecretName); err != nil && !errors.Is(err, keychain.ErrNotFound) {
+			return fmt.Errorf("failed to save consents: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(consents)
+	if err != nil {
+		return fmt.Errorf("failed to save consents: %w", err)
+	}
+	if err = s.keychain.Set(SecretName, string(data)); err != nil {
+		return fmt.Errorf("failed to save consents: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package consent
+
+import (
+	"testing"
+
+	"github.com/f/mcptools/pkg/keychain"
+)
+
+// memoryKeychain keeps secrets in a map.
+type memoryKeychain map[string]string
+
+func (m memoryKeychain) Get(name string) (string, error) {
+	secret, ok := m[name]
+	if !ok {
+		return "", keychain.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (m memoryKeychain) Set(name, secret string) error {
+	m[name] = secret
+	return nil
+}
+
+func (m memoryKeychain) Delete(name string) error {
+	if _, ok := m[name]; !ok {
+		return keychain.ErrNotFound
+	}
+	delete(m, name)
+	return nil
+}
+
+func (m memoryKeychain) Backend() string { return "memory" }
+
+func TestStore(t *testing.T) {
+	kc := memoryKeychain{}
+	store := New(kc)
+
+	for _, c := range []Consent{
+		{Server: "fs", Entity: "tool", Name: "read_file", Decision: Allow},
+		{Server: AnyServer, Entity: "tool", Name: "exec", Decision: Deny},
+		{Server: "shell", Entity: "tool", Name: "exec", Decision: Allow},
+		// Replaces the first consent
+		{Server: "fs", Entity: "tool", Name: "read_file", Decision: Deny},
+	} {
+		if err := store.Remember(c); err != nil {
+			t.Fatalf("Remember(%+v) error = %v", c, err)
+		}
+	}
+	if err := store.Remember(Consent{Server: "fs", Entity: "tool", Name: "x", Decision: "maybe"}); err == nil {
+		t.Error("expected an error for an unknown decision")
+	}
+
+	consents, err := store.List()
+	if err != nil || len(consents) != 3 || consents[0].Server != AnyServer || consents[1].Decision != Deny {
+		t.Fatalf("List() = %+v, %v; want 3 consents sorted by server", consents, err)
+	}
+
+	for _, tc := range []struct {
+		server, name, decision string
+	}{
+		{"fs", "read_file", Deny},
+		{"shell", "exec", Allow},
+		{"git", "exec", Deny},
+		{"git", "read_file", ""},
+	} {
+		c, found, lookupErr := store.Lookup(tc.server, "tool", tc.name)
+		if lookupErr != nil || found != (tc.decision != "") || c.Decision != tc.decision {
+			t.Errorf("Lookup(%s, %s) = %+v, %v, %v; want %q", tc.server, tc.name, c, found, lookupErr, tc.decision)
+		}
+	}
+
+	if n, resetErr := store.Reset("", "exec"); resetErr != nil || n != 2 {
+		t.Errorf("Reset(exec) = %d, %v; want 2 consents forgotten", n, resetErr)
+	}
+	if n, resetErr := store.Reset("", ""); resetErr != nil || n != 1 {
+		t.Errorf("Reset() = %d, %v; want the last consent forgotten", n, resetErr)
+	}
+	if _, ok := kc[SecretName]; ok {
+		t.Error("expected the keychain entry to be deleted with the last consent")
+	}
+}
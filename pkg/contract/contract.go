@@ -0,0 +1,104 @@
+/*
+Package contract defines the stable JSON output of mcp commands.
+
+Every command printing server data with --format json (or pretty) wraps it in an object with
+an apiVersion and a kind, and the output of each kind is described by a JSON Schema published
+with mcp schema. Within an apiVersion, fields are only ever added; removing, renaming or
+retyping a field requires a new apiVersion.
+*/
+package contract
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// APIVersion is the version of the output contract.
+const APIVersion = "mcptools/v1"
+
+//go:embed schemas/v1/*.json
+var schemas embed.FS
+
+// kinds maps commands, as their path below the root command, to the kind of their output.
+var kinds = map[string]string{
+	"tools":         "ToolList",
+	"describe":      "ToolList",
+	"resources":     "ResourceList",
+	"prompts":       "PromptList",
+	"call":          "CallResult",
+	"get-prompt":    "PromptResult",
+	"read-resource": "ResourceContents",
+	"find":          "SearchResults",
+	"stats tools":   "UsageSummary",
+	"matrix":        "CapabilityMatrix",
+	"ls":            "ServerOverview",
+	// The combined output of call and run with --aggregate
+	"aggregate": "Aggregate",
+	// The payload printed on stderr when any of them fails
+	"error": "Error",
+}
+
+// ErrInvalidOutput is returned by Wrap when output does not match its schema.
+var ErrInvalidOutput = errors.New("invalid output")
+
+// Kind returns the kind of the output of command, e.g. "stats tools". It returns false if the
+// command has no output contract.
+func Kind(command string) (string, bool) {
+	kind, ok := kinds[command]
+	return kind, ok
+}
+
+// Commands returns the commands with an output contract in sorted order.
+func Commands() []string {
+	commands := make([]string, 0, len(kinds))
+	for command := range kinds {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// Schema returns the JSON Schema of kind.
+func Schema(kind string) ([]byte, error) {
+	data, err := schemas.ReadFile("schemas/v1/" + kind + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown output kind: %s", kind)
+	}
+	return data, nil
+}
+
+// Wrap returns the output of command as an object carrying the apiVersion and kind, after
+// checking it against the schema of the kind. data must marshal to a JSON object.
+func Wrap(command string, data any) (map[string]any, error) {
+	kind, ok := Kind(command)
+	if !ok {
+		return nil, fmt.Errorf("command %q has no output contract", command)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var output map[string]any
+	if err = json.Unmarshal(raw, &output); err != nil || output == nil {
+		return nil, fmt.Errorf("%w: %s output must be a JSON object", ErrInvalidOutput, command)
+	}
+	output["apiVersion"] = APIVersion
+	output["kind"] = kind
+
+	schemaData, err := Schema(kind)
+	if err != nil {
+		return nil, err
+	}
+	var schema map[string]any
+	if err = json.Unmarshal(schemaData, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema for %s: %w", kind, err)
+	}
+	if err = Validate(schema, output); err != nil {
+		return nil, fmt.Errorf("%w: %s output does not match the %s %s schema: %v", ErrInvalidOutput, command, APIVersion, kind, err)
+	}
+	return output, nil
+}
@@ -0,0 +1,76 @@
+package contract
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSchemasMatchKinds(t *testing.T) {
+	for _, command := range Commands() {
+		kind, _ := Kind(command)
+		data, err := Schema(kind)
+		if err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+
+		var schema map[string]any
+		if err = json.Unmarshal(data, &schema); err != nil {
+			t.Fatalf("%s schema is not valid JSON: %v", kind, err)
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		apiVersion, _ := properties["apiVersion"].(map[string]any)
+		kindProperty, _ := properties["kind"].(map[string]any)
+		if schema["title"] != kind || apiVersion["const"] != APIVersion || kindProperty["const"] != kind {
+			t.Errorf("%s schema does not describe its own kind and apiVersion", kind)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	output, err := Wrap("stats tools", map[string]any{"usage": []map[string]any{
+		{"server": "fs", "tool": "read_file", "calls": 3, "errors": 0, "errorRate": 0, "avgLatencyMs": 12.5},
+	}})
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	if output["apiVersion"] != APIVersion || output["kind"] != "UsageSummary" {
+		t.Errorf("Wrap() = %v", output)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		data    any
+		wantErr string
+	}{
+		{"tool without name", "tools", map[string]any{"tools": []any{map[string]any{"description": "x"}}}, "tools[0]: missing required field name"},
+		{"wrong type", "find", map[string]any{"matches": "none"}, "matches: expected array or null, got string"},
+		{"call without result", "call", map[string]any{}, "matches none of the alternatives"},
+		{"non-integer count", "stats tools", map[string]any{"usage": []any{map[string]any{
+			"server": "fs", "tool": "t", "calls": 1.5, "errors": 0, "errorRate": 0, "avgLatencyMs": 1,
+		}}}, "usage[0].calls: expected integer"},
+		{"not an object", "tools", []any{}, "must be a JSON object"},
+		{"no contract", "alias list", map[string]any{}, "has no output contract"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Wrap(tt.command, tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Wrap() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWrapCallResults(t *testing.T) {
+	for _, data := range []map[string]any{
+		{"content": []any{map[string]any{"type": "text", "text": "hi"}}, "isError": false},
+		{"contents": []any{map[string]any{"uri": "file:///a"}}},
+		{"messages": []any{}},
+	} {
+		if _, err := Wrap("call", data); err != nil {
+			t.Errorf("Wrap(%v) error = %v", data, err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Validate checks a value decoded from JSON against a schema, also decoded from JSON. It
+// supports the keywords the output schemas use: type, const, enum, required, properties,
+// items and anyOf. Other keywords are ignored.
+func Validate(schema map[string]any, value any) error {
+	return validate(schema, value, "")
+}
+
+func validate(schema map[string]any, value any, path string) error {
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		return fmt.Errorf("%s: expected %s, got %s", location(path), describeTypes(types), typeOf(value))
+	}
+
+	if want, ok := schema["const"]; ok && !reflect.DeepEqual(want, value) {
+		return fmt.Errorf("%s: expected %v, got %v", location(path), want, value)
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, want := range enum {
+			if reflect.DeepEqual(want, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", location(path), value, enum)
+		}
+	}
+
+	if object, ok := value.(map[string]any); ok {
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				key, _ := name.(string)
+				if _, present := object[key]; !present {
+					return fmt.Errorf("%s: missing required field %s", location(path), key)
+				}
+			}
+		}
+
+		properties, _ := schema["properties"].(map[string]any)
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		// Sorted so the first error reported does not change between runs
+		sort.Strings(names)
+		for _, name := range names {
+			propertySchema, _ := properties[name].(map[string]any)
+			if field, present := object[name]; present && propertySchema != nil {
+				if err := validate(propertySchema, field, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if array, ok := value.([]any); ok {
+		if itemSchema, ok := schema["items"].(map[string]any); ok {
+			for i, item := range array {
+				if err := validate(itemSchema, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		var errs []error
+		for _, alternative := range anyOf {
+			alternativeSchema, _ := alternative.(map[string]any)
+			err := validate(alternativeSchema, value, path)
+			if err == nil {
+				errs = nil
+				break
+			}
+			errs = append(errs, err)
+		}
+		if errs != nil {
+			return fmt.Errorf("%s: matches none of the alternatives: %w", location(path), errors.Join(errs...))
+		}
+	}
+
+	return nil
+}
+
+func location(path string) string {
+	if path == "" {
+		return "output"
+	}
+	return strings.TrimPrefix(path, ".")
+}
+
+func matchesType(types any, value any) bool {
+	switch t := types.(type) {
+	case string:
+		return isType(t, value)
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); ok && isType(s, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isType(name string, value any) bool {
+	switch name {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return false
+}
+
+func typeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func describeTypes(types any) string {
+	if list, ok := types.([]any); ok {
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(types)
+}
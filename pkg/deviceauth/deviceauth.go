@@ -0,0 +1,239 @@
+/*
+Package deviceauth authenticates to gateways with a FIDO2/WebAuthn credential bound to the
+device, such as Touch ID, Windows Hello or a security key, instead of a long-lived bearer token.
+
+The WebAuthn ceremony runs in the browser on a page served by the gateway, as authenticators
+only sign for the origin of the page asking. The page is opened with a loopback redirect URI,
+in the manner of OAuth for native apps (RFC 8252):
+
+	GET <gateway>/auth/device?redirect_uri=http://127.0.0.1:<port>/callback&state=<state>[&credential_id=<id>]
+
+Without credential_id the page registers a new credential; with it, it asks the authenticator
+for an assertion of that credential. Either way it then redirects to redirect_uri with the
+state, the credential_id and a short-lived access_token with its expires_in seconds. Only the
+credential ID is stored, in $HOME/.mcpt/devices.json; access tokens are kept in memory.
+*/
+package deviceauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPath is the path of the device authentication page on a gateway.
+const DefaultPath = "/auth/device"
+
+// Timeout is how long Authenticate waits for the browser to complete the ceremony.
+const Timeout = 5 * time.Minute
+
+// ErrNotRegistered is returned for gateways without a registered credential.
+var ErrNotRegistered = errors.New("no device credential is registered for this gateway")
+
+// Device is a credential registered with a gateway.
+type Device struct {
+	Registered   time.Time `json:"registered"`
+	CredentialID string    `json:"credential_id"`
+	// Path is the device authentication page of the gateway, if not DefaultPath.
+	Path string `json:"path,omitempty"`
+}
+
+// Devices maps gateway origins, such as https://gateway.example.com, to their credential.
+type Devices map[string]Device
+
+// Session is the result of a completed ceremony.
+type Session struct {
+	Expiry       time.Time
+	CredentialID string
+	AccessToken  string
+}
+
+// Origin returns the origin of a gateway URL, which credentials are registered for.
+func Origin(gateway string) (string, error) {
+	u, err := url.Parse(gateway)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("invalid gateway URL %q: expected an http:// or https:// URL", gateway)
+	}
+	return u.Scheme + "://" + strings.ToLower(u.Host), nil
+}
+
+// GetConfigPath returns the path to the file of registered credentials.
+func GetConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "devices.json"), nil
+}
+
+// Load loads the registered credentials.
+func Load() (Devices, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	devices := make(Devices)
+	data, err := os.ReadFile(configPath) // #nosec G304 - configPath is generated internally by GetConfigPath
+	if os.IsNotExist(err) {
+		return devices, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device credentials: %w", err)
+	}
+	if err = json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("failed to parse device credentials: %w", err)
+	}
+	return devices, nil
+}
+
+// Save saves the registered credentials.
+func (d Devices) Save() error {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(configPath), 0o750); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(configPath, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write device credentials: %w", err)
+	}
+	return nil
+}
+
+// Authenticate runs the WebAuthn ceremony of a gateway in the browser: a registration if device
+// has no credential ID, an assertion of it otherwise. open opens the page, normally OpenBrowser.
+func Authenticate(ctx context.Context, origin string, device Device, open func(string) error) (Session, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to listen for the browser: %w", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	state, err := randomState()
+	if err != nil {
+		return Session{}, err
+	}
+	path := device.Path
+	if path == "" {
+		path = DefaultPath
+	}
+	query := url.Values{
+		"redirect_uri": {"http://" + listener.Addr().String() + "/callback"},
+		"state":        {state},
+	}
+	if device.CredentialID != "" {
+		query.Set("credential_id", device.CredentialID)
+	}
+	page := origin + path + "?" + query.Encode()
+
+	results := make(chan callback, 1)
+	server := &http.Server{
+		Handler:           callbackHandler(state, results),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer func() { _ = server.Close() }()
+
+	if err = open(page); err != nil {
+		return Session{}, fmt.Errorf("failed to open the browser: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		return Session{}, fmt.Errorf("device authentication was not completed: %w", ctx.Err())
+	case result := <-results:
+		if result.err != nil {
+			return Session{}, result.err
+		}
+		if device.CredentialID != "" && result.session.CredentialID != device.CredentialID {
+			return Session{}, fmt.Errorf("the gateway asserted credential %q instead of %q", result.session.CredentialID, device.CredentialID)
+		}
+		return result.session, nil
+	}
+}
+
+type callback struct {
+	err     error
+	session Session
+}
+
+// callbackHandler answers the redirect of the gateway's page, delivering its outcome once.
+func callbackHandler(state string, results chan<- callback) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		}
+
+		var result callback
+		switch {
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("device authentication failed: %s", strings.TrimSpace(query.Get("error")+" "+query.Get("error_description")))
+		case query.Get("credential_id") == "" || query.Get("access_token") == "":
+			result.err = errors.New("device authentication failed: the gateway returned no credential_id or access_token")
+		default:
+			result.session = Session{CredentialID: query.Get("credential_id"), AccessToken: query.Get("access_token")}
+			if seconds, err := strconv.Atoi(query.Get("expires_in")); err == nil && seconds > 0 {
+				result.session.Expiry = time.Now().Add(time.Duration(seconds) * time.Second)
+			}
+		}
+
+		message := "Device authenticated. You can close this tab and return to the terminal."
+		if result.err != nil {
+			message = result.err.Error()
+		}
+		select {
+		case results <- result:
+		default:
+			message = "This authentication request was already completed."
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<!doctype html><title>mcp</title><p>%s</p>\n", html.EscapeString(message))
+	})
+	return mux
+}
+
+func randomState() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// OpenBrowser opens a URL in the default browser.
+func OpenBrowser(page string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", page)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", page)
+	default:
+		cmd = exec.Command("xdg-open", page)
+	}
+	return cmd.Start()
+}
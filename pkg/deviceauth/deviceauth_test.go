@@ -0,0 +1,115 @@
+package deviceauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// browser returns an open function standing in for the browser and the gateway's page: it
+// follows the page's redirect with the given parameters, plus the state it was given.
+func browser(t *testing.T, params url.Values, pages *[]*url.URL) func(string) error {
+	t.Helper()
+	return func(page string) error {
+		u, err := url.Parse(page)
+		if err != nil {
+			return err
+		}
+		*pages = append(*pages, u)
+		redirect := u.Query().Get("redirect_uri")
+		query := url.Values{"state": {u.Query().Get("state")}}
+		for key, values := range params {
+			query[key] = values
+		}
+		go func() {
+			resp, err := http.Get(redirect + "?" + query.Encode())
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+}
+
+func TestAuthenticateRegistersAndAsserts(t *testing.T) {
+	var pages []*url.URL
+	session, err := Authenticate(context.Background(), "https://gateway.example.com", Device{},
+		browser(t, url.Values{"credential_id": {"cred-1"}, "access_token": {"tok"}, "expires_in": {"300"}}, &pages))
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if session.CredentialID != "cred-1" || session.AccessToken != "tok" || session.Expiry.IsZero() {
+		t.Errorf("unexpected session %+v", session)
+	}
+	if pages[0].Path != DefaultPath || pages[0].Query().Has("credential_id") {
+		t.Errorf("registration opened %s", pages[0])
+	}
+	if redirect := pages[0].Query().Get("redirect_uri"); !strings.HasPrefix(redirect, "http://127.0.0.1:") {
+		t.Errorf("redirect_uri %q is not a loopback address", redirect)
+	}
+
+	device := Device{CredentialID: "cred-1", Path: "/webauthn"}
+	if _, err = Authenticate(context.Background(), "https://gateway.example.com", device,
+		browser(t, url.Values{"credential_id": {"cred-1"}, "access_token": {"tok2"}}, &pages)); err != nil {
+		t.Fatalf("Authenticate with a credential: %v", err)
+	}
+	if pages[1].Path != "/webauthn" || pages[1].Query().Get("credential_id") != "cred-1" {
+		t.Errorf("assertion opened %s", pages[1])
+	}
+
+	_, err = Authenticate(context.Background(), "https://gateway.example.com", device,
+		browser(t, url.Values{"credential_id": {"cred-2"}, "access_token": {"tok3"}}, &pages))
+	if err == nil || !strings.Contains(err.Error(), "cred-2") {
+		t.Errorf("expected an error for another credential, got %v", err)
+	}
+}
+
+func TestAuthenticateReportsGatewayErrors(t *testing.T) {
+	var pages []*url.URL
+	_, err := Authenticate(context.Background(), "https://gateway.example.com", Device{},
+		browser(t, url.Values{"error": {"not_allowed"}, "error_description": {"user cancelled"}}, &pages))
+	if err == nil || !strings.Contains(err.Error(), "not_allowed user cancelled") {
+		t.Errorf("expected the gateway's error, got %v", err)
+	}
+}
+
+func TestCallbackRejectsWrongState(t *testing.T) {
+	results := make(chan callback, 1)
+	handler := callbackHandler("expected", results)
+	req, _ := http.NewRequest(http.MethodGet, "/callback?state=forged&credential_id=c&access_token=t", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rec.Code)
+	}
+	if len(results) != 0 {
+		t.Error("a callback with the wrong state was delivered")
+	}
+}
+
+func TestDevicesRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	devices, err := Load()
+	if err != nil || len(devices) != 0 {
+		t.Fatalf("Load without a file: %v, %v", devices, err)
+	}
+	origin, err := Origin("https://Gateway.example.com/mcp?x=1")
+	if err != nil || origin != "https://gateway.example.com" {
+		t.Fatalf("Origin: %q, %v", origin, err)
+	}
+	devices[origin] = Device{CredentialID: "cred-1"}
+	if err = devices.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load()
+	if err != nil || loaded[origin].CredentialID != "cred-1" {
+		t.Errorf("Load: %v, %v", loaded, err)
+	}
+	if _, err = Origin("gateway.example.com"); err == nil {
+		t.Error("expected an error for a URL without a scheme")
+	}
+}
@@ -0,0 +1,119 @@
+// Package discover locates MCP server endpoints through DNS SRV records or multicast DNS, so
+// clients can find gateways without hardcoding hostnames.
+//
+// Endpoints are described like DNS-SD services: an SRV record gives the host and port, and an
+// optional TXT record at the same name holds key=value pairs. The keys "path" (default /mcp) and
+// "scheme" (default https, or http for mDNS on the local network) complete the endpoint URL.
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// discovery schemes.
+const (
+	SchemeDNSSRV = "dns-srv"
+	SchemeMDNS   = "mdns"
+)
+
+// Endpoint is a discovered server endpoint.
+type Endpoint struct {
+	Host     string
+	Scheme   string
+	Path     string
+	Port     uint16
+	Priority uint16
+	Weight   uint16
+}
+
+// URL returns the URL of the endpoint.
+func (e Endpoint) URL() string {
+	return e.Scheme + "://" + net.JoinHostPort(e.Host, strconv.Itoa(int(e.Port))) + e.Path
+}
+
+// lookupSRV and lookupTXT are replaced in tests.
+var (
+	lookupSRV = net.DefaultResolver.LookupSRV
+	lookupTXT = net.DefaultResolver.LookupTXT
+)
+
+// Lookup returns the endpoints found with spec, in the order they should be tried. spec is
+// dns-srv:<name>, e.g. dns-srv:_mcp._tcp.example.com, or mdns:<service>, e.g. mdns:_mcp._tcp.
+func Lookup(ctx context.Context, spec string) ([]Endpoint, error) {
+	scheme, name, found := strings.Cut(spec, ":")
+	if !found || name == "" {
+		return nil, fmt.Errorf("invalid discovery %q (expected %s:name or %s:service)", spec, SchemeDNSSRV, SchemeMDNS)
+	}
+
+	var endpoints []Endpoint
+	var err error
+	switch scheme {
+	case SchemeDNSSRV:
+		endpoints, err = lookupDNS(ctx, name)
+	case SchemeMDNS:
+		endpoints, err = Browse(ctx, name)
+	default:
+		return nil, fmt.Errorf("unknown discovery scheme: %s (supported: %s, %s)", scheme, SchemeDNSSRV, SchemeMDNS)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoints found for %s", spec)
+	}
+	return endpoints, nil
+}
+
+// lookupDNS resolves the SRV records of name. The resolver orders them by priority and
+// shuffles those of equal priority by weight.
+func lookupDNS(ctx context.Context, name string) ([]Endpoint, error) {
+	_, records, err := lookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up SRV records of %s: %w", name, err)
+	}
+
+	// TXT records are optional, so a failed lookup leaves the defaults
+	txt, _ := lookupTXT(ctx, name)
+	options := parseTXT(txt)
+
+	var endpoints []Endpoint
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		// A target of "." means the service is decidedly not available at this name
+		if host == "" {
+			continue
+		}
+		endpoints = append(endpoints, newEndpoint(host, record.Port, record.Priority, record.Weight, options, "https"))
+	}
+	return endpoints, nil
+}
+
+func newEndpoint(host string, port, priority, weight uint16, options map[string]string, scheme string) Endpoint {
+	e := Endpoint{Host: host, Port: port, Priority: priority, Weight: weight, Scheme: scheme, Path: "/mcp"}
+	if s := options["scheme"]; s == "http" || s == "https" {
+		e.Scheme = s
+	}
+	if path, ok := options["path"]; ok {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		e.Path = path
+	}
+	return e
+}
+
+// parseTXT reads key=value pairs from TXT record strings. Keys are case-insensitive.
+func parseTXT(txt []string) map[string]string {
+	options := map[string]string{}
+	for _, s := range txt {
+		key, value, _ := strings.Cut(s, "=")
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			options[key] = strings.TrimSpace(value)
+		}
+	}
+	return options
+}
@@ -0,0 +1,92 @@
+package discover
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestLookupDNSSRV(t *testing.T) {
+	lookupSRV = func(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+		if name != "_mcp._tcp.example.com" {
+			t.Errorf("looked up %s", name)
+		}
+		return "", []*net.SRV{
+			{Target: "gw1.example.com.", Port: 443, Priority: 10},
+			{Target: ".", Port: 0, Priority: 20},
+			{Target: "2001:db8::1", Port: 8443, Priority: 30},
+		}, nil
+	}
+	lookupTXT = func(context.Context, string) ([]string, error) {
+		return []string{"path=v1/mcp", "Scheme=https"}, nil
+	}
+	t.Cleanup(func() {
+		lookupSRV = net.DefaultResolver.LookupSRV
+		lookupTXT = net.DefaultResolver.LookupTXT
+	})
+
+	endpoints, err := Lookup(context.Background(), "dns-srv:_mcp._tcp.example.com")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	var urls []string
+	for _, e := range endpoints {
+		urls = append(urls, e.URL())
+	}
+	want := []string{"https://gw1.example.com:443/v1/mcp", "https://[2001:db8::1]:8443/v1/mcp"}
+	if len(urls) != len(want) || urls[0] != want[0] || urls[1] != want[1] {
+		t.Errorf("URLs = %v, want %v", urls, want)
+	}
+
+	if _, err = Lookup(context.Background(), "consul:mcp"); err == nil {
+		t.Error("expected an error for an unknown scheme")
+	}
+}
+
+func TestParseAnswers(t *testing.T) {
+	service := serviceName("_mcp._tcp")
+	if service != "_mcp._tcp.local." {
+		t.Fatalf("serviceName() = %s", service)
+	}
+
+	answer := func(instance, host string, port, priority uint16, ip [4]byte, txt ...string) []byte {
+		header := func(name string, typ dnsmessage.Type) dnsmessage.ResourceHeader {
+			return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: typ, Class: dnsmessage.ClassINET}
+		}
+		msg := dnsmessage.Message{
+			Header: dnsmessage.Header{Response: true},
+			Answers: []dnsmessage.Resource{{
+				Header: header(service, dnsmessage.TypePTR),
+				Body:   &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(instance)},
+			}},
+			Additionals: []dnsmessage.Resource{
+				{Header: header(instance, dnsmessage.TypeSRV), Body: &dnsmessage.SRVResource{
+					Target: dnsmessage.MustNewName(host), Port: port, Priority: priority}},
+				{Header: header(instance, dnsmessage.TypeTXT), Body: &dnsmessage.TXTResource{TXT: append(txt, "")}},
+				{Header: header(host, dnsmessage.TypeA), Body: &dnsmessage.AResource{A: ip}},
+			},
+		}
+		data, err := msg.Pack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	endpoints := parseAnswers(service, [][]byte{
+		answer("backup._mcp._tcp.local.", "nas.local.", 9000, 20, [4]byte{192, 168, 1, 20}),
+		[]byte("garbage"),
+		answer("main._mcp._tcp.local.", "gw.local.", 8080, 10, [4]byte{192, 168, 1, 10}, "path=/gateway"),
+	})
+	if len(endpoints) != 2 {
+		t.Fatalf("got %d endpoints, want 2", len(endpoints))
+	}
+	if got := endpoints[0].URL(); got != "http://192.168.1.10:8080/gateway" {
+		t.Errorf("first endpoint = %s", got)
+	}
+	if got := endpoints[1].URL(); got != "http://192.168.1.20:9000/mcp" {
+		t.Errorf("second endpoint = %s", got)
+	}
+}
@@ -0,0 +1,166 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// BrowseTimeout is how long Browse collects answers when ctx has no deadline.
+const BrowseTimeout = time.Second
+
+// mdnsAddr is the IPv4 multicast group of multicast DNS.
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Browse asks the local network for instances of service, e.g. _mcp._tcp, with a multicast DNS
+// query and returns the endpoints that answered before ctx is done or BrowseTimeout passes.
+func Browse(ctx context.Context, service string) ([]Endpoint, error) {
+	service = serviceName(service)
+
+	query, err := buildQuery(service)
+	if err != nil {
+		return nil, err
+	}
+
+	// Queries sent from a port other than 5353 get unicast answers, so no multicast group has
+	// to be joined
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mDNS socket: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(BrowseTimeout)
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = conn.SetDeadline(time.Now())
+	}()
+
+	if _, err = conn.WriteToUDP(query, mdnsAddr); err != nil {
+		return nil, fmt.Errorf("failed to send mDNS query: %w", err)
+	}
+
+	var answers [][]byte
+	buf := make([]byte, 9000)
+	for {
+		n, _, readErr := conn.ReadFromUDP(buf)
+		if readErr != nil {
+			var netErr net.Error
+			if errors.As(readErr, &netErr) && netErr.Timeout() {
+				break
+			}
+			return nil, readErr
+		}
+		answers = append(answers, append([]byte(nil), buf[:n]...))
+	}
+
+	return parseAnswers(service, answers), nil
+}
+
+// serviceName turns a service such as _mcp._tcp into its fully qualified mDNS name.
+func serviceName(service string) string {
+	service = strings.TrimSuffix(service, ".")
+	if !strings.HasSuffix(service, ".local") {
+		service += ".local"
+	}
+	return service + "."
+}
+
+func buildQuery(service string) ([]byte, error) {
+	name, err := dnsmessage.NewName(service)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service name %s: %w", service, err)
+	}
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}},
+	}
+	return msg.Pack()
+}
+
+// instance is what the answers say about one instance of a service.
+type instance struct {
+	srv *dnsmessage.SRVResource
+	txt []string
+}
+
+// parseAnswers collects the instances of service from mDNS answers. Responders put the SRV,
+// TXT and address records of an instance in the same answer as its PTR record, usually as
+// additional records. Malformed answers are ignored.
+func parseAnswers(service string, answers [][]byte) []Endpoint {
+	instances := map[string]*instance{}
+	var names []string
+	listed := map[string]bool{}
+	addresses := map[string][]net.IP{}
+
+	get := func(name string) *instance {
+		if instances[name] == nil {
+			instances[name] = &instance{}
+		}
+		return instances[name]
+	}
+
+	for _, data := range answers {
+		var msg dnsmessage.Message
+		if err := msg.Unpack(data); err != nil {
+			continue
+		}
+		for _, rr := range append(msg.Answers, msg.Additionals...) {
+			name := strings.ToLower(rr.Header.Name.String())
+			switch body := rr.Body.(type) {
+			case *dnsmessage.PTRResource:
+				if name == strings.ToLower(service) {
+					target := strings.ToLower(body.PTR.String())
+					if !listed[target] {
+						listed[target] = true
+						names = append(names, target)
+					}
+				}
+			case *dnsmessage.SRVResource:
+				get(name).srv = body
+			case *dnsmessage.TXTResource:
+				get(name).txt = body.TXT
+			case *dnsmessage.AResource:
+				addresses[name] = append(addresses[name], net.IP(body.A[:]))
+			case *dnsmessage.AAAAResource:
+				addresses[name] = append(addresses[name], net.IP(body.AAAA[:]))
+			}
+		}
+	}
+
+	var endpoints []Endpoint
+	for _, name := range names {
+		inst := instances[name]
+		if inst.srv == nil {
+			continue
+		}
+		// .local names often do not resolve outside mDNS, so the announced addresses are used
+		target := strings.ToLower(inst.srv.Target.String())
+		host := strings.TrimSuffix(target, ".")
+		if ips := addresses[target]; len(ips) > 0 {
+			host = ips[0].String()
+		}
+		endpoints = append(endpoints, newEndpoint(host, inst.srv.Port, inst.srv.Priority, inst.srv.Weight, parseTXT(inst.txt), "http"))
+	}
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].Priority != endpoints[j].Priority {
+			return endpoints[i].Priority < endpoints[j].Priority
+		}
+		return endpoints[i].Weight > endpoints[j].Weight
+	})
+	return endpoints
+}
@@ -0,0 +1,195 @@
+// Package doccache keeps a local copy of the documentation of servers, their tools with input
+// schemas, prompts and resources, so it can be read while the servers cannot be reached.
+package doccache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotCached is returned by Load for a server whose documentation was never cached.
+var ErrNotCached = errors.New("no cached documentation")
+
+// Docs is the cached documentation of a server, as listed by the server. Tools, prompts and
+// resources are kept in their wire form.
+type Docs struct {
+	Server       string `json:"server"`
+	Name         string `json:"name,omitempty"`
+	Version      string `json:"version,omitempty"`
+	Instructions string `json:"instructions,omitempty"`
+	// UpdatedAt is when the documentation was last known to be current: when it was listed, or
+	// revalidated since.
+	UpdatedAt time.Time `json:"updatedAt"`
+	Tools     []any     `json:"tools,omitempty"`
+	Prompts   []any     `json:"prompts,omitempty"`
+	Resources []any     `json:"resources,omitempty"`
+	// ETags maps list methods, e.g. tools/list, to the validator the server returned with the
+	// listing, sent back to revalidate it without listing it again.
+	ETags map[string]string `json:"etags,omitempty"`
+}
+
+// Age returns how long before now the documentation was last known to be current.
+func (d Docs) Age(now time.Time) time.Duration {
+	return now.Sub(d.UpdatedAt)
+}
+
+// ToolNames returns the names of the tools of the server, sorted.
+func (d Docs) ToolNames() []string {
+	names := make([]string, 0, len(d.Tools))
+	for _, tool := range d.Tools {
+		if m, ok := tool.(map[string]any); ok {
+			if name, _ := m["name"].(string); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configDir returns the mcptools configuration directory.
+func configDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt"), nil
+}
+
+// GetCachePath returns the directory holding the cached documentation, a file per server.
+func GetCachePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "docs"), nil
+}
+
+// enabledPath returns the path of the marker file that enables caching.
+func enabledPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "docs.enabled"), nil
+}
+
+// Enabled reports whether connections refresh the cache.
+func Enabled() bool {
+	path, err := enabledPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Enable makes connections refresh the cache.
+func Enable() error {
+	path, err := enabledPath()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(path, nil, 0o600)
+}
+
+// Disable stops connections from refreshing the cache. The cached documentation is kept.
+func Disable() error {
+	path, err := enabledPath()
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path returns the file caching the documentation of server.
+func path(server string) (string, error) {
+	dir, err := GetCachePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, url.PathEscape(server)+".json"), nil
+}
+
+// Save caches docs, replacing what was cached for the same server.
+func Save(docs Docs) error {
+	file, err := path(docs.Server)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return fmt.Errorf("failed to create docs cache directory: %w", err)
+	}
+	data, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write and rename so concurrent readers never see a partial file
+	tmp := file + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write docs cache: %w", err)
+	}
+	return os.Rename(tmp, file)
+}
+
+// Load returns the cached documentation of server, or an error wrapping ErrNotCached.
+func Load(server string) (Docs, error) {
+	file, err := path(server)
+	if err != nil {
+		return Docs{}, err
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return Docs{}, fmt.Errorf("%w for %s", ErrNotCached, server)
+	}
+	if err != nil {
+		return Docs{}, fmt.Errorf("failed to read docs cache: %w", err)
+	}
+	var docs Docs
+	if err = json.Unmarshal(data, &docs); err != nil {
+		return Docs{}, fmt.Errorf("invalid docs cache for %s: %w", server, err)
+	}
+	return docs, nil
+}
+
+// Servers returns the names of the servers with cached documentation, sorted.
+func Servers() ([]string, error) {
+	dir, err := GetCachePath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var servers []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if server, unescapeErr := url.PathUnescape(name); unescapeErr == nil {
+			servers = append(servers, server)
+		}
+	}
+	sort.Strings(servers)
+	return servers, nil
+}
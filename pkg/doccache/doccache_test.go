@@ -0,0 +1,62 @@
+package doccache
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := Load("fs"); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("Load() error = %v, want ErrNotCached", err)
+	}
+
+	docs := Docs{
+		Server:    "team/fs",
+		Name:      "filesystem",
+		UpdatedAt: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+		Tools:     []any{map[string]any{"name": "write_file"}, map[string]any{"name": "read_file"}},
+	}
+	if err := Save(docs); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := Save(Docs{Server: "gh"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load("team/fs")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, docs) {
+		t.Errorf("Load() = %+v, want %+v", loaded, docs)
+	}
+	if names := loaded.ToolNames(); !reflect.DeepEqual(names, []string{"read_file", "write_file"}) {
+		t.Errorf("ToolNames() = %v", names)
+	}
+
+	servers, err := Servers()
+	if err != nil || !reflect.DeepEqual(servers, []string{"gh", "team/fs"}) {
+		t.Errorf("Servers() = %v, %v, want gh and team/fs", servers, err)
+	}
+}
+
+func TestEnable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if Enabled() {
+		t.Fatal("caching enabled by default")
+	}
+	if err := Enable(); err != nil || !Enabled() {
+		t.Fatalf("Enable() error = %v, Enabled() = %v", err, Enabled())
+	}
+	if err := Disable(); err != nil || Enabled() {
+		t.Fatalf("Disable() error = %v, Enabled() = %v", err, Enabled())
+	}
+	if err := Disable(); err != nil {
+		t.Errorf("Disable() twice error = %v", err)
+	}
+}
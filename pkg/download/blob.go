@@ -0,0 +1,113 @@
+package download
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// StreamThreshold is the size of a resources/read result above which its blobs are not parsed
+// into strings, but decoded straight from the response while they are written to the output
+// file. The transport still reads the encoded result whole, so memory use grows with it; what is
+// saved is the copy of the blob as a string and the decoded resource, which would otherwise
+// roughly double it.
+const StreamThreshold = 4 << 20
+
+// StreamedBlob is blob contents kept as the JSON string it was read from, so that it can be
+// decoded while it is written instead of being held in memory decoded. Blob is left empty.
+type StreamedBlob struct {
+	mcp.BlobResourceContents
+	raw []byte
+}
+
+// rawString captures a JSON value without copying it. encoding/json passes a slice of its input
+// to UnmarshalJSON, so the value stays valid as long as the input does.
+type rawString []byte
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *rawString) UnmarshalJSON(data []byte) error {
+	*r = data
+	return nil
+}
+
+// parseLargeResult parses a resources/read result like mcp.ParseReadResourceResult, but keeps
+// blobs as slices of raw.
+func parseLargeResult(raw json.RawMessage) (*mcp.ReadResourceResult, error) {
+	var parsed struct {
+		Meta     map[string]any `json:"_meta"`
+		Contents []struct {
+			Text     *string   `json:"text"`
+			URI      string    `json:"uri"`
+			MIMEType string    `json:"mimeType"`
+			Blob     rawString `json:"blob"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if parsed.Contents == nil {
+		return nil, fmt.Errorf("contents is missing")
+	}
+
+	result := &mcp.ReadResourceResult{Result: mcp.Result{Meta: parsed.Meta}}
+	for _, c := range parsed.Contents {
+		switch {
+		case c.Text != nil:
+			result.Contents = append(result.Contents, mcp.TextResourceContents{URI: c.URI, MIMEType: c.MIMEType, Text: *c.Text})
+		case c.Blob != nil:
+			result.Contents = append(result.Contents, StreamedBlob{
+				BlobResourceContents: mcp.BlobResourceContents{URI: c.URI, MIMEType: c.MIMEType},
+				raw:                  c.Blob,
+			})
+		}
+	}
+	return result, nil
+}
+
+// writeContents writes the text and decoded blob contents of a result to w and returns the
+// number of bytes written. Blobs are decoded as they are written.
+func writeContents(w io.Writer, result *mcp.ReadResourceResult) (int64, error) {
+	var written int64
+	for _, content := range result.Contents {
+		var (
+			n   int64
+			err error
+		)
+		switch c := content.(type) {
+		case mcp.TextResourceContents:
+			var m int
+			m, err = io.WriteString(w, c.Text)
+			n = int64(m)
+		case mcp.BlobResourceContents:
+			n, err = io.Copy(w, base64.NewDecoder(base64.StdEncoding, strings.NewReader(c.Blob)))
+		case StreamedBlob:
+			n, err = c.writeTo(w)
+		}
+		written += n
+		if err != nil {
+			return written, fmt.Errorf("failed to write contents: %w", err)
+		}
+	}
+	return written, nil
+}
+
+// writeTo decodes the blob into w.
+func (b StreamedBlob) writeTo(w io.Writer) (int64, error) {
+	var encoded io.Reader
+	if bytes.IndexByte(b.raw, '\\') < 0 && len(b.raw) >= 2 {
+		encoded = bytes.NewReader(b.raw[1 : len(b.raw)-1])
+	} else {
+		// Escapes such as \/ are rare in base64, so they are resolved the slow way
+		var s string
+		if err := json.Unmarshal(b.raw, &s); err != nil {
+			return 0, fmt.Errorf("invalid blob contents: %w", err)
+		}
+		encoded = strings.NewReader(s)
+	}
+	return io.Copy(w, base64.NewDecoder(base64.StdEncoding, encoded))
+}
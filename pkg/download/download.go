@@ -0,0 +1,363 @@
+// Package download implements chunked, resumable reads of large resources.
+//
+// MCP has no native support for partial reads, so chunks are requested through the _meta field
+// of resources/read. The client sends
+//
+//	{"uri": "...", "_meta": {"range": {"offset": 0, "length": 1048576}, "cursor": "..."}}
+//
+// and a server that supports chunking answers with the requested part of the resource and either
+// a byte range or a cursor for the next chunk in the _meta field of the result:
+//
+//	{"contents": [...], "_meta": {"range": {"offset": 0, "total": 524288000}, "nextCursor": "..."}}
+//
+// Servers that ignore the _meta field return the whole resource, which is written as is. A server
+// may also include the SHA-256 checksum of the whole resource as "sha256" in the _meta field of
+// any result, which is verified once the download completes.
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// DefaultChunkSize is the number of bytes requested per chunk.
+const DefaultChunkSize = 1 << 20
+
+// DefaultRetries is the number of times a failed chunk is retried on a new connection.
+const DefaultRetries = 5
+
+// sentinel errors.
+var (
+	ErrRangeMismatch    = errors.New("server returned a different range than requested")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// ReadFunc sends a resources/read request for uri with the given _meta field.
+type ReadFunc func(ctx context.Context, uri string, meta map[string]any) (*mcp.ReadResourceResult, error)
+
+// ConnectFunc opens a new connection to the server. It returns the function used to read chunks
+// and a function that closes the connection.
+type ConnectFunc func() (ReadFunc, func(), error)
+
+// Options configures a download.
+type Options struct {
+	// Verify is the expected SHA-256 checksum of the resource in hex, optionally prefixed with
+	// "sha256:".
+	Verify    string
+	ChunkSize int
+	Retries   int
+}
+
+// Result describes a completed or interrupted download.
+type Result struct {
+	// SHA256 is the checksum of the downloaded resource in hex.
+	SHA256 string
+	// ServerSHA256 is the checksum announced by the server, if any.
+	ServerSHA256 string
+	// Size is the number of bytes downloaded so far.
+	Size int64
+}
+
+// state is persisted next to the partial file so an interrupted download can be resumed.
+type state struct {
+	URI    string `json:"uri"`
+	Cursor string `json:"cursor,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Offset int64  `json:"offset"`
+}
+
+// Resource downloads the resource at uri into path. Data is written to path.part first and moved
+// into place once the download completes; if path.part is left over from an interrupted download
+// of the same resource, the download continues where it stopped. A chunk that fails is retried on
+// a new connection. The completed file is only moved into place if its checksum matches both
+// opts.Verify and the checksum announced by the server, when given.
+func Resource(ctx context.Context, connect ConnectFunc, uri, path string, opts Options) (Result, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+
+	partPath := path + ".part"
+	statePath := partPath + ".json"
+
+	st := loadState(statePath, partPath, uri)
+
+	// #nosec G304 - the output path is provided explicitly by the user
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err = truncate(file, st.Offset); err != nil {
+		return Result{}, err
+	}
+
+	var read ReadFunc
+	closeConn := func() {}
+	defer func() { closeConn() }()
+
+	failures := 0
+	for {
+		if read == nil {
+			read, closeConn, err = connect()
+			if err != nil {
+				closeConn = func() {}
+				read = nil
+				if failures++; failures > opts.Retries {
+					return Result{Size: st.Offset}, fmt.Errorf("failed to connect: %w", err)
+				}
+				continue
+			}
+		}
+
+		meta := map[string]any{
+			"range": map[string]any{"offset": st.Offset, "length": opts.ChunkSize},
+		}
+		if st.Cursor != "" {
+			meta["cursor"] = st.Cursor
+		}
+
+		result, readErr := read(ctx, uri, meta)
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return Result{Size: st.Offset}, ctx.Err()
+			}
+			if failures++; failures > opts.Retries {
+				return Result{Size: st.Offset}, fmt.Errorf("failed to read chunk at offset %d: %w", st.Offset, readErr)
+			}
+			// The connection may have dropped, so retry on a new one
+			closeConn()
+			closeConn = func() {}
+			read = nil
+			continue
+		}
+		failures = 0
+
+		if sum, ok := result.Meta["sha256"].(string); ok {
+			st.SHA256 = sum
+		}
+
+		chunk := parseChunk(result.Meta)
+		if !chunk.chunked {
+			// The server ignored the chunk request and returned the whole resource
+			if err = truncate(file, 0); err != nil {
+				return Result{}, err
+			}
+			if st.Offset, err = writeContents(file, result); err != nil {
+				return Result{}, err
+			}
+			return finish(file, partPath, statePath, path, st, opts.Verify)
+		}
+
+		if chunk.hasOffset && chunk.offset != st.Offset {
+			return Result{Size: st.Offset}, fmt.Errorf("%w: expected offset %d, got %d", ErrRangeMismatch, st.Offset, chunk.offset)
+		}
+
+		// A chunk that fails halfway is cut off again by truncate when the download resumes
+		n, writeErr := writeContents(file, result)
+		if writeErr != nil {
+			return Result{Size: st.Offset}, writeErr
+		}
+		st.Offset += n
+		st.Cursor = chunk.nextCursor
+
+		if chunk.done(st.Offset, n) {
+			return finish(file, partPath, statePath, path, st, opts.Verify)
+		}
+
+		if err = saveState(statePath, st); err != nil {
+			return Result{Size: st.Offset}, err
+		}
+	}
+}
+
+// NewTransportReader returns a ReadFunc that sends resources/read requests directly on t, since
+// the mcp-go client does not allow setting the _meta field of a request.
+func NewTransportReader(t transport.Interface) ReadFunc {
+	var counter atomic.Int64
+
+	return func(ctx context.Context, uri string, meta map[string]any) (*mcp.ReadResourceResult, error) {
+		request := transport.JSONRPCRequest{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      mcp.NewRequestId(fmt.Sprintf("mcpt-read-%d", counter.Add(1))),
+			Method:  string(mcp.MethodResourcesRead),
+			Params: map[string]any{
+				"uri":   uri,
+				"_meta": meta,
+			},
+		}
+
+		response, err := t.SendRequest(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if response.Error != nil {
+			return nil, errors.New(response.Error.Message)
+		}
+
+		if len(response.Result) > StreamThreshold {
+			return parseLargeResult(response.Result)
+		}
+		return mcp.ParseReadResourceResult(&response.Result)
+	}
+}
+
+// chunk describes the part of a resource returned by a single read.
+type chunk struct {
+	nextCursor string
+	offset     int64
+	total      int64
+	chunked    bool
+	hasOffset  bool
+	hasTotal   bool
+}
+
+// done reports whether the chunk is the last one of the resource.
+func (c chunk) done(offset, size int64) bool {
+	if c.nextCursor != "" {
+		return false
+	}
+	if c.hasTotal {
+		return offset >= c.total
+	}
+	// Without a total or cursor, the resource ends with an empty chunk
+	return size == 0 || !c.hasOffset
+}
+
+// parseChunk extracts the chunking information from the _meta field of a result.
+func parseChunk(meta map[string]any) chunk {
+	var c chunk
+
+	if cursor, ok := meta["nextCursor"].(string); ok && cursor != "" {
+		c.chunked = true
+		c.nextCursor = cursor
+	}
+
+	if r, ok := meta["range"].(map[string]any); ok {
+		c.chunked = true
+		if offset, ok := r["offset"].(float64); ok {
+			c.offset = int64(offset)
+			c.hasOffset = true
+		}
+		if total, ok := r["total"].(float64); ok {
+			c.total = int64(total)
+			c.hasTotal = true
+		}
+	}
+
+	return c
+}
+
+// loadState returns the saved state of an interrupted download of uri, or an empty state if
+// there is none. The offset is never beyond the data actually written to the partial file.
+func loadState(statePath, partPath, uri string) state {
+	fresh := state{URI: uri}
+
+	// #nosec G304 - the state path is derived from the user-provided output path
+	raw, err := os.ReadFile(statePath)
+	if err != nil {
+		return fresh
+	}
+
+	var st state
+	if err = json.Unmarshal(raw, &st); err != nil || st.URI != uri {
+		return fresh
+	}
+
+	info, err := os.Stat(partPath)
+	if err != nil || info.Size() < st.Offset {
+		return fresh
+	}
+
+	return st
+}
+
+// saveState records the progress of the download.
+func saveState(statePath string, st state) error {
+	raw, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(statePath, raw, 0o600); err != nil {
+		return fmt.Errorf("failed to save download state: %w", err)
+	}
+	return nil
+}
+
+// truncate discards everything in file after offset and positions it there for writing.
+func truncate(file *os.File, offset int64) error {
+	if err := file.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to truncate output file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek output file: %w", err)
+	}
+	return nil
+}
+
+// finish verifies the checksum of the completed partial file, moves it into place and removes the
+// download state. A file that fails verification is removed.
+func finish(file *os.File, partPath, statePath, path string, st state, verify string) (Result, error) {
+	result := Result{Size: st.Offset, ServerSHA256: normalizeSum(st.SHA256)}
+
+	if err := file.Close(); err != nil {
+		return result, fmt.Errorf("failed to close output file: %w", err)
+	}
+
+	sum, err := FileSHA256(partPath)
+	if err != nil {
+		return result, err
+	}
+	result.SHA256 = sum
+
+	for _, expected := range []string{normalizeSum(verify), result.ServerSHA256} {
+		if expected != "" && expected != sum {
+			_ = os.Remove(partPath)
+			_ = os.Remove(statePath)
+			return result, fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expected, sum)
+		}
+	}
+
+	if err = os.Rename(partPath, path); err != nil {
+		return result, fmt.Errorf("failed to move output file into place: %w", err)
+	}
+	_ = os.Remove(statePath)
+	return result, nil
+}
+
+// FileSHA256 returns the SHA-256 checksum of the file at path in hex.
+func FileSHA256(path string) (string, error) {
+	// #nosec G304 - the path is provided explicitly by the user
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// normalizeSum strips an optional "sha256:" prefix and lowercases a hex checksum.
+func normalizeSum(sum string) string {
+	sum = strings.TrimSpace(sum)
+	sum = strings.TrimPrefix(strings.ToLower(sum), "sha256:")
+	return sum
+}
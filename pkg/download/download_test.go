@@ -0,0 +1,256 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// rangeServer serves data in the requested byte ranges and fails once at failAt.
+type rangeServer struct {
+	data     []byte
+	failAt   int64
+	failed   bool
+	connects int
+}
+
+func (s *rangeServer) connect() (ReadFunc, func(), error) {
+	s.connects++
+	return s.read, func() {}, nil
+}
+
+func (s *rangeServer) read(_ context.Context, uri string, meta map[string]any) (*mcp.ReadResourceResult, error) {
+	r := meta["range"].(map[string]any)
+	offset := r["offset"].(int64)
+	length := int64(r["length"].(int))
+
+	if offset == s.failAt && !s.failed {
+		s.failed = true
+		return nil, errors.New("connection reset")
+	}
+
+	end := min(offset+length, int64(len(s.data)))
+	return &mcp.ReadResourceResult{
+		Result: mcp.Result{Meta: map[string]any{
+			"range": map[string]any{"offset": float64(offset), "total": float64(len(s.data))},
+		}},
+		Contents: []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, Text: string(s.data[offset:end])}},
+	}, nil
+}
+
+func TestResourceRetriesOnNewConnection(t *testing.T) {
+	server := &rangeServer{data: bytes.Repeat([]byte("0123456789"), 100), failAt: 256}
+	path := filepath.Join(t.TempDir(), "out.txt")
+
+	result, err := Resource(context.Background(), server.connect, "file:///big", path, Options{ChunkSize: 128, Retries: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := result.Size
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != int64(len(server.data)) || !bytes.Equal(got, server.data) {
+		t.Errorf("downloaded %d bytes that do not match the resource", size)
+	}
+	if server.connects != 2 {
+		t.Errorf("expected a reconnect after the failure, got %d connections", server.connects)
+	}
+	if _, err = os.Stat(path + ".part.json"); !os.IsNotExist(err) {
+		t.Errorf("download state was not removed")
+	}
+}
+
+func TestResourceResumesInterruptedDownload(t *testing.T) {
+	server := &rangeServer{data: bytes.Repeat([]byte("abcdefgh"), 64), failAt: 256}
+	path := filepath.Join(t.TempDir(), "out.txt")
+
+	result, err := Resource(context.Background(), server.connect, "file:///big", path, Options{ChunkSize: 64})
+	if err == nil {
+		t.Fatal("expected the first attempt to fail")
+	}
+	if result.Size != 256 {
+		t.Errorf("expected 256 bytes before the failure, got %d", result.Size)
+	}
+
+	server.connects = 0
+	if _, err = Resource(context.Background(), server.connect, "file:///big", path, Options{ChunkSize: 64}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, server.data) {
+		t.Errorf("resumed download does not match the resource")
+	}
+}
+
+func TestResourceWithoutChunkingSupport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	connect := func() (ReadFunc, func(), error) {
+		return func(_ context.Context, uri string, _ map[string]any) (*mcp.ReadResourceResult, error) {
+			return &mcp.ReadResourceResult{
+				Contents: []mcp.ResourceContents{mcp.BlobResourceContents{URI: uri, Blob: "aGVsbG8="}},
+			}, nil
+		}, func() {}, nil
+	}
+
+	result, err := Resource(context.Background(), connect, "file:///small", path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if result.Size != 5 || string(got) != "hello" {
+		t.Errorf("got %d bytes %q, want hello", result.Size, got)
+	}
+	if result.SHA256 != helloSHA256 {
+		t.Errorf("SHA256 = %s, want %s", result.SHA256, helloSHA256)
+	}
+}
+
+func TestParseLargeResultStreamsBlobs(t *testing.T) {
+	data := bytes.Repeat([]byte{0xfb, 0xff, 0x00, 'x'}, 1000)
+	encoded := base64.StdEncoding.EncodeToString(data)
+	escaped := strings.ReplaceAll(encoded, "/", `\/`)
+
+	for name, blob := range map[string]string{"plain": encoded, "escaped": escaped} {
+		raw := json.RawMessage(`{"_meta":{"sha256":"abc"},"contents":[` +
+			`{"uri":"file:///a","text":"head:"},{"uri":"file:///a","blob":"` + blob + `"}]}`)
+
+		result, err := parseLargeResult(raw)
+		if err != nil {
+			t.Fatalf("%s: parseLargeResult() error = %v", name, err)
+		}
+		if result.Meta["sha256"] != "abc" {
+			t.Errorf("%s: Meta = %v", name, result.Meta)
+		}
+		if b, ok := result.Contents[1].(StreamedBlob); !ok || b.Blob != "" {
+			t.Errorf("%s: blob was decoded into memory: %T", name, result.Contents[1])
+		}
+
+		var buf bytes.Buffer
+		n, err := writeContents(&buf, result)
+		if err != nil {
+			t.Fatalf("%s: writeContents() error = %v", name, err)
+		}
+		want := append([]byte("head:"), data...)
+		if n != int64(len(want)) || !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s: writeContents() wrote %d bytes that differ from the resource", name, n)
+		}
+	}
+}
+
+const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func TestResourceVerifiesChecksums(t *testing.T) {
+	tests := []struct {
+		name       string
+		verify     string
+		serverSum  string
+		wantErr    bool
+		wantServer string
+	}{
+		{name: "matching --verify", verify: "SHA256:" + helloSHA256},
+		{name: "matching server checksum", serverSum: helloSHA256, wantServer: helloSHA256},
+		{name: "wrong --verify", verify: "deadbeef", wantErr: true},
+		{name: "wrong server checksum", serverSum: "deadbeef", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.txt")
+			connect := func() (ReadFunc, func(), error) {
+				return func(_ context.Context, uri string, _ map[string]any) (*mcp.ReadResourceResult, error) {
+					result := &mcp.ReadResourceResult{
+						Contents: []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, Text: "hello"}},
+					}
+					if tt.serverSum != "" {
+						result.Meta = map[string]any{"sha256": tt.serverSum}
+					}
+					return result, nil
+				}, func() {}, nil
+			}
+
+			result, err := Resource(context.Background(), connect, "file:///small", path, Options{Verify: tt.verify})
+			if tt.wantErr {
+				if !errors.Is(err, ErrChecksumMismatch) {
+					t.Fatalf("expected a checksum mismatch, got %v", err)
+				}
+				if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+					t.Errorf("file that failed verification was moved into place")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ServerSHA256 != tt.wantServer {
+				t.Errorf("ServerSHA256 = %q, want %q", result.ServerSHA256, tt.wantServer)
+			}
+		})
+	}
+}
+
+func TestStoreLinksIdenticalContent(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "blobs"))
+
+	first := filepath.Join(dir, "a.txt")
+	second := filepath.Join(dir, "b.txt")
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	existing, err := store.Link(first, helloSHA256)
+	if err != nil || existing {
+		t.Fatalf("Link(first) = %v, %v; want a new blob", existing, err)
+	}
+	existing, err = store.Link(second, helloSHA256)
+	if err != nil || !existing {
+		t.Fatalf("Link(second) = %v, %v; want the stored blob", existing, err)
+	}
+
+	firstInfo, _ := os.Stat(first)
+	secondInfo, _ := os.Stat(second)
+	if !os.SameFile(firstInfo, secondInfo) {
+		t.Errorf("files with identical content are not linked to the same blob")
+	}
+}
+
+func TestStream(t *testing.T) {
+	server := &rangeServer{data: bytes.Repeat([]byte("0123456789"), 100), failAt: 256}
+
+	var buf bytes.Buffer
+	result, err := Stream(context.Background(), server.connect, "file:///big", &buf, Options{ChunkSize: 128, Retries: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), server.data) || result.Size != int64(len(server.data)) {
+		t.Errorf("streamed %d bytes that do not match the resource", result.Size)
+	}
+	if server.connects != 2 {
+		t.Errorf("expected a reconnect after the failure, got %d connections", server.connects)
+	}
+
+	buf.Reset()
+	_, err = Stream(context.Background(), (&rangeServer{data: server.data, failAt: -1}).connect, "file:///big", &buf,
+		Options{ChunkSize: 128, Verify: "sha256:" + strings.Repeat("0", 64)})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+}
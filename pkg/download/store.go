@@ -0,0 +1,73 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Store is a content-addressed blob store. Blobs are named by their SHA-256 checksum and linked
+// into destination trees with hard links, so identical content downloaded to several places or
+// by repeated syncs is only stored once.
+type Store struct {
+	Dir string
+}
+
+// GetStorePath returns the path to the default blob store in the user's home directory.
+func GetStorePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".mcpt", "blobs"), nil
+}
+
+// NewStore creates a store in dir.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// blobPath returns where the blob with the given checksum is stored.
+func (s *Store) blobPath(sum string) string {
+	return filepath.Join(s.Dir, sum[:2], sum)
+}
+
+// Link moves the file at path into the store under its checksum, or drops it if the store
+// already holds the same content, and replaces it with a hard link to the stored blob. It reports
+// whether the content was already stored. If the store is on a different file system, the file is
+// left in place.
+func (s *Store) Link(path, sum string) (bool, error) {
+	if len(sum) < 2 {
+		return false, fmt.Errorf("invalid checksum: %q", sum)
+	}
+
+	blob := s.blobPath(sum)
+	if err := os.MkdirAll(filepath.Dir(blob), 0o750); err != nil {
+		return false, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	if _, err := os.Stat(blob); errors.Is(err, os.ErrNotExist) {
+		if err = os.Link(path, blob); err != nil {
+			// Hard links cannot cross file systems, keep the plain file
+			return false, nil
+		}
+		// Stored blobs are shared between trees and must not be modified in place
+		_ = os.Chmod(blob, 0o444)
+		return false, nil
+	}
+
+	// Link to a temporary name first so path is never missing if linking fails
+	tmp := path + ".link"
+	_ = os.Remove(tmp)
+	if err := os.Link(blob, tmp); err != nil {
+		return true, nil
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return true, fmt.Errorf("failed to replace file with stored blob: %w", err)
+	}
+
+	return true, nil
+}
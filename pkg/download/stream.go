@@ -0,0 +1,108 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotResumable is returned by Stream when the server restarts a resource halfway through,
+// which cannot be undone in a stream that was already written.
+var ErrNotResumable = errors.New("server returned the whole resource after a partial read")
+
+// Stream reads the resource at uri in chunks and writes it to w, for destinations that cannot
+// be truncated and resumed such as uploads to object storage. A chunk that fails is retried on a
+// new connection, but an interrupted stream starts over when run again. The checksum is
+// verified against opts.Verify and the checksum announced by the server once the resource is
+// read; the caller must discard what was written if an error is returned.
+func Stream(ctx context.Context, connect ConnectFunc, uri string, w io.Writer, opts Options) (Result, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+
+	hash := sha256.New()
+	out := io.MultiWriter(w, hash)
+	var (
+		offset    int64
+		cursor    string
+		serverSum string
+		read      ReadFunc
+		err       error
+	)
+	closeConn := func() {}
+	defer func() { closeConn() }()
+
+	failures := 0
+	for {
+		if read == nil {
+			read, closeConn, err = connect()
+			if err != nil {
+				closeConn = func() {}
+				read = nil
+				if failures++; failures > opts.Retries {
+					return Result{Size: offset}, fmt.Errorf("failed to connect: %w", err)
+				}
+				continue
+			}
+		}
+
+		meta := map[string]any{
+			"range": map[string]any{"offset": offset, "length": opts.ChunkSize},
+		}
+		if cursor != "" {
+			meta["cursor"] = cursor
+		}
+
+		result, readErr := read(ctx, uri, meta)
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return Result{Size: offset}, ctx.Err()
+			}
+			if failures++; failures > opts.Retries {
+				return Result{Size: offset}, fmt.Errorf("failed to read chunk at offset %d: %w", offset, readErr)
+			}
+			closeConn()
+			closeConn = func() {}
+			read = nil
+			continue
+		}
+		failures = 0
+
+		if sum, ok := result.Meta["sha256"].(string); ok {
+			serverSum = sum
+		}
+
+		chunk := parseChunk(result.Meta)
+		if !chunk.chunked && offset > 0 {
+			return Result{Size: offset}, ErrNotResumable
+		}
+		if chunk.chunked && chunk.hasOffset && chunk.offset != offset {
+			return Result{Size: offset}, fmt.Errorf("%w: expected offset %d, got %d", ErrRangeMismatch, offset, chunk.offset)
+		}
+
+		n, writeErr := writeContents(out, result)
+		offset += n
+		if writeErr != nil {
+			return Result{Size: offset}, writeErr
+		}
+		cursor = chunk.nextCursor
+
+		if !chunk.chunked || chunk.done(offset, n) {
+			break
+		}
+	}
+
+	res := Result{Size: offset, SHA256: hex.EncodeToString(hash.Sum(nil)), ServerSHA256: normalizeSum(serverSum)}
+	for _, expected := range []string{normalizeSum(opts.Verify), res.ServerSHA256} {
+		if expected != "" && expected != res.SHA256 {
+			return res, fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expected, res.SHA256)
+		}
+	}
+	return res, nil
+}
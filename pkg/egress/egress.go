@@ -0,0 +1,217 @@
+/*
+Package egress observes the outbound network connections of a process and its children on
+Linux, by polling /proc: the sockets a process holds open are matched with the connections
+listed in /proc/<pid>/net. Polling sees connections that stay open for at least an interval, which
+covers the HTTP clients servers typically use, but may miss very short-lived ones.
+*/
+package egress
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultInterval is how often connections are polled by default.
+const DefaultInterval = time.Second
+
+// ErrUnsupported is returned by Watch on systems without /proc.
+var ErrUnsupported = errors.New("observing network egress is only supported on Linux")
+
+// procRoot is where procfs is mounted, changed in tests.
+var procRoot = "/proc"
+
+// Destination is a remote endpoint a process connected to.
+type Destination struct {
+	Protocol string
+	Addr     netip.AddrPort
+}
+
+func (d Destination) String() string {
+	return d.Protocol + " " + d.Addr.String()
+}
+
+// Local reports whether the destination is on this machine.
+func (d Destination) Local() bool {
+	return d.Addr.Addr().Unmap().IsLoopback()
+}
+
+// Supported reports whether egress can be observed on this system.
+func Supported() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(procRoot, "self", "net", "tcp"))
+	return err == nil
+}
+
+// Watch polls the connections of process pid and its descendants every interval until ctx is
+// done or the process exits, calling report once for each new destination.
+func Watch(ctx context.Context, pid int, interval time.Duration, report func(Destination)) error {
+	if !Supported() {
+		return ErrUnsupported
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	seen := map[Destination]bool{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := os.Stat(filepath.Join(procRoot, strconv.Itoa(pid))); err != nil {
+			return nil
+		}
+		for _, dest := range Connections(pid) {
+			if !seen[dest] {
+				seen[dest] = true
+				report(dest)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Connections returns the remote endpoints process pid and its descendants are connected to.
+// Processes that exit while they are inspected are skipped.
+func Connections(pid int) []Destination {
+	var dests []Destination
+	seen := map[Destination]bool{}
+	for _, p := range processTree(pid) {
+		inodes := socketInodes(p)
+		if len(inodes) == 0 {
+			continue
+		}
+		for _, table := range []string{"tcp", "tcp6", "udp", "udp6"} {
+			for _, dest := range connectionTable(p, table, inodes) {
+				if !seen[dest] {
+					seen[dest] = true
+					dests = append(dests, dest)
+				}
+			}
+		}
+	}
+	return dests
+}
+
+// processTree returns pid and its descendants, found through the parent IDs in /proc/*/stat.
+func processTree(pid int) []int {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return []int{pid}
+	}
+	children := map[int][]int{}
+	for _, entry := range entries {
+		child, convErr := strconv.Atoi(entry.Name())
+		if convErr != nil {
+			continue
+		}
+		data, readErr := os.ReadFile(filepath.Join(procRoot, entry.Name(), "stat"))
+		if readErr != nil {
+			continue
+		}
+		// The command name is in parentheses and may contain spaces; the parent ID is the
+		// second field after it
+		stat := string(data)
+		fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+		if len(fields) < 2 {
+			continue
+		}
+		if parent, convErr := strconv.Atoi(fields[1]); convErr == nil {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	tree := []int{pid}
+	for i := 0; i < len(tree); i++ {
+		tree = append(tree, children[tree[i]]...)
+	}
+	return tree
+}
+
+// socketInodes returns the inodes of the sockets process pid has open.
+func socketInodes(pid int) map[string]bool {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid), "fd")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	inodes := map[string]bool{}
+	for _, entry := range entries {
+		target, linkErr := os.Readlink(filepath.Join(dir, entry.Name()))
+		if linkErr == nil && strings.HasPrefix(target, "socket:[") {
+			inodes[strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")] = true
+		}
+	}
+	return inodes
+}
+
+// tcpListen is the state of listening TCP sockets in /proc/net/tcp.
+const tcpListen = "0A"
+
+// connectionTable returns the remote endpoints of the sockets with the given inodes in a table
+// of /proc/<pid>/net, such as tcp or udp6.
+func connectionTable(pid int, table string, inodes map[string]bool) []Destination {
+	file, err := os.Open(filepath.Join(procRoot, strconv.Itoa(pid), "net", table))
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	protocol := strings.TrimSuffix(table, "6")
+	var dests []Destination
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || !inodes[fields[9]] || (protocol == "tcp" && fields[3] == tcpListen) {
+			continue
+		}
+		remote, parseErr := parseAddr(fields[2])
+		if parseErr != nil || remote.Port() == 0 || remote.Addr().IsUnspecified() {
+			continue
+		}
+		dests = append(dests, Destination{Protocol: protocol, Addr: remote})
+	}
+	return dests
+}
+
+// parseAddr parses an address of /proc/net/tcp, such as 0100007F:1F90: the IP address as 32-bit
+// words in host byte order, and the port, in hexadecimal.
+func parseAddr(s string) (netip.AddrPort, error) {
+	host, portHex, found := strings.Cut(s, ":")
+	if !found {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", s)
+	}
+	raw, err := hex.DecodeString(host)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("invalid port in %q", s)
+	}
+
+	// The kernel prints each 32-bit word of the address as a number in host byte order
+	ip := make([]byte, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.NativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(raw[i:i+4]))
+	}
+	addr, _ := netip.AddrFromSlice(ip)
+	return netip.AddrPortFrom(addr.Unmap(), uint16(port)), nil
+}
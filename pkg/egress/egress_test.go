@@ -0,0 +1,111 @@
+package egress
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+)
+
+// procAddr formats an address the way the kernel prints it in /proc/net/tcp.
+func procAddr(addr netip.AddrPort) string {
+	raw := addr.Addr().AsSlice()
+	s := ""
+	for i := 0; i < len(raw); i += 4 {
+		s += fmt.Sprintf("%08X", binary.NativeEndian.Uint32(raw[i:i+4]))
+	}
+	return fmt.Sprintf("%s:%04X", s, addr.Port())
+}
+
+func TestParseAddr(t *testing.T) {
+	for _, want := range []string{"127.0.0.1:8080", "93.184.216.34:443", "[2001:db8::1]:443", "[::1]:5432"} {
+		addr := netip.MustParseAddrPort(want)
+		got, err := parseAddr(procAddr(addr))
+		if err != nil || got != addr {
+			t.Errorf("parseAddr(%s) = %v, %v; want %s", procAddr(addr), got, err, want)
+		}
+	}
+	// IPv4-mapped addresses in tcp6 are reported as IPv4
+	got, err := parseAddr(procAddr(netip.MustParseAddrPort("[::ffff:10.0.0.1]:80")))
+	if err != nil || got.String() != "10.0.0.1:80" {
+		t.Errorf("mapped address parsed as %v, %v", got, err)
+	}
+	if _, err = parseAddr("zz:1"); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
+
+func TestLocal(t *testing.T) {
+	if !(Destination{Protocol: "tcp", Addr: netip.MustParseAddrPort("127.0.0.1:80")}).Local() {
+		t.Error("loopback destination is not local")
+	}
+	if (Destination{Protocol: "tcp", Addr: netip.MustParseAddrPort("10.1.2.3:80")}).Local() {
+		t.Error("private network destination is local")
+	}
+}
+
+func TestWatchReportsConnections(t *testing.T) {
+	if !Supported() {
+		t.Skip(ErrUnsupported)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	want := netip.MustParseAddrPort(listener.Addr().String())
+	var reported []Destination
+	_ = Watch(ctx, os.Getpid(), 10*time.Millisecond, func(dest Destination) {
+		reported = append(reported, dest)
+		if dest.Addr == want {
+			cancel()
+		}
+	})
+	if !slices.Contains(reported, Destination{Protocol: "tcp", Addr: want}) {
+		t.Fatalf("connection to %s was not reported: %v", want, reported)
+	}
+	for i, dest := range reported {
+		if slices.Contains(reported[i+1:], dest) {
+			t.Errorf("%s was reported twice", dest)
+		}
+	}
+}
+
+func TestProcessTreeIncludesChildren(t *testing.T) {
+	if !Supported() {
+		t.Skip(ErrUnsupported)
+	}
+	child := exec.Command("sleep", "5")
+	if err := child.Start(); err != nil {
+		t.Skip("sleep is not available")
+	}
+	defer func() { _ = child.Process.Kill(); _ = child.Wait() }()
+
+	if tree := processTree(os.Getpid()); !slices.Contains(tree, child.Process.Pid) {
+		t.Errorf("process tree %v does not include child %d", tree, child.Process.Pid)
+	}
+}
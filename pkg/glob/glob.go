@@ -0,0 +1,235 @@
+// Package glob expands @glob: patterns in tool parameters into arrays of the files or server
+// resource URIs they match, for tools that take lists of files.
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Prefix marks a parameter value as a pattern to expand, as in @glob:src/**/*.go.
+const Prefix = "@glob:"
+
+// Match reports whether name matches pattern. Segments of both are separated by /, ** matches
+// any number of segments, and other segments are matched as by path.Match.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// IsURI reports whether a pattern matches resource URIs, such as file:///src/*.go, rather
+// than files.
+func IsURI(pattern string) bool {
+	scheme, _, found := strings.Cut(pattern, "://")
+	return found && scheme != "" && !strings.ContainsAny(scheme, "*?[/")
+}
+
+// Files returns the files matching a pattern such as src/**/*.go, sorted, written the way the
+// pattern is: relative patterns give relative paths.
+func Files(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		if info, err := os.Stat(pattern); err != nil || info.IsDir() {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	// Only the directory before the first segment with a wildcard is walked
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	static := 0
+	for static < len(segments)-1 && !strings.ContainsAny(segments[static], "*?[") {
+		static++
+	}
+	root := strings.Join(segments[:static], "/")
+	switch {
+	case root == "" && strings.HasPrefix(pattern, "/"):
+		root = "/"
+	case root == "":
+		root = "."
+	}
+
+	var matches []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := filepath.ToSlash(p)
+		if root == "." {
+			name = strings.TrimPrefix(name, "./")
+		}
+		if Match(filepath.ToSlash(path.Clean(pattern)), name) {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// URIs returns the URIs matching a pattern, in the order given.
+func URIs(pattern string, uris []string) []string {
+	var matches []string
+	for _, uri := range uris {
+		if Match(pattern, uri) {
+			matches = append(matches, uri)
+		}
+	}
+	return matches
+}
+
+// Expand returns a copy of params with every @glob: value replaced by an array of the files or
+// resource URIs it matches. In arrays, the matches are spliced in place of the pattern.
+// resources lists the URIs of the server's resources; it is only called for URI patterns, and
+// at most once. A pattern matching nothing is an error.
+func Expand(params map[string]any, resources func() ([]string, error)) (map[string]any, error) {
+	e := expander{resources: resources}
+	expanded, err := e.expand(params)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := expanded.(map[string]any)
+	return result, nil
+}
+
+// Has reports whether params contain an @glob: value.
+func Has(params map[string]any) bool {
+	var has func(v any) bool
+	has = func(v any) bool {
+		switch v := v.(type) {
+		case string:
+			return strings.HasPrefix(v, Prefix)
+		case map[string]any:
+			for _, value := range v {
+				if has(value) {
+					return true
+				}
+			}
+		case []any:
+			for _, value := range v {
+				if has(value) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return has(params)
+}
+
+type expander struct {
+	resources func() ([]string, error)
+	uris      []string
+	listed    bool
+}
+
+func (e *expander) expand(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		if pattern, ok := strings.CutPrefix(v, Prefix); ok {
+			return e.match(pattern)
+		}
+		return v, nil
+	case map[string]any:
+		expanded := make(map[string]any, len(v))
+		for key, value := range v {
+			value, err := e.expand(value)
+			if err != nil {
+				return nil, err
+			}
+			expanded[key] = value
+		}
+		return expanded, nil
+	case []any:
+		expanded := make([]any, 0, len(v))
+		for _, value := range v {
+			if s, ok := value.(string); ok && strings.HasPrefix(s, Prefix) {
+				matches, err := e.match(strings.TrimPrefix(s, Prefix))
+				if err != nil {
+					return nil, err
+				}
+				expanded = append(expanded, matches...)
+				continue
+			}
+			value, err := e.expand(value)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, value)
+		}
+		return expanded, nil
+	}
+	return v, nil
+}
+
+func (e *expander) match(pattern string) ([]any, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern in %s", Prefix)
+	}
+
+	var matches []string
+	if IsURI(pattern) {
+		if !e.listed {
+			if e.resources == nil {
+				return nil, fmt.Errorf("%s%s: no server to list resources of", Prefix, pattern)
+			}
+			uris, err := e.resources()
+			if err != nil {
+				return nil, fmt.Errorf("%s%s: %w", Prefix, pattern, err)
+			}
+			e.uris, e.listed = uris, true
+		}
+		matches = URIs(pattern, e.uris)
+	} else {
+		var err error
+		if matches, err = Files(pattern); err != nil {
+			return nil, fmt.Errorf("%s%s: %w", Prefix, pattern, err)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%s%s matched nothing", Prefix, pattern)
+	}
+	values := make([]any, len(matches))
+	for i, match := range matches {
+		values[i] = match
+	}
+	return values, nil
+}
@@ -0,0 +1,83 @@
+package glob
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"src/*.go", "src/main.go", true},
+		{"src/*.go", "src/pkg/main.go", false},
+		{"src/**/*.go", "src/main.go", true},
+		{"src/**/*.go", "src/pkg/deep/main.go", true},
+		{"src/**/*.go", "src/main.txt", false},
+		{"**", "a/b/c", true},
+		{"file:///docs/**/*.md", "file:///docs/guide/intro.md", true},
+		{"file:///docs/*.md", "file:///docs/guide/intro.md", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/main.go", "src/pkg/util.go", "src/pkg/notes.txt", "README.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	listed := 0
+	resources := func() ([]string, error) {
+		listed++
+		return []string{"repo://docs/a.md", "repo://docs/b.txt", "repo://docs/c.md"}, nil
+	}
+
+	params := map[string]any{
+		"paths": "@glob:src/**/*.go",
+		"docs":  "@glob:repo://docs/*.md",
+		"files": []any{"README.md", "@glob:repo://docs/*.txt"},
+		"mode":  "read",
+	}
+	expanded, err := Expand(params, resources)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	want := map[string]string{
+		"paths": "[src/main.go src/pkg/util.go]",
+		"docs":  "[repo://docs/a.md repo://docs/c.md]",
+		"files": "[README.md repo://docs/b.txt]",
+		"mode":  "read",
+	}
+	for key, value := range want {
+		if got := fmt.Sprint(expanded[key]); got != value {
+			t.Errorf("%s = %s, want %s", key, got, value)
+		}
+	}
+	if listed != 1 {
+		t.Errorf("resources listed %d times, want once", listed)
+	}
+	if params["paths"] != "@glob:src/**/*.go" {
+		t.Error("Expand() modified params")
+	}
+
+	if _, err = Expand(map[string]any{"paths": "@glob:lib/*.go"}, resources); err == nil {
+		t.Error("Expand() of a pattern matching nothing succeeded, want an error")
+	}
+}
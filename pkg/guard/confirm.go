@@ -0,0 +1,158 @@
+package guard
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/consent"
+)
+
+// Reasons of requests blocked when confirming them.
+const (
+	// ReasonUnconfirmed requests match a confirm pattern and were not confirmed on the terminal.
+	ReasonUnconfirmed = "unconfirmed"
+	// ReasonRefused requests name an entity the user chose never to allow.
+	ReasonRefused = "refused"
+)
+
+// Answers to a confirmation that are remembered.
+const (
+	answerAlways = "always"
+	answerNever  = "never"
+)
+
+// askTerminal asks a question on the controlling terminal, as stdin and stdout carry the
+// protocol, and returns the answer in lower case. It is swapped out in tests.
+var askTerminal = func(question, choices string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tty.Close() }()
+
+	fmt.Fprintf(tty, "%s %s ", question, choices)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(strings.TrimSpace(answer)), nil
+}
+
+// WithConsents applies the consents remembered for server in store, and remembers the answers
+// always and never to confirmations there. Entities the user chose never to allow are blocked
+// even without a confirm pattern.
+func WithConsents(store *consent.Store, server string) Option {
+	return func(s *FilterServer) {
+		s.consents = store
+		s.consentServer = server
+	}
+}
+
+// confirm asks whether to let through a request for an entity matching a confirm pattern of the
+// policy, unless the user's consent for the entity is remembered. Requests that are declined,
+// or cannot be confirmed without a terminal, are blocked with the explanation returned.
+func (s *FilterServer) confirm(entityType, name, request string, params map[string]interface{}) (Explanation, bool) {
+	if explanation, allowed, remembered := s.remembered(entityType, name, request); remembered {
+		return explanation, allowed
+	}
+
+	pattern := ""
+	for _, p := range s.confirmPatterns[entityType] {
+		if match, _ := filepath.Match(p, name); match {
+			pattern = p
+			break
+		}
+	}
+	if pattern == "" {
+		return Explanation{}, true
+	}
+
+	question := fmt.Sprintf("Guard: allow %s?", request)
+	if arguments, ok := params["arguments"]; ok {
+		if data, err := json.Marshal(arguments); err == nil {
+			question = fmt.Sprintf("Guard: allow %s with %s?", request, data)
+		}
+	}
+	choices := "[y/N]"
+	if s.consents != nil {
+		choices = "[y/N/always/never]"
+	}
+	answer, err := askTerminal(question, choices)
+	if s.consents == nil && (answer == answerAlways || answer == answerNever) {
+		answer = ""
+	}
+	switch answer {
+	case "y", "yes":
+		s.log(fmt.Sprintf("Confirmed %s", request))
+		return Explanation{}, true
+	case answerAlways:
+		s.remember(entityType, name, consent.Allow)
+		s.log(fmt.Sprintf("Confirmed %s, and every later one", request))
+		return Explanation{}, true
+	case answerNever:
+		c := s.remember(entityType, name, consent.Deny)
+		return s.refused(c), false
+	}
+
+	explanation := Explanation{
+		Reason: ReasonUnconfirmed,
+		Entity: entityType,
+		Name:   name,
+		Rule:   fmt.Sprintf("confirm %s:%s", entityType, pattern),
+		Source: s.source(s.confirmPatterns[entityType], pattern),
+		Hint:   "The request was declined on the terminal running the guard",
+	}
+	if err != nil {
+		explanation.Hint = "Requests matching a confirm pattern are confirmed on the terminal running the guard, and it has none; " +
+			"run the guard from a terminal, or remove the pattern from the confirm patterns of the " + explanation.Source
+	}
+	return explanation, false
+}
+
+// remembered applies the consent remembered for an entity, if any: requests for entities the
+// user chose to always allow pass, and those for entities they chose never to allow are blocked.
+func (s *FilterServer) remembered(entityType, name, request string) (Explanation, bool, bool) {
+	if s.consents == nil {
+		return Explanation{}, false, false
+	}
+	c, found, err := s.consents.Lookup(s.consentServer, entityType, name)
+	if err != nil {
+		s.log(fmt.Sprintf("Error reading consents: %v", err))
+		return Explanation{}, false, false
+	}
+	if !found {
+		return Explanation{}, false, false
+	}
+	if c.Decision == consent.Allow {
+		s.log(fmt.Sprintf("Allowed %s, as consented on %s", request, c.Time.Format(time.DateOnly)))
+		return Explanation{}, true, true
+	}
+	return s.refused(c), false, true
+}
+
+// remember stores the user's consent for an entity of the server, and returns it.
+func (s *FilterServer) remember(entityType, name, decision string) consent.Consent {
+	c := consent.Consent{Server: s.consentServer, Entity: entityType, Name: name, Decision: decision, Time: time.Now()}
+	if err := s.consents.Remember(c); err != nil {
+		s.log(fmt.Sprintf("Error remembering consent: %v", err))
+		fmt.Fprintf(os.Stderr, "Warning: the answer is not remembered: %v\n", err)
+	}
+	return c
+}
+
+// refused explains why a request for an entity the user chose never to allow is blocked.
+func (s *FilterServer) refused(c consent.Consent) Explanation {
+	return Explanation{
+		Reason: ReasonRefused,
+		Entity: c.Entity,
+		Name:   c.Name,
+		Rule:   fmt.Sprintf("never %s:%s", c.Entity, c.Name),
+		Source: "consent of " + c.Time.Format(time.DateOnly),
+		Hint:   fmt.Sprintf("The user chose never to allow it; forget the choice with: mcp consent reset %s", c.Name),
+	}
+}
@@ -0,0 +1,156 @@
+package guard
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/f/mcptools/pkg/admin"
+)
+
+// Reasons a request is blocked.
+const (
+	// ReasonDenied requests name an entity matching a deny pattern.
+	ReasonDenied = "denied"
+	// ReasonNotAllowed requests name an entity matching none of the allow patterns of its type.
+	ReasonNotAllowed = "not_allowed"
+	// ReasonOverride requests name an entity denied by an override made through the admin API.
+	ReasonOverride = "admin_override"
+	// ReasonDeprecated requests call a tool the server marks as deprecated, with --no-deprecated.
+	ReasonDeprecated = "deprecated"
+)
+
+// SourceCommandLine is the source of the patterns given with --allow and --deny.
+const SourceCommandLine = "command line"
+
+// Explanation tells why the guard blocked a request. It is sent as the data of the JSON-RPC
+// error returned for the request, and logged.
+type Explanation struct {
+	Reason string `json:"reason"`
+	Entity string `json:"entity"`
+	Name   string `json:"name"`
+	// Rule is the rule that blocked the request, such as "deny tool:write_*".
+	Rule string `json:"rule,omitempty"`
+	// Source is where the rule comes from: a policy file, the command line or an override.
+	Source string `json:"source,omitempty"`
+	Hint   string `json:"hint"`
+}
+
+// String describes the explanation for the log.
+func (e Explanation) String() string {
+	s := fmt.Sprintf("%s %s: %s", e.Entity, e.Name, e.Reason)
+	if e.Rule != "" {
+		s += " by " + e.Rule
+	}
+	if e.Source != "" {
+		s += " (" + e.Source + ")"
+	}
+	return s
+}
+
+// WithPolicy applies the confirm patterns of policy, loaded from path, and attributes its
+// patterns to the file in the explanations of blocked requests. Its allow and deny patterns must
+// also be passed to the filter server.
+func WithPolicy(path string, policy *Policy) Option {
+	return func(s *FilterServer) {
+		s.policyPath = path
+		s.policy = policy
+		s.confirmPatterns = policy.Confirm
+	}
+}
+
+// Explain tells why the entity called name is blocked, or returns false if it is allowed.
+func (s *FilterServer) Explain(entityType, name string) (Explanation, bool) {
+	explanation := Explanation{Entity: entityType, Name: name}
+	if s.overrides != nil {
+		if o, matched := s.overrides.Match(entityType, name); matched {
+			if o.Action != admin.ActionDeny {
+				return Explanation{}, false
+			}
+			explanation.Reason = ReasonOverride
+			explanation.Rule = fmt.Sprintf("deny %s:%s", entityType, o.Pattern)
+			explanation.Source = fmt.Sprintf("admin override #%d", o.ID)
+			explanation.Hint = fmt.Sprintf("Remove the override with 'mcp admin revoke %d', or wait for it to expire", o.ID)
+			return explanation, true
+		}
+	}
+
+	for _, pattern := range s.denyPatterns[entityType] {
+		if match, _ := filepath.Match(pattern, name); match {
+			explanation.Reason = ReasonDenied
+			explanation.Rule = fmt.Sprintf("deny %s:%s", entityType, pattern)
+			explanation.Source = s.source(s.policyDeny(entityType), pattern)
+			explanation.Hint = fmt.Sprintf("Remove the deny pattern %q from %s to allow %s",
+				pattern, place(explanation.Source, "--deny"), name)
+			return explanation, true
+		}
+	}
+
+	allowPatterns := s.allowPatterns[entityType]
+	if len(allowPatterns) == 0 {
+		return Explanation{}, false
+	}
+	for _, pattern := range allowPatterns {
+		if match, _ := filepath.Match(pattern, name); match {
+			return Explanation{}, false
+		}
+	}
+	var sources, places []string
+	for _, pattern := range allowPatterns {
+		if source := s.source(s.policyAllow(entityType), pattern); !slices.Contains(sources, source) {
+			sources = append(sources, source)
+			places = append(places, place(source, "--allow"))
+		}
+	}
+	explanation.Reason = ReasonNotAllowed
+	explanation.Rule = fmt.Sprintf("allow %s:%s", entityType, strings.Join(allowPatterns, ","))
+	explanation.Source = strings.Join(sources, ", ")
+	explanation.Hint = fmt.Sprintf("Only the %ss matching an allow pattern are available; add a pattern matching %s to %s",
+		entityType, name, strings.Join(places, " or "))
+	return explanation, true
+}
+
+// explainDeprecated tells why a call to a deprecated tool is blocked.
+func explainDeprecated(name string) Explanation {
+	return Explanation{
+		Reason: ReasonDeprecated,
+		Entity: "tool",
+		Name:   name,
+		Rule:   "--no-deprecated",
+		Source: SourceCommandLine,
+		Hint:   "The server marks this tool as deprecated; use its replacement, or run the guard without --no-deprecated",
+	}
+}
+
+// source returns where pattern comes from: the policy file if it lists it, or else the
+// command line.
+func (s *FilterServer) source(policyPatterns []string, pattern string) string {
+	if slices.Contains(policyPatterns, pattern) {
+		return "policy " + s.policyPath
+	}
+	return SourceCommandLine
+}
+
+// place names where the patterns of source are written, for hints: the policy file, or flag on
+// the command line.
+func place(source, flag string) string {
+	if source == SourceCommandLine {
+		return flag
+	}
+	return "the " + source
+}
+
+func (s *FilterServer) policyAllow(entityType string) []string {
+	if s.policy == nil {
+		return nil
+	}
+	return s.policy.Allow[entityType]
+}
+
+func (s *FilterServer) policyDeny(entityType string) []string {
+	if s.policy == nil {
+		return nil
+	}
+	return s.policy.Deny[entityType]
+}
@@ -0,0 +1,172 @@
+package guard
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/admin"
+	"github.com/f/mcptools/pkg/consent"
+	"github.com/f/mcptools/pkg/keychain"
+)
+
+func TestExplain(t *testing.T) {
+	policy := &Policy{Deny: map[string][]string{"tool": {"*_secret"}}}
+	s := &FilterServer{
+		allowPatterns: map[string][]string{"tool": {"read_*", "list_*"}},
+		denyPatterns:  map[string][]string{"tool": {"*_secret", "read_env"}},
+	}
+	WithPolicy("/etc/mcp/policy.yaml", policy)(s)
+
+	tests := []struct {
+		name, reason, rule, source, hint string
+	}{
+		{name: "read_file"},
+		{name: "read_secret", reason: ReasonDenied, rule: "deny tool:*_secret", source: "policy /etc/mcp/policy.yaml", hint: "the policy /etc/mcp/policy.yaml"},
+		{name: "read_env", reason: ReasonDenied, rule: "deny tool:read_env", source: SourceCommandLine, hint: "--deny"},
+		{name: "write_file", reason: ReasonNotAllowed, rule: "allow tool:read_*,list_*", source: SourceCommandLine, hint: "--allow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanation, blocked := s.Explain("tool", tt.name)
+			if blocked != (tt.reason != "") {
+				t.Fatalf("Explain(%s) blocked = %v", tt.name, blocked)
+			}
+			if !blocked {
+				return
+			}
+			if explanation.Reason != tt.reason || explanation.Rule != tt.rule || explanation.Source != tt.source ||
+				explanation.Name != tt.name || !strings.Contains(explanation.Hint, tt.hint) {
+				t.Errorf("Explain(%s) = %+v", tt.name, explanation)
+			}
+		})
+	}
+
+	if _, blocked := s.Explain("prompt", "anything"); blocked {
+		t.Error("expected prompts without patterns to be allowed")
+	}
+}
+
+func TestExplainOverrides(t *testing.T) {
+	s := &FilterServer{denyPatterns: map[string][]string{"tool": {"delete_*"}}, overrides: admin.NewOverrides()}
+	allow, _ := s.overrides.Add(admin.Override{Action: admin.ActionAllow, Entity: "tool", Pattern: "delete_tmp"})
+	deny, _ := s.overrides.Add(admin.Override{Action: admin.ActionDeny, Entity: "tool", Pattern: "read_*"})
+
+	if _, blocked := s.Explain("tool", "delete_tmp"); blocked {
+		t.Errorf("expected override #%d to allow delete_tmp", allow.ID)
+	}
+	explanation, blocked := s.Explain("tool", "read_file")
+	if !blocked || explanation.Reason != ReasonOverride || explanation.Rule != "deny tool:read_*" ||
+		!strings.Contains(explanation.Hint, "mcp admin revoke 2") {
+		t.Errorf("Explain(read_file) = %+v, %v; want blocked by override #%d", explanation, blocked, deny.ID)
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	original := askTerminal
+	defer func() { askTerminal = original }()
+
+	s := &FilterServer{}
+	WithPolicy("policy.yaml", &Policy{Confirm: map[string][]string{"tool": {"move_*"}}})(s)
+	s.logFile, _ = os.CreateTemp(t.TempDir(), "guard.log")
+	defer func() { _ = s.Close() }()
+
+	var questions []string
+	answer, answerErr := "y", error(nil)
+	askTerminal = func(question, _ string) (string, error) {
+		questions = append(questions, question)
+		return answer, answerErr
+	}
+
+	if _, ok := s.confirm("tool", "read_file", "call to tool read_file", nil); !ok || len(questions) != 0 {
+		t.Fatalf("expected tools without a confirm pattern to pass unasked, asked %q", questions)
+	}
+	params := map[string]interface{}{"arguments": map[string]interface{}{"to": "/tmp"}}
+	if _, ok := s.confirm("tool", "move_file", "call to tool move_file", params); !ok {
+		t.Error("expected a confirmed call to pass")
+	}
+	if want := `Guard: allow call to tool move_file with {"to":"/tmp"}?`; len(questions) != 1 || questions[0] != want {
+		t.Errorf("asked %q, want %q", questions, want)
+	}
+
+	answer = "n"
+	explanation, ok := s.confirm("tool", "move_file", "call to tool move_file", params)
+	if ok || explanation.Reason != ReasonUnconfirmed || explanation.Rule != "confirm tool:move_*" || explanation.Source != "policy policy.yaml" {
+		t.Errorf("confirm() = %+v, %v; want a declined call blocked", explanation, ok)
+	}
+
+	answerErr = errors.New("no terminal")
+	if explanation, ok = s.confirm("tool", "move_file", "call to tool move_file", params); ok || !strings.Contains(explanation.Hint, "terminal") {
+		t.Errorf("confirm() = %+v, %v; want a call blocked without a terminal", explanation, ok)
+	}
+}
+
+// memoryKeychain keeps secrets in a map.
+type memoryKeychain map[string]string
+
+func (m memoryKeychain) Get(name string) (string, error) {
+	secret, ok := m[name]
+	if !ok {
+		return "", keychain.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (m memoryKeychain) Set(name, secret string) error {
+	m[name] = secret
+	return nil
+}
+
+func (m memoryKeychain) Delete(name string) error {
+	delete(m, name)
+	return nil
+}
+
+func (m memoryKeychain) Backend() string { return "memory" }
+
+func TestConfirmRemembersConsents(t *testing.T) {
+	original := askTerminal
+	defer func() { askTerminal = original }()
+
+	store := consent.New(memoryKeychain{})
+	s := &FilterServer{}
+	WithPolicy("policy.yaml", &Policy{Confirm: map[string][]string{"tool": {"*"}}})(s)
+	WithConsents(store, "fs")(s)
+	s.logFile, _ = os.CreateTemp(t.TempDir(), "guard.log")
+	defer func() { _ = s.Close() }()
+
+	asked := 0
+	answer := "always"
+	askTerminal = func(_, choices string) (string, error) {
+		asked++
+		if choices != "[y/N/always/never]" {
+			t.Errorf("choices = %q, want always and never offered", choices)
+		}
+		return answer, nil
+	}
+
+	for range 2 {
+		if _, ok := s.confirm("tool", "read_file", "call to tool read_file", nil); !ok {
+			t.Error("expected calls to an always allowed tool to pass")
+		}
+	}
+	answer = "never"
+	for range 2 {
+		if explanation, ok := s.confirm("tool", "exec", "call to tool exec", nil); ok || explanation.Reason != ReasonRefused {
+			t.Errorf("confirm(exec) = %+v, %v; want a refused call blocked", explanation, ok)
+		}
+	}
+	if asked != 2 {
+		t.Errorf("asked %d times, want once per tool", asked)
+	}
+
+	// Refusals apply to tools without a confirm pattern, for this server or any
+	if err := store.Remember(consent.Consent{Server: consent.AnyServer, Entity: "prompt", Name: "leak", Decision: consent.Deny}); err != nil {
+		t.Fatal(err)
+	}
+	s.confirmPatterns = nil
+	if _, ok := s.confirm("prompt", "leak", "get of prompt leak", nil); ok {
+		t.Error("expected a prompt refused on every server to be blocked")
+	}
+}
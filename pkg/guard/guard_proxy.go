@@ -9,14 +9,54 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/f/mcptools/pkg/admin"
+	"github.com/f/mcptools/pkg/consent"
+	"github.com/f/mcptools/pkg/protocol"
+	"github.com/f/mcptools/pkg/stdio"
 )
 
 // FilterServer handles proxying requests and filtering tools, prompts, and resources.
 type FilterServer struct {
-	allowPatterns map[string][]string
-	denyPatterns  map[string][]string
-	logFile       *os.File
-	requestID     int
+	allowPatterns   map[string][]string
+	denyPatterns    map[string][]string
+	confirmPatterns map[string][]string
+	deprecatedTools map[string]bool
+	overrides       *admin.Overrides
+	consents        *consent.Store
+	policy          *Policy
+	logFile         *os.File
+	adminSocket     string
+	policyPath      string
+	consentServer   string
+	requestID       json.RawMessage
+	blockDeprecated bool
+	adminDebug      bool
+}
+
+// Option configures a FilterServer.
+type Option func(*FilterServer)
+
+// WithBlockDeprecated hides tools the server marks as deprecated and blocks calls to them.
+func WithBlockDeprecated() Option {
+	return func(s *FilterServer) {
+		s.blockDeprecated = true
+	}
+}
+
+// WithAdminSocket serves the admin API on the Unix socket at path, so allow and deny rules can
+// be overridden while the guard runs.
+func WithAdminSocket(path string) Option {
+	return func(s *FilterServer) {
+		s.adminSocket = path
+	}
+}
+
+// WithAdminDebug also serves pprof profiles and Go runtime metrics on the admin socket.
+func WithAdminDebug() Option {
+	return func(s *FilterServer) {
+		s.adminDebug = true
+	}
 }
 
 // NewFilterServer creates a new filter server.
@@ -56,7 +96,6 @@ func NewFilterServer(allowPatterns, denyPatterns map[string][]string) (*FilterSe
 	return &FilterServer{
 		allowPatterns: allowPatterns,
 		denyPatterns:  denyPatterns,
-		requestID:     0,
 		logFile:       logFile,
 	}, nil
 }
@@ -85,30 +124,16 @@ func (s *FilterServer) Close() error {
 	return nil
 }
 
-// IsAllowed determines if a name is allowed based on the configured patterns.
+// IsAllowed determines if a name is allowed based on the configured patterns. Overrides made
+// through the admin API take precedence.
 func (s *FilterServer) IsAllowed(entityType, name string) bool {
-	// Default: allow if no allow patterns
-	allowed := len(s.allowPatterns[entityType]) == 0
-
-	// If allow patterns exist, check if name matches any
-	for _, pattern := range s.allowPatterns[entityType] {
-		match, _ := filepath.Match(pattern, name)
-		if match {
-			allowed = true
-			break
-		}
-	}
-
-	// Even if allowed, check if name is denied
-	for _, pattern := range s.denyPatterns[entityType] {
-		match, _ := filepath.Match(pattern, name)
-		if match {
-			allowed = false
-			break
+	if s.overrides != nil {
+		if allowed, matched := s.overrides.Decide(entityType, name); matched {
+			return allowed
 		}
 	}
 
-	return allowed
+	return isAllowed(s.allowPatterns, s.denyPatterns, entityType, name)
 }
 
 // filterResponse filters the response based on the allow and deny patterns.
@@ -151,6 +176,12 @@ func (s *FilterServer) filterToolsResponse(resp map[string]interface{}) map[stri
 			continue
 		}
 
+		if _, deprecated := protocol.Deprecation(toolMap); deprecated && s.blockDeprecated {
+			s.deprecatedTools[name] = true
+			s.log(fmt.Sprintf("Filtered deprecated tool: %s", name))
+			continue
+		}
+
 		if s.IsAllowed("tool", name) {
 			filteredTools = append(filteredTools, tool)
 		} else {
@@ -262,8 +293,9 @@ func (s *FilterServer) Start(cmdArgs []string) error {
 	// Encoder for client responses (stdout)
 	clientEncoder := json.NewEncoder(os.Stdout)
 
-	// Decoder for child process responses (childCmd.Stdout)
-	childDecoder := json.NewDecoder(childCmd.Stdout)
+	// Decoder for child process responses (childCmd.Stdout), tolerating byte order marks
+	// emitted by some Windows servers
+	childDecoder := json.NewDecoder(stdio.NewReader(childCmd.Stdout))
 
 	s.log("Guard proxy started, waiting for requests...")
 	fmt.Fprintf(os.Stderr, "Guard proxy started, waiting for requests...\n")
@@ -281,7 +313,7 @@ func (s *FilterServer) Start(cmdArgs []string) error {
 			Method  string                 `json:"method"`           // string (16 bytes: pointer + len)
 			Params  map[string]interface{} `json:"params,omitempty"` // map (8 bytes)
 			JSONRPC string                 `json:"jsonrpc"`          // string (16 bytes: pointer + len)
-			ID      int                    `json:"id"`               // int (8 bytes)
+			ID      json.RawMessage        `json:"id,omitempty"`     // []byte (24 bytes)
 		}
 
 		fmt.Fprintf(os.Stderr, "Waiting for request...\n")
@@ -297,7 +329,7 @@ func (s *FilterServer) Start(cmdArgs []string) error {
 
 		// Log the incoming request
 		s.logJSON("Received request", request)
-		fmt.Fprintf(os.Stderr, "Received request: %s (ID: %d)\n", request.Method, request.ID)
+		fmt.Fprintf(os.Stderr, "Received request: %s (ID: %s)\n", request.Method, string(request.ID))
 		s.requestID = request.ID
 
 		// Handle notifications (methods without an ID)
@@ -310,9 +342,26 @@ func (s *FilterServer) Start(cmdArgs []string) error {
 		// Filter tool calls if necessary
 		if request.Method == "tools/call" {
 			if name, ok := request.Params["name"].(string); ok {
-				if !s.IsAllowed("tool", name) {
-					s.log(fmt.Sprintf("Blocked call to filtered tool: %s", name))
-					s.writeError(fmt.Errorf("tool not found: %s", name))
+				if explanation, blocked := s.Explain("tool", name); blocked {
+					s.writeBlocked(fmt.Sprintf("call to tool %s", name), explanation)
+					continue
+				}
+
+				// Clients may call tools without listing them first, so look up which tools
+				// are deprecated before the first call
+				if s.blockDeprecated && s.deprecatedTools == nil {
+					if err := s.loadDeprecatedTools(childCmd.Stdin, childDecoder); err != nil {
+						s.log(fmt.Sprintf("Error listing tools: %v", err))
+						s.writeError(fmt.Errorf("error listing tools: %w", err))
+						continue
+					}
+				}
+				if s.deprecatedTools[name] {
+					s.writeBlocked(fmt.Sprintf("call to tool %s", name), explainDeprecated(name))
+					continue
+				}
+				if explanation, confirmed := s.confirm("tool", name, fmt.Sprintf("call to tool %s", name), request.Params); !confirmed {
+					s.writeBlocked(fmt.Sprintf("call to tool %s", name), explanation)
 					continue
 				}
 			}
@@ -321,17 +370,14 @@ func (s *FilterServer) Start(cmdArgs []string) error {
 		// Filter resource read requests
 		if request.Method == "resources/read" {
 			if uri, ok := request.Params["uri"].(string); ok {
-				// Extract resource name from URI (everything after the last slash or colon)
-				var name string
-				if idx := strings.LastIndexAny(uri, ":/"); idx != -1 && idx < len(uri)-1 {
-					name = uri[idx+1:]
-				} else {
-					name = uri
-				}
+				name := ResourceName(uri)
 
-				if !s.IsAllowed("resource", name) {
-					s.log(fmt.Sprintf("Blocked read of filtered resource: %s", name))
-					s.writeError(fmt.Errorf("resource not found: %s", uri))
+				if explanation, blocked := s.Explain("resource", name); blocked {
+					s.writeBlocked(fmt.Sprintf("read of resource %s", uri), explanation)
+					continue
+				}
+				if explanation, confirmed := s.confirm("resource", name, fmt.Sprintf("read of resource %s", uri), request.Params); !confirmed {
+					s.writeBlocked(fmt.Sprintf("read of resource %s", uri), explanation)
 					continue
 				}
 			}
@@ -340,9 +386,12 @@ func (s *FilterServer) Start(cmdArgs []string) error {
 		// Filter prompt get requests
 		if request.Method == "prompts/get" {
 			if name, ok := request.Params["name"].(string); ok {
-				if !s.IsAllowed("prompt", name) {
-					s.log(fmt.Sprintf("Blocked get of filtered prompt: %s", name))
-					s.writeError(fmt.Errorf("prompt not found: %s", name))
+				if explanation, blocked := s.Explain("prompt", name); blocked {
+					s.writeBlocked(fmt.Sprintf("get of prompt %s", name), explanation)
+					continue
+				}
+				if explanation, confirmed := s.confirm("prompt", name, fmt.Sprintf("get of prompt %s", name), request.Params); !confirmed {
+					s.writeBlocked(fmt.Sprintf("get of prompt %s", name), explanation)
 					continue
 				}
 			}
@@ -370,6 +419,9 @@ func (s *FilterServer) Start(cmdArgs []string) error {
 		// Apply filtering based on the request method
 		switch request.Method {
 		case "tools/list":
+			if s.deprecatedTools == nil {
+				s.deprecatedTools = make(map[string]bool)
+			}
 			response = s.filterResponse("tool", response)
 		case "prompts/list":
 			response = s.filterResponse("prompt", response)
@@ -386,21 +438,72 @@ func (s *FilterServer) Start(cmdArgs []string) error {
 	}
 }
 
+// loadDeprecatedTools lists the tools of the child process and records which are deprecated.
+func (s *FilterServer) loadDeprecatedTools(childStdin io.Writer, childDecoder *json.Decoder) error {
+	s.deprecatedTools = make(map[string]bool)
+	cursor := ""
+
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		request := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      "mcpt-guard-tools",
+			"method":  "tools/list",
+			"params":  params,
+		}
+		if err := json.NewEncoder(childStdin).Encode(request); err != nil {
+			return err
+		}
+
+		var response map[string]interface{}
+		if err := childDecoder.Decode(&response); err != nil {
+			return err
+		}
+		s.filterToolsResponse(response)
+
+		result, _ := response["result"].(map[string]interface{})
+		next, _ := result["nextCursor"].(string)
+		if next == "" || next == cursor {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// writeBlocked logs why a request was blocked and returns the explanation to the client as the
+// data of a JSON-RPC error.
+func (s *FilterServer) writeBlocked(request string, explanation Explanation) {
+	s.log(fmt.Sprintf("Blocked %s: %s", request, explanation))
+	s.writeErrorData(fmt.Errorf("%s blocked by the guard: %s", request, explanation.Rule), explanation)
+}
+
 // writeError writes a JSON-RPC error response to stdout.
 func (s *FilterServer) writeError(err error) {
+	s.writeErrorData(err, nil)
+}
+
+// writeErrorData writes a JSON-RPC error response with data, if it is not nil, to stdout.
+func (s *FilterServer) writeErrorData(err error, data any) {
 	// Use method not found error code for unsupported methods
 	code := -32000 // Default server error
 	if err.Error() == "method not found" {
 		code = -32601 // Method not found error code
 	}
 
+	rpcError := map[string]interface{}{
+		"code":    code,
+		"message": err.Error(),
+	}
+	if data != nil {
+		rpcError["data"] = data
+	}
 	response := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      s.requestID,
-		"error": map[string]interface{}{
-			"code":    code,
-			"message": err.Error(),
-		},
+		"error":   rpcError,
 	}
 
 	// Log the outgoing error response
@@ -414,11 +517,14 @@ func (s *FilterServer) writeError(err error) {
 }
 
 // RunFilterServer creates and runs a filter server with the specified patterns and command.
-func RunFilterServer(allowPatterns, denyPatterns map[string][]string, cmdArgs []string) error {
+func RunFilterServer(allowPatterns, denyPatterns map[string][]string, cmdArgs []string, opts ...Option) error {
 	server, err := NewFilterServer(allowPatterns, denyPatterns)
 	if err != nil {
 		return fmt.Errorf("error creating server: %w", err)
 	}
+	for _, opt := range opts {
+		opt(server)
+	}
 
 	// Print filtering patterns
 	fmt.Fprintln(os.Stderr, "Guard proxy with filtering:")
@@ -433,6 +539,20 @@ func RunFilterServer(allowPatterns, denyPatterns map[string][]string, cmdArgs []
 		}
 	}
 
+	if server.adminSocket != "" {
+		server.overrides = admin.NewOverrides()
+		var adminOpts []admin.ListenOption
+		if server.adminDebug {
+			adminOpts = append(adminOpts, admin.WithDebug())
+		}
+		adminServer, err := admin.Listen(server.adminSocket, server.overrides, server.log, adminOpts...)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = adminServer.Close() }()
+		fmt.Fprintf(os.Stderr, "Admin API listening on %s\n", server.adminSocket)
+	}
+
 	server.log(fmt.Sprintf("Starting guard proxy for command: %s", strings.Join(cmdArgs, " ")))
 	return server.Start(cmdArgs)
 }
@@ -0,0 +1,121 @@
+package guard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy holds the allow and deny patterns of a guard, by entity type: tool, prompt or resource.
+// Requests for entities matching a confirm pattern are only let through once confirmed on the
+// terminal running the guard. Written as YAML, the entity types may also be plural:
+//
+//	allow:
+//	  tools: ["read_*", "list_*", "move_file"]
+//	confirm:
+//	  tools: ["move_file"]
+//	deny:
+//	  tools: ["*_secret"]
+//	  resources: ["*.env"]
+type Policy struct {
+	Allow   map[string][]string `yaml:"allow,omitempty"`
+	Confirm map[string][]string `yaml:"confirm,omitempty"`
+	Deny    map[string][]string `yaml:"deny,omitempty"`
+}
+
+// LoadPolicy reads a policy from a YAML file.
+func LoadPolicy(path string) (*Policy, error) {
+	// #nosec G304 - the policy path is provided explicitly by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+
+	if policy.Allow, err = normalizePatterns(policy.Allow); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	if policy.Deny, err = normalizePatterns(policy.Deny); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	if policy.Confirm, err = normalizePatterns(policy.Confirm); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// normalizePatterns keys patterns by singular entity type and checks that they are valid.
+func normalizePatterns(patterns map[string][]string) (map[string][]string, error) {
+	normalized := map[string][]string{}
+	for key, list := range patterns {
+		var entityType string
+		switch strings.ToLower(key) {
+		case "tool", "tools":
+			entityType = "tool"
+		case "prompt", "prompts":
+			entityType = "prompt"
+		case "resource", "resources", "res":
+			entityType = "resource"
+		default:
+			return nil, fmt.Errorf("unknown entity type %q (use tools, prompts or resources)", key)
+		}
+		for _, pattern := range list {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+		normalized[entityType] = append(normalized[entityType], list...)
+	}
+	return normalized, nil
+}
+
+// IsAllowed reports whether the policy allows the entity called name.
+func (p *Policy) IsAllowed(entityType, name string) bool {
+	return isAllowed(p.Allow, p.Deny, entityType, name)
+}
+
+// isAllowed applies allow and deny patterns to name: without allow patterns for its type, every
+// name is allowed, and deny patterns win over allow patterns.
+func isAllowed(allowPatterns, denyPatterns map[string][]string, entityType, name string) bool {
+	// Default: allow if no allow patterns
+	allowed := len(allowPatterns[entityType]) == 0
+
+	// If allow patterns exist, check if name matches any
+	for _, pattern := range allowPatterns[entityType] {
+		match, _ := filepath.Match(pattern, name)
+		if match {
+			allowed = true
+			break
+		}
+	}
+
+	// Even if allowed, check if name is denied
+	for _, pattern := range denyPatterns[entityType] {
+		match, _ := filepath.Match(pattern, name)
+		if match {
+			allowed = false
+			break
+		}
+	}
+
+	return allowed
+}
+
+// ResourceName returns the name resource patterns are matched against for a resource URI:
+// everything after its last slash or colon.
+func ResourceName(uri string) string {
+	if idx := strings.LastIndexAny(uri, ":/"); idx != -1 && idx < len(uri)-1 {
+		return uri[idx+1:]
+	}
+	return uri
+}
@@ -0,0 +1,112 @@
+package guard
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/f/mcptools/pkg/record"
+)
+
+// Decision is a request or a listed entity of a recorded session that a policy rejects.
+type Decision struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Entity string    `json:"entity"`
+	Name   string    `json:"name"`
+}
+
+// Simulation reports what a policy would have done to a recorded session: the requests it would
+// have blocked and the entities it would have hidden from listings.
+type Simulation struct {
+	Requests int        `json:"requests"`
+	Checked  int        `json:"checked"`
+	Blocked  []Decision `json:"blocked"`
+	Hidden   []Decision `json:"hidden"`
+}
+
+// requestEntities maps the methods a guard filters to the entity type they act on and the
+// parameter naming it.
+var requestEntities = map[string][2]string{
+	"tools/call":     {"tool", "name"},
+	"prompts/get":    {"prompt", "name"},
+	"resources/read": {"resource", "uri"},
+}
+
+// listEntities maps the listing methods a guard filters to the entity type they list and the
+// field of the result holding the list.
+var listEntities = map[string][2]string{
+	"tools/list":     {"tool", "tools"},
+	"prompts/list":   {"prompt", "prompts"},
+	"resources/list": {"resource", "resources"},
+}
+
+// Simulate replays the entries of a recorded session against policy without contacting any
+// server. Requests are checked as the guard would check them, and listing results are checked
+// entity by entity; each hidden entity is reported once.
+func Simulate(policy *Policy, entries []record.Entry) Simulation {
+	simulation := Simulation{Blocked: []Decision{}, Hidden: []Decision{}}
+	pending := map[string]string{}
+	hidden := map[string]bool{}
+
+	for _, entry := range entries {
+		var message struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params map[string]any  `json:"params"`
+			Result map[string]any  `json:"result"`
+		}
+		if len(entry.Message) == 0 || json.Unmarshal(entry.Message, &message) != nil {
+			continue
+		}
+
+		switch entry.Direction {
+		case record.DirectionSent:
+			if message.Method == "" || len(message.ID) == 0 {
+				continue
+			}
+			simulation.Requests++
+			pending[string(message.ID)] = message.Method
+
+			entity, ok := requestEntities[message.Method]
+			if !ok {
+				continue
+			}
+			value, _ := message.Params[entity[1]].(string)
+			name := value
+			if entity[0] == "resource" {
+				name = ResourceName(value)
+			}
+			simulation.Checked++
+			if !policy.IsAllowed(entity[0], name) {
+				simulation.Blocked = append(simulation.Blocked, Decision{
+					Time: entry.Time, Method: message.Method, Entity: entity[0], Name: value,
+				})
+			}
+		case record.DirectionReceived:
+			method, ok := pending[string(message.ID)]
+			if !ok {
+				continue
+			}
+			delete(pending, string(message.ID))
+
+			entity, ok := listEntities[method]
+			if !ok {
+				continue
+			}
+			items, _ := message.Result[entity[1]].([]any)
+			for _, item := range items {
+				fields, _ := item.(map[string]any)
+				name, _ := fields["name"].(string)
+				if name == "" || hidden[entity[0]+"\x00"+name] || policy.IsAllowed(entity[0], name) {
+					continue
+				}
+				hidden[entity[0]+"\x00"+name] = true
+				simulation.Hidden = append(simulation.Hidden, Decision{
+					Time: entry.Time, Method: method, Entity: entity[0], Name: name,
+				})
+			}
+		}
+	}
+
+	return simulation
+}
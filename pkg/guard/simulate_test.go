@@ -0,0 +1,94 @@
+package guard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/record"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	content := "allow:\n  tools: [\"read_*\"]\nconfirm:\n  tools: [\"read_config\"]\ndeny:\n  tool: [\"read_secret\"]\n  resources: [\"*.env\"]\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Policy{
+		Allow:   map[string][]string{"tool": {"read_*"}},
+		Confirm: map[string][]string{"tool": {"read_config"}},
+		Deny:    map[string][]string{"tool": {"read_secret"}, "resource": {"*.env"}},
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("LoadPolicy() = %+v, want %+v", policy, want)
+	}
+
+	for name, allowed := range map[string]bool{"read_file": true, "read_secret": false, "write_file": false} {
+		if got := policy.IsAllowed("tool", name); got != allowed {
+			t.Errorf("IsAllowed(tool, %s) = %v, want %v", name, got, allowed)
+		}
+	}
+
+	for _, bad := range []string{"deny:\n  widgets: [x]\n", "allow:\n  tools: [\"[\"]\n", "block:\n  tools: [x]\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPolicy(path); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func entry(t *testing.T, direction, message string) record.Entry {
+	t.Helper()
+	if !json.Valid([]byte(message)) {
+		t.Fatalf("invalid message %s", message)
+	}
+	return record.Entry{Direction: direction, Message: json.RawMessage(message)}
+}
+
+func TestSimulate(t *testing.T) {
+	entries := []record.Entry{
+		{Direction: record.DirectionStart, Server: []string{"server"}},
+		entry(t, record.DirectionSent, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`),
+		entry(t, record.DirectionReceived, `{"jsonrpc":"2.0","id":1,"result":{}}`),
+		entry(t, record.DirectionSent, `{"jsonrpc":"2.0","method":"notifications/initialized"}`),
+		entry(t, record.DirectionSent, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`),
+		entry(t, record.DirectionReceived, `{"jsonrpc":"2.0","id":2,"result":{"tools":[{"name":"read_file"},{"name":"delete_file"}]}}`),
+		entry(t, record.DirectionSent, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"delete_file"}}`),
+		entry(t, record.DirectionSent, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"read_file"}}`),
+		entry(t, record.DirectionSent, `{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"file:///app/.env"}}`),
+		entry(t, record.DirectionSent, `{"jsonrpc":"2.0","id":6,"method":"tools/list"}`),
+		entry(t, record.DirectionReceived, `{"jsonrpc":"2.0","id":6,"result":{"tools":[{"name":"delete_file"}]}}`),
+	}
+	policy := &Policy{
+		Allow: map[string][]string{"tool": {"read_*"}},
+		Deny:  map[string][]string{"resource": {"*env"}},
+	}
+
+	simulation := Simulate(policy, entries)
+	if simulation.Requests != 6 || simulation.Checked != 3 {
+		t.Errorf("expected 6 requests and 3 checked, got %d and %d", simulation.Requests, simulation.Checked)
+	}
+
+	var blocked []string
+	for _, d := range simulation.Blocked {
+		blocked = append(blocked, d.Method+" "+d.Name)
+	}
+	if want := "tools/call delete_file,resources/read file:///app/.env"; strings.Join(blocked, ",") != want {
+		t.Errorf("blocked %v, want %s", blocked, want)
+	}
+
+	if len(simulation.Hidden) != 1 || simulation.Hidden[0].Name != "delete_file" {
+		t.Errorf("expected delete_file to be hidden once, got %+v", simulation.Hidden)
+	}
+}
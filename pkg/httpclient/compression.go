@@ -0,0 +1,162 @@
+// Package httpclient builds the HTTP clients used by the streamable HTTP and SSE transports.
+package httpclient
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// supported content encodings.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
+)
+
+// DefaultEncodings is the Accept-Encoding value advertised when compression is enabled.
+const DefaultEncodings = EncodingGzip + ", " + EncodingDeflate + ", " + EncodingZstd
+
+// ParseEncodings validates a comma-separated list of content encodings. It returns an empty
+// string when compression is disabled with "none".
+func ParseEncodings(option string) (string, error) {
+	if option == "" {
+		return DefaultEncodings, nil
+	}
+	if option == "none" {
+		return "", nil
+	}
+
+	encodings := strings.Split(option, ",")
+	for i, encoding := range encodings {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		switch encoding {
+		case EncodingGzip, EncodingDeflate, EncodingZstd:
+			encodings[i] = encoding
+		default:
+			return "", fmt.Errorf("unsupported compression: %s (supported: gzip, deflate, zstd, none)", encoding)
+		}
+	}
+
+	return strings.Join(encodings, ", "), nil
+}
+
+// CompressionTransport is an http.RoundTripper that negotiates compressed responses via
+// Accept-Encoding and transparently decodes them.
+type CompressionTransport struct {
+	Base      http.RoundTripper
+	Encodings string
+}
+
+// NewCompressionTransport wraps base so that responses are requested with the given encodings.
+func NewCompressionTransport(base http.RoundTripper, encodings string) *CompressionTransport {
+	return &CompressionTransport{
+		Base:      base,
+		Encodings: encodings,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CompressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Encodings != "" && req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", t.Encodings)
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return resp, nil
+	}
+
+	body, err := decodeBody(encoding, resp.Body)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// decodeBody wraps body in a decompressor for the given content encoding.
+func decodeBody(encoding string, body io.ReadCloser) (io.ReadCloser, error) {
+	switch encoding {
+	case EncodingGzip, "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip response: %w", err)
+		}
+		return &decodedBody{Reader: reader, closers: []io.Closer{reader, body}}, nil
+	case EncodingDeflate:
+		// HTTP deflate is zlib-wrapped, but some servers send raw DEFLATE data
+		buffered := bufio.NewReader(body)
+		var reader io.ReadCloser
+		if header, _ := buffered.Peek(2); isZlibHeader(header) {
+			var err error
+			if reader, err = zlib.NewReader(buffered); err != nil {
+				return nil, fmt.Errorf("failed to decode deflate response: %w", err)
+			}
+		} else {
+			reader = flate.NewReader(buffered)
+		}
+		return &decodedBody{Reader: reader, closers: []io.Closer{reader, body}}, nil
+	case EncodingZstd:
+		decoder, err := zstd.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode zstd response: %w", err)
+		}
+		return &decodedBody{Reader: decoder, closers: []io.Closer{zstdCloser{decoder}, body}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+}
+
+// isZlibHeader reports whether header starts a zlib stream (RFC 1950): the DEFLATE method, and
+// a check that makes the first two bytes a multiple of 31.
+func isZlibHeader(header []byte) bool {
+	return len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
+
+// decodedBody reads decompressed data and closes both the decompressor and the original body.
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close implements io.Closer.
+func (b *decodedBody) Close() error {
+	var firstErr error
+	for _, c := range b.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// zstdCloser adapts zstd.Decoder, whose Close returns nothing, to io.Closer.
+type zstdCloser struct {
+	decoder *zstd.Decoder
+}
+
+// Close implements io.Closer.
+func (z zstdCloser) Close() error {
+	z.decoder.Close()
+	return nil
+}
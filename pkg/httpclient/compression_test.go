@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch encoding {
+	case EncodingGzip:
+		w = gzip.NewWriter(&buf)
+	case EncodingDeflate:
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+	case EncodingZstd:
+		w, err = zstd.NewWriter(&buf)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressionTransport(t *testing.T) {
+	payload := []byte(`{"jsonrpc":"2.0","id":1,"result":{"contents":[{"text":"` +
+		string(bytes.Repeat([]byte("a"), 4096)) + `"}]}}`)
+
+	// Servers that send raw DEFLATE data as deflate are still understood
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate, "raw-deflate", EncodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			var accepted string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accepted = r.Header.Get("Accept-Encoding")
+				w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+				_, _ = w.Write(compress(t, encoding, payload))
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: NewCompressionTransport(http.DefaultTransport, DefaultEncodings)}
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if accepted != DefaultEncodings {
+				t.Errorf("Accept-Encoding = %q, want %q", accepted, DefaultEncodings)
+			}
+			if !bytes.Equal(body, payload) {
+				t.Errorf("decoded body does not match the original payload")
+			}
+			if resp.Header.Get("Content-Encoding") != "" {
+				t.Errorf("Content-Encoding header was not removed")
+			}
+		})
+	}
+}
+
+func TestParseEncodings(t *testing.T) {
+	tests := []struct {
+		option  string
+		want    string
+		wantErr bool
+	}{
+		{option: "", want: DefaultEncodings},
+		{option: "none", want: ""},
+		{option: "zstd, GZIP", want: "zstd, gzip"},
+		{option: "br", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEncodings(tt.option)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEncodings(%q) error = %v, wantErr %v", tt.option, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseEncodings(%q) = %q, want %q", tt.option, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// FallbackDelay is how long connections to the preferred address family get before the other
+// family is tried in parallel, as recommended by RFC 8305.
+const FallbackDelay = 300 * time.Millisecond
+
+// Preference selects the address family dialed first.
+type Preference int
+
+// address family preferences.
+const (
+	// PreferAny tries the family of the first address the resolver returns first.
+	PreferAny Preference = iota
+	PreferIPv4
+	PreferIPv6
+)
+
+// Dialer connects to hosts with both IPv4 and IPv6 addresses happy-eyeballs style: the
+// addresses of the preferred family are tried one after another, and the other family is
+// tried in parallel once FallbackDelay has passed or the preferred family has failed. The first
+// connection made wins.
+type Dialer struct {
+	lookup     func(ctx context.Context, host string) ([]net.IPAddr, error)
+	dialer     net.Dialer
+	preference Preference
+}
+
+// NewDialer creates a dialer that prefers the given address family.
+func NewDialer(preference Preference) *Dialer {
+	return &Dialer{
+		lookup:     net.DefaultResolver.LookupIPAddr,
+		dialer:     net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		preference: preference,
+	}
+}
+
+// NewTransport returns a copy of http.DefaultTransport that dials with a Dialer preferring the
+// given address family.
+func NewTransport(preference Preference) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = NewDialer(preference).DialContext
+	return t
+}
+
+// DialContext connects to address on the named network.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	primaries, fallbacks := d.partition(addrs)
+	if len(primaries) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+
+	type result struct {
+		conn    net.Conn
+		err     error
+		primary bool
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result, 2)
+	race := func(addrs []net.IPAddr, primary bool) {
+		conn, dialErr := d.dialSerial(ctx, network, addrs, port)
+		results <- result{conn: conn, err: dialErr, primary: primary}
+	}
+	go race(primaries, true)
+
+	var fallbackTimer <-chan time.Time
+	if len(fallbacks) > 0 {
+		timer := time.NewTimer(FallbackDelay)
+		defer timer.Stop()
+		fallbackTimer = timer.C
+	}
+
+	pending, fallbackStarted := 1, false
+	startFallback := func() {
+		if !fallbackStarted && len(fallbacks) > 0 {
+			fallbackStarted = true
+			pending++
+			go race(fallbacks, false)
+		}
+	}
+
+	var firstErr error
+	for pending > 0 {
+		select {
+		case <-fallbackTimer:
+			startFallback()
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				// The losing attempt, if any, is cancelled and its connection closed
+				if pending > 0 {
+					go func() {
+						if late := <-results; late.conn != nil {
+							_ = late.conn.Close()
+						}
+					}()
+				}
+				return res.conn, nil
+			}
+			if firstErr == nil || res.primary {
+				firstErr = res.err
+			}
+			startFallback()
+		}
+	}
+	return nil, firstErr
+}
+
+// partition splits addrs into the preferred family and the other one.
+func (d *Dialer) partition(addrs []net.IPAddr) (primaries, fallbacks []net.IPAddr) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	var preferIPv4 bool
+	switch d.preference {
+	case PreferIPv4:
+		preferIPv4 = true
+	case PreferIPv6:
+		preferIPv4 = false
+	default:
+		preferIPv4 = addrs[0].IP.To4() != nil
+	}
+
+	for _, addr := range addrs {
+		if (addr.IP.To4() != nil) == preferIPv4 {
+			primaries = append(primaries, addr)
+		} else {
+			fallbacks = append(fallbacks, addr)
+		}
+	}
+	if len(primaries) == 0 {
+		return fallbacks, nil
+	}
+	return primaries, fallbacks
+}
+
+// dialSerial tries addrs in order and returns the first connection made.
+func (d *Dialer) dialSerial(ctx context.Context, network string, addrs []net.IPAddr, port string) (net.Conn, error) {
+	var firstErr error
+	for _, addr := range addrs {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+// NormalizeURL checks a server URL and adds the http:// scheme to the shorthand forms
+// localhost:port and [IPv6]:port. IPv6 addresses must be enclosed in brackets, with zones
+// escaped as %25, e.g. http://[fe80::1%25eth0]:8080/mcp.
+func NormalizeURL(raw string) (string, error) {
+	if strings.HasPrefix(raw, "localhost:") || strings.HasPrefix(raw, "[") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid URL %s: missing host", raw)
+	}
+	// Unbracketed IPv6 addresses parse, but with part of the address taken for the port
+	if strings.Count(u.Host, ":") >= 2 && !strings.HasPrefix(u.Host, "[") {
+		return "", fmt.Errorf("invalid URL %s: IPv6 addresses must be enclosed in brackets, e.g. http://[%s]/mcp", raw, u.Host)
+	}
+	return u.String(), nil
+}
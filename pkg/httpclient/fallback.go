@@ -0,0 +1,36 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// Fallback is a transport that connects with a primary transport and switches to a fallback
+// one if the primary cannot connect, e.g. because a proxy blocks server-sent events.
+type Fallback struct {
+	transport.Interface
+	fallback transport.Interface
+	warnings io.Writer
+}
+
+// NewFallback creates a transport that uses primary, or fallback if primary fails to start.
+// Switching is reported on warnings.
+func NewFallback(primary, fallback transport.Interface, warnings io.Writer) *Fallback {
+	return &Fallback{Interface: primary, fallback: fallback, warnings: warnings}
+}
+
+// Start implements transport.Interface.
+func (f *Fallback) Start(ctx context.Context) error {
+	err := f.Interface.Start(ctx)
+	if err == nil {
+		return nil
+	}
+
+	_ = f.Interface.Close()
+	fmt.Fprintf(f.warnings, "Warning: %v; falling back to long-polling\n", err)
+	f.Interface = f.fallback
+	return f.Interface.Start(ctx)
+}
@@ -0,0 +1,323 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// sessionHeader carries the session ID assigned by the server on initialize.
+const sessionHeader = "Mcp-Session-Id"
+
+// pollRetryDelay is how long to wait before polling again after a poll failed, and
+// pollInterval the shortest time between polls, for servers that answer them right away.
+const (
+	pollRetryDelay = 2 * time.Second
+	pollInterval   = time.Second
+)
+
+// LongPoll is a transport for networks whose proxies block or buffer server-sent events.
+// Requests are posted as on the streamable HTTP transport, and responses are read in full
+// whether they come as JSON or as an event stream. Server notifications are fetched with GET
+// requests that the server holds open until it has notifications to return, as a JSON array.
+type LongPoll struct {
+	client    *http.Client
+	headers   map[string]string
+	handler   func(mcp.JSONRPCNotification)
+	clock     clock.Clock
+	stop      context.CancelFunc
+	url       string
+	sessionID string
+	polling   bool
+	mu        sync.Mutex
+}
+
+// NewLongPoll creates a long-polling transport for the MCP endpoint at url.
+func NewLongPoll(url string, headers map[string]string, client *http.Client) *LongPoll {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &LongPoll{url: url, headers: headers, client: client, clock: clock.Real}
+}
+
+// LongPollURL returns the endpoint to long-poll for a server reached over SSE at sseURL. Servers
+// usually serve both transports side by side, so a trailing /sse is replaced by /mcp.
+func LongPollURL(sseURL string) string {
+	u, err := url.Parse(sseURL)
+	if err != nil || !strings.HasSuffix(u.Path, "/sse") {
+		return sseURL
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/sse") + "/mcp"
+	return u.String()
+}
+
+// Start implements transport.Interface. Polling starts once the session is initialized.
+func (t *LongPoll) Start(_ context.Context) error {
+	return nil
+}
+
+// SendRequest implements transport.Interface.
+func (t *LongPoll) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := t.do(ctx, http.MethodPost, body, "application/json, text/event-stream")
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var response transport.JSONRPCResponse
+		if json.Unmarshal(data, &response) == nil && response.Error != nil {
+			return &response, nil
+		}
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+
+	if request.Method == string(mcp.MethodInitialize) {
+		t.mu.Lock()
+		t.sessionID = resp.Header.Get(sessionHeader)
+		t.mu.Unlock()
+		t.startPolling()
+	}
+
+	var messages []json.RawMessage
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+		_ = readEvents(bytes.NewReader(data), func(event []byte) {
+			messages = append(messages, event)
+		})
+	} else {
+		messages = append(messages, data)
+	}
+
+	var response *transport.JSONRPCResponse
+	for _, msg := range messages {
+		if t.dispatch(msg) {
+			continue
+		}
+		var candidate transport.JSONRPCResponse
+		if json.Unmarshal(msg, &candidate) == nil && candidate.ID.String() == request.ID.String() {
+			response = &candidate
+		}
+	}
+	if response == nil {
+		return nil, fmt.Errorf("no response to request %v", request.ID.Value())
+	}
+	return response, nil
+}
+
+// SendNotification implements transport.Interface.
+func (t *LongPoll) SendNotification(ctx context.Context, notification mcp.JSONRPCNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	resp, err := t.do(ctx, http.MethodPost, body, "application/json, text/event-stream")
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("notification failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return nil
+}
+
+// SetNotificationHandler implements transport.Interface.
+func (t *LongPoll) SetNotificationHandler(handler func(mcp.JSONRPCNotification)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handler = handler
+}
+
+// GetSessionId implements transport.Interface.
+func (t *LongPoll) GetSessionId() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// Close stops polling and ends the session on the server.
+func (t *LongPoll) Close() error {
+	t.mu.Lock()
+	stop, sessionID := t.stop, t.sessionID
+	t.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+	if sessionID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if resp, err := t.do(ctx, http.MethodDelete, nil, ""); err == nil {
+			_ = resp.Body.Close()
+		}
+	}
+	return nil
+}
+
+// startPolling starts fetching notifications in the background, once per transport.
+func (t *LongPoll) startPolling() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.polling {
+		return
+	}
+	t.polling = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.stop = cancel
+	go t.poll(ctx)
+}
+
+// poll fetches notifications until ctx is cancelled. It gives up if the server answers with
+// anything but notifications, e.g. 405 from servers that cannot send any.
+func (t *LongPoll) poll(ctx context.Context) {
+	for {
+		next := t.clock.Now().Add(pollInterval)
+		resp, err := t.do(ctx, http.MethodGet, nil, "application/json")
+		if err != nil {
+			next = t.clock.Now().Add(pollRetryDelay)
+		} else {
+			ok := t.readPoll(resp)
+			_ = resp.Body.Close()
+			if !ok {
+				return
+			}
+		}
+
+		select {
+		case <-t.clock.After(clock.Until(t.clock, next)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// readPoll dispatches the notifications returned by a poll. Event streams are accepted too, so
+// servers that ignore the Accept header work once their stream gets through the proxy.
+func (t *LongPoll) readPoll(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return true
+	case http.StatusOK:
+	default:
+		return false
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+		_ = readEvents(resp.Body, func(event []byte) { t.dispatch(event) })
+		return true
+	}
+
+	var messages []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&messages); err != nil {
+		return false
+	}
+	for _, msg := range messages {
+		t.dispatch(msg)
+	}
+	return true
+}
+
+// dispatch passes msg to the notification handler if it is a notification, and reports whether
+// it was one.
+func (t *LongPoll) dispatch(msg json.RawMessage) bool {
+	var probe struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if json.Unmarshal(msg, &probe) != nil || probe.Method == "" || len(probe.ID) > 0 {
+		return false
+	}
+
+	var notification mcp.JSONRPCNotification
+	if json.Unmarshal(msg, &notification) != nil {
+		return true
+	}
+
+	t.mu.Lock()
+	handler := t.handler
+	t.mu.Unlock()
+	if handler != nil {
+		handler(notification)
+	}
+	return true
+}
+
+func (t *LongPoll) do(ctx context.Context, method string, body []byte, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	for k, v := range t.headers {
+		// The headers are shared with the other transports, which accept event streams
+		if !strings.EqualFold(k, "Accept") {
+			req.Header.Set(k, v)
+		}
+	}
+	if sessionID := t.GetSessionId(); sessionID != "" {
+		req.Header.Set(sessionHeader, sessionID)
+	}
+	return t.client.Do(req)
+}
+
+// readEvents calls fn with the data of every server-sent event read from r.
+func readEvents(r io.Reader, fn func(data []byte)) error {
+	reader := bufio.NewReader(r)
+	var data []byte
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		switch {
+		case len(line) == 0 && len(data) > 0:
+			fn(data)
+			data = nil
+		case bytes.HasPrefix(line, []byte("data:")):
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))...)
+		}
+		if err != nil {
+			if len(data) > 0 {
+				fn(data)
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestLongPollReadsBufferedEventStreams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		// A proxy that buffers event streams delivers the whole response at once
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{}}\n\n"+
+			"event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":7,\"result\":{\"ok\":true}}\n\n")
+	}))
+	defer server.Close()
+
+	client := NewLongPoll(server.URL, nil, nil)
+	var notifications []string
+	client.SetNotificationHandler(func(n mcp.JSONRPCNotification) { notifications = append(notifications, n.Method) })
+
+	response, err := client.SendRequest(context.Background(),
+		transport.JSONRPCRequest{JSONRPC: "2.0", ID: mcp.NewRequestId(int64(7)), Method: "tools/call"})
+	if err != nil {
+		t.Fatalf("SendRequest() error = %v", err)
+	}
+	if string(response.Result) != `{"ok":true}` {
+		t.Errorf("Result = %s", response.Result)
+	}
+	if len(notifications) != 1 || notifications[0] != "notifications/progress" {
+		t.Errorf("notifications = %v", notifications)
+	}
+}
+
+func TestLongPollURL(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/sse":        "https://example.com/mcp",
+		"https://example.com/v1/sse?x=1": "https://example.com/v1/mcp?x=1",
+		"https://example.com/events":     "https://example.com/events",
+		"https://example.com/mcp":        "https://example.com/mcp",
+	}
+	for in, want := range tests {
+		if got := LongPollURL(in); got != want {
+			t.Errorf("LongPollURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// failingTransport is a transport that cannot connect.
+type failingTransport struct {
+	transport.Interface
+	closed bool
+}
+
+func (f *failingTransport) Start(context.Context) error {
+	return errors.New("failed to connect to SSE stream")
+}
+
+func (f *failingTransport) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestFallbackSwitchesTransports(t *testing.T) {
+	primary := &failingTransport{}
+	fallback := NewLongPoll("http://example.com/mcp", nil, nil)
+	var warnings bytes.Buffer
+
+	f := NewFallback(primary, fallback, &warnings)
+	if err := f.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if f.Interface != fallback || !primary.closed {
+		t.Error("expected the fallback transport to replace the closed primary one")
+	}
+	if warnings.Len() == 0 {
+		t.Error("expected a warning about the fallback")
+	}
+}
@@ -0,0 +1,172 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+// DefaultMaxRetries is how many times a rate-limited request is retried by default.
+const DefaultMaxRetries = 5
+
+// MaxRetryDelay is the longest a rate-limited request waits before it is retried. Servers asking
+// for longer waits get their error passed on instead.
+const MaxRetryDelay = time.Minute
+
+// retryBaseDelay is the first backoff delay when the server does not say how long to wait.
+const retryBaseDelay = time.Second
+
+// rateLimitCodes are the JSON-RPC error codes taken to mean a request was rate limited: the HTTP
+// status reused as a code, and -32029 in the range reserved for implementation-defined errors.
+var rateLimitCodes = map[int]bool{429: true, -32029: true}
+
+// maxErrorBody bounds the JSON responses inspected for rate limit errors.
+const maxErrorBody = 64 << 10
+
+// RetryTransport is an http.RoundTripper that retries rate-limited requests: responses with
+// status 429, or 503 with a Retry-After header, and JSON-RPC errors with a rate limit code. It
+// waits as long as Retry-After (or the retryAfter field of the error's data) says, or backs off
+// exponentially, with jitter so clients limited together do not retry together.
+type RetryTransport struct {
+	Base     http.RoundTripper
+	warnings io.Writer
+	// Clock waits between attempts and reads Retry-After dates, and Rand draws the jitter. They
+	// default to the wall clock and a randomly seeded source.
+	Clock      clock.Clock
+	Rand       clock.Rand
+	MaxRetries int
+}
+
+// NewRetryTransport wraps base so rate-limited requests are retried up to maxRetries times.
+// Each wait is reported on warnings.
+func NewRetryTransport(base http.RoundTripper, maxRetries int, warnings io.Writer) *RetryTransport {
+	return &RetryTransport{Base: base, MaxRetries: maxRetries, warnings: warnings}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	now := clock.OrReal(t.Clock)
+	// Requests whose body cannot be read again are sent once
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.Base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		delay, limited := rateLimited(resp, now)
+		if !limited || !replayable {
+			return resp, nil
+		}
+		if attempt >= t.MaxRetries {
+			if t.MaxRetries > 0 {
+				fmt.Fprintf(t.warnings, "Warning: still rate limited by %s after %d retries; giving up\n", req.URL.Host, t.MaxRetries)
+			}
+			return resp, nil
+		}
+		if delay <= 0 {
+			delay = backoff(attempt)
+		}
+		if delay > MaxRetryDelay {
+			fmt.Fprintf(t.warnings, "Warning: rate limited by %s, which asks to retry in %s; giving up\n",
+				req.URL.Host, delay.Round(time.Second))
+			return resp, nil
+		}
+		delay = jitter(delay, clock.OrSystem(t.Rand))
+
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+		_ = resp.Body.Close()
+		fmt.Fprintf(t.warnings, "Rate limited by %s, retrying in %s (retry %d of %d)\n",
+			req.URL.Host, delay.Round(100*time.Millisecond), attempt+1, t.MaxRetries)
+		if err = clock.Sleep(req.Context(), now, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rateLimited reports whether a response says the request was rate limited, and how long the
+// server asks to wait, or 0 if it does not say. Retry-After dates are read on c.
+func rateLimited(resp *http.Response, c clock.Clock) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return retryAfter(resp.Header.Get("Retry-After"), c), true
+	case resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != "":
+		return retryAfter(resp.Header.Get("Retry-After"), c), true
+	case resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json"):
+		return 0, false
+	}
+
+	// The body is read to look for a JSON-RPC error and put back for the caller
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody+1))
+	resp.Body = readCloser{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	if err != nil || len(data) > maxErrorBody {
+		return 0, false
+	}
+	var msg struct {
+		Error *struct {
+			Data struct {
+				RetryAfter float64 `json:"retryAfter"`
+			} `json:"data"`
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &msg) != nil || msg.Error == nil || !rateLimitCodes[msg.Error.Code] {
+		return 0, false
+	}
+	if delay := retryAfter(resp.Header.Get("Retry-After"), c); delay > 0 {
+		return delay, true
+	}
+	return time.Duration(msg.Error.Data.RetryAfter * float64(time.Second)), true
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date. It returns 0 if the
+// header is missing or invalid. Dates are compared with the time on c.
+func retryAfter(header string, c clock.Clock) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(clock.Until(c, date), 0)
+	}
+	return 0
+}
+
+// backoff returns the delay before retry attempt+1 when the server does not say how long to
+// wait: one second, doubling each time, up to MaxRetryDelay.
+func backoff(attempt int) time.Duration {
+	if attempt >= 6 {
+		return MaxRetryDelay
+	}
+	return min(retryBaseDelay<<attempt, MaxRetryDelay)
+}
+
+// jitter adds up to a fifth of delay, drawn from r, so clients rate limited at the same time
+// spread out.
+func jitter(delay time.Duration, r clock.Rand) time.Duration {
+	return delay + time.Duration(r.Int64N(int64(delay/5)+1))
+}
+
+// readCloser reads from a reader and closes a closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,155 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+// delayClock records the waits of a RetryTransport and ends them right away.
+type delayClock struct {
+	*clock.Fake
+	delays []time.Duration
+}
+
+func (c *delayClock) After(d time.Duration) <-chan time.Time {
+	c.delays = append(c.delays, d)
+	return c.Fake.After(0)
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     func(w http.ResponseWriter)
+		wantDelay time.Duration
+	}{
+		{"429 with Retry-After", func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}, 3 * time.Second},
+		{"429 without Retry-After", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}, retryBaseDelay},
+		{"503 with Retry-After", func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, 2 * time.Second},
+		{"JSON-RPC rate limit error", func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32029,"message":"slow down","data":{"retryAfter":1.5}}}`)
+		}, 1500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if len(bodies) < 3 {
+					tt.limit(w)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{}}`)
+			}))
+			defer server.Close()
+
+			var warnings bytes.Buffer
+			waits := &delayClock{Fake: clock.NewFake(time.Now())}
+			rt := NewRetryTransport(http.DefaultTransport, DefaultMaxRetries, &warnings)
+			rt.Clock = waits
+
+			resp, err := (&http.Client{Transport: rt}).Post(server.URL, "application/json",
+				strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if !strings.Contains(string(body), `"result"`) {
+				t.Errorf("response = %s, want the result of the third attempt", body)
+			}
+			if len(bodies) != 3 || bodies[2] != bodies[0] {
+				t.Errorf("server got %q, want the same request 3 times", bodies)
+			}
+			if delays := waits.delays; len(delays) != 2 || delays[0] < tt.wantDelay || delays[0] > tt.wantDelay+tt.wantDelay/5 {
+				t.Errorf("delays = %v, want %s plus up to 20%% jitter first", delays, tt.wantDelay)
+			}
+			if !strings.Contains(warnings.String(), "Rate limited by "+server.Listener.Addr().String()+", retrying in") {
+				t.Errorf("warnings = %q", warnings.String())
+			}
+		})
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "7200")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var warnings bytes.Buffer
+	rt := NewRetryTransport(http.DefaultTransport, DefaultMaxRetries, &warnings)
+	resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || requests != 1 {
+		t.Errorf("status = %d after %d requests, want the 429 passed on without waiting 2h", resp.StatusCode, requests)
+	}
+	if !strings.Contains(warnings.String(), "asks to retry in 2h0m0s; giving up") {
+		t.Errorf("warnings = %q", warnings.String())
+	}
+}
+
+func TestRetryTransportSeededJitter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var runs [2][]time.Duration
+	for i := range runs {
+		waits := &delayClock{Fake: clock.NewFake(time.Now())}
+		rt := NewRetryTransport(http.DefaultTransport, 3, io.Discard)
+		rt.Clock, rt.Rand = waits, clock.Seeded(7)
+		resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		runs[i] = waits.delays
+	}
+	if len(runs[0]) != 3 || !slices.Equal(runs[0], runs[1]) {
+		t.Errorf("delays = %v and %v, want the same 3 delays with the same seed", runs[0], runs[1])
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := clock.NewFake(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	if got := retryAfter("120", now); got != 2*time.Minute {
+		t.Errorf("retryAfter(120) = %s", got)
+	}
+	date := now.Now().Add(30 * time.Second).Format(http.TimeFormat)
+	if got := retryAfter(date, now); got != 30*time.Second {
+		t.Errorf("retryAfter(%s) = %s, want 30s", date, got)
+	}
+	if got := retryAfter("soon", now); got != 0 {
+		t.Errorf("retryAfter(soon) = %s, want 0", got)
+	}
+	if got := backoff(40); got != MaxRetryDelay {
+		t.Errorf("backoff(40) = %s, want %s", got, MaxRetryDelay)
+	}
+}
@@ -0,0 +1,83 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Chunk is a part of the text of an indexed resource, small enough to hand to a model. Chunks
+// are numbered from 0 within their resource.
+type Chunk struct {
+	Server string `json:"server"`
+	URI    string `json:"uri"`
+	Name   string `json:"name,omitempty"`
+	Seq    int    `json:"chunk"`
+	// Chunks is the number of chunks of the resource, so neighbors can be fetched.
+	Chunks int    `json:"chunks,omitempty"`
+	Text   string `json:"text"`
+	// Score is the similarity of semantic matches to the query, from 0 to 100.
+	Score float64 `json:"score,omitempty"`
+	Freshness
+}
+
+// Chunk returns chunk seq of the resource at uri of server. Chunks are split the same way
+// whether or not the resource was embedded.
+func (ix *Index) Chunk(ctx context.Context, server, uri string, seq int) (Chunk, error) {
+	doc, err := ix.Document(ctx, server, uri)
+	if err != nil {
+		return Chunk{}, err
+	}
+	chunks := splitChunks(doc.Text, chunkBytes)
+	if seq < 0 || seq >= len(chunks) {
+		return Chunk{}, fmt.Errorf("%w: chunk %d of %s has %d chunks", ErrNotIndexed, seq, uri, len(chunks))
+	}
+	chunk := Chunk{Server: server, URI: uri, Name: doc.Name, Seq: seq, Chunks: len(chunks), Text: chunks[seq]}
+	chunk.Freshness, err = ix.freshness(ctx, server, uri)
+	return chunk, err
+}
+
+// SearchChunks returns the resources matching query as Search does, each with the chunk of its
+// text containing the most words of the query.
+func (ix *Index) SearchChunks(ctx context.Context, query string, opts SearchOptions) ([]Chunk, error) {
+	hits, err := ix.Search(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	chunks := make([]Chunk, 0, len(hits))
+	for _, hit := range hits {
+		doc, docErr := ix.Document(ctx, hit.Server, hit.URI)
+		if docErr != nil {
+			return nil, docErr
+		}
+		parts := splitChunks(doc.Text, chunkBytes)
+		if len(parts) == 0 {
+			continue
+		}
+		best, bestCount := 0, -1
+		for seq, part := range parts {
+			lower := strings.ToLower(part)
+			count := 0
+			for _, term := range terms {
+				count += strings.Count(lower, term)
+			}
+			if count > bestCount {
+				best, bestCount = seq, count
+			}
+		}
+		chunks = append(chunks, Chunk{Server: hit.Server, URI: hit.URI, Name: hit.Name, Seq: best, Chunks: len(parts),
+			Text: parts[best], Freshness: hit.Freshness})
+	}
+	return chunks, nil
+}
+
+// freshness returns how current the indexed text of the resource at uri of server is.
+func (ix *Index) freshness(ctx context.Context, server, uri string) (Freshness, error) {
+	var indexedAt, refreshedAt string
+	err := ix.db.QueryRowContext(ctx, `SELECT indexed_at,
+		coalesce((SELECT refreshed_at FROM servers WHERE name = resources.server), '')
+		FROM resources WHERE server = ? AND uri = ?`, server, uri).Scan(&indexedAt, &refreshedAt)
+	return Freshness{IndexedAt: parseTime(indexedAt), RefreshedAt: parseTime(refreshedAt)}, err
+}
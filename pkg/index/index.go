@@ -0,0 +1,342 @@
+// Package index keeps a local full-text index of the resources of MCP servers, so their
+// contents can be searched without reading every resource again.
+package index
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// DefaultMaxBytes is the size above which the text of a resource is truncated before indexing.
+const DefaultMaxBytes = 1 << 20
+
+// schema creates the index: one row per resource, with the server and URI stored but not
+// indexed, and words stemmed so that "running" finds "run".
+const schema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS resources USING fts5(
+	server UNINDEXED,
+	uri UNINDEXED,
+	name,
+	mime_type UNINDEXED,
+	text,
+	indexed_at UNINDEXED,
+	tokenize = 'porter unicode61'
+);`
+
+// columnText is the column of the resources table snippets are taken from.
+const columnText = 4
+
+// rankWeights weigh matches in the columns of the resources table, in order: a match in the name
+// of a resource counts five times as much as one in its text.
+const rankWeights = "0, 0, 5, 0, 1, 0"
+
+// Marks around the words matching a query in snippets.
+const (
+	MatchStart = "["
+	MatchEnd   = "]"
+)
+
+var (
+	// ErrEmptyQuery is returned when searching for nothing.
+	ErrEmptyQuery = errors.New("search query is empty")
+	// ErrNotIndexed is returned for resources missing from the index.
+	ErrNotIndexed = errors.New("resource not indexed")
+)
+
+// Document is the text of a resource of a server.
+type Document struct {
+	URI      string `json:"uri"`
+	Name     string `json:"name,omitempty"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"-"`
+}
+
+// Hit is a resource matching a search, with the best matching part of its text.
+type Hit struct {
+	Server  string `json:"server"`
+	URI     string `json:"uri"`
+	Name    string `json:"name,omitempty"`
+	Snippet string `json:"snippet"`
+	// Score is the similarity of semantic hits to the query, from 0 to 100.
+	Score float64 `json:"score,omitempty"`
+	Freshness
+}
+
+// Freshness tells how current the indexed text of a resource is: when it was indexed, as it was
+// then, and when the server was last refreshed, finding the resource unchanged since. Times are
+// zero for indexes built before they were recorded.
+type Freshness struct {
+	IndexedAt   time.Time `json:"indexedAt"`
+	RefreshedAt time.Time `json:"refreshedAt"`
+}
+
+// CheckedAt returns when the indexed text was last known to be current: the later of IndexedAt
+// and RefreshedAt, zero if neither was recorded.
+func (f Freshness) CheckedAt() time.Time {
+	if f.RefreshedAt.After(f.IndexedAt) {
+		return f.RefreshedAt
+	}
+	return f.IndexedAt
+}
+
+// Summary tells how many resources of a server are indexed, how many of them with embeddings,
+// when the last of them changed and when the server was last refreshed.
+type Summary struct {
+	Server    string `json:"server"`
+	Resources int    `json:"resources"`
+	Embedded  int    `json:"embedded"`
+	Freshness
+}
+
+// Index is an open resource index.
+type Index struct {
+	db *sql.DB
+}
+
+// GetDBPath returns the path to the index database.
+func GetDBPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "index.db"), nil
+}
+
+// ResolvePath returns the database of the index at path: path itself, or index.db in it if
+// path is a directory. An empty path is the default index.
+func ResolvePath(path string) (string, error) {
+	if path == "" {
+		return GetDBPath()
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, "index.db"), nil
+	}
+	return path, nil
+}
+
+// Open opens the index at path, creating it if needed.
+func Open(path string) (*Index, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index: %w", err)
+	}
+	if _, err = db.Exec(schema + chunkSchema + updateSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open index: %w", err)
+	}
+	_ = os.Chmod(path, 0o600)
+	return &Index{db: db}, nil
+}
+
+// Close closes the index.
+func (ix *Index) Close() error {
+	return ix.db.Close()
+}
+
+// Remove deletes the indexed resources of server and returns how many there were.
+func (ix *Index) Remove(ctx context.Context, server string) (int, error) {
+	if _, err := ix.db.ExecContext(ctx, "DELETE FROM servers WHERE name = ?", server); err != nil {
+		return 0, err
+	}
+	for _, table := range []string{"chunks", "documents"} {
+		if _, err := ix.db.ExecContext(ctx, "DELETE FROM "+table+" WHERE server = ?", server); err != nil {
+			return 0, err
+		}
+	}
+	result, err := ix.db.ExecContext(ctx, "DELETE FROM resources WHERE server = ?", server)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// Servers summarizes the indexed servers.
+func (ix *Index) Servers(ctx context.Context) ([]Summary, error) {
+	rows, err := ix.db.QueryContext(ctx, `SELECT server, count(*),
+		(SELECT count(DISTINCT uri) FROM chunks WHERE chunks.server = resources.server), max(indexed_at),
+		coalesce((SELECT refreshed_at FROM servers WHERE name = resources.server), '')
+		FROM resources GROUP BY server ORDER BY server`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	summaries := []Summary{}
+	for rows.Next() {
+		var s Summary
+		var indexedAt, refreshedAt string
+		if err = rows.Scan(&s.Server, &s.Resources, &s.Embedded, &indexedAt, &refreshedAt); err != nil {
+			return nil, err
+		}
+		s.IndexedAt, s.RefreshedAt = parseTime(indexedAt), parseTime(refreshedAt)
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// Document returns the indexed text of the resource at uri of server.
+func (ix *Index) Document(ctx context.Context, server, uri string) (Document, error) {
+	doc := Document{URI: uri}
+	err := ix.db.QueryRowContext(ctx, "SELECT name, mime_type, text FROM resources WHERE server = ? AND uri = ?",
+		server, uri).Scan(&doc.Name, &doc.MIMEType, &doc.Text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return doc, fmt.Errorf("%w: %s of %s", ErrNotIndexed, uri, server)
+	}
+	return doc, err
+}
+
+// SearchOptions configures Search.
+type SearchOptions struct {
+	// Server restricts the search to the resources of a server.
+	Server string
+	// Limit is the maximum number of hits, 0 for all.
+	Limit int
+	// Raw passes the query to SQLite as an FTS5 query, with its operators such as OR, NEAR and
+	// prefix*. Otherwise every word of the query must appear in a resource.
+	Raw bool
+}
+
+// Search returns the resources matching query, best match first. Matches in resource names
+// weigh more than matches in their text.
+func (ix *Index) Search(ctx context.Context, query string, opts SearchOptions) ([]Hit, error) {
+	match := query
+	if !opts.Raw {
+		match = quoteTerms(query)
+	}
+	if strings.TrimSpace(match) == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	sqlQuery := fmt.Sprintf(`SELECT server, uri, name, snippet(resources, %d, ?, ?, '…', 16), indexed_at,
+		coalesce((SELECT refreshed_at FROM servers WHERE name = resources.server), '')
+		FROM resources WHERE resources MATCH ?`, columnText)
+	args := []any{MatchStart, MatchEnd, match}
+	if opts.Server != "" {
+		sqlQuery += " AND server = ?"
+		args = append(args, opts.Server)
+	}
+	sqlQuery += " ORDER BY bm25(resources, " + rankWeights + ")"
+	if opts.Limit > 0 {
+		sqlQuery += " LIMIT ?"
+		args = append(args, opts.Limit)
+	}
+
+	rows, err := ix.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search query %q: %w", query, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	hits := []Hit{}
+	for rows.Next() {
+		var hit Hit
+		var indexedAt, refreshedAt string
+		if err = rows.Scan(&hit.Server, &hit.URI, &hit.Name, &hit.Snippet, &indexedAt, &refreshedAt); err != nil {
+			return nil, err
+		}
+		hit.IndexedAt, hit.RefreshedAt = parseTime(indexedAt), parseTime(refreshedAt)
+		// Resources matching by name only have no snippet of their text
+		if hit.Snippet == "" {
+			hit.Snippet = hit.Name
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// quoteTerms turns each word of query into an FTS5 string, so that punctuation such as the
+// hyphen of "rate-limit" is not read as an operator.
+func quoteTerms(query string) string {
+	terms := strings.Fields(query)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(terms, " ")
+}
+
+// Source is the part of an MCP client that lists and reads resources.
+type Source interface {
+	ListResources(ctx context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error)
+	ReadResource(ctx context.Context, request mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error)
+}
+
+// CrawlOptions configures Crawl.
+type CrawlOptions struct {
+	// MaxBytes truncates the text of larger resources, DefaultMaxBytes if 0.
+	MaxBytes int
+	// Progress, if set, is called after each resource is read, with the error reading it.
+	Progress func(uri string, err error)
+}
+
+// Crawl lists the resources of a server, following its cursor, and reads their text. Binary
+// resources are left out, and so are resources that cannot be read, which are reported to
+// Progress.
+func Crawl(ctx context.Context, src Source, opts CrawlOptions) ([]Document, error) {
+	var resources []mcp.Resource
+	request := mcp.ListResourcesRequest{}
+	for {
+		resp, err := src.ListResources(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+		resources = append(resources, resp.Resources...)
+		if resp.NextCursor == "" {
+			break
+		}
+		request.Params.Cursor = resp.NextCursor
+	}
+
+	var docs []Document
+	for _, resource := range resources {
+		doc, ok, err := ReadDocument(ctx, src, resource, opts.MaxBytes)
+		if opts.Progress != nil {
+			opts.Progress(resource.URI, err)
+		}
+		if ok {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// ReadDocument reads the text of a resource, truncated to maxBytes, or DefaultMaxBytes if 0. It
+// reports false for resources without text, and for those it fails to read.
+func ReadDocument(ctx context.Context, src Source, resource mcp.Resource, maxBytes int) (Document, bool, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	read := mcp.ReadResourceRequest{}
+	read.Params.URI = resource.URI
+	result, err := src.ReadResource(ctx, read)
+	if err != nil {
+		return Document{}, false, err
+	}
+
+	var parts []string
+	for _, content := range result.Contents {
+		if text, ok := content.(mcp.TextResourceContents); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	if len(parts) == 0 {
+		return Document{}, false, nil
+	}
+	text := strings.Join(parts, "\n")
+	if len(text) > maxBytes {
+		text = strings.ToValidUTF8(text[:maxBytes], "")
+	}
+	return Document{URI: resource.URI, Name: resource.Name, MIMEType: resource.MIMEType, Text: text}, true, nil
+}
@@ -0,0 +1,148 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// fakeSource serves resources two per page, with their text by URI.
+type fakeSource struct {
+	resources []mcp.Resource
+	texts     map[string]string
+}
+
+func (f *fakeSource) ListResources(_ context.Context, request mcp.ListResourcesRequest) (*mcp.ListResourcesResult, error) {
+	start := 0
+	if request.Params.Cursor != "" {
+		start = len(request.Params.Cursor)
+	}
+	end := min(start+2, len(f.resources))
+	result := &mcp.ListResourcesResult{Resources: f.resources[start:end]}
+	if end < len(f.resources) {
+		result.NextCursor = mcp.Cursor(strings.Repeat("x", end))
+	}
+	return result, nil
+}
+
+func (f *fakeSource) ReadResource(_ context.Context, request mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := request.Params.URI
+	if uri == "file:///blob" {
+		return &mcp.ReadResourceResult{Contents: []mcp.ResourceContents{mcp.BlobResourceContents{URI: uri, Blob: "AAAA"}}}, nil
+	}
+	text, ok := f.texts[uri]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &mcp.ReadResourceResult{Contents: []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, Text: text}}}, nil
+}
+
+func TestCrawlAndSearch(t *testing.T) {
+	src := &fakeSource{
+		resources: []mcp.Resource{
+			{URI: "file:///guide.md", Name: "guide.md"},
+			{URI: "file:///limits.md", Name: "limits.md"},
+			{URI: "file:///blob", Name: "blob"},
+			{URI: "file:///missing", Name: "missing"},
+			{URI: "file:///rate-limits.md", Name: "rate-limits.md"},
+		},
+		texts: map[string]string{
+			"file:///guide.md":       "Start the server, then keep it running while clients connect.",
+			"file:///limits.md":      "Clients are throttled above 100 requests per minute.",
+			"file:///rate-limits.md": "See the limits page.",
+		},
+	}
+
+	var failed []string
+	docs, err := Crawl(context.Background(), src, CrawlOptions{Progress: func(uri string, err error) {
+		if err != nil {
+			failed = append(failed, uri)
+		}
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 3 || !reflect.DeepEqual(failed, []string{"file:///missing"}) {
+		t.Fatalf("Crawl() = %d documents, failed %q; want 3 and the missing resource", len(docs), failed)
+	}
+
+	ix, err := Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ix.Close() }()
+	ctx := context.Background()
+	if _, err = ix.Update(ctx, "docs", docs, UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Indexing again replaces the resources of the server
+	if _, err = ix.Update(ctx, "docs", docs, UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ix.Update(ctx, "other", docs[:1], UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := ix.Search(ctx, "run", SearchOptions{Server: "docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].URI != "file:///guide.md" || !strings.Contains(hits[0].Snippet, "[running]") {
+		t.Errorf("Search(run) = %+v, want the guide with running marked", hits)
+	}
+
+	// Name matches rank first, and punctuation is not an operator
+	hits, err = ix.Search(ctx, "limits", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 || hits[0].URI != "file:///rate-limits.md" {
+		t.Errorf("Search(limits) = %+v, want rate-limits.md first", hits)
+	}
+	if _, err = ix.Search(ctx, "rate-limits", SearchOptions{}); err != nil {
+		t.Errorf("Search(rate-limits) error = %v", err)
+	}
+	if hits, err = ix.Search(ctx, "throttle* OR running", SearchOptions{Raw: true, Server: "docs"}); err != nil || len(hits) != 2 {
+		t.Errorf("raw Search() = %+v, %v; want 2 hits", hits, err)
+	}
+	if _, err = ix.Search(ctx, "  ", SearchOptions{}); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("expected %v, got %v", ErrEmptyQuery, err)
+	}
+
+	summaries, err := ix.Servers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 2 || summaries[0].Server != "docs" || summaries[0].Resources != 3 || summaries[0].IndexedAt.IsZero() {
+		t.Errorf("Servers() = %+v", summaries)
+	}
+	if n, err := ix.Remove(ctx, "docs"); err != nil || n != 3 {
+		t.Errorf("Remove() = %d, %v; want 3", n, err)
+	}
+}
+
+func TestFreshnessCheckedAt(t *testing.T) {
+	indexed := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	refreshed := indexed.Add(time.Hour)
+
+	tests := []struct {
+		freshness Freshness
+		want      time.Time
+	}{
+		{Freshness{}, time.Time{}},
+		{Freshness{IndexedAt: indexed}, indexed},
+		{Freshness{IndexedAt: indexed, RefreshedAt: refreshed}, refreshed},
+		{Freshness{IndexedAt: refreshed, RefreshedAt: indexed}, refreshed},
+	}
+	for _, tt := range tests {
+		if got := tt.freshness.CheckedAt(); !got.Equal(tt.want) {
+			t.Errorf("%+v.CheckedAt() = %v, want %v", tt.freshness, got, tt.want)
+		}
+	}
+}
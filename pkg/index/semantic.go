@@ -0,0 +1,233 @@
+package index
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/f/mcptools/pkg/search"
+)
+
+// chunkSchema stores the embeddings of resources, split into chunks that fit the input of
+// embedding models. Vectors are little-endian float32.
+const chunkSchema = `
+CREATE TABLE IF NOT EXISTS chunks (
+	server TEXT    NOT NULL,
+	uri    TEXT    NOT NULL,
+	name   TEXT    NOT NULL,
+	seq    INTEGER NOT NULL,
+	text   TEXT    NOT NULL,
+	model  TEXT    NOT NULL,
+	vector BLOB    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS chunks_server ON chunks (server, model);`
+
+// Sizes of chunks and of embedding requests.
+const (
+	chunkBytes = 2000
+	embedBatch = 64
+	// snippetBytes is the length of the start of a chunk shown as the snippet of a hit.
+	snippetBytes = 200
+)
+
+// ErrNoEmbeddings is returned by semantic searches of an index built without embeddings, or
+// with another model.
+var ErrNoEmbeddings = errors.New("no resources are indexed with embeddings of this model")
+
+// embeddedChunk is a chunk of the text of a resource with its embedding.
+type embeddedChunk struct {
+	doc    Document
+	seq    int
+	text   string
+	vector []float64
+}
+
+// embedChunks splits the text of docs into chunks and embeds them with embedder, in batches.
+// progress, if set, is called after each batch with the number of chunks embedded so far and in
+// total.
+func embedChunks(ctx context.Context, docs []Document, embedder search.Embedder, progress func(done, total int)) ([]embeddedChunk, error) {
+	var chunks []embeddedChunk
+	for _, doc := range docs {
+		for seq, text := range splitChunks(doc.Text, chunkBytes) {
+			chunks = append(chunks, embeddedChunk{doc: doc, seq: seq, text: text})
+		}
+	}
+
+	for start := 0; start < len(chunks); start += embedBatch {
+		batch := chunks[start:min(start+embedBatch, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, c := range batch {
+			// The name gives chunks of the same resource a shared context
+			texts[i] = c.doc.Name + "\n" + c.text
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		for i := range batch {
+			batch[i].vector = vectors[i]
+		}
+		if progress != nil {
+			progress(start+len(batch), len(chunks))
+		}
+	}
+	return chunks, nil
+}
+
+// SemanticSearch returns the resources whose meaning is closest to query, best match first,
+// with the start of the closest chunk of each as its snippet. The score of hits is the cosine
+// similarity of the query and that chunk, scaled to 0-100.
+func (ix *Index) SemanticSearch(ctx context.Context, query string, embedder search.Embedder, model string,
+	opts SearchOptions) ([]Hit, error) {
+	chunks, err := ix.SemanticChunks(ctx, query, embedder, model, opts)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]Hit, len(chunks))
+	for i, c := range chunks {
+		hits[i] = Hit{Server: c.Server, URI: c.URI, Name: c.Name, Snippet: snippet(c.Text), Score: c.Score, Freshness: c.Freshness}
+	}
+	return hits, nil
+}
+
+// SemanticChunks returns the chunk of each resource closest in meaning to query, best match
+// first. Resources sharing nothing with the query are left out.
+func (ix *Index) SemanticChunks(ctx context.Context, query string, embedder search.Embedder, model string,
+	opts SearchOptions) ([]Chunk, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	sqlQuery := `SELECT server, uri, name, seq, text, vector,
+		coalesce((SELECT indexed_at FROM documents d WHERE d.server = chunks.server AND d.uri = chunks.uri), ''),
+		coalesce((SELECT refreshed_at FROM servers WHERE name = chunks.server), '')
+		FROM chunks WHERE model = ?`
+	args := []any{model}
+	if opts.Server != "" {
+		sqlQuery += " AND server = ?"
+		args = append(args, opts.Server)
+	}
+	rows, err := ix.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var vector []float64
+	best := map[[2]string]*Chunk{}
+	counts := map[[2]string]int{}
+	for rows.Next() {
+		var c Chunk
+		var blob []byte
+		var indexedAt, refreshedAt string
+		if err = rows.Scan(&c.Server, &c.URI, &c.Name, &c.Seq, &c.Text, &blob, &indexedAt, &refreshedAt); err != nil {
+			return nil, err
+		}
+		c.IndexedAt, c.RefreshedAt = parseTime(indexedAt), parseTime(refreshedAt)
+		// Only embed the query once there is something to compare it with
+		if vector == nil {
+			embedded, embedErr := embedder.Embed(ctx, []string{query})
+			if embedErr != nil {
+				return nil, embedErr
+			}
+			vector = embedded[0]
+		}
+
+		c.Score = math.Round(search.Cosine(vector, decodeVector(blob))*10000) / 100
+		key := [2]string{c.Server, c.URI}
+		counts[key]++
+		if current, ok := best[key]; !ok || c.Score > current.Score {
+			best[key] = &c
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if vector == nil {
+		return nil, ErrNoEmbeddings
+	}
+
+	chunks := make([]Chunk, 0, len(best))
+	for key, c := range best {
+		c.Chunks = counts[key]
+		if c.Score > 0 {
+			chunks = append(chunks, *c)
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].Score != chunks[j].Score {
+			return chunks[i].Score > chunks[j].Score
+		}
+		return chunks[i].URI < chunks[j].URI
+	})
+	if opts.Limit > 0 && len(chunks) > opts.Limit {
+		chunks = chunks[:opts.Limit]
+	}
+	return chunks, nil
+}
+
+// splitChunks splits text into chunks of at most size bytes, preferring to break after a blank
+// line, then a line, then a space. Chunks are trimmed, and blank ones dropped.
+func splitChunks(text string, size int) []string {
+	var chunks []string
+	for len(text) > 0 {
+		end := len(text)
+		if end > size {
+			end = size
+			for !utf8.RuneStart(text[end]) {
+				end--
+			}
+			for _, sep := range []string{"\n\n", "\n", " "} {
+				if i := strings.LastIndex(text[:end], sep); i > size/2 {
+					end = i + len(sep)
+					break
+				}
+			}
+		}
+		if chunk := strings.TrimSpace(text[:end]); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		text = text[end:]
+	}
+	return chunks
+}
+
+// snippet returns the start of text on one line.
+func snippet(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= snippetBytes {
+		return text
+	}
+	end := snippetBytes
+	for !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[:end] + "…"
+}
+
+func encodeVector(vector []float64) []byte {
+	blob := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(float32(v)))
+	}
+	return blob
+}
+
+func decodeVector(blob []byte) []float64 {
+	vector := make([]float64, len(blob)/4)
+	for i := range vector {
+		vector[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:])))
+	}
+	return vector
+}
+
+// ModelKey identifies the embeddings of an API embedder: those of the same model served at
+// different URLs may differ.
+func ModelKey(e *search.APIEmbedder) string {
+	return fmt.Sprintf("%s %s", e.URL, e.Model)
+}
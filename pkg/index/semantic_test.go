@@ -0,0 +1,112 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeEmbedder embeds texts as counts of the words of a small vocabulary, so texts sharing
+// topics are similar.
+type fakeEmbedder struct {
+	calls int
+}
+
+var vocabulary = [][]string{{"refund", "money", "return"}, {"shipping", "delivery", "parcel"}, {"password", "login"}}
+
+func (f *fakeEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	f.calls++
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float64, len(vocabulary))
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			for topic, words := range vocabulary {
+				for _, w := range words {
+					if strings.HasPrefix(word, w) {
+						vectors[i][topic]++
+					}
+				}
+			}
+		}
+	}
+	return vectors, nil
+}
+
+func TestSemanticSearch(t *testing.T) {
+	ix, err := Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ix.Close() }()
+	ctx := context.Background()
+
+	docs := []Document{
+		{URI: "doc://returns", Name: "returns", Text: "Customers get their money back within 30 days."},
+		{URI: "doc://delivery", Name: "delivery", Text: "Parcels ship in two days. " + strings.Repeat("Delivery is tracked. ", 200)},
+		{URI: "doc://account", Name: "account", Text: "Reset your password from the login page."},
+	}
+	if _, err = ix.Update(ctx, "docs", docs, UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	embedder := &fakeEmbedder{}
+	if _, err = ix.SemanticSearch(ctx, "refund", embedder, "fake", SearchOptions{}); !errors.Is(err, ErrNoEmbeddings) {
+		t.Fatalf("expected %v before embedding, got %v", ErrNoEmbeddings, err)
+	}
+	// Embedding resources indexed without embeddings embeds them all
+	result, err := ix.Update(ctx, "docs", docs, UpdateOptions{Embedder: embedder, Model: "fake"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Changed != 3 {
+		t.Errorf("Update() = %+v, want 3 changed", result)
+	}
+
+	hits, err := ix.SemanticSearch(ctx, "refund policy", embedder, "fake", SearchOptions{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Resources unrelated to the query are left out
+	if len(hits) != 1 || hits[0].URI != "doc://returns" || hits[0].Score != 100 || !strings.Contains(hits[0].Snippet, "money back") {
+		t.Errorf("SemanticSearch(refund policy) = %+v, want returns first", hits)
+	}
+
+	// Embeddings of another model are not compared
+	if _, err = ix.SemanticSearch(ctx, "refund", embedder, "other", SearchOptions{}); !errors.Is(err, ErrNoEmbeddings) {
+		t.Errorf("expected %v for another model, got %v", ErrNoEmbeddings, err)
+	}
+
+	summaries, err := ix.Servers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 1 || summaries[0].Embedded != 3 {
+		t.Errorf("Servers() = %+v, want 3 embedded resources", summaries)
+	}
+
+	// Indexing the resources again without embeddings drops them
+	if _, err = ix.Update(ctx, "docs", docs, UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ix.SemanticSearch(ctx, "refund", embedder, "fake", SearchOptions{}); !errors.Is(err, ErrNoEmbeddings) {
+		t.Errorf("expected %v after indexing again, got %v", ErrNoEmbeddings, err)
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	text := strings.Repeat("word ", 30) + "\n\n" + strings.Repeat("é", 100)
+	chunks := splitChunks(text, 100)
+	noSpace := func(s string) string { return strings.Join(strings.Fields(s), "") }
+	if noSpace(strings.Join(chunks, "")) != noSpace(text) {
+		t.Errorf("splitChunks() lost text: %q", chunks)
+	}
+	for _, chunk := range chunks {
+		if len(chunk) > 100 || !strings.HasSuffix(chunk, "word") && !strings.HasSuffix(chunk, "é") {
+			t.Errorf("expected chunks of at most 100 bytes broken between words, got %q", chunk)
+		}
+	}
+	if chunks := splitChunks(" \n ", 100); len(chunks) != 0 {
+		t.Errorf("expected no chunks of blank text, got %q", chunks)
+	}
+}
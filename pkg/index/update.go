@@ -0,0 +1,261 @@
+package index
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/f/mcptools/pkg/search"
+)
+
+// updateSchema records what was indexed, so that indexes can be refreshed incrementally: a hash
+// of each resource, to only index again those that changed, and the command and settings each
+// server was indexed with, to refresh it without repeating them. model is empty for servers
+// indexed without embeddings.
+const updateSchema = `
+CREATE TABLE IF NOT EXISTS documents (
+	server     TEXT NOT NULL,
+	uri        TEXT NOT NULL,
+	hash       TEXT NOT NULL,
+	indexed_at TEXT NOT NULL,
+	PRIMARY KEY (server, uri)
+);
+CREATE TABLE IF NOT EXISTS servers (
+	name         TEXT PRIMARY KEY,
+	command      TEXT NOT NULL DEFAULT '[]',
+	max_bytes    INTEGER NOT NULL DEFAULT 0,
+	model        TEXT NOT NULL DEFAULT '',
+	refreshed_at TEXT NOT NULL DEFAULT ''
+);`
+
+// ErrServerNotIndexed is returned for servers that were never indexed.
+var ErrServerNotIndexed = errors.New("server not indexed")
+
+// Server is how a server was indexed, and when the index of its resources was last refreshed.
+type Server struct {
+	Name string `json:"name"`
+	// Command starts or connects to the server, as given to mcp index build.
+	Command  []string `json:"command"`
+	MaxBytes int      `json:"maxBytes"`
+	// Model identifies the embeddings of the resources, empty if they have none.
+	Model       string    `json:"model,omitempty"`
+	RefreshedAt time.Time `json:"refreshedAt"`
+}
+
+// UpdateOptions configures Update.
+type UpdateOptions struct {
+	// Embedder, if set, embeds the resources that changed for semantic searches.
+	Embedder search.Embedder
+	// Model identifies the embeddings of Embedder. When it differs from the model the server was
+	// indexed with, every resource is embedded again.
+	Model string
+	// Partial updates only the given resources, leaving the others indexed. Otherwise the
+	// resources missing from the update are removed from the index.
+	Partial bool
+	// Progress, if set, is called after each batch of chunks is embedded, with the number of
+	// chunks embedded so far and in total.
+	Progress func(done, total int)
+}
+
+// UpdateResult counts the resources an update added, changed, removed and left alone.
+type UpdateResult struct {
+	Added     int `json:"added"`
+	Changed   int `json:"changed"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Update indexes the resources of server that were added or changed since it was last indexed,
+// and removes those that are gone. Unchanged resources keep their text, embeddings and time of
+// indexing, so refreshing a large server only costs reading it.
+func (ix *Index) Update(ctx context.Context, server string, docs []Document, opts UpdateOptions) (UpdateResult, error) {
+	var result UpdateResult
+	if opts.Embedder == nil {
+		opts.Model = ""
+	}
+
+	previousModel := ""
+	err := ix.db.QueryRowContext(ctx, "SELECT model FROM servers WHERE name = ?", server).Scan(&previousModel)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return result, err
+	}
+	hashes, err := ix.hashes(ctx, server)
+	if err != nil {
+		return result, err
+	}
+
+	var changed []Document
+	seen := map[string]bool{}
+	for _, doc := range docs {
+		seen[doc.URI] = true
+		previous, indexed := hashes[doc.URI]
+		switch {
+		case !indexed:
+			result.Added++
+		case previous == hashDocument(doc) && opts.Model == previousModel:
+			result.Unchanged++
+			continue
+		default:
+			result.Changed++
+		}
+		changed = append(changed, doc)
+	}
+	var removed []string
+	if !opts.Partial {
+		for uri := range hashes {
+			if !seen[uri] {
+				removed = append(removed, uri)
+			}
+		}
+		result.Removed = len(removed)
+	}
+
+	var chunks []embeddedChunk
+	if opts.Embedder != nil {
+		if chunks, err = embedChunks(ctx, changed, opts.Embedder, opts.Progress); err != nil {
+			return result, err
+		}
+	}
+
+	tx, err := ix.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Embeddings of another model cannot be compared with the new ones
+	if opts.Model != previousModel {
+		if _, err = tx.ExecContext(ctx, "DELETE FROM chunks WHERE server = ?", server); err != nil {
+			return result, err
+		}
+	}
+	for _, uri := range removed {
+		if err = deleteDocument(ctx, tx, server, uri); err != nil {
+			return result, err
+		}
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, doc := range changed {
+		if err = deleteDocument(ctx, tx, server, doc.URI); err != nil {
+			return result, err
+		}
+		if _, err = tx.ExecContext(ctx, "INSERT INTO resources VALUES (?, ?, ?, ?, ?, ?)",
+			server, doc.URI, doc.Name, doc.MIMEType, doc.Text, now); err != nil {
+			return result, err
+		}
+		if _, err = tx.ExecContext(ctx, "INSERT INTO documents VALUES (?, ?, ?, ?)",
+			server, doc.URI, hashDocument(doc), now); err != nil {
+			return result, err
+		}
+	}
+	for _, c := range chunks {
+		if _, err = tx.ExecContext(ctx, "INSERT INTO chunks VALUES (?, ?, ?, ?, ?, ?, ?)",
+			server, c.doc.URI, c.doc.Name, c.seq, c.text, opts.Model, encodeVector(c.vector)); err != nil {
+			return result, err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO servers (name, model, refreshed_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET model = excluded.model, refreshed_at = excluded.refreshed_at`,
+		server, opts.Model, now); err != nil {
+		return result, err
+	}
+	return result, tx.Commit()
+}
+
+// hashes returns the hash of each indexed resource of server by URI. Resources indexed by
+// earlier versions have an empty hash, so they count as changed.
+func (ix *Index) hashes(ctx context.Context, server string) (map[string]string, error) {
+	rows, err := ix.db.QueryContext(ctx, `SELECT r.uri, coalesce(d.hash, '') FROM resources r
+		LEFT JOIN documents d ON d.server = r.server AND d.uri = r.uri WHERE r.server = ?`, server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	hashes := map[string]string{}
+	for rows.Next() {
+		var uri, hash string
+		if err = rows.Scan(&uri, &hash); err != nil {
+			return nil, err
+		}
+		hashes[uri] = hash
+	}
+	return hashes, rows.Err()
+}
+
+// deleteDocument removes a resource, its embeddings and its hash from the index.
+func deleteDocument(ctx context.Context, tx *sql.Tx, server, uri string) error {
+	for _, table := range []string{"resources", "chunks", "documents"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE server = ? AND uri = ?", server, uri); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hashDocument(doc Document) string {
+	sum := sha256.Sum256([]byte(doc.Name + "\x00" + doc.MIMEType + "\x00" + doc.Text))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetServer records the command and settings server is indexed with, for refreshes.
+func (ix *Index) SetServer(ctx context.Context, name string, command []string, maxBytes int) error {
+	data, err := json.Marshal(command)
+	if err != nil {
+		return err
+	}
+	_, err = ix.db.ExecContext(ctx, `INSERT INTO servers (name, command, max_bytes) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET command = excluded.command, max_bytes = excluded.max_bytes`,
+		name, string(data), maxBytes)
+	return err
+}
+
+// Server returns how the server called name was indexed.
+func (ix *Index) Server(ctx context.Context, name string) (Server, error) {
+	servers, err := ix.queryServers(ctx, "WHERE name = ?", name)
+	if err != nil {
+		return Server{}, err
+	}
+	if len(servers) == 0 {
+		return Server{}, fmt.Errorf("%w: %s", ErrServerNotIndexed, name)
+	}
+	return servers[0], nil
+}
+
+// IndexedServers returns how each indexed server was indexed, by name.
+func (ix *Index) IndexedServers(ctx context.Context) ([]Server, error) {
+	return ix.queryServers(ctx, "ORDER BY name")
+}
+
+func (ix *Index) queryServers(ctx context.Context, clause string, args ...any) ([]Server, error) {
+	rows, err := ix.db.QueryContext(ctx, "SELECT name, command, max_bytes, model, refreshed_at FROM servers "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var servers []Server
+	for rows.Next() {
+		var s Server
+		var command, refreshedAt string
+		if err = rows.Scan(&s.Name, &command, &s.MaxBytes, &s.Model, &refreshedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(command), &s.Command)
+		s.RefreshedAt = parseTime(refreshedAt)
+		servers = append(servers, s)
+	}
+	return servers, rows.Err()
+}
+
+// parseTime parses a time stored in the index, or returns the zero time for indexes built
+// before it was recorded.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
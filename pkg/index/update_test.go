@@ -0,0 +1,81 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	ix, err := Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ix.Close() }()
+	ctx := context.Background()
+
+	docs := []Document{
+		{URI: "doc://a", Name: "a", Text: "alpha"},
+		{URI: "doc://b", Name: "b", Text: "bravo"},
+		{URI: "doc://c", Name: "c", Text: "charlie"},
+	}
+	result, err := ix.Update(ctx, "docs", docs, UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (UpdateResult{Added: 3}); result != want {
+		t.Errorf("first Update() = %+v, want %+v", result, want)
+	}
+
+	docs = []Document{docs[0], {URI: "doc://b", Name: "b", Text: "bravo two"}, {URI: "doc://d", Name: "d", Text: "delta"}}
+	result, err = ix.Update(ctx, "docs", docs, UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (UpdateResult{Added: 1, Changed: 1, Removed: 1, Unchanged: 1}); result != want {
+		t.Errorf("second Update() = %+v, want %+v", result, want)
+	}
+	if hits, _ := ix.Search(ctx, "charlie", SearchOptions{}); len(hits) != 0 {
+		t.Errorf("removed resource still found: %+v", hits)
+	}
+
+	// Partial updates leave the other resources indexed
+	result, err = ix.Update(ctx, "docs", []Document{{URI: "doc://a", Name: "a", Text: "alpha two"}}, UpdateOptions{Partial: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (UpdateResult{Changed: 1}); result != want {
+		t.Errorf("partial Update() = %+v, want %+v", result, want)
+	}
+
+	hits, err := ix.Search(ctx, "delta", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].IndexedAt.IsZero() || hits[0].RefreshedAt.Before(hits[0].IndexedAt) {
+		t.Errorf("Search(delta) = %+v, want a hit with its freshness", hits)
+	}
+
+	if err = ix.SetServer(ctx, "docs", []string{"docs"}, 100); err != nil {
+		t.Fatal(err)
+	}
+	server, err := ix.Server(ctx, "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(server.Command, []string{"docs"}) || server.MaxBytes != 100 || server.RefreshedAt.IsZero() {
+		t.Errorf("Server() = %+v", server)
+	}
+	if _, err = ix.Server(ctx, "missing"); !errors.Is(err, ErrServerNotIndexed) {
+		t.Errorf("expected %v, got %v", ErrServerNotIndexed, err)
+	}
+
+	if _, err = ix.Remove(ctx, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if servers, _ := ix.IndexedServers(ctx); len(servers) != 0 {
+		t.Errorf("IndexedServers() after Remove() = %+v", servers)
+	}
+}
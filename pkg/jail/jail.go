@@ -0,0 +1,178 @@
+/*
+Package jail confines the filesystem access of stdio servers to a set of roots, from outside the
+server: on Linux with bubblewrap, or with Landlock where bubblewrap is not installed, and on
+macOS with sandbox-exec. The system directories needed to run programs stay readable; the
+home directories and everything else outside the roots are hidden or denied.
+*/
+package jail
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExecCommand is the hidden command that confines itself with Landlock before running a
+// server, for Linux systems without bubblewrap.
+const ExecCommand = "jail-exec"
+
+var (
+	// ErrUnsupported is returned on systems that cannot confine servers.
+	ErrUnsupported = errors.New("filesystem jails are not supported on this system")
+	// ErrInvalidRoot is returned for malformed --fs-jail values.
+	ErrInvalidRoot = errors.New("invalid jail root")
+)
+
+// Root is a directory a jailed server may access, read-only unless marked writable.
+type Root struct {
+	Path     string
+	ReadOnly bool
+}
+
+// String returns the root in the path[:rw] form ParseRoot accepts.
+func (r Root) String() string {
+	if r.ReadOnly {
+		return r.Path
+	}
+	return r.Path + ":rw"
+}
+
+// ParseRoot parses a root in path[:rw] form. Roots are read-only unless they end with :rw; an
+// explicit :ro is accepted too. The path is made absolute and must be an existing directory.
+func ParseRoot(spec string) (Root, error) {
+	root := Root{Path: spec, ReadOnly: true}
+	if path, ok := strings.CutSuffix(spec, ":rw"); ok {
+		root = Root{Path: path}
+	} else if path, ok = strings.CutSuffix(spec, ":ro"); ok {
+		root.Path = path
+	}
+	if root.Path == "" {
+		return Root{}, fmt.Errorf("%w: %q (use path[:rw])", ErrInvalidRoot, spec)
+	}
+
+	path, err := filepath.Abs(root.Path)
+	if err != nil {
+		return Root{}, fmt.Errorf("%w: %w", ErrInvalidRoot, err)
+	}
+	if resolved, linkErr := filepath.EvalSymlinks(path); linkErr == nil {
+		path = resolved
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Root{}, fmt.Errorf("%w: %w", ErrInvalidRoot, err)
+	}
+	if !info.IsDir() {
+		return Root{}, fmt.Errorf("%w: %s is not a directory", ErrInvalidRoot, root.Path)
+	}
+	root.Path = path
+	return root, nil
+}
+
+// ParseRoots parses roots given as repeated or comma-separated path[:rw] values.
+func ParseRoots(specs []string) ([]Root, error) {
+	var roots []Root
+	for _, spec := range specs {
+		for _, part := range strings.Split(spec, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			root, err := ParseRoot(part)
+			if err != nil {
+				return nil, err
+			}
+			roots = append(roots, root)
+		}
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("%w: no directory given", ErrInvalidRoot)
+	}
+	return roots, nil
+}
+
+// hiddenDirs are replaced by empty directories in bubblewrap jails, as they hold users' files.
+var hiddenDirs = []string{"/home", "/root", "/mnt", "/media", "/run/user"}
+
+// bwrapArgs returns the bubblewrap arguments running command in a jail: the filesystem is
+// mounted read-only, with fresh /dev, /proc and /tmp, hidden is emptied, and the roots are
+// mounted back. The server starts in cwd if it is inside a root, or else in /.
+func bwrapArgs(roots []Root, hidden []string, cwd, command string, args []string) []string {
+	bwrap := []string{"--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc", "--tmpfs", "/tmp"}
+	for _, dir := range hidden {
+		bwrap = append(bwrap, "--tmpfs", dir)
+	}
+	chdir := "/"
+	for _, root := range roots {
+		if root.ReadOnly {
+			bwrap = append(bwrap, "--ro-bind", root.Path, root.Path)
+		} else {
+			bwrap = append(bwrap, "--bind", root.Path, root.Path)
+		}
+		if within(cwd, root.Path) {
+			chdir = cwd
+		}
+	}
+	bwrap = append(bwrap, "--die-with-parent", "--chdir", chdir, "--", command)
+	return append(bwrap, args...)
+}
+
+// sandboxProfile returns the sandbox-exec profile of a jail: reading the directories in hidden
+// and writing anywhere but the temporary directories and terminals is denied, except in the
+// roots. Rules that come later take precedence.
+func sandboxProfile(roots []Root, hidden []string) string {
+	var b strings.Builder
+	b.WriteString("(version 1)\n(allow default)\n")
+	for _, dir := range hidden {
+		fmt.Fprintf(&b, "(deny file-read* (subpath %s))\n", sbplString(dir))
+	}
+	b.WriteString("(deny file-write*)\n")
+	b.WriteString(`(allow file-write* (subpath "/private/tmp") (subpath "/private/var/folders") (literal "/dev/null") (literal "/dev/tty") (regex #"^/dev/fd/"))` + "\n")
+	for _, root := range roots {
+		operations := "file-read*"
+		if !root.ReadOnly {
+			operations += " file-write*"
+		}
+		fmt.Fprintf(&b, "(allow %s (subpath %s))\n", operations, sbplString(root.Path))
+	}
+	return b.String()
+}
+
+// sbplString quotes s as a string of the sandbox profile language.
+func sbplString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// execArgs returns the arguments of ExecCommand running command confined to roots.
+func execArgs(roots []Root, command string, args []string) []string {
+	execArgs := []string{ExecCommand}
+	for _, root := range roots {
+		execArgs = append(execArgs, root.String())
+	}
+	execArgs = append(execArgs, "--", command)
+	return append(execArgs, args...)
+}
+
+// ParseExecArgs parses the arguments of ExecCommand into the roots and the server command.
+func ParseExecArgs(args []string) ([]Root, string, []string, error) {
+	for i, arg := range args {
+		if arg != "--" {
+			continue
+		}
+		if i+1 >= len(args) {
+			break
+		}
+		roots, err := ParseRoots(args[:i])
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return roots, args[i+1], args[i+2:], nil
+	}
+	return nil, "", nil, errors.New("usage: " + ExecCommand + " root... -- command [args...]")
+}
+
+// within reports whether path is dir or inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,25 @@
+package jail
+
+import (
+	"os"
+	"os/exec"
+)
+
+// Command returns the command line that runs command with args confined to roots through
+// sandbox-exec.
+func Command(roots []Root, command string, args []string) (string, []string, error) {
+	sandboxExec, err := exec.LookPath("sandbox-exec")
+	if err != nil {
+		return "", nil, ErrUnsupported
+	}
+	hidden := []string{"/Users", "/Volumes"}
+	if home := os.Getenv("HOME"); home != "" {
+		hidden = append(hidden, home)
+	}
+	return sandboxExec, append([]string{"-p", sandboxProfile(roots, hidden), command}, args...), nil
+}
+
+// Exec is only used on Linux.
+func Exec([]Root, string, []string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,148 @@
+package jail
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Command returns the command line that runs command with args confined to roots: through
+// bubblewrap if it is installed, or else through ExecCommand, which uses Landlock.
+func Command(roots []Root, command string, args []string) (string, []string, error) {
+	if bwrap, err := exec.LookPath("bwrap"); err == nil {
+		cwd, _ := os.Getwd()
+		var hidden []string
+		for _, dir := range append(hiddenDirs, os.Getenv("HOME")) {
+			if info, statErr := os.Stat(dir); dir != "" && statErr == nil && info.IsDir() {
+				hidden = append(hidden, dir)
+			}
+		}
+		return bwrap, bwrapArgs(roots, hidden, cwd, command, args), nil
+	}
+
+	if landlockABI() < 1 {
+		return "", nil, fmt.Errorf("%w: install bubblewrap (bwrap), or use a kernel with Landlock enabled", ErrUnsupported)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return "", nil, err
+	}
+	return self, execArgs(roots, command, args), nil
+}
+
+// Landlock access rights, by the ABI version that introduced them.
+const (
+	accessFileRead  = unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_READ_FILE
+	accessFileWrite = unix.LANDLOCK_ACCESS_FS_WRITE_FILE
+	accessDirRead   = accessFileRead | unix.LANDLOCK_ACCESS_FS_READ_DIR
+	accessDirWrite  = accessFileWrite | unix.LANDLOCK_ACCESS_FS_REMOVE_DIR | unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR | unix.LANDLOCK_ACCESS_FS_MAKE_DIR | unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK | unix.LANDLOCK_ACCESS_FS_MAKE_FIFO | unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+	accessReferV2    = unix.LANDLOCK_ACCESS_FS_REFER
+	accessTruncateV3 = unix.LANDLOCK_ACCESS_FS_TRUNCATE
+)
+
+// systemDirs stay readable and executable in Landlock jails, so programs can be run.
+var systemDirs = []string{"/bin", "/sbin", "/usr", "/lib", "/lib32", "/lib64", "/etc", "/opt", "/nix", "/snap", "/proc", "/sys"}
+
+// landlockABI returns the Landlock ABI version of the kernel, or 0 if it is not available.
+func landlockABI() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+// Exec confines the process to roots with Landlock and replaces it with command. Besides the
+// roots, the system directories and the directory of the command stay readable, and /dev and
+// the temporary directory writable. It only returns on failure.
+func Exec(roots []Root, command string, args []string) error {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return err
+	}
+	abi := landlockABI()
+	if abi < 1 {
+		return fmt.Errorf("%w: Landlock is not enabled in this kernel", ErrUnsupported)
+	}
+	// The ruleset applies to the thread enforcing it, which must be the one calling exec
+	runtime.LockOSThread()
+
+	dirRead, dirWrite := uint64(accessDirRead), uint64(accessDirWrite)
+	if abi >= 2 {
+		dirWrite |= accessReferV2
+	}
+	fileWrite := uint64(accessFileWrite)
+	if abi >= 3 {
+		fileWrite |= accessTruncateV3
+		dirWrite |= accessTruncateV3
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: dirRead | dirWrite}
+	ruleset, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create the Landlock ruleset: %w", errno)
+	}
+	defer func() { _ = unix.Close(int(ruleset)) }()
+
+	allow := func(dir string, access, fileAccess uint64) error {
+		fd, openErr := unix.Open(dir, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if openErr != nil {
+			// Missing system directories are skipped; the roots were checked when parsed
+			return nil
+		}
+		defer func() { _ = unix.Close(fd) }()
+		var stat unix.Stat_t
+		if statErr := unix.Fstat(fd, &stat); statErr == nil && stat.Mode&unix.S_IFMT != unix.S_IFDIR {
+			// Rights on directory entries cannot be granted on files
+			access = fileAccess
+		}
+		rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+		if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, ruleset, unix.LANDLOCK_RULE_PATH_BENEATH,
+			uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+			return fmt.Errorf("failed to allow access to %s: %w", dir, errno)
+		}
+		return nil
+	}
+
+	readOnly := []string{filepath.Dir(path)}
+	readOnly = append(readOnly, systemDirs...)
+	for _, dir := range readOnly {
+		if err = allow(dir, dirRead, accessFileRead); err != nil {
+			return err
+		}
+	}
+	if err = allow("/dev", dirRead|fileWrite, accessFileRead|fileWrite); err != nil {
+		return err
+	}
+	if err = allow(os.TempDir(), dirRead|dirWrite, accessFileRead|fileWrite); err != nil {
+		return err
+	}
+	for _, root := range roots {
+		access, fileAccess := dirRead|dirWrite, uint64(accessFileRead)|fileWrite
+		if root.ReadOnly {
+			access, fileAccess = dirRead, accessFileRead
+		}
+		if err = allow(root.Path, access, fileAccess); err != nil {
+			return err
+		}
+	}
+
+	if err = unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to restrict privileges: %w", err)
+	}
+	if _, _, errno = unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce the Landlock ruleset: %w", errno)
+	}
+
+	// #nosec G204 - the command is the server the user runs
+	return syscall.Exec(path, append([]string{command}, args...), os.Environ())
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package jail
+
+// Command returns ErrUnsupported: servers can only be confined on Linux and macOS.
+func Command([]Root, string, []string) (string, []string, error) {
+	return "", nil, ErrUnsupported
+}
+
+// Exec is only used on Linux.
+func Exec([]Root, string, []string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,102 @@
+package jail
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRoots(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := filepath.Join(dir, "docs")
+	if err = os.Mkdir(docs, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "file")
+	if err = os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	roots, err := ParseRoots([]string{dir, docs + ":ro, " + docs + ":rw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Root{{Path: dir, ReadOnly: true}, {Path: docs, ReadOnly: true}, {Path: docs}}
+	if !reflect.DeepEqual(roots, want) {
+		t.Errorf("ParseRoots() = %v, want %v", roots, want)
+	}
+
+	for _, specs := range [][]string{{filepath.Join(dir, "missing")}, {file}, {":ro"}, {":rw"}, {" , "}} {
+		if _, err = ParseRoots(specs); !errors.Is(err, ErrInvalidRoot) {
+			t.Errorf("ParseRoots(%q) error = %v, want %v", specs, err, ErrInvalidRoot)
+		}
+	}
+}
+
+func TestBwrapArgs(t *testing.T) {
+	roots := []Root{{Path: "/home/me/project"}, {Path: "/srv/data", ReadOnly: true}}
+	got := bwrapArgs(roots, []string{"/home"}, "/home/me/project/src", "node", []string{"server.js"})
+	want := []string{
+		"--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc", "--tmpfs", "/tmp",
+		"--tmpfs", "/home",
+		"--bind", "/home/me/project", "/home/me/project",
+		"--ro-bind", "/srv/data", "/srv/data",
+		"--die-with-parent", "--chdir", "/home/me/project/src", "--", "node", "server.js",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bwrapArgs() = %q, want %q", got, want)
+	}
+
+	got = bwrapArgs(roots, nil, "/home/me/other", "node", nil)
+	if chdir := got[len(got)-3]; chdir != "/" {
+		t.Errorf("expected servers started outside the roots to start in /, got %s", chdir)
+	}
+}
+
+func TestSandboxProfile(t *testing.T) {
+	profile := sandboxProfile([]Root{{Path: `/Users/me/my "project"`}, {Path: "/opt/data", ReadOnly: true}}, []string{"/Users"})
+	for _, rule := range []string{
+		`(deny file-read* (subpath "/Users"))`,
+		"(deny file-write*)\n",
+		`(allow file-read* file-write* (subpath "/Users/me/my \"project\""))`,
+		`(allow file-read* (subpath "/opt/data"))`,
+	} {
+		if !strings.Contains(profile, rule) {
+			t.Errorf("expected %s in profile:\n%s", rule, profile)
+		}
+	}
+	// Later rules win, so the roots must come after the denials
+	if strings.Index(profile, "/opt/data") < strings.Index(profile, "(deny file-write*)") {
+		t.Errorf("expected the roots to be allowed after the denials:\n%s", profile)
+	}
+}
+
+func TestExecArgs(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := []Root{{Path: dir}, {Path: dir, ReadOnly: true}}
+	args := execArgs(roots, "python", []string{"-m", "server", "--", "x"})
+	if args[0] != ExecCommand {
+		t.Fatalf("expected %s first, got %q", ExecCommand, args)
+	}
+
+	gotRoots, command, commandArgs, err := ParseExecArgs(args[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotRoots, roots) || command != "python" || !reflect.DeepEqual(commandArgs, []string{"-m", "server", "--", "x"}) {
+		t.Errorf("ParseExecArgs() = %v %s %q", gotRoots, command, commandArgs)
+	}
+
+	if _, _, _, err = ParseExecArgs([]string{dir, "--"}); err == nil {
+		t.Error("expected an error without a command")
+	}
+}
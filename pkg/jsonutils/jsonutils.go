@@ -12,7 +12,9 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/f/mcptools/pkg/protocol"
 	"golang.org/x/term"
 )
 
@@ -134,6 +136,38 @@ func formatTable(data any) (string, error) {
 		return formatContent(content)
 	}
 
+	if matches, ok5 := mapVal["matches"]; ok5 {
+		return formatMatchesList(matches)
+	}
+
+	if usage, ok6 := mapVal["usage"]; ok6 {
+		return formatUsageList(usage)
+	}
+
+	if pooled, ok10 := mapVal["pooled"]; ok10 {
+		return formatPooledUsage(pooled)
+	}
+
+	if hits, ok11 := mapVal["hits"]; ok11 {
+		return formatHits(hits)
+	}
+
+	if indexed, ok12 := mapVal["indexed"]; ok12 {
+		return formatIndexed(indexed)
+	}
+
+	if matrix, ok7 := mapVal["matrix"]; ok7 {
+		return formatMatrix(matrix)
+	}
+
+	if runs, ok8 := mapVal["runs"]; ok8 {
+		return formatRuns(runs)
+	}
+
+	if calls, ok9 := mapVal["calls"]; ok9 {
+		return formatCalls(calls)
+	}
+
 	return formatGenericMap(mapVal)
 }
 
@@ -192,6 +226,15 @@ func formatToolsList(tools any) (string, error) {
 			displayName = fmt.Sprintf("%s%s%s", ColorBold+ColorCyan, name, ColorReset)
 		}
 
+		// Mark tools the server has deprecated
+		if _, deprecated := protocol.Deprecation(tool); deprecated {
+			if useColors {
+				displayName += fmt.Sprintf(" %s(deprecated)%s", ColorYellow, ColorReset)
+			} else {
+				displayName += " (deprecated)"
+			}
+		}
+
 		// Write the name with parameters
 		fmt.Fprintln(&buf, displayName)
 
@@ -670,6 +713,358 @@ func formatResourcesList(resources any) (string, error) {
 	return buf.String(), nil
 }
 
+// formatMatchesList formats the results of a search as a table, best match first.
+func formatMatchesList(matches any) (string, error) {
+	matchesSlice, ok := matches.([]any)
+	if !ok {
+		return "No matches found", nil
+	}
+
+	if len(matchesSlice) == 0 {
+		return "No matches found", nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	useColors := isTerminal()
+
+	if useColors {
+		fmt.Fprintf(w, "%sSERVER%s\t%sKIND%s\t%sNAME%s\t%sDESCRIPTION%s\n",
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset)
+		fmt.Fprintf(w, "%s------%s\t%s----%s\t%s----%s\t%s-----------%s\n",
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset)
+	} else {
+		fmt.Fprintln(w, "SERVER\tKIND\tNAME\tDESCRIPTION")
+		fmt.Fprintln(w, "------\t----\t----\t-----------")
+	}
+
+	for _, m := range matchesSlice {
+		match, ok1 := m.(map[string]any)
+		if !ok1 {
+			continue
+		}
+
+		server, _ := match["server"].(string)
+		kind, _ := match["kind"].(string)
+		name, _ := match["name"].(string)
+		desc, _ := match["description"].(string)
+		if len(desc) > 60 {
+			desc = desc[:57] + "..."
+		}
+
+		if useColors {
+			fmt.Fprintf(w, "%s\t%s\t%s%s%s\t%s\n", server, kind, ColorGreen, name, ColorReset, desc)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", server, kind, name, desc)
+		}
+	}
+
+	_ = w.Flush()
+	return buf.String(), nil
+}
+
+// formatUsageList formats tool usage summaries as a table.
+func formatUsageList(usage any) (string, error) {
+	usageSlice, ok := usage.([]any)
+	if !ok || len(usageSlice) == 0 {
+		return "No tool calls recorded", nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	useColors := isTerminal()
+
+	if useColors {
+		fmt.Fprintf(w, "%sSERVER%s\t%sTOOL%s\t%sCALLS%s\t%sERROR RATE%s\t%sAVG LATENCY%s\t%sSLO BREACHES%s\n",
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset,
+			ColorCyan, ColorReset)
+	} else {
+		fmt.Fprintln(w, "SERVER\tTOOL\tCALLS\tERROR RATE\tAVG LATENCY\tSLO BREACHES")
+	}
+
+	for _, u := range usageSlice {
+		summary, ok1 := u.(map[string]any)
+		if !ok1 {
+			continue
+		}
+
+		server, _ := summary["server"].(string)
+		tool, _ := summary["tool"].(string)
+		calls, _ := summary["calls"].(float64)
+		errorRate, _ := summary["errorRate"].(float64)
+		latency, _ := summary["avgLatencyMs"].(float64)
+		breaches, _ := summary["sloBreaches"].(float64)
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.1f%%\t%s\t%d\n", server, tool, int(calls), errorRate*100,
+			FormatDuration(time.Duration(latency*float64(time.Millisecond))), int(breaches))
+	}
+
+	_ = w.Flush()
+	return buf.String(), nil
+}
+
+// formatPooledUsage formats tool usage pooled across developers as a table.
+func formatPooledUsage(pooled any) (string, error) {
+	usageSlice, ok := pooled.([]any)
+	if !ok || len(usageSlice) == 0 {
+		return "No tool is used by enough contributors", nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	headers := []string{"SERVER", "TOOL", "CONTRIBUTORS", "CALLS", "ERROR RATE"}
+	if isTerminal() {
+		for i, header := range headers {
+			headers[i] = ColorCyan + header + ColorReset
+		}
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, u := range usageSlice {
+		use, ok1 := u.(map[string]any)
+		if !ok1 {
+			continue
+		}
+
+		server, _ := use["server"].(string)
+		tool, _ := use["tool"].(string)
+		contributors, _ := use["contributors"].(float64)
+		calls, _ := use["calls"].(float64)
+		errorRate, _ := use["errorRate"].(float64)
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.1f%%\n", server, tool, int(contributors), int(calls), errorRate*100)
+	}
+
+	_ = w.Flush()
+	return buf.String(), nil
+}
+
+// formatHits formats the resources matching a full-text search, best match first, each with
+// the matching part of its text on one line.
+func formatHits(hits any) (string, error) {
+	hitsSlice, ok := hits.([]any)
+	if !ok || len(hitsSlice) == 0 {
+		return "No matching resources", nil
+	}
+
+	var buf bytes.Buffer
+	useColors := isTerminal()
+	for _, h := range hitsSlice {
+		hit, ok1 := h.(map[string]any)
+		if !ok1 {
+			continue
+		}
+
+		server, _ := hit["server"].(string)
+		uri, _ := hit["uri"].(string)
+		snippet, _ := hit["snippet"].(string)
+		snippet = strings.Join(strings.Fields(snippet), " ")
+		// Semantic hits are scored by similarity
+		if score, ok2 := hit["score"].(float64); ok2 {
+			server = fmt.Sprintf("%s, %.0f%%", server, score)
+		}
+
+		if useColors {
+			fmt.Fprintf(&buf, "%s%s%s  %s(%s)%s\n", ColorGreen, uri, ColorReset, ColorCyan, server, ColorReset)
+		} else {
+			fmt.Fprintf(&buf, "%s  (%s)\n", uri, server)
+		}
+		fmt.Fprintf(&buf, "  %s\n", snippet)
+		if indexedAt := indexStamp(hit["indexedAt"]); indexedAt != "-" {
+			fmt.Fprintf(&buf, "  indexed %s, checked %s\n", indexedAt, indexStamp(hit["refreshedAt"]))
+		}
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// formatIndexed formats the servers whose resources are indexed as a table.
+func formatIndexed(indexed any) (string, error) {
+	rows, ok := indexed.([]any)
+	if !ok || len(rows) == 0 {
+		return "No servers indexed", nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	headers := []string{"SERVER", "RESOURCES", "EMBEDDED", "INDEXED", "REFRESHED"}
+	if isTerminal() {
+		for i, header := range headers {
+			headers[i] = ColorCyan + header + ColorReset
+		}
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, r := range rows {
+		summary, ok1 := r.(map[string]any)
+		if !ok1 {
+			continue
+		}
+		server, _ := summary["server"].(string)
+		resources, _ := summary["resources"].(float64)
+		embedded, _ := summary["embedded"].(float64)
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", server, int(resources), int(embedded),
+			indexStamp(summary["indexedAt"]), indexStamp(summary["refreshedAt"]))
+	}
+
+	_ = w.Flush()
+	return buf.String(), nil
+}
+
+// indexStamp formats a time recorded in a resource index, or "-" for times indexes built before
+// they were recorded leave zero.
+func indexStamp(v any) string {
+	stamp, _ := v.(string)
+	t, err := time.Parse(time.RFC3339, stamp)
+	if err != nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// formatMatrix formats the capabilities of servers as a table, one server per row. Counts of
+// capabilities a server does not announce are shown as "-".
+func formatMatrix(matrix any) (string, error) {
+	rows, ok := matrix.([]any)
+	if !ok || len(rows) == 0 {
+		return "No servers compared", nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	headers := []string{"SERVER", "PROTOCOL", "TOOLS", "RESOURCES", "SUBSCRIBE", "PROMPTS", "LOGGING", "SAMPLING"}
+	if isTerminal() {
+		for i, header := range headers {
+			headers[i] = ColorCyan + header + ColorReset
+		}
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	count := func(v any) string {
+		if n, ok := v.(float64); ok {
+			return fmt.Sprintf("%d", int(n))
+		}
+		return "-"
+	}
+	flag := func(v any) string {
+		if b, _ := v.(bool); b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	for _, r := range rows {
+		row, ok1 := r.(map[string]any)
+		if !ok1 {
+			continue
+		}
+		server, _ := row["server"].(string)
+		if errText, _ := row["error"].(string); errText != "" {
+			fmt.Fprintf(w, "%s\terror: %s\n", server, errText)
+			continue
+		}
+		protocol, _ := row["protocolVersion"].(string)
+		if protocol == "" {
+			protocol = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", server, protocol,
+			count(row["tools"]), count(row["resources"]), flag(row["subscribe"]),
+			count(row["prompts"]), flag(row["logging"]), flag(row["sampling"]))
+	}
+
+	_ = w.Flush()
+	return buf.String(), nil
+}
+
+// formatRuns formats the runs of a workflow matrix as a table, one run per row.
+func formatRuns(runs any) (string, error) {
+	rows, ok := runs.([]any)
+	if !ok || len(rows) == 0 {
+		return "No runs", nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	headers := []string{"RUN", "STATUS", "STEPS", "ERROR"}
+	if isTerminal() {
+		for i, header := range headers {
+			headers[i] = ColorCyan + header + ColorReset
+		}
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, r := range rows {
+		row, ok1 := r.(map[string]any)
+		if !ok1 {
+			continue
+		}
+		run, _ := row["run"].(string)
+		status, _ := row["status"].(string)
+		steps, _ := row["steps"].(float64)
+		errText, _ := row["error"].(string)
+		if errText == "" {
+			errText = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", run, status, int(steps), errText)
+	}
+
+	_ = w.Flush()
+	return buf.String(), nil
+}
+
+// formatCalls formats recorded tool calls as a table.
+func formatCalls(calls any) (string, error) {
+	rows, ok := calls.([]any)
+	if !ok || len(rows) == 0 {
+		return "No matching tool calls", nil
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	headers := []string{"TIME", "SERVER", "TOOL", "STATUS", "DURATION"}
+	if isTerminal() {
+		for i, header := range headers {
+			headers[i] = ColorCyan + header + ColorReset
+		}
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, c := range rows {
+		call, ok1 := c.(map[string]any)
+		if !ok1 {
+			continue
+		}
+		stamp, _ := call["time"].(string)
+		if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+			stamp = t.Local().Format("2006-01-02 15:04:05")
+		}
+		server, _ := call["server"].(string)
+		tool, _ := call["tool"].(string)
+		status := "ok"
+		if failed, _ := call["error"].(bool); failed {
+			status = "error"
+		}
+		duration, _ := call["durationMs"].(float64)
+		took := FormatDuration(time.Duration(duration * float64(time.Millisecond)))
+		if slo, _ := call["sloMs"].(float64); slo > 0 && duration > slo {
+			took += " (over SLO of " + FormatDuration(time.Duration(slo*float64(time.Millisecond))) + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", stamp, server, tool, status, took)
+	}
+
+	_ = w.Flush()
+	return buf.String(), nil
+}
+
 // formatPromptsList formats a list of prompts as a table.
 func formatPromptsList(prompts any) (string, error) {
 	promptsSlice, ok := prompts.([]any)
@@ -797,21 +1192,23 @@ func formatGenericMap(data map[string]any) (string, error) {
 
 	for _, k := range keys {
 		v := data[k]
-		var valueStr string
-
-		switch val := v.(type) {
-		case string:
-			valueStr = val
-		case nil:
-			valueStr = "<nil>"
-		default:
-			jsonBytes, err := json.Marshal(val)
-			if err != nil {
-				valueStr = fmt.Sprintf("<%T>", val)
-			} else {
-				valueStr = string(jsonBytes)
-				if len(valueStr) > 50 {
-					valueStr = valueStr[:47] + "..."
+		valueStr, humanized := humanizeField(k, v)
+
+		if !humanized {
+			switch val := v.(type) {
+			case string:
+				valueStr = val
+			case nil:
+				valueStr = "<nil>"
+			default:
+				jsonBytes, err := json.Marshal(val)
+				if err != nil {
+					valueStr = fmt.Sprintf("<%T>", val)
+				} else {
+					valueStr = string(jsonBytes)
+					if len(valueStr) > 50 {
+						valueStr = valueStr[:47] + "..."
+					}
 				}
 			}
 		}
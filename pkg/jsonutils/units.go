@@ -0,0 +1,123 @@
+package jsonutils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// FormatDuration formats d for people: milliseconds below a second, seconds with one decimal
+// below a minute, and minutes and hours above.
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "-" + FormatDuration(-d)
+	case d < 10*time.Millisecond:
+		ms := float64(d) / float64(time.Millisecond)
+		if ms == math.Trunc(ms) {
+			return fmt.Sprintf("%.0fms", ms)
+		}
+		return fmt.Sprintf("%.1fms", ms)
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Round(time.Millisecond).Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Hour:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// FormatBytes formats a byte count with binary units, e.g. 1.5 KiB.
+func FormatBytes(n int64) string {
+	if n < 0 {
+		return "-" + FormatBytes(-n)
+	}
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	for _, unit := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		value /= 1024
+		if value < 1024 || unit == "TiB" {
+			return fmt.Sprintf("%.1f %s", value, unit)
+		}
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// FormatTimestamp formats t relative to now followed by its ISO 8601 form, e.g.
+// "5m ago (2025-06-01T10:00:00Z)".
+func FormatTimestamp(t, now time.Time) string {
+	iso := t.UTC().Format(time.RFC3339)
+	diff := now.Sub(t)
+	switch {
+	case diff > -time.Second && diff < time.Second:
+		return "just now (" + iso + ")"
+	case diff > 0:
+		return relative(diff) + " ago (" + iso + ")"
+	default:
+		return "in " + relative(-diff) + " (" + iso + ")"
+	}
+}
+
+func relative(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// now is replaced in tests.
+var now = time.Now
+
+// humanizeField formats the value of a field recognized by its name as a duration, a size or a
+// timestamp. It returns false for other fields and for values of an unexpected type.
+//
+// Durations are numbers in fields named like durationMs, latency_ms or elapsedSeconds, sizes are
+// numbers in fields named size or like sizeBytes or content_bytes, and timestamps are RFC 3339
+// strings in fields named time, timestamp or date or like createdAt, updated_at or startTime.
+func humanizeField(key string, value any) (string, bool) {
+	lower := strings.ToLower(key)
+	hasSuffix := func(suffixes ...string) bool {
+		for _, suffix := range suffixes {
+			// The suffix must be a word of its own, as in fooMs or foo_ms but not items
+			if lower == strings.ToLower(suffix) || strings.HasSuffix(key, suffix) ||
+				strings.HasSuffix(lower, "_"+strings.ToLower(suffix)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if number, ok := value.(float64); ok {
+		switch {
+		case hasSuffix("Ms"):
+			return FormatDuration(time.Duration(number * float64(time.Millisecond))), true
+		case hasSuffix("Seconds", "Secs"):
+			return FormatDuration(time.Duration(number * float64(time.Second))), true
+		case lower == "size" || hasSuffix("Bytes"):
+			return FormatBytes(int64(number)), true
+		}
+		return "", false
+	}
+
+	if text, ok := value.(string); ok && (hasSuffix("Time", "At") || lower == "timestamp" || lower == "date") {
+		for _, layout := range []string{time.RFC3339Nano, time.RFC3339} {
+			if t, err := time.Parse(layout, text); err == nil {
+				return FormatTimestamp(t, now()), true
+			}
+		}
+	}
+	return "", false
+}
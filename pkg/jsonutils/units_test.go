@@ -0,0 +1,77 @@
+package jsonutils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		400 * time.Microsecond:  "0.4ms",
+		3 * time.Millisecond:    "3ms",
+		250 * time.Millisecond:  "250ms",
+		1500 * time.Millisecond: "1.5s",
+		125 * time.Second:       "2m05s",
+		150 * time.Minute:       "2h30m",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:             "512 B",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 30:         "3.0 GiB",
+	}
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	if got := FormatTimestamp(base.Add(-5*time.Minute), base); got != "5m ago (2025-06-01T11:55:00Z)" {
+		t.Errorf("past timestamp = %q", got)
+	}
+	if got := FormatTimestamp(base.Add(3*24*time.Hour), base); got != "in 3d (2025-06-04T12:00:00Z)" {
+		t.Errorf("future timestamp = %q", got)
+	}
+}
+
+func TestFormatGenericMapHumanizesKnownFields(t *testing.T) {
+	originalNow := now
+	defer func() { now = originalNow }()
+	now = func() time.Time { return time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	output, err := formatGenericMap(map[string]any{
+		"durationMs": float64(1250),
+		"size":       float64(2048),
+		"createdAt":  "2025-06-01T10:00:00Z",
+		"items":      float64(3),
+		"pageSize":   float64(50),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"1.2s", "2.0 KiB", "2h ago (2025-06-01T10:00:00Z)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in:\n%s", want, output)
+		}
+	}
+	// Fields named like units only by accident are left alone
+	for _, line := range strings.Split(output, "\n") {
+		if (strings.HasPrefix(line, "items") && !strings.HasSuffix(strings.TrimSpace(line), "3")) ||
+			(strings.HasPrefix(line, "pageSize") && !strings.HasSuffix(strings.TrimSpace(line), "50")) {
+			t.Errorf("unexpected conversion: %q", line)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package keychain
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// fileIterations is the PBKDF2 work factor deriving the key of an encrypted file from its
+// passphrase, lowered in tests.
+var fileIterations = 600_000
+
+// File keeps secrets in a file encrypted with AES-256-GCM, under a key derived from the
+// passphrase in MCPT_KEYCHAIN_PASSPHRASE. It is the fallback for systems without a secret store,
+// such as servers and containers.
+type File struct {
+	path       string
+	passphrase string
+	mu         sync.Mutex
+}
+
+// encryptedFile is the content of a File.
+type encryptedFile struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewFile returns the encrypted file keychain at path, by default $HOME/.mcpt/secrets.enc.
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, ".mcpt", "secrets.enc")
+	}
+	passphrase := os.Getenv("MCPT_KEYCHAIN_PASSPHRASE")
+	if passphrase == "" {
+		return nil, errors.New("no secret store is available on this system; set MCPT_KEYCHAIN_PASSPHRASE to keep secrets in an encrypted file instead")
+	}
+	return &File{path: path, passphrase: passphrase}, nil
+}
+
+// Backend implements Keychain.
+func (f *File) Backend() string { return BackendFile }
+
+// Get implements Keychain.
+func (f *File) Get(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	secrets, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set implements Keychain.
+func (f *File) Set(name, secret string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	secrets[name] = secret
+	return f.save(secrets)
+}
+
+// Delete implements Keychain.
+func (f *File) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, name)
+	return f.save(secrets)
+}
+
+// Names returns the names of the stored secrets, which unlike the platform stores a file can
+// list without prompting.
+func (f *File) Names() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	secrets, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f *File) load() (map[string]string, error) {
+	secrets := map[string]string{}
+	data, err := os.ReadFile(f.path) // #nosec G304 - the keychain file path is generated internally or provided by the caller
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keychain file: %w", err)
+	}
+
+	var file encryptedFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse keychain file %s: %w", f.path, err)
+	}
+	aead, err := f.cipher(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("failed to parse keychain file %s: invalid nonce", f.path)
+	}
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keychain file %s: wrong MCPT_KEYCHAIN_PASSPHRASE or corrupted file", f.path)
+	}
+	if err = json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse keychain file %s: %w", f.path, err)
+	}
+	return secrets, nil
+}
+
+// save encrypts secrets with a fresh salt and nonce and replaces the file atomically.
+func (f *File) save(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	file := encryptedFile{Salt: make([]byte, 16)}
+	if _, err = rand.Read(file.Salt); err != nil {
+		return err
+	}
+	aead, err := f.cipher(file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plaintext, nil)
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(f.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write keychain file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err = tmp.Chmod(0o600); err == nil {
+		_, err = tmp.Write(append(data, '\n'))
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write keychain file: %w", err)
+	}
+	return nil
+}
+
+func (f *File) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, f.passphrase, salt, fileIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,195 @@
+/*
+Package keychain stores secrets such as API keys and passwords in the secret store of the
+operating system: the macOS Keychain, the Windows Credential Manager or libsecret (GNOME
+Keyring, KWallet) on Linux, with an encrypted file as the fallback where none is available.
+
+Settings that take a secret accept a reference to one instead, written keychain:<name>, which
+is resolved when it is used, so the secret is never written to a configuration file or shell
+history.
+*/
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Service is the service name secrets are stored under.
+const Service = "mcptools"
+
+// Prefix starts a reference to a secret in the keychain.
+const Prefix = "keychain:"
+
+// Backend names.
+const (
+	BackendMacOS     = "keychain"
+	BackendWindows   = "wincred"
+	BackendLibsecret = "libsecret"
+	BackendFile      = "file"
+)
+
+var (
+	// ErrNotFound is returned for secrets that are not stored.
+	ErrNotFound = errors.New("secret not found in the keychain")
+	// ErrUnavailable is returned for backends that cannot be used on this system.
+	ErrUnavailable = errors.New("keychain backend is not available on this system")
+)
+
+// platforms are the secret stores of the operating systems, in the order Open prefers them.
+var platforms = []Keychain{macOS{}, windowsCredentials{}, libsecret{}}
+
+// namePattern restricts secret names, so they can be passed to the backends' tools unquoted.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]*$`)
+
+// Keychain stores secrets by name.
+type Keychain interface {
+	// Get returns a secret, or ErrNotFound.
+	Get(name string) (string, error)
+	// Set stores a secret, replacing any with the same name.
+	Set(name, secret string) error
+	// Delete removes a secret, or returns ErrNotFound.
+	Delete(name string) error
+	// Backend returns the name of the backend.
+	Backend() string
+}
+
+// Open returns the keychain of a backend. An empty backend selects the one named by the
+// MCPT_KEYCHAIN environment variable, or else the secret store of the operating system if it is
+// available, or else the encrypted file.
+func Open(backend string) (Keychain, error) {
+	if backend == "" {
+		backend = os.Getenv("MCPT_KEYCHAIN")
+	}
+	switch backend {
+	case "":
+		for _, platform := range platforms {
+			if available(platform) {
+				return platform, nil
+			}
+		}
+		return NewFile("")
+	case BackendMacOS, BackendWindows, BackendLibsecret:
+		for _, platform := range platforms {
+			if platform.Backend() == backend {
+				if !available(platform) {
+					return nil, fmt.Errorf("%s: %w", backend, ErrUnavailable)
+				}
+				return platform, nil
+			}
+		}
+	case BackendFile:
+		return NewFile("")
+	}
+	return nil, fmt.Errorf("unknown keychain backend %q (supported: %s, %s, %s, %s)",
+		backend, BackendMacOS, BackendWindows, BackendLibsecret, BackendFile)
+}
+
+// Backends returns the names of the backends, in the order Open prefers them.
+func Backends() []string {
+	names := make([]string, 0, len(platforms)+1)
+	for _, platform := range platforms {
+		names = append(names, platform.Backend())
+	}
+	return append(names, BackendFile)
+}
+
+// Available reports whether backend can be used on this system. The encrypted file always can.
+func Available(backend string) bool {
+	if backend == BackendFile {
+		return true
+	}
+	for _, platform := range platforms {
+		if platform.Backend() == backend {
+			return available(platform)
+		}
+	}
+	return false
+}
+
+// DefaultBackend returns the name of the backend Open selects without one: the one named by
+// the MCPT_KEYCHAIN environment variable, or else the first available one.
+func DefaultBackend() string {
+	if backend := os.Getenv("MCPT_KEYCHAIN"); backend != "" {
+		return backend
+	}
+	for _, platform := range platforms {
+		if available(platform) {
+			return platform.Backend()
+		}
+	}
+	return BackendFile
+}
+
+// IsReference reports whether value refers to a secret in the keychain.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Resolve returns value, or the secret it refers to if it is a keychain:<name> reference.
+func Resolve(value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+	keychain, err := Open("")
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimPrefix(value, Prefix)
+	secret, err := keychain.Get(name)
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("secret %q not found in the %s keychain; store it with 'mcp keychain set %s'", name, keychain.Backend(), name)
+	}
+	return secret, err
+}
+
+// ValidateName checks that a secret name is usable with every backend.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: use letters, digits, '.', '_', '@' and '-'", name)
+	}
+	return nil
+}
+
+func available(k Keychain) bool {
+	switch k.Backend() {
+	case BackendMacOS:
+		return runtime.GOOS == "darwin" && lookPath("security")
+	case BackendWindows:
+		return runtime.GOOS == "windows"
+	case BackendLibsecret:
+		// secret-tool needs a session bus to reach the secret service
+		return runtime.GOOS != "darwin" && runtime.GOOS != "windows" &&
+			lookPath("secret-tool") && os.Getenv("DBUS_SESSION_BUS_ADDRESS") != ""
+	}
+	return false
+}
+
+func lookPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// run runs a command with stdin as its input and returns its output and exit code. It is
+// swapped out in tests.
+var run = func(stdin string, name string, args ...string) (string, int, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	return stdout.String(), 0, nil
+}
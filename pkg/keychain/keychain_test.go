@@ -0,0 +1,179 @@
+package keychain
+
+import (
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func init() {
+	fileIterations = 1000
+}
+
+// fakeTool stands in for security and secret-tool, keeping secrets in a map.
+type fakeTool struct {
+	secrets map[string]string
+	calls   []string
+}
+
+func (f *fakeTool) run(stdin string, name string, args ...string) (string, int, error) {
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	account := func() string {
+		for i, arg := range args {
+			if (arg == "-a" || arg == "account") && i+1 < len(args) {
+				return args[i+1]
+			}
+		}
+		return ""
+	}
+	switch name + " " + args[0] {
+	case "security find-generic-password":
+		if secret, ok := f.secrets[account()]; ok {
+			return secret + "\n", 0, nil
+		}
+		return "", securityNotFound, nil
+	case "security -i":
+		// add-generic-password -U -s mcptools -a <name> -X <hex>
+		fields := strings.Fields(stdin)
+		secret, err := hex.DecodeString(fields[7])
+		if err != nil {
+			return "", 1, nil
+		}
+		f.secrets[fields[5]] = string(secret)
+		return "", 0, nil
+	case "security delete-generic-password":
+		if _, ok := f.secrets[account()]; !ok {
+			return "", securityNotFound, nil
+		}
+		delete(f.secrets, account())
+		return "", 0, nil
+	case "secret-tool lookup":
+		if secret, ok := f.secrets[account()]; ok {
+			return secret, 0, nil
+		}
+		return "", 1, nil
+	case "secret-tool store":
+		f.secrets[account()] = stdin
+		return "", 0, nil
+	case "secret-tool clear":
+		delete(f.secrets, account())
+		return "", 0, nil
+	}
+	return "", 1, nil
+}
+
+func TestToolBackends(t *testing.T) {
+	for _, keychain := range []Keychain{macOS{}, libsecret{}} {
+		t.Run(keychain.Backend(), func(t *testing.T) {
+			tool := &fakeTool{secrets: map[string]string{}}
+			original := run
+			run = tool.run
+			t.Cleanup(func() { run = original })
+
+			if _, err := keychain.Get("github"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected ErrNotFound, got %v", err)
+			}
+			if err := keychain.Set("github", "ghp_secret value"); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			for _, call := range tool.calls {
+				if strings.Contains(call, "ghp_secret") {
+					t.Errorf("the secret was passed as an argument: %s", call)
+				}
+			}
+			if secret, err := keychain.Get("github"); err != nil || secret != "ghp_secret value" {
+				t.Errorf("Get: %q, %v", secret, err)
+			}
+			if err := keychain.Delete("github"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := keychain.Delete("github"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+			}
+			if err := keychain.Set("-w", "x"); err == nil {
+				t.Error("expected an error for a name that looks like an option")
+			}
+		})
+	}
+}
+
+func TestBackends(t *testing.T) {
+	backends := Backends()
+	if len(backends) != 4 || backends[len(backends)-1] != BackendFile {
+		t.Fatalf("Backends() = %v, want the platform backends and then the file", backends)
+	}
+	if !Available(BackendFile) {
+		t.Error("the file backend should always be available")
+	}
+	if Available("vault") {
+		t.Error("an unknown backend should not be available")
+	}
+
+	t.Setenv("MCPT_KEYCHAIN", BackendFile)
+	if backend := DefaultBackend(); backend != BackendFile {
+		t.Errorf("DefaultBackend() = %q, want the one of MCPT_KEYCHAIN", backend)
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	t.Setenv("MCPT_KEYCHAIN_PASSPHRASE", "correct horse")
+	file, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	if err = file.Set("api", "s3cr3t"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err = file.Set("db", "hunter2"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if secret, getErr := file.Get("api"); getErr != nil || secret != "s3cr3t" {
+		t.Errorf("Get: %q, %v", secret, getErr)
+	}
+	if names, _ := file.Names(); strings.Join(names, ",") != "api,db" {
+		t.Errorf("unexpected names %v", names)
+	}
+	if err = file.Delete("db"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	t.Setenv("MCPT_KEYCHAIN_PASSPHRASE", "wrong")
+	other, _ := NewFile(path)
+	if _, err = other.Get("api"); err == nil || !strings.Contains(err.Error(), "failed to decrypt") {
+		t.Errorf("expected a decryption error with the wrong passphrase, got %v", err)
+	}
+
+	t.Setenv("MCPT_KEYCHAIN_PASSPHRASE", "")
+	if _, err = NewFile(path); err == nil {
+		t.Error("expected an error without a passphrase")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MCPT_KEYCHAIN", BackendFile)
+	t.Setenv("MCPT_KEYCHAIN_PASSPHRASE", "passphrase")
+
+	keychain, err := Open("")
+	if err != nil || keychain.Backend() != BackendFile {
+		t.Fatalf("Open: %v, %v", keychain, err)
+	}
+	if err = keychain.Set("token", "Bearer abc"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	for value, want := range map[string]string{"keychain:token": "Bearer abc", "Bearer plain": "Bearer plain"} {
+		if got, resolveErr := Resolve(value); resolveErr != nil || got != want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", value, got, resolveErr, want)
+		}
+	}
+	if _, err = Resolve("keychain:missing"); err == nil || !strings.Contains(err.Error(), "mcp keychain set missing") {
+		t.Errorf("expected a hint to store the missing secret, got %v", err)
+	}
+	if _, err = Open("vault"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
@@ -0,0 +1,107 @@
+package keychain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// macOS keeps secrets as generic passwords in the login keychain, through the security tool.
+type macOS struct{}
+
+// security exits with errSecItemNotFound for missing items.
+const securityNotFound = 44
+
+func (macOS) Backend() string { return BackendMacOS }
+
+func (macOS) Get(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	out, code, err := run("", "security", "find-generic-password", "-s", Service, "-a", name, "-w")
+	switch {
+	case err != nil:
+		return "", err
+	case code == securityNotFound:
+		return "", ErrNotFound
+	case code != 0:
+		return "", fmt.Errorf("security find-generic-password exited with status %d", code)
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (macOS) Set(name, secret string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	// Commands read in interactive mode keep the secret out of the process list; hex keeps it
+	// out of the command's quoting rules
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", Service, name, hex.EncodeToString([]byte(secret)))
+	_, code, err := run(command, "security", "-i")
+	if err == nil && code != 0 {
+		err = fmt.Errorf("security add-generic-password exited with status %d", code)
+	}
+	return err
+}
+
+func (macOS) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	_, code, err := run("", "security", "delete-generic-password", "-s", Service, "-a", name)
+	switch {
+	case err != nil:
+		return err
+	case code == securityNotFound:
+		return ErrNotFound
+	case code != 0:
+		return fmt.Errorf("security delete-generic-password exited with status %d", code)
+	}
+	return nil
+}
+
+// libsecret keeps secrets in the freedesktop.org secret service (GNOME Keyring, KWallet), through
+// the secret-tool of libsecret.
+type libsecret struct{}
+
+func (libsecret) Backend() string { return BackendLibsecret }
+
+func (libsecret) Get(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	out, code, err := run("", "secret-tool", "lookup", "service", Service, "account", name)
+	switch {
+	case err != nil:
+		return "", err
+	case code != 0 && out == "":
+		return "", ErrNotFound
+	case code != 0:
+		return "", fmt.Errorf("secret-tool lookup exited with status %d", code)
+	}
+	return out, nil
+}
+
+func (libsecret) Set(name, secret string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	// secret-tool reads the secret from stdin, keeping it out of the process list
+	_, code, err := run(secret, "secret-tool", "store", "--label", Service+": "+name, "service", Service, "account", name)
+	if err == nil && code != 0 {
+		err = fmt.Errorf("secret-tool store exited with status %d", code)
+	}
+	return err
+}
+
+func (l libsecret) Delete(name string) error {
+	// secret-tool clear succeeds whether or not the secret exists
+	if _, err := l.Get(name); err != nil {
+		return err
+	}
+	_, code, err := run("", "secret-tool", "clear", "service", Service, "account", name)
+	if err == nil && code != 0 {
+		err = fmt.Errorf("secret-tool clear exited with status %d", code)
+	}
+	return err
+}
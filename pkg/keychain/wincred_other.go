@@ -0,0 +1,14 @@
+//go:build !windows
+
+package keychain
+
+// windowsCredentials is the Windows Credential Manager, which only exists on Windows.
+type windowsCredentials struct{}
+
+func (windowsCredentials) Backend() string { return BackendWindows }
+
+func (windowsCredentials) Get(string) (string, error) { return "", ErrUnavailable }
+
+func (windowsCredentials) Set(string, string) error { return ErrUnavailable }
+
+func (windowsCredentials) Delete(string) error { return ErrUnavailable }
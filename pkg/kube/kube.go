@@ -0,0 +1,75 @@
+// Package kube runs MCP servers inside Kubernetes pods by piping stdio through kubectl exec.
+package kube
+
+import (
+	"errors"
+	"strings"
+)
+
+// Prefix marks a server command that runs inside a pod, e.g. "k8s:my-pod" or
+// "k8s:deploy/my-server".
+const Prefix = "k8s:"
+
+// DefaultKubectl is the kubectl binary used unless Options.Kubectl is set.
+const DefaultKubectl = "kubectl"
+
+// Errors returned when a k8s: server command is incomplete.
+var (
+	ErrMissingTarget  = errors.New("k8s: target is missing, use k8s:<pod> or k8s:<type>/<name>")
+	ErrMissingCommand = errors.New("k8s: the command to run in the pod is missing")
+)
+
+// Options selects where kubectl runs the server.
+type Options struct {
+	// Kubectl is the kubectl binary. Defaults to DefaultKubectl.
+	Kubectl string
+	// Context is the kubeconfig context. Defaults to the current context.
+	Context string
+	// Namespace is the namespace of the pod. Defaults to the context's namespace.
+	Namespace string
+	// Container is the container to exec into. Defaults to the pod's default container.
+	Container string
+}
+
+// IsTarget reports whether arg names a Kubernetes exec target.
+func IsTarget(arg string) bool {
+	return strings.HasPrefix(arg, Prefix)
+}
+
+// Command turns a server command of the form ["k8s:<target>", command, args...] into the
+// kubectl exec command line that runs it inside the pod with stdin attached. The target is
+// anything kubectl exec accepts: a pod name or a resource such as deploy/name.
+func Command(args []string, opts Options) (string, []string, error) {
+	if len(args) == 0 || !IsTarget(args[0]) {
+		return "", nil, ErrMissingTarget
+	}
+
+	target := strings.TrimPrefix(args[0], Prefix)
+	if target == "" {
+		return "", nil, ErrMissingTarget
+	}
+	if len(args) < 2 {
+		return "", nil, ErrMissingCommand
+	}
+
+	kubectl := opts.Kubectl
+	if kubectl == "" {
+		kubectl = DefaultKubectl
+	}
+
+	var kubectlArgs []string
+	if opts.Context != "" {
+		kubectlArgs = append(kubectlArgs, "--context", opts.Context)
+	}
+	if opts.Namespace != "" {
+		kubectlArgs = append(kubectlArgs, "--namespace", opts.Namespace)
+	}
+	kubectlArgs = append(kubectlArgs, "exec", "--stdin")
+	if opts.Container != "" {
+		kubectlArgs = append(kubectlArgs, "--container", opts.Container)
+	}
+	kubectlArgs = append(kubectlArgs, target, "--")
+	kubectlArgs = append(kubectlArgs, args[1:]...)
+
+	return kubectl, kubectlArgs, nil
+}
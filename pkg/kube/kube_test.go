@@ -0,0 +1,53 @@
+package kube
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		err      error
+		opts     Options
+		name     string
+		wantCmd  string
+		args     []string
+		wantArgs []string
+	}{
+		{
+			name:     "pod",
+			args:     []string{"k8s:mcp-0", "node", "server.js"},
+			wantCmd:  "kubectl",
+			wantArgs: []string{"exec", "--stdin", "mcp-0", "--", "node", "server.js"},
+		},
+		{
+			name:    "all options",
+			args:    []string{"k8s:deploy/mcp", "python", "-m", "server"},
+			opts:    Options{Kubectl: "/usr/local/bin/kubectl", Context: "prod", Namespace: "data", Container: "mcp"},
+			wantCmd: "/usr/local/bin/kubectl",
+			wantArgs: []string{"--context", "prod", "--namespace", "data", "exec", "--stdin", "--container", "mcp",
+				"deploy/mcp", "--", "python", "-m", "server"},
+		},
+		{name: "missing target", args: []string{"k8s:", "node"}, err: ErrMissingTarget},
+		{name: "missing command", args: []string{"k8s:mcp-0"}, err: ErrMissingCommand},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, args, err := Command(tt.args, tt.opts)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd != tt.wantCmd || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Command() = %s %v, want %s %v", cmd, args, tt.wantCmd, tt.wantArgs)
+			}
+		})
+	}
+}
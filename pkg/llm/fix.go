@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Fix is corrected parameters proposed for a tool call.
+type Fix struct {
+	Params      map[string]any `json:"params"`
+	Explanation string         `json:"explanation"`
+}
+
+const fixInstructions = `You fix the parameters of Model Context Protocol tool calls that a server rejected.
+Given the tool, its input schema, the parameters sent and the error, reply with only a JSON
+object {"params": {...}, "explanation": "..."}: params are the corrected parameters, keeping
+the values and intent of the original ones wherever possible, and explanation says in one
+sentence what was changed. Never invent values for required parameters the user gave no hint
+of; leave them out instead.`
+
+// FixParams asks the model for parameters that satisfy the input schema of a tool, given the
+// parameters a server rejected and the error it returned.
+func FixParams(ctx context.Context, completer Completer, tool, description string, schema, params map[string]any, problem string) (Fix, error) {
+	schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return Fix{}, err
+	}
+	paramsJSON, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return Fix{}, err
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Tool: %s\n", tool)
+	if description != "" {
+		fmt.Fprintf(&prompt, "Description: %s\n", description)
+	}
+	fmt.Fprintf(&prompt, "\nInput schema:\n%s\n\nParameters sent:\n%s\n\nError:\n%s\n", schemaJSON, paramsJSON, problem)
+
+	reply, err := completer.Complete(ctx, fixInstructions, prompt.String())
+	if err != nil {
+		return Fix{}, err
+	}
+	return parseFix(reply)
+}
+
+// parseFix decodes the reply of the model, which may wrap the JSON object in a code block.
+func parseFix(reply string) (Fix, error) {
+	text := strings.TrimSpace(reply)
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		text = text[start : end+1]
+	}
+
+	var fix Fix
+	if err := json.Unmarshal([]byte(text), &fix); err != nil {
+		return Fix{}, fmt.Errorf("the model did not reply with parameters: %w", err)
+	}
+	if fix.Params == nil {
+		return Fix{}, errors.New("the model did not reply with parameters")
+	}
+	return fix, nil
+}
@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeCompleter struct {
+	reply  string
+	prompt string
+}
+
+func (f *fakeCompleter) Complete(_ context.Context, _, prompt string) (string, error) {
+	f.prompt = prompt
+	return f.reply, nil
+}
+
+func TestFixParams(t *testing.T) {
+	completer := &fakeCompleter{reply: "Here you go:\n```json\n" +
+		`{"params": {"path": "/tmp/a.txt", "lines": 10}, "explanation": "lines must be a number"}` + "\n```"}
+	schema := map[string]any{"type": "object", "properties": map[string]any{"lines": map[string]any{"type": "number"}}}
+
+	fix, err := FixParams(context.Background(), completer, "read_file", "Reads a file", schema,
+		map[string]any{"path": "/tmp/a.txt", "lines": "10"}, "lines: expected number")
+	if err != nil {
+		t.Fatalf("FixParams() error = %v", err)
+	}
+	if fix.Params["lines"] != float64(10) || fix.Explanation != "lines must be a number" {
+		t.Errorf("FixParams() = %+v", fix)
+	}
+	for _, want := range []string{"read_file", `"type": "number"`, `"lines": "10"`, "lines: expected number"} {
+		if !strings.Contains(completer.prompt, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, completer.prompt)
+		}
+	}
+
+	completer.reply = "I can't help with that."
+	if _, err = FixParams(context.Background(), completer, "read_file", "", schema, nil, "error"); err == nil {
+		t.Error("FixParams() with a reply without JSON succeeded, want an error")
+	}
+}
+
+func TestClientComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.Model != "small" || len(body.Messages) != 2 || body.Messages[1].Content != "hi" {
+			t.Errorf("request = %+v", body)
+		}
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hello"}}]}`))
+	}))
+	defer server.Close()
+
+	client := &Client{Client: server.Client(), URL: server.URL, Model: "small", APIKey: "key"}
+	reply, err := client.Complete(context.Background(), "be brief", "hi")
+	if err != nil || reply != "hello" {
+		t.Errorf("Complete() = %q, %v", reply, err)
+	}
+}
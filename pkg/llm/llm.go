@@ -0,0 +1,119 @@
+// Package llm asks a language model behind an OpenAI-compatible chat completions endpoint for
+// help, such as proposing corrected parameters for a tool call a server rejected.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// default chat completions endpoint and model, used unless overridden by the environment.
+const (
+	DefaultURL   = "https://api.openai.com/v1/chat/completions"
+	DefaultModel = "gpt-4o-mini"
+)
+
+// Completer answers a prompt given instructions.
+type Completer interface {
+	Complete(ctx context.Context, system, prompt string) (string, error)
+}
+
+// Client completes prompts with an OpenAI-compatible /chat/completions endpoint. Local models
+// served by Ollama, llama.cpp or LM Studio expose the same API.
+type Client struct {
+	Client *http.Client
+	URL    string
+	Model  string
+	APIKey string
+}
+
+// NewFromEnv creates a client configured by MCPT_LLM_URL, MCPT_LLM_MODEL and MCPT_LLM_KEY,
+// falling back to OPENAI_API_KEY for the key.
+func NewFromEnv() *Client {
+	c := &Client{
+		Client: &http.Client{Timeout: 120 * time.Second},
+		URL:    os.Getenv("MCPT_LLM_URL"),
+		Model:  os.Getenv("MCPT_LLM_MODEL"),
+		APIKey: os.Getenv("MCPT_LLM_KEY"),
+	}
+
+	if c.URL == "" {
+		c.URL = DefaultURL
+	}
+	if c.Model == "" {
+		c.Model = DefaultModel
+	}
+	if c.APIKey == "" {
+		c.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	return c
+}
+
+// Complete implements Completer.
+func (c *Client) Complete(ctx context.Context, system, prompt string) (string, error) {
+	body, err := json.Marshal(map[string]any{
+		"model": c.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": system},
+			{"role": "user", "content": prompt},
+		},
+		"temperature": 0,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("completion request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("completion request failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode completion: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return "", errors.New("completion has no choices")
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+// Configured reports whether a model is configured in the environment, so optional features
+// can stay off rather than fail without one.
+func Configured() bool {
+	for _, name := range []string{"MCPT_LLM_URL", "MCPT_LLM_KEY", "OPENAI_API_KEY"} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
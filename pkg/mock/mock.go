@@ -38,7 +38,7 @@ type Server struct {
 	prompts   map[string]Prompt   // pointer (8 bytes)
 	resources map[string]Resource // pointer (8 bytes)
 	logFile   *os.File            // pointer (8 bytes)
-	id        int                 // int (8 bytes)
+	id        json.RawMessage     // slice (24 bytes)
 }
 
 // NewServer creates a new mock MCP server.
@@ -76,7 +76,6 @@ func NewServer() (*Server, error) {
 	fmt.Fprintf(os.Stderr, "Logging to %s\n", logPath)
 
 	return &Server{
-		id:        0,
 		tools:     make(map[string]Tool),
 		prompts:   make(map[string]Prompt),
 		resources: make(map[string]Resource),
@@ -151,10 +150,10 @@ func (s *Server) Start() error {
 	for {
 		// Request struct with fields ordered for optimal memory alignment
 		var request struct {
-			Method  string         `json:"method"`           // string (16 bytes: pointer + len)
-			Params  map[string]any `json:"params,omitempty"` // map (8 bytes)
-			JSONRPC string         `json:"jsonrpc"`          // string (16 bytes: pointer + len)
-			ID      int            `json:"id"`               // int (8 bytes)
+			Method  string          `json:"method"`           // string (16 bytes: pointer + len)
+			Params  map[string]any  `json:"params,omitempty"` // map (8 bytes)
+			JSONRPC string          `json:"jsonrpc"`          // string (16 bytes: pointer + len)
+			ID      json.RawMessage `json:"id,omitempty"`     // []byte (24 bytes)
 		}
 
 		fmt.Fprintf(os.Stderr, "Waiting for request...\n")
@@ -170,7 +169,7 @@ func (s *Server) Start() error {
 
 		// Log the incoming request
 		s.logJSON("Received request", request)
-		fmt.Fprintf(os.Stderr, "Received request: %s (ID: %d)\n", request.Method, request.ID)
+		fmt.Fprintf(os.Stderr, "Received request: %s (ID: %s)\n", request.Method, string(request.ID))
 		s.id = request.ID
 
 		// Handle notifications (methods without an ID)
@@ -186,6 +185,8 @@ func (s *Server) Start() error {
 		switch request.Method {
 		case "initialize":
 			response = s.handleInitialize(request.Params)
+		case "ping":
+			response = map[string]any{}
 		case "tools/list":
 			response = s.handleToolsList()
 		case "tools/call":
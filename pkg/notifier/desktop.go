@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// desktopCommand returns the command that shows a desktop notification on this system.
+var desktopCommand = func(title, message string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		// Passed as arguments rather than spliced into the script, so they need no quoting
+		return exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message), nil
+	case "windows":
+		return nil, fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	default:
+		path, err := exec.LookPath("notify-send")
+		if err != nil {
+			return nil, fmt.Errorf("desktop notifications need notify-send: %w", err)
+		}
+		return exec.Command(path, "--app-name=mcp", title, message), nil
+	}
+}
+
+// Desktop shows a desktop notification, with osascript on macOS and notify-send elsewhere.
+func Desktop(title, message string) error {
+	cmd, err := desktopCommand(title, message)
+	if err != nil {
+		return err
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("desktop notification failed: %w: %s", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package notifier
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestDesktop(t *testing.T) {
+	original := desktopCommand
+	defer func() { desktopCommand = original }()
+
+	var got []string
+	desktopCommand = func(title, message string) (*exec.Cmd, error) {
+		got = []string{title, message}
+		return exec.Command("true"), nil
+	}
+	if err := Desktop("mcp call build finished", "Took 42s"); err != nil {
+		t.Fatalf("Desktop() error = %v", err)
+	}
+	if strings.Join(got, "|") != "mcp call build finished|Took 42s" {
+		t.Errorf("notified %v", got)
+	}
+
+	desktopCommand = func(string, string) (*exec.Cmd, error) {
+		return exec.Command("sh", "-c", "echo no display >&2; exit 1"), nil
+	}
+	if err := Desktop("title", "message"); err == nil || !strings.Contains(err.Error(), "no display") {
+		t.Errorf("Desktop() error = %v, want the command's output", err)
+	}
+}
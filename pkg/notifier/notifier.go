@@ -0,0 +1,165 @@
+// Package notifier posts messages about events, such as a workflow run finishing, to Slack
+// incoming webhooks and other HTTP endpoints.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Kinds of events.
+const (
+	WorkflowCompleted = "workflow.completed"
+	WorkflowFailed    = "workflow.failed"
+)
+
+// DefaultTemplate is the message posted for an event unless another template is given.
+const DefaultTemplate = `{{ if eq .Kind "workflow.failed" }}Workflow {{ .Workflow }}{{ with .Label }} ({{ . }}){{ end }} failed after {{ .Steps }} steps: {{ .Error }}` +
+	`{{ else }}Workflow {{ .Workflow }}{{ with .Label }} ({{ . }}){{ end }} completed {{ .Steps }} steps{{ end }}`
+
+// Event is something worth notifying about. Templates refer to its fields, e.g. {{ .Workflow }}.
+type Event struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Workflow string    `json:"workflow,omitempty"`
+	Run      string    `json:"run,omitempty"`
+	Label    string    `json:"label,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Steps    int       `json:"steps"`
+}
+
+// Target is an endpoint events are posted to.
+type Target struct {
+	URL string
+	// Slack targets are posted {"text": message}, the payload of Slack incoming webhooks.
+	// Other targets are posted the event as JSON, with the message in its message field.
+	Slack bool
+}
+
+// ParseTarget parses a target: an http or https URL, optionally prefixed with slack: to post
+// in the format of Slack incoming webhooks. URLs on hooks.slack.com are always posted that way.
+func ParseTarget(spec string) (Target, error) {
+	target := Target{URL: spec}
+	if rest, ok := strings.CutPrefix(spec, "slack:"); ok {
+		target = Target{URL: rest, Slack: true}
+	}
+
+	u, err := url.Parse(target.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Target{}, fmt.Errorf("invalid notification target %q: expected an http or https URL", spec)
+	}
+	if u.Hostname() == "hooks.slack.com" {
+		target.Slack = true
+	}
+	return target, nil
+}
+
+// Notifier posts events to its targets.
+type Notifier struct {
+	targets  []Target
+	template *template.Template
+	client   *http.Client
+}
+
+// New creates a notifier posting to targets, with messages rendered by the text/template text,
+// or DefaultTemplate if text is empty.
+func New(targets []Target, text string) (*Notifier, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("message").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification template: %w", err)
+	}
+	// Rendered once up front, so templates referring to unknown fields fail now, not on the event
+	if err = tmpl.Execute(io.Discard, Event{}); err != nil {
+		return nil, fmt.Errorf("invalid notification template: %w", err)
+	}
+	return &Notifier{targets: targets, template: tmpl, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Message renders the message of an event.
+func (n *Notifier) Message(event Event) (string, error) {
+	var buf bytes.Buffer
+	if err := n.template.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("failed to render notification: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Notify posts an event to every target. It returns the errors of the targets it could not
+// post to, after trying all of them.
+func (n *Notifier) Notify(ctx context.Context, event Event) error {
+	if n == nil || len(n.targets) == 0 {
+		return nil
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	message, err := n.Message(event)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, target := range n.targets {
+		if err = n.post(ctx, target, event, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) post(ctx context.Context, target Target, event Event, message string) error {
+	var payload any = map[string]string{"text": message}
+	if !target.Slack {
+		payload = struct {
+			Event
+			Message string `json:"message"`
+		}{event, message}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("notification to %s failed: %w", redact(target.URL), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("notification to %s failed: %s: %s", redact(target.URL), resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// redact returns the host of a webhook URL; the path of webhooks such as Slack's is a secret.
+func redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "webhook"
+	}
+	return u.Host
+}
@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		spec  string
+		url   string
+		slack bool
+	}{
+		{"https://example.com/hook", "https://example.com/hook", false},
+		{"https://hooks.slack.com/services/T0/B0/X", "https://hooks.slack.com/services/T0/B0/X", true},
+		{"slack:https://chat.example.com/hooks/abc", "https://chat.example.com/hooks/abc", true},
+	}
+	for _, tt := range tests {
+		target, err := ParseTarget(tt.spec)
+		if err != nil || target.URL != tt.url || target.Slack != tt.slack {
+			t.Errorf("ParseTarget(%q) = %+v, %v, want %s (slack %v)", tt.spec, target, err, tt.url, tt.slack)
+		}
+	}
+
+	for _, invalid := range []string{"example.com/hook", "ftp://example.com", "slack:"} {
+		if _, err := ParseTarget(invalid); err == nil {
+			t.Errorf("ParseTarget(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var slack, webhook map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := &webhook
+		if r.URL.Path == "/slack" {
+			target = &slack
+		}
+		_ = json.NewDecoder(r.Body).Decode(target)
+	}))
+	defer server.Close()
+
+	n, err := New([]Target{{URL: server.URL + "/slack", Slack: true}, {URL: server.URL + "/hook"}}, "")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	event := Event{Kind: WorkflowFailed, Workflow: "deploy.yaml", Label: "region=eu", Steps: 2, Error: "step rollout: timeout"}
+	if err = n.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	want := "Workflow deploy.yaml (region=eu) failed after 2 steps: step rollout: timeout"
+	if slack["text"] != want {
+		t.Errorf("Slack was posted %v, want text %q", slack, want)
+	}
+	if webhook["message"] != want || webhook["kind"] != WorkflowFailed || webhook["label"] != "region=eu" {
+		t.Errorf("webhook was posted %v", webhook)
+	}
+}
+
+func TestNotifyTemplate(t *testing.T) {
+	if _, err := New(nil, "{{ .Nope }}"); err == nil {
+		t.Error("New() with an unknown field succeeded, want an error")
+	}
+
+	n, err := New(nil, "{{ .Workflow }}: {{ .Kind }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message, _ := n.Message(Event{Kind: WorkflowCompleted, Workflow: "wf.yaml"}); message != "wf.yaml: workflow.completed" {
+		t.Errorf("Message() = %q", message)
+	}
+}
+
+func TestNotifyReportsFailedTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer server.Close()
+
+	n, _ := New([]Target{{URL: server.URL + "/services/secret"}}, "")
+	err := n.Notify(context.Background(), Event{Kind: WorkflowCompleted})
+	if err == nil || !strings.Contains(err.Error(), "410") {
+		t.Fatalf("Notify() error = %v, want the failed post", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("Notify() error %q reveals the webhook path", err)
+	}
+}
@@ -0,0 +1,161 @@
+// Package notify buffers server notifications between a read loop that must never block and
+// consumers that may be slow.
+//
+// A Queue holds a bounded number of notifications. Notifications that supersede each other,
+// such as updates of the same resource, replace the queued one instead of taking another slot,
+// and once the queue is full the oldest notification is dropped.
+package notify
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// DefaultSize is the number of notifications a queue holds by default.
+const DefaultSize = 256
+
+// queued is a notification waiting in a queue.
+type queued[T any] struct {
+	item T
+	key  string
+}
+
+// Queue is a bounded FIFO of notifications. Push never blocks.
+type Queue[T any] struct {
+	items   []queued[T]
+	ready   chan struct{}
+	size    int
+	dropped int64
+	closed  bool
+	mu      sync.Mutex
+}
+
+// NewQueue creates a queue holding up to size notifications, or DefaultSize if size is not
+// positive.
+func NewQueue[T any](size int) *Queue[T] {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Queue[T]{ready: make(chan struct{}, 1), size: size}
+}
+
+// Push adds item to the queue. If key is not empty and an item with the same key is queued,
+// that item is replaced in place. Otherwise, if the queue is full, the oldest item is dropped.
+// Push returns the number of items dropped, 0 or 1.
+func (q *Queue[T]) Push(key string, item T) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return 0
+	}
+
+	dropped := 0
+	replaced := false
+	if key != "" {
+		for i := range q.items {
+			if q.items[i].key == key {
+				q.items[i].item = item
+				replaced = true
+				break
+			}
+		}
+	}
+	if !replaced {
+		if len(q.items) >= q.size {
+			q.items = q.items[1:]
+			q.dropped++
+			dropped = 1
+		}
+		q.items = append(q.items, queued[T]{item: item, key: key})
+	}
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return dropped
+}
+
+// Pop removes and returns the oldest item, waiting for one if the queue is empty. It returns
+// false once ctx is done or the queue is closed and empty.
+func (q *Queue[T]) Pop(ctx context.Context) (T, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			item := q.items[0].item
+			q.items = q.items[1:]
+			q.mu.Unlock()
+			return item, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+
+		var zero T
+		if closed {
+			return zero, false
+		}
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return zero, false
+		}
+	}
+}
+
+// Len returns the number of queued items.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Dropped returns the number of items dropped because the queue was full.
+func (q *Queue[T]) Dropped() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.dropped
+}
+
+// Close stops the queue from accepting items. Pop returns the items already queued, then false.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.ready)
+	}
+}
+
+// Key returns the key under which an MCP notification supersedes earlier ones, or "" if every
+// notification of its kind matters. Only the latest update of a resource, the latest progress
+// of a request and one list change of each kind are kept.
+func Key(method string, params map[string]any) string {
+	switch method {
+	case "notifications/resources/updated":
+		if uri, ok := params["uri"].(string); ok {
+			return method + " " + uri
+		}
+	case "notifications/progress":
+		if token := fmtToken(params["progressToken"]); token != "" {
+			return method + " " + token
+		}
+	case "notifications/tools/list_changed", "notifications/prompts/list_changed",
+		"notifications/resources/list_changed", "notifications/roots/list_changed":
+		return method
+	}
+	return ""
+}
+
+// fmtToken formats a progress token, which is a string or a number, or returns "" for
+// anything else.
+func fmtToken(token any) string {
+	switch t := token.(type) {
+	case string:
+		return "s:" + t
+	case float64:
+		return "n:" + strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return ""
+}
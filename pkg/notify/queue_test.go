@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueueCompactsAndDrops(t *testing.T) {
+	q := NewQueue[string](3)
+
+	q.Push("", "log 1")
+	q.Push("res a", "a v1")
+	q.Push("res b", "b v1")
+	if dropped := q.Push("res a", "a v2"); dropped != 0 {
+		t.Errorf("replacing a queued update dropped %d items", dropped)
+	}
+	if dropped := q.Push("", "log 2"); dropped != 1 {
+		t.Errorf("pushing to a full queue dropped %d items, want 1", dropped)
+	}
+
+	want := []string{"a v2", "b v1", "log 2"}
+	for _, w := range want {
+		got, ok := q.Pop(context.Background())
+		if !ok || got != w {
+			t.Fatalf("Pop() = %q, %v, want %q", got, ok, w)
+		}
+	}
+	if q.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", q.Dropped())
+	}
+}
+
+func TestQueuePopWaits(t *testing.T) {
+	q := NewQueue[int](0)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Push("", 1)
+		q.Close()
+	}()
+
+	if got, ok := q.Pop(context.Background()); !ok || got != 1 {
+		t.Errorf("Pop() = %d, %v, want 1", got, ok)
+	}
+	if _, ok := q.Pop(context.Background()); ok {
+		t.Error("Pop() on a closed, empty queue returned an item")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok := NewQueue[int](1).Pop(ctx); ok {
+		t.Error("Pop() with a canceled context returned an item")
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		method string
+		params map[string]any
+		want   string
+	}{
+		{"notifications/resources/updated", map[string]any{"uri": "file:///a"}, "notifications/resources/updated file:///a"},
+		{"notifications/progress", map[string]any{"progressToken": float64(7)}, "notifications/progress n:7"},
+		{"notifications/progress", map[string]any{"progressToken": "7"}, "notifications/progress s:7"},
+		{"notifications/tools/list_changed", nil, "notifications/tools/list_changed"},
+		{"notifications/message", map[string]any{"level": "info"}, ""},
+	}
+	for _, tt := range tests {
+		if got := Key(tt.method, tt.params); got != tt.want {
+			t.Errorf("Key(%s, %v) = %q, want %q", tt.method, tt.params, got, tt.want)
+		}
+	}
+}
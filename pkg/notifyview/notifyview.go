@@ -0,0 +1,157 @@
+/*
+Package notifyview keeps the notifications a server sent during a session, and shows them in a
+full-screen pane of the shell that follows them live, filtered by kind and logging level.
+*/
+package notifyview
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity is the number of notifications a Log keeps by default.
+const DefaultCapacity = 2000
+
+// Kind groups notifications for filtering.
+type Kind int
+
+// Kinds of notifications.
+const (
+	KindLog Kind = iota
+	KindProgress
+	KindListChanged
+	KindOther
+)
+
+// kindNames names the kinds in the pane.
+var kindNames = [...]string{KindLog: "log", KindProgress: "progress", KindListChanged: "list_changed", KindOther: "other"}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Levels are the logging levels of MCP, from the least to the most severe.
+var Levels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// LevelIndex returns the position of level in Levels, or -1 if it is not one.
+func LevelIndex(level string) int {
+	for i, l := range Levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Entry is a notification received from the server.
+type Entry struct {
+	Time   time.Time
+	Method string
+	Kind   Kind
+	// Level is the logging level of log messages.
+	Level string
+	// Text describes the notification in a line.
+	Text string
+}
+
+// NewEntry describes the notification method with params received at t.
+func NewEntry(method string, params map[string]any, t time.Time) Entry {
+	entry := Entry{Time: t, Method: method, Kind: KindOther}
+
+	switch {
+	case method == "notifications/message":
+		entry.Kind = KindLog
+		entry.Level, _ = params["level"].(string)
+		text := formatValue(params["data"])
+		if logger, _ := params["logger"].(string); logger != "" {
+			text = logger + ": " + text
+		}
+		entry.Text = text
+	case method == "notifications/progress":
+		entry.Kind = KindProgress
+		text := fmt.Sprintf("%v: %v", formatValue(params["progressToken"]), formatValue(params["progress"]))
+		if total, ok := params["total"]; ok {
+			text += "/" + formatValue(total)
+		}
+		if message, _ := params["message"].(string); message != "" {
+			text += " " + message
+		}
+		entry.Text = text
+	case strings.HasSuffix(method, "/list_changed"):
+		entry.Kind = KindListChanged
+		list := strings.TrimSuffix(strings.TrimPrefix(method, "notifications/"), "/list_changed")
+		entry.Text = list + " changed"
+	case method == "notifications/resources/updated":
+		uri, _ := params["uri"].(string)
+		entry.Text = "resource updated: " + uri
+	default:
+		entry.Text = method
+		if len(params) > 0 {
+			entry.Text += " " + formatValue(params)
+		}
+	}
+	return entry
+}
+
+// formatValue writes strings as they are and everything else as JSON.
+func formatValue(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// Log keeps the latest notifications of a session. It is safe for concurrent use: servers
+// send notifications while the pane shows them.
+type Log struct {
+	mu       sync.Mutex
+	entries  []Entry
+	capacity int
+	// dropped counts the entries forgotten to stay within capacity.
+	dropped int
+	changed chan struct{}
+}
+
+// NewLog creates a log keeping up to capacity notifications, or DefaultCapacity if it is not
+// positive.
+func NewLog(capacity int) *Log {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Log{capacity: capacity, changed: make(chan struct{}, 1)}
+}
+
+// Add records a notification, forgetting the oldest one if the log is full.
+func (l *Log) Add(entry Entry) {
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	if over := len(l.entries) - l.capacity; over > 0 {
+		l.entries = append(l.entries[:0:0], l.entries[over:]...)
+		l.dropped += over
+	}
+	l.mu.Unlock()
+
+	select {
+	case l.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Entries returns the notifications kept, oldest first, and the number received in total.
+func (l *Log) Entries() ([]Entry, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.entries...), l.dropped + len(l.entries)
+}
+
+// Changed receives a value after notifications were added.
+func (l *Log) Changed() <-chan struct{} {
+	return l.changed
+}
@@ -0,0 +1,138 @@
+package notifyview
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+
+func TestNewEntry(t *testing.T) {
+	tests := []struct {
+		method string
+		params map[string]any
+		kind   Kind
+		text   string
+	}{
+		{"notifications/message", map[string]any{"level": "info", "logger": "db", "data": "connected"}, KindLog, "db: connected"},
+		{"notifications/message", map[string]any{"level": "error", "data": map[string]any{"code": 5.0}}, KindLog, `{"code":5}`},
+		{"notifications/progress", map[string]any{"progressToken": "t1", "progress": 3.0, "total": 10.0, "message": "indexing"}, KindProgress, "t1: 3/10 indexing"},
+		{"notifications/tools/list_changed", nil, KindListChanged, "tools changed"},
+		{"notifications/resources/updated", map[string]any{"uri": "file:///a"}, KindOther, "resource updated: file:///a"},
+		{"notifications/custom", map[string]any{"a": 1.0}, KindOther, `notifications/custom {"a":1}`},
+	}
+	for _, tt := range tests {
+		entry := NewEntry(tt.method, tt.params, start)
+		if entry.Kind != tt.kind || entry.Text != tt.text {
+			t.Errorf("NewEntry(%s) = %v %q, want %v %q", tt.method, entry.Kind, entry.Text, tt.kind, tt.text)
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	log := NewLog(3)
+	for i := range 5 {
+		log.Add(Entry{Time: start, Text: strings.Repeat("x", i+1)})
+	}
+	entries, total := log.Entries()
+	if len(entries) != 3 || entries[0].Text != "xxx" || total != 5 {
+		t.Errorf("Entries() = %d entries from %q, %d in total, want the last 3 of 5", len(entries), entries[0].Text, total)
+	}
+	select {
+	case <-log.Changed():
+	default:
+		t.Error("Changed() did not signal added entries")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	entries := []Entry{
+		{Kind: KindLog, Level: "debug", Text: "a"},
+		{Kind: KindLog, Level: "warning", Text: "b"},
+		{Kind: KindLog, Level: "verbose", Text: "c"},
+		{Kind: KindProgress, Text: "d"},
+		{Kind: KindListChanged, Text: "e"},
+	}
+	filter := Filter{MinLevel: LevelIndex("info"), Hidden: map[Kind]bool{KindProgress: true}}
+	var texts []string
+	for _, entry := range filter.Apply(entries) {
+		texts = append(texts, entry.Text)
+	}
+	if got := strings.Join(texts, ""); got != "bce" {
+		t.Errorf("Apply() kept %q, want bce", got)
+	}
+}
+
+func TestPane(t *testing.T) {
+	log := NewLog(0)
+	for i := range 20 {
+		log.Add(NewEntry("notifications/message", map[string]any{"level": "info", "data": "line " + string(rune('a'+i))}, start))
+	}
+	pane := NewPane(log, Filter{})
+
+	screen := pane.Render(80, 6)
+	lines := strings.Split(screen, "\r\n")
+	if len(lines) != 6 || !strings.Contains(lines[0], "Notifications 20/20") || !strings.HasSuffix(lines[4], "line t") {
+		t.Fatalf("Render() did not follow the tail:\n%s", screen)
+	}
+
+	// Scrolled back, the view stays in place as more arrive
+	pane.Handle(KeyUp)
+	pane.Render(80, 6)
+	log.Add(NewEntry("notifications/message", map[string]any{"level": "info", "data": "new"}, start))
+	lines = strings.Split(pane.Render(80, 6), "\r\n")
+	if !strings.HasSuffix(lines[4], "line s") || !strings.Contains(lines[0], "[2 back]") {
+		t.Errorf("Render() scrolled back moved:\n%s", strings.Join(lines, "\n"))
+	}
+	pane.Handle(KeyEnd)
+
+	// Paused, new notifications are not shown
+	pane.Handle(KeyPause)
+	log.Add(NewEntry("notifications/progress", map[string]any{"progressToken": "t", "progress": 1.0}, start))
+	screen = pane.Render(80, 6)
+	if strings.Contains(screen, "t: 1") || !strings.Contains(screen, "[paused]") {
+		t.Errorf("Render() while paused:\n%s", screen)
+	}
+	pane.Handle(KeyPause)
+	if screen = pane.Render(80, 6); !strings.Contains(screen, "t: 1") {
+		t.Errorf("Render() after resuming did not show the latest:\n%s", screen)
+	}
+
+	pane.Handle(KeyToggleProgress)
+	pane.Handle(KeyMoreSevere)
+	pane.Handle(KeyMoreSevere)
+	if filter := pane.Filter(); !filter.Hidden[KindProgress] || Levels[filter.MinLevel] != "notice" {
+		t.Errorf("Filter() = %+v, want progress hidden from notice up", filter)
+	}
+	if screen = pane.Render(80, 6); !strings.Contains(screen, "No notifications yet") || !strings.Contains(screen, "level ≥ notice") {
+		t.Errorf("Render() with everything filtered out:\n%s", screen)
+	}
+	if pane.Handle(KeyQuit) {
+		t.Error("Handle(KeyQuit) kept running")
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("\x1b[5~\x1b[6~ +lG"))
+	want := []Key{KeyPageUp, KeyPageDown, KeyPause, KeyMoreSevere, KeyToggleLog, KeyEnd}
+	for _, w := range want {
+		if key, err := ReadKey(r); err != nil || key != w {
+			t.Errorf("ReadKey() = %v, %v, want %v", key, err, w)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	log := NewLog(0)
+	log.Add(NewEntry("notifications/tools/list_changed", nil, start))
+	var out strings.Builder
+	pane := NewPane(log, Filter{})
+	if err := pane.Run(strings.NewReader("jq"), &out, func() (int, int) { return 80, 5 }); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "tools changed") || !strings.HasSuffix(out.String(), "\x1b[?1049l") {
+		t.Errorf("Run() wrote %q", out.String())
+	}
+}
@@ -0,0 +1,375 @@
+package notifyview
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Key is a key pressed in the pane.
+type Key int
+
+// Keys the pane responds to.
+const (
+	KeyNone Key = iota
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyPause
+	KeyMoreSevere
+	KeyLessSevere
+	KeyToggleLog
+	KeyToggleProgress
+	KeyToggleListChanged
+	KeyToggleOther
+	KeyQuit
+)
+
+// ReadKey reads a key press from a terminal in raw mode. Arrow and page keys arrive as escape
+// sequences; j and k scroll like arrows, g and G go to the top and the tail, space pauses, + and
+// - change the level shown, l, p, c and o toggle kinds, and q, Escape and Ctrl-C quit.
+func ReadKey(r *bufio.Reader) (Key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return KeyNone, err
+	}
+
+	switch b {
+	case 0x1b:
+		if r.Buffered() == 0 {
+			return KeyQuit, nil
+		}
+		next, _ := r.ReadByte()
+		if next != '[' && next != 'O' {
+			return KeyNone, nil
+		}
+		code, _ := r.ReadByte()
+		switch code {
+		case 'A':
+			return KeyUp, nil
+		case 'B':
+			return KeyDown, nil
+		case 'H':
+			return KeyHome, nil
+		case 'F':
+			return KeyEnd, nil
+		}
+		// Page keys are "\x1b[5~" and "\x1b[6~"; skip the rest of other sequences
+		number := code
+		for code >= '0' && code <= '9' && r.Buffered() > 0 {
+			code, _ = r.ReadByte()
+		}
+		switch {
+		case code == '~' && number == '5':
+			return KeyPageUp, nil
+		case code == '~' && number == '6':
+			return KeyPageDown, nil
+		}
+		return KeyNone, nil
+	case 'k':
+		return KeyUp, nil
+	case 'j':
+		return KeyDown, nil
+	case 'b':
+		return KeyPageUp, nil
+	case 'f':
+		return KeyPageDown, nil
+	case 'g':
+		return KeyHome, nil
+	case 'G':
+		return KeyEnd, nil
+	case ' ':
+		return KeyPause, nil
+	case '+', '=':
+		return KeyMoreSevere, nil
+	case '-':
+		return KeyLessSevere, nil
+	case 'l':
+		return KeyToggleLog, nil
+	case 'p':
+		return KeyToggleProgress, nil
+	case 'c':
+		return KeyToggleListChanged, nil
+	case 'o':
+		return KeyToggleOther, nil
+	case 'q', 0x03:
+		return KeyQuit, nil
+	}
+	return KeyNone, nil
+}
+
+// Filter selects the notifications shown.
+type Filter struct {
+	// MinLevel is the index in Levels of the least severe log message shown. Messages with a
+	// level that is not in Levels are always shown.
+	MinLevel int
+	// Hidden holds the kinds not shown.
+	Hidden map[Kind]bool
+}
+
+// Match reports whether the filter shows entry.
+func (f Filter) Match(entry Entry) bool {
+	if f.Hidden[entry.Kind] {
+		return false
+	}
+	if entry.Kind == KindLog {
+		if level := LevelIndex(entry.Level); level >= 0 && level < f.MinLevel {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the entries the filter shows.
+func (f Filter) Apply(entries []Entry) []Entry {
+	var shown []Entry
+	for _, entry := range entries {
+		if f.Match(entry) {
+			shown = append(shown, entry)
+		}
+	}
+	return shown
+}
+
+// Pane is the state of the notification pane: the filter, whether it is paused, and how far it
+// is scrolled back from the latest notification.
+type Pane struct {
+	log    *Log
+	filter Filter
+
+	// paused holds the notifications shown when the pane was paused, and is nil otherwise
+	paused []Entry
+	// back is the number of lines scrolled back from the tail, 0 when following it
+	back int
+	// shown is the number of entries shown at the last render, to keep the view in place when
+	// scrolled back as more arrive
+	shown int
+	// total is the number of notifications received at the last render
+	total int
+	// page is the number of lines of entries at the last render
+	page int
+}
+
+// NewPane creates a pane showing the notifications of log through filter.
+func NewPane(log *Log, filter Filter) *Pane {
+	if filter.Hidden == nil {
+		filter.Hidden = map[Kind]bool{}
+	}
+	return &Pane{log: log, filter: filter, page: 10}
+}
+
+// Filter returns the current filter of the pane.
+func (p *Pane) Filter() Filter {
+	return p.filter
+}
+
+// Paused reports whether the pane stopped following new notifications.
+func (p *Pane) Paused() bool {
+	return p.paused != nil
+}
+
+// Handle applies a key press and reports whether the pane should keep running.
+func (p *Pane) Handle(key Key) bool {
+	switch key {
+	case KeyQuit:
+		return false
+	case KeyUp:
+		p.back++
+	case KeyDown:
+		p.back = max(p.back-1, 0)
+	case KeyPageUp:
+		p.back += p.page
+	case KeyPageDown:
+		p.back = max(p.back-p.page, 0)
+	case KeyHome:
+		p.back = p.shown
+	case KeyEnd:
+		p.back = 0
+	case KeyPause:
+		if p.paused != nil {
+			p.paused = nil
+		} else {
+			entries, total := p.log.Entries()
+			p.paused, p.total = entries, total
+		}
+	case KeyMoreSevere:
+		p.filter.MinLevel = min(p.filter.MinLevel+1, len(Levels)-1)
+	case KeyLessSevere:
+		p.filter.MinLevel = max(p.filter.MinLevel-1, 0)
+	case KeyToggleLog:
+		p.toggle(KindLog)
+	case KeyToggleProgress:
+		p.toggle(KindProgress)
+	case KeyToggleListChanged:
+		p.toggle(KindListChanged)
+	case KeyToggleOther:
+		p.toggle(KindOther)
+	}
+	return true
+}
+
+func (p *Pane) toggle(kind Kind) {
+	p.filter.Hidden[kind] = !p.filter.Hidden[kind]
+	// The entries shown change, so the place in them is lost
+	p.back, p.shown = 0, 0
+}
+
+// entries returns the notifications to show, frozen while paused.
+func (p *Pane) entries() []Entry {
+	if p.paused != nil {
+		return p.filter.Apply(p.paused)
+	}
+	entries, total := p.log.Entries()
+	p.total = total
+	return p.filter.Apply(entries)
+}
+
+// helpLine lists the keys at the bottom of the screen.
+const helpLine = "↑/↓ PgUp/PgDn scroll  G tail  space pause  +/- level  l/p/c/o kinds  q quit"
+
+// Render draws the pane on a screen of width by height: a header with the filter, the
+// notifications, oldest at the top, and the keys at the bottom. Lines are separated by "\r\n"
+// for terminals in raw mode.
+func (p *Pane) Render(width, height int) string {
+	if width < 20 {
+		width = 20
+	}
+	if height < 4 {
+		height = 4
+	}
+	p.page = height - 2
+
+	entries := p.entries()
+	if p.back > 0 && len(entries) > p.shown {
+		// Keep the same notifications in view while scrolled back
+		p.back += len(entries) - p.shown
+	}
+	p.shown = len(entries)
+	p.back = min(p.back, max(len(entries)-p.page, 0))
+
+	end := len(entries) - p.back
+	start := max(end-p.page, 0)
+	lines := make([]string, 0, height)
+	lines = append(lines, truncate(p.header(len(entries)), width))
+	for _, entry := range entries[start:end] {
+		lines = append(lines, p.row(entry, width))
+	}
+	if len(entries) == 0 {
+		lines = append(lines, "No notifications yet")
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, truncate(helpLine, width))
+	return strings.Join(lines, "\r\n")
+}
+
+// header describes the filter and the state of the pane.
+func (p *Pane) header(shown int) string {
+	var kinds []string
+	for kind := KindLog; kind <= KindOther; kind++ {
+		if !p.filter.Hidden[kind] {
+			kinds = append(kinds, kind.String())
+		}
+	}
+	if len(kinds) == 0 {
+		kinds = append(kinds, "none")
+	}
+	// The state goes first so narrow terminals still show it
+	header := fmt.Sprintf("Notifications %d/%d", shown, p.total)
+	switch {
+	case p.paused != nil:
+		header += " [paused]"
+	case p.back > 0:
+		header += fmt.Sprintf(" [%d back]", p.back)
+	}
+	return header + fmt.Sprintf("  level ≥ %s  showing %s", Levels[p.filter.MinLevel], strings.Join(kinds, ", "))
+}
+
+// row renders an entry, highlighting warnings and worse.
+func (p *Pane) row(entry Entry, width int) string {
+	line := truncate(strings.ReplaceAll(Format(entry), "\n", " "), width)
+	if entry.Kind == KindLog && LevelIndex(entry.Level) >= LevelIndex("warning") {
+		return "\x1b[1m" + line + "\x1b[0m"
+	}
+	return line
+}
+
+// Format writes an entry on a line, the way the pane shows it without highlighting.
+func Format(entry Entry) string {
+	label := entry.Kind.String()
+	if entry.Kind == KindLog && entry.Level != "" {
+		label = entry.Level
+	}
+	return fmt.Sprintf("%s %-9s %s", entry.Time.Local().Format("15:04:05"), label, entry.Text)
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// refreshInterval is how often the pane redraws when nothing happens, to follow the size of
+// the terminal.
+const refreshInterval = time.Second
+
+// Run shows the pane on out until the user quits, reading keys from in, which must be a
+// terminal in raw mode, and redrawing as notifications arrive. size returns the current size of
+// the terminal.
+func (p *Pane) Run(in io.Reader, out io.Writer, size func() (int, int)) error {
+	type keyPress struct {
+		key Key
+		err error
+	}
+	// Keys are read one at a time on request, so no read is left waiting on in after the pane
+	// quits, which would take the next key from the shell
+	reader := bufio.NewReader(in)
+	requests := make(chan struct{})
+	keys := make(chan keyPress)
+	go func() {
+		for range requests {
+			key, err := ReadKey(reader)
+			keys <- keyPress{key, err}
+		}
+	}()
+	defer close(requests)
+
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	requests <- struct{}{}
+	for {
+		width, height := size()
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+p.Render(width, height))
+
+		select {
+		case press := <-keys:
+			if errors.Is(press.err, io.EOF) {
+				return nil
+			}
+			if press.err != nil {
+				return press.err
+			}
+			if !p.Handle(press.key) {
+				return nil
+			}
+			requests <- struct{}{}
+		case <-p.log.Changed():
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package npipe
+
+import (
+	"context"
+	"io"
+)
+
+// dial fails, as named pipes only exist on Windows.
+func dial(context.Context, Address) (io.ReadWriteCloser, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,108 @@
+//go:build windows
+
+package npipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// retryInterval is how often connecting tries again while the pipe is missing or busy.
+const retryInterval = 50 * time.Millisecond
+
+// impersonationLevels are the CreateFile flags of the impersonation levels.
+var impersonationLevels = map[string]uint32{
+	ImpersonationAnonymous:      windows.SECURITY_ANONYMOUS,
+	ImpersonationIdentification: windows.SECURITY_IDENTIFICATION,
+	ImpersonationImpersonation:  windows.SECURITY_IMPERSONATION,
+	ImpersonationDelegation:     windows.SECURITY_DELEGATION,
+}
+
+// dial opens the client end of the pipe, waiting up to the timeout of addr while the pipe does
+// not exist yet or all its instances are busy, and checks who owns it.
+func dial(ctx context.Context, addr Address) (io.ReadWriteCloser, error) {
+	path, err := windows.UTF16PtrFromString(addr.Path)
+	if err != nil {
+		return nil, err
+	}
+	flags := uint32(windows.SECURITY_SQOS_PRESENT) | impersonationLevels[addr.Impersonation]
+
+	deadline := time.Now().Add(addr.Timeout)
+	for {
+		handle, openErr := windows.CreateFile(path, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil,
+			windows.OPEN_EXISTING, flags, 0)
+		if openErr == nil {
+			if ownerErr := checkOwner(handle, addr.Owner); ownerErr != nil {
+				_ = windows.CloseHandle(handle)
+				return nil, ownerErr
+			}
+			return &pipe{File: os.NewFile(uintptr(handle), addr.Path), handle: handle}, nil
+		}
+		if !errors.Is(openErr, windows.ERROR_PIPE_BUSY) && !errors.Is(openErr, windows.ERROR_FILE_NOT_FOUND) {
+			return nil, openErr
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out after %s: %w", addr.Timeout, openErr)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// checkOwner returns an error unless the pipe is owned by owner: a SID, or OwnerSelf for the
+// current user. An empty owner accepts any.
+func checkOwner(handle windows.Handle, owner string) error {
+	if owner == "" {
+		return nil
+	}
+
+	var want *windows.SID
+	if owner == OwnerSelf {
+		user, err := windows.GetCurrentProcessToken().GetTokenUser()
+		if err != nil {
+			return fmt.Errorf("failed to look up the current user: %w", err)
+		}
+		want = user.User.Sid
+	} else {
+		sid, err := windows.StringToSid(owner)
+		if err != nil {
+			return fmt.Errorf("invalid owner %q: %w", owner, err)
+		}
+		want = sid
+	}
+
+	descriptor, err := windows.GetSecurityInfo(handle, windows.SE_KERNEL_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return fmt.Errorf("failed to read the security descriptor of the pipe: %w", err)
+	}
+	got, _, err := descriptor.Owner()
+	if err != nil {
+		return fmt.Errorf("failed to read the owner of the pipe: %w", err)
+	}
+	if !got.Equals(want) {
+		return fmt.Errorf("the pipe is owned by %s, not %s", got, want)
+	}
+	return nil
+}
+
+// pipe is the client end of a named pipe.
+type pipe struct {
+	*os.File
+	handle windows.Handle
+}
+
+// Close cancels the read blocked on the pipe, which would otherwise keep the handle open, and
+// closes it.
+func (p *pipe) Close() error {
+	_ = windows.CancelIoEx(p.handle, nil)
+	return p.File.Close()
+}
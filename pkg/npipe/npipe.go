@@ -0,0 +1,108 @@
+/*
+Package npipe connects to MCP servers listening on Windows named pipes, which some desktop MCP
+hosts use for their local servers. Pipes are given as npipe:// URLs, such as
+npipe://./pipe/mcp-server, with the options of the connection in the query:
+
+  - owner: the SID the pipe must be owned by, or self for the current user, so a server
+    squatting the pipe name under another account is refused
+  - impersonation: how far the server may impersonate the client: anonymous, identification
+    (the default), impersonation or delegation
+  - timeout: how long to wait for the pipe to be created, or for a free instance of it, when
+    connecting (10s by default)
+
+Messages are newline-delimited JSON, as on stdio. When the server drops the connection, for
+example because it restarted, the next request connects again and replays the initialize
+handshake before it is sent.
+*/
+package npipe
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Scheme starts the URL of a named pipe.
+const Scheme = "npipe://"
+
+// OwnerSelf is the owner of pipes that must be owned by the current user.
+const OwnerSelf = "self"
+
+// Impersonation levels granted to the server.
+const (
+	ImpersonationAnonymous      = "anonymous"
+	ImpersonationIdentification = "identification"
+	ImpersonationImpersonation  = "impersonation"
+	ImpersonationDelegation     = "delegation"
+)
+
+// DefaultTimeout is how long connecting waits for the pipe unless the URL sets timeout.
+const DefaultTimeout = 10 * time.Second
+
+// ErrUnsupported is returned when connecting to a named pipe on a system other than Windows.
+var ErrUnsupported = errors.New("named pipes are only supported on Windows")
+
+// Address is a named pipe and the options of connecting to it.
+type Address struct {
+	// Path is the path of the pipe, such as \\.\pipe\mcp-server.
+	Path string
+	// Owner is the SID the pipe must be owned by, OwnerSelf, or "" not to check the owner.
+	Owner         string
+	Impersonation string
+	Timeout       time.Duration
+}
+
+// IsURL reports whether arg is the URL of a named pipe.
+func IsURL(arg string) bool {
+	return strings.HasPrefix(arg, Scheme)
+}
+
+// ParseURL parses the URL of a named pipe: npipe://host/pipe/name, where host is . for the
+// local machine, with the connection options in the query. The form npipe:////./pipe/name is
+// also accepted.
+func ParseURL(raw string) (Address, error) {
+	if !IsURL(raw) {
+		return Address{}, fmt.Errorf("invalid named pipe %q: expected a URL starting with %s", raw, Scheme)
+	}
+	rest, rawQuery, _ := strings.Cut(strings.TrimPrefix(raw, Scheme), "?")
+
+	segments := strings.FieldsFunc(rest, func(r rune) bool { return r == '/' || r == '\\' })
+	if len(segments) < 3 || !strings.EqualFold(segments[1], "pipe") {
+		return Address{}, fmt.Errorf("invalid named pipe %q: expected %shost/pipe/name, e.g. %s./pipe/mcp-server", raw, Scheme, Scheme)
+	}
+	addr := Address{
+		Path:          `\\` + segments[0] + `\pipe\` + strings.Join(segments[2:], `\`),
+		Impersonation: ImpersonationIdentification,
+		Timeout:       DefaultTimeout,
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Address{}, fmt.Errorf("invalid named pipe %q: %w", raw, err)
+	}
+	for key, values := range query {
+		value := values[len(values)-1]
+		switch key {
+		case "owner":
+			addr.Owner = value
+		case "impersonation":
+			switch value {
+			case ImpersonationAnonymous, ImpersonationIdentification, ImpersonationImpersonation, ImpersonationDelegation:
+				addr.Impersonation = value
+			default:
+				return Address{}, fmt.Errorf("invalid impersonation %q: expected anonymous, identification, impersonation or delegation", value)
+			}
+		case "timeout":
+			timeout, parseErr := time.ParseDuration(value)
+			if parseErr != nil || timeout <= 0 {
+				return Address{}, fmt.Errorf("invalid timeout %q: expected a duration such as 5s", value)
+			}
+			addr.Timeout = timeout
+		default:
+			return Address{}, fmt.Errorf("unknown option %q of named pipe %q (supported: owner, impersonation, timeout)", key, raw)
+		}
+	}
+	return addr, nil
+}
@@ -0,0 +1,163 @@
+package npipe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Address
+		wantErr string
+	}{
+		{
+			raw:  "npipe://./pipe/mcp-server",
+			want: Address{Path: `\\.\pipe\mcp-server`, Impersonation: ImpersonationIdentification, Timeout: DefaultTimeout},
+		},
+		{
+			raw:  "npipe:////./pipe/mcp/server?owner=self&impersonation=anonymous&timeout=2s",
+			want: Address{Path: `\\.\pipe\mcp\server`, Owner: OwnerSelf, Impersonation: ImpersonationAnonymous, Timeout: 2 * time.Second},
+		},
+		{
+			raw:  "npipe://host/PIPE/mcp?owner=S-1-5-18",
+			want: Address{Path: `\\host\pipe\mcp`, Owner: "S-1-5-18", Impersonation: ImpersonationIdentification, Timeout: DefaultTimeout},
+		},
+		{raw: "npipe://./mcp-server", wantErr: "expected npipe://host/pipe/name"},
+		{raw: "npipe://./pipe/mcp?impersonation=full", wantErr: `invalid impersonation "full"`},
+		{raw: "npipe://./pipe/mcp?timeout=soon", wantErr: `invalid timeout "soon"`},
+		{raw: "npipe://./pipe/mcp?mode=message", wantErr: `unknown option "mode"`},
+		{raw: "http://./pipe/mcp", wantErr: "expected a URL starting with npipe://"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseURL(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseURL() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseURL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseURL() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeServer answers the requests on every connection with their method, closing each
+// connection after answering drop requests.
+type fakeServer struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (s *fakeServer) dial(context.Context, Address) (io.ReadWriteCloser, error) {
+	client, server := net.Pipe()
+	go s.serve(server)
+	return client, nil
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var message struct {
+			ID     *mcp.RequestId `json:"id"`
+			Method string         `json:"method"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.methods = append(s.methods, message.Method)
+		s.mu.Unlock()
+		if message.ID == nil {
+			continue
+		}
+		id, _ := json.Marshal(message.ID)
+		fmt.Fprintf(conn, `{"jsonrpc":"2.0","id":%s,"result":{"method":%q}}`+"\n", id, message.Method)
+		if message.Method == "drop" {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...)
+}
+
+func TestTransportReconnectsAndReplaysTheHandshake(t *testing.T) {
+	server := &fakeServer{}
+	var warnings bytes.Buffer
+	tr := New(Address{Path: `\\.\pipe\test`}, WithWarnings(&warnings))
+	tr.dial = server.dial
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tr.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer tr.Close()
+
+	request := func(id int64, method string) {
+		t.Helper()
+		response, err := tr.SendRequest(ctx, transport.JSONRPCRequest{
+			JSONRPC: mcp.JSONRPC_VERSION, ID: mcp.NewRequestId(id), Method: method,
+		})
+		if err != nil {
+			t.Fatalf("SendRequest(%s) error = %v", method, err)
+		}
+		if !strings.Contains(string(response.Result), method) {
+			t.Fatalf("SendRequest(%s) result = %s", method, response.Result)
+		}
+	}
+	request(1, string(mcp.MethodInitialize))
+	if err := tr.SendNotification(ctx, mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION, Notification: mcp.Notification{Method: "notifications/initialized"},
+	}); err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	request(2, "drop")
+
+	// Wait for the transport to notice the server closed the pipe
+	deadline := time.Now().Add(5 * time.Second)
+	for tr.conn.err() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the lost connection was not noticed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	request(3, string(mcp.MethodToolsList))
+
+	want := []string{"initialize", "notifications/initialized", "drop", "initialize", "notifications/initialized", "tools/list"}
+	deadline = time.Now().Add(5 * time.Second)
+	for fmt.Sprint(server.received()) != fmt.Sprint(want) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := server.received(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("server received %v, want %v", got, want)
+	}
+	if !strings.Contains(warnings.String(), "dropped the connection") {
+		t.Errorf("warnings = %q, want a warning about the dropped connection", warnings.String())
+	}
+}
@@ -0,0 +1,275 @@
+package npipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/f/mcptools/pkg/stdio"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// errClosed is returned for requests sent once the transport is closed.
+var errClosed = errors.New("the named pipe transport is closed")
+
+// Option configures a Transport.
+type Option func(*Transport)
+
+// WithWarnings reports connecting again after the server dropped the connection on w.
+func WithWarnings(w io.Writer) Option {
+	return func(t *Transport) {
+		t.warnings = w
+	}
+}
+
+// WithFilter adds a filter applied to every message exchanged with the server, as on the stdio
+// transport.
+func WithFilter(filter stdio.Filter) Option {
+	return func(t *Transport) {
+		t.filters = append(t.filters, filter)
+	}
+}
+
+// Transport is an MCP transport over a named pipe. When the server drops the connection, the
+// next request connects again and replays the initialize handshake first. Requests in flight
+// when the connection is lost fail, since they may have reached the server.
+type Transport struct {
+	addr     Address
+	dial     func(ctx context.Context, addr Address) (io.ReadWriteCloser, error)
+	warnings io.Writer
+	filters  []stdio.Filter
+
+	mu   sync.Mutex
+	conn *connection
+	// handshake is the initialize request, and initialized set once its notification was sent.
+	handshake      *transport.JSONRPCRequest
+	initialized    bool
+	onNotification func(mcp.JSONRPCNotification)
+	onRequest      transport.RequestHandler
+	closed         bool
+}
+
+// New returns a transport connecting to the named pipe at addr once started.
+func New(addr Address, opts ...Option) *Transport {
+	t := &Transport{addr: addr, dial: dial}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Start connects to the pipe.
+func (t *Transport) Start(ctx context.Context) error {
+	_, err := t.connection(ctx)
+	return err
+}
+
+// SendRequest sends a request, connecting again first if the connection was lost.
+func (t *Transport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	c, err := t.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if request.Method == string(mcp.MethodInitialize) {
+		t.mu.Lock()
+		t.handshake = &request
+		t.mu.Unlock()
+	}
+	return t.send(ctx, c, request)
+}
+
+// SendNotification sends a notification, connecting again first if the connection was lost.
+func (t *Transport) SendNotification(ctx context.Context, notification mcp.JSONRPCNotification) error {
+	c, err := t.connection(ctx)
+	if err != nil {
+		return err
+	}
+	if notification.Method == "notifications/initialized" {
+		t.mu.Lock()
+		t.initialized = true
+		t.mu.Unlock()
+	}
+	return c.SendNotification(ctx, notification)
+}
+
+// SetNotificationHandler sets the handler of the notifications of the server, on every
+// connection.
+func (t *Transport) SetNotificationHandler(handler func(notification mcp.JSONRPCNotification)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onNotification = handler
+	if t.conn != nil {
+		t.conn.SetNotificationHandler(handler)
+	}
+}
+
+// SetRequestHandler sets the handler of the requests of the server, such as sampling, on every
+// connection.
+func (t *Transport) SetRequestHandler(handler transport.RequestHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onRequest = handler
+	if t.conn != nil {
+		t.conn.SetRequestHandler(handler)
+	}
+}
+
+// Close closes the connection.
+func (t *Transport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
+
+// GetSessionId returns "", as named pipes have no session ID.
+func (t *Transport) GetSessionId() string {
+	return ""
+}
+
+// connection returns the connection to the pipe, connecting again if the server dropped it.
+func (t *Transport) connection(ctx context.Context) (*connection, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, errClosed
+	}
+	if t.conn != nil && t.conn.err() == nil {
+		return t.conn, nil
+	}
+
+	reconnecting := t.conn != nil
+	if reconnecting {
+		_ = t.conn.Close()
+		t.conn = nil
+	}
+	pipe, err := t.dial(ctx, t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", t.addr.Path, err)
+	}
+	c := newConnection(pipe, t.filters)
+	if t.onNotification != nil {
+		c.SetNotificationHandler(t.onNotification)
+	}
+	if t.onRequest != nil {
+		c.SetRequestHandler(t.onRequest)
+	}
+	if err = c.Start(context.Background()); err != nil {
+		_ = pipe.Close()
+		return nil, fmt.Errorf("failed to connect to %s: %w", t.addr.Path, err)
+	}
+	t.conn = c
+
+	if reconnecting {
+		if t.warnings != nil {
+			fmt.Fprintf(t.warnings, "Warning: the server dropped the connection to %s, connected again\n", t.addr.Path)
+		}
+		if err = t.replay(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// replay repeats the initialize handshake on a new connection. The caller must hold mu.
+func (t *Transport) replay(ctx context.Context, c *connection) error {
+	if t.handshake == nil {
+		return nil
+	}
+	response, err := t.send(ctx, c, *t.handshake)
+	if err == nil && response.Error != nil {
+		err = errors.New(response.Error.Message)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to initialize again after connecting to %s again: %w", t.addr.Path, err)
+	}
+	if !t.initialized {
+		return nil
+	}
+	return c.SendNotification(ctx, mcp.JSONRPCNotification{
+		JSONRPC:      mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{Method: "notifications/initialized"},
+	})
+}
+
+// send sends a request on c, failing it if the connection is lost before the response arrives.
+func (t *Transport) send(ctx context.Context, c *connection, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.lost:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	response, err := c.SendRequest(ctx, request)
+	if err != nil {
+		if lostErr := c.err(); lostErr != nil {
+			return nil, fmt.Errorf("lost the connection to %s: %w; the next request connects again", t.addr.Path, lostErr)
+		}
+	}
+	return response, err
+}
+
+// connection is a single connection to the pipe.
+type connection struct {
+	*transport.Stdio
+	lost    chan struct{}
+	lostErr error
+	once    sync.Once
+}
+
+// newConnection returns a connection exchanging newline-delimited messages over pipe, passed
+// through filters.
+func newConnection(pipe io.ReadWriteCloser, filters []stdio.Filter) *connection {
+	c := &connection{lost: make(chan struct{})}
+	c.Stdio = transport.NewIO(stdio.NewReader(&lostReader{source: pipe, conn: c}, filters...), stdio.NewWriter(pipe, filters...),
+		io.NopCloser(strings.NewReader("")))
+	return c
+}
+
+// err returns why the connection was lost, or nil while it is up.
+func (c *connection) err() error {
+	select {
+	case <-c.lost:
+		return c.lostErr
+	default:
+		return nil
+	}
+}
+
+// lose records that the connection was lost because of err.
+func (c *connection) lose(err error) {
+	c.once.Do(func() {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("the server closed the pipe")
+		}
+		c.lostErr = err
+		close(c.lost)
+	})
+}
+
+// lostReader reads from the pipe, marking the connection lost once reading fails.
+type lostReader struct {
+	source io.Reader
+	conn   *connection
+}
+
+// Read implements io.Reader.
+func (r *lostReader) Read(p []byte) (int, error) {
+	n, err := r.source.Read(p)
+	if err != nil {
+		r.conn.lose(err)
+	}
+	return n, err
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package oauth
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile takes an exclusive lock on the file at path, creating it if needed, and returns a
+// function releasing it. The lock is released by the system if the process dies.
+func lockFile(path string) (func(), error) {
+	// #nosec G304 - the lock file sits next to the token file provided by the user
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err = unix.Flock(int(file.Fd()), unix.LOCK_EX); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unix.Flock(int(file.Fd()), unix.LOCK_UN)
+		_ = file.Close()
+	}, nil
+}
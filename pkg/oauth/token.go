@@ -0,0 +1,308 @@
+// Package oauth keeps the OAuth 2.0 and JWT bearer tokens of HTTP servers fresh. Tokens are read
+// from a file that is shared by every mcp process using it: they are renewed with their refresh
+// token shortly before they expire, under a lock on the file, so servers that rotate refresh
+// tokens see each one used only once.
+package oauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RefreshMargin is how long before it expires a token is renewed.
+const RefreshMargin = time.Minute
+
+// refreshRetry is how long background renewal waits after a failed attempt.
+const refreshRetry = 15 * time.Second
+
+// ErrNoRefreshToken is returned when an expired token cannot be renewed.
+var ErrNoRefreshToken = errors.New("the token has expired and the token file has no refresh_token and token_url to renew it")
+
+// Token is the content of a token file. The fields are those of an OAuth 2.0 token response,
+// plus where and as which client to renew it:
+//
+//	{"access_token": "...", "refresh_token": "...", "expires_at": "2026-10-17T10:00:00Z",
+//	 "token_url": "https://auth.example.com/oauth/token", "client_id": "mcptools"}
+//
+// Without expires_at, the exp claim of an access token that is a JWT is used.
+type Token struct {
+	Expiry       time.Time `json:"expires_at,omitzero"`
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	TokenURL     string    `json:"token_url,omitempty"`
+	ClientID     string    `json:"client_id,omitempty"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+}
+
+// Expires returns when the access token expires, or the zero time if that is unknown.
+func (t Token) Expires() time.Time {
+	if !t.Expiry.IsZero() {
+		return t.Expiry
+	}
+	return jwtExpiry(t.AccessToken)
+}
+
+// jwtExpiry returns the exp claim of a JWT, or the zero time if token is not a JWT with one.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(claims.Exp), 0)
+}
+
+// Source hands out the access token of a token file, renewing it before it expires.
+type Source struct {
+	now      func() time.Time
+	client   *http.Client
+	warnings io.Writer
+	timer    *time.Timer
+	path     string
+	token    Token
+	mu       sync.Mutex
+}
+
+// NewSource reads the token file at path. Failed renewals are reported on warnings while the
+// current token is still valid.
+func NewSource(path string, warnings io.Writer) (*Source, error) {
+	token, err := readToken(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{
+		path:     path,
+		token:    token,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		warnings: warnings,
+		now:      time.Now,
+	}, nil
+}
+
+// Token returns a valid access token, renewing it first if it expires within RefreshMargin.
+// Once a token was handed out, it is also renewed in the background ahead of its expiry, so
+// idle long-lived sessions such as mcp shell and mcp watch find it fresh.
+func (s *Source) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var retry time.Duration
+	expires := s.token.Expires()
+	if s.token.AccessToken == "" || (!expires.IsZero() && s.now().Add(RefreshMargin).After(expires)) {
+		if err := s.refresh(ctx, s.token.AccessToken); err != nil {
+			if s.token.AccessToken == "" || !s.now().Before(expires) {
+				return "", err
+			}
+			fmt.Fprintf(s.warnings, "Warning: %v; using the current token until it expires\n", err)
+			retry = refreshRetry
+		}
+	}
+	s.schedule(retry)
+	return s.token.AccessToken, nil
+}
+
+// Refresh renews the token after a server rejected stale, unless another request or process
+// renewed it already.
+func (s *Source) Refresh(ctx context.Context, stale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.AccessToken != stale {
+		return nil
+	}
+	err := s.refresh(ctx, stale)
+	if err != nil {
+		s.schedule(refreshRetry)
+	} else {
+		s.schedule(0)
+	}
+	return err
+}
+
+// Stop stops renewing the token in the background.
+func (s *Source) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
+
+// schedule arranges for the token to be renewed RefreshMargin before it expires, but no sooner
+// than after minWait, with s.mu held.
+func (s *Source) schedule(minWait time.Duration) {
+	expires := s.token.Expires()
+	if expires.IsZero() || s.token.RefreshToken == "" {
+		return
+	}
+	wait := max(expires.Sub(s.now())-RefreshMargin, minWait)
+	if s.timer != nil {
+		s.timer.Reset(wait)
+		return
+	}
+	s.timer = time.AfterFunc(wait, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := s.Token(ctx); err != nil {
+			fmt.Fprintf(s.warnings, "Warning: %v\n", err)
+		}
+	})
+}
+
+// refresh renews the token under an exclusive lock on the token file, with s.mu held. Another
+// process may have renewed it while we waited for the lock, in which case its token is used
+// instead of spending the refresh token again, which rotating servers would reject.
+func (s *Source) refresh(ctx context.Context, stale string) error {
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock the token file: %w", err)
+	}
+	defer unlock()
+
+	current, err := readToken(s.path)
+	if err != nil {
+		return err
+	}
+	if current.AccessToken != stale {
+		s.token = current
+		return nil
+	}
+	if current.RefreshToken == "" || current.TokenURL == "" {
+		return ErrNoRefreshToken
+	}
+
+	renewed, err := s.exchange(ctx, current)
+	if err != nil {
+		return err
+	}
+	if err = writeToken(s.path, renewed); err != nil {
+		return err
+	}
+	s.token = renewed
+	return nil
+}
+
+// exchange trades the refresh token of a token for a new one at its token endpoint.
+func (s *Source) exchange(ctx context.Context, token Token) (Token, error) {
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {token.RefreshToken}}
+	if token.ClientSecret == "" && token.ClientID != "" {
+		form.Set("client_id", token.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, token.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("token refresh failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if token.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(token.ClientID), url.QueryEscape(token.ClientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("token refresh failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		AccessToken      string  `json:"access_token"`
+		TokenType        string  `json:"token_type"`
+		RefreshToken     string  `json:"refresh_token"`
+		Scope            string  `json:"scope"`
+		Error            string  `json:"error"`
+		ErrorDescription string  `json:"error_description"`
+		ExpiresIn        float64 `json:"expires_in"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err = json.Unmarshal(data, &body); err != nil && resp.StatusCode == http.StatusOK {
+		return Token{}, fmt.Errorf("token refresh failed: invalid response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		reason := strings.TrimSpace(body.Error + ": " + body.ErrorDescription)
+		if body.Error == "" {
+			reason = resp.Status
+		}
+		return Token{}, fmt.Errorf("token refresh failed: %s", strings.TrimSuffix(reason, ":"))
+	}
+
+	renewed := token
+	renewed.AccessToken = body.AccessToken
+	renewed.Expiry = time.Time{}
+	if body.TokenType != "" {
+		renewed.TokenType = body.TokenType
+	}
+	// Servers rotating refresh tokens send a new one; others keep the old one valid
+	if body.RefreshToken != "" {
+		renewed.RefreshToken = body.RefreshToken
+	}
+	if body.Scope != "" {
+		renewed.Scope = body.Scope
+	}
+	if body.ExpiresIn > 0 {
+		renewed.Expiry = s.now().Add(time.Duration(body.ExpiresIn * float64(time.Second))).UTC().Truncate(time.Second)
+	}
+	return renewed, nil
+}
+
+func readToken(path string) (Token, error) {
+	// #nosec G304 - the token file path is provided explicitly by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to read token file: %w", err)
+	}
+	var token Token
+	if err = json.Unmarshal(data, &token); err != nil {
+		return Token{}, fmt.Errorf("failed to parse token file %s: %w", path, err)
+	}
+	if token.AccessToken == "" && token.RefreshToken == "" {
+		return Token{}, fmt.Errorf("token file %s has neither an access_token nor a refresh_token", path)
+	}
+	return token, nil
+}
+
+// writeToken replaces the token file atomically, so other processes never read half of it.
+func writeToken(path string, token Token) error {
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err = tmp.Chmod(0o600); err == nil {
+		_, err = tmp.Write(append(data, '\n'))
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
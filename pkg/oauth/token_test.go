@@ -0,0 +1,182 @@
+package oauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer issues access tokens for refresh tokens it rotates on every use, rejecting
+// refresh tokens used before as rotating servers do.
+type tokenServer struct {
+	*httptest.Server
+	used      map[string]bool
+	exchanges int
+	mu        sync.Mutex
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	t.Helper()
+
+	s := &tokenServer{used: map[string]bool{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		_ = r.ParseForm()
+		refresh := r.PostForm.Get("refresh_token")
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("client_id") != "mcptools" || s.used[refresh] {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":"invalid_grant","error_description":"refresh token reused"}`)
+			return
+		}
+		s.used[refresh] = true
+		s.exchanges++
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("access-%d", s.exchanges),
+			"refresh_token": fmt.Sprintf("refresh-%d", s.exchanges),
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func writeTokenFile(t *testing.T, token Token) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token.json")
+	if err := writeToken(path, token); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestSource(t *testing.T, path string) *Source {
+	t.Helper()
+	source, err := NewSource(path, io.Discard)
+	if err != nil {
+		t.Fatalf("NewSource() error = %v", err)
+	}
+	t.Cleanup(source.Stop)
+	return source
+}
+
+func TestSourceRenewsBeforeExpiry(t *testing.T) {
+	server := newTokenServer(t)
+	path := writeTokenFile(t, Token{AccessToken: "access-0", RefreshToken: "refresh-0",
+		Expiry: time.Now().Add(30 * time.Second), TokenURL: server.URL, ClientID: "mcptools"})
+
+	source := newTestSource(t, path)
+	token, err := source.Token(context.Background())
+	if err != nil || token != "access-1" {
+		t.Fatalf("Token() = %q, %v, want the renewed access-1", token, err)
+	}
+
+	saved, err := readToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.AccessToken != "access-1" || saved.RefreshToken != "refresh-1" || time.Until(saved.Expiry) < 59*time.Minute {
+		t.Errorf("token file = %+v, want the renewed token with the rotated refresh token", saved)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("token file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A fresh token is handed out as is
+	if token, _ = source.Token(context.Background()); token != "access-1" || server.exchanges != 1 {
+		t.Errorf("Token() = %q after %d exchanges, want access-1 after 1", token, server.exchanges)
+	}
+}
+
+func TestSourcesShareRenewals(t *testing.T) {
+	server := newTokenServer(t)
+	path := writeTokenFile(t, Token{AccessToken: "access-0", RefreshToken: "refresh-0",
+		Expiry: time.Now().Add(10 * time.Second), TokenURL: server.URL, ClientID: "mcptools"})
+
+	// Sources of separate processes sharing the token file renew it once between them
+	sources := []*Source{newTestSource(t, path), newTestSource(t, path), newTestSource(t, path)}
+	var wg sync.WaitGroup
+	tokens := make([]string, len(sources))
+	errs := make([]error, len(sources))
+	for i, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens[i], errs[i] = source.Token(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	for i := range sources {
+		if errs[i] != nil || tokens[i] != "access-1" {
+			t.Errorf("source %d: Token() = %q, %v, want access-1", i, tokens[i], errs[i])
+		}
+	}
+	if server.exchanges != 1 {
+		t.Errorf("refresh token was exchanged %d times, want once", server.exchanges)
+	}
+}
+
+func TestSourceExpiredWithoutRefreshToken(t *testing.T) {
+	path := writeTokenFile(t, Token{AccessToken: "access-0", Expiry: time.Now().Add(-time.Minute)})
+	if _, err := newTestSource(t, path).Token(context.Background()); err == nil ||
+		!strings.Contains(err.Error(), "no refresh_token") {
+		t.Errorf("Token() error = %v, want one saying the token cannot be renewed", err)
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","exp":1791000000}`))
+	token := Token{AccessToken: "eyJhbGciOiJIUzI1NiJ9." + payload + ".c2ln"}
+	if got := token.Expires(); !got.Equal(time.Unix(1791000000, 0)) {
+		t.Errorf("Expires() = %v, want the exp claim", got)
+	}
+	if got := (Token{AccessToken: "opaque"}).Expires(); !got.IsZero() {
+		t.Errorf("Expires() of an opaque token = %v, want zero", got)
+	}
+}
+
+func TestTransportRetriesRejectedToken(t *testing.T) {
+	tokens := newTokenServer(t)
+	var seen []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seen = append(seen, r.Header.Get("Authorization")+" "+string(body))
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer api.Close()
+
+	// The token was revoked early, so the server rejects it before it expires
+	path := writeTokenFile(t, Token{AccessToken: "revoked", RefreshToken: "refresh-0",
+		Expiry: time.Now().Add(time.Hour), TokenURL: tokens.URL, ClientID: "mcptools"})
+	client := &http.Client{Transport: NewTransport(http.DefaultTransport, newTestSource(t, path))}
+
+	resp, err := client.Post(api.URL, "application/json", strings.NewReader(`{"id":1}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200 after renewing the token", resp.StatusCode)
+	}
+	if len(seen) != 2 || seen[0] != `Bearer revoked {"id":1}` || seen[1] != `Bearer access-1 {"id":1}` {
+		t.Errorf("server saw %q, want the request with the old and then the new token", seen)
+	}
+}
@@ -0,0 +1,58 @@
+package oauth
+
+import (
+	"io"
+	"net/http"
+)
+
+// Transport is an http.RoundTripper that authorizes requests with the bearer token of a Source.
+// Tokens are renewed before they expire; if a server rejects one anyway, e.g. because it was
+// revoked, the token is renewed and the request retried once.
+type Transport struct {
+	Base   http.RoundTripper
+	Source *Source
+}
+
+// NewTransport wraps base so requests carry the access token of source.
+func NewTransport(base http.RoundTripper, source *Source) *Transport {
+	return &Transport{Base: base, Source: source}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.Base.RoundTrip(authorize(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// Requests whose body cannot be read again are not retried
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	if err = t.Source.Refresh(req.Context(), token); err != nil {
+		return resp, nil
+	}
+	if token, err = t.Source.Token(req.Context()); err != nil {
+		return resp, nil
+	}
+	retry := authorize(req, token)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+	return t.Base.RoundTrip(retry)
+}
+
+// authorize returns a copy of req carrying token.
+func authorize(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
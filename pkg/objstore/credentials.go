@@ -0,0 +1,346 @@
+package objstore
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials sign requests with AWS Signature Version 4: AWS keys for S3, or HMAC keys for
+// GCS.
+type Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// awsCredentials returns the AWS credentials from the environment, or else from the profile in
+// AWS_PROFILE (default) of the shared credentials file.
+func awsCredentials() (Credentials, error) {
+	if creds := (Credentials{
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}); creds.AccessKey != "" && creds.SecretKey != "" {
+		return creds, nil
+	}
+
+	profile := awsProfile()
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		path = awsFile("credentials")
+	}
+	section := readINI(path)[profile]
+	creds := Credentials{
+		AccessKey:    section["aws_access_key_id"],
+		SecretKey:    section["aws_secret_access_key"],
+		SessionToken: section["aws_session_token"],
+	}
+	if creds.AccessKey == "" || creds.SecretKey == "" {
+		return Credentials{}, errors.New("no AWS credentials: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, " +
+			"or add profile " + profile + " to ~/.aws/credentials")
+	}
+	return creds, nil
+}
+
+// awsRegion returns the region in AWS_REGION, AWS_DEFAULT_REGION or the profile of the shared
+// config file, or us-east-1.
+func awsRegion() string {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(name); region != "" {
+			return region
+		}
+	}
+	path := os.Getenv("AWS_CONFIG_FILE")
+	if path == "" {
+		path = awsFile("config")
+	}
+	profile := awsProfile()
+	sections := readINI(path)
+	region := sections["profile "+profile]["region"]
+	if region == "" {
+		region = sections[profile]["region"]
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return region
+}
+
+func awsProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+func awsFile(name string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".aws", name)
+}
+
+// readINI reads the sections of an INI file such as ~/.aws/credentials. A missing file has no
+// sections.
+func readINI(path string) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	if path == "" {
+		return sections
+	}
+	// #nosec G304 - the path is a standard AWS configuration file
+	file, err := os.Open(path)
+	if err != nil {
+		return sections
+	}
+	defer func() { _ = file.Close() }()
+
+	var current map[string]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			current = map[string]string{}
+			sections[name] = current
+		case current != nil:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return sections
+}
+
+// tokenSource returns OAuth access tokens, fetching a new one when the last expires.
+type tokenSource struct {
+	fetch   func(ctx context.Context) (string, time.Duration, error)
+	token   string
+	expires time.Time
+	mu      sync.Mutex
+}
+
+func (s *tokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.expires) {
+		return s.token, nil
+	}
+	token, lifetime, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	// Renewed a minute early, so a token never expires during a request
+	s.token, s.expires = token, time.Now().Add(lifetime-time.Minute)
+	return token, nil
+}
+
+// gcsScope is the OAuth scope of uploads to GCS.
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// googleTokenURL is where OAuth tokens are obtained, replaced in tests.
+var googleTokenURL = "https://oauth2.googleapis.com/token"
+
+// gcsTokenSource returns the source of OAuth tokens for GCS, following Application Default
+// Credentials: a token in GOOGLE_OAUTH_ACCESS_TOKEN, the credentials file in
+// GOOGLE_APPLICATION_CREDENTIALS or the one written by gcloud auth application-default login,
+// or else the metadata server of Google Cloud machines.
+func gcsTokenSource(client *http.Client) (*tokenSource, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return &tokenSource{fetch: func(context.Context) (string, time.Duration, error) {
+			return token, time.Hour, nil
+		}}, nil
+	}
+
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if configDir, err := os.UserConfigDir(); err == nil {
+			if candidate := filepath.Join(configDir, "gcloud", "application_default_credentials.json"); fileExists(candidate) {
+				path = candidate
+			}
+		}
+	}
+	if path == "" {
+		return &tokenSource{fetch: func(ctx context.Context) (string, time.Duration, error) {
+			return metadataToken(ctx, client)
+		}}, nil
+	}
+
+	// #nosec G304 - the path is the standard location of Google credentials
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Google credentials: %w", err)
+	}
+	var file struct {
+		Type         string `json:"type"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKey   string `json:"private_key"`
+		TokenURI     string `json:"token_uri"`
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid Google credentials %s: %w", path, err)
+	}
+
+	switch file.Type {
+	case "service_account":
+		key, err := parsePrivateKey(file.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Google credentials %s: %w", path, err)
+		}
+		tokenURL := file.TokenURI
+		if tokenURL == "" {
+			tokenURL = googleTokenURL
+		}
+		return &tokenSource{fetch: func(ctx context.Context) (string, time.Duration, error) {
+			assertion, err := signJWT(key, file.ClientEmail, tokenURL)
+			if err != nil {
+				return "", 0, err
+			}
+			return exchangeToken(ctx, client, tokenURL, url.Values{
+				"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+				"assertion":  {assertion},
+			})
+		}}, nil
+	case "authorized_user":
+		return &tokenSource{fetch: func(ctx context.Context) (string, time.Duration, error) {
+			return exchangeToken(ctx, client, googleTokenURL, url.Values{
+				"grant_type":    {"refresh_token"},
+				"client_id":     {file.ClientID},
+				"client_secret": {file.ClientSecret},
+				"refresh_token": {file.RefreshToken},
+			})
+		}}, nil
+	}
+	return nil, fmt.Errorf("unsupported Google credentials type %q in %s", file.Type, path)
+}
+
+func parsePrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("no PEM private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, pkcs1Err := x509.ParsePKCS1PrivateKey(block.Bytes); pkcs1Err == nil {
+			return key, nil
+		}
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signJWT returns the assertion a service account exchanges for an access token.
+func signJWT(key *rsa.PrivateKey, email, audience string) (string, error) {
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iss":   email,
+		"scope": gcsScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// exchangeToken posts an OAuth token request and returns the access token and its lifetime.
+func exchangeToken(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (string, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doTokenRequest(client, req)
+}
+
+// metadataToken returns the token of the service account of the Google Cloud machine.
+func metadataToken(ctx context.Context, client *http.Client) (string, time.Duration, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	// Off Google Cloud the host does not resolve or answer, so give up quickly
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	token, lifetime, err := doTokenRequest(client, req)
+	if err != nil {
+		return "", 0, fmt.Errorf("no Google credentials: set GOOGLE_APPLICATION_CREDENTIALS, run gcloud auth application-default login, "+
+			"or set GCS_HMAC_ACCESS_KEY_ID and GCS_HMAC_SECRET (metadata server: %w)", err)
+	}
+	return token, lifetime, nil
+}
+
+func doTokenRequest(client *http.Client, req *http.Request) (string, time.Duration, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", 0, fmt.Errorf("token request failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", 0, fmt.Errorf("failed to decode token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, errors.New("token response has no access token")
+	}
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= time.Minute {
+		lifetime = 5 * time.Minute
+	}
+	return token.AccessToken, lifetime, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,348 @@
+// Package objstore writes objects to Amazon S3 and Google Cloud Storage, streaming them in
+// multipart uploads so large objects never need a local copy.
+//
+// Credentials are found the way the AWS and Google tools find them. For s3:// URLs:
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, or else the profile in
+// AWS_PROFILE (default) of ~/.aws/credentials, in AWS_REGION, AWS_DEFAULT_REGION or the region
+// of the profile in ~/.aws/config. AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL points uploads at
+// S3-compatible storage such as MinIO. For gs:// URLs: the HMAC key in GCS_HMAC_ACCESS_KEY_ID
+// and GCS_HMAC_SECRET, or else Application Default Credentials: GOOGLE_OAUTH_ACCESS_TOKEN, the
+// file in GOOGLE_APPLICATION_CREDENTIALS or written by gcloud auth application-default login,
+// or the metadata server of Google Cloud machines.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PartSize is the size of the parts of multipart uploads. S3 and GCS take up to 10,000 parts,
+// so objects of up to about 80 GB can be written.
+const PartSize = 8 << 20
+
+// IsURL reports whether dest is an s3:// or gs:// URL.
+func IsURL(dest string) bool {
+	return strings.HasPrefix(dest, "s3://") || strings.HasPrefix(dest, "gs://")
+}
+
+// Location is an object, or a prefix of objects, in a bucket.
+type Location struct {
+	Scheme string
+	Bucket string
+	Key    string
+}
+
+// String returns the location as a URL.
+func (l Location) String() string {
+	return l.Scheme + "://" + l.Bucket + "/" + l.Key
+}
+
+// Parse parses an s3://bucket/key or gs://bucket/key URL.
+func Parse(dest string) (Location, error) {
+	scheme, rest, _ := strings.Cut(dest, "://")
+	if scheme != "s3" && scheme != "gs" {
+		return Location{}, fmt.Errorf("invalid object URL %q: expected s3:// or gs://", dest)
+	}
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return Location{}, fmt.Errorf("invalid object URL %q: no bucket", dest)
+	}
+	return Location{Scheme: scheme, Bucket: bucket, Key: key}, nil
+}
+
+// Store writes objects to a bucket.
+type Store struct {
+	client   *http.Client
+	endpoint *url.URL
+	bucket   string
+	region   string
+	// virtualHosted endpoints name the bucket in their host rather than in the path.
+	virtualHosted bool
+	// creds sign requests with Signature Version 4, unless requests carry tokens instead.
+	creds  *Credentials
+	tokens *tokenSource
+	// now is time.Now, replaced in tests.
+	now func() time.Time
+}
+
+// Open returns the store of the bucket of loc, with credentials from the environment.
+func Open(loc Location) (*Store, error) {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	store := &Store{client: client, bucket: loc.Bucket, now: time.Now}
+
+	var endpoint string
+	switch loc.Scheme {
+	case "s3":
+		creds, err := awsCredentials()
+		if err != nil {
+			return nil, err
+		}
+		store.creds = &creds
+		store.region = awsRegion()
+		endpoint = os.Getenv("AWS_ENDPOINT_URL_S3")
+		if endpoint == "" {
+			endpoint = os.Getenv("AWS_ENDPOINT_URL")
+		}
+		if endpoint == "" {
+			// Virtual-hosted style, as AWS recommends
+			endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", loc.Bucket, store.region)
+			store.virtualHosted = true
+		}
+	case "gs":
+		endpoint = "https://storage.googleapis.com"
+		if creds := (Credentials{
+			AccessKey: os.Getenv("GCS_HMAC_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("GCS_HMAC_SECRET"),
+		}); creds.AccessKey != "" && creds.SecretKey != "" {
+			store.creds = &creds
+			store.region = "auto"
+			break
+		}
+		tokens, err := gcsTokenSource(client)
+		if err != nil {
+			return nil, err
+		}
+		store.tokens = tokens
+	default:
+		return nil, fmt.Errorf("unsupported object storage %q", loc.Scheme)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+	store.endpoint = u
+	return store, nil
+}
+
+// Create starts writing the object at dest, an s3:// or gs:// URL. The object only appears once
+// the writer is closed.
+func Create(ctx context.Context, dest string) (*Writer, error) {
+	loc, err := Parse(dest)
+	if err != nil {
+		return nil, err
+	}
+	if loc.Key == "" || strings.HasSuffix(loc.Key, "/") {
+		return nil, fmt.Errorf("invalid object URL %q: no object name", dest)
+	}
+	store, err := Open(loc)
+	if err != nil {
+		return nil, err
+	}
+	return store.Create(ctx, loc.Key), nil
+}
+
+// Create starts writing the object at key. The object only appears once the writer is closed.
+func (s *Store) Create(ctx context.Context, key string) *Writer {
+	return &Writer{ctx: ctx, store: s, key: key}
+}
+
+// do sends a request for the object at key and returns the response body. Statuses other than
+// 2xx are returned as errors.
+func (s *Store) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (http.Header, []byte, error) {
+	u := *s.endpoint
+	objectPath := "/" + key
+	if !s.virtualHosted {
+		objectPath = "/" + s.bucket + objectPath
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + objectPath
+	u.RawPath = ""
+	u.RawQuery = query.Encode()
+	if query.Has("uploads") {
+		// Starting a multipart upload takes ?uploads, which some services reject as ?uploads=
+		u.RawQuery = "uploads"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if s.creds != nil {
+		signV4(req, *s.creds, s.region, sha256Hex(body), s.now())
+	} else {
+		token, err := s.tokens.Token(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, nil, fmt.Errorf("%s %s failed: %w", method, s.bucket+"/"+key, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("%s %s failed: %s: %s", method, s.bucket+"/"+key, resp.Status, errorMessage(data))
+	}
+	return resp.Header, data, nil
+}
+
+// errorMessage returns the message of an XML error response, or the response itself.
+func errorMessage(data []byte) string {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+		return e.Code + ": " + e.Message
+	}
+	return string(bytes.TrimSpace(data))
+}
+
+// Writer streams an object to a bucket: the first PartSize bytes are buffered, and larger
+// objects are sent in a multipart upload, a part at a time.
+type Writer struct {
+	ctx      context.Context
+	store    *Store
+	key      string
+	buf      bytes.Buffer
+	uploadID string
+	etags    []string
+	err      error
+	done     bool
+	// ContentType is the type of the object, set before the first write.
+	ContentType string
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.done {
+		return 0, errors.New("write to closed object")
+	}
+	w.buf.Write(p)
+	// A full part is kept until more follows, so an object that fits in one is a single PUT
+	for w.buf.Len() > PartSize {
+		if w.err = w.writePart(w.buf.Next(PartSize)); w.err != nil {
+			w.abort()
+			return 0, w.err
+		}
+	}
+	return len(p), nil
+}
+
+// Close writes the rest of the object and completes the upload, so the object appears.
+func (w *Writer) Close() error {
+	if w.err != nil || w.done {
+		return w.err
+	}
+	w.done = true
+
+	if w.uploadID == "" {
+		_, _, w.err = w.store.do(w.ctx, http.MethodPut, w.key, nil, w.header(), w.buf.Bytes())
+		return w.err
+	}
+
+	if w.buf.Len() > 0 {
+		if w.err = w.writePart(w.buf.Bytes()); w.err != nil {
+			w.abort()
+			return w.err
+		}
+	}
+	type part struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	complete := struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}{}
+	for i, etag := range w.etags {
+		complete.Parts = append(complete.Parts, part{PartNumber: i + 1, ETag: etag})
+	}
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	_, data, err := w.store.do(w.ctx, http.MethodPost, w.key, url.Values{"uploadId": {w.uploadID}}, nil, body)
+	if err == nil && bytes.Contains(data, []byte("<Error>")) {
+		// S3 reports failures of completion that happen after the response started in its body
+		err = fmt.Errorf("completing upload of %s failed: %s", w.key, errorMessage(data))
+	}
+	if err != nil {
+		w.err = err
+		w.abort()
+	}
+	return w.err
+}
+
+// Abort discards the object. Parts already uploaded are deleted.
+func (w *Writer) Abort() {
+	if w.done {
+		return
+	}
+	w.done = true
+	if w.err == nil {
+		w.err = errors.New("upload aborted")
+	}
+	w.abort()
+}
+
+func (w *Writer) abort() {
+	w.done = true
+	if w.uploadID == "" {
+		return
+	}
+	// Parts left behind are billed until deleted, so this is tried even if the write was canceled
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), 30*time.Second)
+	defer cancel()
+	_, _, _ = w.store.do(ctx, http.MethodDelete, w.key, url.Values{"uploadId": {w.uploadID}}, nil, nil)
+	w.uploadID = ""
+}
+
+// writePart uploads the next part, starting the multipart upload first if needed.
+func (w *Writer) writePart(data []byte) error {
+	if w.uploadID == "" {
+		_, body, err := w.store.do(w.ctx, http.MethodPost, w.key, url.Values{"uploads": {""}}, w.header(), nil)
+		if err != nil {
+			return err
+		}
+		var initiated struct {
+			UploadID string `xml:"UploadId"`
+		}
+		if err = xml.Unmarshal(body, &initiated); err != nil || initiated.UploadID == "" {
+			return fmt.Errorf("starting upload of %s failed: no upload ID in response", w.key)
+		}
+		w.uploadID = initiated.UploadID
+	}
+
+	query := url.Values{"partNumber": {strconv.Itoa(len(w.etags) + 1)}, "uploadId": {w.uploadID}}
+	header, _, err := w.store.do(w.ctx, http.MethodPut, w.key, query, nil, data)
+	if err != nil {
+		return err
+	}
+	w.etags = append(w.etags, header.Get("ETag"))
+	return nil
+}
+
+func (w *Writer) header() http.Header {
+	header := http.Header{}
+	if w.ContentType != "" {
+		header.Set("Content-Type", w.ContentType)
+	}
+	return header
+}
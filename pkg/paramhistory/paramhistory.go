@@ -0,0 +1,106 @@
+/*
+Package paramhistory remembers the params tools were called with in the request builder of
+the shell, newest first, to recall them for the next call.
+*/
+package paramhistory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+// MaxEntries is the number of calls remembered for each tool.
+const MaxEntries = 20
+
+// Entry is the params of a call and when it was made.
+type Entry struct {
+	Params map[string]any `json:"params"`
+	Time   time.Time      `json:"time"`
+}
+
+// History stores the entries of each server, by server name then tool, newest first.
+type History map[string]map[string][]Entry
+
+// GetConfigPath returns the path to the history file.
+func GetConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	configDir := filepath.Join(homeDir, ".mcpt")
+	if mkdirErr := os.MkdirAll(configDir, 0o750); mkdirErr != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", mkdirErr)
+	}
+
+	return filepath.Join(configDir, "param-history.json"), nil
+}
+
+// Load loads the history from the history file.
+func Load() (History, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	history := make(History)
+	data, err := os.ReadFile(configPath) // #nosec G304 - configPath is generated internally by GetConfigPath
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read params history: %w", err)
+	}
+	if len(data) == 0 {
+		return history, nil
+	}
+	if err = json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse params history: %w", err)
+	}
+	return history, nil
+}
+
+// Save saves the history to the history file.
+func Save(history History) error {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal params history: %w", err)
+	}
+	if err = os.WriteFile(configPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write params history: %w", err)
+	}
+	return nil
+}
+
+// List returns the entries of tool of server, newest first.
+func (h History) List(server, tool string) []Entry {
+	return h[server][tool]
+}
+
+// Add remembers a call of tool of server with params at t. A call with the same params as an
+// earlier one moves it to the front; the oldest entries beyond MaxEntries are forgotten.
+func (h History) Add(server, tool string, params map[string]any, t time.Time) {
+	if h[server] == nil {
+		h[server] = map[string][]Entry{}
+	}
+	if params == nil {
+		params = map[string]any{}
+	}
+
+	entries := []Entry{{Params: params, Time: t.UTC()}}
+	for _, entry := range h[server][tool] {
+		if !reflect.DeepEqual(entry.Params, params) && len(entries) < MaxEntries {
+			entries = append(entries, entry)
+		}
+	}
+	h[server][tool] = entries
+}
@@ -0,0 +1,45 @@
+package paramhistory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	history, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	history.Add("fs", "read_file", map[string]any{"path": "a.txt"}, start)
+	history.Add("fs", "read_file", map[string]any{"path": "b.txt"}, start.Add(time.Minute))
+	history.Add("fs", "read_file", map[string]any{"path": "a.txt"}, start.Add(2*time.Minute))
+	history.Add("fs", "list_directory", nil, start)
+	if err = Save(history); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := loaded.List("fs", "read_file")
+	if len(entries) != 2 || entries[0].Params["path"] != "a.txt" || !entries[0].Time.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("List() = %+v, want a.txt moved to the front of 2 entries", entries)
+	}
+	if entries = loaded.List("fs", "list_directory"); len(entries) != 1 || entries[0].Params == nil {
+		t.Errorf("List() of a call without params = %+v", entries)
+	}
+	if entries = loaded.List("git", "read_file"); len(entries) != 0 {
+		t.Errorf("List() of another server = %+v, want none", entries)
+	}
+
+	for i := range MaxEntries + 5 {
+		loaded.Add("fs", "search", map[string]any{"query": i}, start.Add(time.Duration(i)*time.Second))
+	}
+	if entries = loaded.List("fs", "search"); len(entries) != MaxEntries || entries[0].Params["query"] != MaxEntries+4 {
+		t.Errorf("List() after many calls = %d entries, newest %v", len(entries), entries[0].Params)
+	}
+}
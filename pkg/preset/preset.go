@@ -0,0 +1,156 @@
+/*
+Package preset stores named sets of tool arguments, saved from the forms of the web interface
+to fill them again, and shared as links.
+*/
+package preset
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrInvalidLink is returned when decoding a shared preset that is not one.
+var ErrInvalidLink = errors.New("invalid preset link")
+
+// Preset is a named set of arguments for a tool of a server.
+type Preset struct {
+	Name   string         `json:"name"`
+	Tool   string         `json:"tool"`
+	Params map[string]any `json:"params"`
+}
+
+// Presets stores the presets of each server, by server name, then tool, then preset name.
+type Presets map[string]map[string]map[string]map[string]any
+
+// GetConfigPath returns the path to the presets file.
+func GetConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	configDir := filepath.Join(homeDir, ".mcpt")
+	if mkdirErr := os.MkdirAll(configDir, 0o750); mkdirErr != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", mkdirErr)
+	}
+
+	return filepath.Join(configDir, "presets.json"), nil
+}
+
+// Load loads the presets from the presets file.
+func Load() (Presets, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	presets := make(Presets)
+	data, err := os.ReadFile(configPath) // #nosec G304 - configPath is generated internally by GetConfigPath
+	if os.IsNotExist(err) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets file: %w", err)
+	}
+	if len(data) == 0 {
+		return presets, nil
+	}
+	if err = json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("failed to parse presets file: %w", err)
+	}
+	return presets, nil
+}
+
+// Save saves the presets to the presets file.
+func Save(presets Presets) error {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal presets: %w", err)
+	}
+	if err = os.WriteFile(configPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write presets file: %w", err)
+	}
+	return nil
+}
+
+// List returns the presets of server, for tool or for every tool if tool is empty, sorted by
+// tool and name.
+func (p Presets) List(server, tool string) []Preset {
+	list := []Preset{}
+	for toolName, named := range p[server] {
+		if tool != "" && toolName != tool {
+			continue
+		}
+		for name, params := range named {
+			list = append(list, Preset{Name: name, Tool: toolName, Params: params})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Tool != list[j].Tool {
+			return list[i].Tool < list[j].Tool
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Set adds preset to the presets of server, replacing any of the same tool and name.
+func (p Presets) Set(server string, preset Preset) {
+	if p[server] == nil {
+		p[server] = map[string]map[string]map[string]any{}
+	}
+	if p[server][preset.Tool] == nil {
+		p[server][preset.Tool] = map[string]map[string]any{}
+	}
+	p[server][preset.Tool][preset.Name] = preset.Params
+}
+
+// Delete removes the preset called name of tool of server, and reports whether there was one.
+func (p Presets) Delete(server, tool, name string) bool {
+	if _, ok := p[server][tool][name]; !ok {
+		return false
+	}
+	delete(p[server][tool], name)
+	if len(p[server][tool]) == 0 {
+		delete(p[server], tool)
+	}
+	if len(p[server]) == 0 {
+		delete(p, server)
+	}
+	return true
+}
+
+// Encode encodes preset for a share link: URL-safe base64 of its JSON.
+func Encode(preset Preset) (string, error) {
+	data, err := json.Marshal(preset)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Decode decodes a preset encoded by Encode.
+func Decode(link string) (Preset, error) {
+	var preset Preset
+	data, err := base64.RawURLEncoding.DecodeString(link)
+	if err != nil {
+		return preset, fmt.Errorf("%w: %w", ErrInvalidLink, err)
+	}
+	if err = json.Unmarshal(data, &preset); err != nil {
+		return preset, fmt.Errorf("%w: %w", ErrInvalidLink, err)
+	}
+	if preset.Tool == "" {
+		return preset, fmt.Errorf("%w: no tool", ErrInvalidLink)
+	}
+	return preset, nil
+}
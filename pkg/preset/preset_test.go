@@ -0,0 +1,62 @@
+package preset
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	presets, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	presets.Set("fs", Preset{Name: "home", Tool: "list_directory", Params: map[string]any{"path": "~"}})
+	presets.Set("fs", Preset{Name: "docs", Tool: "list_directory", Params: map[string]any{"path": "~/docs"}})
+	presets.Set("fs", Preset{Name: "notes", Tool: "read_file", Params: map[string]any{"path": "notes.md"}})
+	if err = Save(presets); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := loaded.List("fs", "list_directory")
+	if len(list) != 2 || list[0].Name != "docs" || list[1].Params["path"] != "~" {
+		t.Errorf("List() = %+v, want docs then home", list)
+	}
+	if all := loaded.List("fs", ""); len(all) != 3 {
+		t.Errorf("List() of every tool = %+v, want 3 presets", all)
+	}
+
+	if !loaded.Delete("fs", "read_file", "notes") || loaded.Delete("fs", "read_file", "notes") {
+		t.Error("Delete() should remove a preset once")
+	}
+	if _, ok := loaded["fs"]["read_file"]; ok {
+		t.Error("Delete() left an empty tool")
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	preset := Preset{Name: "docs", Tool: "list_directory", Params: map[string]any{"path": "~/docs", "depth": 2.0}}
+	link, err := Encode(preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, preset) {
+		t.Errorf("Decode(Encode()) = %+v, want %+v", decoded, preset)
+	}
+
+	for _, link := range []string{"not base64!", "bnVsbA", "e30"} {
+		if _, err = Decode(link); !errors.Is(err, ErrInvalidLink) {
+			t.Errorf("Decode(%q) error = %v, want %v", link, err, ErrInvalidLink)
+		}
+	}
+}
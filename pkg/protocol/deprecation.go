@@ -0,0 +1,24 @@
+package protocol
+
+// Deprecation reports whether a tool, prompt or resource from a list response is marked as
+// deprecated, and the server's explanation if it gave one. Servers mark entries with
+// "deprecated" set to true or to a message, either in "annotations" or in "_meta".
+func Deprecation(entry map[string]any) (string, bool) {
+	for _, field := range []string{"annotations", "_meta"} {
+		container, ok := entry[field].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch v := container["deprecated"].(type) {
+		case bool:
+			if v {
+				return "", true
+			}
+		case string:
+			return v, true
+		}
+	}
+
+	return "", false
+}
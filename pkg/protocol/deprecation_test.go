@@ -0,0 +1,33 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDeprecation(t *testing.T) {
+	tests := []struct {
+		name           string
+		entry          string
+		wantDeprecated bool
+		wantMessage    string
+	}{
+		{"not deprecated", `{"name":"a","annotations":{"readOnlyHint":true}}`, false, ""},
+		{"annotation flag", `{"name":"a","annotations":{"deprecated":true}}`, true, ""},
+		{"annotation false", `{"name":"a","annotations":{"deprecated":false}}`, false, ""},
+		{"meta message", `{"name":"a","_meta":{"deprecated":"use b instead"}}`, true, "use b instead"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(tt.entry), &entry); err != nil {
+				t.Fatal(err)
+			}
+			message, deprecated := Deprecation(entry)
+			if deprecated != tt.wantDeprecated || message != tt.wantMessage {
+				t.Errorf("Deprecation() = %q, %v; want %q, %v", message, deprecated, tt.wantMessage, tt.wantDeprecated)
+			}
+		})
+	}
+}
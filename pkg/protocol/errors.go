@@ -0,0 +1,143 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// RPCError is a JSON-RPC error returned by a server.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// errorCaptureKey is the context key of an ErrorCapture.
+type errorCaptureKey struct{}
+
+// ErrorCapture holds the JSON-RPC error returned for a request, so that it can be carried with
+// the error the mcp-go client reports by message alone.
+type ErrorCapture struct {
+	mu  sync.Mutex
+	err *RPCError
+}
+
+// CaptureErrors returns a context whose requests, sent through an ErrorTransport, record their
+// JSON-RPC error in the returned capture. Requests sent concurrently need contexts of their own.
+func CaptureErrors(ctx context.Context) (context.Context, *ErrorCapture) {
+	capture := &ErrorCapture{}
+	return context.WithValue(ctx, errorCaptureKey{}, capture), capture
+}
+
+// Err returns the JSON-RPC error of the last request that failed with one, or nil.
+func (c *ErrorCapture) Err() *RPCError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Attach returns err carrying the captured JSON-RPC error, which errors.As then finds, or err
+// itself if there is none or err does not report it, having another cause.
+func (c *ErrorCapture) Attach(err error) error {
+	rpcErr := c.Err()
+	if err == nil || rpcErr == nil || !strings.Contains(err.Error(), rpcErr.Message) {
+		return err
+	}
+	return &capturedError{err: err, rpc: rpcErr}
+}
+
+// capturedError is an error reported by the client, with the JSON-RPC error behind it.
+type capturedError struct {
+	err error
+	rpc *RPCError
+}
+
+func (e *capturedError) Error() string   { return e.err.Error() }
+func (e *capturedError) Unwrap() []error { return []error{e.err, e.rpc} }
+
+// recentErrors is the number of errors an ErrorLog keeps for Match.
+const recentErrors = 16
+
+// ErrorLog remembers the last JSON-RPC errors returned by a server. The mcp-go client reports
+// server errors by their message alone, so the log keeps the code and data for error reports.
+// Matching by message can pick the error of another request with the same text, so requests
+// sent concurrently should be sent with CaptureErrors instead.
+type ErrorLog struct {
+	mu     sync.Mutex
+	recent []*RPCError
+}
+
+// Last returns the last error recorded, or nil if there was none.
+func (l *ErrorLog) Last() *RPCError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.recent) == 0 {
+		return nil
+	}
+	return l.recent[len(l.recent)-1]
+}
+
+// Match returns the latest of the recent errors whose message err reports, or nil if there is
+// none. Unlike Last, it finds the error of a request that failed alongside others.
+func (l *ErrorLog) Match(err error) *RPCError {
+	if err == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := len(l.recent) - 1; i >= 0; i-- {
+		if message := l.recent[i].Message; message != "" && strings.Contains(err.Error(), message) {
+			return l.recent[i]
+		}
+	}
+	return nil
+}
+
+func (l *ErrorLog) record(err *RPCError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.recent) == recentErrors {
+		l.recent = l.recent[1:]
+	}
+	l.recent = append(l.recent, err)
+}
+
+// ErrorTransport records the errors in the responses of inner in an ErrorLog, and in the
+// ErrorCapture of the request's context, if any.
+type ErrorTransport struct {
+	transport.Interface
+	log *ErrorLog
+}
+
+// NewErrorTransport wraps inner so that error responses are recorded in log.
+func NewErrorTransport(inner transport.Interface, log *ErrorLog) *ErrorTransport {
+	return &ErrorTransport{Interface: inner, log: log}
+}
+
+// SendRequest sends request and records the error of the response, if any.
+func (t *ErrorTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	response, err := t.Interface.SendRequest(ctx, request)
+	if err == nil && response != nil && response.Error != nil {
+		rpcErr := &RPCError{
+			Code:    response.Error.Code,
+			Message: response.Error.Message,
+			Data:    response.Error.Data,
+		}
+		t.log.record(rpcErr)
+		if capture, ok := ctx.Value(errorCaptureKey{}).(*ErrorCapture); ok {
+			capture.mu.Lock()
+			capture.err = rpcErr
+			capture.mu.Unlock()
+		}
+	}
+	return response, err
+}
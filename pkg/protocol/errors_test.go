@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// codeTransport fails every request with the same message and the code given as its method.
+type codeTransport struct {
+	transport.Interface
+}
+
+func (codeTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	var code int
+	_, _ = fmt.Sscan(request.Method, &code)
+	response := &transport.JSONRPCResponse{JSONRPC: "2.0", ID: request.ID}
+	response.Error = &struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}{Code: code, Message: "request failed"}
+	return response, nil
+}
+
+func TestErrorCapture(t *testing.T) {
+	tr := NewErrorTransport(codeTransport{}, &ErrorLog{})
+
+	// Concurrent requests failing with the same message each get their own code
+	var wg sync.WaitGroup
+	for code := -32610; code < -32600; code++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, capture := CaptureErrors(context.Background())
+			if _, err := tr.SendRequest(ctx, transport.JSONRPCRequest{Method: fmt.Sprint(code)}); err != nil {
+				t.Error(err)
+				return
+			}
+			// The client reports the error by its message alone
+			err := capture.Attach(fmt.Errorf("failed to list: %w", errors.New("request failed")))
+			var rpcErr *RPCError
+			if !errors.As(err, &rpcErr) || rpcErr.Code != code {
+				t.Errorf("code %d: errors.As() = %v", code, rpcErr)
+			}
+			if err.Error() != "failed to list: request failed" {
+				t.Errorf("Attach() changed the message to %q", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	ctx, capture := CaptureErrors(context.Background())
+	_, _ = tr.SendRequest(ctx, transport.JSONRPCRequest{Method: "-32601"})
+	if err := capture.Attach(context.DeadlineExceeded); err != context.DeadlineExceeded {
+		t.Errorf("Attach() = %v, want an error of another cause left as is", err)
+	}
+	if capture.Attach(nil) != nil {
+		t.Error("Attach(nil) should stay nil")
+	}
+}
@@ -0,0 +1,51 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// IDTransport rewrites the integer request IDs generated by the mcp-go client into prefixed string
+// IDs, for gateways that correlate requests by globally unique IDs. Responses are mapped back to
+// the original IDs so the client can match them.
+type IDTransport struct {
+	transport.Interface
+	prefix string
+}
+
+// NewIDTransport wraps inner so that every request ID is sent as prefix followed by the
+// original number. The placeholder ${uuid} in prefix is replaced by a random UUID generated once
+// for the session.
+func NewIDTransport(inner transport.Interface, prefix string) *IDTransport {
+	return &IDTransport{
+		Interface: inner,
+		prefix:    ExpandIDPrefix(prefix),
+	}
+}
+
+// ExpandIDPrefix replaces the ${uuid} placeholder in prefix with a random UUID.
+func ExpandIDPrefix(prefix string) string {
+	if strings.Contains(prefix, "${uuid}") {
+		prefix = strings.ReplaceAll(prefix, "${uuid}", uuid.NewString())
+	}
+	return prefix
+}
+
+// SendRequest sends request under its prefixed ID and restores the original ID on the response.
+func (t *IDTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	originalID := request.ID
+	request.ID = mcp.NewRequestId(fmt.Sprintf("%s%v", t.prefix, originalID.Value()))
+
+	response, err := t.Interface.SendRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	response.ID = originalID
+	return response, nil
+}
@@ -0,0 +1,46 @@
+package protocol
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// echoTransport answers every request with an empty result carrying the request ID.
+type echoTransport struct {
+	transport.Interface
+	lastID mcp.RequestId
+}
+
+func (e *echoTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	e.lastID = request.ID
+	return &transport.JSONRPCResponse{JSONRPC: "2.0", ID: request.ID}, nil
+}
+
+func TestIDTransport(t *testing.T) {
+	inner := &echoTransport{}
+	tr := NewIDTransport(inner, "mcpt-${uuid}-")
+
+	resp, err := tr.SendRequest(context.Background(), transport.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      mcp.NewRequestId(int64(4)),
+		Method:  "tools/list",
+	})
+	if err != nil {
+		t.Fatalf("SendRequest() error = %v", err)
+	}
+
+	sent, ok := inner.lastID.Value().(string)
+	if !ok {
+		t.Fatalf("expected a string ID on the wire, got %T", inner.lastID.Value())
+	}
+	if !strings.HasPrefix(sent, "mcpt-") || !strings.HasSuffix(sent, "-4") || strings.Contains(sent, "${uuid}") {
+		t.Errorf("unexpected wire ID %q", sent)
+	}
+	if resp.ID.Value() != int64(4) {
+		t.Errorf("expected the response ID to be restored to 4, got %v", resp.ID.Value())
+	}
+}
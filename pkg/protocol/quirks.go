@@ -0,0 +1,108 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Quirk is a workaround for a known non-conformant server behaviour. Fix rewrites a message
+// received from the server and returns the adjusted message, or nil to drop it.
+type Quirk struct {
+	Fix         func(line []byte) []byte
+	Name        string
+	Description string
+}
+
+// quirks is the registry of known workarounds, keyed by name.
+var quirks = map[string]Quirk{
+	"banner": {
+		Name:        "banner",
+		Description: "Drop banners and log output printed to stdout, including text preceding a JSON message",
+		Fix:         dropNonJSON,
+	},
+	"string-ids": {
+		Name:        "string-ids",
+		Description: "Accept numeric request IDs echoed back as strings (\"1\" instead of 1)",
+		Fix:         numericStringIDs,
+	},
+}
+
+// Quirks returns all registered quirks sorted by name.
+func Quirks() []Quirk {
+	list := make([]Quirk, 0, len(quirks))
+	for _, q := range quirks {
+		list = append(list, q)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LookupQuirks resolves a comma-separated list of quirk names.
+func LookupQuirks(names string) ([]Quirk, error) {
+	var list []Quirk
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		q, ok := quirks[name]
+		if !ok {
+			available := make([]string, 0, len(quirks))
+			for _, known := range Quirks() {
+				available = append(available, known.Name)
+			}
+			return nil, fmt.Errorf("unknown quirk: %s (available: %s)", name, strings.Join(available, ", "))
+		}
+		list = append(list, q)
+	}
+	return list, nil
+}
+
+// dropNonJSON drops any line that is not a JSON object. Text printed in front of a JSON object on
+// the same line (for example a prompt without a trailing newline) is stripped.
+func dropNonJSON(line []byte) []byte {
+	start := bytes.IndexByte(line, '{')
+	if start == -1 {
+		return nil
+	}
+
+	candidate := bytes.TrimSpace(line[start:])
+	if !json.Valid(candidate) {
+		return nil
+	}
+	return candidate
+}
+
+// numericStringIDs rewrites a string id that holds an integer back into a JSON number, in
+// responses only. Requests the server sends keep their ids, which the client must echo as given.
+func numericStringIDs(line []byte) []byte {
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return line
+	}
+	if _, isRequest := msg["method"]; isRequest {
+		return line
+	}
+
+	var id string
+	if err := json.Unmarshal(msg["id"], &id); err != nil {
+		return line
+	}
+
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return line
+	}
+
+	msg["id"] = json.RawMessage(strconv.FormatInt(n, 10))
+	fixed, err := json.Marshal(msg)
+	if err != nil {
+		return line
+	}
+	return fixed
+}
@@ -0,0 +1,45 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestLookupQuirks(t *testing.T) {
+	list, err := LookupQuirks("banner, string-ids")
+	if err != nil {
+		t.Fatalf("LookupQuirks() error = %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 quirks, got %d", len(list))
+	}
+
+	if _, err := LookupQuirks("nope"); err == nil {
+		t.Error("expected an error for an unknown quirk")
+	}
+}
+
+func TestQuirkFixes(t *testing.T) {
+	tests := []struct {
+		name  string
+		quirk string
+		line  string
+		want  string
+	}{
+		{"banner line dropped", "banner", "Server ready on stdio", ""},
+		{"banner prefix stripped", "banner", `ready> {"jsonrpc":"2.0","id":1,"result":{}}`, `{"jsonrpc":"2.0","id":1,"result":{}}`},
+		{"json passes banner", "banner", `{"jsonrpc":"2.0","id":1,"result":{}}`, `{"jsonrpc":"2.0","id":1,"result":{}}`},
+		{"string id rewritten", "string-ids", `{"id":"3","jsonrpc":"2.0","result":{}}`, `{"id":3,"jsonrpc":"2.0","result":{}}`},
+		{"server request id kept", "string-ids", `{"id":"3","jsonrpc":"2.0","method":"ping"}`, `{"id":"3","jsonrpc":"2.0","method":"ping"}`},
+		{"non-numeric id kept", "string-ids", `{"id":"abc","jsonrpc":"2.0"}`, `{"id":"abc","jsonrpc":"2.0"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := quirks[tt.quirk]
+			got := q.Fix([]byte(tt.line))
+			if string(got) != tt.want {
+				t.Errorf("Fix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// Package protocol implements checks and adjustments of the MCP JSON-RPC message stream.
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ErrViolation is returned when a server deviates from the protocol in strict mode.
+var ErrViolation = errors.New("protocol violation")
+
+// message holds the top-level JSON-RPC fields used for validation.
+type message struct {
+	JSONRPC *string         `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Result  json.RawMessage `json:"result"`
+	Error   json.RawMessage `json:"error"`
+}
+
+// Validator checks a client session for protocol deviations. It tracks outgoing requests so that
+// responses can be matched against them, and keeps the first deviation found.
+type Validator struct {
+	pending   map[string]string
+	violation error
+	failed    chan struct{}
+	once      sync.Once
+	mutex     sync.Mutex
+}
+
+// NewValidator creates a validator with no outstanding requests.
+func NewValidator() *Validator {
+	return &Validator{
+		pending: make(map[string]string),
+		failed:  make(chan struct{}),
+	}
+}
+
+// Err returns the first deviation found in the session, or nil.
+func (v *Validator) Err() error {
+	select {
+	case <-v.failed:
+		return v.violation
+	default:
+		return nil
+	}
+}
+
+// fail records err as a deviation of the session and returns it. Only the first one is kept.
+func (v *Validator) fail(err error) error {
+	if err != nil {
+		v.once.Do(func() {
+			v.violation = err
+			close(v.failed)
+		})
+	}
+	return err
+}
+
+// CheckOutgoing records a message sent by the client.
+func (v *Validator) CheckOutgoing(line []byte) error {
+	return v.fail(v.checkOutgoing(line))
+}
+
+// CheckIncoming validates a message received from the server.
+func (v *Validator) CheckIncoming(line []byte) error {
+	return v.fail(v.checkIncoming(line))
+}
+
+func (v *Validator) checkOutgoing(line []byte) error {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return fmt.Errorf("%w: client sent invalid JSON: %w", ErrViolation, err)
+	}
+
+	if msg.Method != "" && hasID(msg.ID) {
+		v.mutex.Lock()
+		v.pending[idKey(msg.ID)] = msg.Method
+		v.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (v *Validator) checkIncoming(line []byte) error {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return fmt.Errorf("%w: server sent a non-JSON message: %q", ErrViolation, truncate(line))
+	}
+
+	if msg.JSONRPC == nil {
+		return fmt.Errorf("%w: message is missing the jsonrpc field: %q", ErrViolation, truncate(line))
+	}
+	if *msg.JSONRPC != mcp.JSONRPC_VERSION {
+		return fmt.Errorf("%w: unexpected jsonrpc version %q", ErrViolation, *msg.JSONRPC)
+	}
+
+	// Requests and notifications from the server
+	if msg.Method != "" {
+		if strings.HasPrefix(msg.Method, "notifications/") && hasID(msg.ID) {
+			return fmt.Errorf("%w: notification %s must not have an id", ErrViolation, msg.Method)
+		}
+		return nil
+	}
+
+	// Responses to client requests
+	if !hasID(msg.ID) {
+		return fmt.Errorf("%w: response is missing an id: %q", ErrViolation, truncate(line))
+	}
+
+	key := idKey(msg.ID)
+	v.mutex.Lock()
+	method, known := v.pending[key]
+	delete(v.pending, key)
+	v.mutex.Unlock()
+
+	if !known {
+		return fmt.Errorf("%w: response to unknown id %s", ErrViolation, string(msg.ID))
+	}
+
+	hasResult := len(msg.Result) > 0
+	hasError := len(msg.Error) > 0
+	if hasResult == hasError {
+		return fmt.Errorf("%w: response to %s must have exactly one of result or error", ErrViolation, method)
+	}
+
+	if method == "initialize" && hasResult {
+		return checkInitializeResult(msg.Result)
+	}
+
+	return nil
+}
+
+// checkInitializeResult verifies the shape of an initialize result.
+func checkInitializeResult(raw json.RawMessage) error {
+	var result struct {
+		ProtocolVersion *string         `json:"protocolVersion"`
+		Capabilities    json.RawMessage `json:"capabilities"`
+		ServerInfo      *struct {
+			Name    *string `json:"name"`
+			Version *string `json:"version"`
+		} `json:"serverInfo"`
+	}
+
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return fmt.Errorf("%w: initialize result has the wrong shape: %w", ErrViolation, err)
+	}
+
+	switch {
+	case result.ProtocolVersion == nil:
+		return fmt.Errorf("%w: initialize result is missing protocolVersion", ErrViolation)
+	case len(result.Capabilities) == 0 || result.Capabilities[0] != '{':
+		return fmt.Errorf("%w: initialize result is missing the capabilities object", ErrViolation)
+	case result.ServerInfo == nil:
+		return fmt.Errorf("%w: initialize result is missing serverInfo", ErrViolation)
+	case result.ServerInfo.Name == nil || result.ServerInfo.Version == nil:
+		return fmt.Errorf("%w: initialize serverInfo must have a name and version", ErrViolation)
+	}
+
+	return nil
+}
+
+// hasID reports whether a raw id field is present and not null.
+func hasID(id json.RawMessage) bool {
+	return len(id) > 0 && !bytes.Equal(id, []byte("null"))
+}
+
+// idKey normalises a raw id so that equivalent encodings compare equal.
+func idKey(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, id); err != nil {
+		return string(id)
+	}
+	return buf.String()
+}
+
+// truncate shortens a message for inclusion in an error.
+func truncate(line []byte) string {
+	const maxLen = 120
+	if len(line) > maxLen {
+		return string(line[:maxLen]) + "..."
+	}
+	return string(line)
+}
+
+// StrictTransport fails the requests of a transport whose raw messages are validated on the way,
+// such as the HTTP transports with NewStrictRoundTripper, as soon as a deviation is found. Without
+// it, a response rejected in an event stream would leave its request waiting until it times out.
+type StrictTransport struct {
+	transport.Interface
+	validator *Validator
+}
+
+// NewStrictTransport wraps inner so that its requests fail with the deviations validator finds.
+func NewStrictTransport(inner transport.Interface, validator *Validator) *StrictTransport {
+	return &StrictTransport{
+		Interface: inner,
+		validator: validator,
+	}
+}
+
+// SendRequest sends the request, failing it with the first deviation found in the session.
+func (t *StrictTransport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if err := t.validator.Err(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-t.validator.failed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	response, err := t.Interface.SendRequest(ctx, request)
+	if violation := t.validator.Err(); violation != nil {
+		return nil, violation
+	}
+	return response, err
+}
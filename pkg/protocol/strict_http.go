@@ -0,0 +1,155 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// strictRoundTripper validates the raw messages exchanged with an HTTP server.
+type strictRoundTripper struct {
+	inner     http.RoundTripper
+	validator *Validator
+}
+
+// NewStrictRoundTripper wraps inner so that the JSON-RPC messages in request and response bodies
+// are validated as sent, whether responses come as JSON or as server-sent events. Bodies of failed
+// HTTP requests are left alone. Deviations fail the HTTP request, or the event stream they are
+// found in, and are kept by validator for NewStrictTransport to fail the MCP request with.
+func NewStrictRoundTripper(inner http.RoundTripper, validator *Validator) http.RoundTripper {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	return &strictRoundTripper{inner: inner, validator: validator}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *strictRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err = eachMessage(body, t.validator.CheckOutgoing); err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, err
+	}
+
+	switch mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType {
+	case "application/json":
+		body, readErr := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		if err = eachMessage(body, t.validator.CheckIncoming); err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	case "text/event-stream":
+		resp.Body = &strictEventReader{source: resp.Body, validator: t.validator}
+	}
+	return resp, nil
+}
+
+// eachMessage calls check with every message of a JSON-RPC body, a single message or a batch.
+// Empty bodies, such as those of accepted notifications, hold none.
+func eachMessage(body []byte, check func(line []byte) error) error {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	if body[0] != '[' {
+		return check(body)
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return check(body)
+	}
+	for _, msg := range batch {
+		if err := check(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// strictEventReader passes a server-sent event stream through, validating the data of every
+// message event once the blank line ending it is read.
+type strictEventReader struct {
+	source    io.ReadCloser
+	validator *Validator
+	// line is the part of the current line read so far, and event and data those of the
+	// current event.
+	line  []byte
+	event string
+	data  []byte
+	err   error
+}
+
+// Read implements io.Reader. A chunk completing an event that deviates from the protocol is
+// withheld, and the stream fails with the deviation.
+func (r *strictEventReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.source.Read(p)
+	for _, b := range p[:n] {
+		if b != '\n' {
+			r.line = append(r.line, b)
+			continue
+		}
+		if checkErr := r.endLine(bytes.TrimRight(r.line, "\r")); checkErr != nil {
+			r.err = checkErr
+			return 0, checkErr
+		}
+		r.line = r.line[:0]
+	}
+	return n, err
+}
+
+// endLine handles a complete line of the stream.
+func (r *strictEventReader) endLine(line []byte) error {
+	field, value, _ := bytes.Cut(line, []byte(":"))
+	value = bytes.TrimPrefix(value, []byte(" "))
+	switch {
+	case len(line) == 0:
+		event, data := r.event, r.data
+		r.event, r.data = "", nil
+		// Other events, such as the endpoint event of the SSE transport, carry no message
+		if data == nil || (event != "" && event != "message") {
+			return nil
+		}
+		return r.validator.CheckIncoming(data)
+	case string(field) == "event":
+		r.event = string(value)
+	case string(field) == "data":
+		if r.data == nil {
+			r.data = []byte{}
+		} else {
+			r.data = append(r.data, '\n')
+		}
+		r.data = append(r.data, value...)
+	}
+	return nil
+}
+
+// Close implements io.Closer.
+func (r *strictEventReader) Close() error {
+	return r.source.Close()
+}
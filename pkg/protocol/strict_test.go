@@ -0,0 +1,174 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+func TestValidatorCheckIncoming(t *testing.T) {
+	tests := []struct {
+		name     string
+		outgoing []string
+		incoming string
+		wantErr  bool
+	}{
+		{
+			name:     "valid response",
+			outgoing: []string{`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`},
+			incoming: `{"jsonrpc":"2.0","id":1,"result":{"tools":[]}}`,
+		},
+		{
+			name:     "missing jsonrpc field",
+			outgoing: []string{`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`},
+			incoming: `{"id":1,"result":{}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "response to unknown id",
+			outgoing: []string{`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`},
+			incoming: `{"jsonrpc":"2.0","id":7,"result":{}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "notification with id",
+			incoming: `{"jsonrpc":"2.0","id":3,"method":"notifications/progress"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "plain notification",
+			incoming: `{"jsonrpc":"2.0","method":"notifications/progress","params":{}}`,
+		},
+		{
+			name:     "non-JSON output",
+			incoming: `Server listening on stdio`,
+			wantErr:  true,
+		},
+		{
+			name:     "initialize result without serverInfo",
+			outgoing: []string{`{"jsonrpc":"2.0","id":"a","method":"initialize"}`},
+			incoming: `{"jsonrpc":"2.0","id":"a","result":{"protocolVersion":"2024-11-05","capabilities":{}}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "valid initialize result",
+			outgoing: []string{`{"jsonrpc":"2.0","id":"a","method":"initialize"}`},
+			incoming: `{"jsonrpc":"2.0","id":"a","result":{"protocolVersion":"2024-11-05","capabilities":{},"serverInfo":{"name":"s","version":"1"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator()
+			for _, line := range tt.outgoing {
+				if err := v.CheckOutgoing([]byte(line)); err != nil {
+					t.Fatalf("CheckOutgoing() error = %v", err)
+				}
+			}
+
+			err := v.CheckIncoming([]byte(tt.incoming))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckIncoming() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrViolation) {
+				t.Errorf("expected ErrViolation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStrictRoundTripper(t *testing.T) {
+	const request = `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     bool
+	}{
+		{"valid JSON response", "application/json", `{"jsonrpc":"2.0","id":1,"result":{"tools":[]}}`, false},
+		{"missing jsonrpc field", "application/json", `{"id":1,"result":{}}`, true},
+		{"response to unknown id", "application/json", `{"jsonrpc":"2.0","id":7,"result":{}}`, true},
+		{"valid event stream", "text/event-stream",
+			"event: endpoint\ndata: /messages?session=1\n\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n" +
+				"event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{}}\n\n", false},
+		{"notification with id in event stream", "text/event-stream",
+			"data: {\"jsonrpc\":\"2.0\",\"id\":3,\"method\":\"notifications/progress\"}\n\n", true},
+		{"other content not checked", "text/plain", "rate limited", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if body, _ := io.ReadAll(r.Body); string(body) != request {
+					t.Errorf("server received %q, want %q", body, request)
+				}
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			v := NewValidator()
+			client := &http.Client{Transport: NewStrictRoundTripper(nil, v)}
+			resp, err := client.Post(server.URL, "application/json", strings.NewReader(request))
+			var body []byte
+			if err == nil {
+				body, err = io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("request error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrViolation) || !errors.Is(v.Err(), ErrViolation) {
+					t.Errorf("error = %v, validator error = %v, want %v", err, v.Err(), ErrViolation)
+				}
+			} else if string(body) != tt.body {
+				t.Errorf("body = %q, want it passed on unchanged as %q", body, tt.body)
+			}
+		})
+	}
+}
+
+// blockingTransport is a transport whose requests wait until their context ends.
+type blockingTransport struct {
+	transport.Interface
+}
+
+func (blockingTransport) SendRequest(ctx context.Context, _ transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestStrictTransportFailsPendingRequests(t *testing.T) {
+	v := NewValidator()
+	strict := NewStrictTransport(blockingTransport{}, v)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := strict.SendRequest(context.Background(), transport.JSONRPCRequest{Method: "tools/list"})
+		done <- err
+	}()
+	// A notification with an id, found in an event stream while the request waits
+	_ = v.CheckIncoming([]byte(`{"jsonrpc":"2.0","id":3,"method":"notifications/progress"}`))
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrViolation) {
+			t.Errorf("SendRequest() error = %v, want %v", err, ErrViolation)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SendRequest() kept waiting after a violation")
+	}
+
+	if _, err := strict.SendRequest(context.Background(), transport.JSONRPCRequest{Method: "ping"}); !errors.Is(err, ErrViolation) {
+		t.Errorf("SendRequest() after a violation error = %v, want %v", err, ErrViolation)
+	}
+}
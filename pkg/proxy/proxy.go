@@ -36,7 +36,7 @@ type Server struct {
 	// Fields ordered for optimal memory alignment (8-byte aligned fields first)
 	tools   map[string]Tool
 	logFile *os.File
-	id      int
+	id      json.RawMessage
 }
 
 // NewProxyServer creates a new proxy server.
@@ -75,7 +75,6 @@ func NewProxyServer() (*Server, error) {
 
 	return &Server{
 		tools:   make(map[string]Tool),
-		id:      0,
 		logFile: logFile,
 	}, nil
 }
@@ -339,7 +338,7 @@ func (s *Server) Start() error {
 			Method  string                 `json:"method"`           // string (16 bytes: pointer + len)
 			Params  map[string]interface{} `json:"params,omitempty"` // map (8 bytes)
 			JSONRPC string                 `json:"jsonrpc"`          // string (16 bytes: pointer + len)
-			ID      int                    `json:"id"`               // int (8 bytes)
+			ID      json.RawMessage        `json:"id,omitempty"`     // []byte (24 bytes)
 		}
 
 		fmt.Fprintf(os.Stderr, "Waiting for request...\n")
@@ -355,7 +354,7 @@ func (s *Server) Start() error {
 
 		// Log the incoming request
 		s.logJSON("Received request", request)
-		fmt.Fprintf(os.Stderr, "Received request: %s (ID: %d)\n", request.Method, request.ID)
+		fmt.Fprintf(os.Stderr, "Received request: %s (ID: %s)\n", request.Method, string(request.ID))
 		s.id = request.ID
 
 		// Handle notifications (methods without an ID)
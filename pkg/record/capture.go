@@ -0,0 +1,338 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats of captures made by other tools.
+const (
+	CaptureAuto      = "auto"
+	CaptureInspector = "inspector"
+	CaptureJSONL     = "jsonl"
+)
+
+// Captured is a message of a session captured by another tool.
+type Captured struct {
+	Time      time.Time
+	Direction string
+	Message   json.RawMessage
+}
+
+// ParseCapture reads the messages of a capture made by another tool, in format or, with
+// CaptureAuto, the format its content looks like:
+//
+//   - CaptureInspector: the history of the MCP Inspector, an array of {"request", "response"}
+//     pairs or an object holding one as requestHistory or history, and the notifications it
+//     showed as notifications or serverNotifications. Requests and responses may be JSON
+//     objects or strings holding them, as the Inspector copies them. Request IDs are numbered
+//     in order, since the Inspector does not keep them.
+//   - CaptureJSONL: one JSON-RPC message or batch per line, or an object wrapping it as message
+//     or data with a time or timestamp and a direction.
+//
+// Messages without a time are spaced a millisecond apart from start, and messages without a
+// direction are assigned one from their method: servers send sampling, roots and elicitation
+// requests and the notifications only they send, and clients the rest.
+func ParseCapture(data []byte, format string, start time.Time) ([]Captured, error) {
+	if format == CaptureAuto {
+		format = detectCapture(data)
+	}
+
+	var messages []Captured
+	var err error
+	switch format {
+	case CaptureInspector:
+		messages, err = parseInspector(data)
+	case CaptureJSONL:
+		messages, err = parseJSONL(data)
+	default:
+		return nil, fmt.Errorf("unknown capture format %q, use %s, %s or %s", format, CaptureAuto, CaptureInspector, CaptureJSONL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	assignDirections(messages)
+	for i := range messages {
+		if messages[i].Time.IsZero() {
+			messages[i].Time = start.Add(time.Duration(i) * time.Millisecond)
+		}
+	}
+	return messages, nil
+}
+
+// detectCapture tells an Inspector export, a single JSON document that is not a JSON-RPC
+// message, from JSON lines.
+func detectCapture(data []byte) string {
+	var document any
+	if json.Unmarshal(data, &document) != nil {
+		return CaptureJSONL
+	}
+	switch value := document.(type) {
+	case map[string]any:
+		if _, ok := value["jsonrpc"]; ok {
+			return CaptureJSONL
+		}
+		return CaptureInspector
+	case []any:
+		// An array of pairs, rather than a JSON-RPC batch on a single line
+		if len(value) > 0 {
+			if pair, ok := value[0].(map[string]any); ok {
+				if _, isPair := pair["request"]; isPair {
+					return CaptureInspector
+				}
+			}
+		}
+	}
+	return CaptureJSONL
+}
+
+// inspectorPair is an entry of the request history of the Inspector.
+type inspectorPair struct {
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
+
+func parseInspector(data []byte) ([]Captured, error) {
+	var pairs []inspectorPair
+	var notifications []json.RawMessage
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &pairs); err != nil {
+			return nil, fmt.Errorf("failed to read Inspector history: %w", err)
+		}
+	} else {
+		var export struct {
+			RequestHistory      []inspectorPair   `json:"requestHistory"`
+			History             []inspectorPair   `json:"history"`
+			Notifications       []json.RawMessage `json:"notifications"`
+			ServerNotifications []json.RawMessage `json:"serverNotifications"`
+		}
+		if err := json.Unmarshal(data, &export); err != nil {
+			return nil, fmt.Errorf("failed to read Inspector export: %w", err)
+		}
+		pairs = append(export.RequestHistory, export.History...)
+		notifications = append(export.Notifications, export.ServerNotifications...)
+		if len(pairs) == 0 && len(notifications) == 0 {
+			return nil, fmt.Errorf("no requestHistory, history or notifications in the Inspector export")
+		}
+	}
+
+	var messages []Captured
+	for i, pair := range pairs {
+		request, err := unquoteJSON(pair.Request)
+		if err != nil || request == nil {
+			return nil, fmt.Errorf("request %d: not a JSON object", i+1)
+		}
+		id := i + 1
+		request["jsonrpc"] = "2.0"
+		request["id"] = id
+		message, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, Captured{Message: message})
+
+		response, err := unquoteJSON(pair.Response)
+		if err != nil {
+			return nil, fmt.Errorf("response %d: not a JSON object", i+1)
+		}
+		if response == nil {
+			// Requests still waiting, or that failed, have no response
+			continue
+		}
+		if _, envelope := response["jsonrpc"]; !envelope {
+			// The Inspector keeps the result only
+			response = map[string]any{"result": response}
+		}
+		response["jsonrpc"] = "2.0"
+		response["id"] = id
+		if message, err = json.Marshal(response); err != nil {
+			return nil, err
+		}
+		messages = append(messages, Captured{Message: message})
+	}
+
+	for i, raw := range notifications {
+		notification, err := unquoteJSON(raw)
+		if err != nil || notification == nil {
+			return nil, fmt.Errorf("notification %d: not a JSON object", i+1)
+		}
+		notification["jsonrpc"] = "2.0"
+		message, err := json.Marshal(notification)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, Captured{Message: message})
+	}
+	return messages, nil
+}
+
+// unquoteJSON decodes raw, a JSON object or a string holding one. It returns nil for null or
+// a missing value.
+func unquoteJSON(raw json.RawMessage) (map[string]any, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		raw = json.RawMessage(text)
+	}
+	var object map[string]any
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func parseJSONL(data []byte) ([]Captured, error) {
+	var messages []Captured
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		// Messages keep the line, which the scanner reuses
+		text := bytes.TrimSpace(bytes.Clone(scanner.Bytes()))
+		if len(text) == 0 {
+			continue
+		}
+		parsed, err := parseLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		messages = append(messages, parsed...)
+	}
+	return messages, scanner.Err()
+}
+
+// parseLine reads a message, a batch, or a message wrapped with its time and direction.
+func parseLine(text []byte) ([]Captured, error) {
+	if text[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(text, &batch); err != nil {
+			return nil, err
+		}
+		messages := make([]Captured, len(batch))
+		for i, message := range batch {
+			messages[i] = Captured{Message: message}
+		}
+		return messages, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(text, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["jsonrpc"]; ok {
+		return []Captured{{Message: json.RawMessage(text)}}, nil
+	}
+
+	wrapped := fields["message"]
+	if wrapped == nil {
+		wrapped = fields["data"]
+	}
+	if _, start := fields["server"]; wrapped == nil && start {
+		// The start of a session in a recording of mcptools names the server only
+		return nil, nil
+	}
+	if wrapped == nil {
+		return nil, fmt.Errorf("neither a JSON-RPC message nor one wrapped as message or data")
+	}
+	var direction string
+	for _, key := range []string{"direction", "type", "from"} {
+		if json.Unmarshal(fields[key], &direction) == nil && direction != "" {
+			break
+		}
+	}
+	captured := Captured{Direction: normalizeDirection(direction)}
+	for _, key := range []string{"time", "timestamp"} {
+		if t, ok := parseTime(fields[key]); ok {
+			captured.Time = t
+			break
+		}
+	}
+
+	parsed, err := parseLine(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	for i := range parsed {
+		parsed[i].Time, parsed[i].Direction = captured.Time, captured.Direction
+	}
+	return parsed, nil
+}
+
+// directions maps the words captures use for directions, from the side of the client.
+var directions = map[string]string{
+	"sent": DirectionSent, "send": DirectionSent, "outgoing": DirectionSent, "out": DirectionSent,
+	"client": DirectionSent, "to-server": DirectionSent,
+	"received": DirectionReceived, "receive": DirectionReceived, "incoming": DirectionReceived,
+	"in": DirectionReceived, "server": DirectionReceived, "from-server": DirectionReceived,
+}
+
+func normalizeDirection(direction string) string {
+	return directions[strings.ToLower(direction)]
+}
+
+// parseTime reads an RFC 3339 time or a Unix time in milliseconds.
+func parseTime(raw json.RawMessage) (time.Time, bool) {
+	if len(raw) == 0 {
+		return time.Time{}, false
+	}
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		t, err := time.Parse(time.RFC3339Nano, text)
+		return t, err == nil
+	}
+	ms, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(int64(ms)), true
+}
+
+// serverMethods are the requests and notifications only servers send.
+var serverMethods = map[string]bool{
+	"sampling/createMessage":               true,
+	"roots/list":                           true,
+	"elicitation/create":                   true,
+	"notifications/message":                true,
+	"notifications/resources/updated":      true,
+	"notifications/resources/list_changed": true,
+	"notifications/tools/list_changed":     true,
+	"notifications/prompts/list_changed":   true,
+}
+
+// assignDirections sets the direction of messages that have none: from their method for
+// requests and notifications, and opposite to their request for responses.
+func assignDirections(messages []Captured) {
+	requests := map[string]string{}
+	for i := range messages {
+		var msg struct {
+			Method string          `json:"method"`
+			ID     json.RawMessage `json:"id"`
+		}
+		_ = json.Unmarshal(messages[i].Message, &msg)
+
+		direction := messages[i].Direction
+		switch {
+		case direction != "":
+		case msg.Method != "" && serverMethods[msg.Method]:
+			direction = DirectionReceived
+		case msg.Method != "":
+			direction = DirectionSent
+		case requests[string(msg.ID)] == DirectionReceived:
+			direction = DirectionSent
+		default:
+			direction = DirectionReceived
+		}
+		messages[i].Direction = direction
+
+		if msg.Method != "" && len(msg.ID) > 0 {
+			requests[string(msg.ID)] = direction
+		}
+	}
+}
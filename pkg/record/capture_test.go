@@ -0,0 +1,96 @@
+package record
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// capturedSummary describes captured messages as "direction message" for comparison.
+func capturedSummary(t *testing.T, messages []Captured) []string {
+	t.Helper()
+	summary := make([]string, len(messages))
+	for i, message := range messages {
+		var value any
+		if err := json.Unmarshal(message.Message, &value); err != nil {
+			t.Fatalf("message %d is not JSON: %v", i, err)
+		}
+		data, _ := json.Marshal(value)
+		summary[i] = message.Direction + " " + string(data)
+	}
+	return summary
+}
+
+func TestParseCaptureInspector(t *testing.T) {
+	export := `{
+		"requestHistory": [
+			{"request": "{\"method\":\"tools/list\",\"params\":{}}", "response": "{\"tools\":[]}"},
+			{"request": {"method": "tools/call", "params": {"name": "echo"}}, "response": {"content": []}},
+			{"request": {"method": "ping"}}
+		],
+		"notifications": [{"method": "notifications/message", "params": {"level": "info", "data": "hi"}}]
+	}`
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	messages, err := ParseCapture([]byte(export), CaptureAuto, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`sent {"id":1,"jsonrpc":"2.0","method":"tools/list","params":{}}`,
+		`received {"id":1,"jsonrpc":"2.0","result":{"tools":[]}}`,
+		`sent {"id":2,"jsonrpc":"2.0","method":"tools/call","params":{"name":"echo"}}`,
+		`received {"id":2,"jsonrpc":"2.0","result":{"content":[]}}`,
+		`sent {"id":3,"jsonrpc":"2.0","method":"ping"}`,
+		`received {"jsonrpc":"2.0","method":"notifications/message","params":{"data":"hi","level":"info"}}`,
+	}
+	if got := capturedSummary(t, messages); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCapture() =\n%v\nwant\n%v", got, want)
+	}
+	if !messages[1].Time.Equal(start.Add(time.Millisecond)) {
+		t.Errorf("messages without times were not spaced from start: %v", messages[1].Time)
+	}
+
+	pairs := `[{"request": {"method": "resources/list"}, "response": {"resources": []}}]`
+	if messages, err = ParseCapture([]byte(pairs), CaptureAuto, start); err != nil || len(messages) != 2 {
+		t.Errorf("ParseCapture() of an array of pairs = %d messages, %v", len(messages), err)
+	}
+	if _, err = ParseCapture([]byte(`{"servers": []}`), CaptureInspector, start); err == nil {
+		t.Error("ParseCapture() accepted an export without history")
+	}
+}
+
+func TestParseCaptureJSONL(t *testing.T) {
+	capture := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"summarize"}}
+{"jsonrpc":"2.0","id":7,"method":"sampling/createMessage","params":{}}
+{"jsonrpc":"2.0","id":7,"result":{"role":"assistant"}}
+{"time":"2026-10-17T09:00:01Z","direction":"incoming","message":{"jsonrpc":"2.0","id":1,"result":{"content":[]}}}
+
+[{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}]
+{"time":"2026-10-17T09:00:00Z","direction":"start","server":["npx","server"]}
+`
+	messages, err := ParseCapture([]byte(capture), CaptureAuto, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`sent {"id":1,"jsonrpc":"2.0","method":"tools/call","params":{"name":"summarize"}}`,
+		`received {"id":7,"jsonrpc":"2.0","method":"sampling/createMessage","params":{}}`,
+		`sent {"id":7,"jsonrpc":"2.0","result":{"role":"assistant"}}`,
+		`received {"id":1,"jsonrpc":"2.0","result":{"content":[]}}`,
+		`sent {"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`received {"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`,
+	}
+	if got := capturedSummary(t, messages); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCapture() =\n%v\nwant\n%v", got, want)
+	}
+	if !messages[3].Time.Equal(time.Date(2026, 10, 17, 9, 0, 1, 0, time.UTC)) {
+		t.Errorf("the time of a wrapped message was not kept: %v", messages[3].Time)
+	}
+
+	if _, err = ParseCapture([]byte("{\"jsonrpc\":\"2.0\"}\nnot json\n"), CaptureJSONL, time.Now()); err == nil || err.Error()[:6] != "line 2" {
+		t.Errorf("expected an error on line 2, got %v", err)
+	}
+}
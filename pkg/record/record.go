@@ -0,0 +1,230 @@
+/*
+Package record writes MCP sessions to JSON lines files that can be shared and replayed. Values
+that look like credentials are replaced by tokens backed by a local vault, so a recording does
+not leak secrets but can still be replayed on the machine that made it.
+*/
+package record
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Entry directions.
+const (
+	DirectionStart    = "start"
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+)
+
+// Entry is one line of a recording. The first entry of a session has the start direction and
+// names the server; the others hold a message.
+type Entry struct {
+	Time      time.Time       `json:"time"`
+	Message   json.RawMessage `json:"message,omitempty"`
+	Direction string          `json:"direction"`
+	Server    []string        `json:"server,omitempty"`
+}
+
+// Recorder appends the messages of a session to a recording.
+type Recorder struct {
+	w     io.Writer
+	file  *os.File
+	vault *Vault
+	clock clock.Clock
+	mu    sync.Mutex
+}
+
+// Create starts a recording of a session with server at path, tokenizing secrets with vault.
+func Create(path string, server []string, vault *Vault) (*Recorder, error) {
+	r, err := Open(path, vault)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.Start(server); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Open opens the recording at path to append sessions to, tokenizing secrets with vault. Unlike
+// Create, it does not start a session, so the clock can be set first.
+func Open(path string, vault *Vault) (*Recorder, error) {
+	// #nosec G304 - the recording path is provided explicitly by the user
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	r := NewRecorder(file, vault)
+	r.file = file
+	return r, nil
+}
+
+// NewRecorder records to w, tokenizing secrets with vault.
+func NewRecorder(w io.Writer, vault *Vault) *Recorder {
+	return &Recorder{w: w, vault: vault, clock: clock.Real}
+}
+
+// SetClock sets the clock entries are stamped with, e.g. a clock.Replay following the recording
+// a session replays, so that replaying it records the same times each time.
+func (r *Recorder) SetClock(c clock.Clock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = c
+}
+
+// now returns the time on the clock of the recorder.
+func (r *Recorder) now() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clock.Now()
+}
+
+// Start writes the entry that begins a session with the server run by the command server, or
+// reached at its URL.
+func (r *Recorder) Start(server []string) error {
+	return r.StartAt(r.now(), server)
+}
+
+// StartAt is Start for a session that began at t, such as one captured by another tool.
+func (r *Recorder) StartAt(t time.Time, server []string) error {
+	tokenized := make([]string, len(server))
+	for i, arg := range server {
+		value, err := r.vault.Tokenize(arg)
+		if err != nil {
+			return err
+		}
+		tokenized[i], _ = value.(string)
+	}
+	return r.write(Entry{Time: t, Direction: DirectionStart, Server: tokenized})
+}
+
+// Record writes a message travelling in direction.
+func (r *Recorder) Record(direction string, message any) error {
+	return r.RecordAt(r.now(), direction, message)
+}
+
+// RecordAt is Record for a message sent at t.
+func (r *Recorder) RecordAt(t time.Time, direction string, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	var value any
+	if err = json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value, err = r.vault.Tokenize(value); err != nil {
+		return err
+	}
+	if data, err = json.Marshal(value); err != nil {
+		return err
+	}
+	return r.write(Entry{Time: t, Direction: direction, Message: data})
+}
+
+// Close closes the recording file.
+func (r *Recorder) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+func (r *Recorder) write(entry Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.Time = entry.Time.UTC()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = r.w.Write(append(data, '\n'))
+	return err
+}
+
+// Load reads the entries of a recording.
+func Load(path string) ([]Entry, error) {
+	// #nosec G304 - the recording path is provided explicitly by the user
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	return Read(file)
+}
+
+// Read reads the entries of a recording from r.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Transport wraps a transport.Interface and records every message passing through it.
+type Transport struct {
+	transport.Interface
+	recorder *Recorder
+}
+
+// NewTransport wraps inner so that all traffic is written to recorder.
+func NewTransport(inner transport.Interface, recorder *Recorder) *Transport {
+	return &Transport{Interface: inner, recorder: recorder}
+}
+
+// SendRequest records the request and its response and forwards the request.
+func (t *Transport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	t.report(t.recorder.Record(DirectionSent, request))
+
+	response, err := t.Interface.SendRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	t.report(t.recorder.Record(DirectionReceived, response))
+	return response, nil
+}
+
+// SendNotification records the notification and forwards it.
+func (t *Transport) SendNotification(ctx context.Context, notification mcp.JSONRPCNotification) error {
+	t.report(t.recorder.Record(DirectionSent, notification))
+	return t.Interface.SendNotification(ctx, notification)
+}
+
+// SetNotificationHandler installs handler, recording each notification before it is delivered.
+func (t *Transport) SetNotificationHandler(handler func(notification mcp.JSONRPCNotification)) {
+	t.Interface.SetNotificationHandler(func(notification mcp.JSONRPCNotification) {
+		t.report(t.recorder.Record(DirectionReceived, notification))
+		if handler != nil {
+			handler(notification)
+		}
+	})
+}
+
+// report warns about a failed write without failing the session being recorded.
+func (t *Transport) report(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record message: %v\n", err)
+	}
+}
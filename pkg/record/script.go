@@ -0,0 +1,184 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ScriptOptions configures the conversion of a recording to a script.
+type ScriptOptions struct {
+	// Source names the recording in the header of the script.
+	Source string
+	// ParamsDir, when set, is the directory next to the script the params of calls are
+	// written to, one JSON file per call, instead of being inlined.
+	ParamsDir string
+}
+
+// Script is a recording converted to a shell script of mcp commands.
+type Script struct {
+	// Text is the script.
+	Text string
+	// Files holds the params files, by path relative to the script.
+	Files map[string][]byte
+	// Commands is the number of requests converted to commands, and Skipped the number of
+	// requests no command sends, which are left as comments.
+	Commands, Skipped int
+}
+
+// scriptMessage holds the fields of a recorded message a script is built from.
+type scriptMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params struct {
+		Name      string          `json:"name"`
+		URI       string          `json:"uri"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"params"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// unsafeFileChars are replaced in the names of params files.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ToScript converts the requests a client sent in a recording to mcp commands that send them
+// again: tools/call to mcp call, resources/read to mcp read-resource, prompts/get to mcp
+// get-prompt and the list requests to mcp tools, resources and prompts. The handshake and
+// notifications are left out, and other requests are kept as comments. Requests that failed
+// are followed by the recorded error.
+//
+// Each session runs against the server it was recorded with, or the command given as
+// arguments to the script, and mcp is taken from $MCP if set.
+func ToScript(entries []Entry, options ScriptOptions) Script {
+	script := Script{Files: map[string][]byte{}}
+	var b strings.Builder
+
+	b.WriteString("#!/usr/bin/env bash\n")
+	if options.Source != "" {
+		fmt.Fprintf(&b, "# Generated by mcp trace to-script from %s.\n", options.Source)
+	}
+	b.WriteString("# Pass a server command as arguments to run the calls against it instead of the recorded one.\n")
+	b.WriteString("set -euo pipefail\n\n")
+	b.WriteString("MCP=\"${MCP:-mcp}\"\n")
+	if options.ParamsDir != "" {
+		b.WriteString("here=\"$(cd \"$(dirname \"$0\")\" && pwd)\"\n")
+	}
+
+	// Responses are matched to requests to note the recorded errors. IDs are only unique
+	// within a session.
+	errorsByID := map[string]string{}
+	session := 0
+	for _, entry := range entries {
+		if entry.Direction == DirectionStart || session == 0 {
+			session++
+		}
+		var msg scriptMessage
+		if entry.Direction != DirectionReceived || json.Unmarshal(entry.Message, &msg) != nil {
+			continue
+		}
+		if msg.Method == "" && msg.Error != nil {
+			errorsByID[fmt.Sprintf("%d/%s", session, msg.ID)] = fmt.Sprintf("%d %s", msg.Error.Code, msg.Error.Message)
+		}
+	}
+
+	session = 0
+	var server string
+	for _, entry := range entries {
+		if entry.Direction == DirectionStart || session == 0 {
+			session++
+			server = fmt.Sprintf("server%d", session)
+			fmt.Fprintf(&b, "\n# Session %d\n", session)
+			quoted := make([]string, len(entry.Server))
+			for i, arg := range entry.Server {
+				quoted[i] = shellQuote(arg)
+			}
+			if entry.Direction != DirectionStart || len(quoted) == 0 {
+				b.WriteString("# The recording does not name the server of this session.\n")
+			}
+			fmt.Fprintf(&b, "%s=(%s)\n", server, strings.Join(quoted, " "))
+			fmt.Fprintf(&b, "if [ $# -gt 0 ]; then %s=(\"$@\"); fi\n", server)
+			if entry.Direction == DirectionStart {
+				continue
+			}
+		}
+
+		var msg scriptMessage
+		if entry.Direction != DirectionSent || json.Unmarshal(entry.Message, &msg) != nil ||
+			msg.Method == "" || len(msg.ID) == 0 || msg.Method == "initialize" {
+			continue
+		}
+		target := fmt.Sprintf(`"${%s[@]}"`, server)
+		var command string
+		switch msg.Method {
+		case "tools/call":
+			command = `"$MCP" call ` + shellQuote(msg.Params.Name) + script.params(&options, msg.Params.Name, msg.Params.Arguments) + " " + target
+		case "resources/read":
+			command = `"$MCP" read-resource ` + shellQuote(msg.Params.URI) + " " + target
+		case "prompts/get":
+			command = `"$MCP" get-prompt ` + shellQuote(msg.Params.Name) + script.params(&options, msg.Params.Name, msg.Params.Arguments) + " " + target
+		case "tools/list":
+			command = `"$MCP" tools ` + target
+		case "resources/list":
+			command = `"$MCP" resources ` + target
+		case "prompts/list":
+			command = `"$MCP" prompts ` + target
+		default:
+			script.Skipped++
+			var raw struct {
+				Params json.RawMessage `json:"params"`
+			}
+			params := ""
+			if json.Unmarshal(entry.Message, &raw) == nil && len(raw.Params) > 0 {
+				params = " " + compactJSON(raw.Params)
+			}
+			fmt.Fprintf(&b, "# No mcp command sends %s%s\n", msg.Method, params)
+			continue
+		}
+
+		script.Commands++
+		b.WriteString(command + "\n")
+		if recorded, failed := errorsByID[fmt.Sprintf("%d/%s", session, msg.ID)]; failed {
+			fmt.Fprintf(&b, "# Recorded error: %s\n", recorded)
+		}
+	}
+
+	script.Text = b.String()
+	return script
+}
+
+// params returns the --params flag of a command with arguments, inlined or read from a file.
+func (s *Script) params(options *ScriptOptions, name string, arguments json.RawMessage) string {
+	if len(arguments) == 0 || string(arguments) == "null" {
+		return ""
+	}
+	if options.ParamsDir == "" {
+		return " --params " + shellQuote(compactJSON(arguments))
+	}
+
+	var pretty any
+	_ = json.Unmarshal(arguments, &pretty)
+	data, _ := json.MarshalIndent(pretty, "", "  ")
+	file := path.Join(options.ParamsDir, fmt.Sprintf("%03d-%s.json", len(s.Files)+1, unsafeFileChars.ReplaceAllString(name, "_")))
+	s.Files[file] = append(data, '\n')
+	return ` --params "$(cat "$here"/` + shellQuote(file) + `)"`
+}
+
+// compactJSON writes JSON on a line.
+func compactJSON(data json.RawMessage) string {
+	var value any
+	if json.Unmarshal(data, &value) != nil {
+		return string(data)
+	}
+	compact, _ := json.Marshal(value)
+	return string(compact)
+}
+
+// shellQuote quotes a value so the shell treats it as a single literal word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
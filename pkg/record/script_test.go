@@ -0,0 +1,52 @@
+package record
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestToScript(t *testing.T) {
+	message := func(s string) json.RawMessage { return json.RawMessage(s) }
+	entries := []Entry{
+		{Direction: DirectionStart, Server: []string{"npx", "-y", "server-fs", "it's here"}},
+		{Direction: DirectionSent, Message: message(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{}}`)},
+		{Direction: DirectionSent, Message: message(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)},
+		{Direction: DirectionSent, Message: message(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"read_file","arguments":{"path":"a'b.md"}}}`)},
+		{Direction: DirectionReceived, Message: message(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"no such file"}}`)},
+		{Direction: DirectionSent, Message: message(`{"jsonrpc":"2.0","id":2,"method":"completion/complete","params":{"ref":{}}}`)},
+		{Direction: DirectionStart, Server: []string{"https://example.com/mcp"}},
+		{Direction: DirectionSent, Message: message(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"file:///notes"}}`)},
+		{Direction: DirectionReceived, Message: message(`{"jsonrpc":"2.0","id":1,"result":{"contents":[]}}`)},
+		{Direction: DirectionSent, Message: message(`{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"review","arguments":{"lang":"go"}}}`)},
+	}
+
+	script := ToScript(entries, ScriptOptions{Source: "session.jsonl"})
+	for _, expected := range []string{
+		"# Generated by mcp trace to-script from session.jsonl.",
+		`server1=('npx' '-y' 'server-fs' 'it'\''s here')`,
+		`"$MCP" call 'read_file' --params '{"path":"a'\''b.md"}' "${server1[@]}"` + "\n# Recorded error: -32602 no such file\n",
+		`# No mcp command sends completion/complete {"ref":{}}`,
+		`server2=('https://example.com/mcp')`,
+		`"$MCP" read-resource 'file:///notes' "${server2[@]}"` + "\n\"$MCP\" get-prompt",
+		`"$MCP" get-prompt 'review' --params '{"lang":"go"}' "${server2[@]}"`,
+	} {
+		if !strings.Contains(script.Text, expected) {
+			t.Errorf("expected the script to contain %q, got:\n%s", expected, script.Text)
+		}
+	}
+	if strings.Contains(script.Text, "initialize") {
+		t.Errorf("expected the handshake to be left out, got:\n%s", script.Text)
+	}
+	if script.Commands != 3 || script.Skipped != 1 {
+		t.Errorf("Commands, Skipped = %d, %d, want 3, 1", script.Commands, script.Skipped)
+	}
+
+	script = ToScript(entries, ScriptOptions{ParamsDir: "repro-params"})
+	if len(script.Files) != 2 || string(script.Files["repro-params/001-read_file.json"]) != "{\n  \"path\": \"a'b.md\"\n}\n" {
+		t.Errorf("Files = %q", script.Files)
+	}
+	if !strings.Contains(script.Text, `--params "$(cat "$here"/'repro-params/002-review.json')"`) {
+		t.Errorf("expected params read from files, got:\n%s", script.Text)
+	}
+}
@@ -0,0 +1,248 @@
+package record
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// TokenPrefix starts every token that stands for a secret in a recording.
+const TokenPrefix = "mcpt-secret-"
+
+var (
+	tokenPattern = regexp.MustCompile(TokenPrefix + `[0-9a-f]{16}`)
+	// candidatePattern finds runs of characters secrets are made of. Slashes and dots are left
+	// out so paths and host names are not mistaken for secrets.
+	candidatePattern = regexp.MustCompile(`[A-Za-z0-9_\-+=]{20,}`)
+	jwtPattern       = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`)
+)
+
+// secretPrefixes are the prefixes of well-known credential formats.
+var secretPrefixes = []string{
+	"sk-", "sk_live_", "sk_test_", "rk_live_", "ghp_", "gho_", "ghu_", "ghs_", "ghr_", "github_pat_",
+	"glpat-", "xoxb-", "xoxp-", "xoxa-", "xapp-", "AKIA", "ASIA", "AIza", "ya29.", "npm_", "hf_", "pypi-",
+}
+
+// secretKeys are parts of field names whose string values are always treated as secrets.
+var secretKeys = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "authorization", "credential"}
+
+// LooksLikeSecret reports whether s looks like a credential: a known credential format, or a
+// long random-looking string mixing upper and lower case letters and digits.
+func LooksLikeSecret(s string) bool {
+	for _, prefix := range secretPrefixes {
+		if strings.HasPrefix(s, prefix) && len(s) >= len(prefix)+10 {
+			return true
+		}
+	}
+	if jwtPattern.MatchString(s) && len(jwtPattern.FindString(s)) == len(s) {
+		return true
+	}
+	if len(s) < 20 {
+		return false
+	}
+
+	var upper, lower, digits int
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		case unicode.IsDigit(r):
+			digits++
+		}
+	}
+	if upper == 0 || lower == 0 || digits < 2 {
+		return false
+	}
+
+	// Random strings use close to the largest entropy their length allows
+	return entropy(s) >= 0.85*math.Log2(math.Min(float64(len(s)), 62))
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	var bits float64
+	n := float64(len([]rune(s)))
+	for _, count := range counts {
+		p := float64(count) / n
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// isSecretKey reports whether a field with this name holds a secret.
+func isSecretKey(name string) bool {
+	name = strings.ToLower(name)
+	for _, key := range secretKeys {
+		if strings.Contains(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Vault keeps the secrets replaced by tokens in recordings, so recordings can be shared while
+// the secrets stay on this machine. The same secret always gets the same token.
+type Vault struct {
+	Key     string            `json:"key"`
+	Secrets map[string]string `json:"secrets"`
+	path    string
+	mu      sync.Mutex
+}
+
+// GetVaultPath returns the path of the local vault, creating its directory if needed.
+func GetVaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	configDir := filepath.Join(homeDir, ".mcpt")
+	if err = os.MkdirAll(configDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	return filepath.Join(configDir, "vault.json"), nil
+}
+
+// OpenVault loads the vault at path, creating a new one if it does not exist.
+func OpenVault(path string) (*Vault, error) {
+	v := &Vault{Secrets: map[string]string{}, path: path}
+
+	// #nosec G304 - the vault path is generated internally or provided by the user
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		key := make([]byte, 32)
+		if _, err = rand.Read(key); err != nil {
+			return nil, err
+		}
+		v.Key = hex.EncodeToString(key)
+		return v, v.save()
+	case err != nil:
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("failed to parse vault: %w", err)
+	}
+	if v.Secrets == nil {
+		v.Secrets = map[string]string{}
+	}
+	return v, nil
+}
+
+// Tokenize returns value, a decoded JSON value, with every secret in it replaced by a token.
+// New secrets are stored in the vault.
+func (v *Vault) Tokenize(value any) (any, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	added := false
+	out := v.tokenize(value, false, &added)
+	if added {
+		if err := v.save(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Detokenize returns value with every token replaced by its secret. It fails if a token is not
+// in the vault, e.g. for recordings made on another machine.
+func (v *Vault) Detokenize(value any) (any, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var missing string
+	out := walkStrings(value, func(s string, _ bool) string {
+		return tokenPattern.ReplaceAllStringFunc(s, func(token string) string {
+			secret, ok := v.Secrets[token]
+			if !ok {
+				missing = token
+				return token
+			}
+			return secret
+		})
+	}, false)
+	if missing != "" {
+		return nil, fmt.Errorf("secret %s is not in the local vault", missing)
+	}
+	return out, nil
+}
+
+func (v *Vault) tokenize(value any, secretField bool, added *bool) any {
+	return walkStrings(value, func(s string, secretField bool) string {
+		if secretField && s != "" && !tokenPattern.MatchString(s) {
+			return v.token(s, added)
+		}
+		s = jwtPattern.ReplaceAllStringFunc(s, func(jwt string) string { return v.token(jwt, added) })
+		return candidatePattern.ReplaceAllStringFunc(s, func(candidate string) string {
+			if strings.HasPrefix(candidate, TokenPrefix) || !LooksLikeSecret(candidate) {
+				return candidate
+			}
+			return v.token(candidate, added)
+		})
+	}, secretField)
+}
+
+// token returns the token for secret, storing it in the vault. The caller holds the lock.
+func (v *Vault) token(secret string, added *bool) string {
+	mac := hmac.New(sha256.New, []byte(v.Key))
+	mac.Write([]byte(secret))
+	token := TokenPrefix + hex.EncodeToString(mac.Sum(nil))[:16]
+	if _, ok := v.Secrets[token]; !ok {
+		v.Secrets[token] = secret
+		*added = true
+	}
+	return token
+}
+
+func (v *Vault) save() error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(v.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	return nil
+}
+
+// walkStrings returns a copy of a decoded JSON value with fn applied to every string. fn is
+// told whether the string is the value of a field whose name marks it as secret.
+func walkStrings(value any, fn func(s string, secretField bool) string, secretField bool) any {
+	switch v := value.(type) {
+	case string:
+		return fn(v, secretField)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = walkStrings(item, fn, secretField || isSecretKey(key))
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = walkStrings(item, fn, secretField)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,37 @@
+package record
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Timeline keeps in memory the entries a Recorder writes to it, to show a session as it goes.
+type Timeline struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Write adds the entry encoded in p, as a Recorder writes it.
+func (t *Timeline) Write(p []byte) (int, error) {
+	var entry Entry
+	if err := json.Unmarshal(p, &entry); err != nil {
+		return 0, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, entry)
+	return len(p), nil
+}
+
+// Entries returns the entries of the session, skipping the first after of them.
+func (t *Timeline) Entries(after int) []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	after = max(after, 0)
+	if after >= len(t.entries) {
+		return []Entry{}
+	}
+	return append([]Entry(nil), t.entries[after:]...)
+}
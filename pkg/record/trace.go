@@ -0,0 +1,160 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TraceEvent is an event of the Trace Event Format read by chrome://tracing and Perfetto.
+type TraceEvent struct {
+	Args     map[string]any `json:"args,omitempty"`
+	Name     string         `json:"name"`
+	Category string         `json:"cat,omitempty"`
+	Phase    string         `json:"ph"`
+	Scope    string         `json:"s,omitempty"`
+	// Time and Duration are in microseconds, Time since the start of the recording.
+	Time     int64 `json:"ts"`
+	Duration int64 `json:"dur,omitempty"`
+	PID      int   `json:"pid"`
+	TID      int   `json:"tid"`
+}
+
+// Trace is a recording converted to the Trace Event Format.
+type Trace struct {
+	OtherData       map[string]any `json:"otherData,omitempty"`
+	DisplayTimeUnit string         `json:"displayTimeUnit"`
+	TraceEvents     []TraceEvent   `json:"traceEvents"`
+}
+
+// Phases of trace events.
+const (
+	phaseComplete = "X"
+	phaseInstant  = "I"
+	phaseMetadata = "M"
+)
+
+// traceMessage holds the fields of a JSON-RPC message a trace is built from.
+type traceMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params struct {
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	} `json:"params"`
+	Result struct {
+		IsError bool `json:"isError"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	} `json:"error"`
+}
+
+// pendingRequest is a request waiting for its response.
+type pendingRequest struct {
+	args     map[string]any
+	name     string
+	category string
+	start    time.Time
+}
+
+// ToTrace converts a recording to a trace: each session is a track named after its server,
+// each request a slice from the request to its response, and each notification an instant.
+// Requests the server sends, such as sampling requests, are in the server-request category.
+func ToTrace(entries []Entry) Trace {
+	trace := Trace{DisplayTimeUnit: "ms", TraceEvents: []TraceEvent{}}
+	if len(entries) == 0 {
+		return trace
+	}
+	origin := entries[0].Time
+	trace.OtherData = map[string]any{"recordedAt": origin.UTC().Format(time.RFC3339Nano)}
+	micros := func(t time.Time) int64 { return t.Sub(origin).Microseconds() }
+
+	trace.TraceEvents = append(trace.TraceEvents, TraceEvent{
+		Name: "process_name", Phase: phaseMetadata, PID: 1, Args: map[string]any{"name": "mcp"},
+	})
+
+	session := 0
+	pending := map[string]pendingRequest{}
+	// Requests never answered, e.g. because the session was interrupted, end with their session
+	flush := func(end time.Time) {
+		unanswered := slices.SortedFunc(maps.Values(pending), func(a, b pendingRequest) int { return a.start.Compare(b.start) })
+		for _, req := range unanswered {
+			req.args["response"] = "none"
+			trace.TraceEvents = append(trace.TraceEvents, TraceEvent{
+				Name: req.name, Category: req.category, Phase: phaseComplete, PID: 1, TID: session,
+				Time: micros(req.start), Duration: max(micros(end)-micros(req.start), 1), Args: req.args,
+			})
+		}
+		clear(pending)
+	}
+
+	for i, entry := range entries {
+		if entry.Direction == DirectionStart {
+			if i > 0 {
+				flush(entry.Time)
+			}
+			session++
+			trace.TraceEvents = append(trace.TraceEvents, TraceEvent{
+				Name: "thread_name", Phase: phaseMetadata, PID: 1, TID: session,
+				Args: map[string]any{"name": strings.Join(entry.Server, " ")},
+			})
+			continue
+		}
+
+		var msg traceMessage
+		if json.Unmarshal(entry.Message, &msg) != nil {
+			continue
+		}
+		hasID := len(msg.ID) > 0 && string(msg.ID) != "null"
+
+		switch {
+		case msg.Method != "" && hasID:
+			category := "request"
+			if entry.Direction == DirectionReceived {
+				category = "server-request"
+			}
+			name := msg.Method
+			args := map[string]any{"id": json.RawMessage(msg.ID)}
+			switch {
+			case msg.Params.Name != "":
+				name += " " + msg.Params.Name
+			case msg.Params.URI != "":
+				args["uri"] = msg.Params.URI
+			}
+			pending[entry.Direction+string(msg.ID)] = pendingRequest{args: args, name: name, category: category, start: entry.Time}
+		case msg.Method != "":
+			trace.TraceEvents = append(trace.TraceEvents, TraceEvent{
+				Name: msg.Method, Category: "notification", Phase: phaseInstant, Scope: "t", PID: 1, TID: session,
+				Time: micros(entry.Time), Args: map[string]any{"direction": entry.Direction},
+			})
+		case hasID:
+			// A response travels the other way from its request
+			key := DirectionSent + string(msg.ID)
+			if entry.Direction == DirectionSent {
+				key = DirectionReceived + string(msg.ID)
+			}
+			req, ok := pending[key]
+			if !ok {
+				continue
+			}
+			delete(pending, key)
+			switch {
+			case msg.Error != nil:
+				req.args["error"] = fmt.Sprintf("%d: %s", msg.Error.Code, msg.Error.Message)
+			case msg.Result.IsError:
+				req.args["isError"] = true
+			}
+			trace.TraceEvents = append(trace.TraceEvents, TraceEvent{
+				Name: req.name, Category: req.category, Phase: phaseComplete, PID: 1, TID: session,
+				Time: micros(req.start), Duration: max(micros(entry.Time)-micros(req.start), 1), Args: req.args,
+			})
+		}
+	}
+	flush(entries[len(entries)-1].Time)
+	return trace
+}
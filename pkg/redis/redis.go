@@ -0,0 +1,265 @@
+// Package redis is a minimal client of the Redis protocol (RESP2), enough to share state such as
+// bridge sessions between processes without depending on a full client library.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// poolSize is how many idle connections a client keeps.
+const poolSize = 8
+
+// defaultTimeout bounds commands whose context has no deadline.
+const defaultTimeout = 5 * time.Second
+
+// Error is an error reply of the server, such as "WRONGTYPE Operation against a key...".
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Client sends commands to a Redis server over a small pool of connections.
+type Client struct {
+	tls      *tls.Config
+	pool     chan *conn
+	addr     string
+	username string
+	password string
+	db       int
+}
+
+// Open returns a client of the server at a URL such as redis://:password@host:6379/0, or
+// rediss:// for TLS. Connections are made when commands are sent.
+func Open(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Redis URL %q: expected redis://[user:password@]host[:port][/db]", rawURL)
+	}
+
+	c := &Client{addr: u.Host, pool: make(chan *conn, poolSize)}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.Scheme == "rediss" {
+		c.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil || c.db < 0 {
+			return nil, fmt.Errorf("invalid Redis URL %q: database %q is not a number", rawURL, db)
+		}
+	}
+	return c, nil
+}
+
+// Do sends a command and returns its reply: a string, an int64, nil, or a []any of those.
+// Error replies are returned as Error.
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	replies, err := c.Pipeline(ctx, [][]string{args})
+	if err != nil {
+		return nil, err
+	}
+	if replyErr, ok := replies[0].(Error); ok {
+		return nil, replyErr
+	}
+	return replies[0], nil
+}
+
+// Pipeline sends commands in one round trip and returns their replies, including error replies
+// as Error values. Wrap the commands in MULTI and EXEC to have them applied atomically.
+func (c *Client) Pipeline(ctx context.Context, commands [][]string) ([]any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	_ = cn.SetDeadline(deadline)
+
+	replies, err := cn.roundTrip(commands)
+	if err != nil {
+		// The connection is in an unknown state after a failure, so it is not reused
+		_ = cn.Close()
+		return nil, fmt.Errorf("redis %s: %w", c.addr, err)
+	}
+	c.put(cn)
+	return replies, nil
+}
+
+// Close closes the idle connections of the client.
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.pool:
+			_ = cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: defaultTimeout}
+	var nc net.Conn
+	var err error
+	if c.tls != nil {
+		nc, err = (&tls.Dialer{NetDialer: dialer, Config: c.tls}).DialContext(ctx, "tcp", c.addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	cn := &conn{Conn: nc, reader: bufio.NewReader(nc)}
+
+	var setup [][]string
+	switch {
+	case c.username != "" && c.password != "":
+		setup = append(setup, []string{"AUTH", c.username, c.password})
+	case c.password != "":
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	if len(setup) > 0 {
+		_ = cn.SetDeadline(time.Now().Add(defaultTimeout))
+		replies, err := cn.roundTrip(setup)
+		if err == nil {
+			for _, reply := range replies {
+				if replyErr, isErr := reply.(Error); isErr {
+					err = replyErr
+					break
+				}
+			}
+		}
+		if err != nil {
+			_ = cn.Close()
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.pool <- cn:
+	default:
+		_ = cn.Close()
+	}
+}
+
+// conn is a connection to the server.
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// roundTrip writes commands and reads a reply to each.
+func (cn *conn) roundTrip(commands [][]string) ([]any, error) {
+	var buf []byte
+	for _, args := range commands {
+		buf = AppendCommand(buf, args...)
+	}
+	if _, err := cn.Write(buf); err != nil {
+		return nil, err
+	}
+
+	replies := make([]any, len(commands))
+	for i := range commands {
+		reply, err := ReadReply(cn.reader)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// AppendCommand appends a command encoded as an array of bulk strings to buf.
+func AppendCommand(buf []byte, args ...string) []byte {
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	return buf
+}
+
+// ReadReply reads one reply. Error replies are returned as an Error value, not as err.
+func ReadReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err = io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = ReadReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
@@ -0,0 +1,61 @@
+// The tests are external, as redistest imports this package.
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/redis"
+	"github.com/f/mcptools/pkg/redis/redistest"
+)
+
+func TestClient(t *testing.T) {
+	server := redistest.NewServer(t)
+	server.Password = "secret"
+
+	client, err := redis.Open(strings.Replace(server.URL(), "redis://", "redis://:secret@", 1) + "/2")
+	if err != nil {
+		t.Fatalf("redis.Open() error = %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	ctx := context.Background()
+	if _, err = client.Do(ctx, "SET", "greeting", "hello\r\nworld"); err != nil {
+		t.Fatalf("SET error = %v", err)
+	}
+	if reply, _ := client.Do(ctx, "GET", "greeting"); reply != "hello\r\nworld" {
+		t.Errorf("GET = %q, want the value with its CRLF intact", reply)
+	}
+	if reply, _ := client.Do(ctx, "GET", "missing"); reply != nil {
+		t.Errorf("GET of a missing key = %v, want nil", reply)
+	}
+
+	replies, err := client.Pipeline(ctx, [][]string{{"MULTI"}, {"HINCRBY", "h", "n", "2"}, {"HGETALL", "h"}, {"EXEC"}})
+	if err != nil {
+		t.Fatalf("Pipeline() error = %v", err)
+	}
+	results, _ := replies[3].([]any)
+	if len(results) != 2 || results[0] != int64(2) {
+		t.Errorf("EXEC = %v, want the replies of both commands", replies[3])
+	}
+
+	var replyErr redis.Error
+	if _, err = client.Do(ctx, "NOPE"); !errors.As(err, &replyErr) {
+		t.Errorf("unknown command: error = %v, want a redis.Error", err)
+	}
+
+	wrong, _ := redis.Open(server.URL())
+	if _, err = wrong.Do(ctx, "PING"); err == nil {
+		t.Error("PING without the password succeeded")
+	}
+}
+
+func TestOpenInvalidURL(t *testing.T) {
+	for _, url := range []string{"http://localhost", "redis://", "redis://localhost/db"} {
+		if _, err := redis.Open(url); err == nil {
+			t.Errorf("redis.Open(%q) error = nil", url)
+		}
+	}
+}
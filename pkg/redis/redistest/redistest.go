@@ -0,0 +1,287 @@
+// Package redistest provides an in-memory Redis server for tests, supporting the commands used by
+// mcptools: strings, hashes, sets, key expiry and MULTI/EXEC transactions.
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/f/mcptools/pkg/redis"
+)
+
+// Server is an in-memory Redis server listening on a local port.
+type Server struct {
+	listener net.Listener
+	strings  map[string]string
+	hashes   map[string]map[string]string
+	sets     map[string]map[string]bool
+	ttls     map[string]int64
+	// Password, if set, must be sent with AUTH before other commands.
+	Password string
+	mu       sync.Mutex
+}
+
+// NewServer starts a server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("redistest: failed to listen: %v", err)
+	}
+	s := &Server{
+		listener: listener,
+		strings:  map[string]string{},
+		hashes:   map[string]map[string]string{},
+		sets:     map[string]map[string]bool{},
+		ttls:     map[string]int64{},
+	}
+	go s.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+	return s
+}
+
+// URL returns the redis:// URL of the server.
+func (s *Server) URL() string {
+	return "redis://" + s.listener.Addr().String()
+}
+
+// TTL returns the expiry last set on a key in seconds, or 0 if none was.
+func (s *Server) TTL(key string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ttls[key]
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	reader := bufio.NewReader(conn)
+	authenticated := s.Password == ""
+	var queued [][]string
+	inMulti := false
+
+	for {
+		request, err := redis.ReadReply(reader)
+		if err != nil {
+			return
+		}
+		items, _ := request.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+		if len(args) == 0 {
+			return
+		}
+
+		var reply any
+		name := strings.ToUpper(args[0])
+		switch {
+		case name == "AUTH":
+			if args[len(args)-1] != s.Password {
+				reply = redis.Error("WRONGPASS invalid username-password pair")
+				break
+			}
+			authenticated = true
+			reply = "OK"
+		case !authenticated:
+			reply = redis.Error("NOAUTH Authentication required.")
+		case name == "MULTI":
+			inMulti = true
+			queued = nil
+			reply = "OK"
+		case name == "EXEC":
+			results := make([]any, len(queued))
+			s.mu.Lock()
+			for i, command := range queued {
+				results[i] = s.run(command)
+			}
+			s.mu.Unlock()
+			inMulti = false
+			reply = results
+		case inMulti:
+			queued = append(queued, args)
+			reply = "QUEUED"
+		default:
+			s.mu.Lock()
+			reply = s.run(args)
+			s.mu.Unlock()
+		}
+
+		if _, err = conn.Write(appendReply(nil, reply)); err != nil {
+			return
+		}
+	}
+}
+
+// run runs a command with s.mu held.
+func (s *Server) run(args []string) any {
+	name := strings.ToUpper(args[0])
+	arity := map[string]int{
+		"PING": 1, "SELECT": 2, "GET": 2, "SET": 3, "DEL": 2, "EXPIRE": 3, "HSET": 4, "HSETNX": 4,
+		"HGET": 3, "HGETALL": 2, "HINCRBY": 4, "SADD": 3, "SREM": 3, "SMEMBERS": 2,
+	}
+	if n, ok := arity[name]; !ok {
+		return redis.Error(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	} else if len(args) < n {
+		return redis.Error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", args[0]))
+	}
+
+	switch name {
+	case "PING":
+		return "PONG"
+	case "SELECT":
+		return "OK"
+	case "GET":
+		if value, ok := s.strings[args[1]]; ok {
+			return value
+		}
+		return nil
+	case "SET":
+		s.strings[args[1]] = args[2]
+		return "OK"
+	case "DEL":
+		var n int64
+		for _, key := range args[1:] {
+			if s.exists(key) {
+				n++
+			}
+			delete(s.strings, key)
+			delete(s.hashes, key)
+			delete(s.sets, key)
+			delete(s.ttls, key)
+		}
+		return n
+	case "EXPIRE":
+		if !s.exists(args[1]) {
+			return int64(0)
+		}
+		ttl, _ := strconv.ParseInt(args[2], 10, 64)
+		s.ttls[args[1]] = ttl
+		return int64(1)
+	case "HSET":
+		hash := s.hash(args[1])
+		var added int64
+		for i := 2; i+1 < len(args); i += 2 {
+			if _, ok := hash[args[i]]; !ok {
+				added++
+			}
+			hash[args[i]] = args[i+1]
+		}
+		return added
+	case "HSETNX":
+		hash := s.hash(args[1])
+		if _, ok := hash[args[2]]; ok {
+			return int64(0)
+		}
+		hash[args[2]] = args[3]
+		return int64(1)
+	case "HGET":
+		if value, ok := s.hashes[args[1]][args[2]]; ok {
+			return value
+		}
+		return nil
+	case "HGETALL":
+		hash := s.hashes[args[1]]
+		fields := make([]string, 0, len(hash))
+		for field := range hash {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		reply := make([]any, 0, 2*len(fields))
+		for _, field := range fields {
+			reply = append(reply, field, hash[field])
+		}
+		return reply
+	case "HINCRBY":
+		hash := s.hash(args[1])
+		current, _ := strconv.ParseInt(hash[args[2]], 10, 64)
+		by, err := strconv.ParseInt(args[3], 10, 64)
+		if err != nil {
+			return redis.Error("ERR value is not an integer or out of range")
+		}
+		hash[args[2]] = strconv.FormatInt(current+by, 10)
+		return current + by
+	case "SADD", "SREM":
+		set := s.sets[args[1]]
+		if set == nil {
+			set = map[string]bool{}
+			s.sets[args[1]] = set
+		}
+		var n int64
+		for _, member := range args[2:] {
+			if set[member] != (name == "SADD") {
+				n++
+			}
+			if name == "SADD" {
+				set[member] = true
+			} else {
+				delete(set, member)
+			}
+		}
+		if len(set) == 0 {
+			delete(s.sets, args[1])
+		}
+		return n
+	case "SMEMBERS":
+		reply := []any{}
+		for member := range s.sets[args[1]] {
+			reply = append(reply, member)
+		}
+		return reply
+	}
+	return nil
+}
+
+func (s *Server) exists(key string) bool {
+	_, isString := s.strings[key]
+	_, isHash := s.hashes[key]
+	_, isSet := s.sets[key]
+	return isString || isHash || isSet
+}
+
+func (s *Server) hash(key string) map[string]string {
+	hash := s.hashes[key]
+	if hash == nil {
+		hash = map[string]string{}
+		s.hashes[key] = hash
+	}
+	return hash
+}
+
+// appendReply encodes a reply in RESP2.
+func appendReply(buf []byte, reply any) []byte {
+	switch v := reply.(type) {
+	case nil:
+		return append(buf, "$-1\r\n"...)
+	case redis.Error:
+		return append(buf, "-"+string(v)+"\r\n"...)
+	case int64:
+		return append(buf, ":"+strconv.FormatInt(v, 10)+"\r\n"...)
+	case string:
+		return append(buf, "$"+strconv.Itoa(len(v))+"\r\n"+v+"\r\n"...)
+	case []any:
+		buf = append(buf, "*"+strconv.Itoa(len(v))+"\r\n"...)
+		for _, item := range v {
+			buf = appendReply(buf, item)
+		}
+	}
+	return buf
+}
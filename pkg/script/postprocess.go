@@ -0,0 +1,58 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// PostProcessFunction is the global function a post-processing script must define.
+const PostProcessFunction = "process"
+
+// PostProcess runs the process(result, info) function of the Lua script at path on a response
+// and returns what it returns. The response is passed as a table in its JSON form; info holds
+// details such as the command name.
+func PostProcess(ctx context.Context, path string, result any, info map[string]any) (any, error) {
+	L, fn, err := load(ctx, path, "post-process", PostProcessFunction+"(result, info)")
+	if err != nil {
+		return nil, err
+	}
+	defer L.Close()
+
+	if err = L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, ToLua(L, result), ToLua(L, info)); err != nil {
+		return nil, fmt.Errorf("post-process script failed: %w", err)
+	}
+
+	ret := L.Get(-1)
+	L.Pop(1)
+	return FromLua(ret), nil
+}
+
+// load runs the Lua script at path, used for purpose, and returns the global function the
+// signature names. The state must be closed by the caller.
+func load(ctx context.Context, path, purpose, signature string) (*lua.LState, lua.LValue, error) {
+	// #nosec G304 - the script path is provided explicitly by the user
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s script: %w", purpose, err)
+	}
+
+	L := NewState()
+	L.SetContext(ctx)
+
+	if err = L.DoString(string(source)); err != nil {
+		L.Close()
+		return nil, nil, fmt.Errorf("failed to load %s script: %w", purpose, err)
+	}
+
+	name, _, _ := strings.Cut(signature, "(")
+	fn := L.GetGlobal(name)
+	if fn.Type() != lua.LTFunction {
+		L.Close()
+		return nil, nil, fmt.Errorf("%s script %s must define a %s function", purpose, path, signature)
+	}
+	return L, fn, nil
+}
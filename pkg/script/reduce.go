@@ -0,0 +1,32 @@
+package script
+
+import (
+	"context"
+	"fmt"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// ReduceFunction is the global function a reduce script must define.
+const ReduceFunction = "reduce"
+
+// Reduce folds documents with the reduce(acc, doc, index) function of the Lua script at path:
+// it is called for each document with what it returned for the previous one, nil at first,
+// and the 1-based index of the document. The last value returned is the result.
+func Reduce(ctx context.Context, path string, documents []any) (any, error) {
+	L, fn, err := load(ctx, path, "reduce", ReduceFunction+"(acc, doc, index)")
+	if err != nil {
+		return nil, err
+	}
+	defer L.Close()
+
+	var acc lua.LValue = lua.LNil
+	for i, doc := range documents {
+		if err = L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, acc, ToLua(L, doc), lua.LNumber(i+1)); err != nil {
+			return nil, fmt.Errorf("reduce script failed on document %d: %w", i+1, err)
+		}
+		acc = L.Get(-1)
+		L.Pop(1)
+	}
+	return FromLua(acc), nil
+}
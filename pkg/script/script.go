@@ -0,0 +1,121 @@
+// Package script provides the embedded Lua environment used for user scripts, and the response
+// post-processing hook built on it.
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// NewState creates a Lua state with the standard libraries and a json module providing
+// json.encode(value) and json.decode(text).
+func NewState() *lua.LState {
+	L := lua.NewState()
+
+	module := L.NewTable()
+	L.SetField(module, "encode", L.NewFunction(jsonEncode))
+	L.SetField(module, "decode", L.NewFunction(jsonDecode))
+	L.SetGlobal("json", module)
+
+	return L
+}
+
+// ToLua converts a decoded JSON value to a Lua value.
+func ToLua(L *lua.LState, value any) lua.LValue {
+	switch v := value.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(v)
+	case float64:
+		return lua.LNumber(v)
+	case int:
+		return lua.LNumber(v)
+	case int64:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	case []any:
+		table := L.NewTable()
+		for _, item := range v {
+			table.Append(ToLua(L, item))
+		}
+		return table
+	case map[string]any:
+		table := L.NewTable()
+		for key, item := range v {
+			table.RawSetString(key, ToLua(L, item))
+		}
+		return table
+	default:
+		// Structs and other types go through their JSON form
+		data, err := json.Marshal(v)
+		if err != nil {
+			return lua.LString(fmt.Sprintf("%v", v))
+		}
+		var decoded any
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			return lua.LString(string(data))
+		}
+		return ToLua(L, decoded)
+	}
+}
+
+// FromLua converts a Lua value to a value that can be JSON encoded. Tables with only
+// consecutive integer keys become arrays.
+func FromLua(value lua.LValue) any {
+	switch v := value.(type) {
+	case *lua.LNilType:
+		return nil
+	case lua.LBool:
+		return bool(v)
+	case lua.LNumber:
+		return float64(v)
+	case lua.LString:
+		return string(v)
+	case *lua.LTable:
+		if n := v.MaxN(); n > 0 && n == countTable(v) {
+			array := make([]any, 0, n)
+			for i := 1; i <= n; i++ {
+				array = append(array, FromLua(v.RawGetInt(i)))
+			}
+			return array
+		}
+		object := map[string]any{}
+		v.ForEach(func(key, item lua.LValue) {
+			object[key.String()] = FromLua(item)
+		})
+		return object
+	default:
+		return v.String()
+	}
+}
+
+func countTable(t *lua.LTable) int {
+	n := 0
+	t.ForEach(func(_, _ lua.LValue) { n++ })
+	return n
+}
+
+func jsonEncode(L *lua.LState) int {
+	data, err := json.Marshal(FromLua(L.CheckAny(1)))
+	if err != nil {
+		L.RaiseError("json.encode: %v", err)
+		return 0
+	}
+	L.Push(lua.LString(string(data)))
+	return 1
+}
+
+func jsonDecode(L *lua.LState) int {
+	var value any
+	if err := json.Unmarshal([]byte(L.CheckString(1)), &value); err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(ToLua(L, value))
+	return 1
+}
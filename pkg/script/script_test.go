@@ -0,0 +1,62 @@
+package script
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConversionRoundTrip(t *testing.T) {
+	L := NewState()
+	defer L.Close()
+
+	value := map[string]any{
+		"name":   "test",
+		"count":  float64(3),
+		"tags":   []any{"a", "b"},
+		"nested": map[string]any{"ok": true},
+	}
+	if got := FromLua(ToLua(L, value)); !reflect.DeepEqual(got, value) {
+		t.Errorf("round trip = %#v, want %#v", got, value)
+	}
+}
+
+func TestJSONModule(t *testing.T) {
+	L := NewState()
+	defer L.Close()
+
+	if err := L.DoString(`decoded = json.decode('{"a":[1,2]}'); encoded = json.encode({x = "y"})`); err != nil {
+		t.Fatal(err)
+	}
+	if got := FromLua(L.GetGlobal("decoded")); !reflect.DeepEqual(got, map[string]any{"a": []any{float64(1), float64(2)}}) {
+		t.Errorf("decoded = %#v", got)
+	}
+	if got := L.GetGlobal("encoded").String(); got != `{"x":"y"}` {
+		t.Errorf("encoded = %s", got)
+	}
+}
+
+func TestPostProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "post.lua")
+	source := `function process(result, info)
+  local names = {}
+  for _, tool in ipairs(result.tools) do
+    table.insert(names, info.prefix .. tool.name)
+  end
+  return names
+end`
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	result := map[string]any{"tools": []any{map[string]any{"name": "read"}, map[string]any{"name": "write"}}}
+	got, err := PostProcess(context.Background(), path, result, map[string]any{"prefix": "fs."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []any{"fs.read", "fs.write"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PostProcess() = %#v, want %#v", got, want)
+	}
+}
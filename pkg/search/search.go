@@ -0,0 +1,153 @@
+// Package search ranks the tools, prompts and resources of MCP servers against a keyword query.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// item kinds.
+const (
+	KindTool     = "tool"
+	KindPrompt   = "prompt"
+	KindResource = "resource"
+)
+
+// Item is a searchable tool, prompt or resource of a server.
+type Item struct {
+	Server      string `json:"server"`
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Match is an item that matched a query, with higher scores ranking first.
+type Match struct {
+	Item
+	Score float64 `json:"score"`
+}
+
+// Rank scores every item against the keywords in query and returns the matching items, best
+// match first. Names weigh more than descriptions, and name matches fall back to fuzzy
+// subsequence matching so that "crtiss" still finds "create_issue".
+func Rank(query string, items []Item) []Match {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for _, item := range items {
+		var score float64
+		for _, term := range terms {
+			score += scoreTerm(term, item)
+		}
+		if score > 0 {
+			matches = append(matches, Match{Item: item, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+
+	return matches
+}
+
+// scoreTerm scores a single lowercase keyword against an item.
+func scoreTerm(term string, item Item) float64 {
+	name := strings.ToLower(item.Name)
+	description := strings.ToLower(item.Description)
+
+	var score float64
+	switch {
+	case name == term:
+		score = 100
+	case strings.HasPrefix(name, term):
+		score = 60
+	case containsWord(words(item.Name), term):
+		score = 50
+	case strings.Contains(name, term):
+		score = 40
+	default:
+		score = fuzzyScore(term, name)
+	}
+
+	switch {
+	case containsWord(words(item.Description), term):
+		score += 20
+	case strings.Contains(description, term):
+		score += 10
+	}
+
+	return score
+}
+
+// fuzzyScore scores term as a subsequence of name, favouring compact matches. It returns 0 if
+// the characters of term don't appear in order in name.
+func fuzzyScore(term, name string) float64 {
+	const maxScore = 30
+
+	pos := 0
+	first := -1
+	last := 0
+	for _, r := range term {
+		idx := strings.IndexRune(name[pos:], r)
+		if idx == -1 {
+			return 0
+		}
+		if first == -1 {
+			first = pos + idx
+		}
+		last = pos + idx
+		pos += idx + len(string(r))
+	}
+
+	span := last - first + 1
+	return maxScore * float64(len(term)) / float64(span)
+}
+
+// words splits a name or description into lowercase words at separators and camelCase
+// boundaries.
+func words(s string) []string {
+	var result []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			result = append(result, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	var prev rune
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+		prev = r
+	}
+	flush()
+
+	return result
+}
+
+// containsWord reports whether term is one of words.
+func containsWord(words []string, term string) bool {
+	for _, w := range words {
+		if w == term {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRank(t *testing.T) {
+	items := []Item{
+		{Kind: KindTool, Name: "create_issue", Description: "Create a new issue in a repository"},
+		{Kind: KindTool, Name: "list_issues", Description: "List issues in a repository"},
+		{Kind: KindTool, Name: "get_file", Description: "Read a file from a repository"},
+		{Kind: KindPrompt, Name: "triage", Description: "Triage an open issue"},
+		{Kind: KindResource, Name: "issue", Description: "The issue template"},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "exact name first", query: "issue", want: []string{"issue", "create_issue", "list_issues", "triage"}},
+		{name: "fuzzy name", query: "crtiss", want: []string{"create_issue"}},
+		{name: "word in name", query: "file", want: []string{"get_file"}},
+		{name: "no match", query: "calendar", want: nil},
+		{name: "empty query", query: " ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := Rank(tt.query, items)
+			if len(matches) != len(tt.want) {
+				t.Fatalf("Rank(%q) returned %d matches, want %d: %+v", tt.query, len(matches), len(tt.want), matches)
+			}
+			for i, m := range matches {
+				if m.Name != tt.want[i] {
+					t.Errorf("match %d = %s, want %s", i, m.Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	got := words("getUserProfile_v2-beta")
+	want := []string{"get", "user", "profile", "v2", "beta"}
+	if len(got) != len(want) {
+		t.Fatalf("words() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("words()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// bagOfWordsServer serves embeddings that count a few fixed words, so related texts are similar.
+func bagOfWordsServer(t *testing.T, requests *int) *httptest.Server {
+	t.Helper()
+	vocabulary := []string{"calendar", "event", "meeting", "file", "issue"}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		data := make([]map[string]any, len(req.Input))
+		for i, text := range req.Input {
+			vector := make([]float64, len(vocabulary))
+			for j, word := range vocabulary {
+				vector[j] = float64(strings.Count(strings.ToLower(text), word))
+			}
+			data[i] = map[string]any{"index": i, "embedding": vector}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+}
+
+func TestRankSemantic(t *testing.T) {
+	requests := 0
+	server := bagOfWordsServer(t, &requests)
+	defer server.Close()
+
+	embedder := &CachedEmbedder{
+		Inner: &APIEmbedder{Client: server.Client(), URL: server.URL, Model: "test"},
+		Path:  filepath.Join(t.TempDir(), "embeddings.json"),
+		Key:   "test",
+	}
+	items := []Item{
+		{Kind: KindTool, Name: "read_file", Description: "Read a file"},
+		{Kind: KindTool, Name: "schedule", Description: "Create a calendar event or meeting"},
+	}
+
+	for range 2 {
+		matches, err := RankSemantic(context.Background(), embedder, "make a calendar event", items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matches[0].Name != "schedule" || matches[0].Score <= matches[1].Score {
+			t.Errorf("expected schedule to rank first, got %+v", matches)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected cached embeddings to be reused, got %d requests", requests)
+	}
+}
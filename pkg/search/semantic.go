@@ -0,0 +1,228 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// default embeddings endpoint and model, used unless overridden by the environment.
+const (
+	DefaultEmbeddingsURL   = "https://api.openai.com/v1/embeddings"
+	DefaultEmbeddingsModel = "text-embedding-3-small"
+)
+
+// Embedder turns texts into embedding vectors.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// APIEmbedder computes embeddings with an OpenAI-compatible /embeddings endpoint. Local models
+// served by Ollama, llama.cpp or LM Studio expose the same API.
+type APIEmbedder struct {
+	Client *http.Client
+	URL    string
+	Model  string
+	APIKey string
+}
+
+// NewAPIEmbedderFromEnv creates an embedder configured by MCPT_EMBEDDINGS_URL,
+// MCPT_EMBEDDINGS_MODEL and MCPT_EMBEDDINGS_KEY, falling back to OPENAI_API_KEY for the key.
+func NewAPIEmbedderFromEnv() *APIEmbedder {
+	e := &APIEmbedder{
+		Client: &http.Client{Timeout: 60 * time.Second},
+		URL:    os.Getenv("MCPT_EMBEDDINGS_URL"),
+		Model:  os.Getenv("MCPT_EMBEDDINGS_MODEL"),
+		APIKey: os.Getenv("MCPT_EMBEDDINGS_KEY"),
+	}
+
+	if e.URL == "" {
+		e.URL = DefaultEmbeddingsURL
+	}
+	if e.Model == "" {
+		e.Model = DefaultEmbeddingsModel
+	}
+	if e.APIKey == "" {
+		e.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	return e
+}
+
+// Embed implements Embedder.
+func (e *APIEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(map[string]any{"model": e.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embeddings request failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var result struct {
+		Data []struct {
+			Embedding []float64 `json:"embedding"`
+			Index     int       `json:"index"`
+		} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(result.Data))
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+
+	return vectors, nil
+}
+
+// CachedEmbedder stores embeddings in a file so that unchanged tool descriptions are only
+// embedded once.
+type CachedEmbedder struct {
+	Inner Embedder
+	Path  string
+	// Key distinguishes embeddings of different models in the same cache.
+	Key string
+}
+
+// GetEmbeddingsCachePath returns the path to the embeddings cache in the user's home directory.
+func GetEmbeddingsCachePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".mcpt", "embeddings.json"), nil
+}
+
+// Embed implements Embedder, only asking the inner embedder for texts not in the cache.
+func (c *CachedEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	cache := map[string][]float64{}
+	// #nosec G304 - the cache path is derived from the user's home directory
+	if raw, err := os.ReadFile(c.Path); err == nil {
+		_ = json.Unmarshal(raw, &cache)
+	}
+
+	vectors := make([][]float64, len(texts))
+	var missing []string
+	var missingIdx []int
+	for i, text := range texts {
+		if v, ok := cache[c.cacheKey(text)]; ok {
+			vectors[i] = v
+			continue
+		}
+		missing = append(missing, text)
+		missingIdx = append(missingIdx, i)
+	}
+
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	computed, err := c.Inner.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, v := range computed {
+		vectors[missingIdx[j]] = v
+		cache[c.cacheKey(missing[j])] = v
+	}
+
+	if raw, marshalErr := json.Marshal(cache); marshalErr == nil {
+		if mkdirErr := os.MkdirAll(filepath.Dir(c.Path), 0o750); mkdirErr == nil {
+			_ = os.WriteFile(c.Path, raw, 0o600)
+		}
+	}
+
+	return vectors, nil
+}
+
+// cacheKey identifies the embedding of text in the cache.
+func (c *CachedEmbedder) cacheKey(text string) string {
+	sum := sha256.Sum256([]byte(c.Key + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// RankSemantic ranks items by the cosine similarity of their embedded name and description to
+// the embedded query, best match first. The score is the similarity scaled to 0-100.
+func RankSemantic(ctx context.Context, embedder Embedder, query string, items []Item) ([]Match, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	texts := make([]string, 0, len(items)+1)
+	texts = append(texts, query)
+	for _, item := range items {
+		texts = append(texts, item.Name+": "+item.Description)
+	}
+
+	vectors, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, 0, len(items))
+	for i, item := range items {
+		similarity := Cosine(vectors[0], vectors[i+1])
+		matches = append(matches, Match{Item: item, Score: math.Round(similarity*10000) / 100})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches, nil
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if they can't be compared.
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
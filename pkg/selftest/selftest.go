@@ -0,0 +1,270 @@
+// Package selftest is a conformance suite run by a client against a server it knows, checking
+// that the two agree on the basics of MCP: the handshake, ping, listing and using tools,
+// prompts and resources, and the errors of unknown methods and tools.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Statuses of check results.
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// methodNotFound is the JSON-RPC error code of unknown methods.
+const methodNotFound = -32601
+
+// Expect is what the server under test offers, which the checks use.
+type Expect struct {
+	// Tool is a tool that, called with Arguments, answers with text containing ToolText.
+	Tool      string
+	Arguments map[string]any
+	ToolText  string
+	// Prompt is a prompt that, got with PromptArguments, has a message containing PromptText.
+	// Prompt checks are skipped if it is empty.
+	Prompt          string
+	PromptArguments map[string]string
+	PromptText      string
+	// Resource is the URI of a resource whose text contains ResourceText. Resource checks are
+	// skipped if it is empty.
+	Resource     string
+	ResourceText string
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Check    string        `json:"check"`
+	Status   string        `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// errSkipped is returned by checks that do not apply, with the reason.
+type errSkipped string
+
+func (e errSkipped) Error() string { return string(e) }
+
+// check is a check of the suite, returning a detail of what it saw.
+type check struct {
+	name string
+	run  func(ctx context.Context, c *client.Client, init *mcp.InitializeResult, expect Expect) (string, error)
+}
+
+var checks = []check{
+	{"handshake", checkHandshake},
+	{"ping", checkPing},
+	{"tools/list", checkListTools},
+	{"tools/call", checkCallTool},
+	{"unknown tool", checkUnknownTool},
+	{"prompts/list", checkListPrompts},
+	{"prompts/get", checkGetPrompt},
+	{"resources/list", checkListResources},
+	{"resources/read", checkReadResource},
+	{"unknown method", checkUnknownMethod},
+}
+
+// Run runs the suite against the server c is connected to, which answered the handshake with
+// init. Once a check fails, the checks that need the server to work are still run, so the
+// report shows everything that is wrong.
+func Run(ctx context.Context, c *client.Client, init *mcp.InitializeResult, expect Expect) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		start := time.Now()
+		detail, err := check.run(ctx, c, init, expect)
+		result := Result{Check: check.name, Status: StatusPass, Detail: detail, Duration: time.Since(start)}
+		var skipped errSkipped
+		switch {
+		case errors.As(err, &skipped):
+			result.Status, result.Detail = StatusSkip, skipped.Error()
+		case err != nil:
+			result.Status, result.Detail = StatusFail, err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Failed reports whether a check of results failed.
+func Failed(results []Result) bool {
+	return slices.ContainsFunc(results, func(r Result) bool { return r.Status == StatusFail })
+}
+
+func checkHandshake(_ context.Context, _ *client.Client, init *mcp.InitializeResult, _ Expect) (string, error) {
+	switch {
+	case init == nil:
+		return "", errors.New("no initialize result")
+	case init.ProtocolVersion == "":
+		return "", errors.New("no protocol version negotiated")
+	case init.ServerInfo.Name == "":
+		return "", errors.New("the server did not name itself")
+	}
+	return fmt.Sprintf("protocol %s, %s %s", init.ProtocolVersion, init.ServerInfo.Name, init.ServerInfo.Version), nil
+}
+
+func checkPing(ctx context.Context, c *client.Client, _ *mcp.InitializeResult, _ Expect) (string, error) {
+	return "", c.Ping(ctx)
+}
+
+func checkListTools(ctx context.Context, c *client.Client, _ *mcp.InitializeResult, expect Expect) (string, error) {
+	result, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return "", err
+	}
+	found := false
+	for _, tool := range result.Tools {
+		if tool.Name == "" {
+			return "", errors.New("a tool has no name")
+		}
+		if tool.RawInputSchema == nil && tool.InputSchema.Type != "object" {
+			return "", fmt.Errorf("the input schema of %s is not an object", tool.Name)
+		}
+		found = found || tool.Name == expect.Tool
+	}
+	if !found {
+		return "", fmt.Errorf("%s is not listed", expect.Tool)
+	}
+	return fmt.Sprintf("%d tool(s)", len(result.Tools)), nil
+}
+
+func checkCallTool(ctx context.Context, c *client.Client, _ *mcp.InitializeResult, expect Expect) (string, error) {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = expect.Tool
+	request.Params.Arguments = expect.Arguments
+	result, err := c.CallTool(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	text := contentText(result.Content)
+	if result.IsError {
+		return "", fmt.Errorf("%s failed: %s", expect.Tool, text)
+	}
+	if !strings.Contains(text, expect.ToolText) {
+		return "", fmt.Errorf("%s answered %q, expected it to contain %q", expect.Tool, text, expect.ToolText)
+	}
+	return expect.Tool, nil
+}
+
+func checkUnknownTool(ctx context.Context, c *client.Client, _ *mcp.InitializeResult, _ Expect) (string, error) {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "selftest_no_such_tool"
+	result, err := c.CallTool(ctx, request)
+	switch {
+	case err != nil:
+		return "error: " + err.Error(), nil
+	case result.IsError:
+		return "failed call: " + contentText(result.Content), nil
+	}
+	return "", errors.New("calling an unknown tool succeeded")
+}
+
+func checkListPrompts(ctx context.Context, c *client.Client, _ *mcp.InitializeResult, expect Expect) (string, error) {
+	if expect.Prompt == "" {
+		return "", errSkipped("the server offers no prompt to check")
+	}
+	result, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		return "", err
+	}
+	if !slices.ContainsFunc(result.Prompts, func(p mcp.Prompt) bool { return p.Name == expect.Prompt }) {
+		return "", fmt.Errorf("%s is not listed", expect.Prompt)
+	}
+	return fmt.Sprintf("%d prompt(s)", len(result.Prompts)), nil
+}
+
+func checkGetPrompt(ctx context.Context, c *client.Client, _ *mcp.InitializeResult, expect Expect) (string, error) {
+	if expect.Prompt == "" {
+		return "", errSkipped("the server offers no prompt to check")
+	}
+	request := mcp.GetPromptRequest{}
+	request.Params.Name = expect.Prompt
+	request.Params.Arguments = expect.PromptArguments
+	result, err := c.GetPrompt(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	var texts []string
+	for _, message := range result.Messages {
+		texts = append(texts, contentText([]mcp.Content{message.Content}))
+	}
+	if text := strings.Join(texts, "\n"); !strings.Contains(text, expect.PromptText) {
+		return "", fmt.Errorf("%s has the messages %q, expected them to contain %q", expect.Prompt, text, expect.PromptText)
+	}
+	return expect.Prompt, nil
+}
+
+func checkListResources(ctx context.Context, c *client.Client, _ *mcp.InitializeResult, expect Expect) (string, error) {
+	if expect.Resource == "" {
+		return "", errSkipped("the server offers no resource to check")
+	}
+	result, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		return "", err
+	}
+	if !slices.ContainsFunc(result.Resources, func(r mcp.Resource) bool { return r.URI == expect.Resource }) {
+		return "", fmt.Errorf("%s is not listed", expect.Resource)
+	}
+	return fmt.Sprintf("%d resource(s)", len(result.Resources)), nil
+}
+
+func checkReadResource(ctx context.Context, c *client.Client, _ *mcp.InitializeResult, expect Expect) (string, error) {
+	if expect.Resource == "" {
+		return "", errSkipped("the server offers no resource to check")
+	}
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = expect.Resource
+	result, err := c.ReadResource(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	var texts []string
+	for _, contents := range result.Contents {
+		if text, ok := contents.(mcp.TextResourceContents); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	if text := strings.Join(texts, "\n"); !strings.Contains(text, expect.ResourceText) {
+		return "", fmt.Errorf("%s reads %q, expected it to contain %q", expect.Resource, text, expect.ResourceText)
+	}
+	return expect.Resource, nil
+}
+
+func checkUnknownMethod(ctx context.Context, c *client.Client, _ *mcp.InitializeResult, _ Expect) (string, error) {
+	response, err := c.GetTransport().SendRequest(ctx, transport.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId("selftest-unknown-method"),
+		Method:  "selftest/unknown",
+	})
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case response.Error == nil:
+		return "", errors.New("the server answered an unknown method")
+	case response.Error.Code != methodNotFound:
+		return "", fmt.Errorf("error code %d, expected %d (method not found)", response.Error.Code, methodNotFound)
+	}
+	return fmt.Sprintf("error %d", response.Error.Code), nil
+}
+
+// contentText joins the text of content.
+func contentText(content []mcp.Content) string {
+	var parts []string
+	for _, c := range content {
+		if text, ok := c.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
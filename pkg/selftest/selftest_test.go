@@ -0,0 +1,75 @@
+package selftest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/f/mcptools/pkg/server"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type nameInput struct {
+	Name string `json:"name"`
+}
+
+// connect starts an in-process client of s and initializes it.
+func connect(t *testing.T, s *server.Server) (*client.Client, *mcp.InitializeResult) {
+	t.Helper()
+	c, err := client.NewInProcessClient(s.MCPServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if err = c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{Name: "selftest", Version: "1"}
+	init, err := c.Initialize(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, init
+}
+
+func TestRun(t *testing.T) {
+	s := server.New("greeter", "1.0.0")
+	server.AddTool(s, "greet", "Greets someone", func(ctx context.Context, in nameInput) (string, error) {
+		return "Hello, " + in.Name, nil
+	})
+	server.AddPrompt(s, "welcome", "Welcomes someone", func(ctx context.Context, in nameInput) (string, error) {
+		return "Welcome, " + in.Name, nil
+	})
+	c, init := connect(t, s)
+
+	expect := Expect{
+		Tool: "greet", Arguments: map[string]any{"name": "Ada"}, ToolText: "Hello, Ada",
+		Prompt: "welcome", PromptArguments: map[string]string{"name": "Ada"}, PromptText: "Welcome, Ada",
+	}
+	results := Run(context.Background(), c, init, expect)
+	if Failed(results) {
+		t.Errorf("expected every check to pass, got %+v", results)
+	}
+	statuses := map[string]string{}
+	for _, result := range results {
+		statuses[result.Check] = result.Status
+	}
+	if statuses["handshake"] != StatusPass || statuses["resources/read"] != StatusSkip || statuses["prompts/get"] != StatusPass {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+
+	expect.ToolText = "Goodbye"
+	expect.Resource = "file:///missing"
+	results = Run(context.Background(), c, init, expect)
+	var failed []string
+	for _, result := range results {
+		if result.Status == StatusFail {
+			failed = append(failed, result.Check)
+		}
+	}
+	if len(failed) != 3 || failed[0] != "tools/call" || failed[1] != "resources/list" || failed[2] != "resources/read" {
+		t.Errorf("expected tools/call and the resource checks to fail, got %v in %+v", failed, results)
+	}
+}
@@ -0,0 +1,283 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultToolTimeout bounds how long a command or HTTP tool may run unless configured otherwise.
+const defaultToolTimeout = 30 * time.Second
+
+// maxHTTPResponse is the largest HTTP response body returned by an HTTP tool.
+const maxHTTPResponse = 1 << 20
+
+// Config declares a server in YAML: a list of tools, each backed by a shell command, an HTTP
+// request or a static response.
+type Config struct {
+	Name    string       `yaml:"name"`
+	Version string       `yaml:"version"`
+	Tools   []ToolConfig `yaml:"tools"`
+}
+
+// ToolConfig declares a single tool. Exactly one of Command, HTTP and Response must be set.
+// Arguments are substituted into {{name}} placeholders: shell-quoted in commands, URL-escaped in
+// URLs and verbatim elsewhere.
+type ToolConfig struct {
+	InputSchema map[string]any `yaml:"inputSchema"`
+	HTTP        *HTTPConfig    `yaml:"http"`
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Command     string         `yaml:"command"`
+	Response    string         `yaml:"response"`
+	Timeout     string         `yaml:"timeout"`
+}
+
+// HTTPConfig declares the request made by an HTTP tool.
+type HTTPConfig struct {
+	Headers map[string]string `yaml:"headers"`
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Body    string            `yaml:"body"`
+}
+
+// LoadConfig reads and validates a server definition.
+func LoadConfig(path string) (*Config, error) {
+	// #nosec G304 - the config path is provided explicitly by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg Config
+	if err = yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if cfg.Name == "" {
+		cfg.Name = "mcptools-config-server"
+	}
+	if cfg.Version == "" {
+		cfg.Version = "1.0.0"
+	}
+
+	seen := map[string]bool{}
+	for i, tool := range cfg.Tools {
+		if tool.Name == "" {
+			return nil, fmt.Errorf("tool %d has no name", i+1)
+		}
+		if seen[tool.Name] {
+			return nil, fmt.Errorf("tool %s is declared twice", tool.Name)
+		}
+		seen[tool.Name] = true
+
+		actions := 0
+		if tool.Command != "" {
+			actions++
+		}
+		if tool.HTTP != nil {
+			actions++
+		}
+		if tool.Response != "" {
+			actions++
+		}
+		if actions != 1 {
+			return nil, fmt.Errorf("tool %s must have exactly one of command, http or response", tool.Name)
+		}
+
+		if tool.HTTP != nil && tool.HTTP.URL == "" {
+			return nil, fmt.Errorf("tool %s: http.url is required", tool.Name)
+		}
+		if tool.Timeout != "" {
+			if _, err = time.ParseDuration(tool.Timeout); err != nil {
+				return nil, fmt.Errorf("tool %s: invalid timeout: %w", tool.Name, err)
+			}
+		}
+	}
+
+	return &cfg, nil
+}
+
+// NewConfigServer builds an MCP server exposing the tools declared in cfg.
+func NewConfigServer(cfg *Config) (*server.MCPServer, error) {
+	s := server.NewMCPServer(cfg.Name, cfg.Version, server.WithToolCapabilities(false))
+
+	for _, tc := range cfg.Tools {
+		schema := tc.InputSchema
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		rawSchema, err := json.Marshal(schema)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: invalid input schema: %w", tc.Name, err)
+		}
+
+		s.AddTool(mcp.NewToolWithRawSchema(tc.Name, tc.Description, rawSchema), configToolHandler(tc))
+	}
+
+	return s, nil
+}
+
+// configToolHandler returns the handler that runs a declared tool.
+func configToolHandler(tc ToolConfig) server.ToolHandlerFunc {
+	timeout := defaultToolTimeout
+	if tc.Timeout != "" {
+		timeout, _ = time.ParseDuration(tc.Timeout)
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := request.GetArguments()
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		switch {
+		case tc.Command != "":
+			return runCommandTool(ctx, expand(tc.Command, args, shellQuote), args)
+		case tc.HTTP != nil:
+			return runHTTPTool(ctx, tc.HTTP, args)
+		default:
+			return mcp.NewToolResultText(expand(tc.Response, args, verbatim)), nil
+		}
+	}
+}
+
+// runCommandTool runs command in a shell. The arguments are also passed as JSON in the
+// MCP_ARGUMENTS environment variable.
+func runCommandTool(ctx context.Context, command string, args map[string]any) (*mcp.CallToolResult, error) {
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	// #nosec G204 - the command comes from the server definition written by the user
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	cmd.Env = append(os.Environ(), "MCP_ARGUMENTS="+string(argsJSON))
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if runErr := cmd.Run(); runErr != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("command failed: %v: %s", runErr, msg)), nil
+	}
+
+	return mcp.NewToolResultText(stdout.String()), nil
+}
+
+// runHTTPTool makes the declared HTTP request and returns the response body.
+func runHTTPTool(ctx context.Context, hc *HTTPConfig, args map[string]any) (*mcp.CallToolResult, error) {
+	method := strings.ToUpper(hc.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if hc.Body != "" {
+		body = strings.NewReader(expand(hc.Body, args, verbatim))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, expand(hc.URL, args, urlEscape), body)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid request: %v", err)), nil
+	}
+	for name, value := range hc.Headers {
+		req.Header.Set(name, os.ExpandEnv(expand(value, args, verbatim)))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("request failed: %v", err)), nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponse))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read response: %v", err)), nil
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return mcp.NewToolResultError(fmt.Sprintf("%s: %s", resp.Status, data)), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// expand replaces {{name}} placeholders in template with the escaped argument values.
+// Placeholders for missing arguments become empty.
+func expand(template string, args map[string]any, escape func(string) string) string {
+	var buf strings.Builder
+	for {
+		start := strings.Index(template, "{{")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(template[start:], "}}")
+		if end == -1 {
+			break
+		}
+		end += start
+
+		buf.WriteString(template[:start])
+		name := strings.TrimSpace(template[start+2 : end])
+		if value, ok := args[name]; ok {
+			buf.WriteString(escape(argString(value)))
+		}
+		template = template[end+2:]
+	}
+	buf.WriteString(template)
+
+	return buf.String()
+}
+
+// argString renders an argument value as text; non-string values are JSON encoded.
+func argString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// verbatim inserts values unchanged.
+func verbatim(s string) string {
+	return s
+}
+
+// shellQuote quotes a value so the shell treats it as a single literal word.
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// urlEscape escapes a value for use in any part of a URL.
+func urlEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,114 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const testConfig = `
+name: ops
+tools:
+  - name: echo
+    description: Echo a message
+    inputSchema:
+      type: object
+      properties:
+        message: {type: string}
+    command: printf '%s' {{message}}
+  - name: greet
+    response: Hello {{name}}!
+  - name: lookup
+    http:
+      url: URL/items/{{id}}
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tools.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func callTool(t *testing.T, cfg *Config, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	s, err := NewConfigServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callServerTool(t, s, name, args)
+}
+
+func TestConfigTools(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("item " + r.URL.EscapedPath()))
+	}))
+	defer srv.Close()
+
+	cfg, err := LoadConfig(writeConfig(t, strings.Replace(testConfig, "URL", srv.URL, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args map[string]any
+		name string
+		tool string
+		want string
+	}{
+		{name: "command quotes arguments", tool: "echo", args: map[string]any{"message": "it's $HOME; ls"}, want: "it's $HOME; ls"},
+		{name: "static response", tool: "greet", args: map[string]any{"name": "Ada"}, want: "Hello Ada!"},
+		{name: "http escapes url", tool: "lookup", args: map[string]any{"id": "a b/c"}, want: "item /items/a%20b%2Fc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callTool(t, cfg, tt.tool, tt.args)
+			if result.IsError {
+				t.Fatalf("unexpected error result: %s", resultText(result))
+			}
+			if got := resultText(result); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigCommandFailure(t *testing.T) {
+	cfg := &Config{Name: "ops", Tools: []ToolConfig{{Name: "fail", Command: "echo broken >&2; exit 3"}}}
+
+	result := callTool(t, cfg, "fail", nil)
+	if !result.IsError || !strings.Contains(resultText(result), "broken") {
+		t.Errorf("expected error result with stderr, got %+v", result)
+	}
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		errMsg string
+	}{
+		{name: "missing name", config: "tools:\n  - response: hi\n", errMsg: "has no name"},
+		{name: "no action", config: "tools:\n  - name: a\n", errMsg: "exactly one of"},
+		{name: "two actions", config: "tools:\n  - name: a\n    response: hi\n    command: echo\n", errMsg: "exactly one of"},
+		{name: "duplicate", config: "tools:\n  - name: a\n    response: hi\n  - name: a\n    response: hi\n", errMsg: "declared twice"},
+		{name: "bad timeout", config: "tools:\n  - name: a\n    response: hi\n    timeout: soon\n", errMsg: "invalid timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Fetch limits used unless configured otherwise.
+const (
+	DefaultFetchMaxBytes  = 5 << 20
+	DefaultFetchMaxLength = 20000
+	defaultFetchTimeout   = 30 * time.Second
+	maxFetchRedirects     = 10
+)
+
+// fetchUserAgent identifies requests made by the fetch server.
+const fetchUserAgent = "mcptools-fetch/1.0 (+https://github.com/f/mcptools)"
+
+// Errors returned by the fetch server.
+var (
+	ErrDomainNotAllowed = errors.New("domain is not allowed")
+	ErrNoAllowedDomains = errors.New("at least one allowed domain is required")
+)
+
+// FetchOptions configures the fetch server.
+type FetchOptions struct {
+	// Client makes the requests. If nil, a client with a 30 second timeout is used.
+	Client *http.Client
+	// AllowedDomains lists the hosts that may be fetched. Each entry also allows its subdomains.
+	AllowedDomains []string
+	// MaxBytes limits the size of a downloaded response body.
+	MaxBytes int64
+	// MaxLength is the default number of characters returned by one call.
+	MaxLength int
+}
+
+// fetcher implements the fetch_url tool.
+type fetcher struct {
+	client    *http.Client
+	allowed   []string
+	maxBytes  int64
+	maxLength int
+}
+
+// NewFetchServer builds an MCP server with a fetch_url tool restricted to opts.AllowedDomains.
+func NewFetchServer(opts FetchOptions) (*server.MCPServer, error) {
+	if len(opts.AllowedDomains) == 0 {
+		return nil, ErrNoAllowedDomains
+	}
+
+	f := &fetcher{maxBytes: opts.MaxBytes, maxLength: opts.MaxLength}
+	if f.maxBytes <= 0 {
+		f.maxBytes = DefaultFetchMaxBytes
+	}
+	if f.maxLength <= 0 {
+		f.maxLength = DefaultFetchMaxLength
+	}
+	for _, domain := range opts.AllowedDomains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "*.")
+		if domain != "" {
+			f.allowed = append(f.allowed, strings.TrimSuffix(domain, "."))
+		}
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultFetchTimeout}
+	}
+	// Copy the client so redirects can be checked against the allowlist without changing the
+	// caller's client.
+	f.client = &http.Client{
+		Transport: client.Transport,
+		Jar:       client.Jar,
+		Timeout:   client.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			return f.checkURL(req.URL)
+		},
+	}
+
+	s := server.NewMCPServer("fetch", "1.0.0", server.WithToolCapabilities(false))
+	s.AddTool(mcp.NewTool("fetch_url",
+		mcp.WithDescription(fmt.Sprintf("Fetch a web page and return it as Markdown. Only these domains (and their "+
+			"subdomains) can be fetched: %s. Long pages are returned in parts: call again with start_index to "+
+			"continue.", strings.Join(f.allowed, ", "))),
+		mcp.WithString("url", mcp.Required(), mcp.Description("The http or https URL to fetch")),
+		mcp.WithNumber("max_length", mcp.Description("Maximum number of characters to return"),
+			mcp.DefaultNumber(float64(f.maxLength))),
+		mcp.WithNumber("start_index", mcp.Description("Character to start from, to continue a truncated page"),
+			mcp.DefaultNumber(0)),
+		mcp.WithBoolean("raw", mcp.Description("Return the raw content instead of converting HTML to Markdown")),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithOpenWorldHintAnnotation(true),
+	), f.handleFetch)
+
+	return s, nil
+}
+
+func (f *fetcher) handleFetch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	rawURL, err := request.RequireString("url")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err == nil {
+		err = f.checkURL(u)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	content, err := f.fetch(ctx, u, request.GetBool("raw", false))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(page(content,
+		request.GetInt("start_index", 0), request.GetInt("max_length", f.maxLength))), nil
+}
+
+// fetch downloads u and returns its content, converting HTML to Markdown unless raw is set.
+func (f *fetcher) fetch(ctx context.Context, u *url.URL, raw bool) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", fetchUserAgent)
+	req.Header.Set("Accept", "text/html, text/markdown, text/plain, application/json;q=0.9, */*;q=0.5")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", u, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("failed to fetch %s: %s", u, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", u, err)
+	}
+	if int64(len(body)) > f.maxBytes {
+		return "", fmt.Errorf("response from %s is larger than %d bytes", u, f.maxBytes)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	isHTML := mediaType == "text/html" || mediaType == "application/xhtml+xml" ||
+		(mediaType == "" && bytes.Contains(bytes.ToLower(body[:min(len(body), 512)]), []byte("<html")))
+
+	switch {
+	case isHTML && !raw:
+		return HTMLToMarkdown(bytes.NewReader(body), resp.Request.URL)
+	case isHTML, strings.HasPrefix(mediaType, "text/"), isTextMediaType(mediaType), mediaType == "" && utf8.Valid(body):
+		return string(body), nil
+	default:
+		return "", fmt.Errorf("unsupported content type %s", mediaType)
+	}
+}
+
+// checkURL returns an error unless u is an http or https URL on an allowed domain.
+func (f *fetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for _, domain := range f.allowed {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrDomainNotAllowed, host)
+}
+
+// isTextMediaType reports whether a non text/* media type holds text, e.g. JSON or XML.
+func isTextMediaType(mediaType string) bool {
+	for _, suffix := range []string{"json", "xml", "javascript", "yaml"} {
+		if strings.HasSuffix(mediaType, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// page returns up to length characters of content starting at start, with a note on how to
+// continue if content was cut.
+func page(content string, start, length int) string {
+	runes := []rune(content)
+	if start < 0 {
+		start = 0
+	}
+	if start >= len(runes) {
+		return "No more content."
+	}
+	if length <= 0 {
+		length = DefaultFetchMaxLength
+	}
+
+	end := min(start+length, len(runes))
+	out := string(runes[start:end])
+	if end < len(runes) {
+		out += fmt.Sprintf("\n\n[Content truncated: showing characters %d-%d of %d. Call again with start_index=%d to continue.]",
+			start, end, len(runes), end)
+	}
+	return out
+}
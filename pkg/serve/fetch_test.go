@@ -0,0 +1,95 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testPage = `<html><head><title>Docs</title><script>alert(1)</script></head>
+<body><nav><a href="/">Home</a></nav>
+<main>
+  <h1>Getting   started</h1>
+  <p>Install with <code>go install</code> and read the <a href="/guide">guide</a>.</p>
+  <ul><li>One</li><li><strong>Two</strong></li></ul>
+  <pre><code>mcp tools
+mcp call</code></pre>
+</main></body></html>`
+
+func TestHTMLToMarkdown(t *testing.T) {
+	base, _ := url.Parse("https://docs.example.com/start")
+	got, err := HTMLToMarkdown(strings.NewReader(testPage), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# Getting started\n\n" +
+		"Install with `go install` and read the [guide](https://docs.example.com/guide).\n\n" +
+		"- One\n- **Two**\n\n" +
+		"```\nmcp tools\nmcp call\n```\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFetchServer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(testPage))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Repeat("x", 2048)))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://evil.example.net/", http.StatusFound)
+	})
+	mux.HandleFunc("/binary", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s, err := NewFetchServer(FetchOptions{AllowedDomains: []string{"127.0.0.1"}, MaxBytes: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args    map[string]any
+		name    string
+		want    string
+		isError bool
+	}{
+		{name: "html to markdown", args: map[string]any{"url": srv.URL + "/page"}, want: "# Getting started"},
+		{name: "raw", args: map[string]any{"url": srv.URL + "/page", "raw": true}, want: "<h1>"},
+		{name: "paging", args: map[string]any{"url": srv.URL + "/page", "max_length": 5}, want: "start_index=5"},
+		{name: "disallowed domain", args: map[string]any{"url": "https://example.org/"}, want: "domain is not allowed", isError: true},
+		{name: "disallowed scheme", args: map[string]any{"url": "file:///etc/passwd"}, want: "unsupported URL scheme", isError: true},
+		{name: "redirect to disallowed domain", args: map[string]any{"url": srv.URL + "/redirect"}, want: "domain is not allowed", isError: true},
+		{name: "size limit", args: map[string]any{"url": srv.URL + "/large"}, want: "larger than 1024 bytes", isError: true},
+		{name: "binary content", args: map[string]any{"url": srv.URL + "/binary"}, want: "unsupported content type", isError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callServerTool(t, s, "fetch_url", tt.args)
+			if result.IsError != tt.isError {
+				t.Fatalf("IsError = %v, output: %s", result.IsError, resultText(result))
+			}
+			if got := resultText(result); !strings.Contains(got, tt.want) {
+				t.Errorf("expected output containing %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewFetchServerRequiresDomains(t *testing.T) {
+	if _, err := NewFetchServer(FetchOptions{}); err != ErrNoAllowedDomains {
+		t.Errorf("expected ErrNoAllowedDomains, got %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package serve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultLogCount is the number of commits returned by the log tool unless asked otherwise.
+const defaultLogCount = 20
+
+// maxGitOutput is the largest git output returned by a tool; longer output is truncated.
+const maxGitOutput = 1 << 20
+
+// GitOptions configures the git server.
+type GitOptions struct {
+	// AllowWrite adds the add and commit tools. Without it the server never modifies the repo.
+	AllowWrite bool
+}
+
+// gitRepo runs git commands in a single repository.
+type gitRepo struct {
+	dir string
+}
+
+// NewGitServer builds an MCP server with tools to inspect the git repository containing dir.
+func NewGitServer(dir string, opts GitOptions) (*server.MCPServer, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &gitRepo{dir: abs}
+	top, err := repo.git(context.Background(), "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a git repository: %w", dir, err)
+	}
+	repo.dir = strings.TrimSpace(top)
+
+	s := server.NewMCPServer("git-"+filepath.Base(repo.dir), "1.0.0", server.WithToolCapabilities(false))
+
+	s.AddTool(mcp.NewTool("status",
+		mcp.WithDescription("Show the working tree status"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), repo.handleStatus)
+
+	s.AddTool(mcp.NewTool("log",
+		mcp.WithDescription("Show the commit history, newest first"),
+		mcp.WithString("revision", mcp.Description("Revision or range to start from (default HEAD)")),
+		mcp.WithString("path", mcp.Description("Only show commits touching this path")),
+		mcp.WithNumber("max_count", mcp.Description("Maximum number of commits"), mcp.DefaultNumber(defaultLogCount)),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), repo.handleLog)
+
+	s.AddTool(mcp.NewTool("diff",
+		mcp.WithDescription("Show changes between revisions, or uncommitted changes if no revision is given"),
+		mcp.WithString("from", mcp.Description("Revision to diff from")),
+		mcp.WithString("to", mcp.Description("Revision to diff to (default: the working tree)")),
+		mcp.WithString("path", mcp.Description("Limit the diff to this path")),
+		mcp.WithBoolean("staged", mcp.Description("Show staged changes instead of unstaged ones")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), repo.handleDiff)
+
+	s.AddTool(mcp.NewTool("show",
+		mcp.WithDescription("Show a commit with its diff, or a file at a revision (e.g. HEAD~1:main.go)"),
+		mcp.WithString("revision", mcp.Required(), mcp.Description("Commit, tag or revision:path")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), repo.handleShow)
+
+	s.AddTool(mcp.NewTool("blame",
+		mcp.WithDescription("Show which commit last changed each line of a file"),
+		mcp.WithString("path", mcp.Required(), mcp.Description("File to blame")),
+		mcp.WithString("revision", mcp.Description("Revision to blame at (default: the working tree)")),
+		mcp.WithNumber("start_line", mcp.Description("First line to blame")),
+		mcp.WithNumber("end_line", mcp.Description("Last line to blame")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), repo.handleBlame)
+
+	s.AddTool(mcp.NewTool("grep",
+		mcp.WithDescription("Search tracked files for a regular expression"),
+		mcp.WithString("pattern", mcp.Required(), mcp.Description("Regular expression to search for")),
+		mcp.WithString("revision", mcp.Description("Search this revision instead of the working tree")),
+		mcp.WithString("path", mcp.Description("Limit the search to this path")),
+		mcp.WithBoolean("ignore_case", mcp.Description("Match case-insensitively")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), repo.handleGrep)
+
+	if opts.AllowWrite {
+		s.AddTool(mcp.NewTool("add",
+			mcp.WithDescription("Stage files for the next commit"),
+			mcp.WithArray("paths", mcp.Required(), mcp.Description("Paths to stage"), mcp.WithStringItems()),
+		), repo.handleAdd)
+
+		s.AddTool(mcp.NewTool("commit",
+			mcp.WithDescription("Commit the staged changes"),
+			mcp.WithString("message", mcp.Required(), mcp.Description("Commit message")),
+		), repo.handleCommit)
+	}
+
+	return s, nil
+}
+
+func (r *gitRepo) handleStatus(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return r.result(ctx, "status", "--short", "--branch")
+}
+
+func (r *gitRepo) handleLog(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := []string{"log", "--max-count=" + strconv.Itoa(request.GetInt("max_count", defaultLogCount)),
+		"--format=%H %ad %an%n    %s", "--date=short"}
+
+	revision := request.GetString("revision", "")
+	if err := checkGitArg(revision); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if revision != "" {
+		args = append(args, revision)
+	}
+
+	return r.result(ctx, withPath(args, request.GetString("path", ""))...)
+}
+
+func (r *gitRepo) handleDiff(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := []string{"diff"}
+	if request.GetBool("staged", false) {
+		args = append(args, "--cached")
+	}
+
+	for _, key := range []string{"from", "to"} {
+		revision := request.GetString(key, "")
+		if err := checkGitArg(revision); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if revision != "" {
+			args = append(args, revision)
+		}
+	}
+
+	return r.result(ctx, withPath(args, request.GetString("path", ""))...)
+}
+
+func (r *gitRepo) handleShow(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	revision, err := request.RequireString("revision")
+	if err == nil {
+		err = checkGitArg(revision)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return r.result(ctx, "show", revision, "--")
+}
+
+func (r *gitRepo) handleBlame(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, err := request.RequireString("path")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	args := []string{"blame"}
+	if start := request.GetInt("start_line", 0); start > 0 {
+		end := request.GetInt("end_line", 0)
+		if end > 0 {
+			args = append(args, fmt.Sprintf("-L%d,%d", start, end))
+		} else {
+			args = append(args, fmt.Sprintf("-L%d,", start))
+		}
+	}
+
+	revision := request.GetString("revision", "")
+	if err = checkGitArg(revision); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if revision != "" {
+		args = append(args, revision)
+	}
+
+	return r.result(ctx, withPath(args, path)...)
+}
+
+func (r *gitRepo) handleGrep(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pattern, err := request.RequireString("pattern")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	args := []string{"grep", "--line-number", "--extended-regexp"}
+	if request.GetBool("ignore_case", false) {
+		args = append(args, "--ignore-case")
+	}
+	args = append(args, "-e", pattern)
+
+	revision := request.GetString("revision", "")
+	if err = checkGitArg(revision); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if revision != "" {
+		args = append(args, revision)
+	}
+
+	output, err := r.git(ctx, withPath(args, request.GetString("path", ""))...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return mcp.NewToolResultText("No matches found"), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(output), nil
+}
+
+func (r *gitRepo) handleAdd(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	paths, err := request.RequireStringSlice("paths")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(paths) == 0 {
+		return mcp.NewToolResultError("at least one path is required"), nil
+	}
+
+	return r.result(ctx, append([]string{"add", "--"}, paths...)...)
+}
+
+func (r *gitRepo) handleCommit(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	message, err := request.RequireString("message")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return r.result(ctx, "commit", "--message", message)
+}
+
+// result runs git and turns its output, or its failure, into a tool result.
+func (r *gitRepo) result(ctx context.Context, args ...string) (*mcp.CallToolResult, error) {
+	output, err := r.git(ctx, args...)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if output == "" {
+		output = "(no output)"
+	}
+	return mcp.NewToolResultText(output), nil
+}
+
+// git runs a git command in the repository and returns its output, truncated to maxGitOutput.
+func (r *gitRepo) git(ctx context.Context, args ...string) (string, error) {
+	// #nosec G204 - arguments are passed to git directly, and user values are checked by checkGitArg
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", r.dir, "--no-pager"}, args...)...)
+	cmd.Env = append(cmd.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s: %w", args[0], msg, err)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	output := stdout.String()
+	if len(output) > maxGitOutput {
+		output = output[:maxGitOutput] + "\n... (output truncated)"
+	}
+	return output, nil
+}
+
+// checkGitArg rejects values that git would parse as options, such as --output=<file>.
+func checkGitArg(value string) error {
+	if strings.HasPrefix(value, "-") {
+		return fmt.Errorf("invalid revision %q", value)
+	}
+	return nil
+}
+
+// withPath appends a pathspec after "--" so it is never parsed as a revision or option.
+func withPath(args []string, path string) []string {
+	if path == "" {
+		return args
+	}
+	return append(args, "--", path)
+}
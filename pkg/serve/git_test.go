@@ -0,0 +1,100 @@
+package serve
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "main.go"}, {"commit", "-q", "-m", "Initial commit"}} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+	return dir
+}
+
+func TestGitTools(t *testing.T) {
+	s, err := NewGitServer(newTestRepo(t), GitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args    map[string]any
+		name    string
+		tool    string
+		want    string
+		isError bool
+	}{
+		{name: "log", tool: "log", want: "Initial commit"},
+		{name: "show file", tool: "show", args: map[string]any{"revision": "HEAD:main.go"}, want: "func main"},
+		{name: "blame", tool: "blame", args: map[string]any{"path": "main.go", "start_line": 3, "end_line": 3}, want: "func main"},
+		{name: "grep", tool: "grep", args: map[string]any{"pattern": "^package"}, want: "main.go:1:package main"},
+		{name: "grep without matches", tool: "grep", args: map[string]any{"pattern": "nothing here"}, want: "No matches found"},
+		{name: "option injection", tool: "diff", args: map[string]any{"from": "--output=/tmp/x"}, want: "invalid revision", isError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callServerTool(t, s, tt.tool, tt.args)
+			if result.IsError != tt.isError {
+				t.Fatalf("IsError = %v, output: %s", result.IsError, resultText(result))
+			}
+			if got := resultText(result); !strings.Contains(got, tt.want) {
+				t.Errorf("expected output containing %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if slices.Contains(serverToolNames(t, s), "commit") {
+		t.Error("commit tool should not be exposed without AllowWrite")
+	}
+}
+
+func TestGitWriteTools(t *testing.T) {
+	dir := newTestRepo(t)
+	s, err := NewGitServer(dir, GitOptions{AllowWrite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Test\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := callServerTool(t, s, "add", map[string]any{"paths": []any{"README.md"}}); result.IsError {
+		t.Fatalf("add failed: %s", resultText(result))
+	}
+	if result := callServerTool(t, s, "commit", map[string]any{"message": "Add readme"}); result.IsError {
+		t.Fatalf("commit failed: %s", resultText(result))
+	}
+	if result := callServerTool(t, s, "log", nil); !strings.Contains(resultText(result), "Add readme") {
+		t.Errorf("expected new commit in log, got %s", resultText(result))
+	}
+}
+
+func TestNewGitServerNotARepo(t *testing.T) {
+	if _, err := NewGitServer(t.TempDir(), GitOptions{}); err == nil {
+		t.Error("expected error for a directory outside any repository")
+	}
+}
@@ -0,0 +1,127 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/f/mcptools/pkg/index"
+	"github.com/f/mcptools/pkg/search"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultIndexHits is the number of chunks a search returns unless asked otherwise.
+const defaultIndexHits = 5
+
+// IndexOptions configures the index server.
+type IndexOptions struct {
+	// Embedder embeds queries for semantic searches, which are unavailable if it is nil.
+	Embedder search.Embedder
+	// Model identifies the embeddings of Embedder in the index.
+	Model string
+}
+
+// indexServer implements the tools of the index server.
+type indexServer struct {
+	ix   *index.Index
+	opts IndexOptions
+}
+
+// NewIndexServer builds an MCP retrieval server over an index built by mcp index build: a
+// search tool returning the chunks of resources that best match a query, get_chunk to read the
+// chunks around them, and list_indexed_servers.
+func NewIndexServer(ix *index.Index, opts IndexOptions) *server.MCPServer {
+	s := &indexServer{ix: ix, opts: opts}
+	srv := server.NewMCPServer("index", "1.0.0", server.WithToolCapabilities(false))
+
+	description := "Search the indexed resources of MCP servers by keywords, all of which must appear in a resource. " +
+		"Returns the best matching chunk of text of each resource, best match first, with its server, URI, chunk " +
+		"number, the number of chunks of the resource, and when it was indexed and last checked for changes."
+	if opts.Embedder != nil {
+		description += " With semantic, resources are found by meaning instead, for servers indexed with embeddings."
+	}
+	srv.AddTool(mcp.NewTool("search",
+		mcp.WithDescription(description),
+		mcp.WithString("query", mcp.Required(), mcp.Description("The words, or with semantic the meaning, to look for")),
+		mcp.WithString("server", mcp.Description("Only search the resources of this indexed server")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of chunks"), mcp.DefaultNumber(defaultIndexHits)),
+		mcp.WithBoolean("semantic", mcp.Description("Find resources by meaning using embeddings")),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleSearch)
+	srv.AddTool(mcp.NewTool("get_chunk",
+		mcp.WithDescription("Return a chunk of text of an indexed resource, such as the ones before or after a chunk "+
+			"found by search. Chunks are numbered from 0."),
+		mcp.WithString("server", mcp.Required(), mcp.Description("The server of the resource")),
+		mcp.WithString("uri", mcp.Required(), mcp.Description("The URI of the resource")),
+		mcp.WithNumber("chunk", mcp.Description("The number of the chunk"), mcp.DefaultNumber(0)),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleGetChunk)
+	srv.AddTool(mcp.NewTool("list_indexed_servers",
+		mcp.WithDescription("List the indexed servers, with how many resources of each are indexed, with embeddings or not"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleList)
+
+	return srv
+}
+
+func (s *indexServer) handleSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("query")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts := index.SearchOptions{
+		Server: request.GetString("server", ""),
+		Limit:  request.GetInt("limit", defaultIndexHits),
+	}
+
+	var chunks []index.Chunk
+	if request.GetBool("semantic", false) {
+		if s.opts.Embedder == nil {
+			return mcp.NewToolResultError("semantic search is not configured for this server"), nil
+		}
+		chunks, err = s.ix.SemanticChunks(ctx, query, s.opts.Embedder, s.opts.Model, opts)
+	} else {
+		chunks, err = s.ix.SearchChunks(ctx, query, opts)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jsonResult(map[string]any{"chunks": chunks})
+}
+
+func (s *indexServer) handleGetChunk(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	server, err := request.RequireString("server")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	uri, err := request.RequireString("uri")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	chunk, err := s.ix.Chunk(ctx, server, uri, request.GetInt("chunk", 0))
+	if errors.Is(err, index.ErrNotIndexed) {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return jsonResult(chunk)
+}
+
+func (s *indexServer) handleList(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	summaries, err := s.ix.Servers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return jsonResult(map[string]any{"servers": summaries})
+}
+
+func jsonResult(v any) (*mcp.CallToolResult, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,51 @@
+package serve
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/index"
+)
+
+func TestIndexServer(t *testing.T) {
+	ix, err := index.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ix.Close() }()
+	docs := []index.Document{
+		{URI: "doc://limits", Name: "limits", Text: "Clients are throttled above 100 requests per minute."},
+		{URI: "doc://guide", Name: "guide", Text: strings.Repeat("Start the server. ", 150) + "\n\nThen stop it."},
+	}
+	if _, err = ix.Update(context.Background(), "docs", docs, index.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewIndexServer(ix, IndexOptions{})
+
+	tests := []struct {
+		tool    string
+		args    map[string]any
+		want    string
+		isError bool
+	}{
+		{tool: "search", args: map[string]any{"query": "throttled"}, want: `"uri":"doc://limits","name":"limits","chunk":0,"chunks":1,"text":"Clients`},
+		{tool: "search", args: map[string]any{"query": "stop"}, want: `"chunk":1,"chunks":2`},
+		{tool: "search", args: map[string]any{"query": "throttled", "server": "other"}, want: `"chunks":[]`},
+		{tool: "search", args: map[string]any{"query": "x", "semantic": true}, want: "not configured", isError: true},
+		{tool: "get_chunk", args: map[string]any{"server": "docs", "uri": "doc://guide"}, want: `"chunk":0,"chunks":2,"text":"Start the server.`},
+		{tool: "get_chunk", args: map[string]any{"server": "docs", "uri": "doc://guide", "chunk": 2}, want: "has 2 chunks", isError: true},
+		{tool: "get_chunk", args: map[string]any{"server": "docs", "uri": "doc://missing"}, want: "not indexed", isError: true},
+		{tool: "list_indexed_servers", want: `"resources":2`},
+	}
+	for _, tt := range tests {
+		result := callServerTool(t, s, tt.tool, tt.args)
+		if result.IsError != tt.isError {
+			t.Fatalf("%s %v: IsError = %v, output: %s", tt.tool, tt.args, result.IsError, resultText(result))
+		}
+		if got := resultText(result); !strings.Contains(got, tt.want) {
+			t.Errorf("%s %v: expected output containing %q, got %q", tt.tool, tt.args, tt.want, got)
+		}
+	}
+}
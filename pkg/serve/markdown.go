@@ -0,0 +1,281 @@
+package serve
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements are never rendered: they hold scripts, styling or page chrome rather than
+// content.
+var skippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+	atom.Nav:      true,
+	atom.Form:     true,
+	atom.Button:   true,
+}
+
+var (
+	spaceRun    = regexp.MustCompile(`[ \t\r\n]+`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+	spaceBefore = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// HTMLToMarkdown converts an HTML document to Markdown, keeping headings, paragraphs, links,
+// emphasis, lists, quotes, code and images and dropping everything else. Relative links are
+// resolved against base if it is set.
+func HTMLToMarkdown(r io.Reader, base *url.URL) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	root := doc
+	if main := findElement(doc, atom.Main); main != nil {
+		root = main
+	} else if article := findElement(doc, atom.Article); article != nil {
+		root = article
+	}
+
+	c := markdownConverter{base: base}
+	c.children(root)
+
+	out := spaceBefore.ReplaceAllString(c.buf.String(), "\n")
+	out = blankLines.ReplaceAllString(out, "\n\n")
+	return strings.TrimSpace(out) + "\n", nil
+}
+
+// markdownConverter writes Markdown for a parsed HTML tree.
+type markdownConverter struct {
+	base  *url.URL
+	buf   strings.Builder
+	lists []listState
+	pre   bool
+}
+
+// listState tracks a list being rendered.
+type listState struct {
+	ordered bool
+	index   int
+}
+
+func (c *markdownConverter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child)
+	}
+}
+
+func (c *markdownConverter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		c.children(n)
+		return
+	}
+
+	if skippedElements[n.DataAtom] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		c.block()
+		c.buf.WriteString(strings.Repeat("#", level) + " ")
+		c.buf.WriteString(strings.TrimSpace(c.inline(n)))
+		c.block()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer,
+		atom.Table, atom.Figure, atom.Dl:
+		c.block()
+		c.children(n)
+		c.block()
+	case atom.Tr, atom.Dt, atom.Dd, atom.Figcaption:
+		c.line()
+		c.children(n)
+		c.line()
+	case atom.Td, atom.Th:
+		c.children(n)
+		c.buf.WriteString(" ")
+	case atom.Br:
+		c.buf.WriteString("\n")
+	case atom.Hr:
+		c.block()
+		c.buf.WriteString("---")
+		c.block()
+	case atom.A:
+		text := strings.TrimSpace(c.inline(n))
+		href := c.resolve(attr(n, "href"))
+		switch {
+		case text == "":
+		case href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:"):
+			c.buf.WriteString(text)
+		default:
+			c.buf.WriteString("[" + text + "](" + href + ")")
+		}
+	case atom.Img:
+		if src := c.resolve(attr(n, "src")); src != "" {
+			c.buf.WriteString("![" + attr(n, "alt") + "](" + src + ")")
+		}
+	case atom.Strong, atom.B:
+		c.wrap(n, "**")
+	case atom.Em, atom.I:
+		c.wrap(n, "_")
+	case atom.Code:
+		if c.pre {
+			c.children(n)
+		} else {
+			c.wrap(n, "`")
+		}
+	case atom.Pre:
+		c.block()
+		c.buf.WriteString("```\n")
+		c.pre = true
+		c.children(n)
+		c.pre = false
+		c.line()
+		c.buf.WriteString("```")
+		c.block()
+	case atom.Blockquote:
+		inner := markdownConverter{base: c.base}
+		inner.children(n)
+		c.block()
+		for _, line := range strings.Split(strings.TrimSpace(inner.buf.String()), "\n") {
+			c.buf.WriteString("> " + line + "\n")
+		}
+		c.block()
+	case atom.Ul, atom.Ol:
+		if len(c.lists) == 0 {
+			c.block()
+		} else {
+			c.line()
+		}
+		c.lists = append(c.lists, listState{ordered: n.DataAtom == atom.Ol})
+		c.children(n)
+		c.lists = c.lists[:len(c.lists)-1]
+		if len(c.lists) == 0 {
+			c.block()
+		}
+	case atom.Li:
+		c.line()
+		marker := "- "
+		if len(c.lists) > 0 {
+			list := &c.lists[len(c.lists)-1]
+			list.index++
+			if list.ordered {
+				marker = fmt.Sprintf("%d. ", list.index)
+			}
+			c.buf.WriteString(strings.Repeat("  ", len(c.lists)-1))
+		}
+		c.buf.WriteString(marker)
+		c.children(n)
+		c.line()
+	default:
+		c.children(n)
+	}
+}
+
+// text writes a text node, collapsing whitespace outside preformatted blocks.
+func (c *markdownConverter) text(data string) {
+	if c.pre {
+		c.buf.WriteString(data)
+		return
+	}
+
+	data = spaceRun.ReplaceAllString(data, " ")
+	if strings.HasPrefix(data, " ") && c.atLineStart() {
+		data = data[1:]
+	}
+	c.buf.WriteString(data)
+}
+
+// inline renders the children of n on their own and returns the result.
+func (c *markdownConverter) inline(n *html.Node) string {
+	inner := markdownConverter{base: c.base, pre: c.pre}
+	inner.children(n)
+	return spaceRun.ReplaceAllString(inner.buf.String(), " ")
+}
+
+// wrap renders the children of n between two markers, e.g. ** for bold text.
+func (c *markdownConverter) wrap(n *html.Node, marker string) {
+	text := c.inline(n)
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		c.buf.WriteString(text)
+		return
+	}
+	if strings.HasPrefix(text, " ") {
+		c.buf.WriteString(" ")
+	}
+	c.buf.WriteString(marker + trimmed + marker)
+	if strings.HasSuffix(text, " ") {
+		c.buf.WriteString(" ")
+	}
+}
+
+// resolve turns a link into an absolute URL. Fragment-only links are kept as they are.
+func (c *markdownConverter) resolve(ref string) string {
+	if c.base == nil || ref == "" || strings.HasPrefix(ref, "#") {
+		return ref
+	}
+	u, err := c.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// line ends the current line unless it is already empty.
+func (c *markdownConverter) line() {
+	if !c.atLineStart() {
+		c.buf.WriteString("\n")
+	}
+}
+
+// block separates block elements with a blank line.
+func (c *markdownConverter) block() {
+	c.line()
+	if c.buf.Len() > 0 && !strings.HasSuffix(c.buf.String(), "\n\n") {
+		c.buf.WriteString("\n")
+	}
+}
+
+func (c *markdownConverter) atLineStart() bool {
+	s := c.buf.String()
+	return s == "" || strings.HasSuffix(s, "\n")
+}
+
+// findElement returns the first element of type a under n.
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,300 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/script"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// scriptReloadInterval is how often the script is checked for changes.
+const scriptReloadInterval = 500 * time.Millisecond
+
+// luaFunction matches the first line of a function definition.
+var luaFunction = regexp.MustCompile(`^\s*(?:local\s+)?function\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+
+// ScriptTool is a tool declared in a Lua script. A tool is a global function preceded by a
+// comment block containing @tool:
+//
+//	--- Add two numbers.
+//	-- @tool
+//	-- @param a number The first number
+//	-- @param b? number An optional second number
+//	function add(args)
+//	  return args.a + (args.b or 0)
+//	end
+//
+// "@tool name" exposes the function under a different name. Parameter types are JSON schema
+// types; a name ending in ? marks the parameter optional.
+type ScriptTool struct {
+	Schema      map[string]any
+	Name        string
+	Function    string
+	Description string
+}
+
+// ScriptServer serves the tools declared in a Lua script, reloading them when the file
+// changes.
+type ScriptServer struct {
+	server  *server.MCPServer
+	path    string
+	source  string
+	modTime time.Time
+	tools   []ScriptTool
+	mu      sync.RWMutex
+}
+
+// NewScriptServer loads the script at path and builds an MCP server exposing its tools.
+func NewScriptServer(path string) (*ScriptServer, error) {
+	s := &ScriptServer{
+		path:   path,
+		server: server.NewMCPServer("script", "1.0.0", server.WithToolCapabilities(true)),
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// MCPServer returns the MCP server exposing the script's tools.
+func (s *ScriptServer) MCPServer() *server.MCPServer {
+	return s.server
+}
+
+// Tools returns the tools currently declared by the script.
+func (s *ScriptServer) Tools() []ScriptTool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tools
+}
+
+// Reload reads the script again and replaces the served tools. The script is checked by
+// running it once; if it fails, the previous tools stay in place.
+func (s *ScriptServer) Reload() error {
+	// #nosec G304 - the script path is provided explicitly by the user
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read script: %w", err)
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read script: %w", err)
+	}
+
+	source := string(data)
+	tools, err := ParseScriptTools(source)
+	if err != nil {
+		return err
+	}
+
+	L := script.NewState()
+	defer L.Close()
+	if err = L.DoString(source); err != nil {
+		return fmt.Errorf("failed to load script: %w", err)
+	}
+	for _, tool := range tools {
+		if L.GetGlobal(tool.Function).Type() != lua.LTFunction {
+			return fmt.Errorf("tool %s: %s is not a global function", tool.Name, tool.Function)
+		}
+	}
+
+	s.mu.Lock()
+	s.source = source
+	s.modTime = info.ModTime()
+	s.tools = tools
+	s.mu.Unlock()
+
+	serverTools := make([]server.ServerTool, 0, len(tools))
+	for _, tool := range tools {
+		rawSchema, _ := json.Marshal(tool.Schema)
+		serverTools = append(serverTools, server.ServerTool{
+			Tool:    mcp.NewToolWithRawSchema(tool.Name, tool.Description, rawSchema),
+			Handler: s.toolHandler(tool.Function),
+		})
+	}
+	s.server.SetTools(serverTools...)
+
+	return nil
+}
+
+// Watch reloads the script whenever it changes, until ctx is done. Reload errors are passed
+// to onError and the previous tools are kept.
+func (s *ScriptServer) Watch(ctx context.Context, onReload func([]ScriptTool), onError func(error)) {
+	ticker := time.NewTicker(scriptReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(s.path)
+		if err != nil {
+			continue
+		}
+
+		s.mu.RLock()
+		changed := !info.ModTime().Equal(s.modTime)
+		s.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err = s.Reload(); err != nil {
+			// Remember the failed version so the error is reported once per change
+			s.mu.Lock()
+			s.modTime = info.ModTime()
+			s.mu.Unlock()
+			onError(err)
+			continue
+		}
+		onReload(s.Tools())
+	}
+}
+
+// toolHandler calls function in a fresh Lua state, so calls cannot leak state into each other.
+func (s *ScriptServer) toolHandler(function string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		s.mu.RLock()
+		source := s.source
+		s.mu.RUnlock()
+
+		L := script.NewState()
+		defer L.Close()
+		L.SetContext(ctx)
+
+		if err := L.DoString(source); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to load script: %v", err)), nil
+		}
+
+		err := L.CallByParam(lua.P{Fn: L.GetGlobal(function), NRet: 1, Protect: true},
+			script.ToLua(L, request.GetArguments()))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		ret := L.Get(-1)
+		L.Pop(1)
+
+		switch v := ret.(type) {
+		case lua.LString:
+			return mcp.NewToolResultText(string(v)), nil
+		case *lua.LNilType:
+			return mcp.NewToolResultText(""), nil
+		default:
+			data, jsonErr := json.Marshal(script.FromLua(ret))
+			if jsonErr != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("cannot encode result: %v", jsonErr)), nil
+			}
+			return mcp.NewToolResultText(string(data)), nil
+		}
+	}
+}
+
+// ParseScriptTools finds the functions annotated with @tool in a Lua script.
+func ParseScriptTools(source string) ([]ScriptTool, error) {
+	var (
+		tools   []ScriptTool
+		comment []string
+		seen    = map[string]bool{}
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		if trimmed := strings.TrimSpace(text); strings.HasPrefix(trimmed, "--") {
+			comment = append(comment, strings.TrimLeft(trimmed, "-"))
+			continue
+		}
+
+		if m := luaFunction.FindStringSubmatch(text); m != nil && len(comment) > 0 {
+			tool, isTool, err := parseToolComment(m[1], comment)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if isTool {
+				if seen[tool.Name] {
+					return nil, fmt.Errorf("line %d: tool %s is declared twice", line, tool.Name)
+				}
+				seen[tool.Name] = true
+				tools = append(tools, tool)
+			}
+		}
+		comment = nil
+	}
+
+	return tools, scanner.Err()
+}
+
+// parseToolComment builds a tool from the comment block above function. It reports false if
+// the block has no @tool annotation.
+func parseToolComment(function string, comment []string) (ScriptTool, bool, error) {
+	tool := ScriptTool{Name: function, Function: function}
+	properties := map[string]any{}
+	required := []string{}
+	isTool := false
+	var description []string
+
+	for _, line := range comment {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+
+		switch {
+		case len(fields) > 0 && fields[0] == "@tool":
+			isTool = true
+			if len(fields) > 1 {
+				tool.Name = fields[1]
+			}
+		case len(fields) > 0 && fields[0] == "@param":
+			if len(fields) < 3 {
+				return tool, false, fmt.Errorf("%s: @param needs a name and a type", function)
+			}
+			name, paramType := fields[1], fields[2]
+			if !isSchemaType(paramType) {
+				return tool, false, fmt.Errorf("%s: unknown type %q for parameter %s", function, paramType, name)
+			}
+
+			optional := strings.HasSuffix(name, "?")
+			name = strings.TrimSuffix(name, "?")
+			property := map[string]any{"type": paramType}
+			if len(fields) > 3 {
+				property["description"] = strings.Join(fields[3:], " ")
+			}
+			properties[name] = property
+			if !optional {
+				required = append(required, name)
+			}
+		case strings.HasPrefix(line, "@"):
+			// Other annotations, e.g. @return, are documentation only
+		case line != "":
+			description = append(description, line)
+		}
+	}
+
+	tool.Description = strings.Join(description, " ")
+	tool.Schema = map[string]any{"type": "object", "properties": properties, "required": required}
+	return tool, isTool, nil
+}
+
+func isSchemaType(t string) bool {
+	switch t {
+	case "string", "number", "integer", "boolean", "object", "array":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package serve
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testScript = `
+--- Add two numbers.
+-- @tool
+-- @param a number The first number
+-- @param b? number An optional second number
+function add(args)
+  return args.a + (args.b or 0)
+end
+
+-- Helpers without @tool are not exposed.
+function helper()
+  return "hidden"
+end
+
+--- Split a comma-separated list.
+-- @tool split_list
+-- @param text string The list
+function split(args)
+  local parts = {}
+  for part in string.gmatch(args.text, "[^,]+") do
+    table.insert(parts, part)
+  end
+  return parts
+end
+
+--- Always fails.
+-- @tool
+function fail(args)
+  error("boom")
+end
+`
+
+func writeScript(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseScriptTools(t *testing.T) {
+	tools, err := ParseScriptTools(testScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	if want := []string{"add", "split_list", "fail"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("tools = %v, want %v", names, want)
+	}
+
+	add := tools[0]
+	if add.Description != "Add two numbers." {
+		t.Errorf("unexpected description %q", add.Description)
+	}
+	if required := add.Schema["required"]; !reflect.DeepEqual(required, []string{"a"}) {
+		t.Errorf("required = %v, want [a]", required)
+	}
+
+	if _, err = ParseScriptTools("-- @tool\n-- @param x color\nfunction f() end\n"); err == nil {
+		t.Error("expected error for unknown parameter type")
+	}
+}
+
+func TestScriptServerTools(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.lua")
+	writeScript(t, path, testScript)
+
+	s, err := NewScriptServer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args    map[string]any
+		name    string
+		tool    string
+		want    string
+		isError bool
+	}{
+		{name: "number result", tool: "add", args: map[string]any{"a": 2, "b": 3}, want: "5"},
+		{name: "table result as JSON", tool: "split_list", args: map[string]any{"text": "a,b"}, want: `["a","b"]`},
+		{name: "script error", tool: "fail", want: "boom", isError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callServerTool(t, s.MCPServer(), tt.tool, tt.args)
+			if result.IsError != tt.isError {
+				t.Fatalf("IsError = %v, output: %s", result.IsError, resultText(result))
+			}
+			if got := resultText(result); !strings.Contains(got, tt.want) {
+				t.Errorf("expected output containing %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestScriptServerReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.lua")
+	writeScript(t, path, "-- @tool\nfunction hello(args)\n  return 'v1'\nend\n")
+
+	s, err := NewScriptServer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A broken script is rejected and the previous tools keep working
+	writeScript(t, path, "-- @tool\nfunction hello(args\n")
+	if err = s.Reload(); err == nil {
+		t.Error("expected error for a script that does not compile")
+	}
+	if got := resultText(callServerTool(t, s.MCPServer(), "hello", nil)); got != "v1" {
+		t.Errorf("got %q after failed reload, want v1", got)
+	}
+
+	writeScript(t, path, "-- @tool\nfunction bye(args)\n  return 'v2'\nend\n")
+	if err = s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if names := serverToolNames(t, s.MCPServer()); !reflect.DeepEqual(names, []string{"bye"}) {
+		t.Errorf("tools after reload = %v, want [bye]", names)
+	}
+}
@@ -0,0 +1,28 @@
+// Package serve implements the built-in MCP servers started by the serve-* commands.
+package serve
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/f/mcptools/pkg/server"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// Run serves s over stdio, or over streamable HTTP at httpAddr (e.g. ":8080") if it is set.
+func Run(s *mcpserver.MCPServer, httpAddr string) error {
+	if httpAddr == "" {
+		fmt.Fprintf(os.Stderr, "Serving on stdio, waiting for requests...\n")
+	} else {
+		fmt.Fprintf(os.Stderr, "Serving on http://%s/mcp\n", displayAddr(httpAddr))
+	}
+	return server.Wrap(s).Run(httpAddr)
+}
+
+// displayAddr turns a listen address such as ":8080" into one that can be connected to.
+func displayAddr(addr string) string {
+	if len(addr) > 0 && addr[0] == ':' {
+		return "localhost" + addr
+	}
+	return addr
+}
@@ -0,0 +1,431 @@
+package serve
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// Resource URIs exposed by the SQLite server.
+const (
+	sqliteSchemaURI = "sqlite://schema"
+	sqliteTableURI  = "sqlite://tables/"
+)
+
+// maxQueryRows is the largest number of rows returned by a query or a table resource.
+const maxQueryRows = 1000
+
+// Statement kinds allowed by default. Writes are only allowed when the database is not opened
+// read-only.
+var (
+	defaultReadStatements  = []string{"select", "with", "explain"}
+	defaultWriteStatements = []string{"insert", "update", "delete"}
+)
+
+// Errors returned when a query is rejected.
+var (
+	ErrMultipleStatements = errors.New("only a single SQL statement is allowed")
+	ErrEmptyStatement     = errors.New("empty SQL statement")
+)
+
+// SQLiteOptions configures the SQLite server.
+type SQLiteOptions struct {
+	// Allow lists the statement kinds (first keyword, e.g. "select") the query tool accepts.
+	// If empty, reads are allowed, plus inserts, updates and deletes unless ReadOnly is set.
+	Allow    []string
+	ReadOnly bool
+}
+
+// SQLiteServer exposes a SQLite database over MCP.
+type SQLiteServer struct {
+	db    *sql.DB
+	allow map[string]bool
+}
+
+// OpenSQLite opens the database at path. Read-only databases are opened with mode=ro and
+// query_only, so SQLite itself rejects writes whatever the allowlist says.
+func OpenSQLite(path string, opts SQLiteOptions) (*SQLiteServer, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Add("_pragma", "busy_timeout(5000)")
+	if opts.ReadOnly {
+		query.Set("mode", "ro")
+		query.Add("_pragma", "query_only(1)")
+	}
+
+	db, err := sql.Open("sqlite", "file:"+abs+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	allowed := opts.Allow
+	if len(allowed) == 0 {
+		allowed = defaultReadStatements
+		if !opts.ReadOnly {
+			allowed = append(append([]string{}, defaultReadStatements...), defaultWriteStatements...)
+		}
+	}
+
+	allow := make(map[string]bool, len(allowed))
+	for _, kind := range allowed {
+		allow[strings.ToLower(strings.TrimSpace(kind))] = true
+	}
+
+	return &SQLiteServer{db: db, allow: allow}, nil
+}
+
+// Close closes the database.
+func (s *SQLiteServer) Close() error {
+	return s.db.Close()
+}
+
+// MCPServer builds the MCP server: a query tool, a schema resource and one resource per table.
+func (s *SQLiteServer) MCPServer(ctx context.Context, name string) (*server.MCPServer, error) {
+	srv := server.NewMCPServer(name, "1.0.0",
+		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(false, false),
+	)
+
+	kinds := make([]string, 0, len(s.allow))
+	for kind := range s.allow {
+		kinds = append(kinds, strings.ToUpper(kind))
+	}
+	sort.Strings(kinds)
+
+	srv.AddTool(mcp.NewTool("query",
+		mcp.WithDescription(fmt.Sprintf("Run a single SQL statement against the database. Allowed statements: %s. "+
+			"Returns the column names and up to %d rows.", strings.Join(kinds, ", "), maxQueryRows)),
+		mcp.WithString("sql", mcp.Required(), mcp.Description("The SQL statement to run, with ? placeholders for params")),
+		mcp.WithArray("params", mcp.Description("Values bound to the ? placeholders")),
+	), s.handleQuery)
+
+	srv.AddResource(mcp.NewResource(sqliteSchemaURI, "schema",
+		mcp.WithResourceDescription("The CREATE statements of all tables, indexes and views"),
+		mcp.WithMIMEType("text/plain"),
+	), s.handleSchema)
+
+	tables, err := s.tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		srv.AddResource(mcp.NewResource(sqliteTableURI+url.PathEscape(table), table,
+			mcp.WithResourceDescription(fmt.Sprintf("Rows of table %s (up to %d)", table, maxQueryRows)),
+			mcp.WithMIMEType("application/json"),
+		), s.handleTable)
+	}
+	srv.AddResourceTemplate(mcp.NewResourceTemplate(sqliteTableURI+"{table}", "table",
+		mcp.WithTemplateDescription("Rows of a table"),
+		mcp.WithTemplateMIMEType("application/json"),
+	), s.handleTable)
+
+	return srv, nil
+}
+
+// CheckStatement returns the lowercased kind of a single SQL statement, or an error if the
+// statement is empty, contains several statements or is not allowed.
+func (s *SQLiteServer) CheckStatement(query string) (string, error) {
+	kind, err := statementKind(query)
+	if err != nil {
+		return "", err
+	}
+	if !s.allow[kind] {
+		return "", fmt.Errorf("%s statements are not allowed", strings.ToUpper(kind))
+	}
+	return kind, nil
+}
+
+func (s *SQLiteServer) handleQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("sql")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var params []any
+	if raw, ok := request.GetArguments()["params"].([]any); ok {
+		params = raw
+	}
+
+	kind, err := s.CheckStatement(query)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var result any
+	switch kind {
+	case "insert", "update", "delete", "replace":
+		res, execErr := s.db.ExecContext(ctx, query, params...)
+		if execErr != nil {
+			return mcp.NewToolResultError(execErr.Error()), nil
+		}
+		affected, _ := res.RowsAffected()
+		result = map[string]any{"rowsAffected": affected}
+	default:
+		rows, queryErr := s.query(ctx, query, params...)
+		if queryErr != nil {
+			return mcp.NewToolResultError(queryErr.Error()), nil
+		}
+		result = rows
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+func (s *SQLiteServer) handleSchema(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT sql FROM sqlite_schema WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' ORDER BY type DESC, name")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var statements []string
+	for rows.Next() {
+		var statement string
+		if err = rows.Scan(&statement); err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement+";")
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		// Clients cannot tell empty text contents from a missing resource
+		statements = append(statements, "-- The database is empty")
+	}
+
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      request.Params.URI,
+		MIMEType: "text/plain",
+		Text:     strings.Join(statements, "\n\n"),
+	}}, nil
+}
+
+func (s *SQLiteServer) handleTable(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	table, err := url.PathUnescape(strings.TrimPrefix(request.Params.URI, sqliteTableURI))
+	if err != nil {
+		return nil, fmt.Errorf("invalid table name: %w", err)
+	}
+
+	tables, err := s.tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(tables, table) {
+		return nil, fmt.Errorf("table %s not found", table)
+	}
+
+	rows, err := s.query(ctx, "SELECT * FROM "+quoteIdentifier(table))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      request.Params.URI,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}}, nil
+}
+
+// queryResult is the JSON form of a query's rows.
+type queryResult struct {
+	Columns   []string `json:"columns"`
+	Rows      [][]any  `json:"rows"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+func (s *SQLiteServer) query(ctx context.Context, query string, params ...any) (*queryResult, error) {
+	rows, err := s.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &queryResult{Columns: columns, Rows: [][]any{}}
+	for rows.Next() {
+		if len(result.Rows) == maxQueryRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			if b, ok := value.([]byte); ok && utf8.Valid(b) {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+
+	return result, rows.Err()
+}
+
+func (s *SQLiteServer) tables(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT name FROM sqlite_schema WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+
+	return tables, rows.Err()
+}
+
+// writeStatements are the kinds of statements that change the database. A WITH clause can
+// precede any of them.
+var writeStatements = map[string]bool{"insert": true, "update": true, "delete": true, "replace": true}
+
+// statementKind returns the lowercased first keyword of query, skipping comments. A WITH
+// statement whose common table expressions are followed by a write is of the kind of the write,
+// e.g. delete for WITH x AS (...) DELETE FROM t. It rejects input containing more than one
+// statement.
+func statementKind(query string) (string, error) {
+	var kind, main string
+	depth := 0
+	ended := false
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case strings.HasPrefix(query[i:], "--"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+			continue
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			continue
+		case c == ';':
+			ended = true
+			continue
+		case ended:
+			return "", ErrMultipleStatements
+		}
+
+		if kind == "" {
+			j := i
+			for j < len(query) && (query[j] >= 'a' && query[j] <= 'z' || query[j] >= 'A' && query[j] <= 'Z') {
+				j++
+			}
+			if j == i {
+				return "", fmt.Errorf("unexpected %q at start of statement", c)
+			}
+			kind = strings.ToLower(query[i:j])
+			i = j - 1
+			continue
+		}
+
+		// After the common table expressions, which are all in parentheses, the first select,
+		// values or write keyword at the top level starts the statement that runs
+		if kind == "with" && main == "" {
+			switch {
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+			case isWordByte(c):
+				j := i
+				for j < len(query) && isWordByte(query[j]) {
+					j++
+				}
+				word := strings.ToLower(query[i:j])
+				if depth == 0 && (writeStatements[word] || word == "select" || word == "values") {
+					main = word
+				}
+				i = j - 1
+				continue
+			}
+		}
+
+		// Skip quoted strings and identifiers so semicolons inside them are not mistaken for
+		// statement separators.
+		closing := byte(0)
+		switch c {
+		case '\'', '"', '`':
+			closing = c
+		case '[':
+			closing = ']'
+		}
+		if closing != 0 {
+			for i++; i < len(query) && query[i] != closing; i++ {
+			}
+		}
+	}
+
+	if kind == "" {
+		return "", ErrEmptyStatement
+	}
+	if writeStatements[main] {
+		return main, nil
+	}
+	return kind, nil
+}
+
+// isWordByte reports whether c can be part of an unquoted SQL keyword or identifier.
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
+
+// quoteIdentifier quotes a table or column name for use in SQL.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,177 @@
+package serve
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newTestDatabase(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.db")
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO users (name) VALUES ('ada'), ('grace');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func runQuery(t *testing.T, s *SQLiteServer, query string, params ...any) *mcp.CallToolResult {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"sql": query, "params": params}
+	result, err := s.handleQuery(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestStatementKind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		err   bool
+	}{
+		{name: "select", query: "SELECT 1", want: "select"},
+		{name: "trailing semicolon", query: "select 1;  ", want: "select"},
+		{name: "leading comments", query: "-- note\n/* block */ Delete FROM t", want: "delete"},
+		{name: "semicolon in string", query: "SELECT ';DROP TABLE t'", want: "select"},
+		{name: "with select", query: "WITH x AS (SELECT 1) SELECT replace(a, 'b', 'c') FROM x", want: "with"},
+		{name: "with delete", query: "WITH x AS (SELECT id FROM t WHERE (a = 'delete')) DELETE FROM t WHERE id IN x", want: "delete"},
+		{name: "recursive with insert", query: "with recursive n(i) as (values(1) union all select i+1 from n where i < 3) insert into t select i from n", want: "insert"},
+		{name: "multiple statements", query: "SELECT 1; DROP TABLE t", err: true},
+		{name: "empty", query: " -- nothing\n", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := statementKind(tt.query)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("statementKind(%q) = %q, %v; want %q", tt.query, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteQuery(t *testing.T) {
+	s, err := OpenSQLite(newTestDatabase(t), SQLiteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	result := runQuery(t, s, "SELECT name FROM users WHERE id = ?", 2)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", resultText(result))
+	}
+	var rows queryResult
+	if err = json.Unmarshal([]byte(resultText(result)), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows.Rows) != 1 || rows.Rows[0][0] != "grace" {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+
+	result = runQuery(t, s, "UPDATE users SET name = 'x'")
+	if result.IsError || !strings.Contains(resultText(result), `"rowsAffected":2`) {
+		t.Errorf("unexpected update result: %s", resultText(result))
+	}
+
+	result = runQuery(t, s, "DROP TABLE users")
+	if !result.IsError || !strings.Contains(resultText(result), "DROP statements are not allowed") {
+		t.Errorf("expected DROP to be rejected, got %s", resultText(result))
+	}
+}
+
+func TestSQLiteWithWrite(t *testing.T) {
+	s, err := OpenSQLite(newTestDatabase(t), SQLiteOptions{Allow: []string{"select", "with"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	result := runQuery(t, s, "WITH x AS (SELECT 1) DELETE FROM users")
+	if !result.IsError || !strings.Contains(resultText(result), "DELETE statements are not allowed") {
+		t.Errorf("expected WITH ... DELETE to be rejected, got %s", resultText(result))
+	}
+	result = runQuery(t, s, "WITH x AS (SELECT 1) SELECT count(*) FROM users")
+	if result.IsError || !strings.Contains(resultText(result), "2") {
+		t.Errorf("unexpected WITH ... SELECT result: %s", resultText(result))
+	}
+
+	writable, err := OpenSQLite(newTestDatabase(t), SQLiteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = writable.Close() }()
+	result = runQuery(t, writable, "WITH x AS (SELECT 1) DELETE FROM users WHERE id = 1")
+	if result.IsError || !strings.Contains(resultText(result), `"rowsAffected":1`) {
+		t.Errorf("unexpected WITH ... DELETE result: %s", resultText(result))
+	}
+}
+
+func TestSQLiteReadOnly(t *testing.T) {
+	s, err := OpenSQLite(newTestDatabase(t), SQLiteOptions{ReadOnly: true, Allow: []string{"select", "delete"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	result := runQuery(t, s, "DELETE FROM users")
+	if !result.IsError {
+		t.Errorf("expected write to a read-only database to fail, got %s", resultText(result))
+	}
+}
+
+func TestSQLiteResources(t *testing.T) {
+	s, err := OpenSQLite(newTestDatabase(t), SQLiteOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = sqliteTableURI + "users"
+	contents, err := s.handleTable(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := contents[0].(mcp.TextResourceContents).Text; !strings.Contains(text, `"ada"`) {
+		t.Errorf("unexpected table contents: %s", text)
+	}
+
+	request.Params.URI = sqliteSchemaURI
+	contents, err = s.handleSchema(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := contents[0].(mcp.TextResourceContents).Text; !strings.Contains(text, "CREATE TABLE users") {
+		t.Errorf("unexpected schema: %s", text)
+	}
+
+	request.Params.URI = sqliteTableURI + "missing"
+	if _, err = s.handleTable(context.Background(), request); err == nil {
+		t.Error("expected error for unknown table")
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// clientKey is the context key of the client a request was authenticated as.
+type clientKey struct{}
+
+// WithClient returns a context carrying the client a request was authenticated as, for HTTP
+// middleware authenticating clients in their own way.
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext returns the client the request of ctx was authenticated as, or "" if it
+// was not.
+func ClientFromContext(ctx context.Context) string {
+	client, _ := ctx.Value(clientKey{}).(string)
+	return client
+}
+
+// APIKeys is HTTP middleware admitting requests with one of keys, a map of API keys to the
+// clients they belong to, sent as a bearer token or in the X-API-Key header.
+func APIKeys(keys map[string]string) func(http.Handler) http.Handler {
+	digests := make(map[[sha256.Size]byte]string, len(keys))
+	for key, client := range keys {
+		digests[sha256.Sum256([]byte(key))] = client
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := bearerToken(r)
+			if key == "" {
+				key = r.Header.Get("X-API-Key")
+			}
+			sent := sha256.Sum256([]byte(key))
+			client, found := "", false
+			// Every key is compared, in constant time, so timing does not tell which matched
+			for digest, owner := range digests {
+				if subtle.ConstantTimeCompare(sent[:], digest[:]) == 1 {
+					client, found = owner, true
+				}
+			}
+			if key == "" || !found {
+				unauthorized(w, "")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithClient(r.Context(), client)))
+		})
+	}
+}
+
+// BearerAuth is HTTP middleware admitting requests with a bearer token verify accepts, such as
+// an OAuth access token checked by introspection or as a signed JWT. verify returns the
+// client the token was issued to.
+func BearerAuth(verify func(ctx context.Context, token string) (string, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if token == "" {
+				unauthorized(w, "")
+				return
+			}
+			client, err := verify(r.Context(), token)
+			if err != nil {
+				unauthorized(w, err.Error())
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithClient(r.Context(), client)))
+		})
+	}
+}
+
+// bearerToken returns the bearer token of the Authorization header of r.
+func bearerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > len("Bearer ") && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// unauthorized refuses a request, with the reason a token was invalid if there is one.
+func unauthorized(w http.ResponseWriter, reason string) {
+	challenge := `Bearer realm="mcp"`
+	if reason != "" {
+		challenge += `, error="invalid_token", error_description=` + strconv.Quote(reason)
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// remoteHost returns the host a request came from.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// post sends a JSON-RPC message to the server at url with the given headers.
+func post(t *testing.T, url, message string, header map[string]string) *http.Response {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range header {
+		request.Header.Set(key, value)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = response.Body.Close() })
+	return response
+}
+
+const initializeRequest = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`
+
+func TestAPIKeys(t *testing.T) {
+	var log bytes.Buffer
+	s := New("test", "1.0.0",
+		WithHTTPMiddleware(APIKeys(map[string]string{"sk-alice": "alice", "sk-bob": "bob"})),
+		WithMiddleware(LogRequests(&log)))
+	AddTool(s, "greet", "Greets someone", func(ctx context.Context, in greetInput) (string, error) {
+		return "Hello, " + in.Name + " from " + ClientFromContext(ctx), nil
+	})
+	httpServer := httptest.NewServer(s.Handler())
+	defer httpServer.Close()
+
+	for _, header := range []map[string]string{nil, {"X-API-Key": "sk-eve"}, {"Authorization": "Bearer sk-alic"}} {
+		response := post(t, httpServer.URL, initializeRequest, header)
+		if response.StatusCode != http.StatusUnauthorized || !strings.HasPrefix(response.Header.Get("WWW-Authenticate"), "Bearer") {
+			t.Errorf("expected %v to be refused, got %s", header, response.Status)
+		}
+	}
+
+	response := post(t, httpServer.URL, initializeRequest, map[string]string{"X-API-Key": "sk-bob"})
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("initialize failed: %s", response.Status)
+	}
+	session := response.Header.Get("Mcp-Session-Id")
+	response = post(t, httpServer.URL, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"greet","arguments":{"name":"Ada"}}}`,
+		map[string]string{"Authorization": "Bearer sk-alice", "Mcp-Session-Id": session})
+	body, _ := io.ReadAll(response.Body)
+	if !strings.Contains(string(body), "Hello, Ada from alice") {
+		t.Errorf("unexpected response %s", body)
+	}
+
+	records := logRecords(t, &log)
+	if len(records) != 2 || records[0].Client != "bob" || records[1].Client != "alice" || records[1].Status != StatusOK || records[1].Target != "greet" {
+		t.Errorf("unexpected records %+v", records)
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	s := New("test", "1.0.0",
+		WithHTTPMiddleware(BearerAuth(func(ctx context.Context, token string) (string, error) {
+			if token != "access-token" {
+				return "", errors.New("token expired")
+			}
+			return "app", nil
+		})),
+		WithMiddleware(RateLimit(0, 1)))
+	httpServer := httptest.NewServer(s.Handler())
+	defer httpServer.Close()
+
+	response := post(t, httpServer.URL, initializeRequest, map[string]string{"Authorization": "Bearer stale"})
+	if challenge := response.Header.Get("WWW-Authenticate"); response.StatusCode != http.StatusUnauthorized || !strings.Contains(challenge, `error_description="token expired"`) {
+		t.Errorf("expected the token to be refused, got %s %q", response.Status, challenge)
+	}
+
+	header := map[string]string{"Authorization": "Bearer access-token"}
+	if response = post(t, httpServer.URL, initializeRequest, header); response.StatusCode != http.StatusOK {
+		t.Fatalf("initialize failed: %s", response.Status)
+	}
+	header["Mcp-Session-Id"] = response.Header.Get("Mcp-Session-Id")
+	response = post(t, httpServer.URL, `{"jsonrpc":"2.0","id":2,"method":"ping"}`, header)
+	body, _ := io.ReadAll(response.Body)
+	if !strings.Contains(string(body), "rate limit exceeded") {
+		t.Errorf("expected the client to be rate limited, got %s", body)
+	}
+}
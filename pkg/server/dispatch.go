@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// envelope holds the fields of a JSON-RPC message the framework routes on.
+type envelope struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params struct {
+		Name      string          `json:"name"`
+		URI       string          `json:"uri"`
+		RequestID json.RawMessage `json:"requestId"`
+	} `json:"params"`
+}
+
+// parseEnvelope reads the routing fields of message, which are empty if it is not a JSON-RPC
+// message.
+func parseEnvelope(message []byte) envelope {
+	var e envelope
+	_ = json.Unmarshal(message, &e)
+	return e
+}
+
+// isRequest reports whether the message expects a response.
+func (e envelope) isRequest() bool {
+	return e.Method != "" && len(e.ID) > 0 && string(e.ID) != "null"
+}
+
+// requestKey identifies a request being handled: IDs are only unique within a session.
+type requestKey struct {
+	session, id string
+}
+
+// request is a request being handled.
+type request struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// newRequestKey identifies the request id of the session sessionID, writing id the same way
+// whatever its spacing.
+func newRequestKey(sessionID string, id json.RawMessage) requestKey {
+	var value any
+	if json.Unmarshal(id, &value) == nil {
+		if normalized, err := json.Marshal(value); err == nil {
+			id = normalized
+		}
+	}
+	return requestKey{session: sessionID, id: string(id)}
+}
+
+// begin starts handling the request id of the session sessionID, once fewer than the maximum
+// number of requests are being handled. It returns the context of the request, which is
+// cancelled when the client cancels the request, and done, to call once the request is
+// handled. It fails if ctx is done first.
+func (s *Server) begin(ctx context.Context, sessionID string, id json.RawMessage) (context.Context, func(), error) {
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	key := newRequestKey(sessionID, id)
+	r := &request{cancel: cancel}
+	s.mu.Lock()
+	s.inFlight[key] = r
+	s.mu.Unlock()
+
+	done := func() {
+		s.mu.Lock()
+		if s.inFlight[key] == r {
+			delete(s.inFlight, key)
+		}
+		s.mu.Unlock()
+		cancel()
+		if s.slots != nil {
+			<-s.slots
+		}
+	}
+	return ctx, done, nil
+}
+
+// cancel cancels the request id of the session sessionID, if it is being handled.
+func (s *Server) cancel(sessionID string, id json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.inFlight[newRequestKey(sessionID, id)]; ok {
+		r.cancelled = true
+		r.cancel()
+	}
+}
+
+// wasCancelled reports whether the client cancelled the request id of the session sessionID,
+// which is then left unanswered.
+func (s *Server) wasCancelled(sessionID string, id json.RawMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.inFlight[newRequestKey(sessionID, id)]
+	return ok && r.cancelled
+}
+
+// dispatch handles a message of the session sessionID, returning the response to send, or nil
+// if there is none. Requests are handled in their own context, cancelled when the client
+// cancels them, through the middleware of s, and a panic while handling one is answered with an
+// internal error.
+func (s *Server) dispatch(ctx context.Context, sessionID string, message []byte) any {
+	e := parseEnvelope(message)
+	if e.Method == methodCancelled {
+		s.cancel(sessionID, e.Params.RequestID)
+		return nil
+	}
+	if !e.isRequest() {
+		// Notifications have no response to report a panic in
+		defer func() { _ = recover() }()
+		return s.handleMessage(ctx, message)
+	}
+
+	ctx, done, err := s.begin(ctx, sessionID, e.ID)
+	if err != nil {
+		return nil
+	}
+	defer done()
+	response := s.serve(ctx, newRequest(ctx, sessionID, "", e, message), func(ctx context.Context, request *Request) any {
+		if request.Method == methodSubscribe || request.Method == methodUnsubscribe {
+			response, _ := s.handleSubscription(request.Session, request.Message)
+			return json.RawMessage(response)
+		}
+		return s.handleMessage(ctx, request.Message)
+	})
+	if s.wasCancelled(sessionID, e.ID) {
+		return nil
+	}
+	return response
+}
+
+// serve handles request through the middleware of s and then handler, answering panics with an
+// internal error.
+func (s *Server) serve(ctx context.Context, request *Request, handler RequestHandler) (response any) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			response = ErrorResponse(request, mcp.INTERNAL_ERROR, fmt.Sprintf("internal error handling %s: %v", request.Method, recovered))
+		}
+	}()
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		handler = s.middleware[i](handler)
+	}
+	return handler(ctx, request)
+}
+
+// handleMessage passes message to the underlying server.
+func (s *Server) handleMessage(ctx context.Context, message []byte) any {
+	if response := s.mcp.HandleMessage(ctx, message); response != nil {
+		return response
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// CodeRateLimited is the JSON-RPC error code of requests refused by RateLimit.
+const CodeRateLimited = -32029
+
+// Request is a request of a client, as middleware sees it.
+type Request struct {
+	// Session is the ID of the session the request was sent in.
+	Session string
+	// Client identifies the sender: the client an HTTP authenticator accepted, else the remote
+	// host of HTTP requests, else the session.
+	Client string
+	// Method is the JSON-RPC method, and Target the tool or prompt name or resource URI it
+	// operates on, if any.
+	Method, Target string
+	// ID is the JSON-RPC ID of the request.
+	ID json.RawMessage
+	// Message is the request as received, which middleware may replace.
+	Message []byte
+}
+
+// RequestHandler handles a request, returning the response to send, or nil for none.
+//
+// Over HTTP, the response is a json.RawMessage, and nil if it was streamed as server-sent
+// events.
+type RequestHandler func(ctx context.Context, request *Request) any
+
+// Middleware wraps the handling of requests, such as to log or limit them. Notifications do
+// not go through middleware.
+type Middleware func(next RequestHandler) RequestHandler
+
+// newRequest describes the request e of the session sessionID, sent from the host remote if
+// it came over HTTP.
+func newRequest(ctx context.Context, sessionID, remote string, e envelope, message []byte) *Request {
+	client := ClientFromContext(ctx)
+	if client == "" {
+		client = remote
+	}
+	if client == "" {
+		client = sessionID
+	}
+	target := e.Params.Name
+	if e.Params.URI != "" {
+		target = e.Params.URI
+	}
+	return &Request{Session: sessionID, Client: client, Method: e.Method, Target: target, ID: e.ID, Message: message}
+}
+
+// ErrorResponse returns a JSON-RPC error answering request, for middleware to refuse it.
+func ErrorResponse(request *Request, code int, message string) any {
+	var id any
+	_ = json.Unmarshal(request.ID, &id)
+	return mcp.NewJSONRPCError(mcp.NewRequestId(id), code, message, nil)
+}
+
+// Statuses of request log records.
+const (
+	StatusOK        = "ok"
+	StatusError     = "error"
+	StatusCancelled = "cancelled"
+)
+
+// RequestRecord is a JSON line of the request log written by LogRequests.
+type RequestRecord struct {
+	Time    time.Time `json:"time"`
+	Client  string    `json:"client,omitempty"`
+	Session string    `json:"session,omitempty"`
+	Method  string    `json:"method"`
+	Target  string    `json:"target,omitempty"`
+	// Status is ok, error for JSON-RPC errors and failed tool calls, or cancelled for requests
+	// left unanswered.
+	Status string `json:"status"`
+	Code   int    `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// DurationMS is how long the request took, in milliseconds.
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// LogRequests writes a RequestRecord to w for each request, once it is handled.
+func LogRequests(w io.Writer) Middleware {
+	var mu sync.Mutex
+	return func(next RequestHandler) RequestHandler {
+		return func(ctx context.Context, request *Request) any {
+			start := time.Now()
+			response := next(ctx, request)
+
+			record := RequestRecord{
+				Time:       start.UTC(),
+				Client:     request.Client,
+				Session:    request.Session,
+				Method:     request.Method,
+				Target:     request.Target,
+				Status:     StatusOK,
+				DurationMS: time.Since(start).Milliseconds(),
+			}
+			outcome(response, &record)
+			data, err := json.Marshal(record)
+			if err == nil {
+				mu.Lock()
+				_, _ = w.Write(append(data, '\n'))
+				mu.Unlock()
+			}
+			return response
+		}
+	}
+}
+
+// outcome sets the status of record from the response to the request.
+func outcome(response any, record *RequestRecord) {
+	if response == nil {
+		record.Status = StatusCancelled
+		return
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	var parsed struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+		Result struct {
+			IsError bool `json:"isError"`
+		} `json:"result"`
+	}
+	if json.Unmarshal(data, &parsed) != nil {
+		return
+	}
+	switch {
+	case parsed.Error != nil:
+		record.Status, record.Code, record.Error = StatusError, parsed.Error.Code, parsed.Error.Message
+	case parsed.Result.IsError:
+		record.Status = StatusError
+	}
+}
+
+// RateLimit limits each client to perSecond requests on average, in bursts of up to burst
+// requests. Requests over the limit are refused with CodeRateLimited.
+func RateLimit(perSecond float64, burst int) Middleware {
+	return newLimiter(perSecond, burst, time.Now).middleware
+}
+
+// maxBuckets is the number of clients a limiter tracks before forgetting those that have
+// their full burst back.
+const maxBuckets = 4096
+
+// limiter is a token bucket per client.
+type limiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(perSecond float64, burst int, now func() time.Time) *limiter {
+	return &limiter{rate: perSecond, burst: float64(max(burst, 1)), now: now, buckets: map[string]*bucket{}}
+}
+
+func (l *limiter) middleware(next RequestHandler) RequestHandler {
+	return func(ctx context.Context, request *Request) any {
+		if wait := l.take(request.Client); wait > 0 {
+			return ErrorResponse(request, CodeRateLimited,
+				fmt.Sprintf("rate limit exceeded, retry in %s", wait.Round(time.Millisecond)))
+		}
+		return next(ctx, request)
+	}
+}
+
+// take takes a token from the bucket of client, returning how long to wait for one if there
+// is none.
+func (l *limiter) take(client string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.buckets) >= maxBuckets {
+		for key, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, key)
+			}
+		}
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		if l.rate <= 0 {
+			return time.Hour
+		}
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
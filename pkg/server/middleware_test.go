@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// logRecords parses the records written by LogRequests.
+func logRecords(t *testing.T, log *bytes.Buffer) []RequestRecord {
+	t.Helper()
+	var records []RequestRecord
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		var record RequestRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next RequestHandler) RequestHandler {
+			return func(ctx context.Context, request *Request) any {
+				order = append(order, name)
+				return next(ctx, request)
+			}
+		}
+	}
+	var log bytes.Buffer
+	s := New("test", "1.0.0", WithMiddleware(trace("outer"), LogRequests(&log), trace("inner")))
+	AddTool(s, "greet", "Greets someone", func(ctx context.Context, in greetInput) (string, error) {
+		return "Hello, " + in.Name, nil
+	})
+
+	s.dispatch(context.Background(), "s1", []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"greet","arguments":{"name":"Ada"}}}`))
+	s.dispatch(context.Background(), "s1", []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"greet","arguments":{}}}`))
+	s.dispatch(context.Background(), "s1", []byte(`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"file:///missing"}}`))
+	s.dispatch(context.Background(), "s1", []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+
+	if strings.Join(order[:3], ",") != "outer,inner,outer" {
+		t.Errorf("middleware ran in the order %v", order)
+	}
+	records := logRecords(t, &log)
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, notifications left out, got %+v", records)
+	}
+	if r := records[0]; r.Client != "s1" || r.Method != "tools/call" || r.Target != "greet" || r.Status != StatusOK {
+		t.Errorf("unexpected record of a call %+v", r)
+	}
+	if r := records[1]; r.Status != StatusError || r.Code != 0 {
+		t.Errorf("unexpected record of a failed call %+v", r)
+	}
+	if r := records[2]; r.Status != StatusError || r.Code == 0 || r.Error == "" || r.Target != "file:///missing" {
+		t.Errorf("unexpected record of an error %+v", r)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	l := newLimiter(2, 3, func() time.Time { return now })
+	handler := l.middleware(func(ctx context.Context, request *Request) any { return "ok" })
+	call := func(client string) any {
+		return handler(context.Background(), &Request{Client: client, Method: "ping", ID: json.RawMessage(`7`)})
+	}
+
+	for i := range 3 {
+		if response := call("alice"); response != "ok" {
+			t.Fatalf("request %d within the burst was refused: %v", i, response)
+		}
+	}
+	response, ok := call("alice").(mcp.JSONRPCError)
+	if !ok || response.Error.Code != CodeRateLimited || response.Error.Message != "rate limit exceeded, retry in 500ms" || response.ID.Value() != 7.0 {
+		t.Errorf("expected the request over the limit to be refused, got %+v", response)
+	}
+	if call("bob") != "ok" {
+		t.Error("expected other clients to have their own limit")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if call("alice") != "ok" {
+		t.Error("expected a token back after 500ms")
+	}
+	if _, ok := call("alice").(mcp.JSONRPCError); !ok {
+		t.Error("expected a single token back after 500ms")
+	}
+}
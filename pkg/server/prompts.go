@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// AddPrompt registers the prompt name, calling handler with its arguments decoded into an In.
+// The arguments of the prompt are the fields of In, which are all strings as prompt arguments
+// are, named, described and required as in SchemaFor.
+//
+// The result of handler is sent as a user message if it is a string, as the messages if it is
+// a []mcp.PromptMessage, and as is if it is an *mcp.GetPromptResult. AddPrompt panics if In is
+// not a struct of strings, or Out none of these.
+func AddPrompt[In, Out any](s *Server, name, description string, handler func(context.Context, In) (Out, error)) {
+	schema, err := Schema[In]()
+	if err != nil {
+		panic(fmt.Sprintf("server: prompt %s: %v", name, err))
+	}
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		panic(fmt.Sprintf("server: prompt %s: arguments are a struct, not %v", name, schema["type"]))
+	}
+	required, _ := schema["required"].([]string)
+	switch any(*new(Out)).(type) {
+	case *mcp.GetPromptResult, []mcp.PromptMessage, string:
+	default:
+		panic(fmt.Sprintf("server: prompt %s: results are a string, []mcp.PromptMessage or *mcp.GetPromptResult, not %T", name, *new(Out)))
+	}
+
+	promptOptions := []mcp.PromptOption{mcp.WithPromptDescription(description)}
+	names := make([]string, 0, len(properties))
+	for argument := range properties {
+		names = append(names, argument)
+	}
+	slices.Sort(names)
+	for _, argument := range names {
+		property := properties[argument].(map[string]any)
+		if property["type"] != "string" {
+			panic(fmt.Sprintf("server: prompt %s: argument %s is a %v, not a string", name, argument, property["type"]))
+		}
+		var argumentOptions []mcp.ArgumentOption
+		if text, ok := property["description"].(string); ok {
+			argumentOptions = append(argumentOptions, mcp.ArgumentDescription(text))
+		}
+		if slices.Contains(required, argument) {
+			argumentOptions = append(argumentOptions, mcp.RequiredArgument())
+		}
+		promptOptions = append(promptOptions, mcp.WithArgument(argument, argumentOptions...))
+	}
+
+	s.mcp.AddPrompt(mcp.NewPrompt(name, promptOptions...), func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		for _, argument := range required {
+			if _, ok := request.Params.Arguments[argument]; !ok {
+				return nil, fmt.Errorf("missing required argument %s", argument)
+			}
+		}
+
+		var in In
+		data, err := json.Marshal(request.Params.Arguments)
+		if err == nil {
+			err = json.Unmarshal(data, &in)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+
+		out, err := handler(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		switch out := any(out).(type) {
+		case *mcp.GetPromptResult:
+			return out, nil
+		case []mcp.PromptMessage:
+			return mcp.NewGetPromptResult(description, out), nil
+		default:
+			return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(out.(string))),
+			}), nil
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// AddResource registers the resource at uri, whose contents read returns. Contents are sent as
+// text if mimeType is textual, or if it is empty and they are valid UTF-8, and as a base64
+// blob otherwise.
+//
+// Clients can subscribe to the resource; call NotifyResourceUpdated when it changes.
+func (s *Server) AddResource(uri, name, description, mimeType string, read func(ctx context.Context) ([]byte, error)) {
+	resource := mcp.NewResource(uri, name, mcp.WithResourceDescription(description), mcp.WithMIMEType(mimeType))
+	s.mcp.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		data, err := read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{resourceContents(request.Params.URI, mimeType, data)}, nil
+	})
+}
+
+// AddResourceTemplate registers the resources matching uriTemplate, an RFC 6570 template such
+// as file:///notes/{name}. read is given the URI read and the values of the variables of the
+// template in it.
+func (s *Server) AddResourceTemplate(uriTemplate, name, description, mimeType string, read func(ctx context.Context, uri string, vars map[string]string) ([]byte, error)) {
+	template := mcp.NewResourceTemplate(uriTemplate, name, mcp.WithTemplateDescription(description), mcp.WithTemplateMIMEType(mimeType))
+	s.mcp.AddResourceTemplate(template, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		vars := make(map[string]string, len(request.Params.Arguments))
+		for key, value := range request.Params.Arguments {
+			switch value := value.(type) {
+			case string:
+				vars[key] = value
+			case []string:
+				vars[key] = strings.Join(value, ",")
+			default:
+				vars[key] = fmt.Sprint(value)
+			}
+		}
+		data, err := read(ctx, request.Params.URI, vars)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{resourceContents(request.Params.URI, mimeType, data)}, nil
+	})
+}
+
+// resourceContents wraps data read from the resource at uri as text or a blob.
+func resourceContents(uri, mimeType string, data []byte) mcp.ResourceContents {
+	if isText(mimeType, data) {
+		return mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: string(data)}
+	}
+	return mcp.BlobResourceContents{URI: uri, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)}
+}
+
+// isText reports whether contents of mimeType are text.
+func isText(mimeType string, data []byte) bool {
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	switch {
+	case mediaType == "":
+		return utf8.Valid(data)
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "json"), strings.HasSuffix(mediaType, "xml"), strings.HasSuffix(mediaType, "yaml"),
+		mediaType == "application/javascript", mediaType == "application/toml":
+		return true
+	}
+	return false
+}
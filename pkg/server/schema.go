@@ -0,0 +1,322 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaFor derives the JSON Schema of the values of t, as encoding/json encodes them.
+//
+// Struct fields are named after their json tag, and are required unless the tag has omitempty
+// or the field is a pointer. The jsonschema tag describes a field further, with a comma
+// separated list of options:
+//
+//   - required or optional, to override whether the field is required
+//   - enum=a|b|c, the values the field takes
+//   - default=value
+//   - minimum=n and maximum=n, for numbers
+//   - minLength=n, maxLength=n, pattern=regexp and format=name, for strings
+//   - minItems=n and maxItems=n, for arrays
+//   - title=text
+//   - description=text, which comes last and takes the rest of the tag, commas included
+//
+// Values are read as the type of the field, or of its elements for enum and default on
+// arrays. Types implementing Enumer list their values themselves.
+func SchemaFor(t reflect.Type) (map[string]any, error) {
+	return schemaFor(t, map[reflect.Type]bool{})
+}
+
+// Schema derives the JSON Schema of the values of T.
+func Schema[T any]() (map[string]any, error) {
+	return SchemaFor(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// Enumer is implemented by types whose values are one of a fixed set, such as string
+// constants, which their schema lists as an enum.
+type Enumer interface {
+	Enum() []any
+}
+
+var (
+	enumerType    = reflect.TypeOf((*Enumer)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage(nil))
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaFor derives the schema of t, with seen holding the structs being derived, which cannot
+// contain themselves.
+func schemaFor(t reflect.Type, seen map[reflect.Type]bool) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	schema, err := typeSchema(t, seen)
+	if err != nil {
+		return nil, err
+	}
+	if values := enumValues(t); values != nil {
+		schema["enum"] = values
+	}
+	return schema, nil
+}
+
+// enumValues returns the values of t if it implements Enumer, with either receiver.
+func enumValues(t reflect.Type) []any {
+	switch {
+	case t.Implements(enumerType):
+		return reflect.Zero(t).Interface().(Enumer).Enum()
+	case reflect.PointerTo(t).Implements(enumerType):
+		return reflect.New(t).Interface().(Enumer).Enum()
+	}
+	return nil
+}
+
+// typeSchema derives the schema of t, which is not a pointer.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) (map[string]any, error) {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case t == rawJSONType:
+		return map[string]any{}, nil
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		// Types that encode themselves can be anything
+		return map[string]any{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := schemaFor(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s: JSON objects have string keys", t.Key())
+		}
+		values, err := schemaFor(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		return structSchema(t, seen)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) (map[string]any, error) {
+	if seen[t] {
+		return nil, fmt.Errorf("recursive type %s", t)
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	properties := map[string]any{}
+	var required []string
+	if err := addFields(t, seen, properties, &required); err != nil {
+		return nil, err
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// addFields adds the fields of struct t to properties, flattening embedded structs as
+// encoding/json does.
+func addFields(t reflect.Type, seen map[reflect.Type]bool, properties map[string]any, required *[]string) error {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, omitEmpty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addFields(embedded, seen, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		schema, err := schemaFor(field.Type, seen)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		isRequired := !omitEmpty && field.Type.Kind() != reflect.Pointer
+		if err := applyTag(schema, field.Type, field.Tag.Get("jsonschema"), &isRequired); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+
+		properties[name] = schema
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// applyTag adds the options of a jsonschema tag to the schema of a field of type t.
+func applyTag(schema map[string]any, t reflect.Type, tag string, isRequired *bool) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Enums and defaults of arrays are of their elements
+	values := t
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && schema["type"] == "array" {
+		values = t.Elem()
+		for values.Kind() == reflect.Pointer {
+			values = values.Elem()
+		}
+	}
+
+	for _, option := range splitTag(tag) {
+		key, value, hasValue := strings.Cut(option, "=")
+		switch key {
+		case "required", "optional":
+			if hasValue {
+				return fmt.Errorf("jsonschema option %s takes no value", key)
+			}
+			*isRequired = key == "required"
+			continue
+		}
+		if !hasValue {
+			return fmt.Errorf("jsonschema option %s needs a value", key)
+		}
+
+		switch key {
+		case "description", "title", "pattern", "format":
+			schema[key] = value
+		case "enum":
+			var enum []any
+			for _, text := range strings.Split(value, "|") {
+				v, err := parseValue(values, text)
+				if err != nil {
+					return fmt.Errorf("enum: %w", err)
+				}
+				enum = append(enum, v)
+			}
+			if values == t {
+				schema["enum"] = enum
+			} else {
+				schema["items"].(map[string]any)["enum"] = enum
+			}
+		case "default":
+			v, err := parseValue(values, value)
+			if err != nil {
+				return fmt.Errorf("default: %w", err)
+			}
+			if values != t {
+				v = []any{v}
+			}
+			schema["default"] = v
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a number", key, value)
+			}
+			schema[key] = n
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%s: %q is not a length", key, value)
+			}
+			schema[key] = n
+		default:
+			return fmt.Errorf("unknown jsonschema option %q", key)
+		}
+	}
+	return nil
+}
+
+// parseValue reads a value written in a jsonschema tag as a value of type t.
+func parseValue(t reflect.Type, text string) (any, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", text)
+		}
+		return v, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", text)
+		}
+		return v, nil
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", text)
+		}
+		return v, nil
+	case reflect.String:
+		return text, nil
+	}
+	return nil, fmt.Errorf("values of %s cannot be written in a tag", t)
+}
+
+// jsonName returns the name encoding/json gives a field, whether it has omitempty, and whether
+// it is left out.
+func jsonName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	omitEmpty := false
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" || option == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// splitTag splits a jsonschema tag on commas, up to the description.
+func splitTag(tag string) []string {
+	var options []string
+	for tag != "" {
+		if strings.HasPrefix(tag, "description=") {
+			return append(options, tag)
+		}
+		option, rest, _ := strings.Cut(tag, ",")
+		options = append(options, option)
+		tag = rest
+	}
+	return options
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// color is a string enum listing its values with a method.
+type color string
+
+func (color) Enum() []any { return []any{"red", "green", "blue"} }
+
+// priority is an integer enum listing its values with a pointer method.
+type priority int
+
+func (*priority) Enum() []any { return []any{1, 2, 3} }
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type searchInput struct {
+	base
+	Query   string            `json:"query" jsonschema:"description=What to look for, in words"`
+	Limit   *int              `json:"limit" jsonschema:"minimum=1,maximum=100,default=10"`
+	Since   time.Time         `json:"since,omitempty"`
+	Tags    []string          `json:"tags" jsonschema:"optional,maxItems=5,enum=bug|feature"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Data    []byte            `json:"data,omitempty"`
+	Ignored string            `json:"-"`
+	hidden  string
+}
+
+type paintInput struct {
+	Color    color     `json:"color"`
+	Accents  []color   `json:"accents,omitempty"`
+	Priority *priority `json:"priority"`
+	Mode     string    `json:"mode" jsonschema:"title=Mode,enum=fill|stroke,default=fill"`
+	Name     string    `json:"name" jsonschema:"minLength=1,maxLength=40,pattern=^[a-z]+$"`
+	Email    string    `json:"email,omitempty" jsonschema:"format=email,required"`
+	Opacity  float64   `json:"opacity,omitempty" jsonschema:"enum=0.5|1"`
+	Dry      bool      `json:"dry,omitempty" jsonschema:"default=true"`
+}
+
+type nestedInput struct {
+	Steps []struct {
+		Action string         `json:"action" jsonschema:"enum=open|close"`
+		Extra  map[string]any `json:"extra,omitempty"`
+	} `json:"steps"`
+	Raw  json.RawMessage `json:"raw,omitempty"`
+	When *time.Time      `json:"when"`
+}
+
+// TestSchemaGolden compares the schemas derived from input structs to the files in
+// testdata/schema. Run the tests with -update to rewrite them.
+func TestSchemaGolden(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		schema func() (map[string]any, error)
+	}{
+		{"search", Schema[searchInput]},
+		{"paint", Schema[paintInput]},
+		{"nested", Schema[nestedInput]},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			schema, err := test.schema()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", "schema", test.name+".json")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("schema of %s does not match %s:\n%s", test.name, golden, got)
+			}
+		})
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	type node struct {
+		Next *node `json:"next"`
+	}
+	type badTag struct {
+		Name string `json:"name" jsonschema:"nullable"`
+	}
+	if _, err := Schema[node](); err == nil {
+		t.Error("expected an error on a recursive type")
+	}
+	if _, err := Schema[map[int]string](); err == nil {
+		t.Error("expected an error on a map without string keys")
+	}
+	if _, err := Schema[badTag](); err == nil {
+		t.Error("expected an error on an unknown jsonschema option")
+	}
+	if _, err := Schema[chan int](); err == nil {
+		t.Error("expected an error on a channel")
+	}
+
+	for _, tag := range []string{"minimum=low", "enum=a|b", "default", "required=yes", "maxLength=-1"} {
+		required := true
+		if err := applyTag(map[string]any{"type": "integer"}, reflect.TypeOf(0), tag, &required); err == nil {
+			t.Errorf("expected an error on the tag %q", tag)
+		}
+	}
+}
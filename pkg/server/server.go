@@ -0,0 +1,356 @@
+/*
+Package server is a framework for building MCP servers in Go, on the plumbing the built-in
+serve-* servers of mcptools run on.
+
+Tools are registered with typed handlers, their input schema derived from the struct they take;
+resources with subscription support, which clients use to be told when a resource changes;
+prompts with typed arguments; and handlers can send log messages to the client. Servers are
+served over stdio or streamable HTTP:
+
+	type GreetInput struct {
+		Name string `json:"name" jsonschema:"description=Who to greet"`
+	}
+
+	s := server.New("greeter", "1.0.0")
+	server.AddTool(s, "greet", "Greets someone", func(ctx context.Context, in GreetInput) (string, error) {
+		return "Hello, " + in.Name, nil
+	})
+	log.Fatal(s.Run(""))
+*/
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// Methods the framework handles itself, which the underlying server does not route.
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+	methodCancelled   = "notifications/cancelled"
+)
+
+// DefaultMaxConcurrency is the number of requests a server handles at once unless configured
+// otherwise with WithMaxConcurrency.
+const DefaultMaxConcurrency = 32
+
+// Server is an MCP server under construction or running.
+type Server struct {
+	mcp *mcpserver.MCPServer
+	// slots holds a value for each request being handled, and is nil if their number is not
+	// limited.
+	slots chan struct{}
+
+	mu sync.Mutex
+	// subscriptions holds the sessions subscribed to each resource URI.
+	subscriptions map[string]map[string]bool
+	// inFlight holds the cancellation of the requests being handled, by session and ID.
+	inFlight map[requestKey]*request
+
+	middleware     []Middleware
+	httpMiddleware []func(http.Handler) http.Handler
+}
+
+// Option configures a Server.
+type Option func(*options)
+
+type options struct {
+	instructions   string
+	maxConcurrency int
+	middleware     []Middleware
+	httpMiddleware []func(http.Handler) http.Handler
+}
+
+// WithInstructions sets the instructions the server gives clients when they connect, on how to
+// use its tools, resources and prompts.
+func WithInstructions(instructions string) Option {
+	return func(o *options) {
+		o.instructions = instructions
+	}
+}
+
+// WithMaxConcurrency sets the number of requests the server handles at once, across sessions.
+// Further requests wait for one to finish. Zero or less removes the limit.
+func WithMaxConcurrency(n int) Option {
+	return func(o *options) {
+		o.maxConcurrency = n
+	}
+}
+
+// WithMiddleware wraps the handling of requests, on stdio and HTTP, in middleware, the first
+// outermost.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// WithHTTPMiddleware wraps the HTTP handler of the server in middleware, the first outermost,
+// such as to authenticate clients with APIKeys or BearerAuth.
+func WithHTTPMiddleware(middleware ...func(http.Handler) http.Handler) Option {
+	return func(o *options) {
+		o.httpMiddleware = append(o.httpMiddleware, middleware...)
+	}
+}
+
+// New creates a server named name at version, with the tools, resources, prompts and logging
+// capabilities, and subscriptions to resources.
+func New(name, version string, opts ...Option) *Server {
+	o := buildOptions(opts)
+
+	serverOptions := []mcpserver.ServerOption{
+		mcpserver.WithToolCapabilities(true),
+		mcpserver.WithResourceCapabilities(true, true),
+		mcpserver.WithPromptCapabilities(true),
+		mcpserver.WithLogging(),
+		mcpserver.WithRecovery(),
+	}
+	if o.instructions != "" {
+		serverOptions = append(serverOptions, mcpserver.WithInstructions(o.instructions))
+	}
+	return Wrap(mcpserver.NewMCPServer(name, version, serverOptions...), opts...)
+}
+
+// Wrap serves an mcp-go server built elsewhere, such as the built-in servers of mcptools, with
+// the listeners, request handling and subscriptions of the framework. Options configuring the
+// underlying server, such as WithInstructions, have no effect.
+func Wrap(s *mcpserver.MCPServer, opts ...Option) *Server {
+	o := buildOptions(opts)
+	server := &Server{
+		mcp:            s,
+		subscriptions:  map[string]map[string]bool{},
+		inFlight:       map[requestKey]*request{},
+		middleware:     o.middleware,
+		httpMiddleware: o.httpMiddleware,
+	}
+	if o.maxConcurrency > 0 {
+		server.slots = make(chan struct{}, o.maxConcurrency)
+	}
+	return server
+}
+
+func buildOptions(opts []Option) options {
+	o := options{maxConcurrency: DefaultMaxConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// MCPServer returns the underlying mcp-go server, to use features the framework does not
+// cover.
+func (s *Server) MCPServer() *mcpserver.MCPServer {
+	return s.mcp
+}
+
+// Run serves s over stdio, or over streamable HTTP at httpAddr (e.g. ":8080") if it is set,
+// until the input ends or the process is interrupted.
+func (s *Server) Run(httpAddr string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if httpAddr == "" {
+		return s.ServeStdio(ctx, os.Stdin, os.Stdout)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", s.Handler())
+	httpServer := &http.Server{Addr: httpAddr, Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
+	go func() {
+		<-ctx.Done()
+		_ = httpServer.Close()
+	}()
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler returns an http.Handler serving s over streamable HTTP, to mount at a path such as
+// /mcp. It is wrapped in the HTTP middleware given with WithHTTPMiddleware.
+func (s *Server) Handler() http.Handler {
+	streamable := mcpserver.NewStreamableHTTPServer(s.mcp)
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			streamable.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sessionID := r.Header.Get("Mcp-Session-Id")
+
+		envelope := parseEnvelope(body)
+		switch {
+		case envelope.Method == methodCancelled:
+			s.cancel(sessionID, envelope.Params.RequestID)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		case !envelope.isRequest():
+			streamable.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, done, err := s.begin(r.Context(), sessionID, envelope.ID)
+		if err != nil {
+			return
+		}
+		defer done()
+
+		// The underlying server writes the response itself, which middleware sees if it is JSON
+		tee := &teeWriter{ResponseWriter: w}
+		request := newRequest(ctx, sessionID, remoteHost(r), envelope, body)
+		response := s.serve(ctx, request, func(ctx context.Context, request *Request) any {
+			if request.Method == methodSubscribe || request.Method == methodUnsubscribe {
+				response, _ := s.handleSubscription(request.Session, request.Message)
+				return json.RawMessage(response)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(request.Message))
+			streamable.ServeHTTP(tee, r.WithContext(ctx))
+			return tee.response()
+		})
+		if !tee.wroteHeader && response != nil {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(response)
+		}
+	})
+	for i := len(s.httpMiddleware) - 1; i >= 0; i-- {
+		handler = s.httpMiddleware[i](handler)
+	}
+	return handler
+}
+
+// handleSubscription answers a subscribe or unsubscribe request of the session sessionID, and
+// reports whether message was one.
+func (s *Server) handleSubscription(sessionID string, message []byte) ([]byte, bool) {
+	request := parseEnvelope(message)
+	if request.Method != methodSubscribe && request.Method != methodUnsubscribe {
+		return nil, false
+	}
+
+	response := map[string]any{"jsonrpc": mcp.JSONRPC_VERSION, "id": request.ID}
+	switch {
+	case request.Params.URI == "":
+		response["error"] = map[string]any{"code": mcp.INVALID_PARAMS, "message": "uri is required"}
+	case sessionID == "":
+		response["error"] = map[string]any{"code": mcp.INVALID_REQUEST, "message": "subscriptions need a session"}
+	default:
+		s.mu.Lock()
+		sessions := s.subscriptions[request.Params.URI]
+		if request.Method == methodSubscribe {
+			if sessions == nil {
+				sessions = map[string]bool{}
+				s.subscriptions[request.Params.URI] = sessions
+			}
+			sessions[sessionID] = true
+		} else {
+			delete(sessions, sessionID)
+		}
+		s.mu.Unlock()
+		response["result"] = map[string]any{}
+	}
+	data, _ := json.Marshal(response)
+	return data, true
+}
+
+// NotifyResourceUpdated tells the clients subscribed to the resource at uri that it changed, so
+// they can read it again. Sessions that ended are unsubscribed.
+func (s *Server) NotifyResourceUpdated(uri string) {
+	s.mu.Lock()
+	var sessions []string
+	for sessionID := range s.subscriptions[uri] {
+		sessions = append(sessions, sessionID)
+	}
+	s.mu.Unlock()
+
+	for _, sessionID := range sessions {
+		err := s.mcp.SendNotificationToSpecificClient(sessionID, string(mcp.MethodNotificationResourceUpdated), map[string]any{"uri": uri})
+		if errors.Is(err, mcpserver.ErrSessionNotFound) {
+			s.mu.Lock()
+			delete(s.subscriptions[uri], sessionID)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Subscribed reports whether a client is subscribed to the resource at uri, to skip watching
+// resources nobody follows.
+func (s *Server) Subscribed(uri string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscriptions[uri]) > 0
+}
+
+// Log sends a log message to the client of the request ctx belongs to, if the client asked for
+// messages of level. It does nothing outside of a request.
+func Log(ctx context.Context, level mcp.LoggingLevel, logger string, data any) {
+	s := mcpserver.ServerFromContext(ctx)
+	if s == nil {
+		return
+	}
+	_ = s.SendLogMessageToClient(ctx, mcp.NewLoggingMessageNotification(level, logger, data))
+}
+
+// Limits of the HTTP listener.
+const (
+	readHeaderTimeout = 10 * time.Second
+	maxRequestBody    = 16 << 20
+)
+
+// teeWriter keeps a copy of the JSON response written through it.
+type teeWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+	json        bool
+	body        bytes.Buffer
+}
+
+func (t *teeWriter) WriteHeader(status int) {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		t.json = strings.HasPrefix(t.Header().Get("Content-Type"), "application/json")
+	}
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+	if t.json && t.body.Len()+len(p) <= maxRequestBody {
+		t.body.Write(p)
+	}
+	return t.ResponseWriter.Write(p)
+}
+
+func (t *teeWriter) Flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// response returns the JSON response written, or nil if there is none.
+func (t *teeWriter) response() any {
+	if t.body.Len() == 0 {
+		return nil
+	}
+	return json.RawMessage(bytes.TrimSpace(t.body.Bytes()))
+}
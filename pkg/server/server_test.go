@@ -0,0 +1,342 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handle sends a request to s and returns the result of the response.
+func handle(t *testing.T, s *Server, method string, params any) any {
+	t.Helper()
+	message, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch response := s.MCPServer().HandleMessage(context.Background(), message).(type) {
+	case mcp.JSONRPCResponse:
+		return response.Result
+	case mcp.JSONRPCError:
+		return errors.New(response.Error.Message)
+	default:
+		t.Fatalf("unexpected response %T", response)
+		return nil
+	}
+}
+
+// callText calls a tool of s and returns the text of the result and whether it failed.
+func callText(t *testing.T, s *Server, name string, arguments map[string]any) (string, bool) {
+	t.Helper()
+	result, ok := handle(t, s, "tools/call", map[string]any{"name": name, "arguments": arguments}).(mcp.CallToolResult)
+	if !ok {
+		t.Fatalf("tools/call %s failed", name)
+	}
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n"), result.IsError
+}
+
+type greetInput struct {
+	Name  string `json:"name" jsonschema:"description=Who to greet"`
+	Times int    `json:"times,omitempty"`
+}
+
+type greeting struct {
+	Text string `json:"text"`
+}
+
+func TestAddTool(t *testing.T) {
+	s := New("test", "1.0.0")
+	AddTool(s, "greet", "Greets someone", func(ctx context.Context, in greetInput) (string, error) {
+		if in.Name == "nobody" {
+			return "", errors.New("nobody to greet")
+		}
+		return strings.Repeat("Hello, "+in.Name+"! ", max(in.Times, 1)), nil
+	}, ReadOnly())
+	AddTool(s, "greeting", "Builds a greeting", func(ctx context.Context, in greetInput) (greeting, error) {
+		return greeting{Text: "Hi " + in.Name}, nil
+	})
+
+	tools := handle(t, s, "tools/list", nil).(mcp.ListToolsResult).Tools
+	if len(tools) != 2 {
+		t.Fatalf("expected 2 tools, got %d", len(tools))
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(tools[0].RawInputSchema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["required"].([]any)[0] != "name" || tools[0].Annotations.ReadOnlyHint == nil || !*tools[0].Annotations.ReadOnlyHint {
+		t.Errorf("unexpected tool %s: %v %+v", tools[0].Name, schema, tools[0].Annotations)
+	}
+
+	for _, test := range []struct {
+		tool      string
+		arguments map[string]any
+		want      string
+		failed    bool
+	}{
+		{"greet", map[string]any{"name": "Ada", "times": 2}, "Hello, Ada! Hello, Ada! ", false},
+		{"greet", map[string]any{}, "missing required argument name", true},
+		{"greet", map[string]any{"name": 3}, "invalid arguments", true},
+		{"greet", map[string]any{"name": "nobody"}, "nobody to greet", true},
+		{"greeting", map[string]any{"name": "Ada"}, "{\n  \"text\": \"Hi Ada\"\n}", false},
+	} {
+		text, failed := callText(t, s, test.tool, test.arguments)
+		if !strings.HasPrefix(text, test.want) || failed != test.failed {
+			t.Errorf("%s %v = %q, %v, want %q, %v", test.tool, test.arguments, text, failed, test.want, test.failed)
+		}
+	}
+}
+
+func TestAddToolPanicsOnUnsupportedInput(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected AddTool to panic on an input that is not an object")
+		}
+	}()
+	AddTool(New("test", "1.0.0"), "bad", "", func(ctx context.Context, in []string) (string, error) {
+		return "", nil
+	})
+}
+
+func TestAddPrompt(t *testing.T) {
+	type reviewInput struct {
+		Language string `json:"language" jsonschema:"description=Language of the code"`
+		Focus    string `json:"focus,omitempty"`
+	}
+	s := New("test", "1.0.0")
+	AddPrompt(s, "review", "Reviews code", func(ctx context.Context, in reviewInput) (string, error) {
+		return "Review this " + in.Language + " code", nil
+	})
+
+	prompt := handle(t, s, "prompts/list", nil).(mcp.ListPromptsResult).Prompts[0]
+	if len(prompt.Arguments) != 2 || prompt.Arguments[0].Name != "focus" || prompt.Arguments[0].Required ||
+		prompt.Arguments[1].Name != "language" || !prompt.Arguments[1].Required || prompt.Arguments[1].Description != "Language of the code" {
+		t.Errorf("unexpected arguments %+v", prompt.Arguments)
+	}
+
+	result := handle(t, s, "prompts/get", map[string]any{"name": "review", "arguments": map[string]string{"language": "Go"}}).(mcp.GetPromptResult)
+	if text := result.Messages[0].Content.(mcp.TextContent).Text; text != "Review this Go code" || result.Messages[0].Role != mcp.RoleUser {
+		t.Errorf("unexpected messages %+v", result.Messages)
+	}
+	if err, ok := handle(t, s, "prompts/get", map[string]any{"name": "review"}).(error); !ok || !strings.Contains(err.Error(), "missing required argument language") {
+		t.Errorf("expected a missing argument error, got %v", err)
+	}
+}
+
+func TestAddResource(t *testing.T) {
+	s := New("test", "1.0.0")
+	s.AddResource("file:///notes", "notes", "My notes", "", func(ctx context.Context) ([]byte, error) {
+		return []byte("remember"), nil
+	})
+	s.AddResourceTemplate("file:///images/{name}", "image", "An image", "image/png", func(ctx context.Context, uri string, vars map[string]string) ([]byte, error) {
+		return []byte(vars["name"]), nil
+	})
+
+	contents := handle(t, s, "resources/read", map[string]any{"uri": "file:///notes"}).(mcp.ReadResourceResult).Contents
+	if text, ok := contents[0].(mcp.TextResourceContents); !ok || text.Text != "remember" {
+		t.Errorf("unexpected contents %+v", contents)
+	}
+	contents = handle(t, s, "resources/read", map[string]any{"uri": "file:///images/cat"}).(mcp.ReadResourceResult).Contents
+	if blob, ok := contents[0].(mcp.BlobResourceContents); !ok || blob.Blob != "Y2F0" || blob.URI != "file:///images/cat" {
+		t.Errorf("unexpected contents %+v", contents)
+	}
+}
+
+// stdioClient talks to a server served on stdio.
+type stdioClient struct {
+	t     *testing.T
+	in    io.Writer
+	lines *bufio.Scanner
+}
+
+// serveStdio serves s on stdio until the end of the test, and initializes a session.
+func serveStdio(t *testing.T, s *Server) *stdioClient {
+	t.Helper()
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.ServeStdio(ctx, inReader, outWriter) }()
+	t.Cleanup(func() {
+		cancel()
+		_ = inWriter.Close()
+		go func() { _, _ = io.Copy(io.Discard, outReader) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("ServeStdio() = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("ServeStdio did not return")
+		}
+	})
+
+	c := &stdioClient{t: t, in: inWriter, lines: bufio.NewScanner(outReader)}
+	c.send(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	if capabilities := c.receive()["result"].(map[string]any)["capabilities"].(map[string]any); capabilities["resources"].(map[string]any)["subscribe"] != true {
+		t.Errorf("expected subscriptions to be offered, got %v", capabilities)
+	}
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return c
+}
+
+func (c *stdioClient) send(message string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, message+"\n"); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *stdioClient) receive() map[string]any {
+	c.t.Helper()
+	if !c.lines.Scan() {
+		c.t.Fatalf("no message received: %v", c.lines.Err())
+	}
+	var message map[string]any
+	if err := json.Unmarshal(c.lines.Bytes(), &message); err != nil {
+		c.t.Fatal(err)
+	}
+	return message
+}
+
+func TestServeStdioSubscriptions(t *testing.T) {
+	s := New("test", "1.0.0")
+	s.AddResource("file:///notes", "notes", "", "text/plain", func(ctx context.Context) ([]byte, error) {
+		return []byte("remember"), nil
+	})
+	c := serveStdio(t, s)
+
+	c.send(`{"jsonrpc":"2.0","id":3,"method":"resources/subscribe","params":{"uri":"file:///notes"}}`)
+	if response := c.receive(); response["id"] != 3.0 || response["result"] == nil {
+		t.Fatalf("unexpected subscribe response %v", response)
+	}
+	if !s.Subscribed("file:///notes") || s.Subscribed("file:///other") {
+		t.Error("expected to be subscribed to file:///notes only")
+	}
+
+	s.NotifyResourceUpdated("file:///notes")
+	if notification := c.receive(); notification["method"] != "notifications/resources/updated" ||
+		notification["params"].(map[string]any)["uri"] != "file:///notes" {
+		t.Errorf("unexpected notification %v", notification)
+	}
+
+	c.send(`{"jsonrpc":"2.0","id":4,"method":"resources/unsubscribe","params":{"uri":"file:///notes"}}`)
+	c.receive()
+	if s.Subscribed("file:///notes") {
+		t.Error("expected the subscription to be removed")
+	}
+}
+
+type waitInput struct {
+	Name string `json:"name"`
+}
+
+func TestServeStdioConcurrency(t *testing.T) {
+	s := New("test", "1.0.0", WithMaxConcurrency(2))
+	release := make(chan struct{})
+	started := make(chan string, 3)
+	cancelled := make(chan string, 3)
+	AddTool(s, "wait", "Waits to be released or cancelled", func(ctx context.Context, in waitInput) (string, error) {
+		started <- in.Name
+		select {
+		case <-release:
+			return "released " + in.Name, nil
+		case <-ctx.Done():
+			cancelled <- in.Name
+			return "", ctx.Err()
+		}
+	})
+	AddTool(s, "panic", "Panics", func(ctx context.Context, in waitInput) (string, error) {
+		panic("out of " + in.Name)
+	})
+	c := serveStdio(t, s)
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wait","arguments":{"name":"a"}}}`)
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"wait","arguments":{"name":"b"}}}`)
+	c.send(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"wait","arguments":{"name":"c"}}}`)
+	for range 2 {
+		<-started
+	}
+	select {
+	case name := <-started:
+		t.Fatalf("%s started beyond the maximum concurrency", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Cancelling a request frees its slot for the waiting one, and leaves it unanswered
+	c.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1,"reason":"changed my mind"}}`)
+	if name := <-cancelled; name != "a" {
+		t.Errorf("cancelled %s, want a", name)
+	}
+	if name := <-started; name != "c" {
+		t.Errorf("started %s, want c", name)
+	}
+
+	close(release)
+	ids := map[float64]bool{}
+	for range 2 {
+		response := c.receive()
+		ids[response["id"].(float64)] = true
+	}
+	if !ids[2] || !ids[3] {
+		t.Errorf("expected responses to requests 2 and 3, got %v", ids)
+	}
+
+	c.send(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"panic","arguments":{"name":"luck"}}}`)
+	response := c.receive()
+	if response["id"] != 4.0 || response["error"] == nil && response["result"] == nil {
+		t.Fatalf("unexpected response to a panicking tool %v", response)
+	}
+	c.send(`{"jsonrpc":"2.0","id":5,"method":"ping"}`)
+	if response := c.receive(); response["id"] != 5.0 {
+		t.Errorf("expected the server to keep serving after a panic, got %v", response)
+	}
+}
+
+func TestDispatchRecoversFromPanics(t *testing.T) {
+	s := New("test", "1.0.0")
+	s.MCPServer().AddPrompt(mcp.NewPrompt("boom"), func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		panic("boom")
+	})
+
+	response, ok := s.dispatch(context.Background(), "test", []byte(`{"jsonrpc":"2.0","id":"x","method":"prompts/get","params":{"name":"boom"}}`)).(mcp.JSONRPCError)
+	if !ok || response.Error.Code != mcp.INTERNAL_ERROR || !strings.Contains(response.Error.Message, "boom") || response.ID.Value() != "x" {
+		t.Errorf("dispatch() = %+v, want an internal error", response)
+	}
+}
+
+func TestServeStdioToolChanges(t *testing.T) {
+	s := New("test", "1.0.0")
+	c := serveStdio(t, s)
+
+	greet := func(ctx context.Context, in greetInput) (string, error) { return "Hello, " + in.Name, nil }
+	s.AddTools(NewTool("lamp_on", "Turns the lamp on", greet), NewTool("lamp_off", "Turns the lamp off", greet))
+	if notification := c.receive(); notification["method"] != "notifications/tools/list_changed" {
+		t.Fatalf("expected the tools to change, got %v", notification)
+	}
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	if tools := c.receive()["result"].(map[string]any)["tools"].([]any); len(tools) != 2 {
+		t.Errorf("expected the 2 added tools, got %v", tools)
+	}
+
+	s.RemoveTools("lamp_on", "lamp_off")
+	if notification := c.receive(); notification["method"] != "notifications/tools/list_changed" {
+		t.Fatalf("expected the tools to change, got %v", notification)
+	}
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"lamp_on","arguments":{"name":"x"}}}`)
+	if response := c.receive(); response["error"] == nil {
+		t.Errorf("expected a removed tool to be unknown, got %v", response)
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// stdioSessionID is the ID of the only session of a server on stdio.
+const stdioSessionID = "stdio"
+
+// maxStdioMessage is the size of the largest message read on stdio.
+const maxStdioMessage = 64 << 20
+
+// ServeStdio serves s over newline-delimited JSON-RPC read from in and written to out, until in
+// ends or ctx is done. Requests are handled concurrently; when in ends, the requests being
+// handled are finished before ServeStdio returns, and when ctx is done, they are cancelled.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := &messageWriter{w: out}
+	session := &stdioSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+	if err := s.mcp.RegisterSession(ctx, session); err != nil {
+		return err
+	}
+	defer s.mcp.UnregisterSession(ctx, stdioSessionID)
+	ctx = s.mcp.WithContext(ctx, session)
+
+	go func() {
+		for {
+			select {
+			case notification := <-session.notifications:
+				_ = writer.write(notification)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), maxStdioMessage)
+		for scanner.Scan() {
+			select {
+			case lines <- append([]byte(nil), scanner.Bytes()...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	var requests sync.WaitGroup
+	defer requests.Wait()
+	for {
+		select {
+		case line := <-lines:
+			if len(line) == 0 {
+				continue
+			}
+			if !parseEnvelope(line).isRequest() {
+				// Notifications are handled in order, as the initialized one must come first
+				if err := writer.write(s.dispatch(ctx, stdioSessionID, line)); err != nil {
+					return err
+				}
+				continue
+			}
+			requests.Add(1)
+			go func() {
+				defer requests.Done()
+				_ = writer.write(s.dispatch(ctx, stdioSessionID, line))
+			}()
+		case err := <-readErr:
+			return err
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// messageWriter writes messages on their own line, one at a time.
+type messageWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// write writes message, if it is not nil.
+func (m *messageWriter) write(message any) error {
+	if message == nil {
+		return nil
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = m.w.Write(append(data, '\n'))
+	return err
+}
+
+// stdioSession is the session of the only client of a server on stdio.
+type stdioSession struct {
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+	logLevel      atomic.Value
+	clientInfo    atomic.Value
+}
+
+func (s *stdioSession) SessionID() string {
+	return stdioSessionID
+}
+
+func (s *stdioSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *stdioSession) Initialize() {
+	s.initialized.Store(true)
+}
+
+func (s *stdioSession) Initialized() bool {
+	return s.initialized.Load()
+}
+
+func (s *stdioSession) SetLogLevel(level mcp.LoggingLevel) {
+	s.logLevel.Store(level)
+}
+
+func (s *stdioSession) GetLogLevel() mcp.LoggingLevel {
+	if level, ok := s.logLevel.Load().(mcp.LoggingLevel); ok {
+		return level
+	}
+	return mcp.LoggingLevelError
+}
+
+func (s *stdioSession) SetClientInfo(info mcp.Implementation) {
+	s.clientInfo.Store(info)
+}
+
+func (s *stdioSession) GetClientInfo() mcp.Implementation {
+	info, _ := s.clientInfo.Load().(mcp.Implementation)
+	return info
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// ToolOption configures a tool.
+type ToolOption func(*mcp.Tool)
+
+// ReadOnly marks a tool as not changing its environment.
+func ReadOnly() ToolOption {
+	return func(t *mcp.Tool) {
+		t.Annotations.ReadOnlyHint = mcp.ToBoolPtr(true)
+		t.Annotations.DestructiveHint = mcp.ToBoolPtr(false)
+	}
+}
+
+// Destructive marks a tool as possibly deleting or overwriting data.
+func Destructive() ToolOption {
+	return func(t *mcp.Tool) {
+		t.Annotations.ReadOnlyHint = mcp.ToBoolPtr(false)
+		t.Annotations.DestructiveHint = mcp.ToBoolPtr(true)
+	}
+}
+
+// Idempotent marks a tool as having no further effect when called again with the same
+// arguments.
+func Idempotent() ToolOption {
+	return func(t *mcp.Tool) {
+		t.Annotations.IdempotentHint = mcp.ToBoolPtr(true)
+	}
+}
+
+// Title sets the name of a tool shown to people.
+func Title(title string) ToolOption {
+	return func(t *mcp.Tool) {
+		t.Annotations.Title = title
+	}
+}
+
+// AddTool registers the tool name, calling handler with its arguments decoded into an In, whose
+// type the input schema of the tool is derived from (see SchemaFor).
+//
+// The result of handler is sent as text if it is a string, as is if it is an
+// *mcp.CallToolResult, and as indented JSON otherwise. An error of handler is sent to the
+// client as a failed call. AddTool panics if no schema can be derived from In.
+//
+// Tools can be added while the server runs, and connected clients are told the list of tools
+// changed.
+func AddTool[In, Out any](s *Server, name, description string, handler func(context.Context, In) (Out, error), opts ...ToolOption) {
+	s.AddTools(NewTool(name, description, handler, opts...))
+}
+
+// NewTool builds a tool as AddTool does without registering it, to register several tools at
+// once with AddTools.
+func NewTool[In, Out any](name, description string, handler func(context.Context, In) (Out, error), opts ...ToolOption) mcpserver.ServerTool {
+	schema, err := Schema[In]()
+	if err != nil {
+		panic(fmt.Sprintf("server: tool %s: %v", name, err))
+	}
+	if schema["type"] != "object" {
+		panic(fmt.Sprintf("server: tool %s: arguments are a JSON object, not %v", name, schema["type"]))
+	}
+	rawSchema, err := json.Marshal(schema)
+	if err != nil {
+		panic(fmt.Sprintf("server: tool %s: %v", name, err))
+	}
+	required, _ := schema["required"].([]string)
+
+	tool := mcp.NewToolWithRawSchema(name, description, rawSchema)
+	for _, opt := range opts {
+		opt(&tool)
+	}
+
+	call := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		arguments := request.GetArguments()
+		for _, field := range required {
+			if _, ok := arguments[field]; !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("missing required argument %s", field)), nil
+			}
+		}
+
+		var in In
+		data, err := json.Marshal(arguments)
+		if err == nil {
+			err = json.Unmarshal(data, &in)
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid arguments: %v", err)), nil
+		}
+
+		out, err := handler(ctx, in)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return toolResult(out)
+	}
+	return mcpserver.ServerTool{Tool: tool, Handler: call}
+}
+
+// AddTools registers tools built with NewTool, or replaces those with the same names, and tells
+// connected clients the list of tools changed, once for all of them. Use it for tools that
+// depend on the state of the server, such as those of plugins or discovered devices.
+func (s *Server) AddTools(tools ...mcpserver.ServerTool) {
+	if len(tools) > 0 {
+		s.mcp.AddTools(tools...)
+	}
+}
+
+// RemoveTools unregisters the tools of the given names, and tells connected clients the list of
+// tools changed if any was registered. Calls of the tools already being handled complete.
+func (s *Server) RemoveTools(names ...string) {
+	s.mcp.DeleteTools(names...)
+}
+
+// toolResult converts the result of a tool handler to the result of the call.
+func toolResult(out any) (*mcp.CallToolResult, error) {
+	switch out := out.(type) {
+	case *mcp.CallToolResult:
+		return out, nil
+	case string:
+		return mcp.NewToolResultText(out), nil
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding the result: %w", err)
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
@@ -0,0 +1,137 @@
+package share
+
+// page shows the timeline of a share link, following it every two seconds while it is live.
+// Requests and their responses are matched by id so each response shows how long it took.
+const page = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <meta name="referrer" content="no-referrer">
+    <title>MCP Session</title>
+    <script src="https://cdn.tailwindcss.com"></script>
+</head>
+<body class="bg-gray-50 text-gray-900 antialiased">
+    <div class="max-w-6xl mx-auto p-6">
+        <div class="flex items-center justify-between mb-1">
+            <h1 id="title" class="text-2xl font-bold text-gray-800">MCP Session</h1>
+            <span id="status" class="text-sm text-gray-500"></span>
+        </div>
+        <p id="server" class="text-sm text-gray-500 font-mono mb-4"></p>
+        <p class="text-xs text-gray-400 mb-4">Read-only view. Values that looked like secrets were replaced by tokens when the session was recorded. Click a message to show it.</p>
+
+        <table class="w-full text-sm bg-white border border-gray-200 rounded-lg">
+            <thead class="bg-gray-100 text-left text-gray-600">
+                <tr>
+                    <th class="px-3 py-2 w-28">Time</th>
+                    <th class="px-3 py-2 w-8"></th>
+                    <th class="px-3 py-2">Message</th>
+                    <th class="px-3 py-2 w-24 text-right">Took</th>
+                </tr>
+            </thead>
+            <tbody id="timeline"></tbody>
+        </table>
+        <p id="empty" class="text-gray-500 italic mt-4">No messages yet.</p>
+    </div>
+
+    <script>
+        let next = 0;
+        let start = null;
+        const sentAt = {};
+
+        // Describe a JSON-RPC message in a line
+        function summarize(message) {
+            if (message.method) {
+                let summary = message.method;
+                if (message.params && message.params.name) summary += ' ' + message.params.name;
+                if (message.params && message.params.uri) summary += ' ' + message.params.uri;
+                if (message.id !== undefined) summary += '  #' + message.id;
+                return summary;
+            }
+            if (message.error) {
+                return 'error #' + message.id + ': ' + (message.error.message || JSON.stringify(message.error));
+            }
+            let summary = 'result #' + message.id;
+            if (message.result && message.result.isError) summary += ' (tool error)';
+            return summary;
+        }
+
+        function addEntry(entry) {
+            const time = new Date(entry.time);
+            if (start === null) start = time;
+
+            if (entry.direction === 'start') {
+                document.getElementById('server').textContent = (entry.server || []).join(' ');
+                return;
+            }
+            document.getElementById('empty').classList.add('hidden');
+
+            const message = entry.message || {};
+            const key = JSON.stringify(message.id);
+            let took = '';
+            if (entry.direction === 'sent' && message.id !== undefined) {
+                sentAt[key] = time;
+            } else if (entry.direction === 'received' && !message.method && sentAt[key]) {
+                took = (time - sentAt[key]) + ' ms';
+                delete sentAt[key];
+            }
+
+            const row = document.createElement('tr');
+            row.className = 'border-t border-gray-100 cursor-pointer hover:bg-gray-50' + (message.error || (message.result && message.result.isError) ? ' text-red-700' : '');
+            const cells = [
+                '+' + ((time - start) / 1000).toFixed(3) + ' s',
+                entry.direction === 'sent' ? '→' : '←',
+                summarize(message),
+                took
+            ];
+            cells.forEach((text, i) => {
+                const cell = document.createElement('td');
+                cell.className = 'px-3 py-2' + (i === 2 ? ' font-mono' : '') + (i === 3 ? ' text-right text-gray-500' : '');
+                cell.textContent = text;
+                row.appendChild(cell);
+            });
+
+            const detail = document.createElement('tr');
+            detail.className = 'hidden';
+            const detailCell = document.createElement('td');
+            detailCell.colSpan = 4;
+            const pre = document.createElement('pre');
+            pre.className = 'bg-gray-800 text-gray-100 p-3 text-xs overflow-x-auto';
+            pre.textContent = JSON.stringify(message, null, 2);
+            detailCell.appendChild(pre);
+            detail.appendChild(detailCell);
+            row.onclick = () => detail.classList.toggle('hidden');
+
+            document.getElementById('timeline').appendChild(row);
+            document.getElementById('timeline').appendChild(detail);
+        }
+
+        function load() {
+            fetch(window.location.pathname + '/timeline?after=' + next)
+                .then(response => response.json().then(data => ({ ok: response.ok, data: data })))
+                .then(({ ok, data }) => {
+                    if (!ok) {
+                        document.getElementById('status').textContent = data.error || 'Unavailable';
+                        return;
+                    }
+                    document.getElementById('title').textContent = data.name;
+                    document.title = data.name;
+                    data.entries.forEach(addEntry);
+                    next = data.next;
+                    if (data.live) {
+                        document.getElementById('status').textContent = 'Live, updated ' + new Date().toLocaleTimeString();
+                        setTimeout(load, 2000);
+                    } else {
+                        document.getElementById('status').textContent = 'Recorded session';
+                    }
+                })
+                .catch(() => {
+                    document.getElementById('status').textContent = 'Disconnected, retrying…';
+                    setTimeout(load, 5000);
+                });
+        }
+        load();
+    </script>
+</body>
+</html>
+`
@@ -0,0 +1,181 @@
+/*
+Package share serves read-only views of the message timeline of MCP sessions, live or recorded,
+at links protected by a random token, to debug a misbehaving server together with someone else.
+*/
+package share
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/record"
+)
+
+// PathPrefix is the path share links are served under.
+const PathPrefix = "/share/"
+
+// tokenBytes is the number of random bytes of a token.
+const tokenBytes = 16
+
+// Source is a session whose timeline is shared.
+type Source interface {
+	// Entries returns the entries of the session, skipping the first after of them.
+	Entries(after int) []record.Entry
+}
+
+// Recording is a recorded session, whose timeline does not change.
+type Recording []record.Entry
+
+// Entries returns the entries of the recording, skipping the first after of them.
+func (r Recording) Entries(after int) []record.Entry {
+	after = max(after, 0)
+	if after >= len(r) {
+		return []record.Entry{}
+	}
+	return r[after:]
+}
+
+// Link is a share link to the timeline of a session.
+type Link struct {
+	Token   string    `json:"token"`
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Live    bool      `json:"live"`
+	Created time.Time `json:"created"`
+}
+
+// timeline is the response of the timeline of a share link. Next is the after to ask for the
+// entries that follow.
+type timeline struct {
+	Name    string         `json:"name"`
+	Live    bool           `json:"live"`
+	Entries []record.Entry `json:"entries"`
+	Next    int            `json:"next"`
+}
+
+type shared struct {
+	Link
+	source Source
+	seq    int
+}
+
+// Server serves share links:
+//
+//	GET /share/{token}           the timeline page
+//	GET /share/{token}/timeline  the entries of the timeline, from the after-th on
+//
+// Unknown and revoked tokens are not found.
+type Server struct {
+	mu    sync.Mutex
+	links map[string]*shared
+	added int
+}
+
+// NewServer creates a server without share links.
+func NewServer() *Server {
+	return &Server{links: map[string]*shared{}}
+}
+
+// Add creates a share link called name to the timeline of source. Links to anything but a
+// Recording are live: their page follows the session as it goes.
+func (s *Server) Add(name string, source Source) (Link, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return Link{}, err
+	}
+	token := hex.EncodeToString(buf)
+	_, recorded := source.(Recording)
+	link := Link{Token: token, Name: name, Path: PathPrefix + token, Live: !recorded, Created: time.Now().UTC()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.added++
+	s.links[token] = &shared{Link: link, source: source, seq: s.added}
+	return link, nil
+}
+
+// Revoke removes the share link with token, and reports whether there was one.
+func (s *Server) Revoke(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.links[token]; !ok {
+		return false
+	}
+	delete(s.links, token)
+	return true
+}
+
+// Links returns the share links, oldest first.
+func (s *Server) Links() []Link {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := make([]*shared, 0, len(s.links))
+	for _, l := range s.links {
+		all = append(all, l)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].seq < all[j].seq })
+	links := make([]Link, len(all))
+	for i, l := range all {
+		links[i] = l.Link
+	}
+	return links
+}
+
+func (s *Server) lookup(token string) (*shared, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.links[token]
+	return l, ok
+}
+
+// ServeHTTP serves the page and the timeline of share links.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Tokens are in the URL: keep them out of caches and the referrers of links
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	token, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, PathPrefix), "/")
+	l, ok := s.lookup(token)
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("this share link was revoked or never existed"))
+		return
+	}
+
+	switch rest {
+	case "":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	case "timeline":
+		after, _ := strconv.Atoi(r.URL.Query().Get("after"))
+		after = max(after, 0)
+		entries := l.source.Entries(after)
+		writeJSON(w, http.StatusOK, timeline{Name: l.Name, Live: l.Live, Entries: entries, Next: after + len(entries)})
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,71 @@
+package share
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/f/mcptools/pkg/record"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	live := &record.Timeline{}
+	_, _ = live.Write([]byte(`{"direction":"start","server":["server"]}`))
+	_, _ = live.Write([]byte(`{"direction":"sent","message":{"jsonrpc":"2.0","id":1,"method":"tools/list"}}`))
+
+	liveLink, err := s.Add("live session", live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := s.Add("recording", Recording{{Direction: record.DirectionStart}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !liveLink.Live || recorded.Live || len(liveLink.Token) != 2*tokenBytes || liveLink.Token == recorded.Token {
+		t.Fatalf("Add() = %+v, %+v", liveLink, recorded)
+	}
+	if links := s.Links(); len(links) != 2 || links[0].Token != liveLink.Token {
+		t.Errorf("Links() = %+v, want the live link first", links)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get(liveLink.Path); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "MCP Session") {
+		t.Errorf("page: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := get(liveLink.Path + "/timeline?after=1")
+	var got timeline
+	if err = json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "live session" || !got.Live || len(got.Entries) != 1 || got.Next != 2 {
+		t.Errorf("timeline = %+v, want the request and next 2", got)
+	}
+
+	for _, path := range []string{PathPrefix + "unknown", PathPrefix + "unknown/timeline", liveLink.Path + "/other"} {
+		if rec = get(path); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, rec.Code)
+		}
+	}
+
+	post := httptest.NewRecorder()
+	s.ServeHTTP(post, httptest.NewRequest(http.MethodPost, liveLink.Path, nil))
+	if post.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", post.Code)
+	}
+
+	if !s.Revoke(liveLink.Token) || s.Revoke(liveLink.Token) {
+		t.Error("Revoke() should remove a link once")
+	}
+	if rec = get(liveLink.Path); rec.Code != http.StatusNotFound {
+		t.Errorf("revoked link = %d, want 404", rec.Code)
+	}
+}
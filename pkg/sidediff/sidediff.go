@@ -0,0 +1,173 @@
+/*
+Package sidediff compares two JSON values line by line and renders them side by side, the way
+the shell shows two calls to compare their params and results.
+*/
+package sidediff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Op is how a row differs between the two sides.
+type Op int
+
+// Ops of rows.
+const (
+	Same Op = iota
+	Changed
+	Removed
+	Added
+)
+
+// Row is a line of the left side and the line of the right side facing it. Removed rows have
+// no right line and added rows no left line.
+type Row struct {
+	Op    Op
+	Left  string
+	Right string
+}
+
+// JSON compares a and b as indented JSON, with object keys sorted so that only values differ.
+func JSON(a, b any) []Row {
+	return Lines(jsonLines(a), jsonLines(b))
+}
+
+func jsonLines(value any) []string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return strings.Split(fmt.Sprint(value), "\n")
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// Lines compares two lists of lines. Lines only on one side that face each other between
+// common lines are paired as changed rows.
+func Lines(a, b []string) []Row {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var rows, removed, added []Row
+	flush := func() {
+		paired := min(len(removed), len(added))
+		for k := range paired {
+			rows = append(rows, Row{Op: Changed, Left: removed[k].Left, Right: added[k].Right})
+		}
+		rows = append(rows, removed[paired:]...)
+		rows = append(rows, added[paired:]...)
+		removed, added = removed[:0], added[:0]
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			rows = append(rows, Row{Op: Same, Left: a[i], Right: b[j]})
+			i++
+			j++
+		case j >= len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, Row{Op: Removed, Left: a[i]})
+			i++
+		default:
+			added = append(added, Row{Op: Added, Right: b[j]})
+			j++
+		}
+	}
+	flush()
+	return rows
+}
+
+// Equal reports whether the rows show no difference.
+func Equal(rows []Row) bool {
+	for _, row := range rows {
+		if row.Op != Same {
+			return false
+		}
+	}
+	return true
+}
+
+// markers start rows, so differences show without colors too.
+var markers = [...]string{Same: "  ", Changed: "~ ", Removed: "- ", Added: "+ "}
+
+// ANSI colors of the sides of rows that differ.
+const (
+	red     = "\x1b[31m"
+	green   = "\x1b[32m"
+	yellow  = "\x1b[33m"
+	reverse = "\x1b[7m"
+	reset   = "\x1b[0m"
+)
+
+// Render writes rows in two columns fitting width, under the titles of the sides. With color,
+// removed lines are red, added lines green, and changed lines yellow with the part that changed
+// highlighted.
+func Render(w io.Writer, leftTitle, rightTitle string, rows []Row, width int, color bool) {
+	column := max((width-len(markers[Same])-3)/2, 10)
+
+	fmt.Fprintf(w, "%s%s │ %s\n", markers[Same], pad(truncate(leftTitle, column), column), truncate(rightTitle, column))
+	fmt.Fprintf(w, "%s%s─┼─%s\n", markers[Same], strings.Repeat("─", column), strings.Repeat("─", column))
+	for _, row := range rows {
+		left, right := truncate(row.Left, column), truncate(row.Right, column)
+		padding := strings.Repeat(" ", column-utf8.RuneCountInString(left))
+		if color {
+			switch row.Op {
+			case Changed:
+				left, right = highlight(left, right)
+			case Removed:
+				left = red + left + reset
+			case Added:
+				right = green + right + reset
+			}
+		}
+		fmt.Fprintf(w, "%s%s%s │ %s\n", markers[row.Op], left, padding, right)
+	}
+}
+
+// highlight colors two changed lines, reversing the part between their common prefix and
+// suffix.
+func highlight(left, right string) (string, string) {
+	a, b := []rune(left), []rune(right)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	mark := func(r []rune) string {
+		return yellow + string(r[:prefix]) + reverse + string(r[prefix:len(r)-suffix]) + reset +
+			yellow + string(r[len(r)-suffix:]) + reset
+	}
+	return mark(a), mark(b)
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-utf8.RuneCountInString(s), 0))
+}
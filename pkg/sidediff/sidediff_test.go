@@ -0,0 +1,67 @@
+package sidediff
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	rows := Lines([]string{"a", "b", "c", "d"}, []string{"a", "B", "c", "e", "f"})
+	want := []Row{
+		{Op: Same, Left: "a", Right: "a"},
+		{Op: Changed, Left: "b", Right: "B"},
+		{Op: Same, Left: "c", Right: "c"},
+		{Op: Changed, Left: "d", Right: "e"},
+		{Op: Added, Right: "f"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Lines() = %+v, want %+v", rows, want)
+	}
+	if Equal(rows) || !Equal(Lines([]string{"x"}, []string{"x"})) {
+		t.Error("Equal() did not tell differing rows from the same")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	a := map[string]any{"path": "a.md", "limit": 5.0, "tags": []any{"x"}}
+	b := map[string]any{"path": "b.md", "limit": 5.0}
+	var changed []string
+	for _, row := range JSON(a, b) {
+		if row.Op != Same {
+			changed = append(changed, row.Left+" | "+row.Right)
+		}
+	}
+	want := []string{
+		`  "path": "a.md", |   "path": "b.md"`,
+		`  "tags": [ | `,
+		`    "x" | `,
+		`  ] | `,
+	}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("JSON() changed rows:\n%s\nwant:\n%s", strings.Join(changed, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRender(t *testing.T) {
+	rows := Lines([]string{"same", "limit: 10", "gone"}, []string{"same", "limit: 20"})
+
+	var out bytes.Buffer
+	Render(&out, "#1 search", "#2 search", rows, 40, false)
+	want := "" +
+		"  #1 search         │ #2 search\n" +
+		"  ──────────────────┼──────────────────\n" +
+		"  same              │ same\n" +
+		"~ limit: 10         │ limit: 20\n" +
+		"- gone              │ \n"
+	if out.String() != want {
+		t.Errorf("Render() =\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	Render(&out, "a", "b", rows, 40, true)
+	if !strings.Contains(out.String(), "\x1b[33mlimit: \x1b[7m1\x1b[0m\x1b[33m0\x1b[0m") {
+		t.Errorf("Render() with color did not highlight the changed part: %q", out.String())
+	}
+}
@@ -0,0 +1,175 @@
+// Package stats tracks message size and count statistics for an MCP session.
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/f/mcptools/pkg/jsonutils"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Session holds the counters collected for a single client session.
+type Session struct {
+	Notifications    map[string]int `json:"notifications"`
+	LargestMethod    string         `json:"largestMethod,omitempty"`
+	MessagesSent     int            `json:"messagesSent"`
+	MessagesReceived int            `json:"messagesReceived"`
+	BytesSent        int            `json:"bytesSent"`
+	BytesReceived    int            `json:"bytesReceived"`
+	LargestMessage   int            `json:"largestMessage"`
+	mutex            sync.Mutex
+}
+
+// NewSession creates an empty statistics session.
+func NewSession() *Session {
+	return &Session{
+		Notifications: make(map[string]int),
+	}
+}
+
+// RecordSent records an outgoing message of the given size.
+func (s *Session) RecordSent(method string, size int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.MessagesSent++
+	s.BytesSent += size
+	s.trackLargest(method, size)
+}
+
+// RecordReceived records an incoming message of the given size.
+func (s *Session) RecordReceived(method string, size int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.MessagesReceived++
+	s.BytesReceived += size
+	s.trackLargest(method, size)
+}
+
+// RecordNotification records an incoming notification of the given size.
+func (s *Session) RecordNotification(method string, size int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.MessagesReceived++
+	s.BytesReceived += size
+	s.Notifications[method]++
+	s.trackLargest(method, size)
+}
+
+// trackLargest updates the largest message seen. The caller must hold the mutex.
+func (s *Session) trackLargest(method string, size int) {
+	if size > s.LargestMessage {
+		s.LargestMessage = size
+		s.LargestMethod = method
+	}
+}
+
+// Snapshot returns a copy of the current counters that is safe to read concurrently.
+func (s *Session) Snapshot() *Session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	notifications := make(map[string]int, len(s.Notifications))
+	for method, count := range s.Notifications {
+		notifications[method] = count
+	}
+
+	return &Session{
+		Notifications:    notifications,
+		LargestMethod:    s.LargestMethod,
+		MessagesSent:     s.MessagesSent,
+		MessagesReceived: s.MessagesReceived,
+		BytesSent:        s.BytesSent,
+		BytesReceived:    s.BytesReceived,
+		LargestMessage:   s.LargestMessage,
+	}
+}
+
+// Print writes a human readable summary of the session to w.
+func (s *Session) Print(w io.Writer) {
+	snap := s.Snapshot()
+
+	fmt.Fprintln(w, "Session statistics:")
+	fmt.Fprintf(w, "  Messages sent:     %d (%s)\n", snap.MessagesSent, jsonutils.FormatBytes(int64(snap.BytesSent)))
+	fmt.Fprintf(w, "  Messages received: %d (%s)\n", snap.MessagesReceived, jsonutils.FormatBytes(int64(snap.BytesReceived)))
+	if snap.LargestMethod != "" {
+		fmt.Fprintf(w, "  Largest message:   %s (%s)\n", jsonutils.FormatBytes(int64(snap.LargestMessage)), snap.LargestMethod)
+	} else {
+		fmt.Fprintf(w, "  Largest message:   %s\n", jsonutils.FormatBytes(int64(snap.LargestMessage)))
+	}
+
+	if len(snap.Notifications) == 0 {
+		return
+	}
+
+	methods := make([]string, 0, len(snap.Notifications))
+	for method := range snap.Notifications {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	fmt.Fprintln(w, "  Notifications:")
+	for _, method := range methods {
+		fmt.Fprintf(w, "    %s: %d\n", method, snap.Notifications[method])
+	}
+}
+
+// Transport wraps a transport.Interface and records every message passing through it.
+type Transport struct {
+	transport.Interface
+	session *Session
+}
+
+// NewTransport wraps inner so that all traffic is recorded into session.
+func NewTransport(inner transport.Interface, session *Session) *Transport {
+	return &Transport{
+		Interface: inner,
+		session:   session,
+	}
+}
+
+// SendRequest records the request and its response sizes and forwards the request.
+func (t *Transport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	t.session.RecordSent(request.Method, messageSize(request))
+
+	response, err := t.Interface.SendRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	t.session.RecordReceived(request.Method, messageSize(response))
+	return response, nil
+}
+
+// SendNotification records the notification size and forwards it.
+func (t *Transport) SendNotification(ctx context.Context, notification mcp.JSONRPCNotification) error {
+	t.session.RecordSent(notification.Method, messageSize(notification))
+	return t.Interface.SendNotification(ctx, notification)
+}
+
+// SetNotificationHandler installs handler, recording each notification before it is delivered.
+func (t *Transport) SetNotificationHandler(handler func(notification mcp.JSONRPCNotification)) {
+	t.Interface.SetNotificationHandler(func(notification mcp.JSONRPCNotification) {
+		t.session.RecordNotification(notification.Method, messageSize(notification))
+		if handler != nil {
+			handler(notification)
+		}
+	})
+}
+
+// messageSize returns the size of v on the wire, including the trailing newline delimiter.
+func messageSize(v any) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data) + 1
+}
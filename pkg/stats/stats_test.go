@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type fakeTransport struct {
+	handler func(mcp.JSONRPCNotification)
+}
+
+func (f *fakeTransport) Start(_ context.Context) error { return nil }
+
+func (f *fakeTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	return &transport.JSONRPCResponse{JSONRPC: "2.0", ID: request.ID, Result: json.RawMessage(`{"ok":true}`)}, nil
+}
+
+func (f *fakeTransport) SendNotification(_ context.Context, _ mcp.JSONRPCNotification) error {
+	return nil
+}
+
+func (f *fakeTransport) SetNotificationHandler(handler func(mcp.JSONRPCNotification)) {
+	f.handler = handler
+}
+
+func (f *fakeTransport) Close() error { return nil }
+
+//nolint:revive // Method name required by transport.Interface from mcp-go
+func (f *fakeTransport) GetSessionId() string { return "" }
+
+func TestTransportRecordsTraffic(t *testing.T) {
+	inner := &fakeTransport{}
+	session := NewSession()
+	tr := NewTransport(inner, session)
+
+	_, err := tr.SendRequest(context.Background(), transport.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      mcp.NewRequestId(int64(1)),
+		Method:  "tools/list",
+	})
+	if err != nil {
+		t.Fatalf("SendRequest() error = %v", err)
+	}
+
+	received := 0
+	tr.SetNotificationHandler(func(_ mcp.JSONRPCNotification) { received++ })
+	notification := mcp.JSONRPCNotification{JSONRPC: "2.0"}
+	notification.Method = "notifications/progress"
+	inner.handler(notification)
+	inner.handler(notification)
+
+	snap := session.Snapshot()
+	if snap.MessagesSent != 1 {
+		t.Errorf("MessagesSent = %d, want 1", snap.MessagesSent)
+	}
+	if snap.MessagesReceived != 3 {
+		t.Errorf("MessagesReceived = %d, want 3", snap.MessagesReceived)
+	}
+	if snap.Notifications["notifications/progress"] != 2 || received != 2 {
+		t.Errorf("expected 2 progress notifications, got %d (delivered %d)", snap.Notifications["notifications/progress"], received)
+	}
+	if snap.BytesSent == 0 || snap.BytesReceived == 0 || snap.LargestMessage == 0 {
+		t.Errorf("expected byte counters to be populated, got %+v", snap)
+	}
+
+	var buf bytes.Buffer
+	session.Print(&buf)
+	if !strings.Contains(buf.String(), "notifications/progress: 2") {
+		t.Errorf("Print() output missing notification counts: %s", buf.String())
+	}
+}
@@ -0,0 +1,124 @@
+package stdio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FaultsEnv is the environment variable the mcp command reads faults to inject into its stdio
+// transports from, in the form ParseFaults accepts. It is meant for integration tests of how the
+// client copes with misbehaving servers.
+const FaultsEnv = "MCPT_STDIO_FAULTS"
+
+// DefaultGarbage is the line injected between messages when no other one is set.
+const DefaultGarbage = "this line is not a JSON-RPC message"
+
+// ErrInjectedFault is the error of writes failed on purpose.
+var ErrInjectedFault = errors.New("injected fault")
+
+// Faults are failures a Transport injects into its exchange with the server, to test how the
+// client copes with a misbehaving one. The zero value injects none.
+type Faults struct {
+	// FailWritesAfter makes writes to the server fail once this many bytes were written, the
+	// write crossing the limit being cut short. Zero disables it.
+	FailWritesAfter int64
+	// ReadDelay delays each message read from the server.
+	ReadDelay time.Duration
+	// EOFAfter ends the output of the server after this many messages, as if it had exited.
+	// Zero disables it.
+	EOFAfter int
+	// GarbageEvery injects Garbage (or DefaultGarbage) as a line of its own before every
+	// GarbageEvery-th message read from the server. Zero disables it.
+	GarbageEvery int
+	Garbage      string
+}
+
+// WithFaults injects faults into the exchange with the server. Injected garbage goes through
+// the filters like lines the server writes.
+func WithFaults(faults Faults) Option {
+	return func(t *Transport) {
+		t.faults = faults
+	}
+}
+
+// ParseFaults reads faults written as comma-separated key=value pairs:
+//
+//	fail-writes-after=512,read-delay=50ms,eof-after=3,garbage-every=2,garbage=oops
+//
+// The garbage value must come last if it contains commas.
+func ParseFaults(spec string) (Faults, error) {
+	var faults Faults
+	for rest := strings.TrimSpace(spec); rest != ""; {
+		var pair string
+		if strings.HasPrefix(strings.TrimSpace(rest), "garbage=") {
+			pair, rest = rest, ""
+		} else {
+			pair, rest, _ = strings.Cut(rest, ",")
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return Faults{}, fmt.Errorf("invalid fault %q: expected key=value", pair)
+		}
+
+		var err error
+		switch key {
+		case "fail-writes-after":
+			faults.FailWritesAfter, err = strconv.ParseInt(value, 10, 64)
+		case "read-delay":
+			faults.ReadDelay, err = time.ParseDuration(value)
+		case "eof-after":
+			faults.EOFAfter, err = strconv.Atoi(value)
+		case "garbage-every":
+			faults.GarbageEvery, err = strconv.Atoi(value)
+		case "garbage":
+			faults.Garbage = value
+		default:
+			return Faults{}, fmt.Errorf("unknown fault %q: expected fail-writes-after, read-delay, eof-after, garbage-every or garbage", key)
+		}
+		if err != nil {
+			return Faults{}, fmt.Errorf("invalid fault %s=%s: %w", key, value, err)
+		}
+	}
+	if faults.FailWritesAfter < 0 || faults.ReadDelay < 0 || faults.EOFAfter < 0 || faults.GarbageEvery < 0 {
+		return Faults{}, fmt.Errorf("invalid faults %q: values cannot be negative", spec)
+	}
+	return faults, nil
+}
+
+// garbage returns the line injected between messages.
+func (f Faults) garbage() []byte {
+	if f.Garbage == "" {
+		return []byte(DefaultGarbage)
+	}
+	return []byte(f.Garbage)
+}
+
+// faultWriter fails writes once a number of bytes were written.
+type faultWriter struct {
+	target io.WriteCloser
+	left   int64
+}
+
+// Write implements io.Writer.
+func (w *faultWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= w.left {
+		n, err := w.target.Write(p)
+		w.left -= int64(n)
+		return n, err
+	}
+	n, err := w.target.Write(p[:w.left])
+	w.left -= int64(n)
+	if err == nil {
+		err = fmt.Errorf("%w: write failed after %d bytes", ErrInjectedFault, n)
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (w *faultWriter) Close() error {
+	return w.target.Close()
+}
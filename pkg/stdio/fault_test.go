@@ -0,0 +1,139 @@
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// TestMain lets the test binary act as a server answering every request with an empty result,
+// first printing as many banner lines as STDIO_TEST_BANNER says.
+func TestMain(m *testing.M) {
+	if os.Getenv("STDIO_TEST_SERVER") == "1" {
+		banner, _ := strconv.Atoi(os.Getenv("STDIO_TEST_BANNER"))
+		for i := 0; i < banner; i++ {
+			fmt.Printf("banner line %d\n", i+1)
+		}
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			var request struct {
+				ID json.RawMessage `json:"id"`
+			}
+			if json.Unmarshal(scanner.Bytes(), &request) == nil && len(request.ID) > 0 {
+				fmt.Printf(`{"jsonrpc":"2.0","id":%s,"result":{}}`+"\n", request.ID)
+			}
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// startServer starts the test binary as a server on a transport with opts.
+func startServer(t *testing.T, opts ...Option) *Transport {
+	t.Helper()
+	tr := New(os.Args[0], nil, append([]Option{WithEnv("STDIO_TEST_SERVER=1")}, opts...)...)
+	if err := tr.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { _ = tr.Close() })
+	return tr
+}
+
+// ping sends a ping with a short timeout.
+func ping(tr *Transport, id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err := tr.SendRequest(ctx, transport.JSONRPCRequest{JSONRPC: mcp.JSONRPC_VERSION, ID: mcp.NewRequestId(int64(id)), Method: "ping"})
+	return err
+}
+
+func TestParseFaults(t *testing.T) {
+	faults, err := ParseFaults("fail-writes-after=512, read-delay=50ms,eof-after=3,garbage-every=2,garbage=oops, not json")
+	if err != nil {
+		t.Fatalf("ParseFaults() error = %v", err)
+	}
+	want := Faults{FailWritesAfter: 512, ReadDelay: 50 * time.Millisecond, EOFAfter: 3, GarbageEvery: 2, Garbage: "oops, not json"}
+	if faults != want {
+		t.Errorf("ParseFaults() = %+v, want %+v", faults, want)
+	}
+
+	for _, invalid := range []string{"eof-after", "eof-after=soon", "read-delay=-1s", "explode=1"} {
+		if _, err = ParseFaults(invalid); err == nil {
+			t.Errorf("ParseFaults(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestFaultGarbage(t *testing.T) {
+	// Strict mode rejects the garbage, aborting the session
+	var seen [][]byte
+	strict := func(dir Direction, line []byte) ([]byte, error) {
+		if dir == Incoming && !json.Valid(line) {
+			return nil, fmt.Errorf("invalid message %q", line)
+		}
+		return line, nil
+	}
+	tr := startServer(t, WithFaults(Faults{GarbageEvery: 2}), WithFilter(strict))
+	if err := ping(tr, 1); err != nil {
+		t.Fatalf("first ping error = %v", err)
+	}
+	if err := ping(tr, 2); err == nil || !bytes.Contains([]byte(err.Error()), []byte(DefaultGarbage)) {
+		t.Errorf("second ping error = %v, want the garbage rejected", err)
+	}
+
+	// A quirk dropping stray lines lets the session go on
+	lenient := func(dir Direction, line []byte) ([]byte, error) {
+		if dir == Incoming && !json.Valid(line) {
+			seen = append(seen, line)
+			return nil, nil
+		}
+		return line, nil
+	}
+	tr = startServer(t, WithFaults(Faults{GarbageEvery: 1, Garbage: "banner"}), WithFilter(lenient))
+	for id := 1; id <= 3; id++ {
+		if err := ping(tr, id); err != nil {
+			t.Fatalf("ping %d error = %v", id, err)
+		}
+	}
+	if len(seen) != 3 || string(seen[0]) != "banner" {
+		t.Errorf("dropped %q, want the garbage before each message", seen)
+	}
+}
+
+func TestFaultEOF(t *testing.T) {
+	tr := startServer(t, WithFaults(Faults{EOFAfter: 1}))
+	if err := ping(tr, 1); err != nil {
+		t.Fatalf("first ping error = %v", err)
+	}
+	if err := ping(tr, 2); err == nil {
+		t.Error("ping after the output ended succeeded")
+	}
+}
+
+func TestFaultWrites(t *testing.T) {
+	tr := startServer(t, WithFaults(Faults{FailWritesAfter: 10}))
+	if err := ping(tr, 1); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("ping error = %v, want the injected write failure", err)
+	}
+}
+
+func TestFaultReadDelay(t *testing.T) {
+	tr := startServer(t, WithFaults(Faults{ReadDelay: 100 * time.Millisecond}))
+	start := time.Now()
+	if err := ping(tr, 1); err != nil {
+		t.Fatalf("ping error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("ping answered in %s, want at least the read delay", elapsed)
+	}
+}
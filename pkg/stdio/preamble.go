@@ -0,0 +1,47 @@
+package stdio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// MaxPreambleLines is the number of lines a server may write before its first message that
+// the mcp command skips, see WithPreamble.
+const MaxPreambleLines = 100
+
+// WithPreamble skips up to maxLines lines that are not JSON written by the server before its
+// first message, such as banners or npm warnings, and reports each on warnings. A server
+// writing more is not speaking JSON-RPC, and the session is aborted. Once a message was read,
+// the output goes through the filters as it comes.
+func WithPreamble(maxLines int, warnings io.Writer) Option {
+	return func(t *Transport) {
+		t.preamble = maxLines
+		t.warnings = warnings
+	}
+}
+
+// skipPreamble reports whether line is part of the preamble and should be skipped, or an
+// error once the preamble is too long.
+func (r *filterReader) skipPreamble(line []byte) (bool, error) {
+	t := r.transport
+	if r.started || t.preamble <= 0 {
+		return false, nil
+	}
+	if len(line) == 0 {
+		return true, nil
+	}
+	if json.Valid(line) {
+		r.started = true
+		return false, nil
+	}
+	if r.skipped >= t.preamble {
+		return false, fmt.Errorf("the server wrote more than %d lines that are not JSON-RPC messages before its first message, the last being %q", t.preamble, line)
+	}
+
+	r.skipped++
+	if t.warnings != nil {
+		fmt.Fprintf(t.warnings, "Warning: skipped output of the server before its first message: %q\n", line)
+	}
+	return true, nil
+}
@@ -0,0 +1,49 @@
+package stdio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPreambleSkipped(t *testing.T) {
+	var warnings bytes.Buffer
+	tr := startServer(t, WithEnv("STDIO_TEST_BANNER=2"), WithPreamble(2, &warnings))
+	for id := 1; id <= 2; id++ {
+		if err := ping(tr, id); err != nil {
+			t.Fatalf("ping %d error = %v", id, err)
+		}
+	}
+	if got := strings.Count(warnings.String(), "skipped output of the server"); got != 2 ||
+		!strings.Contains(warnings.String(), `"banner line 2"`) {
+		t.Errorf("warnings = %q, want both banner lines reported", warnings.String())
+	}
+}
+
+func TestPreambleTooLong(t *testing.T) {
+	tr := startServer(t, WithEnv("STDIO_TEST_BANNER=3"), WithPreamble(2, nil))
+	if err := ping(tr, 1); err == nil || !strings.Contains(err.Error(), "more than 2 lines") {
+		t.Errorf("ping error = %v, want the preamble rejected", err)
+	}
+}
+
+func TestPreambleOnlyBeforeFirstMessage(t *testing.T) {
+	// Garbage after the first message goes to the filters as usual
+	var seen []string
+	record := func(dir Direction, line []byte) ([]byte, error) {
+		if dir == Incoming && !bytes.HasPrefix(line, []byte("{")) {
+			seen = append(seen, string(line))
+			return nil, nil
+		}
+		return line, nil
+	}
+	tr := startServer(t, WithFaults(Faults{GarbageEvery: 2}), WithPreamble(2, nil), WithFilter(record))
+	for id := 1; id <= 2; id++ {
+		if err := ping(tr, id); err != nil {
+			t.Fatalf("ping %d error = %v", id, err)
+		}
+	}
+	if len(seen) != 1 || seen[0] != DefaultGarbage {
+		t.Errorf("filters saw %q, want the garbage after the first message", seen)
+	}
+}
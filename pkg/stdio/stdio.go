@@ -0,0 +1,364 @@
+// Package stdio provides a stdio transport that launches the MCP server process itself so that
+// the raw newline-delimited message stream can be inspected and adjusted before it reaches the
+// mcp-go client.
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/f/mcptools/pkg/codec"
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// Direction identifies which way a message is travelling.
+type Direction int
+
+// message directions.
+const (
+	// Outgoing messages are written by the client to the server's stdin.
+	Outgoing Direction = iota
+	// Incoming messages are read by the client from the server's stdout.
+	Incoming
+)
+
+// String returns a human readable name for the direction.
+func (d Direction) String() string {
+	if d == Outgoing {
+		return "outgoing"
+	}
+	return "incoming"
+}
+
+// Filter inspects a single message (without its trailing newline) travelling in the given
+// direction. It returns the message to pass on, nil to drop it, or an error to abort the session.
+type Filter func(dir Direction, line []byte) ([]byte, error)
+
+// Option configures a Transport.
+type Option func(*Transport)
+
+// WithFilter adds a filter that is applied to every message. Filters run in the order they are
+// added.
+func WithFilter(filter Filter) Option {
+	return func(t *Transport) {
+		t.filters = append(t.filters, filter)
+	}
+}
+
+// WithEnv appends environment variables (in KEY=VALUE form) to the server process environment.
+func WithEnv(env ...string) Option {
+	return func(t *Transport) {
+		t.env = append(t.env, env...)
+	}
+}
+
+// WithCodec sets the wire format of the messages exchanged with the server. Filters always see
+// messages as JSON. The default is newline-delimited JSON.
+func WithCodec(c codec.Codec) Option {
+	return func(t *Transport) {
+		t.codec = c
+	}
+}
+
+// Transport is a stdio transport backed by a subprocess that this package manages.
+type Transport struct {
+	*transport.Stdio
+	cmd     *exec.Cmd
+	stderr  io.ReadCloser
+	codec   codec.Codec
+	failed  chan struct{}
+	failErr error
+	command string
+	args    []string
+	env     []string
+	filters []Filter
+	faults  Faults
+	// preamble is the number of lines before the first message to skip, reported on warnings.
+	preamble int
+	warnings io.Writer
+	once     sync.Once
+}
+
+// New creates a transport that will run command with args once started.
+func New(command string, args []string, opts ...Option) *Transport {
+	t := &Transport{
+		command: command,
+		args:    args,
+		codec:   codec.JSON{},
+		failed:  make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Start launches the server process and starts reading its responses.
+func (t *Transport) Start(ctx context.Context) error {
+	// #nosec G204 - the command is provided explicitly by the user
+	cmd := exec.Command(t.command, t.args...)
+	cmd.Env = append(os.Environ(), t.env...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	if startErr := cmd.Start(); startErr != nil {
+		return fmt.Errorf("failed to start command: %w", startErr)
+	}
+
+	var target io.WriteCloser = stdin
+	if t.faults.FailWritesAfter > 0 {
+		target = &faultWriter{target: stdin, left: t.faults.FailWritesAfter}
+	}
+
+	t.cmd = cmd
+	t.stderr = stderr
+	t.Stdio = transport.NewIO(
+		&filterReader{transport: t, source: bufio.NewReader(stdout)},
+		&filterWriter{transport: t, target: target},
+		stderr,
+	)
+
+	return t.Stdio.Start(ctx)
+}
+
+// SendRequest sends a request and waits for its response, returning early if a filter aborted
+// the session while the request was in flight.
+func (t *Transport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-t.failed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	response, err := t.Stdio.SendRequest(ctx, request)
+	if failErr := t.Err(); failErr != nil {
+		return nil, failErr
+	}
+
+	return response, err
+}
+
+// Err returns the error that aborted the session, if any.
+func (t *Transport) Err() error {
+	select {
+	case <-t.failed:
+		return t.failErr
+	default:
+		return nil
+	}
+}
+
+// fail records err as the reason the session was aborted. Only the first error is kept.
+func (t *Transport) fail(err error) {
+	t.once.Do(func() {
+		t.failErr = err
+		close(t.failed)
+	})
+}
+
+// applyFilters runs all filters over line, stopping at the first one that drops or rejects it.
+func (t *Transport) applyFilters(dir Direction, line []byte) ([]byte, error) {
+	for _, filter := range t.filters {
+		var err error
+		line, err = filter(dir, line)
+		if err != nil || line == nil {
+			return nil, err
+		}
+	}
+	return line, nil
+}
+
+// Stderr returns a reader for the stderr output of the server process.
+func (t *Transport) Stderr() io.Reader {
+	return t.stderr
+}
+
+// Close closes the server's standard streams and waits for the process to exit.
+func (t *Transport) Close() error {
+	if t.Stdio == nil {
+		return nil
+	}
+
+	if err := t.Stdio.Close(); err != nil {
+		return err
+	}
+
+	return t.cmd.Wait()
+}
+
+// bom is the UTF-8 encoded byte order mark some Windows servers emit before their messages.
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+// NewReader returns a reader that yields the newline-delimited messages read from r with byte
+// order marks and carriage returns removed, passed through filters as incoming messages. Messages
+// are read whole, so multi-byte characters split across reads of r and lines longer than any
+// buffer size are passed on intact. Reading stops at the first message a filter rejects.
+func NewReader(r io.Reader, filters ...Filter) io.Reader {
+	t := New("", nil)
+	t.filters = filters
+	return &filterReader{transport: t, source: bufio.NewReader(r)}
+}
+
+// NewWriter returns a writer passing each newline-terminated message written to it through
+// filters as an outgoing message before writing it to w.
+func NewWriter(w io.WriteCloser, filters ...Filter) io.WriteCloser {
+	t := New("", nil)
+	t.filters = filters
+	return &filterWriter{transport: t, target: w}
+}
+
+// normalizeLine strips the line terminator and any leading byte order marks from line.
+func normalizeLine(line []byte) []byte {
+	line = bytes.TrimRight(line, "\r\n")
+	for bytes.HasPrefix(line, bom) {
+		line = line[len(bom):]
+	}
+	return line
+}
+
+// filterReader yields the server's stdout one filtered message at a time.
+type filterReader struct {
+	transport *Transport
+	source    *bufio.Reader
+	pending   []byte
+	// held is a message read from the server behind injected garbage, and read the number of
+	// messages read.
+	held []byte
+	read int
+	// skipped counts the lines of the preamble, and started is set once it is over.
+	skipped int
+	started bool
+}
+
+// Read implements io.Reader.
+func (r *filterReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.transport.Err() != nil {
+			return 0, io.EOF
+		}
+
+		line, err := r.next()
+		if len(line) > 0 {
+			skip, filterErr := r.skipPreamble(normalizeLine(line))
+			var message []byte
+			if !skip && filterErr == nil {
+				message, filterErr = r.transport.applyFilters(Incoming, normalizeLine(line))
+			}
+			if filterErr != nil {
+				r.transport.fail(filterErr)
+				// Report a clean EOF so the mcp-go reader stops quietly; callers get the
+				// filter error from SendRequest instead.
+				return 0, io.EOF
+			}
+			if message != nil {
+				r.pending = append(message, '\n')
+			}
+		}
+
+		if err != nil && len(r.pending) == 0 {
+			if errors.Is(err, os.ErrClosed) {
+				// The pipe is closed by Close once the process exits, which is a normal shutdown
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// next reads the next message from the server, injecting the faults of the transport.
+func (r *filterReader) next() ([]byte, error) {
+	faults := r.transport.faults
+	if r.held != nil {
+		line := r.held
+		r.held = nil
+		return line, nil
+	}
+	if faults.EOFAfter > 0 && r.read >= faults.EOFAfter {
+		return nil, io.EOF
+	}
+	if faults.ReadDelay > 0 {
+		time.Sleep(faults.ReadDelay)
+	}
+
+	line, err := r.transport.codec.ReadMessage(r.source)
+	if len(line) == 0 {
+		return line, err
+	}
+	r.read++
+	if faults.GarbageEvery > 0 && r.read%faults.GarbageEvery == 0 && err == nil {
+		// The garbage comes once the message is there, so it never arrives on its own
+		r.held = line
+		return faults.garbage(), nil
+	}
+	return line, err
+}
+
+// filterWriter passes each message written by the client through the filters before it is
+// encoded for the server's stdin. The mcp-go transport writes exactly one newline-terminated message per call.
+type filterWriter struct {
+	transport *Transport
+	target    io.WriteCloser
+	mutex     sync.Mutex
+}
+
+// Write implements io.Writer.
+func (w *filterWriter) Write(p []byte) (int, error) {
+	message, err := w.transport.applyFilters(Outgoing, bytes.TrimRight(p, "\r\n"))
+	if err != nil {
+		w.transport.fail(err)
+		return 0, err
+	}
+	if message == nil {
+		return len(p), nil
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if writeErr := w.transport.codec.WriteMessage(w.target, message); writeErr != nil {
+		return 0, writeErr
+	}
+
+	return len(p), nil
+}
+
+// Close implements io.Closer.
+func (w *filterWriter) Close() error {
+	return w.target.Close()
+}
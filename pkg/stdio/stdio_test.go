@@ -0,0 +1,76 @@
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func readMessages(t *testing.T, data []byte) []map[string]any {
+	t.Helper()
+
+	// Feed the input one byte at a time so that every multi-byte character is split across reads
+	reader := bufio.NewReader(NewReader(iotest.OneByteReader(bytes.NewReader(data))))
+
+	var messages []map[string]any
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			var msg map[string]any
+			if unmarshalErr := json.Unmarshal([]byte(line), &msg); unmarshalErr != nil {
+				t.Fatalf("failed to unmarshal %q: %v", line, unmarshalErr)
+			}
+			messages = append(messages, msg)
+		}
+		if err != nil {
+			return messages
+		}
+	}
+}
+
+func TestReaderMultiByteCharacters(t *testing.T) {
+	text := "héllo wörld 日本語 🚀👩‍💻"
+	data, _ := json.Marshal(map[string]string{"text": text})
+
+	messages := readMessages(t, append(data, '\n'))
+	if len(messages) != 1 || messages[0]["text"] != text {
+		t.Errorf("expected text %q to survive split reads, got %v", text, messages)
+	}
+}
+
+func TestReaderStripsByteOrderMarks(t *testing.T) {
+	input := "\xEF\xBB\xBF{\"id\":1}\r\n\xEF\xBB\xBF{\"id\":2}\n{\"id\":3}"
+
+	messages := readMessages(t, []byte(input))
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+	for i, msg := range messages {
+		if msg["id"] != float64(i+1) {
+			t.Errorf("message %d has id %v", i, msg["id"])
+		}
+	}
+}
+
+func TestReaderVeryLongLines(t *testing.T) {
+	// 4 MB of multi-byte text, far larger than any internal buffer
+	text := strings.Repeat("ünïcødé ", 1<<19)
+	data, _ := json.Marshal(map[string]string{"text": text})
+
+	reader := bufio.NewReader(NewReader(bytes.NewReader(append(data, '\n'))))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+
+	var msg map[string]string
+	if unmarshalErr := json.Unmarshal([]byte(line), &msg); unmarshalErr != nil {
+		t.Fatalf("failed to unmarshal long line: %v", unmarshalErr)
+	}
+	if msg["text"] != text {
+		t.Error("long line was corrupted")
+	}
+}
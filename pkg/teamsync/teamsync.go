@@ -0,0 +1,396 @@
+// Package teamsync keeps a team's mcptools setup consistent by pulling shared configuration
+// from a git repository into $HOME/.mcpt.
+//
+// The repository may hold aliases.json, or aliases.yaml or aliases.toml (in the format of the
+// local aliases file), anonymize.json and a templates directory for mcp new. Aliases are merged one by one and the
+// other files are copied. Each sync remembers what it pulled, so a later sync can tell local
+// edits apart from remote updates: items that only changed remotely are updated, and items
+// changed on both sides are conflicts that keep the local version unless forced.
+package teamsync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/f/mcptools/pkg/alias"
+	"github.com/f/mcptools/pkg/configfile"
+)
+
+// aliasesFile is the name of the file in the repository holding the shared aliases, in JSON,
+// YAML or TOML: aliases.json, aliases.yaml or aliases.toml.
+const aliasesFile = "aliases"
+
+// sharedFiles are the files and directories, relative to both the repository and $HOME/.mcpt,
+// that are copied. Nothing else in the repository is touched, so a repository cannot
+// overwrite local secrets or state.
+var sharedFiles = []string{"anonymize.json", "templates"}
+
+// Change kinds.
+const (
+	Added     = "added"
+	Updated   = "updated"
+	Removed   = "removed"
+	Conflict  = "conflict"
+	Unchanged = "unchanged"
+)
+
+// Change is one difference between the repository and the local configuration.
+type Change struct {
+	Kind string
+	// Item is "alias <name>" or "file <path>".
+	Item string
+}
+
+// Options configures a sync.
+type Options struct {
+	// Remote is the git repository, e.g. git@github.com:org/mcp-config.git. It defaults to the
+	// remote of the previous sync.
+	Remote string
+	// Branch defaults to the repository's default branch.
+	Branch string
+	// Force takes the remote version of conflicting items.
+	Force bool
+	// DryRun reports the changes without making them.
+	DryRun bool
+}
+
+// State is what the previous sync pulled.
+type State struct {
+	Remote  string            `json:"remote"`
+	Branch  string            `json:"branch,omitempty"`
+	Aliases alias.Aliases     `json:"aliases,omitempty"`
+	Files   map[string]string `json:"files,omitempty"`
+}
+
+// configDir returns $HOME/.mcpt.
+func configDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt"), nil
+}
+
+// GetStatePath returns the path of the file remembering the previous sync.
+func GetStatePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sync.json"), nil
+}
+
+// LoadState returns the state of the previous sync, which is empty if there was none.
+func LoadState() (State, error) {
+	var state State
+	path, err := GetStatePath()
+	if err != nil {
+		return state, err
+	}
+	data, err := os.ReadFile(path) // #nosec G304 - path is generated internally by GetStatePath
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	if err = json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	return state, nil
+}
+
+func saveState(state State) error {
+	path, err := GetStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// Run pulls the repository and applies it to the local configuration. It returns every change,
+// in a stable order, and saves the state for the next sync unless opts.DryRun is set.
+func Run(opts Options) ([]Change, error) {
+	state, err := LoadState()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Remote == "" {
+		opts.Remote, opts.Branch = state.Remote, state.Branch
+		if opts.Remote == "" {
+			return nil, errors.New("no remote given and no previous sync to reuse it from")
+		}
+	}
+	if opts.Remote != state.Remote {
+		// A different repository shares no history with what was pulled before
+		state = State{}
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	checkout, err := os.MkdirTemp(dir, "sync-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(checkout) }()
+	if err = clone(opts.Remote, opts.Branch, checkout); err != nil {
+		return nil, err
+	}
+
+	changes, aliases, err := mergeAliases(checkout, state, opts)
+	if err != nil {
+		return nil, err
+	}
+	fileChanges, files, err := copyFiles(checkout, dir, state, opts)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, fileChanges...)
+
+	if opts.DryRun {
+		return changes, nil
+	}
+	return changes, saveState(State{Remote: opts.Remote, Branch: opts.Branch, Aliases: aliases, Files: files})
+}
+
+// clone makes a shallow copy of the branch of remote in dir.
+func clone(remote, branch, dir string) error {
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, "--", remote, dir)
+
+	// #nosec G204 - the remote is provided explicitly by the user
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to clone %s: %w: %s", remote, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// mergeAliases applies the repository's aliases to the local ones. It returns the changes and
+// the remote aliases to remember as the base of the next sync.
+func mergeAliases(checkout string, state State, opts Options) ([]Change, alias.Aliases, error) {
+	remote := alias.Aliases{}
+	path, err := configfile.Find(checkout, aliasesFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path) // #nosec G304 - path within the checkout
+	if err == nil {
+		if err = configfile.Unmarshal(data, configfile.FormatOf(path), &remote); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s in the repository: %w", filepath.Base(path), err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	local, err := alias.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range remote {
+		names[name] = true
+	}
+	for name := range state.Aliases {
+		names[name] = true
+	}
+
+	var changes []Change
+	for _, name := range sortedKeys(names) {
+		remoteAlias, inRemote := remote[name]
+		localAlias, inLocal := local[name]
+		baseAlias, inBase := state.Aliases[name]
+
+		kind := decide(inRemote, inLocal, inBase,
+			reflect.DeepEqual(localAlias, remoteAlias), reflect.DeepEqual(localAlias, baseAlias), opts.Force)
+		switch kind {
+		case Added, Updated:
+			local[name] = remoteAlias
+		case Removed:
+			delete(local, name)
+		case "":
+			continue
+		}
+		changes = append(changes, Change{Kind: kind, Item: "alias " + name})
+	}
+
+	if !opts.DryRun && hasEdits(changes) {
+		if err = alias.Save(local); err != nil {
+			return nil, nil, err
+		}
+	}
+	return changes, remote, nil
+}
+
+// copyFiles copies the shared files of the repository into dir. It returns the changes and the
+// hashes of the remote files to remember as the base of the next sync.
+func copyFiles(checkout, dir string, state State, opts Options) ([]Change, map[string]string, error) {
+	remote := map[string]string{}
+	for _, shared := range sharedFiles {
+		root := filepath.Join(checkout, shared)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				if errors.Is(walkErr, os.ErrNotExist) {
+					return nil
+				}
+				return walkErr
+			}
+			if d.IsDir() || !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(checkout, path)
+			if err != nil {
+				return err
+			}
+			hash, err := hashFile(path)
+			if err != nil {
+				return err
+			}
+			remote[filepath.ToSlash(rel)] = hash
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	names := map[string]bool{}
+	for name := range remote {
+		names[name] = true
+	}
+	for name := range state.Files {
+		names[name] = true
+	}
+
+	var changes []Change
+	for _, name := range sortedKeys(names) {
+		remoteHash, inRemote := remote[name]
+		baseHash, inBase := state.Files[name]
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		localHash, err := hashFile(target)
+		inLocal := err == nil
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, err
+		}
+
+		kind := decide(inRemote, inLocal, inBase, localHash == remoteHash, localHash == baseHash, opts.Force)
+		if kind == "" {
+			continue
+		}
+		changes = append(changes, Change{Kind: kind, Item: "file " + name})
+		if opts.DryRun {
+			continue
+		}
+
+		switch kind {
+		case Added, Updated:
+			if err = copyFile(filepath.Join(checkout, filepath.FromSlash(name)), target); err != nil {
+				return nil, nil, err
+			}
+		case Removed:
+			if err = os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, nil, err
+			}
+		}
+	}
+	return changes, remote, nil
+}
+
+// decide compares the remote, local and base (previously synced) versions of an item. It
+// returns "" when there is nothing to report.
+func decide(inRemote, inLocal, inBase, localIsRemote, localIsBase, force bool) string {
+	switch {
+	case inRemote && inLocal && localIsRemote:
+		return ""
+	case inRemote && !inLocal:
+		if inBase && !force {
+			// Deleted locally after it was synced: keep it deleted
+			return Conflict
+		}
+		return Added
+	case inRemote:
+		if (inBase && localIsBase) || force {
+			return Updated
+		}
+		return Conflict
+	case inLocal && inBase && localIsBase:
+		// Removed from the repository and not edited locally since it was synced
+		return Removed
+	case inLocal && inBase:
+		if force {
+			return Removed
+		}
+		return Conflict
+	default:
+		return ""
+	}
+}
+
+func hasEdits(changes []Change) bool {
+	for _, c := range changes {
+		if c.Kind == Added || c.Kind == Updated || c.Kind == Removed {
+			return true
+		}
+	}
+	return false
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - paths are within the checkout or $HOME/.mcpt
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src) // #nosec G304 - path within the checkout
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o600)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
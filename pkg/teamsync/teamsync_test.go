@@ -0,0 +1,142 @@
+package teamsync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/f/mcptools/pkg/alias"
+)
+
+// newRemote creates a git repository with the given files and returns a function that
+// commits new contents to it.
+func newRemote(t *testing.T) (string, func(files map[string]string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "--quiet")
+
+	commit := func(files map[string]string) {
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			if content == "" {
+				_ = os.Remove(path)
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		git("add", "-A")
+		git("commit", "--quiet", "--allow-empty", "-m", "update")
+	}
+	return dir, commit
+}
+
+func kinds(changes []Change) map[string]string {
+	m := map[string]string{}
+	for _, c := range changes {
+		m[c.Item] = c.Kind
+	}
+	return m
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	remote, commit := newRemote(t)
+
+	commit(map[string]string{
+		"aliases.json":         `{"fs":{"command":"mcp-fs"},"gh":{"command":"mcp-gh"},"db":{"command":"mcp-db"}}`,
+		"templates/ts/tool.ts": "export {}",
+		"README.md":            "not synced",
+	})
+	if err := alias.Save(alias.Aliases{"gh": {Command: "my-gh"}, "mine": {Command: "personal"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Run(Options{Remote: remote})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := map[string]string{
+		"alias db":                  Added,
+		"alias fs":                  Added,
+		"alias gh":                  Conflict,
+		"file templates/ts/tool.ts": Added,
+	}
+	if got := kinds(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("first sync = %v, want %v", got, want)
+	}
+
+	// The remote updates fs and drops db, while gh stays edited locally
+	commit(map[string]string{"aliases.json": `{"fs":{"command":"mcp-fs --v2"},"gh":{"command":"mcp-gh"}}`})
+	changes, err = Run(Options{})
+	if err != nil {
+		t.Fatalf("Run() reusing the remote error = %v", err)
+	}
+	if got := kinds(changes); got["alias fs"] != Updated || got["alias db"] != Removed || got["alias gh"] != Conflict {
+		t.Errorf("second sync = %v", got)
+	}
+
+	local, _ := alias.Load()
+	if local["fs"].Command != "mcp-fs --v2" || local["gh"].Command != "my-gh" || local["mine"].Command != "personal" {
+		t.Errorf("local aliases = %+v", local)
+	}
+	if _, ok := local["db"]; ok {
+		t.Error("db should have been removed")
+	}
+
+	// Forcing takes the remote version of conflicts
+	if _, err = Run(Options{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if local, _ = alias.Load(); local["gh"].Command != "mcp-gh" {
+		t.Errorf("gh after a forced sync = %+v", local["gh"])
+	}
+
+	home, _ := os.UserHomeDir()
+	if _, err = os.Stat(filepath.Join(home, ".mcpt", "templates", "ts", "tool.ts")); err != nil {
+		t.Errorf("template was not copied: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(home, ".mcpt", "README.md")); err == nil {
+		t.Error("files outside the shared ones must not be copied")
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	remote, commit := newRemote(t)
+	commit(map[string]string{"aliases.json": `{"fs":{"command":"mcp-fs"}}`, "anonymize.json": `{"names":["Ann"]}`})
+
+	changes, err := Run(Options{Remote: remote, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := kinds(changes); got["alias fs"] != Added || got["file anonymize.json"] != Added {
+		t.Errorf("dry run = %v", got)
+	}
+
+	if local, _ := alias.Load(); len(local) != 0 {
+		t.Errorf("a dry run must not change aliases: %+v", local)
+	}
+	if state, _ := LoadState(); state.Remote != "" {
+		t.Errorf("a dry run must not save the state: %+v", state)
+	}
+}
@@ -0,0 +1,224 @@
+// Package telemetry counts which mcp commands are run and how they fail, for opt-in telemetry
+// that stays on the machine until exported.
+//
+// Nothing is counted until enabled with Enable, and setting DO_NOT_TRACK turns counting off
+// regardless. Counts are kept in $HOME/.mcpt/telemetry.json: the number of runs of each command
+// and the classes of their errors, never arguments, parameters, server names or results. Nothing
+// is sent anywhere; Export produces a report users can inspect and share themselves.
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// CommandCounts counts the runs of a command, and its failures by error class, e.g. timeout.
+type CommandCounts struct {
+	Runs   int            `json:"runs"`
+	Errors map[string]int `json:"errors,omitempty"`
+}
+
+// Counts are the counts collected since telemetry was enabled, by command path, e.g. "mcp call".
+type Counts struct {
+	Since    time.Time                 `json:"since"`
+	Commands map[string]*CommandCounts `json:"commands"`
+}
+
+// Runs returns the number of runs of all commands.
+func (c Counts) Runs() int {
+	runs := 0
+	for _, counts := range c.Commands {
+		runs += counts.Runs
+	}
+	return runs
+}
+
+// CommandNames returns the commands with counts, most run first.
+func (c Counts) CommandNames() []string {
+	names := make([]string, 0, len(c.Commands))
+	for name := range c.Commands {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := c.Commands[names[i]], c.Commands[names[j]]
+		if a.Runs != b.Runs {
+			return a.Runs > b.Runs
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Report is the exported form of the counts, with the version and platform of mcp.
+type Report struct {
+	Version  string                    `json:"version"`
+	OS       string                    `json:"os"`
+	Arch     string                    `json:"arch"`
+	Since    time.Time                 `json:"since"`
+	Until    time.Time                 `json:"until"`
+	Commands map[string]*CommandCounts `json:"commands"`
+}
+
+// configDir returns the mcptools configuration directory.
+func configDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt"), nil
+}
+
+// enabledPath returns the path of the marker file that enables telemetry.
+func enabledPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "telemetry.enabled"), nil
+}
+
+// GetDataPath returns the path of the file holding the counts.
+func GetDataPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "telemetry.json"), nil
+}
+
+// DoNotTrack reports whether the DO_NOT_TRACK environment variable turns telemetry off.
+func DoNotTrack() bool {
+	value := os.Getenv("DO_NOT_TRACK")
+	return value != "" && value != "0" && value != "false"
+}
+
+// Enabled reports whether commands are counted.
+func Enabled() bool {
+	if DoNotTrack() {
+		return false
+	}
+	path, err := enabledPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Enable turns on counting.
+func Enable() error {
+	path, err := enabledPath()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(path, nil, 0o600)
+}
+
+// Disable turns off counting and deletes the counts collected so far.
+func Disable() error {
+	path, err := enabledPath()
+	if err != nil {
+		return err
+	}
+	dataPath, err := GetDataPath()
+	if err != nil {
+		return err
+	}
+	for _, file := range []string{path, dataPath} {
+		if err = os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load returns the counts collected so far, empty if there are none.
+func Load() (Counts, error) {
+	path, err := GetDataPath()
+	if err != nil {
+		return Counts{}, err
+	}
+	data, err := os.ReadFile(path) // #nosec G304 - path is in $HOME/.mcpt
+	if errors.Is(err, os.ErrNotExist) {
+		return Counts{Commands: map[string]*CommandCounts{}}, nil
+	}
+	if err != nil {
+		return Counts{}, fmt.Errorf("failed to read telemetry: %w", err)
+	}
+	var counts Counts
+	if err = json.Unmarshal(data, &counts); err != nil {
+		return Counts{}, fmt.Errorf("invalid telemetry file %s: %w", path, err)
+	}
+	if counts.Commands == nil {
+		counts.Commands = map[string]*CommandCounts{}
+	}
+	return counts, nil
+}
+
+// save writes counts, replacing the file so concurrent readers never see a partial one.
+// Concurrent runs of mcp may lose each other's increments, which is fine for aggregate counts.
+func save(counts Counts) error {
+	path, err := GetDataPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(counts, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write telemetry: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Record counts a run of command, failed with errorClass unless it is empty. It does nothing
+// while telemetry is disabled.
+func Record(command, errorClass string) error {
+	if !Enabled() {
+		return nil
+	}
+	counts, err := Load()
+	if err != nil {
+		return err
+	}
+	if counts.Since.IsZero() {
+		counts.Since = time.Now().UTC()
+	}
+
+	c, ok := counts.Commands[command]
+	if !ok {
+		c = &CommandCounts{}
+		counts.Commands[command] = c
+	}
+	c.Runs++
+	if errorClass != "" {
+		if c.Errors == nil {
+			c.Errors = map[string]int{}
+		}
+		c.Errors[errorClass]++
+	}
+	return save(counts)
+}
+
+// Export returns the report of counts for version of mcp, as of now.
+func Export(counts Counts, version string, now time.Time) Report {
+	return Report{
+		Version:  version,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Since:    counts.Since,
+		Until:    now.UTC(),
+		Commands: counts.Commands,
+	}
+}
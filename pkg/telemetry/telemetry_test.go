@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DO_NOT_TRACK", "")
+
+	if err := Record("mcp call", ""); err != nil {
+		t.Fatal(err)
+	}
+	if counts, _ := Load(); len(counts.Commands) != 0 {
+		t.Fatalf("recorded %v while disabled", counts.Commands)
+	}
+
+	if err := Enable(); err != nil {
+		t.Fatal(err)
+	}
+	for _, class := range []string{"", "timeout", "timeout", "usage"} {
+		if err := Record("mcp call", class); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Record("mcp tools", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := counts.Commands["mcp call"]
+	if call == nil || call.Runs != 4 || call.Errors["timeout"] != 2 || call.Errors["usage"] != 1 {
+		t.Errorf("mcp call counts = %+v", call)
+	}
+	if counts.Runs() != 5 || counts.Since.IsZero() {
+		t.Errorf("Runs() = %d, Since = %v", counts.Runs(), counts.Since)
+	}
+	if names := counts.CommandNames(); len(names) != 2 || names[0] != "mcp call" {
+		t.Errorf("CommandNames() = %v, want the most run first", names)
+	}
+
+	report := Export(counts, "1.2.3", time.Now())
+	if report.Version != "1.2.3" || report.OS == "" || report.Commands["mcp tools"].Runs != 1 {
+		t.Errorf("Export() = %+v", report)
+	}
+
+	t.Setenv("DO_NOT_TRACK", "1")
+	if Enabled() {
+		t.Error("DO_NOT_TRACK should turn telemetry off")
+	}
+	t.Setenv("DO_NOT_TRACK", "")
+
+	if err = Disable(); err != nil {
+		t.Fatal(err)
+	}
+	if counts, _ = Load(); Enabled() || len(counts.Commands) != 0 {
+		t.Errorf("Disable() kept %v, enabled = %v", counts.Commands, Enabled())
+	}
+}
@@ -0,0 +1,64 @@
+package translate
+
+import "context"
+
+// Descriptions translates, in place, the descriptions of the tools and prompts in a listing such
+// as {"tools": [...]} or {"prompts": [...]}: of the items, of the properties of tool input
+// schemas, and of prompt arguments.
+func (t *Translator) Descriptions(ctx context.Context, listing map[string]any) error {
+	var texts []string
+	var owners []map[string]any
+	collect := func(owner map[string]any) {
+		if description, ok := owner["description"].(string); ok {
+			texts = append(texts, description)
+			owners = append(owners, owner)
+		}
+	}
+
+	for _, key := range []string{"tools", "prompts"} {
+		items, _ := listing[key].([]any)
+		for _, item := range items {
+			entry, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			collect(entry)
+			if schema, ok := entry["inputSchema"].(map[string]any); ok {
+				walkSchema(schema, collect)
+			}
+			arguments, _ := entry["arguments"].([]any)
+			for _, argument := range arguments {
+				if argument, ok := argument.(map[string]any); ok {
+					collect(argument)
+				}
+			}
+		}
+	}
+
+	if len(texts) == 0 {
+		return nil
+	}
+	translated, err := t.Translate(ctx, texts)
+	if err != nil {
+		return err
+	}
+	for i, owner := range owners {
+		owner["description"] = translated[i]
+	}
+	return nil
+}
+
+// walkSchema calls collect for the schemas of the properties and items of schema, recursively.
+func walkSchema(schema map[string]any, collect func(map[string]any)) {
+	properties, _ := schema["properties"].(map[string]any)
+	for _, property := range properties {
+		if property, ok := property.(map[string]any); ok {
+			collect(property)
+			walkSchema(property, collect)
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		collect(items)
+		walkSchema(items, collect)
+	}
+}
@@ -0,0 +1,178 @@
+// Package translate renders the descriptions of tools and prompts in the user's language, with
+// a static glossary of translations and, for texts it lacks, a language model.
+package translate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/f/mcptools/pkg/llm"
+	"gopkg.in/yaml.v3"
+)
+
+// Translator translates texts into a language.
+type Translator struct {
+	// Lang is the language to translate into, e.g. de or pt-BR.
+	Lang string
+	// Glossary maps texts to their translations, used as they are.
+	Glossary map[string]string
+	// Model translates the texts missing from the glossary. Without one they are left as they are.
+	Model llm.Completer
+	// CachePath is a file keeping the translations of the model between runs, if set.
+	CachePath string
+	// CacheKey distinguishes translations of different models in the same cache.
+	CacheKey string
+}
+
+// Language returns the language selected by spec: the spec itself, or for auto the language of
+// the locale in LC_ALL, LC_MESSAGES or LANG, e.g. de for de_DE.UTF-8. It is empty if the locale
+// is unset or C.
+func Language(spec string) string {
+	if spec != "auto" {
+		return spec
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
+			continue
+		}
+		locale, _, _ = strings.Cut(locale, ".")
+		locale, _, _ = strings.Cut(locale, "_")
+		if locale == "C" || locale == "POSIX" {
+			return ""
+		}
+		return locale
+	}
+	return ""
+}
+
+// LoadGlossary reads a glossary: a JSON or YAML object mapping texts to their translations.
+func LoadGlossary(path string) (map[string]string, error) {
+	// #nosec G304 - the glossary path is given by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary: %w", err)
+	}
+	glossary := map[string]string{}
+	if err = yaml.Unmarshal(data, &glossary); err != nil {
+		return nil, fmt.Errorf("invalid glossary %s: %w", path, err)
+	}
+	return glossary, nil
+}
+
+// GetCachePath returns the path to the translations cache in the user's home directory.
+func GetCachePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".mcpt", "translations.json"), nil
+}
+
+// Translate returns the translations of texts, in the same order. Texts found neither in the
+// glossary nor the cache are translated by the model in a single request.
+func (t *Translator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	translated := make([]string, len(texts))
+	cache := t.loadCache()
+
+	var missing []string
+	var missingIdx []int
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			translated[i] = text
+			continue
+		}
+		if v, ok := t.Glossary[text]; ok {
+			translated[i] = v
+			continue
+		}
+		if v, ok := cache[t.cacheKey(text)]; ok {
+			translated[i] = v
+			continue
+		}
+		translated[i] = text
+		missing = append(missing, text)
+		missingIdx = append(missingIdx, i)
+	}
+
+	if len(missing) == 0 || t.Model == nil {
+		return translated, nil
+	}
+
+	computed, err := t.translateWithModel(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, v := range computed {
+		translated[missingIdx[j]] = v
+		cache[t.cacheKey(missing[j])] = v
+	}
+	t.saveCache(cache)
+
+	return translated, nil
+}
+
+const instructions = `You translate the descriptions of tools, their parameters and prompts for a command line
+tool. Reply with only a JSON array of strings: the translations of the given texts, in the same
+order. Keep names of tools, parameters, code, paths and URLs as they are, and keep formatting
+such as line breaks and Markdown.`
+
+func (t *Translator) translateWithModel(ctx context.Context, texts []string) ([]string, error) {
+	input, err := json.MarshalIndent(texts, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	reply, err := t.Model.Complete(ctx, instructions, fmt.Sprintf("Translate into %s:\n%s", t.Lang, input))
+	if err != nil {
+		return nil, err
+	}
+
+	reply = strings.TrimSpace(reply)
+	if start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]"); start >= 0 && end > start {
+		reply = reply[start : end+1]
+	}
+	var translated []string
+	if err = json.Unmarshal([]byte(reply), &translated); err != nil {
+		return nil, fmt.Errorf("the model did not reply with translations: %w", err)
+	}
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("expected %d translations, got %d", len(texts), len(translated))
+	}
+	return translated, nil
+}
+
+func (t *Translator) loadCache() map[string]string {
+	cache := map[string]string{}
+	if t.CachePath == "" {
+		return cache
+	}
+	// #nosec G304 - the cache path is derived from the user's home directory
+	if raw, err := os.ReadFile(t.CachePath); err == nil {
+		_ = json.Unmarshal(raw, &cache)
+	}
+	return cache
+}
+
+func (t *Translator) saveCache(cache map[string]string) {
+	if t.CachePath == "" {
+		return
+	}
+	if raw, err := json.Marshal(cache); err == nil {
+		if mkdirErr := os.MkdirAll(filepath.Dir(t.CachePath), 0o750); mkdirErr == nil {
+			_ = os.WriteFile(t.CachePath, raw, 0o600)
+		}
+	}
+}
+
+// cacheKey identifies the translation of text in the cache.
+func (t *Translator) cacheKey(text string) string {
+	sum := sha256.Sum256([]byte(t.CacheKey + "\x00" + t.Lang + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package translate
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeModel struct {
+	calls  int
+	prompt string
+}
+
+func (f *fakeModel) Complete(_ context.Context, _, prompt string) (string, error) {
+	f.calls++
+	f.prompt = prompt
+	return "```json\n[\"Liest eine Datei\"]\n```", nil
+}
+
+func TestDescriptions(t *testing.T) {
+	model := &fakeModel{}
+	translator := &Translator{
+		Lang:      "de",
+		Glossary:  map[string]string{"Path of the file": "Pfad der Datei", "Line count": "Anzahl der Zeilen"},
+		Model:     model,
+		CachePath: filepath.Join(t.TempDir(), "translations.json"),
+	}
+
+	listing := func() map[string]any {
+		return map[string]any{"tools": []any{map[string]any{
+			"name":        "read_file",
+			"description": "Reads a file",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path":  map[string]any{"type": "string", "description": "Path of the file"},
+					"lines": map[string]any{"type": "number", "description": "Line count"},
+				},
+			},
+		}}}
+	}
+
+	translated := listing()
+	if err := translator.Descriptions(context.Background(), translated); err != nil {
+		t.Fatalf("Descriptions() error = %v", err)
+	}
+	tool := translated["tools"].([]any)[0].(map[string]any)
+	properties := tool["inputSchema"].(map[string]any)["properties"].(map[string]any)
+	if tool["description"] != "Liest eine Datei" || properties["path"].(map[string]any)["description"] != "Pfad der Datei" {
+		t.Errorf("Descriptions() = %v", translated)
+	}
+	if model.calls != 1 || !strings.Contains(model.prompt, "Reads a file") || strings.Contains(model.prompt, "Path of the file") {
+		t.Errorf("model asked %d times with %q, want once for the text missing from the glossary", model.calls, model.prompt)
+	}
+
+	// The translation of the model is cached
+	if err := translator.Descriptions(context.Background(), listing()); err != nil {
+		t.Fatalf("Descriptions() error = %v", err)
+	}
+	if model.calls != 1 {
+		t.Errorf("model asked %d times, want the cached translation used", model.calls)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "pt_BR.UTF-8")
+	if got := Language("auto"); got != "pt" {
+		t.Errorf("Language(auto) = %q, want pt", got)
+	}
+	if got := Language("ja"); got != "ja" {
+		t.Errorf("Language(ja) = %q, want ja", got)
+	}
+	t.Setenv("LANG", "C.UTF-8")
+	if got := Language("auto"); got != "" {
+		t.Errorf("Language(auto) with the C locale = %q, want none", got)
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Package trust guards against stdio servers being swapped for something else between runs, trust
+on first use: the first time a server runs, what its command resolves to is recorded, the
+executable's SHA-256 and, for servers run by a package runner such as npx, the package version
+and registry integrity. Later runs compare against the record. Servers that were pinned with
+mcp trust are refused when they change; others get a warning and their record updated.
+*/
+package trust
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Identity is what a server command resolves to.
+type Identity struct {
+	// Binary is the resolved path of the executable, and SHA256 the hash of its content.
+	Binary string `json:"binary"`
+	SHA256 string `json:"sha256"`
+	// Script is the script an interpreter such as node or python runs, and ScriptSHA256 its hash.
+	Script       string `json:"script,omitempty"`
+	ScriptSHA256 string `json:"script_sha256,omitempty"`
+	// Package is the package a runner such as npx installs, as registry:name@version, and
+	// Integrity the registry's hash of its archive.
+	Package   string `json:"package,omitempty"`
+	Integrity string `json:"integrity,omitempty"`
+}
+
+// Changes describes how other differs from i, one line per field.
+func (i Identity) Changes(other Identity) []string {
+	var changes []string
+	compare := func(what, before, after string) {
+		if before != after {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", what, orNone(before), orNone(after)))
+		}
+	}
+	compare("binary", i.Binary, other.Binary)
+	compare("binary sha256", i.SHA256, other.SHA256)
+	compare("script", i.Script, other.Script)
+	compare("script sha256", i.ScriptSHA256, other.ScriptSHA256)
+	compare("package", i.Package, other.Package)
+	compare("package integrity", i.Integrity, other.Integrity)
+	return changes
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// Record is what was recorded about a server.
+type Record struct {
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Command   string    `json:"command"`
+	Identity
+	// Pinned servers are refused when they change, instead of being warned about.
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// Store maps server names, aliases or command lines, to their records.
+type Store map[string]Record
+
+// GetConfigPath returns the path to the file of records.
+func GetConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "trust.json"), nil
+}
+
+// Load loads the records.
+func Load() (Store, error) {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	store := make(Store)
+	data, err := os.ReadFile(configPath) // #nosec G304 - configPath is generated internally by GetConfigPath
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust records: %w", err)
+	}
+	if err = json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("failed to parse trust records: %w", err)
+	}
+	return store, nil
+}
+
+// Save saves the records.
+func (s Store) Save() error {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(configPath), 0o750); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(configPath, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write trust records: %w", err)
+	}
+	return nil
+}
+
+// Outcome is the result of checking a server against its record.
+type Outcome int
+
+// Outcomes of Check.
+const (
+	// New servers were recorded on first use.
+	New Outcome = iota
+	// Unchanged servers match their record.
+	Unchanged
+	// Changed servers differ from their record, which was updated.
+	Changed
+	// Refused servers were pinned and differ from their record, which was kept.
+	Refused
+)
+
+// Check resolves a server's command and compares it with the record of name, recording it if
+// there is none. Changes are returned for Changed and Refused servers.
+func (s Store) Check(ctx context.Context, name, command string, args []string) (Outcome, []string, error) {
+	identity, err := Resolve(ctx, command, args)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	now := time.Now().UTC()
+	record, found := s[name]
+	if !found {
+		s[name] = Record{Command: commandLine(command, args), Identity: identity, FirstSeen: now, LastSeen: now}
+		return New, nil, nil
+	}
+	changes := record.Changes(identity)
+	if len(changes) > 0 && record.Pinned {
+		return Refused, changes, nil
+	}
+
+	record.Identity = identity
+	record.Command = commandLine(command, args)
+	record.LastSeen = now
+	s[name] = record
+	if len(changes) > 0 {
+		return Changed, changes, nil
+	}
+	return Unchanged, nil, nil
+}
+
+// Pin records a server's current identity and pins it.
+func (s Store) Pin(ctx context.Context, name, command string, args []string) (Record, error) {
+	identity, err := Resolve(ctx, command, args)
+	if err != nil {
+		return Record{}, err
+	}
+	now := time.Now().UTC()
+	record, found := s[name]
+	if !found {
+		record.FirstSeen = now
+	}
+	record.Identity = identity
+	record.Command = commandLine(command, args)
+	record.LastSeen = now
+	record.Pinned = true
+	s[name] = record
+	return record, nil
+}
+
+func commandLine(command string, args []string) string {
+	return strings.TrimSpace(command + " " + strings.Join(args, " "))
+}
+
+// Resolve finds what a server command runs: its executable, the script an interpreter runs,
+// and the package a runner installs.
+func Resolve(ctx context.Context, command string, args []string) (Identity, error) {
+	binary, err := exec.LookPath(command)
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to resolve %s: %w", command, err)
+	}
+	if binary, err = filepath.Abs(binary); err != nil {
+		return Identity{}, err
+	}
+	if resolved, linkErr := filepath.EvalSymlinks(binary); linkErr == nil {
+		binary = resolved
+	}
+	identity := Identity{Binary: binary}
+	if identity.SHA256, err = hashFile(binary); err != nil {
+		return Identity{}, err
+	}
+
+	base := strings.TrimSuffix(strings.ToLower(filepath.Base(command)), ".exe")
+	operand := firstOperand(args)
+	switch {
+	case npmRunners[base]:
+		spec := packageSpec(args)
+		if spec == "" {
+			break
+		}
+		if identity.Package, identity.Integrity, err = resolveNPM(ctx, spec); err != nil {
+			return Identity{}, err
+		}
+	case interpreters[base] && operand != "":
+		if info, statErr := os.Stat(operand); statErr == nil && info.Mode().IsRegular() {
+			if identity.Script, err = filepath.Abs(operand); err != nil {
+				return Identity{}, err
+			}
+			if identity.ScriptSHA256, err = hashFile(operand); err != nil {
+				return Identity{}, err
+			}
+		}
+	}
+	return identity, nil
+}
+
+// npmRunners install and run npm packages.
+var npmRunners = map[string]bool{"npx": true, "bunx": true}
+
+// interpreters run the script given as their first operand.
+var interpreters = map[string]bool{
+	"node": true, "deno": true, "bun": true, "python": true, "python3": true, "ruby": true, "perl": true, "php": true,
+}
+
+// firstOperand returns the first argument that is not an option.
+func firstOperand(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
+
+// packageSpec returns the package an npx command line runs: the value of --package, or the
+// first operand.
+func packageSpec(args []string) string {
+	for i, arg := range args {
+		switch {
+		case (arg == "-p" || arg == "--package") && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--package="):
+			return strings.TrimPrefix(arg, "--package=")
+		}
+	}
+	return firstOperand(args)
+}
+
+// npmView runs npm view, swapped out in tests.
+var npmView = func(ctx context.Context, spec string) ([]byte, error) {
+	// #nosec G204 - the package spec comes from the server command the user runs
+	return exec.CommandContext(ctx, "npm", "view", "--json", spec, "version", "dist.integrity").Output()
+}
+
+// resolveNPM asks the registry which version a package spec resolves to, and the integrity hash
+// of its archive.
+func resolveNPM(ctx context.Context, spec string) (string, string, error) {
+	out, err := npmView(ctx, spec)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve npm package %s: %w", spec, err)
+	}
+	var view struct {
+		Version   string `json:"version"`
+		Integrity string `json:"dist.integrity"`
+	}
+	// Specs matching several versions return a list; npx runs the highest, which npm lists last
+	var views []json.RawMessage
+	if json.Unmarshal(out, &views) == nil && len(views) > 0 {
+		out = views[len(views)-1]
+	}
+	if err = json.Unmarshal(out, &view); err != nil || view.Version == "" {
+		return "", "", fmt.Errorf("failed to resolve npm package %s: unexpected npm view output", spec)
+	}
+
+	name := spec
+	if at := strings.LastIndex(spec, "@"); at > 0 {
+		name = spec[:at]
+	}
+	return "npm:" + name + "@" + view.Version, view.Integrity, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path) // #nosec G304 - the path is the server's own executable or script
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
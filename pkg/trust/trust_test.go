@@ -0,0 +1,120 @@
+package trust
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeExecutable(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	server := filepath.Join(dir, "server")
+	writeExecutable(t, server, "#!/bin/sh\necho v1\n")
+
+	store := Store{}
+	ctx := context.Background()
+	if outcome, _, err := store.Check(ctx, "srv", server, nil); err != nil || outcome != New {
+		t.Fatalf("first run: %v, %v", outcome, err)
+	}
+	if outcome, _, err := store.Check(ctx, "srv", server, nil); err != nil || outcome != Unchanged {
+		t.Fatalf("second run: %v, %v", outcome, err)
+	}
+
+	writeExecutable(t, server, "#!/bin/sh\necho v2\n")
+	outcome, changes, err := store.Check(ctx, "srv", server, nil)
+	if err != nil || outcome != Changed || len(changes) != 1 || !strings.HasPrefix(changes[0], "binary sha256:") {
+		t.Fatalf("changed binary: %v, %v, %v", outcome, changes, err)
+	}
+	if outcome, _, _ = store.Check(ctx, "srv", server, nil); outcome != Unchanged {
+		t.Errorf("expected the change to be accepted, got %v", outcome)
+	}
+
+	if _, err = store.Pin(ctx, "srv", server, nil); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+	pinned := store["srv"].SHA256
+	writeExecutable(t, server, "#!/bin/sh\necho v3\n")
+	if outcome, _, _ = store.Check(ctx, "srv", server, nil); outcome != Refused {
+		t.Errorf("expected a pinned server that changed to be refused, got %v", outcome)
+	}
+	if store["srv"].SHA256 != pinned {
+		t.Error("the record of a refused server was updated")
+	}
+}
+
+func TestResolveScript(t *testing.T) {
+	dir := t.TempDir()
+	interpreter := filepath.Join(dir, "node")
+	writeExecutable(t, interpreter, "#!/bin/sh\n")
+	script := filepath.Join(dir, "index.js")
+	writeExecutable(t, script, "console.log(1)\n")
+
+	identity, err := Resolve(context.Background(), interpreter, []string{"--no-warnings", script, "--port", "1"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if identity.Script != script || identity.ScriptSHA256 == "" {
+		t.Errorf("expected the script to be hashed, got %+v", identity)
+	}
+}
+
+func TestResolveNPM(t *testing.T) {
+	dir := t.TempDir()
+	npx := filepath.Join(dir, "npx")
+	writeExecutable(t, npx, "#!/bin/sh\n")
+
+	var asked []string
+	original := npmView
+	t.Cleanup(func() { npmView = original })
+	npmView = func(_ context.Context, spec string) ([]byte, error) {
+		asked = append(asked, spec)
+		switch spec {
+		case "@scope/server":
+			return []byte(`{"version": "1.2.3", "dist.integrity": "sha512-abc"}`), nil
+		case "tool@^2":
+			return []byte(`[{"version": "2.0.0", "dist.integrity": "sha512-old"}, {"version": "2.1.0", "dist.integrity": "sha512-new"}]`), nil
+		}
+		return nil, errors.New("exit status 1")
+	}
+
+	identity, err := Resolve(context.Background(), npx, []string{"-y", "@scope/server", "/tmp"})
+	if err != nil || identity.Package != "npm:@scope/server@1.2.3" || identity.Integrity != "sha512-abc" {
+		t.Errorf("unexpected identity %+v, %v", identity, err)
+	}
+	identity, err = Resolve(context.Background(), npx, []string{"--package", "tool@^2", "tool-server"})
+	if err != nil || identity.Package != "npm:tool@2.1.0" || identity.Integrity != "sha512-new" {
+		t.Errorf("unexpected identity %+v, %v", identity, err)
+	}
+	if _, err = Resolve(context.Background(), npx, []string{"-y", "missing"}); err == nil {
+		t.Error("expected an error for a package npm cannot resolve")
+	}
+	if strings.Join(asked, ",") != "@scope/server,tool@^2,missing" {
+		t.Errorf("unexpected specs %v", asked)
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := Load()
+	if err != nil || len(store) != 0 {
+		t.Fatalf("Load without a file: %v, %v", store, err)
+	}
+	store["fs"] = Record{Command: "npx fs", Pinned: true, Identity: Identity{Binary: "/usr/bin/npx", SHA256: "ab"}}
+	if err = store.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load()
+	if err != nil || !loaded["fs"].Pinned || loaded["fs"].SHA256 != "ab" {
+		t.Errorf("Load: %+v, %v", loaded, err)
+	}
+}
@@ -0,0 +1,360 @@
+package usage
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// Statuses of calls, as stored in the status column.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// schema creates the tables of the usage database. Times are stored as UTC text of fixed width,
+// so they sort chronologically and compare with dates such as '2026-10-01'.
+const schema = `
+CREATE TABLE IF NOT EXISTS calls (
+	id          INTEGER PRIMARY KEY,
+	time        TEXT    NOT NULL,
+	server      TEXT    NOT NULL,
+	tool        TEXT    NOT NULL,
+	duration_ms INTEGER NOT NULL,
+	status      TEXT    NOT NULL,
+	slo_ms      INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS calls_time ON calls (time);
+CREATE INDEX IF NOT EXISTS calls_tool ON calls (tool);
+CREATE TABLE IF NOT EXISTS settings (
+	name  TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);`
+
+// timeLayout is the layout of the time column.
+const timeLayout = "2006-01-02T15:04:05.000Z"
+
+// pruneInterval is how often long-running processes apply the retention policy.
+const pruneInterval = time.Hour
+
+// database is an open usage database.
+type database struct {
+	db     *sql.DB
+	pruned time.Time
+}
+
+// databases are kept open for the life of the process, by path.
+var (
+	databases = map[string]*database{}
+	dbMutex   sync.Mutex
+)
+
+// GetDBPath returns the path to the usage database.
+func GetDBPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "usage.db"), nil
+}
+
+// open returns the usage database, creating it and importing the usage.jsonl log of earlier
+// versions if needed. The database is shared with other processes, such as a bridge recording
+// calls while the CLI queries them: it is in WAL mode and waits for locks instead of failing.
+// The caller must hold dbMutex.
+func open() (*database, error) {
+	path, err := GetDBPath()
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := databases[path]; ok {
+		return d, nil
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	query := url.Values{}
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "synchronous(NORMAL)")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage database: %w", err)
+	}
+	if _, err = db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open usage database: %w", err)
+	}
+	if err = migrate(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open usage database: %w", err)
+	}
+	_ = os.Chmod(path, 0o600)
+
+	if err = importLog(db, filepath.Join(filepath.Dir(path), "usage.jsonl")); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	d := &database{db: db}
+	databases[path] = d
+	return d, nil
+}
+
+// migrate adds the columns of later versions to a calls table created by an earlier one.
+func migrate(db *sql.DB) error {
+	var found int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('calls') WHERE name = 'slo_ms'").Scan(&found); err != nil {
+		return err
+	}
+	if found > 0 {
+		return nil
+	}
+	_, err := db.Exec("ALTER TABLE calls ADD COLUMN slo_ms INTEGER NOT NULL DEFAULT 0")
+	if err != nil && strings.Contains(err.Error(), "duplicate column") {
+		// Another process migrated the table first
+		return nil
+	}
+	return err
+}
+
+// importLog moves the records of a usage.jsonl log into the database. The log is renamed first,
+// so of several processes opening the database at once only one imports it.
+func importLog(db *sql.DB, path string) error {
+	imported := path + ".imported"
+	if err := os.Rename(path, imported); err != nil {
+		return nil
+	}
+
+	// #nosec G304 - the log path is derived from the user's home directory
+	file, err := os.Open(imported)
+	if err != nil {
+		return fmt.Errorf("failed to import usage log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to import usage log: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+		if err = insert(tx, record); err != nil {
+			return fmt.Errorf("failed to import usage log: %w", err)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("failed to import usage log: %w", err)
+	}
+	return tx.Commit()
+}
+
+// execer is a database or a transaction.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func insert(db execer, record Record) error {
+	status := StatusOK
+	if record.Error {
+		status = StatusError
+	}
+	_, err := db.Exec("INSERT INTO calls (time, server, tool, duration_ms, status, slo_ms) VALUES (?, ?, ?, ?, ?, ?)",
+		record.Time.UTC().Format(timeLayout), record.Server, record.Tool, record.DurationMS, status, record.SLOMS)
+	return err
+}
+
+// Append adds a record to the usage database, applying the retention policy if it was not
+// applied recently.
+func Append(record Record) error {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+
+	d, err := open()
+	if err != nil {
+		return err
+	}
+	if err = insert(d.db, record); err != nil {
+		return fmt.Errorf("failed to record tool call: %w", err)
+	}
+	if time.Since(d.pruned) > pruneInterval {
+		d.pruned = time.Now()
+		return prune(d.db)
+	}
+	return nil
+}
+
+// Load returns all records, oldest first.
+func Load() ([]Record, error) {
+	return query(context.Background(), "", "id", 0)
+}
+
+// Query returns the records matching where, an SQL expression over the columns of the calls
+// table: time, server, tool, duration_ms, status ('ok' or 'error') and slo_ms (0 for tools
+// without a latency objective), e.g. tool='read_file' AND status='error'. The newest limit records are returned, newest first;
+// limit 0 returns all of them. The query cannot modify the database.
+func Query(ctx context.Context, where string, limit int) ([]Record, error) {
+	return query(ctx, where, "time DESC, id DESC", limit)
+}
+
+func query(ctx context.Context, where, order string, limit int) ([]Record, error) {
+	dbMutex.Lock()
+	d, err := open()
+	dbMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	// The expression is the user's, so it runs on a connection that rejects writes
+	if _, err = conn.ExecContext(ctx, "PRAGMA query_only = 1"); err != nil {
+		return nil, err
+	}
+	defer func() { _, _ = conn.ExecContext(context.WithoutCancel(ctx), "PRAGMA query_only = 0") }()
+
+	statement := "SELECT time, server, tool, duration_ms, status, slo_ms FROM calls"
+	if strings.TrimSpace(where) != "" {
+		statement += " WHERE " + where
+	}
+	statement += " ORDER BY " + order
+	if limit > 0 {
+		statement += " LIMIT " + strconv.Itoa(limit)
+	}
+
+	rows, err := conn.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	records := []Record{}
+	for rows.Next() {
+		var (
+			record       Record
+			stamp, state string
+		)
+		if err = rows.Scan(&stamp, &record.Server, &record.Tool, &record.DurationMS, &state, &record.SLOMS); err != nil {
+			return nil, err
+		}
+		record.Time, _ = time.Parse(timeLayout, stamp)
+		record.Error = state == StatusError
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return records, nil
+}
+
+// Reset deletes all records.
+func Reset() error {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+
+	d, err := open()
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec("DELETE FROM calls")
+	return err
+}
+
+// Retention returns how long records are kept; 0 keeps them forever.
+func Retention() (time.Duration, error) {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+
+	d, err := open()
+	if err != nil {
+		return 0, err
+	}
+	return retention(d.db)
+}
+
+func retention(db *sql.DB) (time.Duration, error) {
+	var value string
+	err := db.QueryRow("SELECT value FROM settings WHERE name = 'retention'").Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	age, _ := time.ParseDuration(value)
+	return age, nil
+}
+
+// SetRetention keeps records for age, deleting older ones now and from then on; 0 keeps them
+// forever.
+func SetRetention(age time.Duration) error {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+
+	d, err := open()
+	if err != nil {
+		return err
+	}
+	if age <= 0 {
+		_, err = d.db.Exec("DELETE FROM settings WHERE name = 'retention'")
+		return err
+	}
+	if _, err = d.db.Exec("INSERT INTO settings (name, value) VALUES ('retention', ?) "+
+		"ON CONFLICT (name) DO UPDATE SET value = excluded.value", age.String()); err != nil {
+		return err
+	}
+	return prune(d.db)
+}
+
+// prune deletes the records older than the retention policy allows.
+func prune(db *sql.DB) error {
+	age, err := retention(db)
+	if err != nil || age <= 0 {
+		return err
+	}
+	cutoff := time.Now().Add(-age).UTC().Format(timeLayout)
+	if _, err = db.Exec("DELETE FROM calls WHERE time < ?", cutoff); err != nil {
+		return fmt.Errorf("failed to apply retention: %w", err)
+	}
+	return nil
+}
+
+// ParseRetention parses how long to keep records: a number of days such as 90d, a duration
+// such as 12h, or off to keep them forever.
+func ParseRetention(spec string) (time.Duration, error) {
+	if spec == "off" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(spec, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	age, err := time.ParseDuration(spec)
+	if err != nil || age <= 0 {
+		return 0, fmt.Errorf("invalid retention %q: expected days such as 90d, a duration such as 12h, or off", spec)
+	}
+	return age, nil
+}
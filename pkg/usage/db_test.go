@@ -0,0 +1,130 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+
+	now := time.Now()
+	for i, record := range []Record{
+		{Server: "fs", Tool: "read_file", DurationMS: 10},
+		{Server: "fs", Tool: "read_file", DurationMS: 30, Error: true},
+		{Server: "gh", Tool: "create_issue", DurationMS: 100, Error: true},
+	} {
+		record.Time = now.Add(time.Duration(i) * time.Second)
+		if err := Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := Query(ctx, "tool='read_file' AND status='error'", 0)
+	if err != nil || len(records) != 1 || records[0].DurationMS != 30 || !records[0].Error {
+		t.Errorf("Query() = %+v, %v; want the failed read_file call", records, err)
+	}
+
+	records, err = Query(ctx, "", 2)
+	if err != nil || len(records) != 2 || records[0].Tool != "create_issue" {
+		t.Errorf("Query(limit 2) = %+v, %v; want the 2 newest calls, newest first", records, err)
+	}
+
+	if _, err = Query(ctx, "no_such_column = 1", 0); err == nil {
+		t.Error("expected an error for an invalid query")
+	}
+	_, _ = Query(ctx, "1; DELETE FROM calls", 0)
+	if records, _ = Load(); len(records) != 3 {
+		t.Errorf("query deleted records, %d left", len(records))
+	}
+}
+
+func TestRetention(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := Append(Record{Time: time.Now().Add(-48 * time.Hour), Server: "fs", Tool: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(Record{Time: time.Now(), Server: "fs", Tool: "new"}); err != nil {
+		t.Fatal(err)
+	}
+
+	age, err := ParseRetention("1d")
+	if err != nil || age != 24*time.Hour {
+		t.Fatalf("ParseRetention(1d) = %v, %v", age, err)
+	}
+	if err = SetRetention(age); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Retention(); got != age {
+		t.Errorf("Retention() = %v, want %v", got, age)
+	}
+
+	records, err := Load()
+	if err != nil || len(records) != 1 || records[0].Tool != "new" {
+		t.Errorf("Load() = %+v, %v; want only the recent call", records, err)
+	}
+	if _, err = ParseRetention("forever"); err == nil {
+		t.Error("expected an error for an invalid retention")
+	}
+}
+
+func TestImportLog(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".mcpt")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	log := `{"time":"2026-01-02T03:04:05Z","server":"fs","tool":"read_file","durationMs":12,"error":true}` + "\nnot json\n"
+	if err := os.WriteFile(filepath.Join(dir, "usage.jsonl"), []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Load()
+	if err != nil || len(records) != 1 || records[0].Tool != "read_file" || !records[0].Error ||
+		!records[0].Time.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Load() = %+v, %v; want the imported call", records, err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "usage.jsonl")); !os.IsNotExist(err) {
+		t.Error("expected the imported log to be renamed")
+	}
+}
+
+func TestOpenMigratesEarlierDatabases(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	path, err := GetDBPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec(`CREATE TABLE calls (id INTEGER PRIMARY KEY, time TEXT NOT NULL, server TEXT NOT NULL,
+		tool TEXT NOT NULL, duration_ms INTEGER NOT NULL, status TEXT NOT NULL);
+		INSERT INTO calls (time, server, tool, duration_ms, status) VALUES ('2026-10-01T00:00:00.000Z', 'fs', 'read_file', 10, 'ok')`); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	if err = Append(Record{Time: time.Now(), Server: "fs", Tool: "search", DurationMS: 900, SLOMS: 500}); err != nil {
+		t.Fatalf("Append() to an earlier database error = %v", err)
+	}
+	records, err := Query(context.Background(), "slo_ms > 0 AND duration_ms > slo_ms", 0)
+	if err != nil || len(records) != 1 || records[0].Tool != "search" || !records[0].OverSLO() {
+		t.Errorf("Query() of SLO breaches = %+v, %v; want the search call", records, err)
+	}
+	if records, _ = Load(); len(records) != 2 {
+		t.Errorf("Load() = %+v, want the earlier call kept", records)
+	}
+}
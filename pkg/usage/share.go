@@ -0,0 +1,206 @@
+package usage
+
+import (
+	"cmp"
+	crand "crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ShareVersion is the version of the Share format.
+const ShareVersion = 1
+
+// Defaults of ShareOptions and Pool.
+const (
+	DefaultBucket          = 5
+	DefaultEpsilon         = 1.0
+	DefaultMinContributors = 3
+)
+
+// ErrShareVersion is returned for shares written by an incompatible version.
+var ErrShareVersion = errors.New("unsupported usage share version")
+
+// Share is the anonymized usage of one developer, meant to be pooled with those of others. It
+// holds no user, host or time of individual calls: only how often each tool was called over a
+// number of days, with noise added and rounded to a bucket.
+type Share struct {
+	Version int         `json:"version"`
+	Days    int         `json:"days"`
+	Bucket  int         `json:"bucket"`
+	Epsilon float64     `json:"epsilon"`
+	Tools   []SharedUse `json:"tools"`
+}
+
+// SharedUse is the bucketed call and error counts of a tool.
+type SharedUse struct {
+	Server string `json:"server"`
+	Tool   string `json:"tool"`
+	Calls  int    `json:"calls"`
+	Errors int    `json:"errors"`
+}
+
+// ShareOptions configures Anonymize.
+type ShareOptions struct {
+	// Since drops calls made before it. Zero keeps every call.
+	Since time.Time
+	// Bucket rounds counts to a multiple of it; counts rounded to zero are dropped.
+	Bucket int
+	// Epsilon is the privacy budget of the Laplace noise added to each count: smaller values
+	// add more noise. Zero adds none.
+	Epsilon float64
+	// Rand is the source of the noise, a cryptographically seeded one if nil.
+	Rand *rand.Rand
+}
+
+// Anonymize aggregates records into a share: servers are reduced to a label without paths,
+// arguments or credentials, and counts get Laplace noise of scale 1/Epsilon before being rounded
+// to a multiple of Bucket, so no single call can be told from the share.
+func Anonymize(records []Record, opts ShareOptions) Share {
+	if opts.Bucket <= 0 {
+		opts.Bucket = 1
+	}
+	if opts.Rand == nil {
+		var seed [32]byte
+		_, _ = crand.Read(seed[:])
+		opts.Rand = rand.New(rand.NewChaCha8(seed))
+	}
+
+	type key struct{ server, tool string }
+	counts := map[key]*SharedUse{}
+	var first time.Time
+	for _, r := range records {
+		if r.Time.Before(opts.Since) {
+			continue
+		}
+		if first.IsZero() || r.Time.Before(first) {
+			first = r.Time
+		}
+		k := key{ServerLabel(r.Server), r.Tool}
+		use, ok := counts[k]
+		if !ok {
+			use = &SharedUse{Server: k.server, Tool: k.tool}
+			counts[k] = use
+		}
+		use.Calls++
+		if r.Error {
+			use.Errors++
+		}
+	}
+
+	share := Share{Version: ShareVersion, Bucket: opts.Bucket, Epsilon: opts.Epsilon, Tools: []SharedUse{}}
+	if !opts.Since.IsZero() {
+		first = opts.Since
+	}
+	if !first.IsZero() {
+		share.Days = int(math.Ceil(time.Since(first).Hours() / 24))
+	}
+	for _, use := range counts {
+		use.Calls = bucket(float64(use.Calls)+laplace(opts.Rand, opts.Epsilon), opts.Bucket)
+		use.Errors = min(bucket(float64(use.Errors)+laplace(opts.Rand, opts.Epsilon), opts.Bucket), use.Calls)
+		if use.Calls > 0 {
+			share.Tools = append(share.Tools, *use)
+		}
+	}
+	sortUses(share.Tools)
+	return share
+}
+
+// laplace draws from the Laplace distribution of scale 1/epsilon, or returns 0 if epsilon is 0.
+func laplace(r *rand.Rand, epsilon float64) float64 {
+	if epsilon <= 0 {
+		return 0
+	}
+	u := r.Float64() - 0.5
+	return -math.Copysign(1, u) * math.Log(1-2*math.Abs(u)) / epsilon
+}
+
+// bucket rounds n to the nearest multiple of size, and negative values to zero.
+func bucket(n float64, size int) int {
+	return max(0, int(math.Round(n/float64(size)))*size)
+}
+
+// ServerLabel reduces the server name of a record, an alias, URL or command line, to a label
+// that identifies the server but not the user: the host of URLs, the package a runner such as
+// npx installs, or else the name of the executable.
+func ServerLabel(server string) string {
+	if u, err := url.Parse(server); err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https") {
+		return u.Hostname()
+	}
+	fields := strings.Fields(server)
+	if len(fields) == 0 {
+		return ""
+	}
+	command := strings.TrimSuffix(strings.ToLower(filepath.Base(fields[0])), ".exe")
+	if runners[command] {
+		for _, arg := range fields[1:] {
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			// Drop the version, keeping the @ of scoped packages
+			if at := strings.LastIndex(arg, "@"); at > 0 {
+				arg = arg[:at]
+			}
+			return arg
+		}
+	}
+	return command
+}
+
+// runners install and run the package given as their first operand.
+var runners = map[string]bool{"npx": true, "bunx": true, "pnpx": true, "uvx": true, "pipx": true}
+
+// PooledUse is the usage of a tool across contributors.
+type PooledUse struct {
+	SharedUse
+	Contributors int     `json:"contributors"`
+	ErrorRate    float64 `json:"errorRate"`
+}
+
+// Pool sums shares from several developers. Tools used by fewer than minContributors of them
+// are left out, so a tool only one person uses cannot point back at them.
+func Pool(shares []Share, minContributors int) ([]PooledUse, error) {
+	type key struct{ server, tool string }
+	pooled := map[key]*PooledUse{}
+	for i, share := range shares {
+		if share.Version != ShareVersion {
+			return nil, fmt.Errorf("share %d: %w %d", i+1, ErrShareVersion, share.Version)
+		}
+		for _, use := range share.Tools {
+			k := key{use.Server, use.Tool}
+			p, ok := pooled[k]
+			if !ok {
+				p = &PooledUse{SharedUse: SharedUse{Server: use.Server, Tool: use.Tool}}
+				pooled[k] = p
+			}
+			p.Calls += use.Calls
+			p.Errors += use.Errors
+			p.Contributors++
+		}
+	}
+
+	uses := make([]PooledUse, 0, len(pooled))
+	for _, p := range pooled {
+		if p.Contributors < minContributors || p.Calls == 0 {
+			continue
+		}
+		p.ErrorRate = float64(p.Errors) / float64(p.Calls)
+		uses = append(uses, *p)
+	}
+	slices.SortFunc(uses, func(a, b PooledUse) int {
+		return cmp.Or(cmp.Compare(b.Calls, a.Calls), cmp.Compare(a.Server, b.Server), cmp.Compare(a.Tool, b.Tool))
+	})
+	return uses, nil
+}
+
+func sortUses(uses []SharedUse) {
+	slices.SortFunc(uses, func(a, b SharedUse) int {
+		return cmp.Or(cmp.Compare(b.Calls, a.Calls), cmp.Compare(a.Server, b.Server), cmp.Compare(a.Tool, b.Tool))
+	})
+}
@@ -0,0 +1,239 @@
+// Package usage records which tools are called, for local, opt-in usage analytics.
+//
+// Recording is off until enabled with Enable. Records are stored in the SQLite database
+// $HOME/.mcpt/usage.db and never leave the machine.
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Record is a single tool call. SLOMS is the latency objective of the tool when the call was
+// made, 0 if it had none.
+type Record struct {
+	Time       time.Time `json:"time"`
+	Server     string    `json:"server"`
+	Tool       string    `json:"tool"`
+	DurationMS int64     `json:"durationMs"`
+	SLOMS      int64     `json:"sloMs,omitempty"`
+	Error      bool      `json:"error,omitempty"`
+}
+
+// OverSLO reports whether the call took longer than the latency objective of its tool.
+func (r Record) OverSLO() bool {
+	return r.SLOMS > 0 && r.DurationMS > r.SLOMS
+}
+
+// ToolSummary aggregates the calls of a single tool. SLOBreaches counts the calls slower than
+// the latency objective of the tool.
+type ToolSummary struct {
+	Server       string  `json:"server"`
+	Tool         string  `json:"tool"`
+	Calls        int     `json:"calls"`
+	Errors       int     `json:"errors"`
+	ErrorRate    float64 `json:"errorRate"`
+	AvgLatencyMS float64 `json:"avgLatencyMs"`
+	SLOBreaches  int     `json:"sloBreaches"`
+}
+
+// configDir returns the mcptools configuration directory.
+func configDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt"), nil
+}
+
+// enabledPath returns the path of the marker file that enables recording.
+func enabledPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "usage.enabled"), nil
+}
+
+// Enabled reports whether usage recording has been enabled.
+func Enabled() bool {
+	path, err := enabledPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Enable turns on usage recording.
+func Enable() error {
+	path, err := enabledPath()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(path, nil, 0o600)
+}
+
+// Disable turns off usage recording. Existing records are kept.
+func Disable() error {
+	path, err := enabledPath()
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Summarize aggregates records per server and tool, most called first.
+func Summarize(records []Record) []ToolSummary {
+	type key struct{ server, tool string }
+	totals := map[key]*ToolSummary{}
+	latency := map[key]int64{}
+
+	for _, r := range records {
+		k := key{r.Server, r.Tool}
+		s, ok := totals[k]
+		if !ok {
+			s = &ToolSummary{Server: r.Server, Tool: r.Tool}
+			totals[k] = s
+		}
+		s.Calls++
+		if r.Error {
+			s.Errors++
+		}
+		if r.OverSLO() {
+			s.SLOBreaches++
+		}
+		latency[k] += r.DurationMS
+	}
+
+	summaries := make([]ToolSummary, 0, len(totals))
+	for k, s := range totals {
+		s.ErrorRate = float64(s.Errors) / float64(s.Calls)
+		s.AvgLatencyMS = float64(latency[k]) / float64(s.Calls)
+		summaries = append(summaries, *s)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Calls != summaries[j].Calls {
+			return summaries[i].Calls > summaries[j].Calls
+		}
+		if summaries[i].Server != summaries[j].Server {
+			return summaries[i].Server < summaries[j].Server
+		}
+		return summaries[i].Tool < summaries[j].Tool
+	})
+
+	return summaries
+}
+
+// Transport records every tools/call request sent through it.
+type Transport struct {
+	transport.Interface
+	record   func(Record) error
+	server   string
+	slo      func(tool string) time.Duration
+	warnings io.Writer
+}
+
+// TransportOption configures a Transport.
+type TransportOption func(*Transport)
+
+// WithSLO sets the latency objectives of the tools, recorded with each call. Calls slower than
+// the objective of their tool are reported on warnings.
+func WithSLO(slo func(tool string) time.Duration, warnings io.Writer) TransportOption {
+	return func(t *Transport) {
+		t.slo = slo
+		t.warnings = warnings
+	}
+}
+
+// WithoutRecording keeps the tool calls out of the usage log, for transports that only report
+// calls slower than their objective.
+func WithoutRecording() TransportOption {
+	return func(t *Transport) {
+		t.record = func(Record) error { return nil }
+	}
+}
+
+// NewTransport wraps inner so that tool calls to server are appended to the usage log.
+func NewTransport(inner transport.Interface, server string, opts ...TransportOption) *Transport {
+	t := &Transport{
+		Interface: inner,
+		record:    Append,
+		server:    server,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// SendRequest forwards the request and records it if it is a tool call.
+func (t *Transport) SendRequest(ctx context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	if request.Method != string(mcp.MethodToolsCall) {
+		return t.Interface.SendRequest(ctx, request)
+	}
+
+	start := time.Now()
+	response, err := t.Interface.SendRequest(ctx, request)
+	duration := time.Since(start)
+
+	record := Record{
+		Time:       start,
+		Server:     t.server,
+		Tool:       toolName(request.Params),
+		DurationMS: duration.Milliseconds(),
+		Error:      err != nil || response.Error != nil || isErrorResult(response.Result),
+	}
+	if t.slo != nil {
+		if slo := t.slo(record.Tool); slo > 0 {
+			record.SLOMS = slo.Milliseconds()
+			if duration > slo && t.warnings != nil {
+				fmt.Fprintf(t.warnings, "Warning: %s of %s took %s, over its SLO of %s\n",
+					record.Tool, t.server, duration.Round(time.Millisecond), slo)
+			}
+		}
+	}
+	// Analytics must never break a call, so recording errors are ignored
+	_ = t.record(record)
+
+	return response, err
+}
+
+// toolName extracts the tool name from the params of a tools/call request.
+func toolName(params any) string {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return ""
+	}
+	var p struct {
+		Name string `json:"name"`
+	}
+	_ = json.Unmarshal(raw, &p)
+	return p.Name
+}
+
+// isErrorResult reports whether a tool result is flagged as an error.
+func isErrorResult(result json.RawMessage) bool {
+	var r struct {
+		IsError bool `json:"isError"`
+	}
+	_ = json.Unmarshal(result, &r)
+	return r.IsError
+}
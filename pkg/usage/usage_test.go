@@ -0,0 +1,135 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// fakeTransport answers every request with result.
+type fakeTransport struct {
+	transport.Interface
+	result string
+}
+
+func (f *fakeTransport) SendRequest(_ context.Context, request transport.JSONRPCRequest) (*transport.JSONRPCResponse, error) {
+	return &transport.JSONRPCResponse{ID: request.ID, Result: json.RawMessage(f.result)}, nil
+}
+
+func TestTransportRecordsToolCalls(t *testing.T) {
+	var records []Record
+	tr := &Transport{
+		Interface: &fakeTransport{result: `{"content":[],"isError":true}`},
+		server:    "github",
+		record: func(r Record) error {
+			records = append(records, r)
+			return nil
+		},
+	}
+
+	for _, method := range []string{"tools/list", "tools/call"} {
+		request := transport.JSONRPCRequest{
+			ID:     mcp.NewRequestId(1),
+			Method: method,
+			Params: mcp.CallToolParams{Name: "create_issue"},
+		}
+		if _, err := tr.SendRequest(context.Background(), request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if records[0].Server != "github" || records[0].Tool != "create_issue" || !records[0].Error {
+		t.Errorf("unexpected record: %+v", records[0])
+	}
+}
+
+func TestTransportWarnsAboutSlowCalls(t *testing.T) {
+	var records []Record
+	var warnings bytes.Buffer
+	slo := func(tool string) time.Duration {
+		if tool == "search" {
+			return time.Nanosecond
+		}
+		return time.Hour
+	}
+	tr := NewTransport(&fakeTransport{result: `{"content":[]}`}, "github", WithSLO(slo, &warnings))
+	tr.record = func(r Record) error {
+		records = append(records, r)
+		return nil
+	}
+
+	for _, tool := range []string{"search", "create_issue"} {
+		request := transport.JSONRPCRequest{
+			ID:     mcp.NewRequestId(1),
+			Method: "tools/call",
+			Params: mcp.CallToolParams{Name: tool},
+		}
+		if _, err := tr.SendRequest(context.Background(), request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !strings.HasPrefix(warnings.String(), "Warning: search of github took ") || strings.Count(warnings.String(), "\n") != 1 {
+		t.Errorf("warnings = %q, want one for search", warnings.String())
+	}
+	if len(records) != 2 || records[1].SLOMS != time.Hour.Milliseconds() || records[1].OverSLO() {
+		t.Errorf("records = %+v, want the SLO of each tool recorded", records)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	records := []Record{
+		{Server: "fs", Tool: "read_file", DurationMS: 10},
+		{Server: "fs", Tool: "read_file", DurationMS: 30, SLOMS: 20, Error: true},
+		{Server: "gh", Tool: "create_issue", DurationMS: 100},
+	}
+
+	summaries := Summarize(records)
+	if len(summaries) != 2 {
+		t.Fatalf("expected 2 summaries, got %d", len(summaries))
+	}
+
+	first := summaries[0]
+	if first.Tool != "read_file" || first.Calls != 2 || first.Errors != 1 || first.ErrorRate != 0.5 || first.AvgLatencyMS != 20 ||
+		first.SLOBreaches != 1 {
+		t.Errorf("unexpected summary: %+v", first)
+	}
+}
+
+func TestEnableDisable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if Enabled() {
+		t.Fatal("expected recording to be off by default")
+	}
+	if err := Enable(); err != nil {
+		t.Fatal(err)
+	}
+	if !Enabled() {
+		t.Error("expected recording to be on after Enable")
+	}
+
+	if err := Append(Record{Server: "fs", Tool: "read_file"}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := Load()
+	if err != nil || len(records) != 1 {
+		t.Errorf("Load() = %v, %v; want 1 record", records, err)
+	}
+
+	if err = Disable(); err != nil {
+		t.Fatal(err)
+	}
+	if Enabled() {
+		t.Error("expected recording to be off after Disable")
+	}
+}
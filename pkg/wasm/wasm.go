@@ -0,0 +1,164 @@
+// Package wasm runs MCP servers compiled to WebAssembly (WASI preview 1) in an embedded
+// sandboxed runtime.
+package wasm
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// wasmPageSize is the size of a WebAssembly memory page.
+const wasmPageSize = 64 * 1024
+
+// ErrInvalidMount is returned for malformed --dir values.
+var ErrInvalidMount = errors.New("invalid directory mount")
+
+// Mount gives the module access to a host directory.
+type Mount struct {
+	HostPath  string
+	GuestPath string
+	ReadOnly  bool
+}
+
+// Options configures the sandbox a module runs in. By default the module has no filesystem,
+// no environment variables and no network access (WASI preview 1 has no sockets).
+type Options struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Env holds the environment variables visible to the module, in KEY=VALUE form.
+	Env []string
+	// Args are passed to the module after its name.
+	Args []string
+	// Mounts are the host directories the module may access.
+	Mounts []Mount
+	// CacheDir stores compiled modules to speed up later runs. Caching is off if empty.
+	CacheDir string
+	// MemoryLimitMB caps the module's memory. Zero uses the runtime's limit of 4 GiB.
+	MemoryLimitMB uint32
+}
+
+// GetCachePath returns the directory compiled modules are cached in.
+func GetCachePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "wasm-cache"), nil
+}
+
+// ParseMount parses a mount in host[:guest][:ro] form. The guest path defaults to the host
+// path.
+func ParseMount(spec string) (Mount, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) > 3 || parts[0] == "" {
+		return Mount{}, fmt.Errorf("%w: %q (use host[:guest][:ro])", ErrInvalidMount, spec)
+	}
+
+	mount := Mount{HostPath: parts[0], GuestPath: parts[0]}
+	if last := parts[len(parts)-1]; len(parts) > 1 && (last == "ro" || last == "rw") {
+		mount.ReadOnly = last == "ro"
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) == 3 {
+		return Mount{}, fmt.Errorf("%w: %q (use host[:guest][:ro])", ErrInvalidMount, spec)
+	}
+	if len(parts) == 2 && parts[1] != "" {
+		mount.GuestPath = parts[1]
+	}
+
+	info, err := os.Stat(mount.HostPath)
+	if err != nil {
+		return Mount{}, fmt.Errorf("%w: %w", ErrInvalidMount, err)
+	}
+	if !info.IsDir() {
+		return Mount{}, fmt.Errorf("%w: %s is not a directory", ErrInvalidMount, mount.HostPath)
+	}
+
+	return mount, nil
+}
+
+// Run runs the WASI module at path until it exits or ctx is done. A non-zero exit code is
+// returned as an error.
+func Run(ctx context.Context, path string, opts Options) error {
+	// #nosec G304 - the module path is provided explicitly by the user
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read module: %w", err)
+	}
+
+	runtimeConfig := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if opts.MemoryLimitMB > 0 {
+		runtimeConfig = runtimeConfig.WithMemoryLimitPages(opts.MemoryLimitMB * 1024 * 1024 / wasmPageSize)
+	}
+	if opts.CacheDir != "" {
+		cache, cacheErr := wazero.NewCompilationCacheWithDir(opts.CacheDir)
+		if cacheErr == nil {
+			defer func() { _ = cache.Close(ctx) }()
+			runtimeConfig = runtimeConfig.WithCompilationCache(cache)
+		}
+	}
+
+	r := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	defer func() { _ = r.Close(ctx) }()
+
+	if _, err = wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		return fmt.Errorf("failed to set up WASI: %w", err)
+	}
+
+	compiled, err := r.CompileModule(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to compile module: %w", err)
+	}
+
+	fsConfig := wazero.NewFSConfig()
+	for _, mount := range opts.Mounts {
+		if mount.ReadOnly {
+			fsConfig = fsConfig.WithReadOnlyDirMount(mount.HostPath, mount.GuestPath)
+		} else {
+			fsConfig = fsConfig.WithDirMount(mount.HostPath, mount.GuestPath)
+		}
+	}
+
+	moduleConfig := wazero.NewModuleConfig().
+		WithArgs(append([]string{filepath.Base(path)}, opts.Args...)...).
+		WithStdin(opts.Stdin).
+		WithStdout(opts.Stdout).
+		WithStderr(opts.Stderr).
+		WithFSConfig(fsConfig).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+	for _, env := range opts.Env {
+		key, value, _ := strings.Cut(env, "=")
+		moduleConfig = moduleConfig.WithEnv(key, value)
+	}
+
+	mod, err := r.InstantiateModule(ctx, compiled, moduleConfig)
+	if mod != nil {
+		_ = mod.Close(ctx)
+	}
+
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() == 0 {
+			return nil
+		}
+		return fmt.Errorf("module exited with code %d", exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run module: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testProgram echoes stdin, then reports its environment and what it can see under /data.
+const testProgram = `package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	in, _ := io.ReadAll(os.Stdin)
+	fmt.Printf("echo:%s\n", in)
+	fmt.Printf("env:%s\n", os.Getenv("GREETING"))
+	data, err := os.ReadFile("/data/hello.txt")
+	fmt.Printf("read:%s %v\n", data, err != nil)
+	err = os.WriteFile("/data/out.txt", []byte("x"), 0o600)
+	fmt.Printf("write-failed:%v\n", err != nil)
+	if len(os.Args) > 1 {
+		os.Exit(3)
+	}
+}
+`
+
+// buildTestModule compiles testProgram to a WASI module.
+func buildTestModule(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping WASI build in short mode")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(testProgram), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module wasmtest\n\ngo 1.21\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "server.wasm")
+	cmd := exec.Command("go", "build", "-o", out, ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build WASI module: %v: %s", err, output)
+	}
+	return out
+}
+
+func TestRun(t *testing.T) {
+	module := buildTestModule(t)
+
+	data := t.TempDir()
+	if err := os.WriteFile(filepath.Join(data, "hello.txt"), []byte("hi"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := t.TempDir()
+	var stdout bytes.Buffer
+	err := Run(context.Background(), module, Options{
+		Stdin:    strings.NewReader("ping"),
+		Stdout:   &stdout,
+		Stderr:   os.Stderr,
+		Env:      []string{"GREETING=hello"},
+		Mounts:   []Mount{{HostPath: data, GuestPath: "/data", ReadOnly: true}},
+		CacheDir: cache,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "echo:ping\nenv:hello\nread:hi false\nwrite-failed:true\n"
+	if stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+	if _, err = os.Stat(filepath.Join(data, "out.txt")); err == nil {
+		t.Error("module wrote to a read-only mount")
+	}
+
+	stdout.Reset()
+	err = Run(context.Background(), module, Options{Stdin: strings.NewReader(""), Stdout: &stdout, Args: []string{"fail"}, CacheDir: cache})
+	if err == nil || !strings.Contains(err.Error(), "exited with code 3") {
+		t.Errorf("expected exit code error, got %v", err)
+	}
+	if !strings.Contains(stdout.String(), "read: true") {
+		t.Errorf("expected no filesystem access without mounts, got %q", stdout.String())
+	}
+}
+
+func TestParseMount(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		spec string
+		want Mount
+		err  bool
+	}{
+		{spec: dir, want: Mount{HostPath: dir, GuestPath: dir}},
+		{spec: dir + ":/data", want: Mount{HostPath: dir, GuestPath: "/data"}},
+		{spec: dir + ":/data:ro", want: Mount{HostPath: dir, GuestPath: "/data", ReadOnly: true}},
+		{spec: dir + ":ro", want: Mount{HostPath: dir, GuestPath: dir, ReadOnly: true}},
+		{spec: dir + ":/a:/b", err: true},
+		{spec: filepath.Join(dir, "missing"), err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseMount(tt.spec)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseMount(%q) = %+v, %v; want %+v", tt.spec, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package webauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Statuses of audit records.
+const (
+	StatusOK           = "ok"
+	StatusError        = "error"
+	StatusUnauthorized = "unauthorized"
+)
+
+// MethodLogin is the method of audit records of users signing in.
+const MethodLogin = "login"
+
+// AuditRecord is a JSON line of the audit log of the web interface.
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user,omitempty"`
+	Remote     string    `json:"remote,omitempty"`
+	Method     string    `json:"method"`
+	Target     string    `json:"target,omitempty"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// GetAuditLogPath returns the default audit log path, creating its directory if needed.
+func GetAuditLogPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	logDir := filepath.Join(homeDir, ".mcpt", "logs")
+	if err = os.MkdirAll(logDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create log directory: %w", err)
+	}
+	return filepath.Join(logDir, "web-audit.log"), nil
+}
+
+// AuditLog writes what the users of the web interface do as AuditRecords. A nil AuditLog
+// writes nothing.
+type AuditLog struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// NewAuditLog creates an audit log writing to w, such as an audit.File.
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{w: w, now: time.Now}
+}
+
+// Record writes a record of a request of the signed in user of r to method of target that
+// started at start and failed with err if it is not nil.
+func (l *AuditLog) Record(r *http.Request, method, target string, start time.Time, err error) {
+	if l == nil {
+		return
+	}
+	record := AuditRecord{
+		Time:       start.UTC(),
+		User:       User(r.Context()),
+		Remote:     remoteHost(r),
+		Method:     method,
+		Target:     target,
+		Status:     StatusOK,
+		DurationMS: l.now().Sub(start).Milliseconds(),
+	}
+	if err != nil {
+		record.Status, record.Error = StatusError, err.Error()
+	}
+	l.write(record)
+}
+
+// Login is a LoginFunc writing a record of each attempt to sign in.
+func (l *AuditLog) Login(r *http.Request, user string, err error) {
+	if l == nil {
+		return
+	}
+	record := AuditRecord{Time: l.now().UTC(), User: user, Remote: remoteHost(r), Method: MethodLogin, Status: StatusOK}
+	if err != nil {
+		record.Status, record.Error = StatusUnauthorized, err.Error()
+	}
+	l.write(record)
+}
+
+func (l *AuditLog) write(record AuditRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err = l.w.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write the audit log: %v\n", err)
+	}
+}
+
+func remoteHost(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,466 @@
+package webauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Paths served by OIDC for signing in and out.
+const (
+	CallbackPath = "/auth/callback"
+	LogoutPath   = "/auth/logout"
+)
+
+// sessionCookie holds the session of a signed in user.
+const sessionCookie = "mcpt_session"
+
+// Lifetimes of sessions and of sign-ins waiting for the provider.
+const (
+	sessionTTL = 12 * time.Hour
+	pendingTTL = 10 * time.Minute
+)
+
+// OIDCConfig configures signing in with an OpenID Connect provider.
+type OIDCConfig struct {
+	// Issuer is the URL of the provider, where its discovery document is found.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback URL registered with the provider. It defaults to
+	// CallbackPath on the host of each request.
+	RedirectURL string
+	// Allow lists the users who may sign in: email addresses, subjects, or @domain for every
+	// verified address of a domain. Everyone the provider signs in is allowed if it is empty.
+	Allow []string
+	// OnLogin, if not nil, is told when users sign in and when they fail to.
+	OnLogin LoginFunc
+	// Client is used to talk to the provider. It defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// provider holds the endpoints of a provider's discovery document.
+type provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+type pending struct {
+	verifier string
+	nonce    string
+	redirect string
+	returnTo string
+	expires  time.Time
+}
+
+type session struct {
+	user    string
+	expires time.Time
+}
+
+// OIDC signs users in with the authorization code flow of an OpenID Connect provider, with
+// PKCE. Signed in users get a session cookie. The ID token is taken from the token endpoint
+// of the provider over HTTPS, which validates its issuer in place of its signature (OpenID
+// Connect Core 3.1.3.7). That is why the issuer and the token endpoint must use HTTPS unless
+// they are on the loopback interface.
+type OIDC struct {
+	config   OIDCConfig
+	provider provider
+	now      func() time.Time
+
+	mu       sync.Mutex
+	pending  map[string]pending
+	sessions map[string]session
+}
+
+// NewOIDC reads the discovery document of the provider of config.
+func NewOIDC(ctx context.Context, config OIDCConfig) (*OIDC, error) {
+	if config.Issuer == "" || config.ClientID == "" {
+		return nil, errors.New("signing in with OpenID Connect needs an issuer and a client ID")
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if !secureURL(config.Issuer) {
+		return nil, fmt.Errorf("the OpenID Connect issuer %s must use https", config.Issuer)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := config.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the OpenID Connect provider: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to discover the OpenID Connect provider: %s", resp.Status)
+	}
+
+	var p provider
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid OpenID Connect discovery document: %w", err)
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != config.Issuer {
+		return nil, fmt.Errorf("the OpenID Connect provider says it is %q, not %q", p.Issuer, config.Issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" {
+		return nil, errors.New("the OpenID Connect discovery document has no authorization or token endpoint")
+	}
+	if !secureURL(p.TokenEndpoint) {
+		return nil, fmt.Errorf("the OpenID Connect token endpoint %s must use https", p.TokenEndpoint)
+	}
+
+	return &OIDC{
+		config:   config,
+		provider: p,
+		now:      time.Now,
+		pending:  map[string]pending{},
+		sessions: map[string]session{},
+	}, nil
+}
+
+// secureURL reports whether raw is an https URL, or an http one on the loopback interface, that
+// ID tokens can be trusted over without checking their signature.
+func secureURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	default:
+		return false
+	}
+}
+
+// Wrap implements Authenticator. Page requests without a session are sent to the provider to
+// sign in and come back to the same page; API requests are refused.
+func (o *OIDC) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case CallbackPath:
+			o.callback(w, r)
+			return
+		case LogoutPath:
+			o.logout(w, r)
+			return
+		}
+
+		if crossSite(r) {
+			http.Error(w, "Requests from other sites are not allowed", http.StatusForbidden)
+			return
+		}
+		if user, ok := o.session(r); ok {
+			h.ServeHTTP(w, withUser(r, user))
+			return
+		}
+		if r.Method != http.MethodGet || strings.HasPrefix(r.URL.Path, "/api/") {
+			http.Error(w, "Sign in to use this MCP Tools web interface", http.StatusUnauthorized)
+			return
+		}
+		o.signIn(w, r)
+	})
+}
+
+// signIn sends the browser to the provider to sign in.
+func (o *OIDC) signIn(w http.ResponseWriter, r *http.Request) {
+	state, err1 := randomString()
+	nonce, err2 := randomString()
+	verifier, err3 := randomString()
+	if err := errors.Join(err1, err2, err3); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	redirect := o.redirectURL(r)
+	o.mu.Lock()
+	o.expire()
+	o.pending[state] = pending{
+		verifier: verifier,
+		nonce:    nonce,
+		redirect: redirect,
+		returnTo: r.URL.RequestURI(),
+		expires:  o.now().Add(pendingTTL),
+	}
+	o.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.config.ClientID},
+		"redirect_uri":          {redirect},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	target := o.provider.AuthorizationEndpoint
+	if strings.Contains(target, "?") {
+		target += "&" + query.Encode()
+	} else {
+		target += "?" + query.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// callback completes signing in when the provider sends the browser back.
+func (o *OIDC) callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	o.mu.Lock()
+	p, ok := o.pending[query.Get("state")]
+	delete(o.pending, query.Get("state"))
+	o.mu.Unlock()
+	if !ok || o.now().After(p.expires) {
+		http.Error(w, "This sign-in expired or was not started here; reload the page to sign in again", http.StatusBadRequest)
+		return
+	}
+	if providerErr := query.Get("error"); providerErr != "" {
+		o.failed(w, r, "", fmt.Errorf("the provider refused to sign in: %s %s", providerErr, query.Get("error_description")))
+		return
+	}
+
+	user, err := o.exchange(r.Context(), query.Get("code"), p)
+	if err == nil && !o.allowed(user) {
+		err = fmt.Errorf("%s may not use this interface", user.name)
+	}
+	if err != nil {
+		o.failed(w, r, user.name, err)
+		return
+	}
+
+	token, err := randomString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	o.mu.Lock()
+	o.sessions[token] = session{user: user.name, expires: o.now().Add(sessionTTL)}
+	o.mu.Unlock()
+	if o.config.OnLogin != nil {
+		o.config.OnLogin(r, user.name, nil)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(p.redirect, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, p.returnTo, http.StatusFound)
+}
+
+func (o *OIDC) failed(w http.ResponseWriter, r *http.Request, user string, err error) {
+	if o.config.OnLogin != nil {
+		o.config.OnLogin(r, user, err)
+	}
+	http.Error(w, "Signing in failed: "+err.Error(), http.StatusForbidden)
+}
+
+// logout ends the session of the request.
+func (o *OIDC) logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		o.mu.Lock()
+		delete(o.sessions, cookie.Value)
+		o.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, "Signed out of MCP Tools.\n")
+}
+
+// session returns the user of the session of a request.
+func (o *OIDC) session(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return "", false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	s, ok := o.sessions[cookie.Value]
+	if !ok || o.now().After(s.expires) {
+		delete(o.sessions, cookie.Value)
+		return "", false
+	}
+	return s.user, true
+}
+
+// expire forgets expired sign-ins and sessions. o.mu must be held.
+func (o *OIDC) expire() {
+	now := o.now()
+	for state, p := range o.pending {
+		if now.After(p.expires) {
+			delete(o.pending, state)
+		}
+	}
+	for token, s := range o.sessions {
+		if now.After(s.expires) {
+			delete(o.sessions, token)
+		}
+	}
+}
+
+func (o *OIDC) redirectURL(r *http.Request) string {
+	if o.config.RedirectURL != "" {
+		return o.config.RedirectURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + CallbackPath
+}
+
+// identity is a user signed in by the provider.
+type identity struct {
+	name     string
+	subject  string
+	email    string
+	verified bool
+}
+
+// exchange redeems the authorization code of a sign-in for the identity in its ID token.
+func (o *OIDC) exchange(ctx context.Context, code string, p pending) (identity, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirect},
+		"client_id":     {o.config.ClientID},
+		"code_verifier": {p.verifier},
+	}
+	if o.config.ClientSecret != "" {
+		form.Set("client_secret", o.config.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.config.Client.Do(req)
+	if err != nil {
+		return identity{}, fmt.Errorf("failed to redeem the authorization code: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return identity{}, fmt.Errorf("invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		return identity{}, fmt.Errorf("failed to redeem the authorization code: %s %s %s",
+			resp.Status, body.Error, body.ErrorDescription)
+	}
+
+	return o.verify(body.IDToken, p.nonce)
+}
+
+// verify checks the claims of an ID token and returns its identity.
+func (o *OIDC) verify(idToken, nonce string) (identity, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return identity{}, errors.New("the ID token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return identity{}, errors.New("the ID token is not a JWT")
+	}
+
+	var claims struct {
+		Issuer        string          `json:"iss"`
+		Subject       string          `json:"sub"`
+		Audience      json.RawMessage `json:"aud"`
+		Expiry        float64         `json:"exp"`
+		Nonce         string          `json:"nonce"`
+		Email         string          `json:"email"`
+		EmailVerified *bool           `json:"email_verified"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return identity{}, fmt.Errorf("invalid ID token claims: %w", err)
+	}
+
+	var audience []string
+	if json.Unmarshal(claims.Audience, &audience) != nil {
+		var single string
+		_ = json.Unmarshal(claims.Audience, &single)
+		audience = []string{single}
+	}
+	switch {
+	case strings.TrimSuffix(claims.Issuer, "/") != o.config.Issuer:
+		return identity{}, fmt.Errorf("the ID token was issued by %q", claims.Issuer)
+	case !slices.Contains(audience, o.config.ClientID):
+		return identity{}, errors.New("the ID token is not meant for this client")
+	case o.now().After(time.Unix(int64(claims.Expiry), 0)):
+		return identity{}, errors.New("the ID token has expired")
+	case claims.Nonce != nonce:
+		return identity{}, errors.New("the ID token is not for this sign-in")
+	case claims.Subject == "":
+		return identity{}, errors.New("the ID token has no subject")
+	}
+
+	id := identity{name: claims.Subject, subject: claims.Subject, email: claims.Email}
+	// Addresses the provider did not verify could be anyone's
+	id.verified = claims.Email != "" && claims.EmailVerified != nil && *claims.EmailVerified
+	if id.verified {
+		id.name = claims.Email
+	}
+	return id, nil
+}
+
+// allowed reports whether the configuration lets a user sign in.
+func (o *OIDC) allowed(id identity) bool {
+	if len(o.config.Allow) == 0 {
+		return true
+	}
+	for _, allow := range o.config.Allow {
+		switch {
+		case allow == id.subject:
+			return true
+		case id.verified && strings.EqualFold(allow, id.email):
+			return true
+		case id.verified && strings.HasPrefix(allow, "@") && strings.HasSuffix(strings.ToLower(id.email), strings.ToLower(allow)):
+			return true
+		}
+	}
+	return false
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
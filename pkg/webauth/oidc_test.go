@@ -0,0 +1,219 @@
+package webauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProvider is an OpenID Connect provider signing in whoever its claims say.
+type testProvider struct {
+	*httptest.Server
+	claims map[string]any
+	// challenge and nonce are those of the last sign-in started.
+	challenge string
+	nonce     string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	p := &testProvider{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "the-code" || base64.RawURLEncoding.EncodeToString(verifier[:]) != p.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		claims := map[string]any{"iss": p.URL, "aud": "inspector", "exp": time.Now().Add(time.Hour).Unix(), "nonce": p.nonce}
+		for name, value := range p.claims {
+			claims[name] = value
+		}
+		payload, _ := json.Marshal(claims)
+		idToken := "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "access_token": "at"})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// signIn follows a sign-in from a page request to the callback, and returns the response of
+// the callback.
+func (p *testProvider) signIn(t *testing.T, handler http.Handler) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://inspector/?tool=greet", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("page without a session = %d, want a redirect to the provider", rec.Code)
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(location.String(), p.URL+"/authorize?") {
+		t.Fatalf("redirect to %v, want the authorization endpoint", location)
+	}
+	query := location.Query()
+	if query.Get("redirect_uri") != "http://inspector"+CallbackPath || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("authorization request = %v", query)
+	}
+	p.challenge, p.nonce = query.Get("code_challenge"), query.Get("nonce")
+
+	rec = httptest.NewRecorder()
+	callback := "http://inspector" + CallbackPath + "?" + url.Values{"code": {"the-code"}, "state": {query.Get("state")}}.Encode()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, callback, nil))
+	return rec
+}
+
+func TestOIDC(t *testing.T) {
+	provider := newTestProvider(t)
+	var logins []string
+	oidc, err := NewOIDC(context.Background(), OIDCConfig{
+		Issuer:   provider.URL,
+		ClientID: "inspector",
+		Allow:    []string{"@example.com"},
+		OnLogin: func(_ *http.Request, user string, err error) {
+			if err != nil {
+				user += " failed"
+			}
+			logins = append(logins, user)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := oidc.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(User(r.Context())))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://inspector/api/call", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("API request without a session = %d, want 401", rec.Code)
+	}
+
+	provider.claims = map[string]any{"sub": "1234", "email": "alice@example.com", "email_verified": true}
+	rec = provider.signIn(t, handler)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/?tool=greet" {
+		t.Fatalf("callback = %d to %q, want a redirect back to the page", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookie || !cookies[0].HttpOnly {
+		t.Fatalf("callback cookies = %v, want the session cookie", cookies)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://inspector/api/call", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "alice@example.com" {
+		t.Errorf("request with a session = %d %q, want alice@example.com", rec.Code, rec.Body.String())
+	}
+
+	logout := httptest.NewRequest(http.MethodGet, "http://inspector"+LogoutPath, nil)
+	logout.AddCookie(cookies[0])
+	handler.ServeHTTP(httptest.NewRecorder(), logout)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("request after signing out = %d, want 401", rec.Code)
+	}
+
+	// Unverified addresses do not match the allowed domain
+	provider.claims = map[string]any{"sub": "5678", "email": "mallory@example.com", "email_verified": false}
+	if rec = provider.signIn(t, handler); rec.Code != http.StatusForbidden {
+		t.Errorf("callback for an unverified address = %d, want 403", rec.Code)
+	}
+
+	if len(logins) != 2 || logins[0] != "alice@example.com" || logins[1] != "5678 failed" {
+		t.Errorf("logins = %v", logins)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://inspector"+CallbackPath+"?code=the-code&state=forged", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("callback with an unknown state = %d, want 400", rec.Code)
+	}
+}
+
+func TestOIDCVerify(t *testing.T) {
+	o := &OIDC{config: OIDCConfig{Issuer: "https://id.example.com", ClientID: "inspector"}, now: time.Now}
+	token := func(claims map[string]any) string {
+		payload, _ := json.Marshal(claims)
+		return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+	}
+	valid := func() map[string]any {
+		return map[string]any{"iss": "https://id.example.com", "aud": []string{"other", "inspector"}, "sub": "1234",
+			"exp": time.Now().Add(time.Minute).Unix(), "nonce": "n"}
+	}
+
+	id, err := o.verify(token(valid()), "n")
+	if err != nil || id.name != "1234" || id.verified {
+		t.Errorf("verify() = %+v, %v, want subject 1234", id, err)
+	}
+
+	for name, change := range map[string]func(map[string]any){
+		"issuer":   func(c map[string]any) { c["iss"] = "https://evil.example.com" },
+		"audience": func(c map[string]any) { c["aud"] = "other" },
+		"expired":  func(c map[string]any) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"nonce":    func(c map[string]any) { c["nonce"] = "replayed" },
+		"subject":  func(c map[string]any) { delete(c, "sub") },
+	} {
+		claims := valid()
+		change(claims)
+		if _, err = o.verify(token(claims), "n"); err == nil {
+			t.Errorf("verify() accepted a token with a wrong %s", name)
+		}
+	}
+	if _, err = o.verify("not a jwt", "n"); err == nil {
+		t.Error("verify() accepted a token that is not a JWT")
+	}
+}
+
+func TestNewOIDCRequiresHTTPS(t *testing.T) {
+	if _, err := NewOIDC(context.Background(), OIDCConfig{Issuer: "http://id.example.com", ClientID: "inspector"}); err == nil ||
+		!strings.Contains(err.Error(), "must use https") {
+		t.Errorf("NewOIDC() with an http issuer error = %v, want it refused", err)
+	}
+
+	var issuer string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer,
+			"authorization_endpoint": issuer + "/authorize",
+			"token_endpoint":         "http://id.example.com/token",
+		})
+	}))
+	defer server.Close()
+	issuer = server.URL
+
+	if _, err := NewOIDC(context.Background(), OIDCConfig{Issuer: issuer, ClientID: "inspector"}); err == nil ||
+		!strings.Contains(err.Error(), "token endpoint") {
+		t.Errorf("NewOIDC() with an http token endpoint error = %v, want it refused", err)
+	}
+
+	for raw, want := range map[string]bool{
+		"https://id.example.com": true,
+		"http://localhost:8080":  true,
+		"http://127.0.0.1:5556":  true,
+		"http://[::1]/dex":       true,
+		"http://id.example.com":  false,
+		"ftp://id.example.com":   false,
+		"id.example.com":         false,
+	} {
+		if got := secureURL(raw); got != want {
+			t.Errorf("secureURL(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
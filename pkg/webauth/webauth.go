@@ -0,0 +1,263 @@
+/*
+Package webauth signs in the users of the web interface, so a team can share one instance on
+an internal network: with HTTP basic authentication against a file of password hashes, or with
+the authorization code flow of an OpenID Connect provider.
+*/
+package webauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// hashScheme names the password hashes of users files.
+const hashScheme = "pbkdf2-sha256"
+
+// hashIterations is the number of PBKDF2 iterations of new password hashes.
+const hashIterations = 210000
+
+// ErrInvalidHash is returned for password hashes that were not made by HashPassword.
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// Authenticator signs users in before letting their requests through to a handler.
+type Authenticator interface {
+	// Wrap returns a handler serving the requests of signed in users with h, with their user
+	// name in the context of the request.
+	Wrap(h http.Handler) http.Handler
+}
+
+// LoginFunc is told of each attempt to sign in: err is nil if user signed in.
+type LoginFunc func(r *http.Request, user string, err error)
+
+type userKey struct{}
+
+// User returns the user signed in for a request, or "" if there is none.
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// crossSite reports whether r changes state on behalf of another site. Browsers send the
+// credentials of signed in users with such requests too.
+func crossSite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || u.Host != r.Host
+}
+
+func withUser(r *http.Request, user string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+}
+
+// Users maps user names to the hashes of their passwords:
+//
+//	{"alice": "pbkdf2-sha256$210000$<salt>$<hash>"}
+type Users map[string]string
+
+// GetUsersPath returns the default path of the users file.
+func GetUsersPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "web-users.json"), nil
+}
+
+// LoadUsers reads the users file at path. A missing file has no users.
+func LoadUsers(path string) (Users, error) {
+	// #nosec G304 - the users file path is provided by the user or generated internally
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Users{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users file: %w", err)
+	}
+
+	users := Users{}
+	if err = json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse users file %s: %w", path, err)
+	}
+	return users, nil
+}
+
+// Save writes the users to the file at path, readable only by its owner.
+func (u Users) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create users directory: %w", err)
+	}
+	data, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write users file: %w", err)
+	}
+	return nil
+}
+
+// Names returns the user names, sorted.
+func (u Users) Names() []string {
+	names := make([]string, 0, len(u))
+	for name := range u {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check reports whether password is that of user.
+func (u Users) Check(user, password string) bool {
+	hash, ok := u[user]
+	if !ok {
+		// Spend as long on unknown users as on known ones
+		hash = dummyHash
+	}
+	match, err := CheckPassword(hash, password)
+	return ok && err == nil && match
+}
+
+// dummyHash is checked against for unknown users.
+var dummyHash = hashScheme + "$" + strconv.Itoa(hashIterations) + "$AAAAAAAAAAAAAAAAAAAAAA$AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// HashPassword returns a salted hash of password for a users file.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, hashIterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s$%d$%s$%s", hashScheme, hashIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether password matches a hash made by HashPassword.
+func CheckPassword(hash, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != hashScheme {
+		return false, ErrInvalidHash
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false, ErrInvalidHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false, ErrInvalidHash
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(key, want) == 1, nil
+}
+
+// Basic signs users in with HTTP basic authentication. Browsers send the credentials with
+// every request, so passwords that were checked once are remembered by a keyed digest, to hash
+// each password only once.
+type Basic struct {
+	users   Users
+	realm   string
+	onLogin LoginFunc
+
+	mu       sync.Mutex
+	key      []byte
+	verified map[string][]byte
+}
+
+// NewBasic creates a basic authenticator of users. onLogin, if not nil, is told when users
+// first sign in and when they fail to.
+func NewBasic(users Users, realm string, onLogin LoginFunc) (*Basic, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &Basic{users: users, realm: realm, onLogin: onLogin, key: key, verified: map[string][]byte{}}, nil
+}
+
+// Wrap implements Authenticator.
+func (b *Basic) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if crossSite(r) {
+			http.Error(w, "Requests from other sites are not allowed", http.StatusForbidden)
+			return
+		}
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			b.challenge(w)
+			return
+		}
+		if !b.check(r, user, password) {
+			if b.onLogin != nil {
+				b.onLogin(r, user, errors.New("wrong user name or password"))
+			}
+			b.challenge(w)
+			return
+		}
+		h.ServeHTTP(w, withUser(r, user))
+	})
+}
+
+// check reports whether password is that of user, logging the first successful check.
+func (b *Basic) check(r *http.Request, user, password string) bool {
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(password))
+	digest := mac.Sum(nil)
+
+	b.mu.Lock()
+	known, ok := b.verified[user]
+	b.mu.Unlock()
+	if ok && hmac.Equal(known, digest) {
+		return true
+	}
+
+	if !b.users.Check(user, password) {
+		return false
+	}
+	b.mu.Lock()
+	b.verified[user] = digest
+	b.mu.Unlock()
+	if b.onLogin != nil {
+		b.onLogin(r, user, nil)
+	}
+	return true
+}
+
+func (b *Basic) challenge(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Basic realm="+strconv.Quote(b.realm)+", charset=\"UTF-8\"")
+	http.Error(w, "Sign in to use this MCP Tools web interface", http.StatusUnauthorized)
+}
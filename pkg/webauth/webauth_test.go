@@ -0,0 +1,158 @@
+package webauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, checkErr := CheckPassword(hash, "s3cret"); !ok || checkErr != nil {
+		t.Errorf("CheckPassword(right password) = %v, %v", ok, checkErr)
+	}
+	if ok, _ := CheckPassword(hash, "wrong"); ok {
+		t.Error("CheckPassword(wrong password) = true")
+	}
+	if other, _ := HashPassword("s3cret"); other == hash {
+		t.Error("HashPassword() should salt hashes")
+	}
+	for _, invalid := range []string{"", "s3cret", "md5$1$AA$AA", "pbkdf2-sha256$x$AA$AA"} {
+		if _, checkErr := CheckPassword(invalid, "s3cret"); !errors.Is(checkErr, ErrInvalidHash) {
+			t.Errorf("CheckPassword(%q) error = %v, want %v", invalid, checkErr, ErrInvalidHash)
+		}
+	}
+}
+
+func TestUsers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	users, err := LoadUsers(path)
+	if err != nil || len(users) != 0 {
+		t.Fatalf("LoadUsers(missing file) = %v, %v", users, err)
+	}
+
+	if users["bob"], err = HashPassword("hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	if users["alice"], err = HashPassword("s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err = users.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadUsers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := loaded.Names(); len(names) != 2 || names[0] != "alice" {
+		t.Errorf("Names() = %v, want alice and bob", names)
+	}
+	if !loaded.Check("alice", "s3cret") || loaded.Check("alice", "hunter2") || loaded.Check("carol", "s3cret") {
+		t.Error("Check() should accept only the password of each user")
+	}
+}
+
+func TestBasic(t *testing.T) {
+	hash, err := HashPassword("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type login struct {
+		user string
+		ok   bool
+	}
+	var logins []login
+	basic, err := NewBasic(Users{"alice": hash}, "MCP Tools", func(_ *http.Request, user string, err error) {
+		logins = append(logins, login{user, err == nil})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := basic.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(User(r.Context())))
+	}))
+
+	serve := func(method, user, password string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://inspector:41999/api/call", nil)
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "", "", nil); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("no credentials = %d %v, want a 401 challenge", rec.Code, rec.Header())
+	}
+	if rec := serve(http.MethodGet, "alice", "wrong", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password = %d, want 401", rec.Code)
+	}
+	for range 2 {
+		if rec := serve(http.MethodPost, "alice", "s3cret", nil); rec.Code != http.StatusOK || rec.Body.String() != "alice" {
+			t.Errorf("right password = %d %q, want alice", rec.Code, rec.Body.String())
+		}
+	}
+	if rec := serve(http.MethodPost, "alice", "s3cret", http.Header{"Origin": {"https://evil.example.com"}}); rec.Code != http.StatusForbidden {
+		t.Errorf("cross-site POST = %d, want 403", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "alice", "s3cret", http.Header{"Origin": {"http://inspector:41999"}}); rec.Code != http.StatusOK {
+		t.Errorf("same-origin POST = %d, want 200", rec.Code)
+	}
+
+	want := []login{{"alice", false}, {"alice", true}}
+	if len(logins) != len(want) || logins[0] != want[0] || logins[1] != want[1] {
+		t.Errorf("logins = %v, want a failure and one sign-in", logins)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewAuditLog(&buf)
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	log.now = func() time.Time { return start.Add(250 * time.Millisecond) }
+
+	req := httptest.NewRequest(http.MethodPost, "/api/call", nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+	log.Login(req, "mallory", errors.New("wrong user name or password"))
+	log.Record(withUser(req, "alice"), "tools/call", "delete_file", start, nil)
+	log.Record(withUser(req, "alice"), "resources/read", "file:///etc/shadow", start, errors.New("denied"))
+
+	var records []AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	want := []AuditRecord{
+		{Time: start.Add(250 * time.Millisecond), User: "mallory", Remote: "10.0.0.7", Method: MethodLogin,
+			Status: StatusUnauthorized, Error: "wrong user name or password"},
+		{Time: start, User: "alice", Remote: "10.0.0.7", Method: "tools/call", Target: "delete_file",
+			Status: StatusOK, DurationMS: 250},
+		{Time: start, User: "alice", Remote: "10.0.0.7", Method: "resources/read", Target: "file:///etc/shadow",
+			Status: StatusError, Error: "denied", DurationMS: 250},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %+v\nwant %+v", records, want)
+	}
+
+	var none *AuditLog
+	none.Record(req, "tools/call", "greet", start, nil)
+	none.Login(req, "alice", nil)
+}
@@ -0,0 +1,235 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+// Decisions on an approval.
+const (
+	DecisionApproved = "approved"
+	DecisionRejected = "rejected"
+)
+
+// ErrNoApproval is returned when deciding on an approval nobody asked for.
+var ErrNoApproval = errors.New("no approval requested")
+
+// Approval is the request to run a step marked approve: true, and the decision on it.
+type Approval struct {
+	Requested time.Time      `json:"requested"`
+	Decided   time.Time      `json:"decided,omitzero"`
+	Params    map[string]any `json:"params,omitempty"`
+	Run       string         `json:"run"`
+	Step      string         `json:"step"`
+	Tool      string         `json:"tool"`
+	Decision  string         `json:"decision,omitempty"`
+	DecidedBy string         `json:"decided_by,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+}
+
+// Approvals keeps approvals on disk, one file per step under a directory per run, so a run
+// waiting for approval can be approved from another terminal.
+type Approvals struct {
+	Dir string
+	// Poll is how often Wait looks for a decision.
+	Poll time.Duration
+	// Clock stamps requests and decisions and paces Wait. It defaults to the wall clock.
+	Clock clock.Clock
+}
+
+// GetApprovalsPath returns the directory approvals are kept in.
+func GetApprovalsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".mcpt", "approvals"), nil
+}
+
+// RunID names the run journaled to journalPath, e.g. deploy-3f2a9c. It is the same each time
+// the run is resumed.
+func RunID(journalPath string) string {
+	abs, err := filepath.Abs(journalPath)
+	if err != nil {
+		abs = journalPath
+	}
+	sum := sha256.Sum256([]byte(abs))
+	stem, _, _ := strings.Cut(filepath.Base(journalPath), ".")
+	return stem + "-" + hex.EncodeToString(sum[:3])
+}
+
+func (a Approvals) path(run, step string) (string, error) {
+	for _, name := range []string{run, step} {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return "", fmt.Errorf("invalid run or step %q", name)
+		}
+	}
+	return filepath.Join(a.Dir, run, step+".json"), nil
+}
+
+// Request records that a step waits for approval, replacing any earlier decision on it.
+func (a Approvals) Request(approval Approval) error {
+	approval.Requested = clock.OrReal(a.Clock).Now().UTC()
+	approval.Decided, approval.Decision, approval.DecidedBy, approval.Reason = time.Time{}, "", "", ""
+	return a.write(approval)
+}
+
+// Decide approves or rejects the step of a run waiting for approval.
+func (a Approvals) Decide(run, step string, approved bool, by, reason string) (Approval, error) {
+	approval, err := a.Get(run, step)
+	if err != nil {
+		return Approval{}, err
+	}
+	if approval.Decision != "" {
+		return approval, fmt.Errorf("step %s of run %s was already %s by %s", step, run, approval.Decision, approval.DecidedBy)
+	}
+	approval.Decision = DecisionRejected
+	if approved {
+		approval.Decision = DecisionApproved
+	}
+	approval.Decided = clock.OrReal(a.Clock).Now().UTC()
+	approval.DecidedBy, approval.Reason = by, reason
+	return approval, a.write(approval)
+}
+
+// Get returns the approval of the step of a run.
+func (a Approvals) Get(run, step string) (Approval, error) {
+	var approval Approval
+	path, err := a.path(run, step)
+	if err != nil {
+		return approval, err
+	}
+	// #nosec G304 - the path is checked to stay within the approvals directory
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return approval, fmt.Errorf("%w for step %s of run %s", ErrNoApproval, step, run)
+	}
+	if err != nil {
+		return approval, fmt.Errorf("failed to read approval: %w", err)
+	}
+	if err = json.Unmarshal(data, &approval); err != nil {
+		return approval, fmt.Errorf("invalid approval %s: %w", path, err)
+	}
+	return approval, nil
+}
+
+// Pending returns the approvals waiting for a decision, oldest first.
+func (a Approvals) Pending() ([]Approval, error) {
+	paths, err := filepath.Glob(filepath.Join(a.Dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var pending []Approval
+	for _, path := range paths {
+		run, step := filepath.Base(filepath.Dir(path)), strings.TrimSuffix(filepath.Base(path), ".json")
+		approval, err := a.Get(run, step)
+		if err != nil {
+			return nil, err
+		}
+		if approval.Decision == "" {
+			pending = append(pending, approval)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Requested.Before(pending[j].Requested) })
+	return pending, nil
+}
+
+// Wait waits until the step of a run is decided on, or ctx is done.
+func (a Approvals) Wait(ctx context.Context, run, step string) (Approval, error) {
+	poll := a.Poll
+	if poll <= 0 {
+		poll = 500 * time.Millisecond
+	}
+	for {
+		approval, err := a.Get(run, step)
+		if err != nil {
+			return approval, err
+		}
+		if approval.Decision != "" {
+			return approval, nil
+		}
+		if err = clock.Sleep(ctx, clock.OrReal(a.Clock), poll); err != nil {
+			return approval, err
+		}
+	}
+}
+
+func (a Approvals) write(approval Approval) error {
+	path, err := a.path(approval.Run, approval.Step)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create approvals directory: %w", err)
+	}
+	data, err := json.MarshalIndent(approval, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Written to a temporary file first so a waiting run never reads half an approval
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write approval: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write approval: %w", err)
+	}
+	return nil
+}
+
+// NotifyWebhook posts an approval request to url as JSON. The webhook may decide on it by
+// answering with {"approved": true} or {"approved": false, "reason": "..."}; decided is false if
+// it answers with anything else, and the approval is left to be decided some other way.
+func NotifyWebhook(ctx context.Context, client *http.Client, url string, approval Approval) (decided, approved bool, reason string, err error) {
+	body, err := json.Marshal(map[string]any{
+		"run":     approval.Run,
+		"step":    approval.Step,
+		"tool":    approval.Tool,
+		"params":  approval.Params,
+		"approve": fmt.Sprintf("mcp approve %s %s", approval.Run, approval.Step),
+		"reject":  fmt.Sprintf("mcp approve --reject %s %s", approval.Run, approval.Step),
+	})
+	if err != nil {
+		return false, false, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, false, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, false, "", fmt.Errorf("approval webhook failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, false, "", fmt.Errorf("approval webhook failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var answer struct {
+		Approved *bool  `json:"approved"`
+		Reason   string `json:"reason"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&answer) != nil || answer.Approved == nil {
+		return false, false, "", nil
+	}
+	return true, *answer.Approved, answer.Reason, nil
+}
@@ -0,0 +1,129 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+func TestApprovals(t *testing.T) {
+	approvals := Approvals{Dir: t.TempDir(), Poll: time.Millisecond}
+	if _, err := approvals.Decide("deploy-1", "rollout", true, "alex", ""); !errors.Is(err, ErrNoApproval) {
+		t.Fatalf("Decide() without a request error = %v, want ErrNoApproval", err)
+	}
+
+	request := Approval{Run: "deploy-1", Step: "rollout", Tool: "rollout", Params: map[string]any{"env": "prod"}}
+	if err := approvals.Request(request); err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	pending, err := approvals.Pending()
+	if err != nil || len(pending) != 1 || pending[0].Params["env"] != "prod" {
+		t.Fatalf("Pending() = %+v, %v, want the requested approval", pending, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = approvals.Decide("deploy-1", "rollout", false, "alex", "change freeze")
+	}()
+	decision, err := approvals.Wait(ctx, "deploy-1", "rollout")
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if decision.Decision != DecisionRejected || decision.DecidedBy != "alex" || decision.Reason != "change freeze" {
+		t.Errorf("Wait() = %+v, want the rejection", decision)
+	}
+	if _, err = approvals.Decide("deploy-1", "rollout", true, "sam", ""); err == nil {
+		t.Error("deciding twice succeeded, want an error")
+	}
+	if pending, _ = approvals.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() = %+v after the decision, want none", pending)
+	}
+
+	// Asking again, as a resumed run does, replaces the earlier decision
+	if err = approvals.Request(request); err != nil {
+		t.Fatal(err)
+	}
+	if approval, _ := approvals.Get("deploy-1", "rollout"); approval.Decision != "" {
+		t.Errorf("Request() kept the decision %q", approval.Decision)
+	}
+
+	if _, err = approvals.Get("..", "rollout"); err == nil {
+		t.Error("Get() outside the approvals directory succeeded, want an error")
+	}
+}
+
+func TestApprovalsClock(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := clock.NewFake(start)
+	approvals := Approvals{Dir: t.TempDir(), Poll: time.Minute, Clock: now}
+	if err := approvals.Request(Approval{Run: "deploy-1", Step: "rollout", Tool: "rollout"}); err != nil {
+		t.Fatal(err)
+	}
+
+	decisions := make(chan Approval, 1)
+	go func() {
+		decision, _ := approvals.Wait(context.Background(), "deploy-1", "rollout")
+		decisions <- decision
+	}()
+	for now.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	now.Advance(30 * time.Second)
+	if _, err := approvals.Decide("deploy-1", "rollout", true, "alex", ""); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-decisions:
+		t.Fatal("Wait() returned before polling again")
+	case <-time.After(10 * time.Millisecond):
+	}
+	now.Advance(30 * time.Second)
+
+	decision := <-decisions
+	if !decision.Requested.Equal(start) || !decision.Decided.Equal(start.Add(30*time.Second)) {
+		t.Errorf("requested at %s and decided at %s, want the times of the fake clock", decision.Requested, decision.Decided)
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	var answer string
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(answer))
+	}))
+	defer server.Close()
+
+	approval := Approval{Run: "deploy-1", Step: "rollout", Tool: "rollout"}
+
+	answer = `{"approved": false, "reason": "no ticket"}`
+	decided, approved, reason, err := NotifyWebhook(context.Background(), server.Client(), server.URL, approval)
+	if err != nil || !decided || approved || reason != "no ticket" {
+		t.Errorf("NotifyWebhook() = %v, %v, %q, %v, want a rejection", decided, approved, reason, err)
+	}
+	if got["approve"] != "mcp approve deploy-1 rollout" {
+		t.Errorf("webhook was posted %v", got)
+	}
+
+	answer = `ok`
+	if decided, _, _, err = NotifyWebhook(context.Background(), server.Client(), server.URL, approval); err != nil || decided {
+		t.Errorf("NotifyWebhook() = %v, %v, want the approval left undecided", decided, err)
+	}
+}
+
+func TestRunID(t *testing.T) {
+	if RunID("deploy.yaml.journal") != RunID("deploy.yaml.journal") {
+		t.Error("RunID() differs for the same journal")
+	}
+	if RunID("a/deploy.yaml.journal") == RunID("b/deploy.yaml.journal") {
+		t.Error("RunID() is the same for different journals")
+	}
+}
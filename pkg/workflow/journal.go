@@ -0,0 +1,160 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/f/mcptools/pkg/clock"
+)
+
+// Statuses of a step in a journal.
+const (
+	StatusStarted   = "started"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Record is one line of a journal.
+type Record struct {
+	Time   time.Time      `json:"time"`
+	Result map[string]any `json:"result,omitempty"`
+	Step   string         `json:"step"`
+	Status string         `json:"status"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// Journal records on disk when each step of a run starts and how it ends, so a run that was
+// interrupted can be resumed without calling completed steps again. Every record is synced to
+// disk before the run goes on.
+type Journal struct {
+	file   *os.File
+	clock  clock.Clock
+	last   map[string]Record
+	loaded int
+}
+
+// OpenJournal opens the journal at path. With resume, the records of the earlier run are
+// loaded and new records appended; otherwise the journal starts empty.
+func OpenJournal(path string, resume bool) (*Journal, error) {
+	j := &Journal{last: map[string]Record{}, clock: clock.Real}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if err := j.load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// #nosec G304 - the journal path is provided explicitly by the user
+	file, err := os.OpenFile(path, flags, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	j.file = file
+	return j, nil
+}
+
+// load reads the records of an earlier run. A record cut short by the interruption can only be
+// the last one; it is dropped from the file so new records start on a line of their own.
+func (j *Journal) load(path string) error {
+	// #nosec G304 - the journal path is provided explicitly by the user
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	for offset, line := 0, 1; offset < len(data); line++ {
+		end := bytes.IndexByte(data[offset:], '\n')
+		var record Record
+		if end < 0 || json.Unmarshal(data[offset:offset+end], &record) != nil {
+			if end < 0 || offset+end+1 == len(data) {
+				return os.Truncate(path, int64(offset))
+			}
+			return fmt.Errorf("invalid journal %s, line %d", path, line)
+		}
+		j.last[record.Step] = record
+		j.loaded++
+		offset += end + 1
+	}
+	return nil
+}
+
+// Loaded returns the number of records loaded from an earlier run.
+func (j *Journal) Loaded() int {
+	return j.loaded
+}
+
+// Done returns the number of steps recorded as completed.
+func (j *Journal) Done() int {
+	done := 0
+	for _, record := range j.last {
+		if record.Status == StatusCompleted {
+			done++
+		}
+	}
+	return done
+}
+
+// Completed returns the result of a step that completed in an earlier run.
+func (j *Journal) Completed(step string) (map[string]any, bool) {
+	record, ok := j.last[step]
+	if !ok || record.Status != StatusCompleted {
+		return nil, false
+	}
+	return record.Result, true
+}
+
+// Interrupted reports whether a step started in an earlier run without being recorded as
+// completed or failed, so it may or may not have had its effect.
+func (j *Journal) Interrupted(step string) bool {
+	return j.last[step].Status == StatusStarted
+}
+
+// Start records that a step is about to run.
+func (j *Journal) Start(step string) error {
+	return j.write(Record{Step: step, Status: StatusStarted})
+}
+
+// Complete records the result of a step.
+func (j *Journal) Complete(step string, result map[string]any) error {
+	return j.write(Record{Step: step, Status: StatusCompleted, Result: result})
+}
+
+// Fail records that a step failed; it runs again when the run is resumed.
+func (j *Journal) Fail(step string, err error) error {
+	return j.write(Record{Step: step, Status: StatusFailed, Error: err.Error()})
+}
+
+// SetClock sets the clock records are stamped with.
+func (j *Journal) SetClock(c clock.Clock) {
+	j.clock = c
+}
+
+// Close closes the journal.
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+func (j *Journal) write(record Record) error {
+	record.Time = j.clock.Now().UTC()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to journal step %s: %w", record.Step, err)
+	}
+	if _, err = j.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to journal step %s: %w", record.Step, err)
+	}
+	if err = j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to journal step %s: %w", record.Step, err)
+	}
+	j.last[record.Step] = record
+	return nil
+}
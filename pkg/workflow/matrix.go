@@ -0,0 +1,66 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Axis is an input a workflow is run with once for each of several values.
+type Axis struct {
+	Name   string
+	Values []string
+}
+
+// ParseAxis parses an axis written as name=value,value,...
+func ParseAxis(spec string) (Axis, error) {
+	name, list, ok := strings.Cut(spec, "=")
+	if !ok || name == "" {
+		return Axis{}, fmt.Errorf("invalid matrix %q: expected name=value,value", spec)
+	}
+
+	axis := Axis{Name: name}
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			axis.Values = append(axis.Values, value)
+		}
+	}
+	if len(axis.Values) == 0 {
+		return Axis{}, fmt.Errorf("invalid matrix %q: no values", spec)
+	}
+	return axis, nil
+}
+
+// Expand returns the input values of each run of a matrix: every combination of the values of
+// axes, on top of the fixed values. The first axis varies slowest.
+func Expand(values map[string]string, axes []Axis) []map[string]string {
+	runs := []map[string]string{copyValues(values)}
+	for _, axis := range axes {
+		var next []map[string]string
+		for _, run := range runs {
+			for _, value := range axis.Values {
+				expanded := copyValues(run)
+				expanded[axis.Name] = value
+				next = append(next, expanded)
+			}
+		}
+		runs = next
+	}
+	return runs
+}
+
+func copyValues(values map[string]string) map[string]string {
+	copied := make(map[string]string, len(values))
+	for name, value := range values {
+		copied[name] = value
+	}
+	return copied
+}
+
+// Label names a run of a matrix by its values on the axes, e.g. env=prod region=eu.
+func Label(values map[string]string, axes []Axis) string {
+	parts := make([]string, len(axes))
+	for i, axis := range axes {
+		parts[i] = axis.Name + "=" + values[axis.Name]
+	}
+	return strings.Join(parts, " ")
+}
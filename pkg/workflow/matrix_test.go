@@ -0,0 +1,42 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAxis(t *testing.T) {
+	axis, err := ParseAxis("region=us, eu")
+	if err != nil {
+		t.Fatalf("ParseAxis() error = %v", err)
+	}
+	if want := (Axis{Name: "region", Values: []string{"us", "eu"}}); !reflect.DeepEqual(axis, want) {
+		t.Errorf("ParseAxis() = %+v, want %+v", axis, want)
+	}
+
+	for _, invalid := range []string{"region", "=us", "region=", "region= , "} {
+		if _, err = ParseAxis(invalid); err == nil {
+			t.Errorf("ParseAxis(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	axes := []Axis{
+		{Name: "env", Values: []string{"staging", "prod"}},
+		{Name: "region", Values: []string{"us", "eu"}},
+	}
+	runs := Expand(map[string]string{"version": "2"}, axes)
+
+	var labels []string
+	for _, run := range runs {
+		if run["version"] != "2" {
+			t.Errorf("run %v lost the value of version", run)
+		}
+		labels = append(labels, Label(run, axes))
+	}
+	want := []string{"env=staging region=us", "env=staging region=eu", "env=prod region=us", "env=prod region=eu"}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("Expand() runs = %v, want %v", labels, want)
+	}
+}
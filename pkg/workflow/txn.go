@@ -0,0 +1,162 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Statuses of the rollback of a step.
+const (
+	RollbackCompensated = "compensated"
+	RollbackFailed      = "failed"
+	RollbackSkipped     = "skipped"
+)
+
+// Rollback is how a completed step was undone after a later step of a transaction failed.
+type Rollback struct {
+	Step   string `json:"step"`
+	Tool   string `json:"tool,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RollbackError is returned by Transaction.Run when a step fails. It tells how the steps that
+// completed before it were undone.
+type RollbackError struct {
+	Err       error
+	Step      string
+	Rollbacks []Rollback
+}
+
+// Error implements error.
+func (e *RollbackError) Error() string {
+	if len(e.Rollbacks) == 0 {
+		return fmt.Sprintf("step %s: %v; no earlier step to roll back", e.Step, e.Err)
+	}
+	var incomplete []string
+	for _, rollback := range e.Rollbacks {
+		if rollback.Status != RollbackCompensated {
+			incomplete = append(incomplete, rollback.Step)
+		}
+	}
+	if len(incomplete) > 0 {
+		return fmt.Sprintf("step %s: %v; the rollback is incomplete, %s not undone", e.Step, e.Err, strings.Join(incomplete, ", "))
+	}
+	return fmt.Sprintf("step %s: %v; rolled back %d step(s)", e.Step, e.Err, len(e.Rollbacks))
+}
+
+// Unwrap returns the error of the failed step.
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
+
+// RolledBack reports whether every completed step was compensated.
+func (e *RollbackError) RolledBack() bool {
+	for _, rollback := range e.Rollbacks {
+		if rollback.Status != RollbackCompensated {
+			return false
+		}
+	}
+	return true
+}
+
+// Transaction runs the steps of a workflow as a unit, as far as the tools allow: when a step
+// fails, the compensating calls of the steps completed before it are made in reverse order.
+// Compensations are best effort; one failing does not stop the others. The failed step itself
+// is not compensated, so a step cut short after it had its effect is left as it is.
+type Transaction struct {
+	// Call calls a tool and returns its result.
+	Call func(ctx context.Context, tool string, params map[string]any) (map[string]any, error)
+	// Inputs are the values of the inputs of the workflow, see Workflow.Bind.
+	Inputs map[string]any
+	// Progress receives a line per step and compensation.
+	Progress io.Writer
+}
+
+// completedStep is a step of a transaction that completed, with its result.
+type completedStep struct {
+	step   Step
+	result map[string]any
+}
+
+// Run runs the steps of wf and returns the result of the last step. When a step fails, the
+// steps completed before it are rolled back and a *RollbackError is returned. Steps needing
+// approval are refused before any step runs, as a transaction cannot wait for approvals.
+func (t *Transaction) Run(ctx context.Context, wf *Workflow) (map[string]any, error) {
+	for _, step := range wf.Steps {
+		if step.Approve {
+			return nil, fmt.Errorf("step %s needs approval, which transactions cannot wait for; run the workflow with mcp run", step.ID)
+		}
+	}
+
+	results := map[string]map[string]any{}
+	var completed []completedStep
+	var last map[string]any
+
+	for i, step := range wf.Steps {
+		prefix := fmt.Sprintf("[%d/%d] %s (%s)", i+1, len(wf.Steps), step.ID, step.Tool)
+
+		result, err := t.call(ctx, step.Tool, step.Params, results)
+		if err != nil {
+			fmt.Fprintf(t.Progress, "%s: failed\n", prefix)
+			return nil, &RollbackError{Step: step.ID, Err: err, Rollbacks: t.rollback(ctx, completed, results)}
+		}
+		fmt.Fprintf(t.Progress, "%s: completed\n", prefix)
+		results[step.ID], last = result, result
+		completed = append(completed, completedStep{step: step, result: result})
+	}
+	return last, nil
+}
+
+// rollback makes the compensating calls of the completed steps, the last one first. They are
+// made even if ctx is done, since the run was most likely interrupted.
+func (t *Transaction) rollback(ctx context.Context, completed []completedStep, results map[string]map[string]any) []Rollback {
+	if len(completed) == 0 {
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	fmt.Fprintf(t.Progress, "Rolling back %d completed step(s)\n", len(completed))
+
+	rollbacks := make([]Rollback, 0, len(completed))
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i].step
+		rollback := Rollback{Step: step.ID, Status: RollbackSkipped}
+		if step.Compensate == nil {
+			fmt.Fprintf(t.Progress, "  %s: no compensation declared, left as it is\n", step.ID)
+			rollbacks = append(rollbacks, rollback)
+			continue
+		}
+
+		rollback.Tool = step.Compensate.Tool
+		if _, err := t.call(ctx, step.Compensate.Tool, step.Compensate.Params, results); err != nil {
+			rollback.Status, rollback.Error = RollbackFailed, err.Error()
+			fmt.Fprintf(t.Progress, "  %s: compensation %s failed: %v\n", step.ID, step.Compensate.Tool, err)
+		} else {
+			rollback.Status = RollbackCompensated
+			fmt.Fprintf(t.Progress, "  %s: compensated with %s\n", step.ID, step.Compensate.Tool)
+		}
+		rollbacks = append(rollbacks, rollback)
+	}
+	return rollbacks
+}
+
+// call resolves params against the inputs and results, and calls tool with them. A result the
+// tool reports as an error is an error.
+func (t *Transaction) call(ctx context.Context, tool string, params map[string]any, results map[string]map[string]any) (map[string]any, error) {
+	resolved, err := Resolve(params, Scope{Inputs: t.Inputs, Results: results})
+	if err != nil {
+		return nil, err
+	}
+	resolvedParams, _ := resolved.(map[string]any)
+
+	result, err := t.Call(ctx, tool, resolvedParams)
+	if err != nil {
+		return nil, err
+	}
+	if isError, _ := result["isError"].(bool); isError {
+		return nil, fmt.Errorf("tool reported an error: %s", resultText(result))
+	}
+	return result, nil
+}
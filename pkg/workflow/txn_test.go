@@ -0,0 +1,133 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testTransaction() *Workflow {
+	return &Workflow{Steps: []Step{
+		{ID: "create", Tool: "create", Params: map[string]any{"path": "{{ inputs.name }}"},
+			Compensate: &Compensation{Tool: "delete", Params: map[string]any{"path": "{{ steps.create.result.content[0].text }}"}}},
+		{ID: "label", Tool: "label"},
+		{ID: "tag", Tool: "tag", Compensate: &Compensation{Tool: "untag"}},
+		{ID: "publish", Tool: "publish"},
+	}}
+}
+
+func TestTransactionRollsBackInReverseOrder(t *testing.T) {
+	server := &fakeServer{fail: map[string]bool{"publish": true}}
+	var paths []any
+	txn := &Transaction{
+		Call: func(ctx context.Context, tool string, params map[string]any) (map[string]any, error) {
+			if tool == "delete" {
+				paths = append(paths, params["path"])
+			}
+			return server.call(ctx, tool, params)
+		},
+		Inputs:   map[string]any{"name": "notes.md"},
+		Progress: io.Discard,
+	}
+
+	_, err := txn.Run(context.Background(), testTransaction())
+	var rollbackErr *RollbackError
+	if !errors.As(err, &rollbackErr) || rollbackErr.Step != "publish" {
+		t.Fatalf("Run() error = %v, want a rollback after publish failed", err)
+	}
+	if want := []string{"create", "label", "tag", "publish", "untag", "delete"}; !reflect.DeepEqual(server.calls, want) {
+		t.Errorf("called %v, want %v", server.calls, want)
+	}
+	if !reflect.DeepEqual(paths, []any{"create:notes.md"}) {
+		t.Errorf("deleted %v, want the path in the result of create", paths)
+	}
+
+	want := []Rollback{
+		{Step: "tag", Tool: "untag", Status: RollbackCompensated},
+		{Step: "label", Status: RollbackSkipped},
+		{Step: "create", Tool: "delete", Status: RollbackCompensated},
+	}
+	if !reflect.DeepEqual(rollbackErr.Rollbacks, want) {
+		t.Errorf("rollbacks = %+v, want %+v", rollbackErr.Rollbacks, want)
+	}
+	if rollbackErr.RolledBack() || !strings.Contains(err.Error(), "label not undone") {
+		t.Errorf("Run() error = %v, want the step without compensation reported", err)
+	}
+}
+
+func TestTransactionCompensatesDespiteFailures(t *testing.T) {
+	server := &fakeServer{fail: map[string]bool{"publish": true, "untag": true}}
+	wf := testTransaction()
+	wf.Steps[1].Compensate = &Compensation{Tool: "unlabel"}
+	txn := &Transaction{Call: server.call, Inputs: map[string]any{"name": "a"}, Progress: io.Discard}
+
+	_, err := txn.Run(context.Background(), wf)
+	var rollbackErr *RollbackError
+	if !errors.As(err, &rollbackErr) {
+		t.Fatalf("Run() error = %v, want a rollback", err)
+	}
+	if want := []string{"create", "label", "tag", "publish", "untag", "unlabel", "delete"}; !reflect.DeepEqual(server.calls, want) {
+		t.Errorf("called %v, want every compensation tried despite untag failing", server.calls)
+	}
+	if rollbackErr.Rollbacks[0].Status != RollbackFailed || rollbackErr.Rollbacks[0].Error == "" {
+		t.Errorf("rollbacks = %+v, want the failed compensation of tag reported", rollbackErr.Rollbacks)
+	}
+}
+
+func TestTransactionCommits(t *testing.T) {
+	server := &fakeServer{}
+	txn := &Transaction{Call: server.call, Inputs: map[string]any{"name": "a"}, Progress: io.Discard}
+
+	result, err := txn.Run(context.Background(), testTransaction())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if text := resultText(result); text != "publish" {
+		t.Errorf("Run() returned %q, want the result of the last step", text)
+	}
+	if want := []string{"create", "label", "tag", "publish"}; !reflect.DeepEqual(server.calls, want) {
+		t.Errorf("called %v, want no compensation", server.calls)
+	}
+}
+
+func TestTransactionRefusesApprovals(t *testing.T) {
+	wf := testTransaction()
+	wf.Steps[3].Approve = true
+	server := &fakeServer{}
+	txn := &Transaction{Call: server.call, Progress: io.Discard}
+
+	if _, err := txn.Run(context.Background(), wf); err == nil || len(server.calls) != 0 {
+		t.Errorf("Run() error = %v, calls = %v, want the transaction refused before any step", err, server.calls)
+	}
+}
+
+func TestLoadCompensations(t *testing.T) {
+	wf, err := Load(writeFile(t, "plan.yaml", `steps:
+  - id: create
+    tool: create_issue
+    compensate:
+      tool: close_issue
+      params:
+        number: "{{ steps.create.result.number }}"
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c := wf.Steps[0].Compensate; c == nil || c.Tool != "close_issue" {
+		t.Errorf("Load() compensation = %+v", c)
+	}
+
+	invalid := map[string]string{
+		"missing tool":  "steps:\n  - {id: a, tool: x, compensate: {params: {p: 1}}}\n",
+		"forward ref":   "steps:\n  - {id: a, tool: x, compensate: {tool: y, params: {p: \"{{ steps.b.result.x }}\"}}}\n  - {id: b, tool: y}\n",
+		"unknown input": "steps:\n  - {id: a, tool: x, compensate: {tool: y, params: {p: \"{{ inputs.env }}\"}}}\n",
+	}
+	for name, content := range invalid {
+		if _, err = Load(writeFile(t, "plan.yaml", content)); err == nil {
+			t.Errorf("%s: Load() succeeded, want an error", name)
+		}
+	}
+}
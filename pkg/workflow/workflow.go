@@ -0,0 +1,371 @@
+// Package workflow runs workflows: tool calls made one after the other, where the parameters of
+// a step may refer to the results of earlier steps, such as those written by mcp suggest-chain.
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow is a list of steps, run in order, and the inputs they are parameterized with.
+type Workflow struct {
+	Inputs map[string]Input `yaml:"inputs"`
+	Steps  []Step           `yaml:"steps"`
+}
+
+// Input is a value given when a workflow is run, such as the environment to run it against.
+type Input struct {
+	Default     any    `yaml:"default"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+}
+
+// Step calls a tool. String parameters may refer to inputs as {{ inputs.<name> }} and to the
+// results of earlier steps as {{ steps.<id>.result.<path> }}; a parameter that is only a
+// reference takes the referenced value as is, with its type. Steps marked approve: true only
+// run once approved. Compensate is the call undoing the step, made by transactions when a later
+// step fails; mcp run ignores it.
+type Step struct {
+	Params     map[string]any `yaml:"params"`
+	Compensate *Compensation  `yaml:"compensate"`
+	ID         string         `yaml:"id"`
+	Tool       string         `yaml:"tool"`
+	Approve    bool           `yaml:"approve"`
+}
+
+// Compensation is a tool call undoing the effect of a step, such as deleting what it created.
+// Its parameters may refer to inputs and to the results of the step and earlier steps.
+type Compensation struct {
+	Params map[string]any `yaml:"params"`
+	Tool   string         `yaml:"tool"`
+}
+
+// reference matches {{ steps.<id>.result.<path> }} and {{ inputs.<name> }}.
+var reference = regexp.MustCompile(`\{\{\s*(?:steps\.([A-Za-z0-9_-]+)\.result\.([^}\s]+)|inputs\.([A-Za-z0-9_-]+))\s*\}\}`)
+
+// Scope holds the values references are resolved with.
+type Scope struct {
+	Inputs  map[string]any
+	Results map[string]map[string]any
+}
+
+// Load reads a workflow from a YAML file and checks that its steps have unique IDs and refer
+// only to declared inputs and earlier steps.
+func Load(path string) (*Workflow, error) {
+	// #nosec G304 - the workflow path is provided explicitly by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+
+	var wf Workflow
+	if err = yaml.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("invalid workflow %s: %w", path, err)
+	}
+	if len(wf.Steps) == 0 {
+		return nil, fmt.Errorf("invalid workflow %s: no steps", path)
+	}
+
+	seen := map[string]bool{}
+	for i, step := range wf.Steps {
+		if step.ID == "" || step.Tool == "" {
+			return nil, fmt.Errorf("invalid workflow %s: step %d needs an id and a tool", path, i+1)
+		}
+		if seen[step.ID] {
+			return nil, fmt.Errorf("invalid workflow %s: duplicate step id %q", path, step.ID)
+		}
+		for _, m := range references(step.Params) {
+			if _, declared := wf.Inputs[m[3]]; m[3] != "" && !declared {
+				return nil, fmt.Errorf("invalid workflow %s: step %s refers to input %s, which is not declared", path, step.ID, m[3])
+			}
+			if m[1] != "" && !seen[m[1]] {
+				return nil, fmt.Errorf("invalid workflow %s: step %s refers to %s, which does not run before it", path, step.ID, m[1])
+			}
+		}
+		seen[step.ID] = true
+
+		if step.Compensate == nil {
+			continue
+		}
+		if step.Compensate.Tool == "" {
+			return nil, fmt.Errorf("invalid workflow %s: the compensation of step %s needs a tool", path, step.ID)
+		}
+		for _, m := range references(step.Compensate.Params) {
+			if _, declared := wf.Inputs[m[3]]; m[3] != "" && !declared {
+				return nil, fmt.Errorf("invalid workflow %s: the compensation of step %s refers to input %s, which is not declared", path, step.ID, m[3])
+			}
+			if m[1] != "" && !seen[m[1]] {
+				return nil, fmt.Errorf("invalid workflow %s: the compensation of step %s refers to %s, which does not run before it", path, step.ID, m[1])
+			}
+		}
+	}
+	return &wf, nil
+}
+
+// references returns the matches of reference in value.
+func references(value any) [][]string {
+	var matches [][]string
+	switch v := value.(type) {
+	case string:
+		matches = append(matches, reference.FindAllStringSubmatch(v, -1)...)
+	case map[string]any:
+		for _, item := range v {
+			matches = append(matches, references(item)...)
+		}
+	case []any:
+		for _, item := range v {
+			matches = append(matches, references(item)...)
+		}
+	}
+	return matches
+}
+
+// Bind returns the values of the inputs of wf: the given values, or else their defaults.
+// Values that are valid JSON, such as 3, true or ["a"], are decoded; others are strings.
+func (wf *Workflow) Bind(values map[string]string) (map[string]any, error) {
+	inputs := map[string]any{}
+	for name, value := range values {
+		if _, declared := wf.Inputs[name]; !declared {
+			return nil, fmt.Errorf("unknown input %q", name)
+		}
+		var decoded any
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			decoded = value
+		}
+		inputs[name] = decoded
+	}
+	for name, input := range wf.Inputs {
+		if _, set := inputs[name]; set {
+			continue
+		}
+		if input.Required {
+			return nil, fmt.Errorf("input %q is required", name)
+		}
+		inputs[name] = input.Default
+	}
+	return inputs, nil
+}
+
+// Resolve replaces the references in value with the values of scope.
+func Resolve(value any, scope Scope) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if m := reference.FindStringSubmatch(v); m != nil && m[0] == strings.TrimSpace(v) {
+			return scope.lookup(m)
+		}
+		var err error
+		resolved := reference.ReplaceAllStringFunc(v, func(ref string) string {
+			found, lookupErr := scope.lookup(reference.FindStringSubmatch(ref))
+			if lookupErr != nil {
+				err = lookupErr
+				return ref
+			}
+			if s, ok := found.(string); ok {
+				return s
+			}
+			return fmt.Sprint(found)
+		})
+		return resolved, err
+	case map[string]any:
+		resolved := make(map[string]any, len(v))
+		for key, item := range v {
+			r, err := Resolve(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = r
+		}
+		return resolved, nil
+	case []any:
+		resolved := make([]any, len(v))
+		for i, item := range v {
+			r, err := Resolve(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+	}
+	return value, nil
+}
+
+// lookup returns the value a match of reference refers to.
+func (scope Scope) lookup(m []string) (any, error) {
+	if m[3] != "" {
+		value, ok := scope.Inputs[m[3]]
+		if !ok {
+			return nil, fmt.Errorf("inputs.%s: no such input", m[3])
+		}
+		return value, nil
+	}
+	return lookupResult(scope.Results, m[1], m[2])
+}
+
+// lookupResult finds path in the result of step id, or else in its structured content.
+func lookupResult(results map[string]map[string]any, id, path string) (any, error) {
+	result, ok := results[id]
+	if !ok {
+		return nil, fmt.Errorf("steps.%s.result.%s: step %s has no result", id, path, id)
+	}
+	if value, found := Lookup(result, path); found {
+		return value, nil
+	}
+	if structured, isMap := result["structuredContent"].(map[string]any); isMap {
+		if value, found := Lookup(structured, path); found {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("steps.%s.result.%s: not found in the result of %s", id, path, id)
+}
+
+// Lookup follows a path such as content[0].text through value. name[] collects name from every
+// item of an array.
+func Lookup(value any, path string) (any, bool) {
+	if path == "" {
+		return value, true
+	}
+	segment, rest, _ := strings.Cut(path, ".")
+
+	name, index := segment, ""
+	if i := strings.Index(segment, "["); i >= 0 && strings.HasSuffix(segment, "]") {
+		name, index = segment[:i], segment[i+1:len(segment)-1]
+	}
+
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	if value, ok = object[name]; !ok {
+		return nil, false
+	}
+	if segment == name {
+		return Lookup(value, rest)
+	}
+
+	items, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	if index == "" {
+		collected := make([]any, 0, len(items))
+		for _, item := range items {
+			if found, ok := Lookup(item, rest); ok {
+				collected = append(collected, found)
+			}
+		}
+		return collected, true
+	}
+	n, err := strconv.Atoi(index)
+	if err != nil || n < 0 || n >= len(items) {
+		return nil, false
+	}
+	return Lookup(items[n], rest)
+}
+
+// Runner runs the steps of a workflow, journaling each so an interrupted run can be resumed.
+type Runner struct {
+	// Call calls a tool and returns its result.
+	Call func(ctx context.Context, tool string, params map[string]any) (map[string]any, error)
+	// Idempotent reports whether calling tool twice has the same effect as calling it once.
+	Idempotent func(tool string) bool
+	// Confirm asks whether to run again a step that was interrupted while it ran, for tools
+	// that are not idempotent.
+	Confirm func(step Step) (bool, error)
+	// Approve waits for the approval of a step marked approve: true, called with its resolved
+	// parameters. It returns an error if the step is rejected.
+	Approve func(ctx context.Context, step Step, params map[string]any) error
+	// Inputs are the values of the inputs of the workflow, see Workflow.Bind.
+	Inputs map[string]any
+	// Journal records the progress of the run.
+	Journal *Journal
+	// Progress receives a line per step.
+	Progress io.Writer
+}
+
+// Run runs the steps of wf that have not completed yet and returns the result of the last step.
+// Steps completed in an earlier run are skipped and their journaled results used instead.
+func (r *Runner) Run(ctx context.Context, wf *Workflow) (map[string]any, error) {
+	results := map[string]map[string]any{}
+	var last map[string]any
+
+	for i, step := range wf.Steps {
+		prefix := fmt.Sprintf("[%d/%d] %s (%s)", i+1, len(wf.Steps), step.ID, step.Tool)
+
+		if result, done := r.Journal.Completed(step.ID); done {
+			fmt.Fprintf(r.Progress, "%s: completed in an earlier run, skipped\n", prefix)
+			results[step.ID], last = result, result
+			continue
+		}
+		if r.Journal.Interrupted(step.ID) && !r.Idempotent(step.Tool) {
+			again, err := r.Confirm(step)
+			if err != nil {
+				return nil, err
+			}
+			if !again {
+				return nil, fmt.Errorf("step %s was interrupted and may have run already; not running it again", step.ID)
+			}
+		}
+
+		resolved, err := Resolve(step.Params, Scope{Inputs: r.Inputs, Results: results})
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %w", step.ID, err)
+		}
+		params, _ := resolved.(map[string]any)
+
+		if step.Approve {
+			if r.Approve == nil {
+				return nil, fmt.Errorf("step %s needs approval, but there is no way to ask for it", step.ID)
+			}
+			fmt.Fprintf(r.Progress, "%s: waiting for approval\n", prefix)
+			if err = r.Approve(ctx, step, params); err != nil {
+				return nil, fmt.Errorf("step %s: %w", step.ID, err)
+			}
+		}
+
+		if err = r.Journal.Start(step.ID); err != nil {
+			return nil, err
+		}
+		result, err := r.Call(ctx, step.Tool, params)
+		if err == nil {
+			if isError, _ := result["isError"].(bool); isError {
+				err = fmt.Errorf("tool reported an error: %s", resultText(result))
+			}
+		}
+		if err != nil {
+			if journalErr := r.Journal.Fail(step.ID, err); journalErr != nil {
+				return nil, journalErr
+			}
+			fmt.Fprintf(r.Progress, "%s: failed\n", prefix)
+			return nil, fmt.Errorf("step %s: %w", step.ID, err)
+		}
+		if err = r.Journal.Complete(step.ID, result); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(r.Progress, "%s: completed\n", prefix)
+		results[step.ID], last = result, result
+	}
+	return last, nil
+}
+
+// resultText joins the text content of a tool result.
+func resultText(result map[string]any) string {
+	var texts []string
+	content, _ := result["content"].([]any)
+	for _, item := range content {
+		if m, ok := item.(map[string]any); ok {
+			if text, ok := m["text"].(string); ok {
+				texts = append(texts, text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,288 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeFile(t, "workflow.yaml", `steps:
+  - id: search
+    tool: search_files
+    params:
+      pattern: "*.go"
+  - id: read
+    tool: read_file
+    params:
+      path: "{{ steps.search.result.content[0].text }}"
+`)
+	wf, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(wf.Steps) != 2 || wf.Steps[1].Tool != "read_file" {
+		t.Errorf("Load() = %+v", wf)
+	}
+
+	invalid := map[string]string{
+		"no steps":      "steps: []\n",
+		"missing tool":  "steps:\n  - id: a\n",
+		"duplicate id":  "steps:\n  - {id: a, tool: x}\n  - {id: a, tool: y}\n",
+		"forward ref":   "steps:\n  - {id: a, tool: x, params: {p: \"{{ steps.b.result.x }}\"}}\n  - {id: b, tool: y}\n",
+		"not a mapping": "- a\n",
+		"unknown input": "steps:\n  - {id: a, tool: x, params: {p: \"{{ inputs.env }}\"}}\n",
+	}
+	for name, content := range invalid {
+		if _, err = Load(writeFile(t, "workflow.yaml", content)); err == nil {
+			t.Errorf("%s: Load() succeeded, want an error", name)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	results := map[string]map[string]any{
+		"search": {
+			"content":           []any{map[string]any{"type": "text", "text": "main.go"}},
+			"structuredContent": map[string]any{"count": float64(2), "files": []any{map[string]any{"path": "a"}, map[string]any{"path": "b"}}},
+		},
+	}
+
+	params := map[string]any{
+		"path":  "{{ steps.search.result.content[0].text }}",
+		"count": "{{steps.search.result.count}}",
+		"paths": "{{ steps.search.result.files[].path }}",
+		"note":  "found {{ steps.search.result.count }} files",
+		"fixed": true,
+	}
+	got, err := Resolve(params, Scope{Results: results})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	want := map[string]any{
+		"path":  "main.go",
+		"count": float64(2),
+		"paths": []any{"a", "b"},
+		"note":  "found 2 files",
+		"fixed": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %v, want %v", got, want)
+	}
+
+	if _, err = Resolve("{{ steps.search.result.missing }}", Scope{Results: results}); err == nil {
+		t.Error("Resolve() of a missing field succeeded, want an error")
+	}
+	if _, err = Resolve("x {{ steps.other.result.text }}", Scope{Results: results}); err == nil {
+		t.Error("Resolve() of a step without a result succeeded, want an error")
+	}
+}
+
+// fakeServer records the tool calls of a run and fails the tools listed in fail.
+type fakeServer struct {
+	calls []string
+	fail  map[string]bool
+}
+
+func (s *fakeServer) call(_ context.Context, tool string, params map[string]any) (map[string]any, error) {
+	s.calls = append(s.calls, tool)
+	if s.fail[tool] {
+		return nil, errors.New("server unavailable")
+	}
+	text := tool
+	if path, ok := params["path"].(string); ok {
+		text += ":" + path
+	}
+	return map[string]any{"content": []any{map[string]any{"type": "text", "text": text}}}, nil
+}
+
+func testWorkflow() *Workflow {
+	return &Workflow{Steps: []Step{
+		{ID: "search", Tool: "search"},
+		{ID: "read", Tool: "read", Params: map[string]any{"path": "{{ steps.search.result.content[0].text }}"}},
+		{ID: "write", Tool: "write"},
+	}}
+}
+
+func newRunner(t *testing.T, server *fakeServer, journal string, resume bool, confirm func(Step) (bool, error)) *Runner {
+	t.Helper()
+	j, err := OpenJournal(journal, resume)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	t.Cleanup(func() { _ = j.Close() })
+	return &Runner{
+		Call:       server.call,
+		Idempotent: func(tool string) bool { return tool != "write" },
+		Confirm:    confirm,
+		Journal:    j,
+		Progress:   io.Discard,
+	}
+}
+
+func TestBind(t *testing.T) {
+	wf := &Workflow{Inputs: map[string]Input{
+		"env":     {Required: true},
+		"region":  {Default: "us"},
+		"retries": {Default: 1},
+	}}
+
+	got, err := wf.Bind(map[string]string{"env": "staging", "retries": "3"})
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	want := map[string]any{"env": "staging", "region": "us", "retries": float64(3)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Bind() = %v, want %v", got, want)
+	}
+
+	if _, err = wf.Bind(map[string]string{"region": "eu"}); err == nil || !strings.Contains(err.Error(), "env") {
+		t.Errorf("Bind() without a required input error = %v", err)
+	}
+	if _, err = wf.Bind(map[string]string{"env": "prod", "zone": "a"}); err == nil || !strings.Contains(err.Error(), "zone") {
+		t.Errorf("Bind() with an unknown input error = %v", err)
+	}
+}
+
+func TestResolveInputs(t *testing.T) {
+	scope := Scope{Inputs: map[string]any{"env": "staging", "replicas": float64(2)}}
+	params := map[string]any{
+		"name":     "app-{{ inputs.env }}",
+		"replicas": "{{ inputs.replicas }}",
+	}
+
+	got, err := Resolve(params, scope)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	want := map[string]any{"name": "app-staging", "replicas": float64(2)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %v, want %v", got, want)
+	}
+}
+
+func TestRunResumesAfterFailure(t *testing.T) {
+	journal := filepath.Join(t.TempDir(), "journal")
+	never := func(Step) (bool, error) { t.Fatal("unexpected confirmation"); return false, nil }
+
+	server := &fakeServer{fail: map[string]bool{"read": true}}
+	if _, err := newRunner(t, server, journal, false, never).Run(context.Background(), testWorkflow()); err == nil {
+		t.Fatal("Run() succeeded, want the read step to fail")
+	}
+
+	server = &fakeServer{}
+	result, err := newRunner(t, server, journal, true, never).Run(context.Background(), testWorkflow())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if want := []string{"read", "write"}; !reflect.DeepEqual(server.calls, want) {
+		t.Errorf("resumed run called %v, want %v", server.calls, want)
+	}
+	if text := resultText(result); text != "write" {
+		t.Errorf("Run() returned %q, want the result of the last step", text)
+	}
+
+	// A completed run has nothing left to call
+	server = &fakeServer{}
+	if _, err = newRunner(t, server, journal, true, never).Run(context.Background(), testWorkflow()); err != nil || len(server.calls) != 0 {
+		t.Errorf("resuming a completed run called %v (error %v), want nothing", server.calls, err)
+	}
+}
+
+func TestRunConfirmsInterruptedSteps(t *testing.T) {
+	// The run was interrupted while write ran, after read, and the last record was cut short
+	journal := writeFile(t, "journal", `{"step":"search","status":"completed","result":{"content":[{"type":"text","text":"main.go"}]}}
+{"step":"read","status":"started"}
+{"step":"write","status":"started"}
+{"step":"wri`)
+
+	var asked []string
+	decline := func(step Step) (bool, error) {
+		asked = append(asked, step.ID)
+		return false, nil
+	}
+
+	server := &fakeServer{}
+	_, err := newRunner(t, server, journal, true, decline).Run(context.Background(), testWorkflow())
+	if err == nil || !strings.Contains(err.Error(), "step write was interrupted") {
+		t.Fatalf("Run() error = %v, want the declined step to stop the run", err)
+	}
+	// read is idempotent, so it runs again without asking
+	if !reflect.DeepEqual(asked, []string{"write"}) || !reflect.DeepEqual(server.calls, []string{"read"}) {
+		t.Errorf("asked about %v and called %v, want only write asked and read called", asked, server.calls)
+	}
+
+	accept := func(Step) (bool, error) { return true, nil }
+	server = &fakeServer{}
+	if _, err = newRunner(t, server, journal, true, accept).Run(context.Background(), testWorkflow()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !reflect.DeepEqual(server.calls, []string{"write"}) {
+		t.Errorf("called %v, want only the confirmed write step", server.calls)
+	}
+}
+
+func TestRunFailsOnToolError(t *testing.T) {
+	journal := filepath.Join(t.TempDir(), "journal")
+	j, err := OpenJournal(journal, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = j.Close() }()
+
+	runner := &Runner{
+		Call: func(context.Context, string, map[string]any) (map[string]any, error) {
+			return map[string]any{"isError": true, "content": []any{map[string]any{"type": "text", "text": "no such file"}}}, nil
+		},
+		Journal:  j,
+		Progress: io.Discard,
+	}
+	_, err = runner.Run(context.Background(), &Workflow{Steps: []Step{{ID: "read", Tool: "read"}}})
+	if err == nil || !strings.Contains(err.Error(), "no such file") {
+		t.Errorf("Run() error = %v, want the tool's error", err)
+	}
+	if _, done := j.Completed("read"); done {
+		t.Error("a step whose tool reported an error was journaled as completed")
+	}
+}
+
+func TestRunWaitsForApproval(t *testing.T) {
+	wf := testWorkflow()
+	wf.Steps[2].Approve = true
+
+	var approved []string
+	reject := func(_ context.Context, step Step, _ map[string]any) error {
+		approved = append(approved, step.ID)
+		return errors.New("rejected by ops")
+	}
+
+	server := &fakeServer{}
+	runner := newRunner(t, server, filepath.Join(t.TempDir(), "journal"), false, nil)
+	runner.Approve = reject
+	_, err := runner.Run(context.Background(), wf)
+	if err == nil || !strings.Contains(err.Error(), "rejected by ops") {
+		t.Fatalf("Run() error = %v, want the rejection", err)
+	}
+	if !reflect.DeepEqual(approved, []string{"write"}) || !reflect.DeepEqual(server.calls, []string{"search", "read"}) {
+		t.Errorf("asked to approve %v and called %v, want only write asked and not called", approved, server.calls)
+	}
+
+	runner = newRunner(t, &fakeServer{}, filepath.Join(t.TempDir(), "journal"), false, nil)
+	if _, err = runner.Run(context.Background(), wf); err == nil {
+		t.Error("Run() of a step needing approval without Approve succeeded, want an error")
+	}
+}